@@ -2,6 +2,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/STaninnat/ecom-backend/internal/config"
@@ -20,8 +22,15 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// RefreshTokenData holds information about a refresh token and its associated provider.
+// RefreshTokenData holds a refresh session: the current refresh token, its provider, and the device it was issued to.
 type RefreshTokenData struct {
-	Token    string `json:"token"`
-	Provider string `json:"provider"`
+	Token      string    `json:"token"`
+	Provider   string    `json:"provider"`
+	SessionID  string    `json:"session_id,omitempty"`
+	UserID     string    `json:"user_id,omitempty"`
+	Device     string    `json:"device,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitzero"`
+	LastUsedAt time.Time `json:"last_used_at,omitzero"`
 }
@@ -38,12 +38,14 @@ func IsValidEmailFormat(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// ValidateAccessToken validates a JWT access token string using the provided secret and returns the claims if valid.
+// ValidateAccessToken validates a JWT access token string and returns the claims if valid.
+// With a key set configured, the token is verified with the key named by its kid header and the secret is ignored;
+// otherwise it must be HS256 signed with the provided secret.
 func (cfg *Config) ValidateAccessToken(tokenString string, secret string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(_ *jwt.Token) (any, error) {
-		return []byte(secret), nil
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		return cfg.accessTokenKey(token, secret)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not parse token: %w", err)
@@ -72,11 +74,31 @@ func (cfg *Config) ValidateAccessToken(tokenString string, secret string) (*Clai
 	return claims, nil
 }
 
+// accessTokenKey returns the key to verify an access token with, refusing tokens whose algorithm does not match the key.
+func (cfg *Config) accessTokenKey(token *jwt.Token, secret string) (any, error) {
+	if cfg.APIConfig == nil || cfg.JWTKeys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+		}
+		return []byte(secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := cfg.JWTKeys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return key.Public, nil
+}
+
 // ValidateRefreshToken validates the format and signature of a refresh token and returns the associated user UUID.
 func (cfg *Config) ValidateRefreshToken(refreshToken string) (uuid.UUID, error) {
 	parts := strings.Split(refreshToken, ":")
 	if len(parts) != 3 {
-		userID, err := cfg.GetUserIDFromRefreshToken(refreshToken)
+		userID, err := cfg.userIDFromGoogleRefreshToken(refreshToken)
 		if err != nil {
 			return uuid.Nil, fmt.Errorf("invalid refresh token format")
 		}
@@ -114,51 +136,27 @@ func (cfg *Config) ValidateCookieRefreshTokenData(_ http.ResponseWriter, r *http
 		return uuid.Nil, nil, err
 	}
 
-	storedTokenJSON, err := cfg.RedisClient.Get(r.Context(), "refresh_token:"+userID.String()).Result()
-	if err != nil {
-		return uuid.Nil, nil, err
-	}
-
-	storedData, err := ParseRefreshTokenData(storedTokenJSON)
+	storedData, err := cfg.sessionByToken(r.Context(), refreshToken)
 	if err != nil {
 		return uuid.Nil, nil, err
 	}
 
-	if storedData.Token != refreshToken {
+	if storedData.Token != refreshToken || storedData.UserID != userID.String() {
 		return uuid.Nil, nil, errors.New("invalid session")
 	}
 
 	return userID, &storedData, nil
 }
 
-// GetUserIDFromRefreshToken uses Redis KEYS, which is slow for large datasets. Avoid in hot paths.
-// GetUserIDFromRefreshToken retrieves the user UUID associated with a given refresh token by scanning Redis keys. Avoid in hot paths.
-func (cfg *Config) GetUserIDFromRefreshToken(refreshToken string) (uuid.UUID, error) {
-	keys, err := cfg.RedisClient.Keys(context.Background(), "refresh_token:*").Result()
+// userIDFromGoogleRefreshToken returns the user UUID of the Google session holding the given refresh token.
+// Google refresh tokens are opaque, so the user can only be found through the session.
+func (cfg *Config) userIDFromGoogleRefreshToken(refreshToken string) (uuid.UUID, error) {
+	storedData, err := cfg.sessionByToken(context.Background(), refreshToken)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error fetching keys from Redis: %w", err)
+		return uuid.Nil, err
 	}
-
-	for _, key := range keys {
-		storedTokenJSON, err := cfg.RedisClient.Get(context.Background(), key).Result()
-		if err != nil {
-			continue
-		}
-
-		storedData, err := ParseRefreshTokenData(storedTokenJSON)
-		if err != nil {
-			continue
-		}
-
-		if storedData.Token == refreshToken && storedData.Provider == "google" {
-			userIDStr := strings.TrimPrefix(key, "refresh_token:")
-			userID, err := uuid.Parse(userIDStr)
-			if err != nil {
-				return uuid.Nil, fmt.Errorf("invalid user ID format in Redis key")
-			}
-			return userID, nil
-		}
+	if storedData.Provider != "google" {
+		return uuid.Nil, errors.New("refresh token not found in Redis")
 	}
-
-	return uuid.Nil, fmt.Errorf("refresh token not found in Redis")
+	return uuid.Parse(storedData.UserID)
 }
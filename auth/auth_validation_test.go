@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/STaninnat/ecom-backend/internal/config"
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -133,12 +135,31 @@ func TestValidateRefreshToken(t *testing.T) {
 		}
 	})
 	t.Run("invalid format", func(t *testing.T) {
-		mock.ExpectKeys("refresh_token:*").SetVal([]string{})
+		mock.ExpectGet("refresh_token_lookup:notavalidtoken").RedisNil()
+		mock.ExpectGet("refresh_token_rotated:notavalidtoken").RedisNil()
 		_, err := cfg.ValidateRefreshToken("notavalidtoken")
 		if err == nil {
 			t.Error("expected error for invalid format")
 		}
 	})
+	t.Run("google session", func(t *testing.T) {
+		stored, _ := json.Marshal(RefreshTokenData{Token: "googletoken", Provider: "google", SessionID: "sess1", UserID: userID})
+		mock.ExpectGet("refresh_token_lookup:googletoken").SetVal("sess1")
+		mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
+		got, err := cfg.ValidateRefreshToken("googletoken")
+		if err != nil || got.String() != userID {
+			t.Errorf("expected %s, got %v, err %v", userID, got, err)
+		}
+	})
+	t.Run("local session without signature", func(t *testing.T) {
+		stored, _ := json.Marshal(RefreshTokenData{Token: "localtoken", Provider: "local", SessionID: "sess1", UserID: userID})
+		mock.ExpectGet("refresh_token_lookup:localtoken").SetVal("sess1")
+		mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
+		_, err := cfg.ValidateRefreshToken("localtoken")
+		if err == nil {
+			t.Error("expected error for unsigned local token")
+		}
+	})
 	t.Run("signature mismatch", func(t *testing.T) {
 		parts := strings.Split(refreshToken, ":")
 		badToken := parts[0] + ":" + parts[1] + ":badsignature"
@@ -197,7 +218,8 @@ func TestValidateCookieRefreshTokenData_ErrorsAndHappyPath(t *testing.T) {
 	// Error from ValidateRefreshToken
 	r2, _ := http.NewRequest("GET", "/", nil)
 	r2.AddCookie(&http.Cookie{Name: "refresh_token", Value: "badtoken"})
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{})
+	mock.ExpectGet("refresh_token_lookup:badtoken").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:badtoken").RedisNil()
 	_, _, err = cfg.ValidateCookieRefreshTokenData(w, r2)
 	if err == nil {
 		t.Error("expected error from ValidateRefreshToken")
@@ -208,7 +230,7 @@ func TestValidateCookieRefreshTokenData_ErrorsAndHappyPath(t *testing.T) {
 	refreshToken, _ := cfg.GenerateRefreshToken(userID)
 	r3, _ := http.NewRequest("GET", "/", nil)
 	r3.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
-	mock.ExpectGet("refresh_token:" + userID).SetErr(fmt.Errorf("redis get error"))
+	mock.ExpectGet("refresh_token_lookup:" + refreshToken).SetErr(fmt.Errorf("redis get error"))
 	_, _, err = cfg.ValidateCookieRefreshTokenData(w, r3)
 	if err == nil || !strings.Contains(err.Error(), "redis get error") {
 		t.Error("expected redis get error")
@@ -217,7 +239,8 @@ func TestValidateCookieRefreshTokenData_ErrorsAndHappyPath(t *testing.T) {
 	// ParseRefreshTokenData error
 	r4, _ := http.NewRequest("GET", "/", nil)
 	r4.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
-	mock.ExpectGet("refresh_token:" + userID).SetVal("notjson")
+	mock.ExpectGet("refresh_token_lookup:" + refreshToken).SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal("notjson")
 	_, _, err = cfg.ValidateCookieRefreshTokenData(w, r4)
 	if err == nil {
 		t.Error("expected parse error")
@@ -226,20 +249,33 @@ func TestValidateCookieRefreshTokenData_ErrorsAndHappyPath(t *testing.T) {
 	// Invalid session (storedData.Token != refreshToken)
 	r5, _ := http.NewRequest("GET", "/", nil)
 	r5.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
-	stored, _ := json.Marshal(RefreshTokenData{Token: "othertoken", Provider: "local"})
-	mock.ExpectGet("refresh_token:" + userID).SetVal(string(stored))
+	stored, _ := json.Marshal(RefreshTokenData{Token: "othertoken", Provider: "local", SessionID: "sess1", UserID: userID})
+	mock.ExpectGet("refresh_token_lookup:" + refreshToken).SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
 	_, _, err = cfg.ValidateCookieRefreshTokenData(w, r5)
 	if err == nil || !strings.Contains(err.Error(), "invalid session") {
 		t.Error("expected invalid session error")
 	}
 
+	// Session of another user
+	r7, _ := http.NewRequest("GET", "/", nil)
+	r7.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	stored, _ = json.Marshal(RefreshTokenData{Token: refreshToken, Provider: "local", SessionID: "sess1", UserID: utils.NewUUIDString()})
+	mock.ExpectGet("refresh_token_lookup:" + refreshToken).SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
+	_, _, err = cfg.ValidateCookieRefreshTokenData(w, r7)
+	if err == nil || !strings.Contains(err.Error(), "invalid session") {
+		t.Error("expected invalid session error for another user's session")
+	}
+
 	// Happy path
 	r6, _ := http.NewRequest("GET", "/", nil)
 	r6.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
-	stored, _ = json.Marshal(RefreshTokenData{Token: refreshToken, Provider: "local"})
-	mock.ExpectGet("refresh_token:" + userID).SetVal(string(stored))
+	stored, _ = json.Marshal(RefreshTokenData{Token: refreshToken, Provider: "local", SessionID: "sess1", UserID: userID})
+	mock.ExpectGet("refresh_token_lookup:" + refreshToken).SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
 	uid, data, err := cfg.ValidateCookieRefreshTokenData(w, r6)
-	if err != nil || uid.String() != userID || data.Token != refreshToken {
+	if err != nil || uid.String() != userID || data.Token != refreshToken || data.SessionID != "sess1" {
 		t.Errorf("expected happy path, got uid=%v, data=%v, err=%v", uid, data, err)
 	}
 }
@@ -251,58 +287,102 @@ func (d *dummyResponseWriter) Header() http.Header       { return http.Header{}
 func (d *dummyResponseWriter) Write([]byte) (int, error) { return 0, nil }
 func (d *dummyResponseWriter) WriteHeader(_ int)         {}
 
-// TestGetUserIDFromRefreshToken_ErrorsAndHappyPath tests mapping refresh tokens back to user IDs via Redis.
-func TestGetUserIDFromRefreshToken_ErrorsAndHappyPath(t *testing.T) {
-	db, mock := redismock.NewClientMock()
-	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
-
-	// Redis Keys error
-	mock.ExpectKeys("refresh_token:*").SetErr(fmt.Errorf("keys error"))
-	_, err := cfg.GetUserIDFromRefreshToken("token")
-	if err == nil || !strings.Contains(err.Error(), "keys error") {
-		t.Error("expected keys error")
+// newTestKeySet returns a key set signing with a new key of the algorithm, with a retired key "old" kept for verification.
+func newTestKeySet(t *testing.T, alg string) (*jwtkeys.KeySet, crypto.Signer) {
+	t.Helper()
+	current, err := jwtkeys.Generate(alg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
 	}
-
-	// Redis Get error
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{"refresh_token:uid1"})
-	mock.ExpectGet("refresh_token:uid1").SetErr(fmt.Errorf("get error"))
-	_, err = cfg.GetUserIDFromRefreshToken("token")
-	if err == nil || !strings.Contains(err.Error(), "refresh token not found") {
-		t.Error("expected not found error due to get error")
+	old, err := jwtkeys.Generate(jwtkeys.AlgEdDSA)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	set, err := jwtkeys.NewKeySet("current",
+		&jwtkeys.Key{ID: "current", Algorithm: alg, Private: current, Public: current.Public()},
+		&jwtkeys.Key{ID: "old", Algorithm: jwtkeys.AlgEdDSA, Public: old.Public()},
+	)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
 	}
+	return set, old
+}
 
-	// ParseRefreshTokenData error
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{"refresh_token:uid1"})
-	mock.ExpectGet("refresh_token:uid1").SetVal("notjson")
-	_, err = cfg.GetUserIDFromRefreshToken("token")
-	if err == nil || !strings.Contains(err.Error(), "refresh token not found") {
-		t.Error("expected not found error due to parse error")
+// TestAccessToken_KeySet tests signing with the current key and verifying by kid, including keys retired by a rotation.
+func TestAccessToken_KeySet(t *testing.T) {
+	for _, alg := range []string{jwtkeys.AlgRS256, jwtkeys.AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			keys, oldKey := newTestKeySet(t, alg)
+			cfg := &Config{APIConfig: &config.APIConfig{JWTKeys: keys, Issuer: "issuer", Audience: "aud"}}
+
+			tok, err := cfg.GenerateAccessToken("user1", time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(tok, &Claims{})
+			if err != nil || parsed.Header["kid"] != "current" || parsed.Method.Alg() != alg {
+				t.Fatalf("unexpected header %v (err %v)", parsed.Header, err)
+			}
+			claims, err := cfg.ValidateAccessToken(tok, "")
+			if err != nil || claims.UserID != "user1" {
+				t.Fatalf("expected valid token, got %v", err)
+			}
+
+			// Tokens signed before the rotation still verify with the retired key
+			oldTok := signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey)
+			if _, err := cfg.ValidateAccessToken(oldTok, ""); err != nil {
+				t.Errorf("expected token of retired key to verify, got %v", err)
+			}
+		})
 	}
+}
 
-	// Invalid user ID format in Redis key
-	stored, _ := json.Marshal(RefreshTokenData{Token: "token", Provider: "google"})
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{"refresh_token:baduid"})
-	mock.ExpectGet("refresh_token:baduid").SetVal(string(stored))
-	_, err = cfg.GetUserIDFromRefreshToken("token")
-	if err == nil || !strings.Contains(err.Error(), "invalid user ID format") {
-		t.Error("expected invalid user ID format error")
+// TestAccessToken_KeySet_Rejects tests that unknown kids, mismatched algorithms and HS256 tokens are refused once keys are configured.
+func TestAccessToken_KeySet_Rejects(t *testing.T) {
+	keys, oldKey := newTestKeySet(t, jwtkeys.AlgEdDSA)
+	cfg := &Config{APIConfig: &config.APIConfig{JWTKeys: keys, JWTSecret: testJWTSecret, Issuer: "issuer", Audience: "aud"}}
+
+	cases := map[string]string{
+		"unknown_kid": signTestToken(t, jwt.SigningMethodEdDSA, "missing", oldKey),
+		"no_kid":      signTestToken(t, jwt.SigningMethodEdDSA, "", oldKey),
+		"hs256":       signTestToken(t, jwt.SigningMethodHS256, "old", []byte(testJWTSecret)),
+	}
+	for name, tok := range cases {
+		if _, err := cfg.ValidateAccessToken(tok, testJWTSecret); err == nil {
+			t.Errorf("%s: expected token to be rejected", name)
+		}
 	}
 
-	// Not found (no match)
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{"refresh_token:uid1"})
-	mock.ExpectGet("refresh_token:uid1").SetVal(string(stored))
-	_, err = cfg.GetUserIDFromRefreshToken("notfound")
-	if err == nil || !strings.Contains(err.Error(), "refresh token not found") {
-		t.Error("expected not found error")
+	// Without keys, only HS256 is accepted
+	cfg.JWTKeys = nil
+	if _, err := cfg.ValidateAccessToken(cases["unknown_kid"], testJWTSecret); err == nil {
+		t.Error("expected EdDSA token to be rejected without a key set")
 	}
+	if _, err := cfg.ValidateAccessToken(cases["hs256"], testJWTSecret); err != nil {
+		t.Errorf("expected HS256 token to verify with the secret, got %v", err)
+	}
+}
 
-	// Happy path (provider == "google")
-	uid := utils.NewUUIDString()
-	stored, _ = json.Marshal(RefreshTokenData{Token: "token", Provider: "google"})
-	mock.ExpectKeys("refresh_token:*").SetVal([]string{"refresh_token:" + uid})
-	mock.ExpectGet("refresh_token:" + uid).SetVal(string(stored))
-	got, err := cfg.GetUserIDFromRefreshToken("token")
-	if err != nil || got.String() != uid {
-		t.Errorf("expected happy path, got %v, err %v", got, err)
+// signTestToken signs valid claims for user1 with the given method, kid and key.
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(method, Claims{
+		UserID: "user1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "issuer",
+			Audience:  []string{"aud"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
 	}
+	return s
 }
@@ -0,0 +1,355 @@
+// Package auth provides authentication, token management, validation, and session utilities for the ecom-backend project.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// refresh_sessions.go: Per-device refresh sessions in Redis, so that signing in on one device does not sign out another.
+// Each session is a rotation family: every refresh replaces its token, and replaying a replaced token after a short
+// grace period revokes the session.
+
+const (
+	// RedisRefreshSessionPrefix is the prefix for refresh session keys: refresh_session:<sessionID> -> RefreshTokenData.
+	RedisRefreshSessionPrefix = "refresh_session:"
+	// RedisUserSessionsPrefix is the prefix for the set of a user's session IDs: user_sessions:<userID>.
+	RedisUserSessionsPrefix = "user_sessions:"
+	// RedisRefreshTokenLookupPrefix is the prefix for refresh token lookups: refresh_token_lookup:<token> -> session ID.
+	RedisRefreshTokenLookupPrefix = "refresh_token_lookup:"
+	// RedisRotatedRefreshTokenPrefix is the prefix for replaced refresh tokens: refresh_token_rotated:<token> -> the session as it was.
+	RedisRotatedRefreshTokenPrefix = "refresh_token_rotated:"
+)
+
+var (
+	// ErrSessionNotFound is returned when a refresh session has expired, was revoked, or belongs to another user.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshTokenReuseError reports a replayed refresh token. By the time it is returned, the session it belonged to has been revoked.
+type RefreshTokenReuseError struct {
+	// Session is the session as it was when the replayed token was current.
+	Session RefreshTokenData
+}
+
+func (e *RefreshTokenReuseError) Error() string {
+	return fmt.Sprintf("%s for session %s", ErrRefreshTokenReused, e.Session.SessionID)
+}
+
+// Unwrap lets errors.Is match ErrRefreshTokenReused.
+func (e *RefreshTokenReuseError) Unwrap() error {
+	return ErrRefreshTokenReused
+}
+
+// newSessionID generates session IDs. It can be overridden for testing.
+var newSessionID = utils.NewUUIDString
+
+// RefreshTokenReuseGrace is how long a rotated refresh token still gets its successor instead of counting as reuse,
+// so that concurrent refreshes from one client, such as several open tabs, do not revoke its session.
+const RefreshTokenReuseGrace = 30 * time.Second
+
+// rotatedRefreshToken is what is remembered about a replaced refresh token: the session as it was, and what replaced it.
+type rotatedRefreshToken struct {
+	RefreshTokenData
+	ReplacedBy string    `json:"replaced_by,omitempty"`
+	RotatedAt  time.Time `json:"rotated_at,omitzero"`
+}
+
+// rotateRefreshTokenScript swaps a session's refresh token in one step, so only one of several concurrent refreshes
+// with the same token can replace it. The others see the rotated record it wrote.
+// KEYS: old token lookup, old token rotated record, session, new token lookup.
+// ARGV: session ID, rotated record, session, TTL in milliseconds.
+// Returns 1 if the token was rotated, 0 if the old token no longer belongs to the session.
+var rotateRefreshTokenScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[4])
+redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[4])
+redis.call('SET', KEYS[4], ARGV[1], 'PX', ARGV[4])
+return 1
+`)
+
+// RotateRefreshToken replaces the refresh token of the session that oldToken belongs to, records the use,
+// and returns the refresh token the client should keep. The session keeps its ID, so it stays one entry in the
+// user's session list. The old token is remembered for ttl, so presenting it again is detected as reuse. If a
+// concurrent refresh rotated oldToken less than RefreshTokenReuseGrace ago, that refresh's token is returned instead.
+func (cfg *Config) RotateRefreshToken(r *http.Request, oldToken, newToken string, ttl time.Duration) (string, error) {
+	if newToken == "" {
+		return "", errors.New("refresh token cannot be empty")
+	}
+	ctx := r.Context()
+
+	data, err := cfg.sessionByToken(ctx, oldToken)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	rotated, err := json.Marshal(rotatedRefreshToken{RefreshTokenData: data, ReplacedBy: newToken, RotatedAt: now})
+	if err != nil {
+		return "", err
+	}
+	data.Token = newToken
+	data.LastUsedAt = now
+	setSessionClient(&data, r)
+
+	if oldToken == newToken {
+		return newToken, cfg.saveRefreshSession(ctx, data, ttl)
+	}
+
+	session, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	keys := []string{
+		RedisRefreshTokenLookupPrefix + oldToken,
+		RedisRotatedRefreshTokenPrefix + oldToken,
+		RedisRefreshSessionPrefix + data.SessionID,
+		RedisRefreshTokenLookupPrefix + newToken,
+	}
+	swapped, err := rotateRefreshTokenScript.Run(ctx, cfg.RedisClient, keys, data.SessionID, rotated, session, ttl.Milliseconds()).Int()
+	if err != nil {
+		return "", err
+	}
+	if swapped == 0 {
+		// Rotated by a concurrent refresh, or revoked, since it was looked up
+		previous, err := cfg.checkRefreshTokenReuse(ctx, oldToken)
+		if err != nil {
+			return "", err
+		}
+		return previous.ReplacedBy, nil
+	}
+
+	if err := cfg.RedisClient.SAdd(ctx, RedisUserSessionsPrefix+data.UserID, data.SessionID).Err(); err != nil {
+		return "", err
+	}
+	return newToken, cfg.RedisClient.Expire(ctx, RedisUserSessionsPrefix+data.UserID, ttl).Err()
+}
+
+// ListRefreshSessions returns the user's active sessions, most recently used first.
+// Expired sessions still listed in the user's set are removed from it.
+func (cfg *Config) ListRefreshSessions(ctx context.Context, userID string) ([]RefreshTokenData, error) {
+	ids, err := cfg.RedisClient.SMembers(ctx, RedisUserSessionsPrefix+userID).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]RefreshTokenData, 0, len(ids))
+	for _, id := range ids {
+		data, err := cfg.getRefreshSession(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			_ = cfg.RedisClient.SRem(ctx, RedisUserSessionsPrefix+userID, id).Err()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, data)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeRefreshSession deletes one of the user's sessions and its refresh token.
+// Returns ErrSessionNotFound if the session does not exist or belongs to another user.
+func (cfg *Config) RevokeRefreshSession(ctx context.Context, userID, sessionID string) error {
+	data, err := cfg.getRefreshSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if data.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return cfg.deleteRefreshSession(ctx, userID, sessionID, data.Token)
+}
+
+// RevokeRefreshSessions deletes every session of the user except keepSessionID; an empty keepSessionID deletes them all.
+// Returns the number of active sessions revoked.
+func (cfg *Config) RevokeRefreshSessions(ctx context.Context, userID, keepSessionID string) (int, error) {
+	ids, err := cfg.RedisClient.SMembers(ctx, RedisUserSessionsPrefix+userID).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, id := range ids {
+		if id == keepSessionID {
+			continue
+		}
+		data, err := cfg.getRefreshSession(ctx, id)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return revoked, err
+		}
+		if err := cfg.deleteRefreshSession(ctx, userID, id, data.Token); err != nil {
+			return revoked, err
+		}
+		if data.Token != "" {
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// saveRefreshSession writes the session, its token lookup, and its entry in the user's session set.
+// Every session uses the same TTL, so extending the set to the newest session's expiry keeps it alive for all of them.
+func (cfg *Config) saveRefreshSession(ctx context.Context, data RefreshTokenData, ttl time.Duration) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.RedisClient.Set(ctx, RedisRefreshSessionPrefix+data.SessionID, jsonData, ttl).Err(); err != nil {
+		return err
+	}
+	if err := cfg.RedisClient.Set(ctx, RedisRefreshTokenLookupPrefix+data.Token, data.SessionID, ttl).Err(); err != nil {
+		return err
+	}
+	if err := cfg.RedisClient.SAdd(ctx, RedisUserSessionsPrefix+data.UserID, data.SessionID).Err(); err != nil {
+		return err
+	}
+	return cfg.RedisClient.Expire(ctx, RedisUserSessionsPrefix+data.UserID, ttl).Err()
+}
+
+// deleteRefreshSession removes a session, its token lookup, and its entry in the user's session set.
+func (cfg *Config) deleteRefreshSession(ctx context.Context, userID, sessionID, token string) error {
+	keys := []string{RedisRefreshSessionPrefix + sessionID}
+	if token != "" {
+		keys = append(keys, RedisRefreshTokenLookupPrefix+token)
+	}
+	if err := cfg.RedisClient.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	return cfg.RedisClient.SRem(ctx, RedisUserSessionsPrefix+userID, sessionID).Err()
+}
+
+// sessionByToken returns the session that currently holds the refresh token. A token rotated less than
+// RefreshTokenReuseGrace ago returns the session as it was when the token was current; a token rotated out
+// earlier revokes its session and returns a *RefreshTokenReuseError.
+func (cfg *Config) sessionByToken(ctx context.Context, refreshToken string) (RefreshTokenData, error) {
+	sessionID, err := cfg.RedisClient.Get(ctx, RedisRefreshTokenLookupPrefix+refreshToken).Result()
+	if errors.Is(err, redis.Nil) {
+		rotated, err := cfg.checkRefreshTokenReuse(ctx, refreshToken)
+		return rotated.RefreshTokenData, err
+	}
+	if err != nil {
+		return RefreshTokenData{}, err
+	}
+	return cfg.getRefreshSession(ctx, sessionID)
+}
+
+// checkRefreshTokenReuse looks up a token that holds no session. Within RefreshTokenReuseGrace of its rotation,
+// while its successor is still current, it is a concurrent refresh from the same client and its rotated record is
+// returned. Otherwise either the client or someone who stole the token is replaying it; there is no telling which,
+// so the whole session is revoked. Returns ErrSessionNotFound for tokens that were never issued or have expired.
+func (cfg *Config) checkRefreshTokenReuse(ctx context.Context, refreshToken string) (rotatedRefreshToken, error) {
+	stored, err := cfg.RedisClient.Get(ctx, RedisRotatedRefreshTokenPrefix+refreshToken).Result()
+	if errors.Is(err, redis.Nil) {
+		return rotatedRefreshToken{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return rotatedRefreshToken{}, err
+	}
+	var rotated rotatedRefreshToken
+	if err := json.Unmarshal([]byte(stored), &rotated); err != nil {
+		return rotatedRefreshToken{}, err
+	}
+	if rotated.Token == "" || rotated.Provider == "" {
+		return rotatedRefreshToken{}, errors.New("token and provider fields are required")
+	}
+
+	current, err := cfg.getRefreshSession(ctx, rotated.SessionID)
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		// Already revoked or expired
+	case err != nil:
+		return rotatedRefreshToken{}, err
+	case rotated.ReplacedBy != "" && current.Token == rotated.ReplacedBy && time.Since(rotated.RotatedAt) <= RefreshTokenReuseGrace:
+		return rotated, nil
+	default:
+		if err := cfg.deleteRefreshSession(ctx, current.UserID, current.SessionID, current.Token); err != nil {
+			return rotatedRefreshToken{}, fmt.Errorf("revoking session of reused refresh token: %w", err)
+		}
+	}
+	return rotatedRefreshToken{}, &RefreshTokenReuseError{Session: rotated.RefreshTokenData}
+}
+
+// getRefreshSession reads a session by ID.
+func (cfg *Config) getRefreshSession(ctx context.Context, sessionID string) (RefreshTokenData, error) {
+	stored, err := cfg.RedisClient.Get(ctx, RedisRefreshSessionPrefix+sessionID).Result()
+	if errors.Is(err, redis.Nil) {
+		return RefreshTokenData{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return RefreshTokenData{}, err
+	}
+	return ParseRefreshTokenData(stored)
+}
+
+// setSessionClient records the client address and device of the request on the session.
+func setSessionClient(data *RefreshTokenData, r *http.Request) {
+	if r == nil {
+		return
+	}
+	if ip := middlewares.GetIPAddress(r); ip != "" {
+		data.IP = ip
+	}
+	if ua := r.UserAgent(); ua != "" {
+		data.UserAgent = ua
+		data.Device = DeviceFromUserAgent(ua)
+	}
+}
+
+// userAgentBrowsers and userAgentPlatforms map user agent markers to names, checked in order:
+// Edge and Opera also claim to be Chrome, Chrome claims to be Safari, iOS claims to be macOS, and Android claims to be Linux.
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	}
+	userAgentPlatforms = [][2]string{
+		{"Windows", "Windows"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	}
+)
+
+// DeviceFromUserAgent returns a short device label such as "Chrome on Windows" for showing in a session list.
+func DeviceFromUserAgent(userAgent string) string {
+	browser := firstUserAgentMatch(userAgent, userAgentBrowsers)
+	platform := firstUserAgentMatch(userAgent, userAgentPlatforms)
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+// firstUserAgentMatch returns the name of the first marker found in the user agent.
+func firstUserAgentMatch(userAgent string, markers [][2]string) string {
+	for _, m := range markers {
+		if strings.Contains(userAgent, m[0]) {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,348 @@
+// Package auth provides authentication, token management, validation, and session utilities for the ecom-backend project.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	redismock "github.com/go-redis/redismock/v9"
+
+	"github.com/STaninnat/ecom-backend/internal/config"
+)
+
+// refresh_sessions_test.go: Tests for per-device refresh sessions: rotation, listing, revocation, and device labels.
+
+// withSessionID makes new sessions use the given ID for the duration of the test.
+func withSessionID(t *testing.T, id string) {
+	t.Helper()
+	orig := newSessionID
+	newSessionID = func() string { return id }
+	t.Cleanup(func() { newSessionID = orig })
+}
+
+// expectSessionSet expects the session JSON to be written under the session ID and runs check on the decoded session.
+func expectSessionSet(mock redismock.ClientMock, sessionID string, ttl time.Duration, check func(RefreshTokenData) error) *redismock.ExpectedStatus {
+	key := RedisRefreshSessionPrefix + sessionID
+	return mock.CustomMatch(func(_, actual []any) error {
+		if actual[1] != key {
+			return fmt.Errorf("expected key %s, got %v", key, actual[1])
+		}
+		raw, ok := actual[2].([]byte)
+		if !ok {
+			return fmt.Errorf("expected JSON bytes, got %T", actual[2])
+		}
+		var data RefreshTokenData
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return err
+		}
+		if check == nil {
+			return nil
+		}
+		return check(data)
+	}).ExpectSet(key, nil, ttl)
+}
+
+// sessionJSON returns the stored form of a session.
+func sessionJSON(data RefreshTokenData) string {
+	b, _ := json.Marshal(data)
+	return string(b)
+}
+
+// expectRotation expects the rotation script to swap oldToken for newToken in the session and runs check on the
+// rotated record and the session it writes.
+func expectRotation(mock redismock.ClientMock, oldToken, newToken, sessionID string, ttl time.Duration, check func(rotatedRefreshToken, RefreshTokenData) error) *redismock.ExpectedCmd {
+	keys := []string{
+		RedisRefreshTokenLookupPrefix + oldToken,
+		RedisRotatedRefreshTokenPrefix + oldToken,
+		RedisRefreshSessionPrefix + sessionID,
+		RedisRefreshTokenLookupPrefix + newToken,
+	}
+	return mock.CustomMatch(func(_, actual []any) error {
+		if len(actual) != 11 {
+			return fmt.Errorf("expected 11 arguments, got %v", actual)
+		}
+		for i, key := range keys {
+			if actual[3+i] != key {
+				return fmt.Errorf("expected key %s, got %v", key, actual[3+i])
+			}
+		}
+		if actual[7] != sessionID || actual[10] != ttl.Milliseconds() {
+			return fmt.Errorf("unexpected arguments %v", actual[7:])
+		}
+		var rotated rotatedRefreshToken
+		var data RefreshTokenData
+		if err := json.Unmarshal(actual[8].([]byte), &rotated); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(actual[9].([]byte), &data); err != nil {
+			return err
+		}
+		return check(rotated, data)
+	}).ExpectEvalSha(rotateRefreshTokenScript.Hash(), keys, sessionID, nil, nil, ttl.Milliseconds())
+}
+
+// TestRotateRefreshToken tests that rotation keeps the session ID, swaps the token lookup, and records the use.
+func TestRotateRefreshToken(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	r, _ := http.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "198.51.100.4:5555"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Version/17.0 Mobile/15E148 Safari/604.1")
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := RefreshTokenData{Token: "old", Provider: "local", SessionID: "sess1", UserID: "user1", Device: "Chrome on Windows", CreatedAt: created, LastUsedAt: created}
+	mock.ExpectGet("refresh_token_lookup:old").SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(old))
+	expectRotation(mock, "old", "new", "sess1", time.Hour, func(rotated rotatedRefreshToken, data RefreshTokenData) error {
+		if rotated.Token != "old" || rotated.Device != "Chrome on Windows" || rotated.ReplacedBy != "new" || rotated.RotatedAt.IsZero() {
+			return fmt.Errorf("unexpected rotated record %+v", rotated)
+		}
+		if data.Token != "new" || data.UserID != "user1" || !data.CreatedAt.Equal(created) {
+			return fmt.Errorf("unexpected session %+v", data)
+		}
+		if !data.LastUsedAt.After(created) || data.IP != "198.51.100.4" || data.Device != "Safari on iOS" {
+			return fmt.Errorf("use not recorded: %+v", data)
+		}
+		return nil
+	}).SetVal(int64(1))
+	mock.ExpectSAdd("user_sessions:user1", "sess1").SetVal(0)
+	mock.ExpectExpire("user_sessions:user1", time.Hour).SetVal(true)
+
+	token, err := cfg.RotateRefreshToken(r, "old", "new", time.Hour)
+	if err != nil || token != "new" {
+		t.Fatalf("expected new token, got %q, %v", token, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Unknown token
+	mock.ExpectGet("refresh_token_lookup:gone").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:gone").RedisNil()
+	if _, err := cfg.RotateRefreshToken(r, "gone", "new", time.Hour); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	// Empty new token
+	if _, err := cfg.RotateRefreshToken(r, "old", "", time.Hour); err == nil {
+		t.Error("expected error for empty token")
+	}
+}
+
+// TestRotateRefreshToken_Concurrent tests that a refresh losing the rotation race to another refresh of the same
+// token gets the winner's token instead of revoking the session.
+func TestRotateRefreshToken_Concurrent(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	r, _ := http.NewRequest("POST", "/", nil)
+
+	old := RefreshTokenData{Token: "old", Provider: "local", SessionID: "sess1", UserID: "user1"}
+	current := RefreshTokenData{Token: "winner", Provider: "local", SessionID: "sess1", UserID: "user1"}
+	mock.ExpectGet("refresh_token_lookup:old").SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(old))
+	expectRotation(mock, "old", "new", "sess1", time.Hour, func(rotatedRefreshToken, RefreshTokenData) error { return nil }).SetVal(int64(0))
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(rotatedJSON(old, "winner", time.Now()))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+
+	token, err := cfg.RotateRefreshToken(r, "old", "new", time.Hour)
+	if err != nil || token != "winner" {
+		t.Fatalf("expected the winner's token, got %q, %v", token, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// rotatedJSON returns the stored form of a token replaced by replacedBy at rotatedAt.
+func rotatedJSON(data RefreshTokenData, replacedBy string, rotatedAt time.Time) string {
+	b, _ := json.Marshal(rotatedRefreshToken{RefreshTokenData: data, ReplacedBy: replacedBy, RotatedAt: rotatedAt})
+	return string(b)
+}
+
+// TestSessionByToken_Reuse tests that replaying a rotated token revokes the session and reports the reuse.
+func TestSessionByToken_Reuse(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	ctx := context.Background()
+
+	rotated := RefreshTokenData{Token: "old", Provider: "local", SessionID: "sess1", UserID: "user1"}
+	current := RefreshTokenData{Token: "new", Provider: "local", SessionID: "sess1", UserID: "user1"}
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(sessionJSON(rotated))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+	mock.ExpectDel("refresh_session:sess1", "refresh_token_lookup:new").SetVal(2)
+	mock.ExpectSRem("user_sessions:user1", "sess1").SetVal(1)
+
+	_, err := cfg.sessionByToken(ctx, "old")
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	var reuse *RefreshTokenReuseError
+	if !errors.As(err, &reuse) || reuse.Session.UserID != "user1" || reuse.Session.SessionID != "sess1" {
+		t.Errorf("expected reuse of user1's sess1, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Replaying again after the session is gone is still reported
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(sessionJSON(rotated))
+	mock.ExpectGet("refresh_session:sess1").RedisNil()
+	if _, err := cfg.sessionByToken(ctx, "old"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	// Within the grace period the token still resolves to its session, as it was
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(rotatedJSON(rotated, "new", time.Now()))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+	if data, err := cfg.sessionByToken(ctx, "old"); err != nil || data.Token != "old" || data.SessionID != "sess1" {
+		t.Errorf("expected the rotated session within the grace period, got %+v, %v", data, err)
+	}
+
+	// After the grace period, or once the successor was rotated too, it is reuse
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(rotatedJSON(rotated, "new", time.Now().Add(-RefreshTokenReuseGrace-time.Second)))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+	mock.ExpectDel("refresh_session:sess1", "refresh_token_lookup:new").SetVal(2)
+	mock.ExpectSRem("user_sessions:user1", "sess1").SetVal(1)
+	if _, err := cfg.sessionByToken(ctx, "old"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused after the grace period, got %v", err)
+	}
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(rotatedJSON(rotated, "newer", time.Now()))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+	mock.ExpectDel("refresh_session:sess1", "refresh_token_lookup:new").SetVal(2)
+	mock.ExpectSRem("user_sessions:user1", "sess1").SetVal(1)
+	if _, err := cfg.sessionByToken(ctx, "old"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused for a stale successor, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Revocation failure is returned instead of the reuse
+	mock.ExpectGet("refresh_token_lookup:old").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:old").SetVal(sessionJSON(rotated))
+	mock.ExpectGet("refresh_session:sess1").SetVal(sessionJSON(current))
+	mock.ExpectDel("refresh_session:sess1", "refresh_token_lookup:new").SetErr(errors.New("redis down"))
+	if _, err := cfg.sessionByToken(ctx, "old"); err == nil || errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected revocation error, got %v", err)
+	}
+}
+
+// TestListRefreshSessions tests ordering by last use and pruning of expired sessions.
+func TestListRefreshSessions(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	older := RefreshTokenData{Token: "t1", Provider: "local", SessionID: "s1", UserID: "user1", LastUsedAt: base}
+	newer := RefreshTokenData{Token: "t2", Provider: "google", SessionID: "s2", UserID: "user1", LastUsedAt: base.Add(time.Hour)}
+
+	mock.ExpectSMembers("user_sessions:user1").SetVal([]string{"s1", "expired", "s2"})
+	mock.ExpectGet("refresh_session:s1").SetVal(sessionJSON(older))
+	mock.ExpectGet("refresh_session:expired").RedisNil()
+	mock.ExpectSRem("user_sessions:user1", "expired").SetVal(1)
+	mock.ExpectGet("refresh_session:s2").SetVal(sessionJSON(newer))
+
+	sessions, err := cfg.ListRefreshSessions(ctx, "user1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].SessionID != "s2" || sessions[1].SessionID != "s1" {
+		t.Errorf("unexpected sessions %+v", sessions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Redis error
+	mock.ExpectSMembers("user_sessions:user1").SetErr(errors.New("redis down"))
+	if _, err := cfg.ListRefreshSessions(ctx, "user1"); err == nil {
+		t.Error("expected redis error")
+	}
+}
+
+// TestRevokeRefreshSession tests revoking a session and refusing sessions of other users.
+func TestRevokeRefreshSession(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	ctx := context.Background()
+
+	session := RefreshTokenData{Token: "t1", Provider: "local", SessionID: "s1", UserID: "user1"}
+	mock.ExpectGet("refresh_session:s1").SetVal(sessionJSON(session))
+	mock.ExpectDel("refresh_session:s1", "refresh_token_lookup:t1").SetVal(2)
+	mock.ExpectSRem("user_sessions:user1", "s1").SetVal(1)
+	if err := cfg.RevokeRefreshSession(ctx, "user1", "s1"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	mock.ExpectGet("refresh_session:s1").SetVal(sessionJSON(session))
+	if err := cfg.RevokeRefreshSession(ctx, "user2", "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound for another user's session, got %v", err)
+	}
+
+	mock.ExpectGet("refresh_session:missing").RedisNil()
+	if err := cfg.RevokeRefreshSession(ctx, "user1", "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRevokeRefreshSessions tests revoking all sessions except the kept one.
+func TestRevokeRefreshSessions(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
+	ctx := context.Background()
+
+	mock.ExpectSMembers("user_sessions:user1").SetVal([]string{"s1", "s2", "expired"})
+	mock.ExpectGet("refresh_session:s2").SetVal(sessionJSON(RefreshTokenData{Token: "t2", Provider: "local", SessionID: "s2", UserID: "user1"}))
+	mock.ExpectDel("refresh_session:s2", "refresh_token_lookup:t2").SetVal(2)
+	mock.ExpectSRem("user_sessions:user1", "s2").SetVal(1)
+	mock.ExpectGet("refresh_session:expired").RedisNil()
+	mock.ExpectDel("refresh_session:expired").SetVal(0)
+	mock.ExpectSRem("user_sessions:user1", "expired").SetVal(1)
+
+	revoked, err := cfg.RevokeRefreshSessions(ctx, "user1", "s1")
+	if err != nil || revoked != 1 {
+		t.Errorf("expected 1 revoked, got %d, err %v", revoked, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Redis error while reading a session
+	mock.ExpectSMembers("user_sessions:user1").SetVal([]string{"s1"})
+	mock.ExpectGet("refresh_session:s1").SetErr(errors.New("redis down"))
+	if _, err := cfg.RevokeRefreshSessions(ctx, "user1", ""); err == nil {
+		t.Error("expected redis error")
+	}
+}
+
+// TestDeviceFromUserAgent tests device labels for common browsers and platforms.
+func TestDeviceFromUserAgent(t *testing.T) {
+	tests := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36 Edg/126.0": "Edge on Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15":    "Safari on macOS",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36":     "Chrome on Android",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0":                                                "Firefox on Linux",
+		"Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/604.1":      "Safari on iPadOS",
+		"curl/8.5.0": "Unknown device",
+		"":           "Unknown device",
+	}
+	for ua, want := range tests {
+		if got := DeviceFromUserAgent(ua); got != want {
+			t.Errorf("DeviceFromUserAgent(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
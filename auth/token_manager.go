@@ -18,17 +18,16 @@ import (
 
 // token_manager.go: JWT access/refresh token generation, storage, and validation.
 
-// RedisRefreshTokenPrefix is the prefix used for refresh token keys in Redis.
-const RedisRefreshTokenPrefix = "refresh_token:"
-
 // GenerateAccessToken generates a signed JWT access token for the given user ID and expiration time.
 func (cfg *Config) GenerateAccessToken(userID string, expiresAt time.Time) (string, error) {
 	if cfg == nil {
 		return "", errors.New("cfg is nil")
 	}
 
-	if err := ValidateConfig(cfg.JWTSecret, "JWTSecret"); err != nil {
-		return "", err
+	if cfg.APIConfig == nil || cfg.JWTKeys == nil {
+		if err := ValidateConfig(cfg.JWTSecret, "JWTSecret"); err != nil {
+			return "", err
+		}
 	}
 
 	timeNow := time.Now().UTC()
@@ -47,8 +46,20 @@ func (cfg *Config) GenerateAccessToken(userID string, expiresAt time.Time) (stri
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
+	// Without a key set, tokens are signed with the shared secret
+	if cfg.JWTKeys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
+		if err != nil {
+			return "", fmt.Errorf("error signing JWT: %w", err)
+		}
+		return tokenString, nil
+	}
+
+	key := cfg.JWTKeys.SigningKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", fmt.Errorf("error signing JWT: %w", err)
 	}
@@ -94,7 +105,8 @@ func (cfg *Config) GenerateTokens(userID string, accessTokenExpiresAt time.Time)
 	return accessToken, newRefreshToken, nil
 }
 
-// StoreRefreshTokenInRedis stores the refresh token and its metadata in Redis for the given user ID and provider.
+// StoreRefreshTokenInRedis starts a new refresh session for the given user ID and provider.
+// Sessions on other devices are left alone.
 func (cfg *Config) StoreRefreshTokenInRedis(r *http.Request, userID, refreshToken, provider string, ttl time.Duration) error {
 	if cfg == nil {
 		return errors.New("Config is nil")
@@ -118,30 +130,18 @@ func (cfg *Config) StoreRefreshTokenInRedis(r *http.Request, userID, refreshToke
 		return errors.New("refresh token cannot be empty")
 	}
 
+	now := time.Now().UTC()
 	data := RefreshTokenData{
-		Token:    refreshToken,
-		Provider: provider,
+		Token:      refreshToken,
+		Provider:   provider,
+		SessionID:  newSessionID(),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastUsedAt: now,
 	}
+	setSessionClient(&data, r)
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	// Store refresh_token:<userID> -> token data (legacy)
-	err = cfg.RedisClient.Set(r.Context(), RedisRefreshTokenPrefix+userID, jsonData, ttl).Err()
-	if err != nil {
-		return err
-	}
-
-	// Store refresh_token_lookup:<token> -> userID for O(1) lookup
-	lookupKey := "refresh_token_lookup:" + refreshToken
-	err = cfg.RedisClient.Set(r.Context(), lookupKey, userID, ttl).Err()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return cfg.saveRefreshSession(r.Context(), data, ttl)
 }
 
 // ParseRefreshTokenData parses a JSON string into a RefreshTokenData struct and validates required fields.
@@ -173,14 +173,11 @@ func ValidateConfig(secret string, secretName string) error {
 	return nil
 }
 
-// WARNING: GetUserIDFromRefreshToken uses Redis KEYS, which is slow for large datasets. Avoid in hot paths.
-
-// GetUserIDByRefreshToken does an O(1) lookup for the user ID associated with a given refresh token in Redis.
+// GetUserIDByRefreshToken does an O(1) lookup for the user ID of the session holding the given refresh token.
 func (cfg *Config) GetUserIDByRefreshToken(ctx context.Context, refreshToken string) (string, error) {
-	lookupKey := "refresh_token_lookup:" + refreshToken
-	userID, err := cfg.RedisClient.Get(ctx, lookupKey).Result()
+	data, err := cfg.sessionByToken(ctx, refreshToken)
 	if err != nil {
 		return "", err
 	}
-	return userID, nil
+	return data.UserID, nil
 }
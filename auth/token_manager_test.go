@@ -108,19 +108,31 @@ func TestStoreRefreshTokenInRedis(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
 	r, _ := http.NewRequest("GET", "/", nil)
-
-	// Prepare expected JSON as []byte
-	tokenData := RefreshTokenData{Token: "token", Provider: "local"}
-	jsonData, _ := json.Marshal(tokenData)
+	r.RemoteAddr = "203.0.113.7:1234"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/126.0 Safari/537.36")
+	withSessionID(t, "sess1")
 
 	// Set up expected Redis calls for the valid case
-	mock.ExpectSet("refresh_token:user1", jsonData, time.Minute).SetVal("OK")
-	mock.ExpectSet("refresh_token_lookup:token", "user1", time.Minute).SetVal("OK")
+	expectSessionSet(mock, "sess1", time.Minute, func(data RefreshTokenData) error {
+		if data.Token != "token" || data.Provider != "local" || data.UserID != "user1" {
+			return fmt.Errorf("unexpected session %+v", data)
+		}
+		if data.IP != "203.0.113.7" || data.Device != "Chrome on Windows" || data.CreatedAt.IsZero() || !data.LastUsedAt.Equal(data.CreatedAt) {
+			return fmt.Errorf("unexpected session metadata %+v", data)
+		}
+		return nil
+	}).SetVal("OK")
+	mock.ExpectSet("refresh_token_lookup:token", "sess1", time.Minute).SetVal("OK")
+	mock.ExpectSAdd("user_sessions:user1", "sess1").SetVal(1)
+	mock.ExpectExpire("user_sessions:user1", time.Minute).SetVal(true)
 
 	err := cfg.StoreRefreshTokenInRedis(r, "user1", "token", "local", time.Minute)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
 
 	err = cfg.StoreRefreshTokenInRedis(r, "user1", "", "local", time.Minute)
 	if err == nil {
@@ -145,9 +157,7 @@ func TestStoreRefreshTokenInRedis(t *testing.T) {
 	// New: Redis Set error
 	db, mock = redismock.NewClientMock()
 	cfg = &Config{APIConfig: &config.APIConfig{RedisClient: db}}
-	tokenData = RefreshTokenData{Token: "token", Provider: "local"}
-	jsonData, _ = json.Marshal(tokenData)
-	mock.ExpectSet("refresh_token:user1", jsonData, time.Minute).SetErr(fmt.Errorf("redis set error"))
+	expectSessionSet(mock, "sess1", time.Minute, nil).SetErr(fmt.Errorf("redis set error"))
 	err = cfg.StoreRefreshTokenInRedis(r, "user1", "token", "local", time.Minute)
 	if err == nil || err.Error() != "redis set error" {
 		t.Error("expected redis set error")
@@ -159,11 +169,17 @@ func TestStoreRefreshTokenInRedis_GoogleProvider(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
 	r, _ := http.NewRequest("GET", "/", nil)
+	withSessionID(t, "sess1")
 
-	tokenData := RefreshTokenData{Token: "token", Provider: "google"}
-	jsonData, _ := json.Marshal(tokenData)
-	mock.ExpectSet("refresh_token:user1", jsonData, time.Minute).SetVal("OK")
-	mock.ExpectSet("refresh_token_lookup:token", "user1", time.Minute).SetVal("OK")
+	expectSessionSet(mock, "sess1", time.Minute, func(data RefreshTokenData) error {
+		if data.Provider != "google" {
+			return fmt.Errorf("expected google provider, got %q", data.Provider)
+		}
+		return nil
+	}).SetVal("OK")
+	mock.ExpectSet("refresh_token_lookup:token", "sess1", time.Minute).SetVal("OK")
+	mock.ExpectSAdd("user_sessions:user1", "sess1").SetVal(1)
+	mock.ExpectExpire("user_sessions:user1", time.Minute).SetVal(true)
 
 	err := cfg.StoreRefreshTokenInRedis(r, "user1", "token", "google", time.Minute)
 	if err != nil {
@@ -223,9 +239,12 @@ func TestGetUserIDByRefreshToken(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	cfg := &Config{APIConfig: &config.APIConfig{RedisClient: db}}
 
-	// Set up expected Redis call for the valid case
-	mock.ExpectGet("refresh_token_lookup:validtoken").SetVal("user1")
+	// Set up expected Redis calls for the valid case
+	stored, _ := json.Marshal(RefreshTokenData{Token: "validtoken", Provider: "local", SessionID: "sess1", UserID: "user1"})
+	mock.ExpectGet("refresh_token_lookup:validtoken").SetVal("sess1")
+	mock.ExpectGet("refresh_session:sess1").SetVal(string(stored))
 	mock.ExpectGet("refresh_token_lookup:invalidtoken").RedisNil()
+	mock.ExpectGet("refresh_token_rotated:invalidtoken").RedisNil()
 
 	userID, err := cfg.GetUserIDByRefreshToken(context.Background(), "validtoken")
 	if err != nil || userID != "user1" {
@@ -0,0 +1,79 @@
+// Package main deletes duplicate reviews so the one-review-per-product index can be built, then recomputes the
+// rating summaries of the products that had them. Each user keeps one review per product: a published review over a
+// pending one, and otherwise the most recently updated. Run it when startup fails to create the review indexes.
+//
+//	go run ./cmd/dedupe-reviews
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	reviewhandlers "github.com/STaninnat/ecom-backend/handlers/review"
+	"github.com/STaninnat/ecom-backend/internal/config"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	if err := godotenv.Load(".env.development"); err != nil {
+		log.Printf("Warning: assuming default configuration, env unreadable: %v", err)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	mongoURI := os.Getenv("MONGO_URI")
+	if dbURL == "" || mongoURI == "" {
+		log.Fatal("DATABASE_URL and MONGO_URI must be set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	postgres := config.NewPostgresProvider(dbURL)
+	dbConn, queries, err := postgres.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Postgres: %v", err)
+	}
+	defer func() { _ = postgres.Close() }()
+
+	mongoProvider := config.NewMongoProvider(mongoURI)
+	_, mongoDB, err := mongoProvider.Connect(ctx)
+	if err != nil {
+		log.Fatalf("MongoDB: %v", err)
+	}
+	defer func() { _ = mongoProvider.Close(context.Background()) }()
+
+	deleted, err := intmongo.DedupeReviews(ctx,
+		&intmongo.MongoCollectionAdapter{Inner: mongoDB.Collection("reviews")},
+		&intmongo.MongoCollectionAdapter{Inner: mongoDB.Collection("review_media")})
+	for _, review := range deleted {
+		log.Printf("Deleted review %s by user %s of product %s", review.ID, review.UserID, review.ProductID)
+	}
+	if err != nil {
+		log.Fatalf("Dedupe reviews: %v", err)
+	}
+	log.Printf("Deleted %d duplicate reviews", len(deleted))
+
+	// The deleted reviews may have been counted in their products' rating summaries
+	reviewMongo := intmongo.NewReviewMongo(mongoDB)
+	store := &reviewhandlers.ReviewDBAdapter{Queries: queries, DBConn: dbConn}
+	refreshed := map[string]bool{}
+	for _, review := range deleted {
+		if refreshed[review.ProductID] {
+			continue
+		}
+		err := store.RefreshProductRatingSummary(ctx, review.ProductID, func(ctx context.Context) (intmongo.ProductRatingSummary, error) {
+			return reviewMongo.GetProductRatingSummary(ctx, review.ProductID)
+		})
+		if err != nil {
+			log.Fatalf("Refresh rating summary of product %s: %v", review.ProductID, err)
+		}
+		refreshed[review.ProductID] = true
+	}
+	log.Printf("Refreshed rating summaries for %d products", len(refreshed))
+}
@@ -0,0 +1,113 @@
+// Package main manages the PEM keys that sign access tokens, stored one per file as <kid>.pem in JWT_KEYS_DIR.
+//
+//	go run ./cmd/jwt-keys generate -alg EdDSA -dir ./keys [-kid 2026-10]
+//	go run ./cmd/jwt-keys retire -dir ./keys -kid 2026-09
+//
+// To rotate, generate a key and deploy it while the old key still signs so verifiers fetch it from the JWKS,
+// then point JWT_SIGNING_KEY_ID at it and retire the old key. A retired key keeps only its public half,
+// so tokens it signed stay valid until they expire; delete the file after the access token TTL has passed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "retire":
+		err = retire(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jwt-keys generate -alg RS256|EdDSA -dir DIR [-kid ID]")
+	fmt.Fprintln(os.Stderr, "       jwt-keys retire -dir DIR -kid ID")
+	os.Exit(2)
+}
+
+// generate writes a new private key to DIR/<kid>.pem, refusing to overwrite an existing key.
+func generate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	alg := fs.String("alg", jwtkeys.AlgEdDSA, "signing algorithm: RS256 or EdDSA")
+	dir := fs.String("dir", "./keys", "directory holding the keys (JWT_KEYS_DIR)")
+	kid := fs.String("kid", time.Now().UTC().Format("20060102-150405"), "key ID, also the file name")
+	_ = fs.Parse(args)
+
+	key, err := jwtkeys.Generate(*alg)
+	if err != nil {
+		return err
+	}
+	data, err := jwtkeys.EncodePrivateKeyPEM(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		return fmt.Errorf("creating key directory: %w", err)
+	}
+	path := filepath.Join(*dir, *kid+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+
+	log.Printf("Wrote %s key %q to %s; set JWT_SIGNING_KEY_ID=%s to sign with it", *alg, *kid, path, *kid)
+	return nil
+}
+
+// retire replaces DIR/<kid>.pem with its public half, so the key verifies tokens but can no longer sign.
+func retire(args []string) error {
+	fs := flag.NewFlagSet("retire", flag.ExitOnError)
+	dir := fs.String("dir", "./keys", "directory holding the keys (JWT_KEYS_DIR)")
+	kid := fs.String("kid", "", "ID of the key to retire")
+	_ = fs.Parse(args)
+	if *kid == "" {
+		return fmt.Errorf("-kid is required")
+	}
+
+	path := filepath.Join(*dir, *kid+".pem")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	key, err := jwtkeys.ParsePEM(*kid, data)
+	if err != nil {
+		return err
+	}
+	public, err := jwtkeys.EncodePublicKeyPEM(key.Public)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, public, 0o600); err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+
+	log.Printf("Retired key %q; it now only verifies tokens", *kid)
+	return nil
+}
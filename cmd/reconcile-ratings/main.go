@@ -0,0 +1,56 @@
+// Package main rebuilds the rating summary stored on every product from the approved reviews in MongoDB.
+// Run it after restoring a backup, changing moderation decisions outside the API, or whenever summaries drift.
+//
+//	go run ./cmd/reconcile-ratings
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	reviewhandlers "github.com/STaninnat/ecom-backend/handlers/review"
+	"github.com/STaninnat/ecom-backend/internal/config"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	if err := godotenv.Load(".env.development"); err != nil {
+		log.Printf("Warning: assuming default configuration, env unreadable: %v", err)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	mongoURI := os.Getenv("MONGO_URI")
+	if dbURL == "" || mongoURI == "" {
+		log.Fatal("DATABASE_URL and MONGO_URI must be set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	postgres := config.NewPostgresProvider(dbURL)
+	_, queries, err := postgres.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Postgres: %v", err)
+	}
+	defer func() { _ = postgres.Close() }()
+
+	mongoProvider := config.NewMongoProvider(mongoURI)
+	_, mongoDB, err := mongoProvider.Connect(ctx)
+	if err != nil {
+		log.Fatalf("MongoDB: %v", err)
+	}
+	defer func() { _ = mongoProvider.Close(context.Background()) }()
+
+	updated, err := reviewhandlers.ReconcileRatingSummaries(ctx, intmongo.NewReviewMongo(mongoDB), queries)
+	if err != nil {
+		log.Printf("Reconciled %d products before failing", updated)
+		log.Fatalf("Reconcile rating summaries: %v", err)
+	}
+	log.Printf("Reconciled rating summaries for %d products", updated)
+}
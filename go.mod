@@ -24,6 +24,7 @@ require (
 	github.com/testcontainers/testcontainers-go/modules/mongodb v0.38.0
 	go.mongodb.org/mongo-driver/v2 v2.2.2
 	golang.org/x/crypto v0.40.0
+	golang.org/x/image v0.25.0
 	golang.org/x/oauth2 v0.30.0
 )
 
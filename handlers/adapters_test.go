@@ -252,10 +252,10 @@ func TestLegacyUserService_GetUserByID(t *testing.T) {
 	expectedUser := database.User{ID: userID, Name: "Test User", Email: "test@example.com"}
 
 	// Set up expected query and result
-	mock.ExpectQuery(`SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at FROM users\s+WHERE id = \$1\s+LIMIT 1`).
+	mock.ExpectQuery(`SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at, email_verified_at FROM users\s+WHERE id = \$1\s+LIMIT 1`).
 		WithArgs(userID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "provider", "provider_id", "phone", "address", "role", "created_at", "updated_at"}).
-			AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Email, nil, "local", nil, nil, nil, "user", expectedUser.CreatedAt, expectedUser.UpdatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "provider", "provider_id", "phone", "address", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Email, nil, "local", nil, nil, nil, "user", expectedUser.CreatedAt, expectedUser.UpdatedAt, nil))
 
 	user, err := adapter.GetUserByID(ctx, userID)
 
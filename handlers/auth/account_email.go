@@ -0,0 +1,198 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/mailer"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// account_email.go: Email verification and password reset, with links mailed through a pluggable Mailer.
+
+// AccountEmailConfig wires email verification and password reset. With a nil Mailer or Tokens, both flows are disabled.
+type AccountEmailConfig struct {
+	Mailer mailer.Mailer
+	Tokens *AccountTokens
+	// BaseURL is the frontend address that the emailed links point to, e.g. https://shop.example.com.
+	BaseURL string
+	// RequireVerification blocks local sign-in until the email is verified.
+	RequireVerification bool
+}
+
+// enabled reports whether account emails can be sent.
+func (c AccountEmailConfig) enabled() bool {
+	return c.Mailer != nil && c.Tokens != nil
+}
+
+// VerifyEmail marks the email of the token's user as verified, if it is still the address the link was sent to.
+// Returns an AppError with "invalid_token" code if the token is unknown, expired or already used,
+// or if the user's email has changed since.
+func (s *AuthServiceImpl) VerifyEmail(ctx context.Context, token string) error {
+	if !s.email.enabled() {
+		return &handlers.AppError{Code: "invalid_request", Message: "Email verification is not enabled"}
+	}
+	issued, err := s.email.Tokens.Consume(ctx, TokenPurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+
+	timeNow := time.Now().UTC()
+	verified, err := s.db.MarkUserEmailVerified(ctx, database.MarkUserEmailVerifiedParams{
+		ID:              issued.UserID,
+		EmailVerifiedAt: sql.NullTime{Time: timeNow, Valid: true},
+		Email:           issued.Email,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "update_user_error", Message: "Error verifying email", Err: err}
+	}
+	if verified == 0 {
+		return &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}
+	}
+	return nil
+}
+
+// ForgotPassword mails a password reset link if an account has the given email.
+// Always returns nil for unknown emails, so the response does not reveal which accounts exist.
+func (s *AuthServiceImpl) ForgotPassword(ctx context.Context, email string) error {
+	if !s.email.enabled() {
+		return &handlers.AppError{Code: "invalid_request", Message: "Password reset is not enabled"}
+	}
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return &handlers.AppError{Code: "database_error", Message: "Error getting user", Err: err}
+	}
+
+	token, err := s.email.Tokens.Issue(ctx, TokenPurposeResetPassword, user.ID, user.Email, ResetPasswordTokenTTL)
+	if err != nil {
+		return err
+	}
+	err = s.email.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your account.\n\n"+
+			"Open this link within %s to choose a new password:\n%s\n\n"+
+			"If this was not you, ignore this email; your password has not changed.\n",
+			formatTTL(ResetPasswordTokenTTL), s.accountLink("reset-password", token)),
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "mail_error", Message: "Error sending email", Err: err}
+	}
+	return nil
+}
+
+// ResetPassword sets a new password for the token's user and revokes every refresh token of that user,
+// signing out all of their sessions.
+// Returns an AppError with "invalid_token" code if the token is unknown, expired or already used.
+func (s *AuthServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if !s.email.enabled() {
+		return &handlers.AppError{Code: "invalid_request", Message: "Password reset is not enabled"}
+	}
+
+	// Hash first so that a rejected password does not use up the token
+	hashedPassword, err := s.auth.HashPassword(newPassword)
+	if err != nil {
+		return &handlers.AppError{Code: "invalid_request", Message: "Invalid password", Err: err}
+	}
+
+	issued, err := s.email.Tokens.Consume(ctx, TokenPurposeResetPassword, token)
+	if err != nil {
+		return err
+	}
+	userID := issued.UserID
+
+	timeNow := time.Now().UTC()
+	err = s.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:        userID,
+		Password:  utils.ToNullString(hashedPassword),
+		UpdatedAt: timeNow,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "update_user_error", Message: "Error updating password", Err: err}
+	}
+
+	// The reset link proved the user owns the address it was sent to, so mark it verified if it is still their email.
+	// Errors are ignored: the password is already changed.
+	_, _ = s.db.MarkUserEmailVerified(ctx, database.MarkUserEmailVerifiedParams{
+		ID:              userID,
+		EmailVerifiedAt: sql.NullTime{Time: timeNow, Valid: true},
+		Email:           issued.Email,
+	})
+
+	// Sign out every device, since whoever held the old password may hold a session
+	if _, err := s.auth.RevokeRefreshSessions(ctx, userID, ""); err != nil {
+		return &handlers.AppError{Code: "redis_error", Message: "Error revoking refresh tokens", Err: err}
+	}
+	return nil
+}
+
+// sendVerificationEmail mails an email verification link to the user.
+// Errors are ignored: the account is already saved, and signing in again sends a new link.
+func (s *AuthServiceImpl) sendVerificationEmail(ctx context.Context, userID, email string) {
+	s.mailVerificationLink(ctx, userID, email, "Welcome! Open this link within %s to verify your email address:\n%s\n\n"+
+		"If you did not create an account, ignore this email.\n")
+}
+
+// SendEmailChangeVerification revokes the links mailed to the old address of a user who changed their email,
+// and mails a verification link to the new address.
+// Errors are ignored: the change is already saved, links to the old address can no longer verify the new one,
+// and signing in again sends a new link.
+func (s *AuthServiceImpl) SendEmailChangeVerification(ctx context.Context, userID, email string) {
+	if s.email.enabled() {
+		_ = s.email.Tokens.RevokeAll(ctx, TokenPurposeVerifyEmail, userID)
+		_ = s.email.Tokens.RevokeAll(ctx, TokenPurposeResetPassword, userID)
+	}
+	s.mailVerificationLink(ctx, userID, email, "Your account's email address was changed to this one. "+
+		"Open this link within %s to verify it:\n%s\n\n"+
+		"If you did not change it, ignore this email.\n")
+}
+
+// mailVerificationLink issues an email verification token and mails it in body, a format taking the link's
+// lifetime and the link.
+func (s *AuthServiceImpl) mailVerificationLink(ctx context.Context, userID, email, body string) {
+	if !s.email.enabled() {
+		return
+	}
+	token, err := s.email.Tokens.Issue(ctx, TokenPurposeVerifyEmail, userID, email, VerifyEmailTokenTTL)
+	if err != nil {
+		return
+	}
+	_ = s.email.Mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf(body, formatTTL(VerifyEmailTokenTTL), s.accountLink("verify-email", token)),
+	})
+}
+
+// requiresVerification reports whether the user must verify their email before signing in.
+func (s *AuthServiceImpl) requiresVerification(user database.User) bool {
+	return s.email.enabled() && s.email.RequireVerification && !user.EmailVerifiedAt.Valid
+}
+
+// accountLink builds the frontend link that carries an account token.
+func (s *AuthServiceImpl) accountLink(page, token string) string {
+	return strings.TrimRight(s.email.BaseURL, "/") + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// formatTTL formats a token lifetime for an email, e.g. "30 minutes", "1 hour" or "24 hours".
+func formatTTL(ttl time.Duration) string {
+	switch {
+	case ttl >= 2*time.Hour:
+		return fmt.Sprintf("%d hours", int(ttl.Hours()))
+	case ttl >= time.Hour:
+		return "1 hour"
+	default:
+		return fmt.Sprintf("%d minutes", int(ttl.Minutes()))
+	}
+}
@@ -0,0 +1,298 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/auth"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/mailer"
+)
+
+// account_email_test.go: Tests for email verification, password reset, and how they affect signup and signin.
+
+// fakeMailer records the messages it is given.
+type fakeMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (f *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+// newTestAccountEmail returns an enabled AccountEmailConfig backed by in-memory fakes.
+func newTestAccountEmail(requireVerification bool) (AccountEmailConfig, *fakeMailer) {
+	m := &fakeMailer{}
+	return AccountEmailConfig{
+		Mailer:              m,
+		Tokens:              NewAccountTokens(newFakeTokenRedis()),
+		BaseURL:             "https://shop.example.com/",
+		RequireVerification: requireVerification,
+	}, m
+}
+
+// tokenFromMail extracts the token from the link in an account email.
+func tokenFromMail(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	start := strings.Index(msg.Body, "https://")
+	require.GreaterOrEqual(t, start, 0, "no link in mail body")
+	link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+// TestAuthServiceImpl_VerifyEmail tests that a verification token marks the email verified once.
+func TestAuthServiceImpl_VerifyEmail(t *testing.T) {
+	email, _ := newTestAccountEmail(false)
+	token, err := email.Tokens.Issue(context.Background(), TokenPurposeVerifyEmail, testUUID, "user@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+
+	var marked []database.MarkUserEmailVerifiedParams
+	mockDB := &MockDBQueries{
+		MarkUserEmailVerifiedFunc: func(_ context.Context, params database.MarkUserEmailVerifiedParams) (int64, error) {
+			marked = append(marked, params)
+			return 1, nil
+		},
+	}
+	service := &AuthServiceImpl{db: mockDB, email: email}
+
+	require.NoError(t, service.VerifyEmail(context.Background(), token))
+	require.Len(t, marked, 1)
+	assert.Equal(t, testUUID, marked[0].ID)
+	assert.Equal(t, "user@example.com", marked[0].Email)
+	assert.True(t, marked[0].EmailVerifiedAt.Valid)
+
+	assertAppErrorCode(t, service.VerifyEmail(context.Background(), token), "invalid_token")
+	assert.Len(t, marked, 1)
+}
+
+// TestAuthServiceImpl_VerifyEmail_EmailChanged tests that a link sent to an address the user no longer has is refused.
+func TestAuthServiceImpl_VerifyEmail_EmailChanged(t *testing.T) {
+	email, _ := newTestAccountEmail(false)
+	token, err := email.Tokens.Issue(context.Background(), TokenPurposeVerifyEmail, testUUID, "old@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+	mockDB := &MockDBQueries{
+		// The user's email is no longer old@example.com, so no row matches
+		MarkUserEmailVerifiedFunc: func(_ context.Context, _ database.MarkUserEmailVerifiedParams) (int64, error) {
+			return 0, nil
+		},
+	}
+	service := &AuthServiceImpl{db: mockDB, email: email}
+
+	assertAppErrorCode(t, service.VerifyEmail(context.Background(), token), "invalid_token")
+}
+
+// TestAuthServiceImpl_VerifyEmail_Disabled tests that the flow is refused without a mailer.
+func TestAuthServiceImpl_VerifyEmail_Disabled(t *testing.T) {
+	service := &AuthServiceImpl{}
+	assertAppErrorCode(t, service.VerifyEmail(context.Background(), "tok"), "invalid_request")
+	assertAppErrorCode(t, service.ForgotPassword(context.Background(), "user@example.com"), "invalid_request")
+	assertAppErrorCode(t, service.ResetPassword(context.Background(), "tok", testPassword), "invalid_request")
+}
+
+// TestAuthServiceImpl_ForgotPassword tests that only known emails get a reset link and unknown ones are not revealed.
+func TestAuthServiceImpl_ForgotPassword(t *testing.T) {
+	t.Run("known_email", func(t *testing.T) {
+		email, m := newTestAccountEmail(false)
+		mockDB := &MockDBQueries{
+			GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+				return database.User{ID: testUUID, Email: "user@example.com"}, nil
+			},
+		}
+		service := &AuthServiceImpl{db: mockDB, email: email}
+
+		require.NoError(t, service.ForgotPassword(context.Background(), "user@example.com"))
+		require.Len(t, m.sent, 1)
+		assert.Equal(t, "user@example.com", m.sent[0].To)
+		assert.Contains(t, m.sent[0].Body, "https://shop.example.com/reset-password?token=")
+		assert.Contains(t, m.sent[0].Body, "1 hour")
+
+		issued, err := email.Tokens.Consume(context.Background(), TokenPurposeResetPassword, tokenFromMail(t, m.sent[0]))
+		require.NoError(t, err)
+		assert.Equal(t, AccountToken{UserID: testUUID, Email: "user@example.com"}, issued)
+	})
+
+	t.Run("unknown_email", func(t *testing.T) {
+		email, m := newTestAccountEmail(false)
+		mockDB := &MockDBQueries{
+			GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+				return database.User{}, sql.ErrNoRows
+			},
+		}
+		service := &AuthServiceImpl{db: mockDB, email: email}
+
+		require.NoError(t, service.ForgotPassword(context.Background(), "nobody@example.com"))
+		assert.Empty(t, m.sent)
+	})
+
+	t.Run("mail_error", func(t *testing.T) {
+		email, m := newTestAccountEmail(false)
+		m.err = errors.New("smtp down")
+		mockDB := &MockDBQueries{
+			GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+				return database.User{ID: testUUID, Email: "user@example.com"}, nil
+			},
+		}
+		service := &AuthServiceImpl{db: mockDB, email: email}
+
+		assertAppErrorCode(t, service.ForgotPassword(context.Background(), "user@example.com"), "mail_error")
+	})
+}
+
+// TestAuthServiceImpl_ResetPassword tests that a reset sets the new password, verifies the email and revokes every session.
+func TestAuthServiceImpl_ResetPassword(t *testing.T) {
+	email, _ := newTestAccountEmail(false)
+	token, err := email.Tokens.Issue(context.Background(), TokenPurposeResetPassword, testUUID, "user@example.com", ResetPasswordTokenTTL)
+	require.NoError(t, err)
+
+	sessions := newFakeSessionAuth(testSessions()...)
+
+	var updated database.UpdateUserPasswordParams
+	var verified database.MarkUserEmailVerifiedParams
+	mockDB := &MockDBQueries{
+		UpdateUserPasswordFunc: func(_ context.Context, params database.UpdateUserPasswordParams) error {
+			updated = params
+			return nil
+		},
+		MarkUserEmailVerifiedFunc: func(_ context.Context, params database.MarkUserEmailVerifiedParams) (int64, error) {
+			verified = params
+			return 1, nil
+		},
+	}
+	service := &AuthServiceImpl{db: mockDB, auth: sessions, email: email}
+
+	require.NoError(t, service.ResetPassword(context.Background(), token, "a-new-password"))
+	assert.Equal(t, testUUID, updated.ID)
+	require.NoError(t, auth.CheckPasswordHash("a-new-password", updated.Password.String))
+	assert.Equal(t, "user@example.com", verified.Email, "only the address the link was sent to is verified")
+	assert.Len(t, sessions.sessions, 1, "every session of the user should be revoked")
+
+	assertAppErrorCode(t, service.ResetPassword(context.Background(), token, "another-password"), "invalid_token")
+}
+
+// TestAuthServiceImpl_ResetPassword_HashErrorKeepsToken tests that a rejected password does not use up the token.
+func TestAuthServiceImpl_ResetPassword_HashErrorKeepsToken(t *testing.T) {
+	email, _ := newTestAccountEmail(false)
+	token, err := email.Tokens.Issue(context.Background(), TokenPurposeResetPassword, testUUID, "user@example.com", ResetPasswordTokenTTL)
+	require.NoError(t, err)
+	service := &AuthServiceImpl{auth: &mockAuthConfigWithHashError{}, email: email}
+
+	assertAppErrorCode(t, service.ResetPassword(context.Background(), token, "pw"), "invalid_request")
+
+	issued, err := email.Tokens.Consume(context.Background(), TokenPurposeResetPassword, token)
+	require.NoError(t, err)
+	assert.Equal(t, testUUID, issued.UserID)
+}
+
+// TestAuthServiceImpl_SignUp_SendsVerification tests that signup mails a verification link and,
+// when verification is required, issues no tokens.
+func TestAuthServiceImpl_SignUp_SendsVerification(t *testing.T) {
+	for _, required := range []bool{false, true} {
+		t.Run(map[bool]string{false: "optional", true: "required"}[required], func(t *testing.T) {
+			mockDB := &MockDBQueries{
+				CheckUserExistsByNameFunc:  func(_ context.Context, _ string) (bool, error) { return false, nil },
+				CheckUserExistsByEmailFunc: func(_ context.Context, _ string) (bool, error) { return false, nil },
+				CreateUserFunc:             func(_ context.Context, _ database.CreateUserParams) error { return nil },
+			}
+			email, m := newTestAccountEmail(required)
+			service := &AuthServiceImpl{
+				db:          mockDB,
+				dbConn:      &MockDBConn{},
+				auth:        &mockServiceAuthConfig{},
+				redisClient: &FakeRedis{},
+				email:       email,
+			}
+
+			result, err := service.SignUp(context.Background(), SignUpParams{Name: "user", Email: "user@example.com", Password: testPassword})
+			require.NoError(t, err)
+			assert.Equal(t, required, result.VerificationPending)
+			assert.Equal(t, required, result.AccessToken == "")
+			require.Len(t, m.sent, 1)
+			assert.Equal(t, "user@example.com", m.sent[0].To)
+			assert.Contains(t, m.sent[0].Body, "https://shop.example.com/verify-email?token=")
+		})
+	}
+}
+
+// TestAuthServiceImpl_SignIn_EmailNotVerified tests that unverified accounts are refused and sent a new link
+// only when verification is required.
+func TestAuthServiceImpl_SignIn_EmailNotVerified(t *testing.T) {
+	hash, _ := auth.HashPassword(testPassword)
+	mockDB := &MockDBQueries{
+		GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+			return database.User{ID: testUUID, Email: "user@example.com", Password: sql.NullString{String: hash, Valid: true}}, nil
+		},
+		UpdateUserStatusByIDFunc: func(_ context.Context, _ database.UpdateUserStatusByIDParams) error { return nil },
+	}
+
+	email, m := newTestAccountEmail(true)
+	service := &AuthServiceImpl{db: mockDB, dbConn: &MockDBConn{}, auth: &mockServiceAuthConfig{}, email: email}
+	result, err := service.SignIn(context.Background(), SignInParams{Email: "user@example.com", Password: testPassword})
+	assert.Nil(t, result)
+	assertAppErrorCode(t, err, "email_not_verified")
+	assert.Len(t, m.sent, 1)
+
+	email, m = newTestAccountEmail(false)
+	service.email = email
+	result, err = service.SignIn(context.Background(), SignInParams{Email: "user@example.com", Password: testPassword})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Empty(t, m.sent)
+}
+
+// TestAuthServiceImpl_SendEmailChangeVerification tests that a changed email gets a link that verifies it,
+// and that links mailed to the old address stop working.
+func TestAuthServiceImpl_SendEmailChangeVerification(t *testing.T) {
+	var marked []database.MarkUserEmailVerifiedParams
+	mockDB := &MockDBQueries{
+		MarkUserEmailVerifiedFunc: func(_ context.Context, params database.MarkUserEmailVerifiedParams) (int64, error) {
+			marked = append(marked, params)
+			return 1, nil
+		},
+	}
+	email, m := newTestAccountEmail(false)
+	service := &AuthServiceImpl{db: mockDB, email: email}
+	ctx := context.Background()
+	oldVerify, err := email.Tokens.Issue(ctx, TokenPurposeVerifyEmail, testUUID, "old@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+	oldReset, err := email.Tokens.Issue(ctx, TokenPurposeResetPassword, testUUID, "old@example.com", ResetPasswordTokenTTL)
+	require.NoError(t, err)
+
+	service.SendEmailChangeVerification(ctx, testUUID, "new@example.com")
+	require.Len(t, m.sent, 1)
+	assert.Equal(t, "new@example.com", m.sent[0].To)
+	assert.Contains(t, m.sent[0].Body, "changed")
+
+	assertAppErrorCode(t, service.VerifyEmail(ctx, oldVerify), "invalid_token")
+	_, err = email.Tokens.Consume(ctx, TokenPurposeResetPassword, oldReset)
+	assertAppErrorCode(t, err, "invalid_token")
+	assert.Empty(t, marked)
+
+	require.NoError(t, service.VerifyEmail(ctx, tokenFromMail(t, m.sent[0])))
+	require.Len(t, marked, 1)
+	assert.Equal(t, testUUID, marked[0].ID)
+	assert.Equal(t, "new@example.com", marked[0].Email)
+
+	// Disabled without a mailer
+	assert.NotPanics(t, func() {
+		(&AuthServiceImpl{}).SendEmailChangeVerification(context.Background(), testUUID, "new@example.com")
+	})
+}
+
+// TestFormatTTL tests the token lifetimes shown in emails.
+func TestFormatTTL(t *testing.T) {
+	assert.Equal(t, "30 minutes", formatTTL(30*time.Minute))
+	assert.Equal(t, "1 hour", formatTTL(time.Hour))
+	assert.Equal(t, "24 hours", formatTTL(24*time.Hour))
+}
@@ -0,0 +1,137 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+)
+
+// account_tokens.go: Single-use, expiring tokens for email verification and password reset, stored hashed in Redis.
+
+const (
+	// AccountTokenKeyPrefix is the prefix for email verification and password reset tokens in Redis.
+	AccountTokenKeyPrefix = "account_token:"
+	// AccountTokenUserPrefix is the prefix for the set of a user's outstanding tokens of one purpose in Redis.
+	AccountTokenUserPrefix = "account_tokens:"
+
+	// TokenPurposeVerifyEmail marks a token that verifies a user's email address.
+	TokenPurposeVerifyEmail = "verify_email"
+	// TokenPurposeResetPassword marks a token that resets a user's password.
+	TokenPurposeResetPassword = "reset_password"
+
+	// VerifyEmailTokenTTL is the time-to-live for email verification tokens.
+	VerifyEmailTokenTTL = 24 * time.Hour
+	// ResetPasswordTokenTTL is the time-to-live for password reset tokens.
+	ResetPasswordTokenTTL = time.Hour
+)
+
+// AccountTokenRedis defines the Redis operations needed by AccountTokens.
+type AccountTokenRedis interface {
+	Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
+	GetDel(ctx context.Context, key string) *redis.StringCmd
+	SAdd(ctx context.Context, key string, members ...any) *redis.IntCmd
+	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// AccountToken is what a token was issued for: a user and the email address the link was sent to.
+type AccountToken struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// AccountTokens issues and consumes account tokens. Only a SHA-256 hash of each token is stored,
+// so a leaked Redis snapshot cannot be used to verify emails or reset passwords.
+type AccountTokens struct {
+	redis AccountTokenRedis
+}
+
+// NewAccountTokens creates a new AccountTokens.
+func NewAccountTokens(redisClient AccountTokenRedis) *AccountTokens {
+	return &AccountTokens{redis: redisClient}
+}
+
+// Issue creates a token for the user, to be mailed to email, that is valid for ttl and can be used once.
+func (t *AccountTokens) Issue(ctx context.Context, purpose, userID, email string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", &handlers.AppError{Code: "token_generation_error", Message: "Error generating token", Err: err}
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	value, err := json.Marshal(AccountToken{UserID: userID, Email: email})
+	if err != nil {
+		return "", &handlers.AppError{Code: "token_generation_error", Message: "Error generating token", Err: err}
+	}
+	key := accountTokenKey(purpose, token)
+	if err := t.redis.Set(ctx, key, string(value), ttl).Err(); err != nil {
+		return "", &handlers.AppError{Code: "redis_error", Message: "Error storing token", Err: err}
+	}
+
+	// Track the token under its user so RevokeAll can find it
+	userKey := accountTokenUserKey(purpose, userID)
+	if err := t.redis.SAdd(ctx, userKey, key).Err(); err != nil {
+		return "", &handlers.AppError{Code: "redis_error", Message: "Error storing token", Err: err}
+	}
+	if err := t.redis.Expire(ctx, userKey, ttl).Err(); err != nil {
+		return "", &handlers.AppError{Code: "redis_error", Message: "Error storing token", Err: err}
+	}
+	return token, nil
+}
+
+// Consume returns what the token was issued for and deletes the token, so it cannot be used again.
+// Returns an AppError with "invalid_token" code if the token is unknown, expired or already used.
+func (t *AccountTokens) Consume(ctx context.Context, purpose, token string) (AccountToken, error) {
+	if token == "" {
+		return AccountToken{}, &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}
+	}
+	value, err := t.redis.GetDel(ctx, accountTokenKey(purpose, token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return AccountToken{}, &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}
+	}
+	if err != nil {
+		return AccountToken{}, &handlers.AppError{Code: "redis_error", Message: "Error reading token", Err: err}
+	}
+
+	// Tokens stored before they carried an email cannot be checked against the user's address
+	var issued AccountToken
+	if err := json.Unmarshal([]byte(value), &issued); err != nil || issued.UserID == "" || issued.Email == "" {
+		return AccountToken{}, &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}
+	}
+	return issued, nil
+}
+
+// RevokeAll deletes every outstanding token of the user for purpose, e.g. after the user's email changes.
+func (t *AccountTokens) RevokeAll(ctx context.Context, purpose, userID string) error {
+	userKey := accountTokenUserKey(purpose, userID)
+	keys, err := t.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return &handlers.AppError{Code: "redis_error", Message: "Error reading tokens", Err: err}
+	}
+	if err := t.redis.Del(ctx, append(keys, userKey)...).Err(); err != nil {
+		return &handlers.AppError{Code: "redis_error", Message: "Error revoking tokens", Err: err}
+	}
+	return nil
+}
+
+// accountTokenKey returns the Redis key for a token's hash.
+func accountTokenKey(purpose, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return AccountTokenKeyPrefix + purpose + ":" + hex.EncodeToString(sum[:])
+}
+
+// accountTokenUserKey returns the Redis key for the set of a user's tokens of one purpose.
+func accountTokenUserKey(purpose, userID string) string {
+	return AccountTokenUserPrefix + purpose + ":" + userID
+}
@@ -0,0 +1,170 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+)
+
+// account_tokens_test.go: Tests for issuing and consuming single-use account tokens.
+
+// fakeTokenRedis is an in-memory AccountTokenRedis that records the TTL of each key.
+type fakeTokenRedis struct {
+	values map[string]string
+	sets   map[string][]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeTokenRedis() *fakeTokenRedis {
+	return &fakeTokenRedis{values: map[string]string{}, sets: map[string][]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeTokenRedis) SAdd(_ context.Context, key string, members ...any) *redis.IntCmd {
+	for _, member := range members {
+		f.sets[key] = append(f.sets[key], member.(string))
+	}
+	return redis.NewIntResult(int64(len(members)), nil)
+}
+
+func (f *fakeTokenRedis) SMembers(_ context.Context, key string) *redis.StringSliceCmd {
+	return redis.NewStringSliceResult(f.sets[key], nil)
+}
+
+func (f *fakeTokenRedis) Expire(_ context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	f.ttls[key] = expiration
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeTokenRedis) Del(_ context.Context, keys ...string) *redis.IntCmd {
+	for _, key := range keys {
+		delete(f.values, key)
+		delete(f.sets, key)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (f *fakeTokenRedis) Set(_ context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd {
+	f.values[key] = value.(string)
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeTokenRedis) GetDel(_ context.Context, key string) *redis.StringCmd {
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	delete(f.values, key)
+	return redis.NewStringResult(value, nil)
+}
+
+// TestAccountTokens_IssueAndConsume tests that a token maps back to its user and email once and only for its purpose.
+func TestAccountTokens_IssueAndConsume(t *testing.T) {
+	store := newFakeTokenRedis()
+	tokens := NewAccountTokens(store)
+
+	token, err := tokens.Issue(context.Background(), TokenPurposeResetPassword, "u1", "user@example.com", ResetPasswordTokenTTL)
+	require.NoError(t, err)
+	assert.Len(t, token, 43)
+
+	// Only the hash is stored
+	for key, ttl := range store.ttls {
+		assert.True(t, strings.HasPrefix(key, "account_token:reset_password:") || key == "account_tokens:reset_password:u1")
+		assert.NotContains(t, key, token)
+		assert.Equal(t, time.Hour, ttl)
+	}
+
+	_, err = tokens.Consume(context.Background(), TokenPurposeVerifyEmail, token)
+	assertAppErrorCode(t, err, "invalid_token")
+
+	issued, err := tokens.Consume(context.Background(), TokenPurposeResetPassword, token)
+	require.NoError(t, err)
+	assert.Equal(t, AccountToken{UserID: "u1", Email: "user@example.com"}, issued)
+
+	_, err = tokens.Consume(context.Background(), TokenPurposeResetPassword, token)
+	assertAppErrorCode(t, err, "invalid_token")
+}
+
+// TestAccountTokens_Consume_Errors tests empty tokens and Redis failures.
+func TestAccountTokens_Consume_Errors(t *testing.T) {
+	_, err := NewAccountTokens(newFakeTokenRedis()).Consume(context.Background(), TokenPurposeVerifyEmail, "")
+	assertAppErrorCode(t, err, "invalid_token")
+
+	db, mock := redismock.NewClientMock()
+	mock.ExpectGetDel(accountTokenKey(TokenPurposeVerifyEmail, "tok")).SetErr(errors.New("redis down"))
+	_, err = NewAccountTokens(db).Consume(context.Background(), TokenPurposeVerifyEmail, "tok")
+	assertAppErrorCode(t, err, "redis_error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountTokens_Issue_RedisError tests that a token that cannot be stored is not returned.
+func TestAccountTokens_Issue_RedisError(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.Regexp().ExpectSet("account_token:verify_email:.*", `{"user_id":"u1","email":"user@example.com"}`, VerifyEmailTokenTTL).SetErr(errors.New("redis down"))
+
+	token, err := NewAccountTokens(db).Issue(context.Background(), TokenPurposeVerifyEmail, "u1", "user@example.com", VerifyEmailTokenTTL)
+	assert.Empty(t, token)
+	assertAppErrorCode(t, err, "redis_error")
+}
+
+// TestAccountTokens_Consume_LegacyValue tests that a token stored without the email it was sent to is refused.
+func TestAccountTokens_Consume_LegacyValue(t *testing.T) {
+	store := newFakeTokenRedis()
+	store.values[accountTokenKey(TokenPurposeVerifyEmail, "tok")] = "u1"
+
+	_, err := NewAccountTokens(store).Consume(context.Background(), TokenPurposeVerifyEmail, "tok")
+	assertAppErrorCode(t, err, "invalid_token")
+}
+
+// TestAccountTokens_RevokeAll tests that every outstanding token of a user and purpose stops working,
+// while other users and purposes are untouched.
+func TestAccountTokens_RevokeAll(t *testing.T) {
+	tokens := NewAccountTokens(newFakeTokenRedis())
+	ctx := context.Background()
+	first, err := tokens.Issue(ctx, TokenPurposeVerifyEmail, "u1", "old@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+	second, err := tokens.Issue(ctx, TokenPurposeVerifyEmail, "u1", "old@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+	reset, err := tokens.Issue(ctx, TokenPurposeResetPassword, "u1", "old@example.com", ResetPasswordTokenTTL)
+	require.NoError(t, err)
+	other, err := tokens.Issue(ctx, TokenPurposeVerifyEmail, "u2", "other@example.com", VerifyEmailTokenTTL)
+	require.NoError(t, err)
+
+	require.NoError(t, tokens.RevokeAll(ctx, TokenPurposeVerifyEmail, "u1"))
+
+	for _, token := range []string{first, second} {
+		_, err := tokens.Consume(ctx, TokenPurposeVerifyEmail, token)
+		assertAppErrorCode(t, err, "invalid_token")
+	}
+	_, err = tokens.Consume(ctx, TokenPurposeResetPassword, reset)
+	require.NoError(t, err)
+	_, err = tokens.Consume(ctx, TokenPurposeVerifyEmail, other)
+	require.NoError(t, err)
+}
+
+// TestAccountTokens_RevokeAll_RedisError tests that a failure to list the user's tokens is returned.
+func TestAccountTokens_RevokeAll_RedisError(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectSMembers("account_tokens:verify_email:u1").SetErr(errors.New("redis down"))
+
+	assertAppErrorCode(t, NewAccountTokens(db).RevokeAll(context.Background(), TokenPurposeVerifyEmail, "u1"), "redis_error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// assertAppErrorCode asserts that err is an AppError with the given code.
+func assertAppErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var appErr *handlers.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, code, appErr.Code)
+}
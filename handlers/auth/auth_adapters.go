@@ -68,6 +68,16 @@ func (a *DBQueriesAdapter) UpdateUserSigninStatusByEmail(ctx context.Context, pa
 	return a.Queries.UpdateUserSigninStatusByEmail(ctx, params)
 }
 
+// MarkUserEmailVerified records when a user's email was verified, if it is still the given email.
+func (a *DBQueriesAdapter) MarkUserEmailVerified(ctx context.Context, params database.MarkUserEmailVerifiedParams) (int64, error) {
+	return a.Queries.MarkUserEmailVerified(ctx, params)
+}
+
+// UpdateUserPassword sets a user's password hash.
+func (a *DBQueriesAdapter) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) error {
+	return a.Queries.UpdateUserPassword(ctx, params)
+}
+
 // DBConnAdapter adapts *sql.DB to the DBConn interface.
 type DBConnAdapter struct {
 	*sql.DB
@@ -110,3 +120,35 @@ func (a *AuthConfigAdapter) StoreRefreshTokenInRedis(ctx context.Context, userID
 func (a *AuthConfigAdapter) GenerateAccessToken(userID string, expiresAt time.Time) (string, error) {
 	return a.AuthConfig.GenerateAccessToken(userID, expiresAt)
 }
+
+// RotateRefreshToken expects *http.Request, not context.Context
+func (a *AuthConfigAdapter) RotateRefreshToken(ctx context.Context, oldToken, newToken string, ttl time.Duration) (string, error) {
+	if a.AuthConfig == nil {
+		return "", errors.New("AuthConfig is nil")
+	}
+	r, ok := ctx.Value(HTTPRequestKey).(*http.Request)
+	if !ok || r == nil {
+		return "", errors.New("RotateRefreshToken requires *http.Request in context under 'httpRequest' key")
+	}
+	return a.AuthConfig.RotateRefreshToken(r, oldToken, newToken, ttl)
+}
+
+// ListRefreshSessions returns the user's active refresh sessions.
+func (a *AuthConfigAdapter) ListRefreshSessions(ctx context.Context, userID string) ([]auth.RefreshTokenData, error) {
+	return a.AuthConfig.ListRefreshSessions(ctx, userID)
+}
+
+// RevokeRefreshSession deletes one of the user's refresh sessions.
+func (a *AuthConfigAdapter) RevokeRefreshSession(ctx context.Context, userID, sessionID string) error {
+	return a.AuthConfig.RevokeRefreshSession(ctx, userID, sessionID)
+}
+
+// RevokeRefreshSessions deletes every refresh session of the user except keepSessionID.
+func (a *AuthConfigAdapter) RevokeRefreshSessions(ctx context.Context, userID, keepSessionID string) (int, error) {
+	return a.AuthConfig.RevokeRefreshSessions(ctx, userID, keepSessionID)
+}
+
+// withHTTPRequest returns the request context carrying the request itself, which session writes need for the client IP and device.
+func withHTTPRequest(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), HTTPRequestKey, r)
+}
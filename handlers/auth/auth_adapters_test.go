@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -198,9 +199,9 @@ func TestDBQueriesAdapter_WithSqlMock(t *testing.T) {
 	require.NoError(t, err)
 
 	// Test GetUserByEmail - use exact SQL pattern
-	mock.ExpectQuery("SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at FROM users").WithArgs("test@example.com").WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "password", "provider", "provider_id", "phone", "address", "role", "created_at", "updated_at"}).
-			AddRow("user-id", "Test User", "test@example.com", "hashed", "local", nil, nil, nil, "user", time.Now(), time.Now()),
+	mock.ExpectQuery("SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at, email_verified_at FROM users").WithArgs("test@example.com").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "email", "password", "provider", "provider_id", "phone", "address", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow("user-id", "Test User", "test@example.com", "hashed", "local", nil, nil, nil, "user", time.Now(), time.Now(), nil),
 	)
 	user, err := adapter.GetUserByEmail(ctx, "test@example.com")
 	require.NoError(t, err)
@@ -225,7 +226,7 @@ func TestDBQueriesAdapter_WithSqlMock(t *testing.T) {
 	assert.Equal(t, "user-id", result.ID)
 
 	// Test UpdateUserSigninStatusByEmail - use exact SQL pattern
-	mock.ExpectExec("UPDATE users SET provider = \\$2, provider_id = \\$3, updated_at = \\$4 WHERE email = \\$1").WithArgs("test@example.com", "google", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET provider = \\$2, provider_id = \\$3, updated_at = \\$4, email_verified_at = COALESCE\\(email_verified_at, \\$4\\) WHERE email = \\$1").WithArgs("test@example.com", "google", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
 	err = adapter.UpdateUserSigninStatusByEmail(ctx, database.UpdateUserSigninStatusByEmailParams{
 		Email:      "test@example.com",
 		Provider:   "google",
@@ -390,3 +391,44 @@ func TestAuthConfigAdapter_StoreRefreshTokenInRedis_WithNilRequest(t *testing.T)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "requires *http.Request")
 }
+
+// TestAuthConfigAdapter_RotateRefreshToken_ContextCases tests that rotation needs the request in the context.
+func TestAuthConfigAdapter_RotateRefreshToken_ContextCases(t *testing.T) {
+	redisClient, mock := redismock.NewClientMock()
+	adapter := &AuthConfigAdapter{AuthConfig: &auth.Config{APIConfig: &config.APIConfig{RedisClient: redisClient}}}
+
+	_, err := adapter.RotateRefreshToken(context.Background(), "old", "new", time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires *http.Request")
+
+	r, _ := http.NewRequest("POST", "/", nil)
+	mock.ExpectGet(auth.RedisRefreshTokenLookupPrefix + "old").RedisNil()
+	mock.ExpectGet(auth.RedisRotatedRefreshTokenPrefix + "old").RedisNil()
+	_, err = adapter.RotateRefreshToken(withHTTPRequest(r), "old", "new", time.Minute)
+	require.ErrorIs(t, err, auth.ErrSessionNotFound)
+
+	_, err = (&AuthConfigAdapter{}).RotateRefreshToken(withHTTPRequest(r), "old", "new", time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AuthConfig is nil")
+}
+
+// TestAuthConfigAdapter_SessionMethods tests that listing and revoking sessions forward to the auth config.
+func TestAuthConfigAdapter_SessionMethods(t *testing.T) {
+	redisClient, mock := redismock.NewClientMock()
+	adapter := &AuthConfigAdapter{AuthConfig: &auth.Config{APIConfig: &config.APIConfig{RedisClient: redisClient}}}
+	ctx := context.Background()
+
+	mock.ExpectSMembers(auth.RedisUserSessionsPrefix + "u1").SetVal([]string{})
+	sessions, err := adapter.ListRefreshSessions(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	mock.ExpectGet(auth.RedisRefreshSessionPrefix + "s1").RedisNil()
+	require.ErrorIs(t, adapter.RevokeRefreshSession(ctx, "u1", "s1"), auth.ErrSessionNotFound)
+
+	mock.ExpectSMembers(auth.RedisUserSessionsPrefix + "u1").SetVal([]string{"s1"})
+	revoked, err := adapter.RevokeRefreshSessions(ctx, "u1", "s1")
+	require.NoError(t, err)
+	assert.Zero(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -26,8 +26,9 @@ import (
 
 // --- RefreshTokenData represents refresh token data structure ---
 type RefreshTokenData struct {
-	Token    string `json:"token"`
-	Provider string `json:"provider"`
+	Token     string `json:"token"`
+	Provider  string `json:"provider"`
+	SessionID string `json:"session_id,omitempty"`
 }
 
 // --- MockAuthService is a mock implementation of AuthService for testing ---
@@ -51,8 +52,8 @@ func (m *MockAuthService) SignIn(ctx context.Context, params SignInParams) (*Aut
 	return args.Get(0).(*AuthResult), args.Error(1)
 }
 
-func (m *MockAuthService) SignOut(ctx context.Context, userID string, provider string) error {
-	args := m.Called(ctx, userID, provider)
+func (m *MockAuthService) SignOut(ctx context.Context, userID string, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
 	return args.Error(0)
 }
 
@@ -77,6 +78,52 @@ func (m *MockAuthService) GenerateGoogleAuthURL(state string) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthService) UnlockAccount(ctx context.Context, adminID, email string) error {
+	args := m.Called(ctx, adminID, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) SendEmailChangeVerification(ctx context.Context, userID, email string) {
+	m.Called(ctx, userID, email)
+}
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ReportRefreshTokenReuse(ctx context.Context, session auth.RefreshTokenData, ip string) {
+	m.Called(ctx, session, ip)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, userID string) ([]auth.RefreshTokenData, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth.RefreshTokenData), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (int, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	return args.Int(0), args.Error(1)
+}
+
 // --- MockHandlersConfig is a mock implementation of HandlersConfig for testing ---
 type MockHandlersConfig struct {
 	mock.Mock
@@ -155,7 +202,7 @@ func (cfg *TestHandlersAuthConfig) HandlerSignOut(w http.ResponseWriter, r *http
 	}
 
 	// Call business logic service
-	err = cfg.GetAuthService().SignOut(ctx, userID, storedData.Provider)
+	err = cfg.GetAuthService().SignOut(ctx, userID, storedData.SessionID)
 	if err != nil {
 		cfg.handleAuthError(w, r, err, "sign_out", ip, userAgent)
 		return
@@ -355,6 +402,8 @@ type MockDBQueries struct {
 	WithTxFunc                        func(tx DBTx) DBQueries
 	CheckExistsAndGetIDByEmailFunc    func(ctx context.Context, email string) (database.CheckExistsAndGetIDByEmailRow, error)
 	UpdateUserSigninStatusByEmailFunc func(ctx context.Context, params database.UpdateUserSigninStatusByEmailParams) error
+	MarkUserEmailVerifiedFunc         func(ctx context.Context, params database.MarkUserEmailVerifiedParams) (int64, error)
+	UpdateUserPasswordFunc            func(ctx context.Context, params database.UpdateUserPasswordParams) error
 }
 
 func (m *MockDBQueries) CheckUserExistsByName(ctx context.Context, name string) (bool, error) {
@@ -384,6 +433,12 @@ func (m *MockDBQueries) CheckExistsAndGetIDByEmail(ctx context.Context, email st
 func (m *MockDBQueries) UpdateUserSigninStatusByEmail(ctx context.Context, params database.UpdateUserSigninStatusByEmailParams) error {
 	return m.UpdateUserSigninStatusByEmailFunc(ctx, params)
 }
+func (m *MockDBQueries) MarkUserEmailVerified(ctx context.Context, params database.MarkUserEmailVerifiedParams) (int64, error) {
+	return m.MarkUserEmailVerifiedFunc(ctx, params)
+}
+func (m *MockDBQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) error {
+	return m.UpdateUserPasswordFunc(ctx, params)
+}
 
 // mockServiceAuthConfig is a mock implementation of the AuthConfig interface for service-level tests.
 type mockServiceAuthConfig struct{}
@@ -409,6 +464,26 @@ func (m *mockServiceAuthConfig) GenerateAccessToken(_ string, _ time.Time) (stri
 	return "access-token", nil
 }
 
+// RotateRefreshToken is a mock implementation for refresh token rotation in tests.
+func (m *mockServiceAuthConfig) RotateRefreshToken(_ context.Context, _, newToken string, _ time.Duration) (string, error) {
+	return newToken, nil
+}
+
+// ListRefreshSessions is a mock implementation that reports no sessions.
+func (m *mockServiceAuthConfig) ListRefreshSessions(_ context.Context, _ string) ([]auth.RefreshTokenData, error) {
+	return nil, nil
+}
+
+// RevokeRefreshSession is a mock implementation for revoking one session in tests.
+func (m *mockServiceAuthConfig) RevokeRefreshSession(_ context.Context, _, _ string) error {
+	return nil
+}
+
+// RevokeRefreshSessions is a mock implementation for revoking sessions in tests.
+func (m *mockServiceAuthConfig) RevokeRefreshSessions(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
+
 // --- DBQueriesAdapter method forwarding tests ---
 type fakeQueries struct {
 	CheckUserExistsByNameFunc         func(ctx context.Context, name string) (bool, error)
@@ -519,6 +594,18 @@ func (m *mockAuthConfigWithTokenError) StoreRefreshTokenInRedis(_ context.Contex
 func (m *mockAuthConfigWithTokenError) GenerateAccessToken(_ string, _ time.Time) (string, error) {
 	return "", assert.AnError
 }
+func (m *mockAuthConfigWithTokenError) RotateRefreshToken(_ context.Context, _, newToken string, _ time.Duration) (string, error) {
+	return newToken, nil
+}
+func (m *mockAuthConfigWithTokenError) ListRefreshSessions(_ context.Context, _ string) ([]auth.RefreshTokenData, error) {
+	return nil, nil
+}
+func (m *mockAuthConfigWithTokenError) RevokeRefreshSession(_ context.Context, _, _ string) error {
+	return nil
+}
+func (m *mockAuthConfigWithTokenError) RevokeRefreshSessions(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
 
 type mockAuthConfigWithStoreError struct{}
 
@@ -532,6 +619,18 @@ func (m *mockAuthConfigWithStoreError) StoreRefreshTokenInRedis(_ context.Contex
 func (m *mockAuthConfigWithStoreError) GenerateAccessToken(_ string, _ time.Time) (string, error) {
 	return "", nil
 }
+func (m *mockAuthConfigWithStoreError) RotateRefreshToken(_ context.Context, _, _ string, _ time.Duration) (string, error) {
+	return "", assert.AnError
+}
+func (m *mockAuthConfigWithStoreError) ListRefreshSessions(_ context.Context, _ string) ([]auth.RefreshTokenData, error) {
+	return nil, nil
+}
+func (m *mockAuthConfigWithStoreError) RevokeRefreshSession(_ context.Context, _, _ string) error {
+	return nil
+}
+func (m *mockAuthConfigWithStoreError) RevokeRefreshSessions(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
 
 type mockAuthConfigWithHashError struct{}
 
@@ -547,6 +646,18 @@ func (m *mockAuthConfigWithHashError) StoreRefreshTokenInRedis(_ context.Context
 func (m *mockAuthConfigWithHashError) GenerateAccessToken(_ string, _ time.Time) (string, error) {
 	return "", nil
 }
+func (m *mockAuthConfigWithHashError) RotateRefreshToken(_ context.Context, _, newToken string, _ time.Duration) (string, error) {
+	return newToken, nil
+}
+func (m *mockAuthConfigWithHashError) ListRefreshSessions(_ context.Context, _ string) ([]auth.RefreshTokenData, error) {
+	return nil, nil
+}
+func (m *mockAuthConfigWithHashError) RevokeRefreshSession(_ context.Context, _, _ string) error {
+	return nil
+}
+func (m *mockAuthConfigWithHashError) RevokeRefreshSessions(_ context.Context, _, _ string) (int, error) {
+	return 0, nil
+}
 
 // --- Mocks for OAuth ---
 type mockOAuth2ExchangerWithClient struct {
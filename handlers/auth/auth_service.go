@@ -37,8 +37,6 @@ const (
 	GoogleProvider = "google"
 	// UserRole is the string identifier for a regular user role.
 	UserRole = "user"
-	// RefreshTokenKeyPrefix is the prefix for refresh token keys in Redis.
-	RefreshTokenKeyPrefix = "refresh_token:"
 	// OAuthStateKeyPrefix is the prefix for OAuth state keys in Redis.
 	OAuthStateKeyPrefix = "oauth_state:"
 
@@ -47,14 +45,23 @@ const (
 )
 
 // AuthService defines the business logic interface for authentication.
-// Provides methods for signup, signin, signout, token refresh, Google OAuth, and auth URL generation.
+// Provides methods for signup, signin, signout, token refresh, Google OAuth, auth URL generation, email verification, password reset, and session management.
 type AuthService interface {
 	SignUp(ctx context.Context, params SignUpParams) (*AuthResult, error)
 	SignIn(ctx context.Context, params SignInParams) (*AuthResult, error)
-	SignOut(ctx context.Context, userID string, provider string) error
+	SignOut(ctx context.Context, userID string, sessionID string) error
 	RefreshToken(ctx context.Context, userID string, provider string, refreshToken string) (*AuthResult, error)
 	HandleGoogleAuth(ctx context.Context, code string, state string) (*AuthResult, error)
 	GenerateGoogleAuthURL(state string) (string, error)
+	UnlockAccount(ctx context.Context, adminID, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	SendEmailChangeVerification(ctx context.Context, userID, email string)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ReportRefreshTokenReuse(ctx context.Context, session auth.RefreshTokenData, ip string)
+	ListSessions(ctx context.Context, userID string) ([]auth.RefreshTokenData, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (int, error)
 }
 
 // SignUpParams represents signup request parameters
@@ -68,6 +75,8 @@ type SignUpParams struct {
 type SignInParams struct {
 	Email    string
 	Password string
+	// IP is the client address, used to limit failed sign-ins per IP
+	IP string
 }
 
 // UserGoogleInfo represents user information retrieved from Google OAuth
@@ -85,6 +94,8 @@ type AuthResult struct {
 	AccessTokenExpires  time.Time
 	RefreshTokenExpires time.Time
 	IsNewUser           bool
+	// VerificationPending is set when the new account must verify its email before signing in; no tokens are issued.
+	VerificationPending bool
 }
 
 // DBQueries defines the interface for database query operations needed by AuthServiceImpl.
@@ -97,6 +108,8 @@ type DBQueries interface {
 	WithTx(tx DBTx) DBQueries
 	CheckExistsAndGetIDByEmail(ctx context.Context, email string) (database.CheckExistsAndGetIDByEmailRow, error)
 	UpdateUserSigninStatusByEmail(ctx context.Context, params database.UpdateUserSigninStatusByEmailParams) error
+	MarkUserEmailVerified(ctx context.Context, params database.MarkUserEmailVerifiedParams) (int64, error)
+	UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) error
 }
 
 // DBConn defines the interface for database connection operations needed by AuthServiceImpl.
@@ -123,6 +136,10 @@ type AuthConfig interface {
 	GenerateTokens(userID string, expiresAt time.Time) (string, string, error)
 	StoreRefreshTokenInRedis(ctx context.Context, userID, refreshToken, provider string, ttl time.Duration) error
 	GenerateAccessToken(userID string, expiresAt time.Time) (string, error)
+	RotateRefreshToken(ctx context.Context, oldToken, newToken string, ttl time.Duration) (string, error)
+	ListRefreshSessions(ctx context.Context, userID string) ([]auth.RefreshTokenData, error)
+	RevokeRefreshSession(ctx context.Context, userID, sessionID string) error
+	RevokeRefreshSessions(ctx context.Context, userID, keepSessionID string) (int, error)
 }
 
 // OAuth2Exchanger abstracts all OAuth2 operations needed by authServiceImpl
@@ -140,15 +157,23 @@ type AuthServiceImpl struct {
 	auth        AuthConfig
 	redisClient MinimalRedis
 	oauth       OAuth2Exchanger
+	limiter     *SignInLimiter
+	email       AccountEmailConfig
+	events      SecurityEventStore
 }
 
 // NewAuthService creates a new AuthService instance with the given dependencies.
+// A nil limiter turns off failed sign-in limits; an email config without a Mailer turns off email verification and password reset.
+// A nil event store turns off logging of refresh token reuse; the reused session is revoked either way.
 func NewAuthService(
 	db DBQueries,
 	dbConn DBConn,
 	auth AuthConfig,
 	redisClient MinimalRedis,
 	oauth OAuth2Exchanger,
+	limiter *SignInLimiter,
+	email AccountEmailConfig,
+	events SecurityEventStore,
 ) AuthService {
 	return &AuthServiceImpl{
 		db:          db,
@@ -156,6 +181,9 @@ func NewAuthService(
 		auth:        auth,
 		redisClient: redisClient,
 		oauth:       oauth,
+		limiter:     limiter,
+		email:       email,
+		events:      events,
 	}
 }
 
@@ -163,7 +191,7 @@ func NewAuthService(
 // Now aliases handlers.AppError for consistency
 type AuthError = handlers.AppError
 
-// SignUp handles user registration with local authentication.
+// SignUp handles user registration with local authentication and mails an email verification link.
 func (s *AuthServiceImpl) SignUp(ctx context.Context, params SignUpParams) (*AuthResult, error) {
 	// Check if name exists
 	nameExists, err := s.db.CheckUserExistsByName(ctx, params.Name)
@@ -218,6 +246,15 @@ func (s *AuthServiceImpl) SignUp(ctx context.Context, params SignUpParams) (*Aut
 		return nil, &handlers.AppError{Code: "create_user_error", Message: "Error creating user", Err: err}
 	}
 
+	// Accounts that must verify their email first get no tokens
+	if s.email.enabled() && s.email.RequireVerification {
+		if err = tx.Commit(); err != nil {
+			return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+		}
+		s.sendVerificationEmail(ctx, userID.String(), params.Email)
+		return &AuthResult{UserID: userID.String(), IsNewUser: true, VerificationPending: true}, nil
+	}
+
 	// Generate tokens and store refresh token
 	authResult, err := s.generateAndStoreTokens(ctx, userID.String(), timeNow, true)
 	if err != nil {
@@ -228,23 +265,39 @@ func (s *AuthServiceImpl) SignUp(ctx context.Context, params SignUpParams) (*Aut
 		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
 	}
 
+	s.sendVerificationEmail(ctx, userID.String(), params.Email)
 	return authResult, nil
 }
 
 // SignIn handles user authentication with local credentials.
+// Failed attempts are limited per account and per IP; see SignInLimiter.
+// When email verification is required, unverified accounts are refused and sent a new verification link.
 func (s *AuthServiceImpl) SignIn(ctx context.Context, params SignInParams) (*AuthResult, error) {
+	if s.limiter != nil {
+		if err := s.limiter.Check(ctx, params.Email, params.IP); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get user by email
 	user, err := s.db.GetUserByEmail(ctx, params.Email)
 	if err != nil {
+		s.recordSignInFailure(ctx, params)
 		return nil, &handlers.AppError{Code: "user_not_found", Message: "Invalid credentials"}
 	}
 
 	// Check password
 	err = auth.CheckPasswordHash(params.Password, user.Password.String)
 	if err != nil {
+		s.recordSignInFailure(ctx, params)
 		return nil, &handlers.AppError{Code: "invalid_password", Message: "Invalid credentials"}
 	}
 
+	if s.requiresVerification(user) {
+		s.sendVerificationEmail(ctx, user.ID, user.Email)
+		return nil, &handlers.AppError{Code: "email_not_verified", Message: "Verify your email address first; we sent you a new link"}
+	}
+
 	// Parse user ID
 	userID, err := uuid.Parse(user.ID)
 	if err != nil {
@@ -283,14 +336,40 @@ func (s *AuthServiceImpl) SignIn(ctx context.Context, params SignInParams) (*Aut
 		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
 	}
 
+	if s.limiter != nil {
+		_ = s.limiter.Reset(ctx, params.Email)
+	}
+
 	return authResult, nil
 }
 
-// SignOut handles user signout, revoking tokens and cleaning up session state.
-func (s *AuthServiceImpl) SignOut(ctx context.Context, userID string, _ string) error {
-	// Delete refresh token from Redis
-	err := s.redisClient.Del(ctx, RefreshTokenKeyPrefix+userID).Err()
-	if err != nil {
+// recordSignInFailure counts a failed sign-in when limits are on.
+func (s *AuthServiceImpl) recordSignInFailure(ctx context.Context, params SignInParams) {
+	if s.limiter != nil {
+		s.limiter.RecordFailure(ctx, params.Email, params.IP)
+	}
+}
+
+// UnlockAccount clears the failed sign-in lockout of the account with the given email.
+func (s *AuthServiceImpl) UnlockAccount(ctx context.Context, adminID, email string) error {
+	if s.limiter == nil {
+		return &handlers.AppError{Code: "invalid_request", Message: "Sign-in limits are not enabled"}
+	}
+	if _, err := s.db.GetUserByEmail(ctx, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "account_not_found", Message: "Account not found"}
+		}
+		return &handlers.AppError{Code: "database_error", Message: "Error getting user", Err: err}
+	}
+	return s.limiter.Unlock(ctx, email, adminID)
+}
+
+// SignOut handles user signout, revoking the refresh session of the current device.
+// Sessions on other devices stay signed in.
+func (s *AuthServiceImpl) SignOut(ctx context.Context, userID string, sessionID string) error {
+	// Delete refresh session from Redis; an already expired session is signed out anyway
+	err := s.auth.RevokeRefreshSession(ctx, userID, sessionID)
+	if err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
 		return &handlers.AppError{Code: "redis_error", Message: "Error deleting refresh token", Err: err}
 	}
 
@@ -305,7 +384,7 @@ func (s *AuthServiceImpl) RefreshToken(ctx context.Context, userID string, provi
 		return s.refreshGoogleToken(ctx, userID, refreshToken, timeNow)
 	}
 
-	return s.refreshLocalToken(ctx, userID, timeNow)
+	return s.refreshLocalToken(ctx, userID, refreshToken, timeNow)
 }
 
 // GenerateGoogleAuthURL generates the Google OAuth authorization URL for the given state.
@@ -382,6 +461,12 @@ func (s *AuthServiceImpl) refreshGoogleToken(ctx context.Context, userID, refres
 
 	refreshTokenExpiresAt := timeNow.Add(RefreshTokenTTL)
 
+	// Google keeps the refresh token, so the session only records the use
+	_, err = s.auth.RotateRefreshToken(ctx, refreshToken, refreshToken, RefreshTokenTTL)
+	if err != nil {
+		return nil, s.rotateRefreshTokenError(ctx, err)
+	}
+
 	return &AuthResult{
 		UserID:              userID,
 		AccessToken:         newToken.AccessToken,
@@ -392,16 +477,45 @@ func (s *AuthServiceImpl) refreshGoogleToken(ctx context.Context, userID, refres
 	}, nil
 }
 
-// refreshLocalToken handles local authentication token refresh
-func (s *AuthServiceImpl) refreshLocalToken(ctx context.Context, userID string, timeNow time.Time) (*AuthResult, error) {
-	// Delete old refresh token
-	err := s.redisClient.Del(ctx, RefreshTokenKeyPrefix+userID).Err()
+// refreshLocalToken handles local authentication token refresh.
+// The new refresh token replaces the old one in the same session. When a concurrent refresh of the same client
+// already replaced it, the client gets that refresh's token, so every request ends up holding the current one.
+func (s *AuthServiceImpl) refreshLocalToken(ctx context.Context, userID, oldRefreshToken string, timeNow time.Time) (*AuthResult, error) {
+	accessTokenExpiresAt := timeNow.Add(AccessTokenTTL)
+	refreshTokenExpiresAt := timeNow.Add(RefreshTokenTTL)
+
+	accessToken, refreshToken, err := s.auth.GenerateTokens(userID, accessTokenExpiresAt)
 	if err != nil {
-		return nil, &handlers.AppError{Code: "redis_error", Message: "Error deleting old refresh token", Err: err}
+		return nil, &handlers.AppError{Code: "token_generation_error", Message: "Error generating tokens", Err: err}
 	}
 
-	// Generate new tokens and store refresh token
-	return s.generateAndStoreTokens(ctx, userID, timeNow, false)
+	refreshToken, err = s.auth.RotateRefreshToken(ctx, oldRefreshToken, refreshToken, RefreshTokenTTL)
+	if err != nil {
+		return nil, s.rotateRefreshTokenError(ctx, err)
+	}
+
+	return &AuthResult{
+		UserID:              userID,
+		AccessToken:         accessToken,
+		RefreshToken:        refreshToken,
+		AccessTokenExpires:  accessTokenExpiresAt,
+		RefreshTokenExpires: refreshTokenExpiresAt,
+		IsNewUser:           false,
+	}, nil
+}
+
+// rotateRefreshTokenError maps a failed rotation to an AppError; a session revoked during the refresh is signed out.
+// A token rotated by a concurrent refresh more than auth.RefreshTokenReuseGrace ago counts as reuse and is reported.
+func (s *AuthServiceImpl) rotateRefreshTokenError(ctx context.Context, err error) error {
+	var reuse *auth.RefreshTokenReuseError
+	if errors.As(err, &reuse) {
+		s.ReportRefreshTokenReuse(ctx, reuse.Session, requestIP(ctx))
+		return &handlers.AppError{Code: "refresh_token_reused", Message: "Refresh token was already used, please sign in again", Err: err}
+	}
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		return &handlers.AppError{Code: "invalid_session", Message: "Session has been signed out"}
+	}
+	return &handlers.AppError{Code: "redis_error", Message: "Error storing refresh token", Err: err}
 }
 
 // getUserInfoFromGoogle retrieves user information from Google API
@@ -550,7 +664,7 @@ func (apicfg *HandlersAuthConfig) MergeCart(ctx context.Context, r *http.Request
 
 	// For each item in the guest cart, add it to the user's cart
 	for _, item := range guestCart.Items {
-		if err := cartService.AddItemToUserCart(ctx, userID, item.ProductID, item.Quantity); err != nil {
+		if err := cartService.AddItemToUserCart(ctx, userID, item.ProductID, item.VariantID, item.Quantity); err != nil {
 			apicfg.LogHandlerError(ctx, "merge_cart", "add_item_to_user_cart_failed", "Failed to add item to user cart", "", "", err)
 			return
 		}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/go-redis/redismock/v9"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -126,7 +127,6 @@ func TestConstants(t *testing.T) {
 	assert.NotZero(t, OAuthStateTTL)
 	assert.Equal(t, "local", LocalProvider)
 	assert.Equal(t, "user", UserRole)
-	assert.Equal(t, "refresh_token:", RefreshTokenKeyPrefix)
 	assert.Equal(t, "oauth_state:", OAuthStateKeyPrefix)
 	assert.Equal(t, "valid", OAuthStateValid)
 }
@@ -150,7 +150,7 @@ func TestAuthServiceImpl_RefreshToken(t *testing.T) {
 			redisClient: &ErrorRedis{},
 			db:          nil,
 			dbConn:      nil,
-			auth:        &mockServiceAuthConfig{},
+			auth:        &mockAuthConfigWithStoreError{},
 		}
 		result, err := svc.RefreshToken(context.Background(), testUserID, testProvider, testRefreshToken)
 		require.Error(t, err)
@@ -818,28 +818,19 @@ type testAuthServiceImpl struct {
 // }
 
 // TestAuthServiceImpl_SignOut verifies the behavior of the SignOut function:
-// - success case where the refresh token is deleted from Redis
-// - failure case where Redis returns an error during deletion
+// - success case where the refresh session is revoked
+// - failure case where Redis returns an error during revocation
 func TestAuthServiceImpl_SignOut(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		mockRedis := &mockRedisClient{
-			DelFunc: func(_ context.Context, keys ...string) *redis.IntCmd {
-				assert.Contains(t, keys[0], "refresh_token:")
-				return redis.NewIntResult(1, nil)
-			},
-		}
-		svc := &AuthServiceImpl{redisClient: mockRedis}
-		err := svc.SignOut(context.Background(), testUserID, testProvider)
+		fake := newFakeSessionAuth(auth.RefreshTokenData{SessionID: "s1", UserID: testUserID, Token: testRefreshToken, Provider: testProvider})
+		svc := &AuthServiceImpl{auth: fake}
+		err := svc.SignOut(context.Background(), testUserID, "s1")
 		assert.NoError(t, err)
+		assert.Empty(t, fake.sessions)
 	})
 	t.Run("redis error", func(t *testing.T) {
-		mockRedis := &mockRedisClient{
-			DelFunc: func(_ context.Context, _ ...string) *redis.IntCmd {
-				return redis.NewIntResult(0, assert.AnError)
-			},
-		}
-		svc := &AuthServiceImpl{redisClient: mockRedis}
-		err := svc.SignOut(context.Background(), testUserID, testProvider)
+		svc := &AuthServiceImpl{auth: &fakeSessionAuth{err: assert.AnError}}
+		err := svc.SignOut(context.Background(), testUserID, "s1")
 		require.Error(t, err)
 		appErr := &handlers.AppError{}
 		ok := errors.As(err, &appErr)
@@ -1319,6 +1310,7 @@ func TestAuthServiceImpl_refreshGoogleToken_Success(t *testing.T) {
 
 	service := &AuthServiceImpl{
 		oauth: mockOAuth,
+		auth:  &mockServiceAuthConfig{},
 	}
 
 	result, err := service.refreshGoogleToken(ctx, userID, refreshToken, timeNow)
@@ -1397,3 +1389,83 @@ func newAuthServiceWithTokenOrStoreError(authConfig AuthConfig) *AuthServiceImpl
 		redisClient: &FakeRedis{},
 	}
 }
+
+// TestAuthServiceImpl_SignIn_Locked tests that a locked out account is rejected before the user is looked up.
+func TestAuthServiceImpl_SignIn_Locked(t *testing.T) {
+	rdb, rmock := redismock.NewClientMock()
+	rmock.ExpectTTL("signin_block:account:user@example.com").SetVal(5 * time.Minute)
+	mockDB := &MockDBQueries{
+		GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+			t.Fatal("user should not be looked up while locked out")
+			return database.User{}, nil
+		},
+	}
+	service := &AuthServiceImpl{db: mockDB, limiter: NewSignInLimiter(rdb, nil, testLimitConfig)}
+
+	result, err := service.SignIn(context.Background(), SignInParams{Email: "user@example.com", Password: testPassword, IP: "1.2.3.4"})
+	require.Nil(t, result)
+	var appErr *handlers.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "signin_locked", appErr.Code)
+}
+
+// TestAuthServiceImpl_SignIn_RecordsFailure tests that a wrong password is counted against the account and IP.
+func TestAuthServiceImpl_SignIn_RecordsFailure(t *testing.T) {
+	hash, _ := auth.HashPassword(testPassword)
+	rdb, rmock := redismock.NewClientMock()
+	rmock.ExpectTTL("signin_block:account:user@example.com").SetVal(-2)
+	rmock.ExpectTTL("signin_block:ip:1.2.3.4").SetVal(-2)
+	expectFailureCount(rmock, "signin_fail:account:user@example.com", 1)
+	rmock.ExpectSet("signin_block:account:user@example.com", "delay", time.Second).SetVal("OK")
+	expectFailureCount(rmock, "signin_fail:ip:1.2.3.4", 4)
+	rmock.ExpectSet("signin_block:ip:1.2.3.4", "delay", 8*time.Second).SetVal("OK")
+	mockDB := &MockDBQueries{
+		GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+			return database.User{ID: testUUID, Password: sql.NullString{String: hash, Valid: true}}, nil
+		},
+	}
+	service := &AuthServiceImpl{db: mockDB, limiter: NewSignInLimiter(rdb, nil, testLimitConfig)}
+
+	_, err := service.SignIn(context.Background(), SignInParams{Email: "user@example.com", Password: "wrongpassword", IP: "1.2.3.4"})
+	require.ErrorContains(t, err, "Invalid credentials")
+	assert.NoError(t, rmock.ExpectationsWereMet())
+}
+
+// TestAuthServiceImpl_UnlockAccount tests unlocking known and unknown accounts, and that unlocking needs the limiter.
+func TestAuthServiceImpl_UnlockAccount(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		rdb, rmock := redismock.NewClientMock()
+		rmock.ExpectDel("signin_fail:account:user@example.com", "signin_block:account:user@example.com").SetVal(1)
+		mockDB := &MockDBQueries{
+			GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+				return database.User{ID: testUUID}, nil
+			},
+		}
+		service := &AuthServiceImpl{db: mockDB, limiter: NewSignInLimiter(rdb, nil, testLimitConfig)}
+
+		require.NoError(t, service.UnlockAccount(context.Background(), "admin1", "user@example.com"))
+		assert.NoError(t, rmock.ExpectationsWereMet())
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockDB := &MockDBQueries{
+			GetUserByEmailFunc: func(_ context.Context, _ string) (database.User, error) {
+				return database.User{}, sql.ErrNoRows
+			},
+		}
+		service := &AuthServiceImpl{db: mockDB, limiter: NewSignInLimiter(nil, nil, testLimitConfig)}
+
+		err := service.UnlockAccount(context.Background(), "admin1", "nobody@example.com")
+		var appErr *handlers.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "account_not_found", appErr.Code)
+	})
+
+	t.Run("limits_disabled", func(t *testing.T) {
+		service := &AuthServiceImpl{}
+		err := service.UnlockAccount(context.Background(), "admin1", "user@example.com")
+		var appErr *handlers.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "invalid_request", appErr.Code)
+	})
+}
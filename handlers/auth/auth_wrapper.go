@@ -2,13 +2,18 @@
 package authhandlers
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"sync"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	carthandlers "github.com/STaninnat/ecom-backend/handlers/cart"
 	userhandlers "github.com/STaninnat/ecom-backend/handlers/user"
+	"github.com/STaninnat/ecom-backend/internal/mailer"
+	"github.com/STaninnat/ecom-backend/middlewares"
 )
 
 // auth_wrapper.go: Provides configuration and initialization logic for auth handlers, including service setup and error handling.
@@ -44,6 +49,11 @@ func (cfg *HandlersAuthConfig) InitAuthService() error {
 		return errors.New("redis client not initialized")
 	}
 
+	accountEmail, err := cfg.newAccountEmailConfig()
+	if err != nil {
+		return err
+	}
+
 	cfg.authMutex.Lock()
 	defer cfg.authMutex.Unlock()
 
@@ -53,6 +63,9 @@ func (cfg *HandlersAuthConfig) InitAuthService() error {
 		&AuthConfigAdapter{cfg.Auth},
 		cfg.RedisClient,
 		cfg.OAuth.Google,
+		cfg.newSignInLimiter(),
+		accountEmail,
+		cfg.DB,
 	)
 
 	// Set Logger if not already set
@@ -82,14 +95,19 @@ func (cfg *HandlersAuthConfig) GetAuthService() AuthService {
 		// Validate that the embedded config is not nil before accessing its fields
 		if cfg.Config == nil || cfg.APIConfig == nil || cfg.DB == nil {
 			// Return a default service that will fail gracefully when used
-			cfg.authService = NewAuthService(nil, nil, nil, nil, nil)
+			cfg.authService = NewAuthService(nil, nil, nil, nil, nil, nil, AccountEmailConfig{}, nil)
 		} else {
+			// The mail config is validated when it is loaded; if it is still invalid, account emails are turned off
+			accountEmail, _ := cfg.newAccountEmailConfig()
 			cfg.authService = NewAuthService(
 				&DBQueriesAdapter{cfg.DB},
 				&DBConnAdapter{cfg.DBConn},
 				&AuthConfigAdapter{cfg.Auth},
 				cfg.RedisClient,
 				cfg.OAuth.Google,
+				cfg.newSignInLimiter(),
+				accountEmail,
+				cfg.DB,
 			)
 		}
 	}
@@ -97,6 +115,55 @@ func (cfg *HandlersAuthConfig) GetAuthService() AuthService {
 	return cfg.authService
 }
 
+// SendEmailChangeVerification mails a verification link to a user's changed email through the auth service,
+// so other handlers can verify emails without creating the service before it is first used.
+func (cfg *HandlersAuthConfig) SendEmailChangeVerification(ctx context.Context, userID, email string) {
+	cfg.GetAuthService().SendEmailChangeVerification(ctx, userID, email)
+}
+
+// newSignInLimiter builds the failed sign-in limiter from the API config, with lockouts logged to the database.
+// Returns nil, turning the limits off, when Redis is not configured.
+func (cfg *HandlersAuthConfig) newSignInLimiter() *SignInLimiter {
+	if cfg.RedisClient == nil {
+		return nil
+	}
+	return NewSignInLimiter(cfg.RedisClient, cfg.DB, SignInLimitConfig{
+		MaxAttempts:   cfg.SignInMaxAttempts,
+		IPMaxAttempts: cfg.SignInIPMaxAttempts,
+		Lockout:       cfg.SignInLockout,
+		MaxDelay:      cfg.SignInMaxDelay,
+	})
+}
+
+// signInIP returns the IP that failed sign-ins are limited by: the connecting address, or the client address
+// reported by one of the configured trusted proxies. Forwarding headers from anyone else are ignored, so clients
+// cannot dodge the per-IP limit by rotating them.
+func (cfg *HandlersAuthConfig) signInIP(r *http.Request) string {
+	var trusted []*net.IPNet
+	if cfg.Config != nil && cfg.APIConfig != nil {
+		trusted = cfg.SignInTrustedProxies
+	}
+	return middlewares.GetTrustedClientIP(r, trusted)
+}
+
+// newAccountEmailConfig builds the email verification and password reset config from the API config.
+// Returns a config that turns both flows off when Redis is not configured.
+func (cfg *HandlersAuthConfig) newAccountEmailConfig() (AccountEmailConfig, error) {
+	if cfg.RedisClient == nil {
+		return AccountEmailConfig{}, nil
+	}
+	m, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return AccountEmailConfig{}, fmt.Errorf("failed to create mailer: %w", err)
+	}
+	return AccountEmailConfig{
+		Mailer:              m,
+		Tokens:              NewAccountTokens(cfg.RedisClient),
+		BaseURL:             cfg.AppBaseURL,
+		RequireVerification: cfg.EmailVerificationRequired,
+	}, nil
+}
+
 // handleAuthError handles authentication-specific errors with proper logging and responses.
 // Categorizes errors and provides appropriate HTTP status codes and messages.
 func (cfg *HandlersAuthConfig) handleAuthError(w http.ResponseWriter, r *http.Request, err error, operation, ip, userAgent string) {
@@ -119,6 +186,15 @@ func (cfg *HandlersAuthConfig) handleAuthError(w http.ResponseWriter, r *http.Re
 		"google_api_error":       {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
 		"no_refresh_token":       {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
 		"google_token_error":     {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"signin_locked":          {Status: http.StatusTooManyRequests, Message: "", UseAppErr: true},
+		"account_not_found":      {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"invalid_request":        {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"invalid_token":          {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"email_not_verified":     {Status: http.StatusForbidden, Message: "", UseAppErr: true},
+		"mail_error":             {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
+		"invalid_session":        {Status: http.StatusUnauthorized, Message: "", UseAppErr: true},
+		"session_not_found":      {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"refresh_token_reused":   {Status: http.StatusUnauthorized, Message: "", UseAppErr: true},
 	}
 	userhandlers.HandleErrorWithCodeMap(cfg.Logger, w, r, err, operation, ip, userAgent, codeMap, http.StatusInternalServerError, "Internal server error")
 }
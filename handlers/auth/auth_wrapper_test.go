@@ -45,6 +45,27 @@ func TestInitAuthService_Success(t *testing.T) {
 	assert.NotNil(t, cfg.authService)
 }
 
+// TestNewAccountEmailConfig tests that account emails need Redis and a usable mail backend.
+func TestNewAccountEmailConfig(t *testing.T) {
+	apiCfg := &config.APIConfig{AppBaseURL: "https://shop.example.com", EmailVerificationRequired: true}
+	cfg := &HandlersAuthConfig{Config: &handlers.Config{APIConfig: apiCfg}}
+
+	emailCfg, err := cfg.newAccountEmailConfig()
+	require.NoError(t, err)
+	assert.False(t, emailCfg.enabled())
+
+	apiCfg.RedisClient, _ = redismock.NewClientMock()
+	emailCfg, err = cfg.newAccountEmailConfig()
+	require.NoError(t, err)
+	assert.True(t, emailCfg.enabled())
+	assert.True(t, emailCfg.RequireVerification)
+	assert.Equal(t, "https://shop.example.com", emailCfg.BaseURL)
+
+	apiCfg.Mail.Backend = "pigeon"
+	_, err = cfg.newAccountEmailConfig()
+	require.Error(t, err)
+}
+
 // TestInitAuthService_MissingDB checks that initialization fails gracefully when the database is missing.
 func TestInitAuthService_MissingDB(t *testing.T) {
 	cfg := &HandlersAuthConfig{
@@ -230,6 +251,10 @@ func TestHandleAuthError_AllErrorCodes(t *testing.T) {
 			{"OAuthBadRequest_google_api_error", "google_api_error", http.StatusBadRequest, "Test error"},
 			{"OAuthBadRequest_no_refresh_token", "no_refresh_token", http.StatusBadRequest, "Test error"},
 			{"OAuthBadRequest_google_token_error", "google_token_error", http.StatusBadRequest, "Test error"},
+			// Account email codes
+			{"AccountEmail_invalid_token", "invalid_token", http.StatusBadRequest, "Test error"},
+			{"AccountEmail_email_not_verified", "email_not_verified", http.StatusForbidden, "Test error"},
+			{"AccountEmail_mail_error", "mail_error", http.StatusInternalServerError, "Something went wrong, please try again later"},
 		}
 
 		for _, tc := range testCases {
@@ -0,0 +1,125 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"net/http"
+
+	"github.com/STaninnat/ecom-backend/auth"
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/middlewares"
+)
+
+// handler_account_email.go: Provides HTTP handlers for email verification and password reset.
+
+// VerifyEmailRequest represents the payload for verifying an email address.
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ForgotPasswordRequest represents the payload for requesting a password reset.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents the payload for resetting a password.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// HandlerVerifyEmail handles email verification requests.
+// @Summary      Verify email
+// @Description  Verifies the user's email address with the token from the verification email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        verify  body  VerifyEmailRequest  true  "Verification token"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/auth/verify-email [post]
+func (cfg *HandlersAuthConfig) HandlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	params, err := auth.DecodeAndValidate[VerifyEmailRequest](w, r)
+	if err != nil {
+		cfg.Logger.LogHandlerError(ctx, "verify_email", "invalid_request", "Invalid verify email payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := cfg.GetAuthService().VerifyEmail(ctx, params.Token); err != nil {
+		cfg.handleAuthError(w, r, err, "verify_email", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "verify_email", "Email verified", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Email verified",
+	})
+}
+
+// HandlerForgotPassword handles password reset requests.
+// Responds the same whether or not an account has the email.
+// @Summary      Forgot password
+// @Description  Emails a password reset link if an account has the given email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        forgot  body  ForgotPasswordRequest  true  "Account email"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/auth/forgot-password [post]
+func (cfg *HandlersAuthConfig) HandlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	params, err := auth.DecodeAndValidate[ForgotPasswordRequest](w, r)
+	if err != nil {
+		cfg.Logger.LogHandlerError(ctx, "forgot_password", "invalid_request", "Invalid forgot password payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := cfg.GetAuthService().ForgotPassword(ctx, params.Email); err != nil {
+		cfg.handleAuthError(w, r, err, "forgot_password", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "forgot_password", "Password reset requested", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "If an account uses this email, a password reset link has been sent",
+	})
+}
+
+// HandlerResetPassword handles password changes with a reset token. Every session of the user is signed out.
+// @Summary      Reset password
+// @Description  Sets a new password with the token from the password reset email and signs out all sessions
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        reset  body  ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/auth/reset-password [post]
+func (cfg *HandlersAuthConfig) HandlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	params, err := auth.DecodeAndValidate[ResetPasswordRequest](w, r)
+	if err != nil {
+		cfg.Logger.LogHandlerError(ctx, "reset_password", "invalid_request", "Invalid reset password payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := cfg.GetAuthService().ResetPassword(ctx, params.Token, params.Password); err != nil {
+		cfg.handleAuthError(w, r, err, "reset_password", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "reset_password", "Password reset", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Password reset, please sign in with your new password",
+	})
+}
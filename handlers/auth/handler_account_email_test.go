@@ -0,0 +1,153 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	carthandlers "github.com/STaninnat/ecom-backend/handlers/cart"
+)
+
+// handler_account_email_test.go: Tests for the email verification and password reset handlers.
+
+// newAccountEmailTestConfig returns a HandlersAuthConfig with a mocked service and logger.
+func newAccountEmailTestConfig(operation string) (*HandlersAuthConfig, *MockAuthService) {
+	mockAuthService := new(MockAuthService)
+	mockLogger := new(MockHandlersConfig)
+	mockLogger.On("LogHandlerSuccess", mock.Anything, operation, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mockLogger.On("LogHandlerError", mock.Anything, operation, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	return &HandlersAuthConfig{Config: &handlers.Config{}, Logger: mockLogger, authService: mockAuthService}, mockAuthService
+}
+
+// TestHandlerVerifyEmail tests verification, bad payloads and invalid tokens.
+func TestHandlerVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		callsSvc   bool
+		wantCode   int
+	}{
+		{name: "success", body: `{"token":"tok"}`, callsSvc: true, wantCode: http.StatusOK},
+		{name: "missing_token", body: `{}`, wantCode: http.StatusBadRequest},
+		{name: "invalid_token", body: `{"token":"tok"}`, callsSvc: true, serviceErr: &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockAuthService := newAccountEmailTestConfig("verify_email")
+			if tt.callsSvc {
+				mockAuthService.On("VerifyEmail", mock.Anything, "tok").Return(tt.serviceErr)
+			}
+
+			w := httptest.NewRecorder()
+			cfg.HandlerVerifyEmail(w, httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerForgotPassword tests that valid requests get the same response and bad emails are rejected.
+func TestHandlerForgotPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		callsSvc bool
+		wantCode int
+	}{
+		{name: "success", body: `{"email":"user@example.com"}`, callsSvc: true, wantCode: http.StatusOK},
+		{name: "invalid_email", body: `{"email":"not-an-email"}`, wantCode: http.StatusBadRequest},
+		{name: "unknown_field", body: `{"email":"user@example.com","name":"x"}`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockAuthService := newAccountEmailTestConfig("forgot_password")
+			if tt.callsSvc {
+				mockAuthService.On("ForgotPassword", mock.Anything, "user@example.com").Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			cfg.HandlerForgotPassword(w, httptest.NewRequest(http.MethodPost, "/forgot-password", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.callsSvc {
+				var resp handlers.HandlerResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Contains(t, resp.Message, "If an account uses this email")
+			}
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerResetPassword tests resets, bad payloads and service errors.
+func TestHandlerResetPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		callsSvc   bool
+		wantCode   int
+	}{
+		{name: "success", body: `{"token":"tok","password":"new-password"}`, callsSvc: true, wantCode: http.StatusOK},
+		{name: "missing_password", body: `{"token":"tok"}`, wantCode: http.StatusBadRequest},
+		{name: "invalid_token", body: `{"token":"tok","password":"new-password"}`, callsSvc: true, serviceErr: &handlers.AppError{Code: "invalid_token", Message: "Invalid or expired token"}, wantCode: http.StatusBadRequest},
+		{name: "redis_error", body: `{"token":"tok","password":"new-password"}`, callsSvc: true, serviceErr: &handlers.AppError{Code: "redis_error", Message: "Error revoking refresh tokens"}, wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockAuthService := newAccountEmailTestConfig("reset_password")
+			if tt.callsSvc {
+				mockAuthService.On("ResetPassword", mock.Anything, "tok", "new-password").Return(tt.serviceErr)
+			}
+
+			w := httptest.NewRecorder()
+			cfg.HandlerResetPassword(w, httptest.NewRequest(http.MethodPost, "/reset-password", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerSignUp_VerificationPending tests that a signup waiting for email verification sets no cookies.
+func TestHandlerSignUp_VerificationPending(t *testing.T) {
+	cfg, mockAuthService := newAccountEmailTestConfig("signup-local")
+	cfg.HandlersCartConfig = &carthandlers.HandlersCartConfig{}
+	mockAuthService.On("SignUp", mock.Anything, SignUpParams{Name: "Test User", Email: "test@example.com", Password: "password123"}).
+		Return(&AuthResult{UserID: "user123", IsNewUser: true, VerificationPending: true}, nil)
+
+	w := httptest.NewRecorder()
+	body := `{"name":"Test User","email":"test@example.com","password":"password123"}`
+	cfg.HandlerSignUp(w, httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	var resp handlers.HandlerResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "Signup successful, check your email to verify your account", resp.Message)
+}
+
+// TestHandlerSignIn_EmailNotVerified tests that an unverified account gets 403.
+func TestHandlerSignIn_EmailNotVerified(t *testing.T) {
+	cfg, mockAuthService := newAccountEmailTestConfig("signin-local")
+	mockAuthService.On("SignIn", mock.Anything, SignInParams{Email: "test@example.com", Password: "password123", IP: "192.0.2.1"}).
+		Return(nil, &handlers.AppError{Code: "email_not_verified", Message: "Verify your email address first; we sent you a new link"})
+
+	w := httptest.NewRecorder()
+	cfg.HandlerSignIn(w, httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"email":"test@example.com","password":"password123"}`)))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,53 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_admin_unlock.go: Lets admins clear a failed sign-in lockout.
+
+// UnlockAccountRequest represents the payload for unlocking an account.
+type UnlockAccountRequest struct {
+	Email string `json:"email"`
+}
+
+// HandlerAdminUnlockAccount handles HTTP POST requests to clear an account's failed sign-in lockout (admin only).
+// @Summary      Unlock account
+// @Description  Clears the failed sign-in count and lockout of an account (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        unlock  body  UnlockAccountRequest  true  "Account to unlock"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/admin/user/unlock [post]
+func (cfg *HandlersAuthConfig) HandlerAdminUnlockAccount(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := context.WithValue(r.Context(), utils.ContextKeyUserID, user.ID)
+
+	var req UnlockAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		cfg.Logger.LogHandlerError(ctx, "admin_unlock_account", "invalid_request", "Invalid request payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := cfg.GetAuthService().UnlockAccount(ctx, user.ID, req.Email); err != nil {
+		cfg.handleAuthError(w, r.WithContext(ctx), err, "admin_unlock_account", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "admin_unlock_account", "Account unlocked", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Account unlocked",
+	})
+}
@@ -0,0 +1,52 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_admin_unlock_test.go: Tests for the admin account unlock handler.
+
+// TestHandlerAdminUnlockAccount tests unlocking, bad payloads and service errors.
+func TestHandlerAdminUnlockAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "success", body: `{"email":"user@example.com"}`, wantCode: http.StatusOK},
+		{name: "missing_email", body: `{}`, wantCode: http.StatusBadRequest},
+		{name: "invalid_json", body: `{bad`, wantCode: http.StatusBadRequest},
+		{name: "not_found", body: `{"email":"user@example.com"}`, serviceErr: &handlers.AppError{Code: "account_not_found", Message: "Account not found"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			mockLogger := new(MockHandlersConfig)
+			cfg := &HandlersAuthConfig{Config: &handlers.Config{}, Logger: mockLogger, authService: mockAuthService}
+			if tt.wantCode == http.StatusOK || tt.serviceErr != nil {
+				mockAuthService.On("UnlockAccount", mock.Anything, "admin1", "user@example.com").Return(tt.serviceErr)
+			}
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "admin_unlock_account", "Account unlocked", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "admin_unlock_account", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/admin/user/unlock", strings.NewReader(tt.body))
+			cfg.HandlerAdminUnlockAccount(w, r, database.User{ID: "admin1", Role: "admin"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
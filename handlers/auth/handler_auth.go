@@ -60,7 +60,7 @@ func (cfg *HandlersAuthConfig) HandlerSignUp(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Call business logic service
-	result, err := cfg.GetAuthService().SignUp(ctx, SignUpParams{
+	result, err := cfg.GetAuthService().SignUp(withHTTPRequest(r), SignUpParams{
 		Name:     params.Name,
 		Email:    params.Email,
 		Password: params.Password,
@@ -71,6 +71,17 @@ func (cfg *HandlersAuthConfig) HandlerSignUp(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, result.UserID)
+
+	// No session until the email is verified
+	if result.VerificationPending {
+		cfg.Logger.LogHandlerSuccess(ctxWithUserID, "signup-local", "Local signup success, email verification pending", ip, userAgent)
+		middlewares.RespondWithJSON(w, http.StatusCreated, handlers.HandlerResponse{
+			Message: "Signup successful, check your email to verify your account",
+		})
+		return
+	}
+
 	// Merge cart if needed
 	cfg.MergeCart(ctx, r, result.UserID)
 
@@ -78,7 +89,6 @@ func (cfg *HandlersAuthConfig) HandlerSignUp(w http.ResponseWriter, r *http.Requ
 	auth.SetTokensAsCookies(w, result.AccessToken, result.RefreshToken, result.AccessTokenExpires, result.RefreshTokenExpires)
 
 	// Log success
-	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, result.UserID)
 	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "signup-local", "Local signup success", ip, userAgent)
 
 	// Respond
@@ -96,6 +106,8 @@ func (cfg *HandlersAuthConfig) HandlerSignUp(w http.ResponseWriter, r *http.Requ
 // @Param        signin  body  SigninRequest  true  "Signin payload"
 // @Success      200  {object}  handlers.HandlerResponse
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
 // @Router       /v1/auth/signin [post]
 func (cfg *HandlersAuthConfig) HandlerSignIn(w http.ResponseWriter, r *http.Request) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
@@ -119,9 +131,10 @@ func (cfg *HandlersAuthConfig) HandlerSignIn(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Call business logic service
-	result, err := cfg.GetAuthService().SignIn(ctx, SignInParams{
+	result, err := cfg.GetAuthService().SignIn(withHTTPRequest(r), SignInParams{
 		Email:    params.Email,
 		Password: params.Password,
+		IP:       cfg.signInIP(r),
 	})
 
 	if err != nil {
@@ -147,7 +160,7 @@ func (cfg *HandlersAuthConfig) HandlerSignIn(w http.ResponseWriter, r *http.Requ
 
 // HandlerSignOut handles user logout requests.
 // @Summary      User signout
-// @Description  Logs out the user and revokes tokens
+// @Description  Logs out the current device and revokes its tokens; other devices stay signed in
 // @Tags         auth
 // @Produce      json
 // @Success      200  {object}  handlers.HandlerResponse
@@ -159,6 +172,9 @@ func (cfg *HandlersAuthConfig) HandlerSignOut(w http.ResponseWriter, r *http.Req
 
 	// Get user info from token
 	userID, storedData, err := cfg.Auth.ValidateCookieRefreshTokenData(w, r)
+	if cfg.handleRefreshTokenReuse(w, r, err, "sign_out", ip, userAgent) {
+		return
+	}
 	if err != nil {
 		cfg.Logger.LogHandlerError(
 			ctx,
@@ -172,7 +188,7 @@ func (cfg *HandlersAuthConfig) HandlerSignOut(w http.ResponseWriter, r *http.Req
 	}
 
 	// Call business logic service
-	err = cfg.GetAuthService().SignOut(ctx, userID.String(), storedData.Provider)
+	err = cfg.GetAuthService().SignOut(ctx, userID.String(), storedData.SessionID)
 	if err != nil {
 		cfg.handleAuthError(w, r, err, "sign_out", ip, userAgent)
 		return
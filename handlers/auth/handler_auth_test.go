@@ -326,11 +326,13 @@ func TestHandlerSignIn_Success(t *testing.T) {
 	mockAuthService.On("SignIn", mock.Anything, SignInParams{
 		Email:    "test@example.com",
 		Password: "password123",
+		IP:       "192.0.2.1",
 	}).Return(expectedResult, nil)
 	mockHandlersConfig.On("LogHandlerSuccess", mock.Anything, "signin-local", "Local signin success", mock.Anything, mock.Anything).Return()
 
 	req := httptest.NewRequest("POST", "/signin", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9") // ignored: the client is not a trusted proxy
 	w := httptest.NewRecorder()
 
 	cfg.HandlerSignIn(w, req)
@@ -367,6 +369,7 @@ func runHandlerSignInErrorTest(
 	}
 
 	jsonBody, _ := json.Marshal(requestBody)
+	signInParams.IP = "192.0.2.1" // httptest's default client address
 	mockAuthService.On("SignIn", mock.Anything, signInParams).Return(nil, appError)
 	mockHandlersConfig.On("LogHandlerError", mock.Anything, "signin-local", mock.MatchedBy(func(code string) bool {
 		return code == appError.Code || (appError.Code == "unknown_error" && code == "internal_error")
@@ -424,6 +427,18 @@ func TestHandlerSignIn_InvalidPassword(t *testing.T) {
 	)
 }
 
+// TestHandlerSignIn_Locked checks that the handler returns 429 while the account is locked out after failed sign-ins.
+func TestHandlerSignIn_Locked(t *testing.T) {
+	runHandlerSignInErrorTest(
+		t,
+		map[string]string{"email": "test@example.com", "password": "password123"},
+		SignInParams{Email: "test@example.com", Password: "password123"},
+		&handlers.AppError{Code: "signin_locked", Message: "Too many failed sign-in attempts, try again in 900 seconds"},
+		http.StatusTooManyRequests,
+		"Too many failed sign-in attempts, try again in 900 seconds",
+	)
+}
+
 // TestHandlerSignIn_DatabaseError checks that the handler returns a 500 error when a database error occurs during sign-in.
 func TestHandlerSignIn_DatabaseError(t *testing.T) {
 	dbError := errors.New("database connection failed")
@@ -514,8 +529,9 @@ func TestHandlerSignOut_SignOutFailure(t *testing.T) {
 	// Create test data
 	userID := testUserID2
 	storedData := &RefreshTokenData{
-		Token:    "test-refresh-token",
-		Provider: "local",
+		Token:     "test-refresh-token",
+		Provider:  "local",
+		SessionID: "session-1",
 	}
 
 	// Create request
@@ -527,7 +543,7 @@ func TestHandlerSignOut_SignOutFailure(t *testing.T) {
 	mockAuth.On("ValidateCookieRefreshTokenData", mock.Anything, mock.Anything).Return(userID, storedData, nil)
 
 	mockService := cfg.authService.(*MockAuthService)
-	mockService.On("SignOut", mock.Anything, userID, storedData.Provider).Return(errors.New("signout failed"))
+	mockService.On("SignOut", mock.Anything, userID, storedData.SessionID).Return(errors.New("signout failed"))
 
 	cfg.MockHandlersConfig.On("LogHandlerError", mock.Anything, "sign_out", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, mock.Anything).Return()
 
@@ -640,8 +656,9 @@ func TestHandlerSignOut_AppErrorFromService(t *testing.T) {
 		func(cfg *TestHandlersAuthConfig, req *http.Request) {
 			userID := testUserID2
 			storedData := &RefreshTokenData{
-				Token:    "test-refresh-token",
-				Provider: "local",
+				Token:     "test-refresh-token",
+				Provider:  "local",
+				SessionID: "session-1",
 			}
 			mockAuth := cfg.Auth
 			mockAuth.On("ValidateCookieRefreshTokenData", mock.Anything, mock.Anything).Return(userID, storedData, nil)
@@ -650,7 +667,7 @@ func TestHandlerSignOut_AppErrorFromService(t *testing.T) {
 				Message: "Failed to delete refresh token",
 			}
 			mockService := cfg.authService.(*MockAuthService)
-			mockService.On("SignOut", mock.Anything, userID, storedData.Provider).Return(appError)
+			mockService.On("SignOut", mock.Anything, userID, storedData.SessionID).Return(appError)
 			cfg.MockHandlersConfig.On("LogHandlerError", mock.Anything, "sign_out", "redis_error", "Failed to delete refresh token", mock.Anything, mock.Anything, mock.Anything).Return()
 		},
 		http.StatusInternalServerError,
@@ -825,8 +842,9 @@ func runHandlerSignOutProviderTest(
 
 	userID := testUserID2
 	storedData := &RefreshTokenData{
-		Token:    token,
-		Provider: provider,
+		Token:     token,
+		Provider:  provider,
+		SessionID: "session-1",
 	}
 
 	req := httptest.NewRequest("POST", "/signout", nil)
@@ -835,7 +853,7 @@ func runHandlerSignOutProviderTest(
 	mockAuth := cfg.Auth
 	mockAuth.On("ValidateCookieRefreshTokenData", mock.Anything, mock.Anything).Return(userID, storedData, nil)
 	mockService := cfg.authService.(*MockAuthService)
-	mockService.On("SignOut", mock.Anything, userID, provider).Return(nil)
+	mockService.On("SignOut", mock.Anything, userID, storedData.SessionID).Return(nil)
 
 	if provider == "unknown" || provider == "local" {
 		cfg.MockHandlersConfig.On("LogHandlerSuccess", mock.Anything, "sign_out", "Sign out success", mock.Anything, mock.Anything).Return()
@@ -119,6 +119,7 @@ func TestHandlerIntegration(t *testing.T) {
 		mockAuthService.On("SignIn", mock.Anything, SignInParams{
 			Email:    "test@example.com",
 			Password: "password123",
+			IP:       "192.0.2.1",
 		}).Return(expectedResult, nil)
 		mockHandlersConfig.On("LogHandlerSuccess", mock.Anything, "signin-local", "Local signin success", mock.Anything, mock.Anything).Return()
 
@@ -542,7 +543,7 @@ func TestAuthServiceMethods(t *testing.T) {
 		}
 
 		// Execute
-		authService := NewAuthService(nil, nil, &AuthConfigAdapter{authConfig}, nil, nil)
+		authService := NewAuthService(nil, nil, &AuthConfigAdapter{authConfig}, nil, nil, nil, AccountEmailConfig{}, nil)
 
 		// Assertions
 		assert.NotNil(t, authService)
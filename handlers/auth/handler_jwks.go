@@ -0,0 +1,30 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"net/http"
+
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
+	"github.com/STaninnat/ecom-backend/middlewares"
+)
+
+// handler_jwks.go: Publishes the public keys access tokens are verified with.
+
+// HandlerJWKS serves the JSON Web Key Set other services use to verify access tokens, picking the key by the token's kid.
+// The set is empty when tokens are signed with the shared HS256 secret.
+// @Summary      JSON Web Key Set
+// @Description  Lists the public keys that verify access tokens
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  jwtkeys.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (cfg *HandlersAuthConfig) HandlerJWKS(w http.ResponseWriter, _ *http.Request) {
+	jwks := jwtkeys.JWKS{Keys: []jwtkeys.JWK{}}
+	if cfg.Config != nil && cfg.APIConfig != nil && cfg.JWTKeys != nil {
+		jwks = cfg.JWTKeys.JWKS()
+	}
+
+	// Verifiers may cache the set; a rotated-in key is published before it starts signing
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	middlewares.RespondWithJSON(w, http.StatusOK, jwks)
+}
@@ -0,0 +1,43 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/config"
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
+)
+
+// handler_jwks_test.go: Tests for publishing the access token verification keys.
+
+// TestHandlerJWKS tests that the configured keys are published and that the set is empty without them.
+func TestHandlerJWKS(t *testing.T) {
+	priv, err := jwtkeys.Generate(jwtkeys.AlgEdDSA)
+	require.NoError(t, err)
+	keys, err := jwtkeys.NewKeySet("k1", &jwtkeys.Key{ID: "k1", Algorithm: jwtkeys.AlgEdDSA, Private: priv, Public: priv.Public()})
+	require.NoError(t, err)
+
+	cfg := &HandlersAuthConfig{Config: &handlers.Config{APIConfig: &config.APIConfig{JWTKeys: keys}}}
+	w := httptest.NewRecorder()
+	cfg.HandlerJWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Cache-Control"), "max-age")
+	var jwks jwtkeys.JWKS
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "k1", jwks.Keys[0].KeyID)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+
+	w = httptest.NewRecorder()
+	(&HandlersAuthConfig{Config: &handlers.Config{APIConfig: &config.APIConfig{}}}).HandlerJWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+}
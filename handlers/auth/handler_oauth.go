@@ -72,7 +72,7 @@ func (cfg *HandlersAuthConfig) HandlerGoogleCallback(w http.ResponseWriter, r *h
 	}
 
 	// Call business logic service
-	result, err := cfg.GetAuthService().HandleGoogleAuth(ctx, code, state)
+	result, err := cfg.GetAuthService().HandleGoogleAuth(withHTTPRequest(r), code, state)
 	if err != nil {
 		cfg.handleAuthError(w, r, err, "callback-google", ip, userAgent)
 		return
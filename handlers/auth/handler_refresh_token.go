@@ -3,7 +3,9 @@ package authhandlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/STaninnat/ecom-backend/auth"
 	"github.com/STaninnat/ecom-backend/handlers"
@@ -27,6 +29,9 @@ func (cfg *HandlersAuthConfig) HandlerRefreshToken(w http.ResponseWriter, r *htt
 
 	// Get user info from token
 	userID, storedData, err := cfg.Auth.ValidateCookieRefreshTokenData(w, r)
+	if cfg.handleRefreshTokenReuse(w, r, err, "refresh_token", ip, userAgent) {
+		return
+	}
 	if err != nil {
 		cfg.Logger.LogHandlerError(
 			ctx,
@@ -40,7 +45,7 @@ func (cfg *HandlersAuthConfig) HandlerRefreshToken(w http.ResponseWriter, r *htt
 	}
 
 	// Call business logic service
-	result, err := cfg.GetAuthService().RefreshToken(ctx, userID.String(), storedData.Provider, storedData.Token)
+	result, err := cfg.GetAuthService().RefreshToken(withHTTPRequest(r), userID.String(), storedData.Provider, storedData.Token)
 	if err != nil {
 		cfg.handleAuthError(w, r, err, "refresh_token", ip, userAgent)
 		return
@@ -58,3 +63,21 @@ func (cfg *HandlersAuthConfig) HandlerRefreshToken(w http.ResponseWriter, r *htt
 		Message: "Token refreshed successfully",
 	})
 }
+
+// handleRefreshTokenReuse responds to a cookie holding an already rotated refresh token and reports whether it did.
+// The token's session was revoked when the reuse was detected, so the cookies are cleared as well.
+func (cfg *HandlersAuthConfig) handleRefreshTokenReuse(w http.ResponseWriter, r *http.Request, err error, operation, ip, userAgent string) bool {
+	var reuse *auth.RefreshTokenReuseError
+	if !errors.As(err, &reuse) {
+		return false
+	}
+
+	cfg.GetAuthService().ReportRefreshTokenReuse(r.Context(), reuse.Session, ip)
+	ctxWithUserID := context.WithValue(r.Context(), utils.ContextKeyUserID, reuse.Session.UserID)
+	cfg.Logger.LogHandlerError(ctxWithUserID, operation, "refresh_token_reused", "Rotated refresh token was reused", ip, userAgent, err)
+
+	expiredTime := time.Now().UTC().Add(-1 * time.Hour)
+	auth.SetTokensAsCookies(w, "", "", expiredTime, expiredTime)
+	middlewares.RespondWithError(w, http.StatusUnauthorized, "Refresh token was already used, please sign in again")
+	return true
+}
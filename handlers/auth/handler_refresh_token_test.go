@@ -2,6 +2,7 @@
 package authhandlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/auth"
 	"github.com/STaninnat/ecom-backend/handlers"
@@ -254,3 +256,39 @@ func TestRealHandlerRefreshToken_Direct(t *testing.T) {
 		})
 	}
 }
+
+// TestRealHandlerRefreshToken_ReusedToken tests that a rotated refresh token presented again is reported,
+// its cookies are cleared and the request is refused, on refresh and on sign out.
+func TestRealHandlerRefreshToken_ReusedToken(t *testing.T) {
+	for _, op := range []string{"refresh_token", "sign_out"} {
+		t.Run(op, func(t *testing.T) {
+			cfg, mockAuthService, redisMock := newSessionsTestConfig(op)
+			token, err := cfg.Auth.GenerateRefreshToken(testUUID)
+			require.NoError(t, err)
+
+			rotated := auth.RefreshTokenData{Token: token, Provider: LocalProvider, SessionID: "s1", UserID: testUUID}
+			snapshot, _ := json.Marshal(rotated)
+			redisMock.ExpectGet(auth.RedisRefreshTokenLookupPrefix + token).RedisNil()
+			redisMock.ExpectGet(auth.RedisRotatedRefreshTokenPrefix + token).SetVal(string(snapshot))
+			// The session was already revoked by an earlier replay
+			redisMock.ExpectGet(auth.RedisRefreshSessionPrefix + "s1").RedisNil()
+			mockAuthService.On("ReportRefreshTokenReuse", mock.Anything, rotated, "192.0.2.1").Return()
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/auth/"+op, nil)
+			r.AddCookie(&http.Cookie{Name: "refresh_token", Value: token})
+			w := httptest.NewRecorder()
+			if op == "refresh_token" {
+				cfg.HandlerRefreshToken(w, r)
+			} else {
+				cfg.HandlerSignOut(w, r)
+			}
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.NotEmpty(t, w.Result().Cookies())
+			mockAuthService.AssertExpectations(t)
+			mockAuthService.AssertNotCalled(t, "RefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockAuthService.AssertNotCalled(t, "SignOut", mock.Anything, mock.Anything, mock.Anything)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/auth"
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_sessions.go: Provides HTTP handlers for listing and revoking the devices a user is signed in on.
+
+// SessionResponse describes one signed-in device. The refresh token is never included.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Provider   string    `json:"provider"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current marks the session of the device making the request
+	Current bool `json:"current"`
+}
+
+// RevokeSessionsResponse reports how many sessions were signed out.
+type RevokeSessionsResponse struct {
+	Message string `json:"message"`
+	Revoked int    `json:"revoked"`
+}
+
+// HandlerListSessions handles requests to list the user's signed-in devices.
+// @Summary      List sessions
+// @Description  Lists the devices the user is signed in on, most recently used first
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   SessionResponse
+// @Failure      401  {object}  map[string]string
+// @Router       /v1/auth/sessions [get]
+func (cfg *HandlersAuthConfig) HandlerListSessions(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := context.WithValue(r.Context(), utils.ContextKeyUserID, user.ID)
+
+	sessions, err := cfg.GetAuthService().ListSessions(ctx, user.ID)
+	if err != nil {
+		cfg.handleAuthError(w, r.WithContext(ctx), err, "list_sessions", ip, userAgent)
+		return
+	}
+
+	currentID := cfg.currentSessionID(w, r, user.ID)
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, SessionResponse{
+			ID:         s.SessionID,
+			Device:     s.Device,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			Provider:   s.Provider,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			Current:    s.SessionID == currentID,
+		})
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "list_sessions", "Listed sessions", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// HandlerRevokeSession handles requests to sign out one of the user's devices.
+// Revoking the current session also clears its cookies.
+// @Summary      Revoke session
+// @Description  Signs out one of the user's devices
+// @Tags         auth
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/auth/sessions/{id} [delete]
+func (cfg *HandlersAuthConfig) HandlerRevokeSession(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := context.WithValue(r.Context(), utils.ContextKeyUserID, user.ID)
+
+	sessionID := chi.URLParam(r, "id")
+	if sessionID == "" {
+		cfg.Logger.LogHandlerError(ctx, "revoke_session", "missing_session_id", "Session ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Session ID is required")
+		return
+	}
+
+	if err := cfg.GetAuthService().RevokeSession(ctx, user.ID, sessionID); err != nil {
+		cfg.handleAuthError(w, r.WithContext(ctx), err, "revoke_session", ip, userAgent)
+		return
+	}
+
+	if sessionID == cfg.currentSessionID(w, r, user.ID) {
+		expiredTime := time.Now().UTC().Add(-1 * time.Hour)
+		auth.SetTokensAsCookies(w, "", "", expiredTime, expiredTime)
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "revoke_session", "Session revoked", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Session revoked",
+	})
+}
+
+// HandlerRevokeOtherSessions handles requests to sign out every device except the current one.
+// @Summary      Revoke other sessions
+// @Description  Signs out every device of the user except the one making the request
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  RevokeSessionsResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /v1/auth/sessions/revoke-others [post]
+func (cfg *HandlersAuthConfig) HandlerRevokeOtherSessions(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := context.WithValue(r.Context(), utils.ContextKeyUserID, user.ID)
+
+	currentID := cfg.currentSessionID(w, r, user.ID)
+	if currentID == "" {
+		cfg.Logger.LogHandlerError(ctx, "revoke_other_sessions", "missing_session", "No current session", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "No current session; sign in again")
+		return
+	}
+
+	revoked, err := cfg.GetAuthService().RevokeOtherSessions(ctx, user.ID, currentID)
+	if err != nil {
+		cfg.handleAuthError(w, r.WithContext(ctx), err, "revoke_other_sessions", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "revoke_other_sessions", "Other sessions revoked", ip, userAgent)
+	middlewares.RespondWithJSON(w, http.StatusOK, RevokeSessionsResponse{
+		Message: "Other sessions revoked",
+		Revoked: revoked,
+	})
+}
+
+// currentSessionID returns the ID of the session in the request's refresh token cookie,
+// or "" if there is none or it belongs to someone other than the user.
+func (cfg *HandlersAuthConfig) currentSessionID(w http.ResponseWriter, r *http.Request, userID string) string {
+	if cfg.Config == nil || cfg.Auth == nil {
+		return ""
+	}
+	cookieUserID, data, err := cfg.Auth.ValidateCookieRefreshTokenData(w, r)
+	if err != nil || cookieUserID.String() != userID {
+		return ""
+	}
+	return data.SessionID
+}
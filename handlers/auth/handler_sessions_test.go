@@ -0,0 +1,168 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/auth"
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/config"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_sessions_test.go: Tests for the session list and revoke handlers.
+
+// newSessionsTestConfig returns a HandlersAuthConfig with a mocked service and logger, and an auth config
+// whose Redis is mocked so the current session can be read from the refresh token cookie.
+func newSessionsTestConfig(operation string) (*HandlersAuthConfig, *MockAuthService, redismock.ClientMock) {
+	redisClient, redisMock := redismock.NewClientMock()
+	apiCfg := &config.APIConfig{RefreshSecret: "refreshsecretkeyrefreshsecretkey1234", RedisClient: redisClient}
+	mockAuthService := new(MockAuthService)
+	mockLogger := new(MockHandlersConfig)
+	mockLogger.On("LogHandlerSuccess", mock.Anything, operation, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mockLogger.On("LogHandlerError", mock.Anything, operation, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	return &HandlersAuthConfig{
+		Config:      &handlers.Config{APIConfig: apiCfg, Auth: &auth.Config{APIConfig: apiCfg}},
+		Logger:      mockLogger,
+		authService: mockAuthService,
+	}, mockAuthService, redisMock
+}
+
+// addSessionCookie adds a refresh token cookie for the given session and expects its lookup.
+func addSessionCookie(t *testing.T, cfg *HandlersAuthConfig, redisMock redismock.ClientMock, r *http.Request, userID, sessionID string) {
+	t.Helper()
+	token, err := cfg.Auth.GenerateRefreshToken(userID)
+	require.NoError(t, err)
+	r.AddCookie(&http.Cookie{Name: "refresh_token", Value: token})
+
+	stored, _ := json.Marshal(auth.RefreshTokenData{Token: token, Provider: LocalProvider, SessionID: sessionID, UserID: userID})
+	redisMock.ExpectGet(auth.RedisRefreshTokenLookupPrefix + token).SetVal(sessionID)
+	redisMock.ExpectGet(auth.RedisRefreshSessionPrefix + sessionID).SetVal(string(stored))
+}
+
+// TestHandlerListSessions tests that sessions are listed without tokens and the current one is marked.
+func TestHandlerListSessions(t *testing.T) {
+	cfg, mockAuthService, redisMock := newSessionsTestConfig("list_sessions")
+	lastUsed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockAuthService.On("ListSessions", mock.Anything, testUUID).Return([]auth.RefreshTokenData{
+		{SessionID: "s2", UserID: testUUID, Token: "secret-2", Provider: GoogleProvider, Device: "Chrome on Android", LastUsedAt: lastUsed},
+		{SessionID: "s1", UserID: testUUID, Token: "secret-1", Provider: LocalProvider, Device: "Firefox on Linux", IP: "192.0.2.1"},
+	}, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	addSessionCookie(t, cfg, redisMock, r, testUUID, "s1")
+	w := httptest.NewRecorder()
+	cfg.HandlerListSessions(w, r, database.User{ID: testUUID})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret-")
+	var resp []SessionResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 2)
+	assert.Equal(t, "s2", resp[0].ID)
+	assert.False(t, resp[0].Current)
+	assert.True(t, lastUsed.Equal(resp[0].LastUsedAt))
+	assert.Equal(t, "s1", resp[1].ID)
+	assert.True(t, resp[1].Current)
+	assert.Equal(t, "Firefox on Linux", resp[1].Device)
+}
+
+// TestHandlerListSessions_ServiceError tests that service errors are mapped to status codes.
+func TestHandlerListSessions_ServiceError(t *testing.T) {
+	cfg, mockAuthService, _ := newSessionsTestConfig("list_sessions")
+	mockAuthService.On("ListSessions", mock.Anything, testUUID).Return(nil, &handlers.AppError{Code: "redis_error", Message: "Error listing sessions"})
+
+	w := httptest.NewRecorder()
+	cfg.HandlerListSessions(w, httptest.NewRequest(http.MethodGet, "/sessions", nil), database.User{ID: testUUID})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// TestHandlerRevokeSession tests revoking another device, the current device, and unknown sessions.
+func TestHandlerRevokeSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		sessionID   string
+		serviceErr  error
+		current     bool
+		wantCode    int
+		wantCleared bool
+	}{
+		{name: "other_device", sessionID: "s2", current: true, wantCode: http.StatusOK},
+		{name: "current_device", sessionID: "s1", current: true, wantCode: http.StatusOK, wantCleared: true},
+		{name: "not_found", sessionID: "s9", serviceErr: &handlers.AppError{Code: "session_not_found", Message: "Session not found"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockAuthService, redisMock := newSessionsTestConfig("revoke_session")
+			mockAuthService.On("RevokeSession", mock.Anything, testUUID, tt.sessionID).Return(tt.serviceErr)
+
+			r := httptest.NewRequest(http.MethodDelete, "/sessions/"+tt.sessionID, nil)
+			if tt.current {
+				addSessionCookie(t, cfg, redisMock, r, testUUID, "s1")
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.sessionID)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			cfg.HandlerRevokeSession(w, r, database.User{ID: testUUID})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantCleared, len(w.Result().Cookies()) > 0)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerRevokeOtherSessions tests that the current session is kept and required.
+func TestHandlerRevokeOtherSessions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cfg, mockAuthService, redisMock := newSessionsTestConfig("revoke_other_sessions")
+		mockAuthService.On("RevokeOtherSessions", mock.Anything, testUUID, "s1").Return(2, nil)
+
+		r := httptest.NewRequest(http.MethodPost, "/sessions/revoke-others", nil)
+		addSessionCookie(t, cfg, redisMock, r, testUUID, "s1")
+		w := httptest.NewRecorder()
+		cfg.HandlerRevokeOtherSessions(w, r, database.User{ID: testUUID})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp RevokeSessionsResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, 2, resp.Revoked)
+	})
+
+	t.Run("no_current_session", func(t *testing.T) {
+		cfg, mockAuthService, _ := newSessionsTestConfig("revoke_other_sessions")
+
+		w := httptest.NewRecorder()
+		cfg.HandlerRevokeOtherSessions(w, httptest.NewRequest(http.MethodPost, "/sessions/revoke-others", nil), database.User{ID: testUUID})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "RevokeOtherSessions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cookie_of_another_user", func(t *testing.T) {
+		cfg, mockAuthService, redisMock := newSessionsTestConfig("revoke_other_sessions")
+
+		r := httptest.NewRequest(http.MethodPost, "/sessions/revoke-others", nil)
+		addSessionCookie(t, cfg, redisMock, r, "7c9e6679-7425-40de-944b-e07fc1f90ae7", "s1")
+		w := httptest.NewRecorder()
+		cfg.HandlerRevokeOtherSessions(w, r, database.User{ID: testUUID})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockAuthService.AssertNotCalled(t, "RevokeOtherSessions", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
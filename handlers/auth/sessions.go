@@ -0,0 +1,79 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/auth"
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// SecurityEventRefreshTokenReused is recorded when a refresh token that was already rotated is presented again.
+const SecurityEventRefreshTokenReused = "refresh_token_reused"
+
+// sessions.go: Business logic for listing and revoking a user's signed-in devices.
+
+// ListSessions returns the user's active sessions, most recently used first.
+func (s *AuthServiceImpl) ListSessions(ctx context.Context, userID string) ([]auth.RefreshTokenData, error) {
+	sessions, err := s.auth.ListRefreshSessions(ctx, userID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "redis_error", Message: "Error listing sessions", Err: err}
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out one of the user's sessions.
+func (s *AuthServiceImpl) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	err := s.auth.RevokeRefreshSession(ctx, userID, sessionID)
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		return &handlers.AppError{Code: "session_not_found", Message: "Session not found"}
+	}
+	if err != nil {
+		return &handlers.AppError{Code: "redis_error", Message: "Error revoking session", Err: err}
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs out every session of the user except the current one and returns how many were signed out.
+func (s *AuthServiceImpl) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (int, error) {
+	if currentSessionID == "" {
+		return 0, &handlers.AppError{Code: "invalid_request", Message: "Current session is required"}
+	}
+	revoked, err := s.auth.RevokeRefreshSessions(ctx, userID, currentSessionID)
+	if err != nil {
+		return revoked, &handlers.AppError{Code: "redis_error", Message: "Error revoking sessions", Err: err}
+	}
+	return revoked, nil
+}
+
+// ReportRefreshTokenReuse records that a rotated refresh token of the session was presented again.
+// The session is already revoked by then; recording is best-effort so a failed write does not change the response.
+func (s *AuthServiceImpl) ReportRefreshTokenReuse(ctx context.Context, session auth.RefreshTokenData, ip string) {
+	if s.events == nil {
+		return
+	}
+	_ = s.events.CreateSecurityEvent(ctx, database.CreateSecurityEventParams{
+		ID:        utils.NewUUIDString(),
+		EventType: SecurityEventRefreshTokenReused,
+		Subject:   session.UserID,
+		IpAddress: utils.ToNullString(ip),
+		Details:   utils.ToNullString(fmt.Sprintf("rotated refresh token of session %s (%s) reused, session revoked", session.SessionID, session.Device)),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// requestIP returns the client IP of the request carried in the context, or "" if there is none.
+func requestIP(ctx context.Context) string {
+	r, ok := ctx.Value(HTTPRequestKey).(*http.Request)
+	if !ok || r == nil {
+		return ""
+	}
+	ip, _ := handlers.GetRequestMetadata(r)
+	return ip
+}
@@ -0,0 +1,212 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/auth"
+)
+
+// sessions_test.go: Tests for session listing, revocation, and how signout, refresh and password reset use sessions.
+
+// fakeSessionAuth is an AuthConfig whose refresh sessions live in memory.
+type fakeSessionAuth struct {
+	mockServiceAuthConfig
+	sessions map[string]auth.RefreshTokenData
+	rotated  map[string]string
+	err      error
+}
+
+func newFakeSessionAuth(sessions ...auth.RefreshTokenData) *fakeSessionAuth {
+	f := &fakeSessionAuth{sessions: map[string]auth.RefreshTokenData{}, rotated: map[string]string{}}
+	for _, s := range sessions {
+		f.sessions[s.SessionID] = s
+	}
+	return f
+}
+
+func (f *fakeSessionAuth) RotateRefreshToken(_ context.Context, oldToken, newToken string, _ time.Duration) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	for id, s := range f.sessions {
+		if s.Token == oldToken {
+			s.Token = newToken
+			f.sessions[id] = s
+			f.rotated[oldToken] = newToken
+			return newToken, nil
+		}
+	}
+	if successor, ok := f.rotated[oldToken]; ok {
+		return successor, nil
+	}
+	return "", auth.ErrSessionNotFound
+}
+
+func (f *fakeSessionAuth) ListRefreshSessions(_ context.Context, userID string) ([]auth.RefreshTokenData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []auth.RefreshTokenData
+	for _, s := range f.sessions {
+		if s.UserID == userID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSessionAuth) RevokeRefreshSession(_ context.Context, userID, sessionID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	s, ok := f.sessions[sessionID]
+	if !ok || s.UserID != userID {
+		return auth.ErrSessionNotFound
+	}
+	delete(f.sessions, sessionID)
+	return nil
+}
+
+func (f *fakeSessionAuth) RevokeRefreshSessions(_ context.Context, userID, keepSessionID string) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	revoked := 0
+	for id, s := range f.sessions {
+		if s.UserID == userID && id != keepSessionID {
+			delete(f.sessions, id)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// testSessions returns two sessions of testUUID and one of another user.
+func testSessions() []auth.RefreshTokenData {
+	return []auth.RefreshTokenData{
+		{SessionID: "s1", UserID: testUUID, Token: "t1", Provider: LocalProvider},
+		{SessionID: "s2", UserID: testUUID, Token: "t2", Provider: GoogleProvider},
+		{SessionID: "s3", UserID: "other-user", Token: "t3", Provider: LocalProvider},
+	}
+}
+
+// TestAuthServiceImpl_ListSessions tests that only the user's sessions are returned.
+func TestAuthServiceImpl_ListSessions(t *testing.T) {
+	service := &AuthServiceImpl{auth: newFakeSessionAuth(testSessions()...)}
+	sessions, err := service.ListSessions(context.Background(), testUUID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	service.auth = &fakeSessionAuth{err: errors.New("redis down")}
+	_, err = service.ListSessions(context.Background(), testUUID)
+	assertAppErrorCode(t, err, "redis_error")
+}
+
+// TestAuthServiceImpl_RevokeSession tests revoking own sessions and refusing others' sessions.
+func TestAuthServiceImpl_RevokeSession(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	service := &AuthServiceImpl{auth: fake}
+
+	require.NoError(t, service.RevokeSession(context.Background(), testUUID, "s1"))
+	assert.NotContains(t, fake.sessions, "s1")
+
+	assertAppErrorCode(t, service.RevokeSession(context.Background(), testUUID, "s3"), "session_not_found")
+	assert.Contains(t, fake.sessions, "s3")
+
+	fake.err = errors.New("redis down")
+	assertAppErrorCode(t, service.RevokeSession(context.Background(), testUUID, "s2"), "redis_error")
+}
+
+// TestAuthServiceImpl_RevokeOtherSessions tests that the current session and other users' sessions are kept.
+func TestAuthServiceImpl_RevokeOtherSessions(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	service := &AuthServiceImpl{auth: fake}
+
+	revoked, err := service.RevokeOtherSessions(context.Background(), testUUID, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	assert.Contains(t, fake.sessions, "s1")
+	assert.NotContains(t, fake.sessions, "s2")
+	assert.Contains(t, fake.sessions, "s3")
+
+	_, err = service.RevokeOtherSessions(context.Background(), testUUID, "")
+	assertAppErrorCode(t, err, "invalid_request")
+}
+
+// TestAuthServiceImpl_SignOut_RevokesCurrentSessionOnly tests that signing out keeps other devices signed in.
+func TestAuthServiceImpl_SignOut_RevokesCurrentSessionOnly(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	service := &AuthServiceImpl{auth: fake}
+
+	require.NoError(t, service.SignOut(context.Background(), testUUID, "s1"))
+	assert.NotContains(t, fake.sessions, "s1")
+	assert.Contains(t, fake.sessions, "s2")
+
+	// Already gone
+	require.NoError(t, service.SignOut(context.Background(), testUUID, "s1"))
+}
+
+// TestAuthServiceImpl_RefreshToken_RotatesSession tests that a local refresh replaces the token in the same session.
+func TestAuthServiceImpl_RefreshToken_RotatesSession(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	service := &AuthServiceImpl{auth: fake}
+
+	result, err := service.RefreshToken(context.Background(), testUUID, LocalProvider, "t1")
+	require.NoError(t, err)
+	assert.NotEqual(t, "t1", result.RefreshToken)
+	assert.Equal(t, result.RefreshToken, fake.sessions["s1"].Token)
+	assert.Len(t, fake.sessions, 3)
+
+	// The session was revoked on another device in the meantime
+	delete(fake.sessions, "s1")
+	_, err = service.RefreshToken(context.Background(), testUUID, LocalProvider, result.RefreshToken)
+	assertAppErrorCode(t, err, "invalid_session")
+}
+
+// TestAuthServiceImpl_RefreshToken_Concurrent tests that a refresh racing another refresh of the same token gets
+// the token the other refresh issued.
+func TestAuthServiceImpl_RefreshToken_Concurrent(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	service := &AuthServiceImpl{auth: fake}
+
+	first, err := service.RefreshToken(context.Background(), testUUID, LocalProvider, "t1")
+	require.NoError(t, err)
+	second, err := service.RefreshToken(context.Background(), testUUID, LocalProvider, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, first.RefreshToken, fake.sessions["s1"].Token)
+}
+
+// TestAuthServiceImpl_RefreshToken_Reused tests that a refresh racing a reuse detection is refused and reported.
+func TestAuthServiceImpl_RefreshToken_Reused(t *testing.T) {
+	fake := newFakeSessionAuth(testSessions()...)
+	fake.err = &auth.RefreshTokenReuseError{Session: testSessions()[0]}
+	events := &fakeSecurityEvents{}
+	service := &AuthServiceImpl{auth: fake, events: events}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/auth/refresh", nil)
+	_, err := service.RefreshToken(withHTTPRequest(r), testUUID, LocalProvider, "t1")
+	assertAppErrorCode(t, err, "refresh_token_reused")
+	require.Len(t, events.events, 1)
+	assert.Equal(t, SecurityEventRefreshTokenReused, events.events[0].EventType)
+	assert.Equal(t, testUUID, events.events[0].Subject)
+	assert.Equal(t, "192.0.2.1", events.events[0].IpAddress.String)
+	assert.Contains(t, events.events[0].Details.String, "s1")
+}
+
+// TestAuthServiceImpl_ReportRefreshTokenReuse_NoEventStore tests that reporting is skipped without an event store.
+func TestAuthServiceImpl_ReportRefreshTokenReuse_NoEventStore(t *testing.T) {
+	service := &AuthServiceImpl{}
+	assert.NotPanics(t, func() {
+		service.ReportRefreshTokenReuse(context.Background(), testSessions()[0], "192.0.2.1")
+	})
+}
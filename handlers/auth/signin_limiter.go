@@ -0,0 +1,182 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// signin_limiter.go: Counts failed sign-ins per account and per IP in Redis, delaying retries with exponential backoff
+// and locking the account or IP out after too many failures.
+
+const (
+	// SignInFailKeyPrefix is the prefix for failed sign-in counters in Redis.
+	SignInFailKeyPrefix = "signin_fail:"
+	// SignInBlockKeyPrefix is the prefix for sign-in backoff and lockout keys in Redis.
+	SignInBlockKeyPrefix = "signin_block:"
+
+	// signInBaseDelay is the wait after the first failure; it doubles with every further failure.
+	signInBaseDelay = time.Second
+
+	// SecurityEventAccountLocked is recorded when an account is locked out after too many failed sign-ins.
+	SecurityEventAccountLocked = "account_locked"
+	// SecurityEventIPLocked is recorded when an IP is locked out after too many failed sign-ins.
+	SecurityEventIPLocked = "ip_locked"
+	// SecurityEventAccountUnlocked is recorded when an admin unlocks an account.
+	SecurityEventAccountUnlocked = "account_unlocked"
+)
+
+// SignInLimiterRedis defines the Redis operations needed by SignInLimiter.
+type SignInLimiterRedis interface {
+	TxPipeline() redis.Pipeliner
+	TTL(ctx context.Context, key string) *redis.DurationCmd
+	Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// SecurityEventStore records security events.
+type SecurityEventStore interface {
+	CreateSecurityEvent(ctx context.Context, arg database.CreateSecurityEventParams) error
+}
+
+// SignInLimitConfig holds the failed sign-in thresholds.
+type SignInLimitConfig struct {
+	// MaxAttempts is the number of failures for one account before it is locked out.
+	MaxAttempts int
+	// IPMaxAttempts is the number of failures from one IP, across accounts, before the IP is locked out.
+	IPMaxAttempts int
+	// Lockout is how long a lockout lasts. Failures are forgotten once none has occurred for this long.
+	Lockout time.Duration
+	// MaxDelay caps the backoff between failures before a lockout.
+	MaxDelay time.Duration
+}
+
+// SignInLimiter tracks failed sign-ins. Accounts are keyed by normalized email, so unknown emails are
+// limited the same way as real ones and responses do not reveal which accounts exist.
+type SignInLimiter struct {
+	redis  SignInLimiterRedis
+	events SecurityEventStore
+	config SignInLimitConfig
+}
+
+// NewSignInLimiter creates a new SignInLimiter.
+func NewSignInLimiter(redisClient SignInLimiterRedis, events SecurityEventStore, config SignInLimitConfig) *SignInLimiter {
+	return &SignInLimiter{redis: redisClient, events: events, config: config}
+}
+
+// Check returns an AppError with "signin_locked" code while the account or IP is locked out
+// or waiting out a backoff delay, and "redis_error" if the state cannot be read.
+func (l *SignInLimiter) Check(ctx context.Context, email, ip string) error {
+	for _, key := range l.blockKeys(email, ip) {
+		ttl, err := l.redis.TTL(ctx, key).Result()
+		if err != nil {
+			return &handlers.AppError{Code: "redis_error", Message: "Error checking sign-in attempts", Err: err}
+		}
+		if ttl > 0 {
+			return &handlers.AppError{
+				Code:    "signin_locked",
+				Message: fmt.Sprintf("Too many failed sign-in attempts, try again in %d seconds", int(math.Ceil(ttl.Seconds()))),
+			}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed sign-in for the account and the IP, starting a backoff delay or a lockout.
+// Errors are ignored: the sign-in has already failed, and a missed count only makes the limit slightly looser.
+func (l *SignInLimiter) RecordFailure(ctx context.Context, email, ip string) {
+	l.recordFailure(ctx, "account:"+normalizeEmail(email), l.config.MaxAttempts, SecurityEventAccountLocked, normalizeEmail(email), ip)
+	if ip != "" {
+		l.recordFailure(ctx, "ip:"+ip, l.config.IPMaxAttempts, SecurityEventIPLocked, ip, ip)
+	}
+}
+
+// Reset clears the failure count and any backoff or lockout of an account, after a successful sign-in or an unlock.
+func (l *SignInLimiter) Reset(ctx context.Context, email string) error {
+	subject := "account:" + normalizeEmail(email)
+	return l.redis.Del(ctx, SignInFailKeyPrefix+subject, SignInBlockKeyPrefix+subject).Err()
+}
+
+// Unlock clears an account's lockout and records who unlocked it.
+func (l *SignInLimiter) Unlock(ctx context.Context, email, adminID string) error {
+	if err := l.Reset(ctx, email); err != nil {
+		return &handlers.AppError{Code: "redis_error", Message: "Error unlocking account", Err: err}
+	}
+	l.recordEvent(ctx, database.CreateSecurityEventParams{
+		EventType:   SecurityEventAccountUnlocked,
+		Subject:     normalizeEmail(email),
+		ActorUserID: utils.ToNullString(adminID),
+	})
+	return nil
+}
+
+// recordFailure increments one failure counter and blocks the subject for the backoff delay,
+// or for the lockout duration once the limit is reached.
+func (l *SignInLimiter) recordFailure(ctx context.Context, subject string, limit int, lockEvent, eventSubject, ip string) {
+	// Increment and expire in one transaction so a counter can never be left without a TTL
+	pipe := l.redis.TxPipeline()
+	incr := pipe.Incr(ctx, SignInFailKeyPrefix+subject)
+	pipe.Expire(ctx, SignInFailKeyPrefix+subject, l.config.Lockout)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return
+	}
+	failures := incr.Val()
+
+	if limit > 0 && failures >= int64(limit) {
+		// Start over once the lockout ends
+		if err := l.redis.Set(ctx, SignInBlockKeyPrefix+subject, "locked", l.config.Lockout).Err(); err != nil {
+			return
+		}
+		_ = l.redis.Del(ctx, SignInFailKeyPrefix+subject).Err()
+		l.recordEvent(ctx, database.CreateSecurityEventParams{
+			EventType: lockEvent,
+			Subject:   eventSubject,
+			IpAddress: utils.ToNullString(ip),
+			Details:   utils.ToNullString(fmt.Sprintf("%d failed sign-in attempts, locked for %s", failures, l.config.Lockout)),
+		})
+		return
+	}
+	_ = l.redis.Set(ctx, SignInBlockKeyPrefix+subject, "delay", l.backoff(failures)).Err()
+}
+
+// backoff returns the delay after the given number of failures: 1s, 2s, 4s, ... capped at MaxDelay.
+func (l *SignInLimiter) backoff(failures int64) time.Duration {
+	delay := signInBaseDelay << min(failures-1, 30)
+	if l.config.MaxDelay > 0 && delay > l.config.MaxDelay {
+		return l.config.MaxDelay
+	}
+	return delay
+}
+
+// recordEvent writes a security event. Errors are ignored so that a failing event log never blocks sign-ins.
+func (l *SignInLimiter) recordEvent(ctx context.Context, event database.CreateSecurityEventParams) {
+	if l.events == nil {
+		return
+	}
+	event.ID = utils.NewUUIDString()
+	event.CreatedAt = time.Now().UTC()
+	_ = l.events.CreateSecurityEvent(ctx, event)
+}
+
+// blockKeys returns the backoff and lockout keys that apply to a sign-in attempt.
+func (l *SignInLimiter) blockKeys(email, ip string) []string {
+	keys := []string{SignInBlockKeyPrefix + "account:" + normalizeEmail(email)}
+	if ip != "" {
+		keys = append(keys, SignInBlockKeyPrefix+"ip:"+ip)
+	}
+	return keys
+}
+
+// normalizeEmail lowercases and trims an email so that variants of one address share a counter.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,140 @@
+// Package authhandlers implements HTTP handlers for user authentication, including signup, signin, signout, token refresh, and OAuth integration.
+package authhandlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// signin_limiter_test.go: Tests for failed sign-in counting, backoff, lockout and unlock.
+
+const (
+	testLimiterEmail = "user@example.com"
+	testLimiterIP    = "1.2.3.4"
+)
+
+var testLimitConfig = SignInLimitConfig{MaxAttempts: 3, IPMaxAttempts: 10, Lockout: 15 * time.Minute, MaxDelay: 30 * time.Second}
+
+// fakeSecurityEvents records the security events it is given.
+type fakeSecurityEvents struct {
+	events []database.CreateSecurityEventParams
+}
+
+func (f *fakeSecurityEvents) CreateSecurityEvent(_ context.Context, arg database.CreateSecurityEventParams) error {
+	f.events = append(f.events, arg)
+	return nil
+}
+
+// expectFailureCount expects the transaction that increments a failure counter and refreshes its TTL.
+func expectFailureCount(mock redismock.ClientMock, key string, count int64) {
+	mock.ExpectTxPipeline()
+	mock.ExpectIncr(key).SetVal(count)
+	mock.ExpectExpire(key, testLimitConfig.Lockout).SetVal(true)
+	mock.ExpectTxPipelineExec()
+}
+
+// TestSignInLimiter_Check tests that a pending backoff or lockout on the account or IP blocks the attempt.
+func TestSignInLimiter_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		accountTTL time.Duration
+		ipTTL      time.Duration
+		wantCode   string
+	}{
+		{name: "clear", accountTTL: -2, ipTTL: -2},
+		{name: "account_blocked", accountTTL: 4 * time.Second, wantCode: "signin_locked"},
+		{name: "ip_blocked", accountTTL: -2, ipTTL: 10 * time.Minute, wantCode: "signin_locked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := redismock.NewClientMock()
+			mock.ExpectTTL("signin_block:account:" + testLimiterEmail).SetVal(tt.accountTTL)
+			if tt.accountTTL <= 0 {
+				mock.ExpectTTL("signin_block:ip:" + testLimiterIP).SetVal(tt.ipTTL)
+			}
+
+			err := NewSignInLimiter(db, nil, testLimitConfig).Check(context.Background(), " User@Example.com ", testLimiterIP)
+			if tt.wantCode == "" {
+				require.NoError(t, err)
+			} else {
+				var appErr *handlers.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestSignInLimiter_CheckRedisError tests that the attempt fails when the limiter state cannot be read.
+func TestSignInLimiter_CheckRedisError(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectTTL("signin_block:account:" + testLimiterEmail).SetErr(errors.New("redis down"))
+
+	err := NewSignInLimiter(db, nil, testLimitConfig).Check(context.Background(), testLimiterEmail, testLimiterIP)
+	var appErr *handlers.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "redis_error", appErr.Code)
+}
+
+// TestSignInLimiter_RecordFailure_Backoff tests that a failure below the limit starts a doubling delay for the account and IP.
+func TestSignInLimiter_RecordFailure_Backoff(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	expectFailureCount(mock, "signin_fail:account:"+testLimiterEmail, 2)
+	mock.ExpectSet("signin_block:account:"+testLimiterEmail, "delay", 2*time.Second).SetVal("OK")
+	expectFailureCount(mock, "signin_fail:ip:"+testLimiterIP, 1)
+	mock.ExpectSet("signin_block:ip:"+testLimiterIP, "delay", time.Second).SetVal("OK")
+	events := &fakeSecurityEvents{}
+
+	NewSignInLimiter(db, events, testLimitConfig).RecordFailure(context.Background(), testLimiterEmail, testLimiterIP)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, events.events)
+}
+
+// TestSignInLimiter_RecordFailure_Lockout tests that reaching the limit locks the account out and records a security event.
+func TestSignInLimiter_RecordFailure_Lockout(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	expectFailureCount(mock, "signin_fail:account:"+testLimiterEmail, 3)
+	mock.ExpectSet("signin_block:account:"+testLimiterEmail, "locked", 15*time.Minute).SetVal("OK")
+	mock.ExpectDel("signin_fail:account:" + testLimiterEmail).SetVal(1)
+	events := &fakeSecurityEvents{}
+
+	NewSignInLimiter(db, events, testLimitConfig).RecordFailure(context.Background(), testLimiterEmail, "")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, events.events, 1)
+	assert.Equal(t, SecurityEventAccountLocked, events.events[0].EventType)
+	assert.Equal(t, testLimiterEmail, events.events[0].Subject)
+	assert.NotEmpty(t, events.events[0].ID)
+}
+
+// TestSignInLimiter_Backoff tests that the delay doubles per failure and stops at MaxDelay.
+func TestSignInLimiter_Backoff(t *testing.T) {
+	l := NewSignInLimiter(nil, nil, testLimitConfig)
+	assert.Equal(t, time.Second, l.backoff(1))
+	assert.Equal(t, 8*time.Second, l.backoff(4))
+	assert.Equal(t, 30*time.Second, l.backoff(6))
+	assert.Equal(t, 30*time.Second, l.backoff(100))
+}
+
+// TestSignInLimiter_Unlock tests that unlocking clears the account state and records who unlocked it.
+func TestSignInLimiter_Unlock(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectDel("signin_fail:account:"+testLimiterEmail, "signin_block:account:"+testLimiterEmail).SetVal(2)
+	events := &fakeSecurityEvents{}
+
+	require.NoError(t, NewSignInLimiter(db, events, testLimitConfig).Unlock(context.Background(), "USER@example.com", "admin1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, events.events, 1)
+	assert.Equal(t, SecurityEventAccountUnlocked, events.events[0].EventType)
+	assert.Equal(t, "admin1", events.events[0].ActorUserID.String)
+}
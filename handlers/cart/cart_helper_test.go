@@ -15,6 +15,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/models"
 )
 
@@ -23,12 +24,12 @@ import (
 // Mock service for cart
 type MockCartService struct{ mock.Mock }
 
-func (m *MockCartService) AddItemToUserCart(ctx context.Context, userID, productID string, quantity int) error {
-	args := m.Called(ctx, userID, productID, quantity)
+func (m *MockCartService) AddItemToUserCart(ctx context.Context, userID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, userID, productID, variantID, quantity)
 	return args.Error(0)
 }
-func (m *MockCartService) AddItemToGuestCart(ctx context.Context, sessionID, productID string, quantity int) error {
-	args := m.Called(ctx, sessionID, productID, quantity)
+func (m *MockCartService) AddItemToGuestCart(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, sessionID, productID, variantID, quantity)
 	return args.Error(0)
 }
 func (m *MockCartService) GetUserCart(ctx context.Context, userID string) (*models.Cart, error) {
@@ -45,20 +46,20 @@ func (m *MockCartService) GetGuestCart(ctx context.Context, sessionID string) (*
 	}
 	return args.Get(0).(*models.Cart), args.Error(1)
 }
-func (m *MockCartService) UpdateItemQuantity(ctx context.Context, userID, productID string, quantity int) error {
-	args := m.Called(ctx, userID, productID, quantity)
+func (m *MockCartService) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, userID, productID, variantID, quantity)
 	return args.Error(0)
 }
-func (m *MockCartService) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID string, quantity int) error {
-	args := m.Called(ctx, sessionID, productID, quantity)
+func (m *MockCartService) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, sessionID, productID, variantID, quantity)
 	return args.Error(0)
 }
-func (m *MockCartService) RemoveItem(ctx context.Context, userID, productID string) error {
-	args := m.Called(ctx, userID, productID)
+func (m *MockCartService) RemoveItem(ctx context.Context, userID, productID, variantID string) error {
+	args := m.Called(ctx, userID, productID, variantID)
 	return args.Error(0)
 }
-func (m *MockCartService) RemoveGuestItem(ctx context.Context, sessionID, productID string) error {
-	args := m.Called(ctx, sessionID, productID)
+func (m *MockCartService) RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error {
+	args := m.Called(ctx, sessionID, productID, variantID)
 	return args.Error(0)
 }
 func (m *MockCartService) DeleteUserCart(ctx context.Context, userID string) error {
@@ -108,13 +109,18 @@ func (m *MockCartMongoAPI) GetCartByUserID(ctx context.Context, userID string) (
 	return args.Get(0).(*models.Cart), args.Error(1)
 }
 
-func (m *MockCartMongoAPI) UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error {
-	args := m.Called(ctx, userID, productID, quantity)
+func (m *MockCartMongoAPI) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, userID, productID, variantID, quantity)
 	return args.Error(0)
 }
 
-func (m *MockCartMongoAPI) RemoveItemFromCart(ctx context.Context, userID string, productID string) error {
-	args := m.Called(ctx, userID, productID)
+func (m *MockCartMongoAPI) UpdateItemPrice(ctx context.Context, userID, productID, variantID string, price money.Amount) error {
+	args := m.Called(ctx, userID, productID, variantID, price)
+	return args.Error(0)
+}
+
+func (m *MockCartMongoAPI) RemoveItemFromCart(ctx context.Context, userID, productID, variantID string) error {
+	args := m.Called(ctx, userID, productID, variantID)
 	return args.Error(0)
 }
 
@@ -128,14 +134,43 @@ type MockProductAPI struct {
 	mock.Mock
 }
 
+func (m *MockProductAPI) WithTx(_ DBTxAPI) ProductAPI {
+	return m
+}
+
 func (m *MockProductAPI) GetProductByID(ctx context.Context, productID string) (database.Product, error) {
 	args := m.Called(ctx, productID)
 	return args.Get(0).(database.Product), args.Error(1)
 }
 
-func (m *MockProductAPI) UpdateProductStock(ctx context.Context, params database.UpdateProductStockParams) error {
+func (m *MockProductAPI) GetProductByIDForUpdate(ctx context.Context, productID string) (database.Product, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(database.Product), args.Error(1)
+}
+
+func (m *MockProductAPI) DecrementProductStock(ctx context.Context, params database.DecrementProductStockParams) (int64, error) {
 	args := m.Called(ctx, params)
-	return args.Error(0)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockProductAPI) ProductHasActiveVariants(ctx context.Context, productID string) (bool, error) {
+	args := m.Called(ctx, productID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductAPI) GetProductVariantByID(ctx context.Context, variantID string) (database.ProductVariant, error) {
+	args := m.Called(ctx, variantID)
+	return args.Get(0).(database.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductAPI) GetProductVariantByIDForUpdate(ctx context.Context, variantID string) (database.ProductVariant, error) {
+	args := m.Called(ctx, variantID)
+	return args.Get(0).(database.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductAPI) DecrementProductVariantStock(ctx context.Context, params database.DecrementProductVariantStockParams) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 // MockOrderAPI is a mock implementation of OrderAPI for testing
@@ -143,6 +178,10 @@ type MockOrderAPI struct {
 	mock.Mock
 }
 
+func (m *MockOrderAPI) WithTx(_ DBTxAPI) OrderAPI {
+	return m
+}
+
 func (m *MockOrderAPI) CreateOrder(ctx context.Context, params database.CreateOrderParams) error {
 	args := m.Called(ctx, params)
 	return args.Error(0)
@@ -193,13 +232,13 @@ func (m *MockCartRedisAPI) SaveGuestCart(ctx context.Context, sessionID string,
 	return args.Error(0)
 }
 
-func (m *MockCartRedisAPI) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID string, quantity int) error {
-	args := m.Called(ctx, sessionID, productID, quantity)
+func (m *MockCartRedisAPI) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, sessionID, productID, variantID, quantity)
 	return args.Error(0)
 }
 
-func (m *MockCartRedisAPI) RemoveGuestItem(ctx context.Context, sessionID, productID string) error {
-	args := m.Called(ctx, sessionID, productID)
+func (m *MockCartRedisAPI) RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error {
+	args := m.Called(ctx, sessionID, productID, variantID)
 	return args.Error(0)
 }
 
@@ -223,13 +262,18 @@ func (m *MockCartMongo) GetCartByUserID(ctx context.Context, userID string) (*mo
 	return args.Get(0).(*models.Cart), args.Error(1)
 }
 
-func (m *MockCartMongo) UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error {
-	args := m.Called(ctx, userID, productID, quantity)
+func (m *MockCartMongo) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
+	args := m.Called(ctx, userID, productID, variantID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockCartMongo) UpdateItemPrice(ctx context.Context, userID, productID, variantID string, price money.Amount) error {
+	args := m.Called(ctx, userID, productID, variantID, price)
 	return args.Error(0)
 }
 
-func (m *MockCartMongo) RemoveItemFromCart(ctx context.Context, userID string, productID string) error {
-	args := m.Called(ctx, userID, productID)
+func (m *MockCartMongo) RemoveItemFromCart(ctx context.Context, userID, productID, variantID string) error {
+	args := m.Called(ctx, userID, productID, variantID)
 	return args.Error(0)
 }
 
@@ -8,13 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
 	"github.com/STaninnat/ecom-backend/models"
 	"github.com/STaninnat/ecom-backend/utils"
@@ -38,19 +38,27 @@ const (
 type CartMongoAPI interface {
 	AddItemToCart(ctx context.Context, userID string, item models.CartItem) error
 	GetCartByUserID(ctx context.Context, userID string) (*models.Cart, error)
-	UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error
-	RemoveItemFromCart(ctx context.Context, userID string, productID string) error
+	UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error
+	UpdateItemPrice(ctx context.Context, userID, productID, variantID string, price money.Amount) error
+	RemoveItemFromCart(ctx context.Context, userID, productID, variantID string) error
 	ClearCart(ctx context.Context, userID string) error
 }
 
 // ProductAPI defines the interface for product operations
 type ProductAPI interface {
+	WithTx(tx DBTxAPI) ProductAPI
 	GetProductByID(ctx context.Context, productID string) (database.Product, error)
-	UpdateProductStock(ctx context.Context, params database.UpdateProductStockParams) error
+	GetProductByIDForUpdate(ctx context.Context, productID string) (database.Product, error)
+	DecrementProductStock(ctx context.Context, params database.DecrementProductStockParams) (int64, error)
+	ProductHasActiveVariants(ctx context.Context, productID string) (bool, error)
+	GetProductVariantByID(ctx context.Context, variantID string) (database.ProductVariant, error)
+	GetProductVariantByIDForUpdate(ctx context.Context, variantID string) (database.ProductVariant, error)
+	DecrementProductVariantStock(ctx context.Context, params database.DecrementProductVariantStockParams) (int64, error)
 }
 
 // OrderAPI defines the interface for order operations
 type OrderAPI interface {
+	WithTx(tx DBTxAPI) OrderAPI
 	CreateOrder(ctx context.Context, params database.CreateOrderParams) error
 	CreateOrderItem(ctx context.Context, params database.CreateOrderItemParams) error
 }
@@ -70,8 +78,8 @@ type DBTxAPI interface {
 type CartRedisAPI interface {
 	GetGuestCart(ctx context.Context, sessionID string) (*models.Cart, error)
 	SaveGuestCart(ctx context.Context, sessionID string, cart *models.Cart) error
-	UpdateGuestItemQuantity(ctx context.Context, sessionID, productID string, quantity int) error
-	RemoveGuestItem(ctx context.Context, sessionID, productID string) error
+	UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error
+	RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error
 	DeleteGuestCart(ctx context.Context, sessionID string) error
 }
 
@@ -96,13 +104,18 @@ func (a *CartMongoAdapter) GetCartByUserID(ctx context.Context, userID string) (
 }
 
 // UpdateItemQuantity updates the quantity of an item in the user's cart in MongoDB
-func (a *CartMongoAdapter) UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error {
-	return a.cartMongo.UpdateItemQuantity(ctx, userID, productID, quantity)
+func (a *CartMongoAdapter) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
+	return a.cartMongo.UpdateItemQuantity(ctx, userID, productID, variantID, quantity)
+}
+
+// UpdateItemPrice updates the price snapshot of an item in the user's cart in MongoDB
+func (a *CartMongoAdapter) UpdateItemPrice(ctx context.Context, userID, productID, variantID string, price money.Amount) error {
+	return a.cartMongo.UpdateItemPrice(ctx, userID, productID, variantID, price)
 }
 
 // RemoveItemFromCart removes an item from the user's cart in MongoDB
-func (a *CartMongoAdapter) RemoveItemFromCart(ctx context.Context, userID string, productID string) error {
-	return a.cartMongo.RemoveItemFromCart(ctx, userID, productID)
+func (a *CartMongoAdapter) RemoveItemFromCart(ctx context.Context, userID, productID, variantID string) error {
+	return a.cartMongo.RemoveItemFromCart(ctx, userID, productID, variantID)
 }
 
 // ClearCart clears the user's cart in MongoDB
@@ -120,14 +133,49 @@ func NewProductAdapter(db *database.Queries) ProductAPI {
 	return &ProductAdapter{db: db}
 }
 
+// WithTx returns a ProductAPI bound to the given transaction.
+// If tx is not backed by a *sql.Tx the adapter itself is returned.
+func (a *ProductAdapter) WithTx(tx DBTxAPI) ProductAPI {
+	sqlTx, ok := unwrapSQLTx(tx)
+	if !ok {
+		return a
+	}
+	return &ProductAdapter{db: a.db.WithTx(sqlTx)}
+}
+
 // GetProductByID retrieves a product by its ID
 func (a *ProductAdapter) GetProductByID(ctx context.Context, productID string) (database.Product, error) {
 	return a.db.GetProductByID(ctx, productID)
 }
 
-// UpdateProductStock updates the stock for a product
-func (a *ProductAdapter) UpdateProductStock(ctx context.Context, params database.UpdateProductStockParams) error {
-	return a.db.UpdateProductStock(ctx, params)
+// GetProductByIDForUpdate retrieves a product by its ID and locks the row until the transaction ends
+func (a *ProductAdapter) GetProductByIDForUpdate(ctx context.Context, productID string) (database.Product, error) {
+	return a.db.GetProductByIDForUpdate(ctx, productID)
+}
+
+// DecrementProductStock decrements stock only if enough is available and returns the number of affected rows
+func (a *ProductAdapter) DecrementProductStock(ctx context.Context, params database.DecrementProductStockParams) (int64, error) {
+	return a.db.DecrementProductStock(ctx, params)
+}
+
+// ProductHasActiveVariants reports whether a product has any active variant
+func (a *ProductAdapter) ProductHasActiveVariants(ctx context.Context, productID string) (bool, error) {
+	return a.db.ProductHasActiveVariants(ctx, productID)
+}
+
+// GetProductVariantByID retrieves a product variant by its ID
+func (a *ProductAdapter) GetProductVariantByID(ctx context.Context, variantID string) (database.ProductVariant, error) {
+	return a.db.GetProductVariantByID(ctx, variantID)
+}
+
+// GetProductVariantByIDForUpdate retrieves a product variant by its ID and locks the row until the transaction ends
+func (a *ProductAdapter) GetProductVariantByIDForUpdate(ctx context.Context, variantID string) (database.ProductVariant, error) {
+	return a.db.GetProductVariantByIDForUpdate(ctx, variantID)
+}
+
+// DecrementProductVariantStock decrements variant stock only if enough is available and returns the number of affected rows
+func (a *ProductAdapter) DecrementProductVariantStock(ctx context.Context, params database.DecrementProductVariantStockParams) (int64, error) {
+	return a.db.DecrementProductVariantStock(ctx, params)
 }
 
 // OrderAdapter adapts the database to OrderAPI interface
@@ -140,6 +188,16 @@ func NewOrderAdapter(db *database.Queries) OrderAPI {
 	return &OrderAdapter{db: db}
 }
 
+// WithTx returns an OrderAPI bound to the given transaction.
+// If tx is not backed by a *sql.Tx the adapter itself is returned.
+func (a *OrderAdapter) WithTx(tx DBTxAPI) OrderAPI {
+	sqlTx, ok := unwrapSQLTx(tx)
+	if !ok {
+		return a
+	}
+	return &OrderAdapter{db: a.db.WithTx(sqlTx)}
+}
+
 // CreateOrder creates a new order in the database
 func (a *OrderAdapter) CreateOrder(ctx context.Context, params database.CreateOrderParams) error {
 	_, err := a.db.CreateOrder(ctx, params)
@@ -182,6 +240,15 @@ func (a *DBTxAdapter) Rollback() error {
 	return a.tx.Rollback()
 }
 
+// unwrapSQLTx extracts the underlying *sql.Tx from a DBTxAPI, if any.
+func unwrapSQLTx(tx DBTxAPI) (*sql.Tx, bool) {
+	txAdapter, ok := tx.(*DBTxAdapter)
+	if !ok || txAdapter == nil || txAdapter.tx == nil {
+		return nil, false
+	}
+	return txAdapter.tx, true
+}
+
 // cartRedisImpl implements CartRedisAPI
 type cartRedisImpl struct {
 	redisClient redis.Cmdable
@@ -230,7 +297,7 @@ func (r *cartRedisImpl) SaveGuestCart(ctx context.Context, sessionID string, car
 }
 
 // UpdateGuestItemQuantity updates the quantity of an item in a guest cart in Redis
-func (r *cartRedisImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID string, quantity int) error {
+func (r *cartRedisImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
 	cart, err := r.GetGuestCart(ctx, sessionID)
 	if err != nil {
 		return err
@@ -238,7 +305,7 @@ func (r *cartRedisImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID,
 
 	updated := false
 	for i, item := range cart.Items {
-		if item.ProductID == productID {
+		if item.Matches(productID, variantID) {
 			cart.Items[i].Quantity = quantity
 			updated = true
 			break
@@ -252,7 +319,7 @@ func (r *cartRedisImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID,
 }
 
 // RemoveGuestItem removes an item from a guest cart in Redis
-func (r *cartRedisImpl) RemoveGuestItem(ctx context.Context, sessionID, productID string) error {
+func (r *cartRedisImpl) RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error {
 	cart, err := r.GetGuestCart(ctx, sessionID)
 	if err != nil {
 		return err
@@ -260,7 +327,7 @@ func (r *cartRedisImpl) RemoveGuestItem(ctx context.Context, sessionID, productI
 
 	newItems := make([]models.CartItem, 0, len(cart.Items))
 	for _, item := range cart.Items {
-		if item.ProductID != productID {
+		if !item.Matches(productID, variantID) {
 			newItems = append(newItems, item)
 		}
 	}
@@ -302,8 +369,8 @@ func NewCartService(
 	}
 }
 
-// AddItemToUserCart adds an item to a user's cart
-func (s *cartServiceImpl) AddItemToUserCart(ctx context.Context, userID string, productID string, quantity int) error {
+// AddItemToUserCart adds an item to a user's cart; variantID is required for products with active variants
+func (s *cartServiceImpl) AddItemToUserCart(ctx context.Context, userID, productID, variantID string, quantity int) error {
 	// Validate inputs
 	if userID == "" {
 		return &handlers.AppError{Code: "invalid_request", Message: "User ID is required"}
@@ -318,22 +385,10 @@ func (s *cartServiceImpl) AddItemToUserCart(ctx context.Context, userID string,
 		return &handlers.AppError{Code: "invalid_request", Message: "Quantity exceeds maximum allowed"}
 	}
 
-	// Validate product exists and get price
-	product, err := s.product.GetProductByID(ctx, productID)
+	// Validate product and variant exist and get price
+	item, err := s.newCartItem(ctx, productID, variantID, quantity)
 	if err != nil {
-		return &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
-	}
-
-	price, err := strconv.ParseFloat(product.Price, 64)
-	if err != nil {
-		return &handlers.AppError{Code: "invalid_price", Message: "Invalid product price format", Err: err}
-	}
-
-	item := models.CartItem{
-		ProductID: productID,
-		Quantity:  quantity,
-		Price:     price,
-		Name:      product.Name,
+		return err
 	}
 
 	if err := s.cartMongo.AddItemToCart(ctx, userID, item); err != nil {
@@ -343,8 +398,8 @@ func (s *cartServiceImpl) AddItemToUserCart(ctx context.Context, userID string,
 	return nil
 }
 
-// AddItemToGuestCart adds an item to a guest cart
-func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID string, productID string, quantity int) error {
+// AddItemToGuestCart adds an item to a guest cart; variantID is required for products with active variants
+func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
 	// Validate inputs
 	if sessionID == "" {
 		return &handlers.AppError{Code: "invalid_request", Message: "Session ID is required"}
@@ -360,15 +415,10 @@ func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID stri
 		return &handlers.AppError{Code: "invalid_request", Message: "Quantity exceeds maximum allowed"}
 	}
 
-	// Validate product exists and get price
-	product, err := s.product.GetProductByID(ctx, productID)
-	if err != nil {
-		return &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
-	}
-
-	price, err := strconv.ParseFloat(product.Price, 64)
+	// Validate product and variant exist and get price
+	item, err := s.newCartItem(ctx, productID, variantID, quantity)
 	if err != nil {
-		return &handlers.AppError{Code: "invalid_price", Message: "Invalid product price format", Err: err}
+		return err
 	}
 
 	// Get existing cart or create new one
@@ -391,7 +441,7 @@ func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID stri
 	// Check if item already exists and update quantity
 	found := false
 	for i := range cart.Items {
-		if cart.Items[i].ProductID == productID {
+		if cart.Items[i].Matches(productID, variantID) {
 			cart.Items[i].Quantity += quantity
 			found = true
 			break
@@ -402,12 +452,7 @@ func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID stri
 		if len(cart.Items) >= MaxCartItems {
 			return &handlers.AppError{Code: "cart_full", Message: "Cart is full"}
 		}
-		cart.Items = append(cart.Items, models.CartItem{
-			ProductID: productID,
-			Quantity:  quantity,
-			Price:     price,
-			Name:      product.Name,
-		})
+		cart.Items = append(cart.Items, item)
 	}
 
 	cart.UpdatedAt = time.Now().UTC()
@@ -419,6 +464,51 @@ func (s *cartServiceImpl) AddItemToGuestCart(ctx context.Context, sessionID stri
 	return nil
 }
 
+// newCartItem builds a cart line for the product, or for its variant when variantID is set, priced at the
+// variant's price override or else the product price. A product with active variants can only be added as one of them.
+func (s *cartServiceImpl) newCartItem(ctx context.Context, productID, variantID string, quantity int) (models.CartItem, error) {
+	product, err := s.product.GetProductByID(ctx, productID)
+	if err != nil {
+		return models.CartItem{}, &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
+	}
+
+	item := models.CartItem{
+		ProductID: productID,
+		Quantity:  quantity,
+		Name:      product.Name,
+	}
+	unitPrice := product.Price
+	if variantID == "" {
+		hasVariants, err := s.product.ProductHasActiveVariants(ctx, productID)
+		if err != nil {
+			return models.CartItem{}, &handlers.AppError{Code: "database_error", Message: "Failed to check product variants", Err: err}
+		}
+		if hasVariants {
+			return models.CartItem{}, &handlers.AppError{Code: "invalid_request", Message: "Variant ID is required for this product"}
+		}
+	} else {
+		variant, err := s.product.GetProductVariantByID(ctx, variantID)
+		if err != nil || variant.ProductID != productID {
+			return models.CartItem{}, &handlers.AppError{Code: "variant_not_found", Message: "Product variant not found", Err: err}
+		}
+		if !variant.IsActive {
+			return models.CartItem{}, &handlers.AppError{Code: "product_unavailable", Message: fmt.Sprintf("Variant %s is not available", variant.Sku)}
+		}
+		item.VariantID = variant.ID
+		item.SKU = variant.Sku
+		if variant.Price.Valid {
+			unitPrice = variant.Price.String
+		}
+	}
+
+	price, err := money.Parse(unitPrice, money.DefaultCurrency)
+	if err != nil {
+		return models.CartItem{}, &handlers.AppError{Code: "invalid_price", Message: "Invalid product price format", Err: err}
+	}
+	item.Price = price
+	return item, nil
+}
+
 // GetUserCart retrieves a user's cart
 func (s *cartServiceImpl) GetUserCart(ctx context.Context, userID string) (*models.Cart, error) {
 	if userID == "" {
@@ -462,28 +552,30 @@ func validateItemQuantityInputs(id, idType, productID string, quantity int) erro
 	return nil
 }
 
-func (s *cartServiceImpl) UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error {
+// UpdateItemQuantity sets the quantity of a product variant's line in a user's cart
+func (s *cartServiceImpl) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
 	if err := validateItemQuantityInputs(userID, "User ID", productID, quantity); err != nil {
 		return err
 	}
-	if err := s.cartMongo.UpdateItemQuantity(ctx, userID, productID, quantity); err != nil {
+	if err := s.cartMongo.UpdateItemQuantity(ctx, userID, productID, variantID, quantity); err != nil {
 		return &handlers.AppError{Code: "update_failed", Message: "Failed to update item quantity", Err: err}
 	}
 	return nil
 }
 
-func (s *cartServiceImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID string, productID string, quantity int) error {
+// UpdateGuestItemQuantity sets the quantity of a product variant's line in a guest cart
+func (s *cartServiceImpl) UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error {
 	if err := validateItemQuantityInputs(sessionID, "Session ID", productID, quantity); err != nil {
 		return err
 	}
-	if err := s.redis.UpdateGuestItemQuantity(ctx, sessionID, productID, quantity); err != nil {
+	if err := s.redis.UpdateGuestItemQuantity(ctx, sessionID, productID, variantID, quantity); err != nil {
 		return &handlers.AppError{Code: "update_failed", Message: "Failed to update guest item quantity", Err: err}
 	}
 	return nil
 }
 
-// RemoveItem removes an item from a user's cart
-func (s *cartServiceImpl) RemoveItem(ctx context.Context, userID string, productID string) error {
+// RemoveItem removes a product variant's line from a user's cart
+func (s *cartServiceImpl) RemoveItem(ctx context.Context, userID, productID, variantID string) error {
 	if userID == "" {
 		return &handlers.AppError{Code: "invalid_request", Message: "User ID is required"}
 	}
@@ -491,14 +583,14 @@ func (s *cartServiceImpl) RemoveItem(ctx context.Context, userID string, product
 		return &handlers.AppError{Code: "invalid_request", Message: "Product ID is required"}
 	}
 
-	if err := s.cartMongo.RemoveItemFromCart(ctx, userID, productID); err != nil {
+	if err := s.cartMongo.RemoveItemFromCart(ctx, userID, productID, variantID); err != nil {
 		return &handlers.AppError{Code: "remove_failed", Message: "Failed to remove item from cart", Err: err}
 	}
 	return nil
 }
 
-// RemoveGuestItem removes an item from a guest cart
-func (s *cartServiceImpl) RemoveGuestItem(ctx context.Context, sessionID string, productID string) error {
+// RemoveGuestItem removes a product variant's line from a guest cart
+func (s *cartServiceImpl) RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error {
 	if sessionID == "" {
 		return &handlers.AppError{Code: "invalid_request", Message: "Session ID is required"}
 	}
@@ -506,7 +598,7 @@ func (s *cartServiceImpl) RemoveGuestItem(ctx context.Context, sessionID string,
 		return &handlers.AppError{Code: "invalid_request", Message: "Product ID is required"}
 	}
 
-	if err := s.redis.RemoveGuestItem(ctx, sessionID, productID); err != nil {
+	if err := s.redis.RemoveGuestItem(ctx, sessionID, productID, variantID); err != nil {
 		return &handlers.AppError{Code: "remove_failed", Message: "Failed to remove item from guest cart", Err: err}
 	}
 	return nil
@@ -551,7 +643,18 @@ func (s *cartServiceImpl) CheckoutUserCart(ctx context.Context, userID string) (
 		return nil, &handlers.AppError{Code: "cart_empty", Message: "Cart is empty"}
 	}
 
-	return s.processCheckout(ctx, cart, userID)
+	result, err := s.processCheckout(ctx, cart, userID)
+	if changes, ok := refreshCartPrices(cart, err); ok {
+		// Store the current prices so the customer can review them and retry
+		for _, change := range changes {
+			_ = s.cartMongo.UpdateItemPrice(ctx, userID, change.ProductID, change.VariantID, change.CurrentPrice)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 // CheckoutGuestCart processes checkout for a guest cart
@@ -573,6 +676,10 @@ func (s *cartServiceImpl) CheckoutGuestCart(ctx context.Context, sessionID strin
 	}
 
 	result, err := s.processCheckout(ctx, cart, userID)
+	if _, ok := refreshCartPrices(cart, err); ok {
+		// Store the current prices so the customer can review them and retry
+		_ = s.redis.SaveGuestCart(ctx, sessionID, cart)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -583,7 +690,10 @@ func (s *cartServiceImpl) CheckoutGuestCart(ctx context.Context, sessionID strin
 	return result, nil
 }
 
-// processCheckout handles the common checkout logic
+// processCheckout handles the common checkout logic.
+// Stock is reserved with row locks on the same transaction that creates the order,
+// so concurrent checkouts cannot both pass the stock check and oversell.
+// Items are priced from the locked product rows; a stale cart price fails with "price_changed".
 func (s *cartServiceImpl) processCheckout(ctx context.Context, cart *models.Cart, userID string) (*CartCheckoutResult, error) {
 	tx, err := s.dbConn.BeginTx(ctx, nil)
 	if err != nil {
@@ -596,34 +706,34 @@ func (s *cartServiceImpl) processCheckout(ctx context.Context, cart *models.Cart
 		}
 	}()
 
-	totalAmount := 0.0
-	timeNow := time.Now().UTC()
+	product := s.product.WithTx(tx)
+	order := s.order.WithTx(tx)
 
-	// Validate stock and calculate total
-	for _, item := range cart.Items {
-		qty32, err := safeIntToInt32(item.Quantity)
-		if err != nil {
-			return nil, &handlers.AppError{Code: "invalid_quantity", Message: "Quantity too large", Err: err}
-		}
+	timeNow := time.Now().UTC()
 
-		product, err := s.product.GetProductByID(ctx, item.ProductID)
-		if err != nil {
-			return nil, &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
-		}
+	// Lock product and variant rows, verify stock for every item and decrement it
+	stock, err := reserveStock(ctx, product, cart.Items, timeNow)
+	if err != nil {
+		return nil, err
+	}
 
-		if product.Stock < qty32 {
-			return nil, &handlers.AppError{Code: "insufficient_stock", Message: "Insufficient stock for product", Err: err}
-		}
+	// Price from the locked rows; the cart only holds the price seen when the item was added
+	prices, err := priceCheckoutItems(cart.Items, stock)
+	if err != nil {
+		return nil, err
+	}
 
-		totalAmount += item.Price * float64(item.Quantity)
+	totalAmount, err := checkoutTotal(cart.Items, prices)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create order
 	orderID := utils.NewUUIDString()
-	err = s.order.CreateOrder(ctx, database.CreateOrderParams{
+	err = order.CreateOrder(ctx, database.CreateOrderParams{
 		ID:          orderID,
 		UserID:      userID,
-		TotalAmount: fmt.Sprintf("%.2f", totalAmount),
+		TotalAmount: totalAmount.String(),
 		Status:      "pending",
 		CreatedAt:   timeNow,
 		UpdatedAt:   timeNow,
@@ -632,34 +742,20 @@ func (s *cartServiceImpl) processCheckout(ctx context.Context, cart *models.Cart
 		return nil, &handlers.AppError{Code: "create_order_failed", Message: "Failed to create order", Err: err}
 	}
 
-	// Create order items and update stock
+	// Create order items
 	for _, item := range cart.Items {
 		qty32, err := safeIntToInt32(item.Quantity)
 		if err != nil {
 			return nil, &handlers.AppError{Code: "invalid_quantity", Message: "Quantity too large", Err: err}
 		}
 
-		negStock, err := safeIntToInt32(-item.Quantity)
-		if err != nil {
-			return nil, &handlers.AppError{Code: "invalid_quantity", Message: "Quantity too large", Err: err}
-		}
-
-		// Update product stock
-		err = s.product.UpdateProductStock(ctx, database.UpdateProductStockParams{
-			ID:    item.ProductID,
-			Stock: negStock,
-		})
-		if err != nil {
-			return nil, &handlers.AppError{Code: "update_stock_failed", Message: "Failed to update product stock", Err: err}
-		}
-
-		// Create order item
-		err = s.order.CreateOrderItem(ctx, database.CreateOrderItemParams{
+		err = order.CreateOrderItem(ctx, database.CreateOrderItemParams{
 			ID:        utils.NewUUIDString(),
 			OrderID:   orderID,
 			ProductID: item.ProductID,
+			VariantID: utils.ToNullString(item.VariantID),
 			Quantity:  qty32,
-			Price:     fmt.Sprintf("%.2f", item.Price),
+			Price:     prices[lineOf(item)].String(),
 			CreatedAt: timeNow,
 			UpdatedAt: timeNow,
 		})
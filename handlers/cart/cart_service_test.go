@@ -18,6 +18,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/models"
 )
 
@@ -42,7 +43,7 @@ func TestAddItemToUserCart_ProductNotFound(t *testing.T) {
 	dbErr := errors.New("product not found")
 	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(database.Product{}, dbErr)
 
-	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", 2)
+	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", "", 2)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -69,8 +70,9 @@ func TestAddItemToUserCart_InvalidPrice(t *testing.T) {
 	}
 
 	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(product, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, "product123").Return(false, nil)
 
-	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", 2)
+	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", "", 2)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -98,9 +100,10 @@ func TestAddItemToUserCart_AddFailed(t *testing.T) {
 
 	dbErr := errors.New("add to cart failed")
 	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(product, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, "product123").Return(false, nil)
 	mockCartMongo.On("AddItemToCart", mock.Anything, "user123", mock.AnythingOfType("models.CartItem")).Return(dbErr)
 
-	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", 2)
+	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", "", 2)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -110,6 +113,86 @@ func TestAddItemToUserCart_AddFailed(t *testing.T) {
 	mockCartMongo.AssertExpectations(t)
 }
 
+// TestAddItemToUserCart_Variant verifies that a variant line carries the variant's SKU and price override.
+func TestAddItemToUserCart_Variant(t *testing.T) {
+	mockCartMongo := new(MockCartMongoAPI)
+	mockProduct := new(MockProductAPI)
+
+	svc := NewCartService(mockCartMongo, mockProduct, new(MockOrderAPI), new(MockDBConnAPI), new(MockCartRedisAPI))
+
+	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(database.Product{ID: "product123", Name: "Tee", Price: "20.00"}, nil)
+	mockProduct.On("GetProductVariantByID", mock.Anything, "variant1").Return(database.ProductVariant{
+		ID: "variant1", ProductID: "product123", Sku: "TEE-XL", Price: sql.NullString{String: "24.00", Valid: true}, IsActive: true,
+	}, nil)
+	mockCartMongo.On("AddItemToCart", mock.Anything, "user123", mock.MatchedBy(func(item models.CartItem) bool {
+		return item.VariantID == "variant1" && item.SKU == "TEE-XL" && item.Price.String() == "24.00" && item.Quantity == 2
+	})).Return(nil)
+
+	err := svc.AddItemToUserCart(context.Background(), "user123", "product123", "variant1", 2)
+	require.NoError(t, err)
+	mockProduct.AssertNotCalled(t, "ProductHasActiveVariants", mock.Anything, mock.Anything)
+	mockCartMongo.AssertExpectations(t)
+}
+
+// TestAddItemToUserCart_VariantErrors verifies the variant checks made before an item is added.
+func TestAddItemToUserCart_VariantErrors(t *testing.T) {
+	product := database.Product{ID: "product123", Name: "Tee", Price: "20.00"}
+	tests := []struct {
+		name      string
+		variantID string
+		setup     func(m *MockProductAPI)
+		wantCode  string
+	}{
+		{
+			name: "variant required",
+			setup: func(m *MockProductAPI) {
+				m.On("ProductHasActiveVariants", mock.Anything, "product123").Return(true, nil)
+			},
+			wantCode: "invalid_request",
+		},
+		{
+			name: "variant check fails",
+			setup: func(m *MockProductAPI) {
+				m.On("ProductHasActiveVariants", mock.Anything, "product123").Return(false, errors.New("db down"))
+			},
+			wantCode: "database_error",
+		},
+		{
+			name:      "variant of another product",
+			variantID: "variant1",
+			setup: func(m *MockProductAPI) {
+				m.On("GetProductVariantByID", mock.Anything, "variant1").Return(database.ProductVariant{ID: "variant1", ProductID: "other", IsActive: true}, nil)
+			},
+			wantCode: "variant_not_found",
+		},
+		{
+			name:      "variant inactive",
+			variantID: "variant1",
+			setup: func(m *MockProductAPI) {
+				m.On("GetProductVariantByID", mock.Anything, "variant1").Return(database.ProductVariant{ID: "variant1", ProductID: "product123", Sku: "TEE-XL"}, nil)
+			},
+			wantCode: "product_unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartMongo := new(MockCartMongoAPI)
+			mockProduct := new(MockProductAPI)
+			svc := NewCartService(mockCartMongo, mockProduct, new(MockOrderAPI), new(MockDBConnAPI), new(MockCartRedisAPI))
+
+			mockProduct.On("GetProductByID", mock.Anything, "product123").Return(product, nil)
+			tt.setup(mockProduct)
+
+			err := svc.AddItemToUserCart(context.Background(), "user123", "product123", tt.variantID, 1)
+			appErr := &handlers.AppError{}
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.wantCode, appErr.Code)
+			mockCartMongo.AssertNotCalled(t, "AddItemToCart", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // TestAddItemToGuestCart_Success tests the successful addition of an item to a guest cart.
 // It verifies that the service correctly validates inputs, gets product information,
 // retrieves or creates the guest cart, and saves it to Redis.
@@ -137,10 +220,11 @@ func TestAddItemToGuestCart_Success(t *testing.T) {
 	}
 
 	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(product, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, "product123").Return(false, nil)
 	mockRedis.On("GetGuestCart", mock.Anything, "session123").Return(existingCart, nil)
 	mockRedis.On("SaveGuestCart", mock.Anything, "session123", mock.AnythingOfType("*models.Cart")).Return(nil)
 
-	err := svc.AddItemToGuestCart(context.Background(), "session123", "product123", 2)
+	err := svc.AddItemToGuestCart(context.Background(), "session123", "product123", "", 2)
 	require.NoError(t, err)
 	mockProduct.AssertExpectations(t)
 	mockRedis.AssertExpectations(t)
@@ -173,7 +257,7 @@ func TestAddItemToGuestCart_InvalidInputs(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := svc.AddItemToGuestCart(context.Background(), tc.sessionID, tc.productID, tc.quantity)
+			err := svc.AddItemToGuestCart(context.Background(), tc.sessionID, tc.productID, "", tc.quantity)
 			require.Error(t, err)
 			appErr := &handlers.AppError{}
 			ok := errors.As(err, &appErr)
@@ -206,7 +290,7 @@ func TestAddItemToGuestCart_CartFull(t *testing.T) {
 		items[i] = models.CartItem{
 			ProductID: fmt.Sprintf("product%d", i),
 			Quantity:  1,
-			Price:     10.0,
+			Price:     money.MustParse("10.00", money.USD),
 			Name:      fmt.Sprintf("Product %d", i),
 		}
 	}
@@ -220,9 +304,10 @@ func TestAddItemToGuestCart_CartFull(t *testing.T) {
 	}
 
 	mockProduct.On("GetProductByID", mock.Anything, "product123").Return(product, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, "product123").Return(false, nil)
 	mockRedis.On("GetGuestCart", mock.Anything, "session123").Return(fullCart, nil)
 
-	err := svc.AddItemToGuestCart(context.Background(), "session123", "product123", 2)
+	err := svc.AddItemToGuestCart(context.Background(), "session123", "product123", "", 2)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -316,8 +401,8 @@ func TestCartScenarios(t *testing.T) {
 }
 
 // Shared helper for UpdateItemQuantity and UpdateGuestItemQuantity invalid input tests
-func runUpdateQuantityInvalidInputsTest(t *testing.T, updateFunc func(context.Context, string, string, int) error, id string, productID string, quantity int, wantCode string) {
-	err := updateFunc(context.Background(), id, productID, quantity)
+func runUpdateQuantityInvalidInputsTest(t *testing.T, updateFunc func(context.Context, string, string, string, int) error, id string, productID string, quantity int, wantCode string) {
+	err := updateFunc(context.Background(), id, productID, "", quantity)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -326,9 +411,9 @@ func runUpdateQuantityInvalidInputsTest(t *testing.T, updateFunc func(context.Co
 }
 
 // Shared helper for UpdateItemQuantity and UpdateGuestItemQuantity failure tests
-func runUpdateQuantityFailureTest(t *testing.T, updateFunc func(context.Context, string, string, int) error, setupMock func(), id string, productID string, quantity int, wantCode string) {
+func runUpdateQuantityFailureTest(t *testing.T, updateFunc func(context.Context, string, string, string, int) error, setupMock func(), id string, productID string, quantity int, wantCode string) {
 	setupMock()
-	err := updateFunc(context.Background(), id, productID, quantity)
+	err := updateFunc(context.Background(), id, productID, "", quantity)
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -351,7 +436,7 @@ func TestUpdateQuantity_InvalidInputs(t *testing.T) {
 		productID  string
 		quantity   int
 		wantCode   string
-		updateFunc func(context.Context, string, string, int) error
+		updateFunc func(context.Context, string, string, string, int) error
 	}{
 		{"empty userID", "", "product123", 5, "invalid_request", svc.UpdateItemQuantity},
 		{"empty productID", "user123", "", 5, "invalid_request", svc.UpdateItemQuantity},
@@ -384,7 +469,7 @@ func TestUpdateQuantity_Failure(t *testing.T) {
 	t.Run("UpdateItemQuantity_Failure", func(t *testing.T) {
 		setupMock := func() {
 			dbErr := errors.New("update quantity failed")
-			mockCartMongo.On("UpdateItemQuantity", mock.Anything, "user123", "product123", 5).Return(dbErr)
+			mockCartMongo.On("UpdateItemQuantity", mock.Anything, "user123", "product123", "", 5).Return(dbErr)
 		}
 		runUpdateQuantityFailureTest(t, svc.UpdateItemQuantity, setupMock, "user123", "product123", 5, "update_failed")
 		mockCartMongo.AssertExpectations(t)
@@ -393,7 +478,7 @@ func TestUpdateQuantity_Failure(t *testing.T) {
 	t.Run("UpdateGuestItemQuantity_Failure", func(t *testing.T) {
 		setupMock := func() {
 			redisErr := errors.New("update guest quantity failed")
-			mockRedis.On("UpdateGuestItemQuantity", mock.Anything, "session123", "product123", 5).Return(redisErr)
+			mockRedis.On("UpdateGuestItemQuantity", mock.Anything, "session123", "product123", "", 5).Return(redisErr)
 		}
 		runUpdateQuantityFailureTest(t, svc.UpdateGuestItemQuantity, setupMock, "session123", "product123", 5, "update_failed")
 		mockRedis.AssertExpectations(t)
@@ -401,8 +486,8 @@ func TestUpdateQuantity_Failure(t *testing.T) {
 }
 
 // Shared helper for RemoveItem and RemoveGuestItem invalid input tests
-func runRemoveItemInvalidInputsTest(t *testing.T, removeFunc func(context.Context, string, string) error, id string, productID string, wantCode string) {
-	err := removeFunc(context.Background(), id, productID)
+func runRemoveItemInvalidInputsTest(t *testing.T, removeFunc func(context.Context, string, string, string) error, id string, productID string, wantCode string) {
+	err := removeFunc(context.Background(), id, productID, "")
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -411,9 +496,9 @@ func runRemoveItemInvalidInputsTest(t *testing.T, removeFunc func(context.Contex
 }
 
 // Shared helper for RemoveItem and RemoveGuestItem failure tests
-func runRemoveItemFailureTest(t *testing.T, removeFunc func(context.Context, string, string) error, setupMock func(), id string, productID string, wantCode string) {
+func runRemoveItemFailureTest(t *testing.T, removeFunc func(context.Context, string, string, string) error, setupMock func(), id string, productID string, wantCode string) {
 	setupMock()
-	err := removeFunc(context.Background(), id, productID)
+	err := removeFunc(context.Background(), id, productID, "")
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -435,7 +520,7 @@ func TestRemoveItem_InvalidInputs(t *testing.T) {
 		id         string
 		productID  string
 		wantCode   string
-		removeFunc func(context.Context, string, string) error
+		removeFunc func(context.Context, string, string, string) error
 	}{
 		{"empty userID", "", "product123", "invalid_request", svc.RemoveItem},
 		{"empty productID", "user123", "", "invalid_request", svc.RemoveItem},
@@ -462,7 +547,7 @@ func TestRemoveItem_Failure(t *testing.T) {
 	t.Run("RemoveItem_Failure", func(t *testing.T) {
 		setupMock := func() {
 			dbErr := errors.New("remove item failed")
-			mockCartMongo.On("RemoveItemFromCart", mock.Anything, "user123", "product123").Return(dbErr)
+			mockCartMongo.On("RemoveItemFromCart", mock.Anything, "user123", "product123", "").Return(dbErr)
 		}
 		runRemoveItemFailureTest(t, svc.RemoveItem, setupMock, "user123", "product123", "remove_failed")
 		mockCartMongo.AssertExpectations(t)
@@ -471,7 +556,7 @@ func TestRemoveItem_Failure(t *testing.T) {
 	t.Run("RemoveGuestItem_Failure", func(t *testing.T) {
 		setupMock := func() {
 			redisErr := errors.New("remove guest item failed")
-			mockRedis.On("RemoveGuestItem", mock.Anything, "session123", "product123").Return(redisErr)
+			mockRedis.On("RemoveGuestItem", mock.Anything, "session123", "product123", "").Return(redisErr)
 		}
 		runRemoveItemFailureTest(t, svc.RemoveGuestItem, setupMock, "session123", "product123", "remove_failed")
 		mockRedis.AssertExpectations(t)
@@ -571,8 +656,8 @@ func TestCheckoutUserCart_Success(t *testing.T) {
 		ID:     userID,
 		UserID: userID,
 		Items: []models.CartItem{
-			{ProductID: "prod1", Quantity: 2, Price: 10.0, Name: "Product 1"},
-			{ProductID: "prod2", Quantity: 1, Price: 20.0, Name: "Product 2"},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.00", money.USD), Name: "Product 1"},
+			{ProductID: "prod2", Quantity: 1, Price: money.MustParse("20.00", money.USD), Name: "Product 2"},
 		},
 	}
 
@@ -581,12 +666,13 @@ func TestCheckoutUserCart_Success(t *testing.T) {
 	// Mock transaction begin
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
 	// Mock product lookups and stock
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod2").Return(database.Product{ID: "prod2", Name: "Product 2", Price: "20.00", Stock: 5}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod2").Return(database.Product{ID: "prod2", Name: "Product 2", Price: "20.00", Stock: 5, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	// Mock order creation
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
 	// Mock stock update
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	// Mock order item creation
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(nil)
 	// Mock commit
@@ -643,11 +729,12 @@ func TestCheckoutUserCart_InsufficientStock(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 5, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 5, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 2}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 2, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutUserCart(context.Background(), userID)
@@ -673,11 +760,11 @@ func TestCheckoutUserCart_ProductNotFound(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{}, errors.New("not found"))
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{}, errors.New("not found"))
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutUserCart(context.Background(), userID)
@@ -702,7 +789,7 @@ func TestCheckoutUserCart_BeginTxError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	// Fix: Return a typed nil for DBTxAPI to avoid interface conversion panic
@@ -732,11 +819,13 @@ func TestCheckoutUserCart_CreateOrderError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(errors.New("order error"))
 	mockDBTx.On("Rollback").Return(nil)
 
@@ -763,13 +852,14 @@ func TestCheckoutUserCart_UpdateStockError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(errors.New("stock error"))
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(0), errors.New("stock error"))
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutUserCart(context.Background(), userID)
@@ -795,13 +885,14 @@ func TestCheckoutUserCart_CreateOrderItemError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(errors.New("order item error"))
 	mockDBTx.On("Rollback").Return(nil)
 
@@ -828,13 +919,14 @@ func TestCheckoutUserCart_ClearCartError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     userID,
 		UserID: userID,
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockCartMongo.On("GetCartByUserID", mock.Anything, userID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(nil)
 	mockDBTx.On("Commit").Return(nil)
 	mockDBTx.On("Rollback").Return(nil)
@@ -864,14 +956,15 @@ func TestCheckoutGuestCart_Success(t *testing.T) {
 		ID:     sessionID,
 		UserID: "",
 		Items: []models.CartItem{
-			{ProductID: "prod1", Quantity: 2, Price: 10.0, Name: "Product 1"},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.00", money.USD), Name: "Product 1"},
 		},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(nil)
 	mockDBTx.On("Commit").Return(nil)
 	mockDBTx.On("Rollback").Return(nil)
@@ -982,11 +1075,12 @@ func TestCheckoutGuestCart_InsufficientStock(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 5, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 5, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 2}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 2, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutGuestCart(context.Background(), sessionID, userID)
@@ -1013,11 +1107,11 @@ func TestCheckoutGuestCart_ProductNotFound(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{}, errors.New("not found"))
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{}, errors.New("not found"))
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutGuestCart(context.Background(), sessionID, userID)
@@ -1043,7 +1137,7 @@ func TestCheckoutGuestCart_BeginTxError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	// Fix: Return a typed nil for DBTxAPI to avoid interface conversion panic
@@ -1074,11 +1168,13 @@ func TestCheckoutGuestCart_CreateOrderError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(errors.New("order error"))
 	mockDBTx.On("Rollback").Return(nil)
 
@@ -1106,13 +1202,14 @@ func TestCheckoutGuestCart_UpdateStockError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(errors.New("stock error"))
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(0), errors.New("stock error"))
 	mockDBTx.On("Rollback").Return(nil)
 
 	result, err := svc.CheckoutGuestCart(context.Background(), sessionID, userID)
@@ -1139,13 +1236,14 @@ func TestCheckoutGuestCart_CreateOrderItemError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(errors.New("order item error"))
 	mockDBTx.On("Rollback").Return(nil)
 
@@ -1173,13 +1271,14 @@ func TestCheckoutGuestCart_ClearCartError(t *testing.T) {
 	cart := &models.Cart{
 		ID:     sessionID,
 		UserID: "",
-		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: 10.0, Name: "Product 1"}},
+		Items:  []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}},
 	}
 	mockRedis.On("GetGuestCart", mock.Anything, sessionID).Return(cart, nil)
 	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
-	mockProduct.On("GetProductByID", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "10.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
 	mockOrder.On("CreateOrder", mock.Anything, mock.AnythingOfType("database.CreateOrderParams")).Return(nil)
-	mockProduct.On("UpdateProductStock", mock.Anything, mock.AnythingOfType("database.UpdateProductStockParams")).Return(nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
 	mockOrder.On("CreateOrderItem", mock.Anything, mock.AnythingOfType("database.CreateOrderItemParams")).Return(nil)
 	mockDBTx.On("Commit").Return(nil)
 	mockDBTx.On("Rollback").Return(nil)
@@ -1268,13 +1367,13 @@ func TestCartMongoAdapter_WithSqlMock(t *testing.T) {
 	assert.Equal(t, expectedCart, cart)
 
 	// Test UpdateItemQuantity
-	mockMongo.On("UpdateItemQuantity", ctx, "user-id", "product-1", 3).Return(nil)
-	err = adapter.UpdateItemQuantity(ctx, "user-id", "product-1", 3)
+	mockMongo.On("UpdateItemQuantity", ctx, "user-id", "product-1", "", 3).Return(nil)
+	err = adapter.UpdateItemQuantity(ctx, "user-id", "product-1", "", 3)
 	require.NoError(t, err)
 
 	// Test RemoveItemFromCart
-	mockMongo.On("RemoveItemFromCart", ctx, "user-id", "product-1").Return(nil)
-	err = adapter.RemoveItemFromCart(ctx, "user-id", "product-1")
+	mockMongo.On("RemoveItemFromCart", ctx, "user-id", "product-1", "").Return(nil)
+	err = adapter.RemoveItemFromCart(ctx, "user-id", "product-1", "")
 	require.NoError(t, err)
 
 	// Test ClearCart
@@ -1304,21 +1403,37 @@ func TestProductAdapter_WithSqlMock(t *testing.T) {
 	ctx := context.Background()
 
 	// Test GetProductByID
-	mock.ExpectQuery("SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at FROM products").WithArgs("product-1").WillReturnRows(
-		sqlmock.NewRows([]string{"id", "category_id", "name", "description", "price", "stock", "image_url", "is_active", "created_at", "updated_at"}).
-			AddRow("product-1", "category-1", "Test Product", "Test Description", "10.99", 100, nil, true, time.Now(), time.Now()),
+	mock.ExpectQuery("SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at, (.+) FROM products").WithArgs("product-1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "category_id", "name", "description", "price", "stock", "image_url", "is_active", "created_at", "updated_at", "rating_avg", "rating_count", "rating_1_count", "rating_2_count", "rating_3_count", "rating_4_count", "rating_5_count"}).
+			AddRow("product-1", "category-1", "Test Product", "Test Description", "10.99", 100, nil, true, time.Now(), time.Now(), "0.00", 0, 0, 0, 0, 0, 0),
 	)
 	product, err := adapter.GetProductByID(ctx, "product-1")
 	require.NoError(t, err)
 	assert.Equal(t, "product-1", product.ID)
 
-	// Test UpdateProductStock
-	mock.ExpectExec("UPDATE products SET stock = \\$2 WHERE id = \\$1").WithArgs("product-1", 95).WillReturnResult(sqlmock.NewResult(0, 1))
-	err = adapter.UpdateProductStock(ctx, database.UpdateProductStockParams{
-		ID:    "product-1",
-		Stock: 95,
+	// Test GetProductByIDForUpdate
+	mock.ExpectQuery("SELECT (.+) FROM products WHERE id = \\$1 FOR UPDATE").WithArgs("product-1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "category_id", "name", "description", "price", "stock", "image_url", "is_active", "created_at", "updated_at", "rating_avg", "rating_count", "rating_1_count", "rating_2_count", "rating_3_count", "rating_4_count", "rating_5_count"}).
+			AddRow("product-1", "category-1", "Test Product", "Test Description", "10.99", 100, nil, true, time.Now(), time.Now(), "0.00", 0, 0, 0, 0, 0, 0),
+	)
+	product, err = adapter.GetProductByIDForUpdate(ctx, "product-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), product.Stock)
+
+	// Test DecrementProductStock
+	now := time.Now().UTC()
+	mock.ExpectExec("UPDATE products SET stock = stock - \\$1::int, updated_at = \\$2 WHERE id = \\$3 AND stock >= \\$1::int").
+		WithArgs(5, now, "product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err := adapter.DecrementProductStock(ctx, database.DecrementProductStockParams{
+		Quantity:  5,
+		UpdatedAt: now,
+		ID:        "product-1",
 	})
-	assert.NoError(t, err)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// Test WithTx falls back to the adapter when the tx is not a *sql.Tx
+	assert.Equal(t, adapter, adapter.WithTx(&MockDBTxAPI{}))
 
 	// Verify all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -1346,8 +1461,8 @@ func TestOrderAdapter_WithSqlMock(t *testing.T) {
 	// Test CreateOrder
 	mock.ExpectQuery("INSERT INTO orders").WithArgs(
 		"order-1", "user-1", "0.00", "pending", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
-	).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "status", "payment_method", "external_payment_id", "tracking_number", "shipping_address", "contact_phone", "created_at", "updated_at"}).
-		AddRow("order-1", "user-1", "0.00", "pending", nil, nil, nil, nil, nil, time.Now(), time.Now()))
+	).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "status", "payment_method", "external_payment_id", "tracking_number", "shipping_address", "contact_phone", "created_at", "updated_at", "refund_status"}).
+		AddRow("order-1", "user-1", "0.00", "pending", nil, nil, nil, nil, nil, time.Now(), time.Now(), "none"))
 	err = adapter.CreateOrder(ctx, database.CreateOrderParams{
 		ID:                "order-1",
 		UserID:            "user-1",
@@ -1365,7 +1480,7 @@ func TestOrderAdapter_WithSqlMock(t *testing.T) {
 
 	// Test CreateOrderItem
 	mock.ExpectExec("INSERT INTO order_items").WithArgs(
-		"item-1", "order-1", "product-1", 2, "10.99", sqlmock.AnyArg(), sqlmock.AnyArg(),
+		"item-1", "order-1", "product-1", sql.NullString{}, 2, "10.99", sqlmock.AnyArg(), sqlmock.AnyArg(),
 	).WillReturnResult(sqlmock.NewResult(1, 1))
 	err = adapter.CreateOrderItem(ctx, database.CreateOrderItemParams{
 		ID:        "item-1",
@@ -1501,7 +1616,7 @@ func TestCartRedisAPI_WithRedisMock(t *testing.T) {
 	}
 	updatedCartJSON, _ := json.Marshal(updatedCart)
 	mock.ExpectSet("guest_cart:session-123", updatedCartJSON, 7*24*time.Hour).SetVal("OK")
-	err = adapter.UpdateGuestItemQuantity(ctx, sessionID, "product-1", 5)
+	err = adapter.UpdateGuestItemQuantity(ctx, sessionID, "product-1", "", 5)
 	require.NoError(t, err)
 
 	// Test RemoveGuestItem
@@ -1511,7 +1626,7 @@ func TestCartRedisAPI_WithRedisMock(t *testing.T) {
 	emptyCart := &models.Cart{Items: []models.CartItem{}}
 	emptyCartJSON, _ := json.Marshal(emptyCart)
 	mock.ExpectSet("guest_cart:session-123", emptyCartJSON, 7*24*time.Hour).SetVal("OK")
-	err = adapter.RemoveGuestItem(ctx, sessionID, "product-1")
+	err = adapter.RemoveGuestItem(ctx, sessionID, "product-1", "")
 	require.NoError(t, err)
 
 	// Test DeleteGuestCart
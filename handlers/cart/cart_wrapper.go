@@ -21,14 +21,14 @@ import (
 
 // CartService defines the business logic interface for cart operations.
 type CartService interface {
-	AddItemToUserCart(ctx context.Context, userID string, productID string, quantity int) error
-	AddItemToGuestCart(ctx context.Context, sessionID string, productID string, quantity int) error
+	AddItemToUserCart(ctx context.Context, userID, productID, variantID string, quantity int) error
+	AddItemToGuestCart(ctx context.Context, sessionID, productID, variantID string, quantity int) error
 	GetUserCart(ctx context.Context, userID string) (*models.Cart, error)
 	GetGuestCart(ctx context.Context, sessionID string) (*models.Cart, error)
-	UpdateItemQuantity(ctx context.Context, userID string, productID string, quantity int) error
-	UpdateGuestItemQuantity(ctx context.Context, sessionID string, productID string, quantity int) error
-	RemoveItem(ctx context.Context, userID string, productID string) error
-	RemoveGuestItem(ctx context.Context, sessionID string, productID string) error
+	UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error
+	UpdateGuestItemQuantity(ctx context.Context, sessionID, productID, variantID string, quantity int) error
+	RemoveItem(ctx context.Context, userID, productID, variantID string) error
+	RemoveGuestItem(ctx context.Context, sessionID, productID, variantID string) error
 	DeleteUserCart(ctx context.Context, userID string) error
 	DeleteGuestCart(ctx context.Context, sessionID string) error
 	CheckoutUserCart(ctx context.Context, userID string) (*CartCheckoutResult, error)
@@ -93,7 +93,7 @@ func (cfg *HandlersCartConfig) handleCartError(w http.ResponseWriter, r *http.Re
 		case "invalid_request":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message, appErr.Code)
-		case "product_not_found":
+		case "product_not_found", "variant_not_found":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusNotFound, appErr.Message, appErr.Code)
 		case "item_not_found":
@@ -104,7 +104,31 @@ func (cfg *HandlersCartConfig) handleCartError(w http.ResponseWriter, r *http.Re
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message, appErr.Code)
 		case "insufficient_stock":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			var stockErr *InsufficientStockError
+			if errors.As(appErr.Err, &stockErr) {
+				middlewares.RespondWithJSON(w, http.StatusBadRequest, InsufficientStockResponse{
+					Error: appErr.Message,
+					Code:  appErr.Code,
+					Items: stockErr.Items,
+				})
+				return
+			}
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message, appErr.Code)
+		case "product_unavailable":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message, appErr.Code)
+		case "price_changed":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			var priceErr *PriceChangedError
+			if errors.As(appErr.Err, &priceErr) {
+				middlewares.RespondWithJSON(w, http.StatusConflict, PriceChangedResponse{
+					Error: appErr.Message,
+					Code:  appErr.Code,
+					Items: priceErr.Items,
+				})
+				return
+			}
+			middlewares.RespondWithError(w, http.StatusConflict, appErr.Message, appErr.Code)
 		case "add_failed", "get_failed", "update_failed", "remove_failed", "clear_failed", "get_cart_failed", "save_cart_failed":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusInternalServerError, "Internal server error", appErr.Code)
@@ -137,7 +161,7 @@ func (cfg *HandlersCartConfig) handleCartItemOperation(
 	id string,
 	parseIDErrMsg string,
 	parseReq func(*http.Request) (string, string, int, error),
-	serviceCall func(ctx context.Context, id, productID string, quantity int) error,
+	serviceCall func(ctx context.Context, id, productID, variantID string, quantity int) error,
 	opName string,
 	successMsg string,
 	responseMsg string,
@@ -151,7 +175,7 @@ func (cfg *HandlersCartConfig) handleCartItemOperation(
 		return
 	}
 
-	productID, _, quantity, err := parseReq(r)
+	productID, variantID, quantity, err := parseReq(r)
 	if err != nil {
 		cfg.Logger.LogHandlerError(ctx, opName, "invalid request body", "Failed to parse body", ip, userAgent, err)
 		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -164,7 +188,7 @@ func (cfg *HandlersCartConfig) handleCartItemOperation(
 		return
 	}
 
-	if err := serviceCall(ctx, id, productID, quantity); err != nil {
+	if err := serviceCall(ctx, id, productID, variantID, quantity); err != nil {
 		cfg.handleCartError(w, r, err, opName, ip, userAgent)
 		return
 	}
@@ -176,22 +200,26 @@ func (cfg *HandlersCartConfig) handleCartItemOperation(
 // CartItemRequest is the DTO for cart item operations (add/update).
 // Fields:
 //   - ProductID: required, identifies the product
+//   - VariantID: required for products with active variants, identifies the variant
 //   - Quantity: required, number of items to add/update
 //
 // Validation is performed in the handler layer.
 type CartItemRequest struct {
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
 // CartUpdateRequest is the DTO for updating cart items in the cart.
 // Fields:
 //   - ProductID: required, identifies the product
+//   - VariantID: identifies the variant line, empty for a line without a variant
 //   - Quantity: required, new quantity for the item
 //
 // Validation is performed in the handler layer.
 type CartUpdateRequest struct {
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
@@ -206,6 +234,29 @@ type CartResponse struct {
 	OrderID string `json:"order_id,omitempty"`
 }
 
+// InsufficientStockResponse is returned when checkout fails because of missing stock.
+// Fields:
+//   - Error: human readable error message
+//   - Code: always "insufficient_stock"
+//   - Items: every cart item that cannot be fulfilled, with requested and available quantities
+type InsufficientStockResponse struct {
+	Error string          `json:"error"`
+	Code  string          `json:"code"`
+	Items []StockShortage `json:"items"`
+}
+
+// PriceChangedResponse is returned when checkout fails because product prices changed.
+// The cart is updated to the current prices, so the checkout can be retried after review.
+// Fields:
+//   - Error: human readable error message
+//   - Code: always "price_changed"
+//   - Items: every cart item whose price changed, with the cart and current prices
+type PriceChangedResponse struct {
+	Error string        `json:"error"`
+	Code  string        `json:"code"`
+	Items []PriceChange `json:"items"`
+}
+
 // NewCartServiceWithDeps creates a new cart service with all required dependencies.
 // Convenience function for initialization in main or tests.
 // Parameters:
@@ -2,6 +2,7 @@
 package carthandlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // cart_wrapper_test.go: Tests for cart service initialization, error handling, DTOs, and service creation.
@@ -68,6 +70,8 @@ func TestHandleCartError_AppErrorCodes(t *testing.T) {
 		{"product_not_found", "product_not_found", http.StatusNotFound},
 		{"cart_empty", "cart_empty", http.StatusBadRequest},
 		{"insufficient_stock", "insufficient_stock", http.StatusBadRequest},
+		{"product_unavailable", "product_unavailable", http.StatusBadRequest},
+		{"price_changed", "price_changed", http.StatusConflict},
 		{"cart_full", "cart_full", http.StatusBadRequest},
 		{"add_failed", "add_failed", http.StatusInternalServerError},
 		{"get_failed", "get_failed", http.StatusInternalServerError},
@@ -102,6 +106,31 @@ func TestHandleCartError_AppErrorCodes(t *testing.T) {
 	}
 }
 
+// TestHandleCartError_PriceChanged tests that a price change lists the changed items in the response body.
+func TestHandleCartError_PriceChanged(t *testing.T) {
+	cfg := &HandlersCartConfig{Config: &handlers.Config{}}
+	mockLogger := new(MockLogger)
+	cfg.Logger = mockLogger
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", nil)
+	err := &handlers.AppError{
+		Code:    "price_changed",
+		Message: "Prices have changed since the items were added to the cart",
+		Err: &PriceChangedError{Items: []PriceChange{
+			{ProductID: "prod1", Name: "Product 1", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.50", money.USD)},
+		}},
+	}
+	mockLogger.On("LogHandlerError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	cfg.handleCartError(w, r, err, "op", "ip", "ua")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp PriceChangedResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "price_changed", resp.Code)
+	assert.Equal(t, []PriceChange{{ProductID: "prod1", Name: "Product 1", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.50", money.USD)}}, resp.Items)
+}
+
 // TestHandleCartError_NonAppError tests the handleCartError method with non-AppError types.
 // It verifies that the method correctly handles generic errors by returning HTTP 500
 // and ensures that the error handling is robust for different error types.
@@ -0,0 +1,124 @@
+// Package carthandlers implements HTTP handlers for cart operations including user and guest carts.
+package carthandlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// checkout_pricing.go: Prices checkout items from the locked product and variant rows and detects stale cart prices.
+
+// PriceChange describes a cart item whose stored price differs from the current product or variant price.
+type PriceChange struct {
+	ProductID     string       `json:"product_id"`
+	VariantID     string       `json:"variant_id,omitempty"`
+	Name          string       `json:"name,omitempty"`
+	ExpectedPrice money.Amount `json:"expected_price"`
+	CurrentPrice  money.Amount `json:"current_price"`
+}
+
+// PriceChangedError lists every cart item whose price changed since it was added.
+// It is carried as the Err of a "price_changed" AppError.
+type PriceChangedError struct {
+	Items []PriceChange
+}
+
+// Error implements the error interface for PriceChangedError.
+func (e *PriceChangedError) Error() string {
+	ids := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		ids = append(ids, item.ProductID)
+	}
+	return fmt.Sprintf("price changed for products: %s", strings.Join(ids, ", "))
+}
+
+// priceCheckoutItems returns the current unit price of every line in the cart: the variant's price override,
+// or the product price for lines without a variant or variants without an override.
+// The rows must be the ones locked by reserveStock, so the prices cannot change before commit.
+// Returns a "price_changed" AppError listing every item whose cart price is stale.
+func priceCheckoutItems(items []models.CartItem, stock lockedStock) (map[cartLine]money.Amount, error) {
+	prices := make(map[cartLine]money.Amount, len(items))
+	for _, item := range items {
+		line := lineOf(item)
+		if _, ok := prices[line]; ok {
+			continue
+		}
+		unitPrice := stock.products[item.ProductID].Price
+		if variant, ok := stock.variants[item.VariantID]; ok && variant.Price.Valid {
+			unitPrice = variant.Price.String
+		}
+		price, err := money.Parse(unitPrice, money.DefaultCurrency)
+		if err != nil {
+			return nil, &handlers.AppError{Code: "invalid_price", Message: "Invalid product price", Err: err}
+		}
+		prices[line] = price
+	}
+
+	var changes []PriceChange
+	reported := make(map[cartLine]bool)
+	for _, item := range items {
+		line := lineOf(item)
+		current := prices[line]
+		if item.Price.Equal(current) || reported[line] {
+			continue
+		}
+		reported[line] = true
+		changes = append(changes, PriceChange{
+			ProductID:     item.ProductID,
+			VariantID:     item.VariantID,
+			Name:          stock.products[item.ProductID].Name,
+			ExpectedPrice: item.Price,
+			CurrentPrice:  current,
+		})
+	}
+
+	if len(changes) > 0 {
+		return nil, &handlers.AppError{
+			Code:    "price_changed",
+			Message: "Prices have changed since the items were added to the cart",
+			Err:     &PriceChangedError{Items: changes},
+		}
+	}
+
+	return prices, nil
+}
+
+// checkoutTotal sums the cart items at the given unit prices.
+func checkoutTotal(items []models.CartItem, prices map[cartLine]money.Amount) (money.Amount, error) {
+	total := money.New(0, money.DefaultCurrency)
+	for _, item := range items {
+		line, err := prices[lineOf(item)].Mul(int64(item.Quantity))
+		if err == nil {
+			total, err = total.Add(line)
+		}
+		if err != nil {
+			return money.Amount{}, &handlers.AppError{Code: "invalid_price", Message: "Invalid order total", Err: err}
+		}
+	}
+	return total, nil
+}
+
+// refreshCartPrices updates the cart's price snapshot in place from a "price_changed" error.
+// Returns the changed items, or false if err is not a price change.
+func refreshCartPrices(cart *models.Cart, err error) ([]PriceChange, bool) {
+	var priceErr *PriceChangedError
+	if !errors.As(err, &priceErr) {
+		return nil, false
+	}
+
+	current := make(map[cartLine]money.Amount, len(priceErr.Items))
+	for _, change := range priceErr.Items {
+		current[cartLine{productID: change.ProductID, variantID: change.VariantID}] = change.CurrentPrice
+	}
+	for i := range cart.Items {
+		if price, ok := current[lineOf(cart.Items[i])]; ok {
+			cart.Items[i].Price = price
+		}
+	}
+	return priceErr.Items, true
+}
@@ -0,0 +1,280 @@
+// Package carthandlers implements HTTP handlers for cart operations including user and guest carts.
+package carthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// checkout_pricing_test.go: Tests for checkout pricing, stale cart price detection, and cart price refresh.
+
+// TestPriceCheckoutItems_UsesProductPrices verifies that prices come from the product rows.
+func TestPriceCheckoutItems_UsesProductPrices(t *testing.T) {
+	products := map[string]database.Product{
+		"a": {ID: "a", Price: "12.50"},
+		"b": {ID: "b", Price: "3.00"},
+	}
+	items := []models.CartItem{
+		{ProductID: "a", Quantity: 1, Price: money.MustParse("12.50", money.USD)},
+		{ProductID: "b", Quantity: 2, Price: money.MustParse("3.00", money.USD)},
+	}
+
+	prices, err := priceCheckoutItems(items, lockedStock{products: products})
+	require.NoError(t, err)
+	assert.Equal(t, map[cartLine]money.Amount{{productID: "a"}: money.New(1250, money.USD), {productID: "b"}: money.New(300, money.USD)}, prices)
+}
+
+// TestPriceCheckoutItems_VariantPrices verifies that variant lines use the variant's price override,
+// falling back to the product price when the variant has none.
+func TestPriceCheckoutItems_VariantPrices(t *testing.T) {
+	stock := lockedStock{
+		products: map[string]database.Product{"a": {ID: "a", Name: "A", Price: "20.00"}},
+		variants: map[string]database.ProductVariant{
+			"a-xl": {ID: "a-xl", ProductID: "a", Price: sql.NullString{String: "24.00", Valid: true}},
+			"a-m":  {ID: "a-m", ProductID: "a"},
+		},
+	}
+	items := []models.CartItem{
+		{ProductID: "a", VariantID: "a-xl", Quantity: 1, Price: money.MustParse("24.00", money.USD)},
+		{ProductID: "a", VariantID: "a-m", Quantity: 1, Price: money.MustParse("20.00", money.USD)},
+		{ProductID: "a", VariantID: "a-xl", Quantity: 1, Price: money.MustParse("20.00", money.USD)},
+	}
+
+	_, err := priceCheckoutItems(items, stock)
+
+	var priceErr *PriceChangedError
+	require.True(t, errors.As(err, &priceErr))
+	assert.Equal(t, []PriceChange{
+		{ProductID: "a", VariantID: "a-xl", Name: "A", ExpectedPrice: money.MustParse("20.00", money.USD), CurrentPrice: money.MustParse("24.00", money.USD)},
+	}, priceErr.Items)
+
+	prices, err := priceCheckoutItems(items[:2], stock)
+	require.NoError(t, err)
+	assert.Equal(t, map[cartLine]money.Amount{
+		{productID: "a", variantID: "a-xl"}: money.New(2400, money.USD),
+		{productID: "a", variantID: "a-m"}:  money.New(2000, money.USD),
+	}, prices)
+}
+
+// TestPriceCheckoutItems_ListsEveryChangedItem verifies that each stale product is reported once.
+func TestPriceCheckoutItems_ListsEveryChangedItem(t *testing.T) {
+	products := map[string]database.Product{
+		"a": {ID: "a", Name: "A", Price: "12.50"},
+		"b": {ID: "b", Name: "B", Price: "3.00"},
+		"c": {ID: "c", Name: "C", Price: "1.00"},
+	}
+	items := []models.CartItem{
+		{ProductID: "c", Quantity: 1, Price: money.MustParse("2.00", money.USD)},
+		{ProductID: "a", Quantity: 1, Price: money.MustParse("10.00", money.USD)},
+		{ProductID: "b", Quantity: 1, Price: money.MustParse("3.00", money.USD)},
+		{ProductID: "c", Quantity: 1, Price: money.MustParse("2.00", money.USD)},
+	}
+
+	_, err := priceCheckoutItems(items, lockedStock{products: products})
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "price_changed", appErr.Code)
+
+	var priceErr *PriceChangedError
+	require.True(t, errors.As(err, &priceErr))
+	assert.Equal(t, []PriceChange{
+		{ProductID: "c", Name: "C", ExpectedPrice: money.MustParse("2.00", money.USD), CurrentPrice: money.MustParse("1.00", money.USD)},
+		{ProductID: "a", Name: "A", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.50", money.USD)},
+	}, priceErr.Items)
+	assert.Equal(t, "price changed for products: c, a", priceErr.Error())
+}
+
+// TestPriceCheckoutItems_InvalidPrice verifies that an unparsable product price is rejected.
+func TestPriceCheckoutItems_InvalidPrice(t *testing.T) {
+	_, err := priceCheckoutItems(
+		[]models.CartItem{{ProductID: "a", Quantity: 1, Price: money.MustParse("1.00", money.USD)}},
+		lockedStock{products: map[string]database.Product{"a": {ID: "a", Price: "abc"}}},
+	)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "invalid_price", appErr.Code)
+}
+
+// TestRefreshCartPrices verifies that only the changed items of the cart are repriced.
+func TestRefreshCartPrices(t *testing.T) {
+	cart := &models.Cart{Items: []models.CartItem{
+		{ProductID: "a", Price: money.MustParse("10.00", money.USD)},
+		{ProductID: "b", Price: money.MustParse("3.00", money.USD)},
+		{ProductID: "a", VariantID: "a-xl", Price: money.MustParse("10.00", money.USD)},
+	}}
+	err := &handlers.AppError{Code: "price_changed", Err: &PriceChangedError{Items: []PriceChange{
+		{ProductID: "a", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.50", money.USD)},
+	}}}
+
+	changes, ok := refreshCartPrices(cart, err)
+	require.True(t, ok)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "12.50", cart.Items[0].Price.String())
+	assert.Equal(t, "3.00", cart.Items[1].Price.String())
+	assert.Equal(t, "10.00", cart.Items[2].Price.String())
+
+	_, ok = refreshCartPrices(cart, errors.New("other"))
+	assert.False(t, ok)
+	_, ok = refreshCartPrices(cart, nil)
+	assert.False(t, ok)
+}
+
+// TestCheckoutUserCart_PriceChanged verifies that a stale user cart is rejected and updated to the current prices.
+func TestCheckoutUserCart_PriceChanged(t *testing.T) {
+	mockCartMongo := new(MockCartMongoAPI)
+	mockProduct := new(MockProductAPI)
+	mockOrder := new(MockOrderAPI)
+	mockDBConn := new(MockDBConnAPI)
+	mockDBTx := new(MockDBTxAPI)
+	mockRedis := new(MockCartRedisAPI)
+
+	svc := NewCartService(mockCartMongo, mockProduct, mockOrder, mockDBConn, mockRedis)
+	cart := &models.Cart{UserID: testUserID, Items: []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}}}
+
+	mockCartMongo.On("GetCartByUserID", mock.Anything, testUserID).Return(cart, nil)
+	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "12.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
+	mockDBTx.On("Rollback").Return(nil)
+	mockCartMongo.On("UpdateItemPrice", mock.Anything, testUserID, "prod1", "", money.New(1200, money.USD)).Return(nil)
+
+	result, err := svc.CheckoutUserCart(context.Background(), testUserID)
+	require.Error(t, err)
+	assert.Nil(t, result)
+
+	var priceErr *PriceChangedError
+	require.True(t, errors.As(err, &priceErr))
+	assert.Equal(t, []PriceChange{{ProductID: "prod1", Name: "Product 1", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.00", money.USD)}}, priceErr.Items)
+
+	mockCartMongo.AssertExpectations(t)
+	mockOrder.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	mockDBTx.AssertNotCalled(t, "Commit")
+	mockCartMongo.AssertNotCalled(t, "ClearCart", mock.Anything, mock.Anything)
+}
+
+// TestCheckoutGuestCart_PriceChanged verifies that a stale guest cart is rejected and saved with the current prices.
+func TestCheckoutGuestCart_PriceChanged(t *testing.T) {
+	mockCartMongo := new(MockCartMongoAPI)
+	mockProduct := new(MockProductAPI)
+	mockOrder := new(MockOrderAPI)
+	mockDBConn := new(MockDBConnAPI)
+	mockDBTx := new(MockDBTxAPI)
+	mockRedis := new(MockCartRedisAPI)
+
+	svc := NewCartService(mockCartMongo, mockProduct, mockOrder, mockDBConn, mockRedis)
+	cart := &models.Cart{Items: []models.CartItem{{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD), Name: "Product 1"}}}
+
+	mockRedis.On("GetGuestCart", mock.Anything, testSessionIDService).Return(cart, nil)
+	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Name: "Product 1", Price: "8.00", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.AnythingOfType("database.DecrementProductStockParams")).Return(int64(1), nil)
+	mockDBTx.On("Rollback").Return(nil)
+	mockRedis.On("SaveGuestCart", mock.Anything, testSessionIDService, mock.MatchedBy(func(c *models.Cart) bool {
+		return len(c.Items) == 1 && c.Items[0].Price.Equal(money.MustParse("8.00", money.USD))
+	})).Return(nil)
+
+	result, err := svc.CheckoutGuestCart(context.Background(), testSessionIDService, testUserID)
+	require.Error(t, err)
+	assert.Nil(t, result)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "price_changed", appErr.Code)
+
+	mockRedis.AssertExpectations(t)
+	mockRedis.AssertNotCalled(t, "DeleteGuestCart", mock.Anything, mock.Anything)
+	mockOrder.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+}
+
+// TestProcessCheckout_PricesFromProducts verifies that the order total and item prices come from the product rows.
+func TestProcessCheckout_PricesFromProducts(t *testing.T) {
+	mockCartMongo := new(MockCartMongoAPI)
+	mockProduct := new(MockProductAPI)
+	mockOrder := new(MockOrderAPI)
+	mockDBConn := new(MockDBConnAPI)
+	mockDBTx := new(MockDBTxAPI)
+
+	svc := &cartServiceImpl{cartMongo: mockCartMongo, product: mockProduct, order: mockOrder, dbConn: mockDBConn}
+	cart := &models.Cart{Items: []models.CartItem{
+		{ProductID: "prod1", Quantity: 2, Price: money.MustParse("19.99", money.USD)},
+		{ProductID: "prod2", Quantity: 1, Price: money.MustParse("0.10", money.USD)},
+	}}
+
+	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Price: "19.99", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod2").Return(database.Product{ID: "prod2", Price: "0.10", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.Anything).Return(int64(1), nil)
+	mockOrder.On("CreateOrder", mock.Anything, mock.MatchedBy(func(p database.CreateOrderParams) bool {
+		return p.TotalAmount == "40.08" && p.UserID == testUserID
+	})).Return(nil)
+	mockOrder.On("CreateOrderItem", mock.Anything, mock.MatchedBy(func(p database.CreateOrderItemParams) bool {
+		return p.ProductID == "prod1" && p.Price == "19.99"
+	})).Return(nil)
+	mockOrder.On("CreateOrderItem", mock.Anything, mock.MatchedBy(func(p database.CreateOrderItemParams) bool {
+		return p.ProductID == "prod2" && p.Price == "0.10"
+	})).Return(nil)
+	mockDBTx.On("Commit").Return(nil)
+	mockDBTx.On("Rollback").Return(nil)
+	mockCartMongo.On("ClearCart", mock.Anything, testUserID).Return(nil)
+
+	result, err := svc.processCheckout(context.Background(), cart, testUserID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.OrderID)
+	mockOrder.AssertExpectations(t)
+}
+
+// TestProcessCheckout_VariantLine verifies that a variant line reserves the variant's stock, is priced at its
+// override, and records the variant on the order item.
+func TestProcessCheckout_VariantLine(t *testing.T) {
+	mockCartMongo := new(MockCartMongoAPI)
+	mockProduct := new(MockProductAPI)
+	mockOrder := new(MockOrderAPI)
+	mockDBConn := new(MockDBConnAPI)
+	mockDBTx := new(MockDBTxAPI)
+
+	svc := &cartServiceImpl{cartMongo: mockCartMongo, product: mockProduct, order: mockOrder, dbConn: mockDBConn}
+	cart := &models.Cart{Items: []models.CartItem{
+		{ProductID: "prod1", VariantID: "var1", SKU: "TEE-XL", Quantity: 2, Price: money.MustParse("24.00", money.USD)},
+	}}
+
+	mockDBConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockDBTx, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "prod1").Return(database.Product{ID: "prod1", Price: "20.00", Stock: 0, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("GetProductVariantByIDForUpdate", mock.Anything, "var1").Return(database.ProductVariant{
+		ID: "var1", ProductID: "prod1", Sku: "TEE-XL", Price: sql.NullString{String: "24.00", Valid: true}, Stock: 5, IsActive: true,
+	}, nil)
+	mockProduct.On("DecrementProductVariantStock", mock.Anything, mock.MatchedBy(func(p database.DecrementProductVariantStockParams) bool {
+		return p.ID == "var1" && p.Quantity == 2
+	})).Return(int64(1), nil)
+	mockOrder.On("CreateOrder", mock.Anything, mock.MatchedBy(func(p database.CreateOrderParams) bool {
+		return p.TotalAmount == "48.00"
+	})).Return(nil)
+	mockOrder.On("CreateOrderItem", mock.Anything, mock.MatchedBy(func(p database.CreateOrderItemParams) bool {
+		return p.ProductID == "prod1" && p.VariantID == sql.NullString{String: "var1", Valid: true} && p.Price == "24.00"
+	})).Return(nil)
+	mockDBTx.On("Commit").Return(nil)
+	mockDBTx.On("Rollback").Return(nil)
+	mockCartMongo.On("ClearCart", mock.Anything, testUserID).Return(nil)
+
+	result, err := svc.processCheckout(context.Background(), cart, testUserID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.OrderID)
+	mockProduct.AssertNotCalled(t, "DecrementProductStock", mock.Anything, mock.Anything)
+	mockOrder.AssertExpectations(t)
+}
@@ -32,7 +32,7 @@ func (cfg *HandlersCartConfig) HandlerAddItemToUserCart(w http.ResponseWriter, r
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				return "", "", 0, err
 			}
-			return req.ProductID, "", req.Quantity, nil
+			return req.ProductID, req.VariantID, req.Quantity, nil
 		},
 		cfg.GetCartService().AddItemToUserCart,
 		"add_item_to_cart",
@@ -60,7 +60,7 @@ func (cfg *HandlersCartConfig) HandlerAddItemToGuestCart(w http.ResponseWriter,
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				return "", "", 0, err
 			}
-			return req.ProductID, "", req.Quantity, nil
+			return req.ProductID, req.VariantID, req.Quantity, nil
 		},
 		cfg.GetCartService().AddItemToGuestCart,
 		"add_item_guest_cart",
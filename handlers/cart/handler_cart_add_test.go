@@ -37,7 +37,7 @@ func TestHandlerAddItemToUserCart_Success(t *testing.T) {
 	user := database.User{ID: "u1"}
 	reqBody := CartItemRequest{ProductID: "p1", Quantity: 2}
 	jsonBody, _ := json.Marshal(reqBody)
-	mockService.On("AddItemToUserCart", mock.Anything, user.ID, reqBody.ProductID, reqBody.Quantity).Return(nil)
+	mockService.On("AddItemToUserCart", mock.Anything, user.ID, reqBody.ProductID, reqBody.VariantID, reqBody.Quantity).Return(nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "add_item_to_cart", "Added item to cart", mock.Anything, mock.Anything).Return()
 
 	req := httptest.NewRequest("POST", "/cart", bytes.NewBuffer(jsonBody))
@@ -108,7 +108,7 @@ func TestHandlerAddItemToUserCart_ServiceError(t *testing.T) {
 	reqBody := CartItemRequest{ProductID: "p1", Quantity: 2}
 	jsonBody, _ := json.Marshal(reqBody)
 	err := &handlers.AppError{Code: "product_not_found", Message: "not found", Err: errors.New("fail")}
-	mockService.On("AddItemToUserCart", mock.Anything, user.ID, reqBody.ProductID, reqBody.Quantity).Return(err)
+	mockService.On("AddItemToUserCart", mock.Anything, user.ID, reqBody.ProductID, reqBody.VariantID, reqBody.Quantity).Return(err)
 	mockLogger.On("LogHandlerError", mock.Anything, "add_item_to_cart", "product_not_found", "not found", mock.Anything, mock.Anything, mock.Anything).Return()
 
 	req := httptest.NewRequest("POST", "/cart", bytes.NewBuffer(jsonBody))
@@ -132,7 +132,7 @@ func TestHandlerAddItemToGuestCart_Success(t *testing.T) {
 	}
 	reqBody := CartItemRequest{ProductID: "p1", Quantity: 2}
 	jsonBody, _ := json.Marshal(reqBody)
-	mockService.On("AddItemToGuestCart", mock.Anything, testSessionIDAdd, reqBody.ProductID, reqBody.Quantity).Return(nil)
+	mockService.On("AddItemToGuestCart", mock.Anything, testSessionIDAdd, reqBody.ProductID, reqBody.VariantID, reqBody.Quantity).Return(nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "add_item_guest_cart", "Added item to guest cart", mock.Anything, mock.Anything).Return()
 
 	req := httptest.NewRequest("POST", "/cart", bytes.NewBuffer(jsonBody))
@@ -241,7 +241,7 @@ func TestHandlerAddItemToGuestCart_ServiceError(t *testing.T) {
 	reqBody := CartItemRequest{ProductID: "p1", Quantity: 2}
 	jsonBody, _ := json.Marshal(reqBody)
 	err := &handlers.AppError{Code: "cart_full", Message: "full", Err: errors.New("fail")}
-	mockService.On("AddItemToGuestCart", mock.Anything, testSessionIDAdd, reqBody.ProductID, reqBody.Quantity).Return(err)
+	mockService.On("AddItemToGuestCart", mock.Anything, testSessionIDAdd, reqBody.ProductID, reqBody.VariantID, reqBody.Quantity).Return(err)
 	mockLogger.On("LogHandlerError", mock.Anything, "add_item_guest_cart", "cart_full", "full", mock.Anything, mock.Anything, mock.Anything).Return()
 
 	req := httptest.NewRequest("POST", "/cart", bytes.NewBuffer(jsonBody))
@@ -21,6 +21,7 @@ import (
 // @Produce      json
 // @Success      200  {object}  CartResponse
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  PriceChangedResponse
 // @Router       /v1/cart/checkout [post]
 func (cfg *HandlersCartConfig) HandlerCheckoutUserCart(w http.ResponseWriter, r *http.Request, user database.User) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
@@ -55,6 +56,7 @@ type GuestCheckoutRequest struct {
 // @Param        checkout  body  GuestCheckoutRequest  true  "Guest checkout payload"
 // @Success      200  {object}  CartResponse
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  PriceChangedResponse
 // @Router       /v1/guest-cart/checkout [post]
 func (cfg *HandlersCartConfig) HandlerCheckoutGuestCart(w http.ResponseWriter, r *http.Request) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
@@ -72,6 +72,22 @@ func TestHandlerCheckoutUserCart(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   map[string]any{"error": "Cart is empty", "code": "cart_empty"},
 		},
+		{
+			name: "insufficient stock lists short items",
+			user: database.User{ID: "user1"},
+			setupMock: func(mockService *MockCartService) {
+				err := newInsufficientStockError([]StockShortage{
+					{ProductID: "prod1", Name: "Product 1", Requested: 3, Available: 1},
+				})
+				mockService.On("CheckoutUserCart", mock.Anything, "user1").Return(nil, err)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]any{
+				"error": "Insufficient stock for one or more products",
+				"code":  "insufficient_stock",
+				"items": []any{map[string]any{"product_id": "prod1", "name": "Product 1", "requested": float64(3), "available": float64(1)}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
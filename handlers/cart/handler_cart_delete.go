@@ -14,9 +14,10 @@ import (
 
 // handler_cart_delete.go: Provides handlers for managing items in authenticated user and guest carts.
 
-// DeleteItemRequest represents a request containing a product ID.
+// DeleteItemRequest represents a request containing a product ID and, for a variant line, the variant ID.
 type DeleteItemRequest struct {
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
 }
 
 // HandlerRemoveItemFromUserCart handles HTTP requests to remove an item from a user's cart.
@@ -58,7 +59,7 @@ func (cfg *HandlersCartConfig) HandlerRemoveItemFromUserCart(w http.ResponseWrit
 		return
 	}
 
-	if err := cfg.GetCartService().RemoveItem(ctx, user.ID, req.ProductID); err != nil {
+	if err := cfg.GetCartService().RemoveItem(ctx, user.ID, req.ProductID, req.VariantID); err != nil {
 		cfg.handleCartError(w, r, err, "remove_item_from_cart", ip, userAgent)
 		return
 	}
@@ -148,7 +149,7 @@ func (cfg *HandlersCartConfig) HandlerRemoveItemFromGuestCart(w http.ResponseWri
 		return
 	}
 
-	if err := cfg.GetCartService().RemoveGuestItem(ctx, sessionID, req.ProductID); err != nil {
+	if err := cfg.GetCartService().RemoveGuestItem(ctx, sessionID, req.ProductID, req.VariantID); err != nil {
 		cfg.handleCartError(w, r, err, "remove_item_from_guest_cart", ip, userAgent)
 		return
 	}
@@ -35,7 +35,7 @@ func TestHandlerRemoveItemFromUserCart(t *testing.T) {
 			user: database.User{ID: "user1"},
 			body: DeleteItemRequest{ProductID: "prod1"},
 			setupMock: func(mockService *MockCartService) {
-				mockService.On("RemoveItem", mock.Anything, "user1", "prod1").Return(nil)
+				mockService.On("RemoveItem", mock.Anything, "user1", "prod1", "").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   handlers.HandlerResponse{Message: "Item removed from cart"},
@@ -62,7 +62,7 @@ func TestHandlerRemoveItemFromUserCart(t *testing.T) {
 			body: DeleteItemRequest{ProductID: "prod1"},
 			setupMock: func(mockService *MockCartService) {
 				err := &handlers.AppError{Code: "item_not_found", Message: "Item not found"}
-				mockService.On("RemoveItem", mock.Anything, "user1", "prod1").Return(err)
+				mockService.On("RemoveItem", mock.Anything, "user1", "prod1", "").Return(err)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   map[string]any{"error": "Item not found", "code": "item_not_found"},
@@ -182,7 +182,7 @@ func TestHandlerRemoveItemFromGuestCart(t *testing.T) {
 			sessionID: "sess1",
 			body:      DeleteItemRequest{ProductID: "prod1"},
 			setupMock: func(mockService *MockCartService) {
-				mockService.On("RemoveGuestItem", mock.Anything, "sess1", "prod1").Return(nil)
+				mockService.On("RemoveGuestItem", mock.Anything, "sess1", "prod1", "").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   handlers.HandlerResponse{Message: "Item removed from cart"},
@@ -217,7 +217,7 @@ func TestHandlerRemoveItemFromGuestCart(t *testing.T) {
 			body:      DeleteItemRequest{ProductID: "prod1"},
 			setupMock: func(mockService *MockCartService) {
 				err := &handlers.AppError{Code: "item_not_found", Message: "Item not found"}
-				mockService.On("RemoveGuestItem", mock.Anything, "sess1", "prod1").Return(err)
+				mockService.On("RemoveGuestItem", mock.Anything, "sess1", "prod1", "").Return(err)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   map[string]any{"error": "Item not found", "code": "item_not_found"},
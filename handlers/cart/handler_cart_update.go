@@ -53,7 +53,7 @@ func (cfg *HandlersCartConfig) HandlerUpdateItemQuantity(w http.ResponseWriter,
 		return
 	}
 
-	if err := cfg.GetCartService().UpdateItemQuantity(ctx, user.ID, req.ProductID, req.Quantity); err != nil {
+	if err := cfg.GetCartService().UpdateItemQuantity(ctx, user.ID, req.ProductID, req.VariantID, req.Quantity); err != nil {
 		cfg.handleCartError(w, r, err, "update_item_quantity", ip, userAgent)
 		return
 	}
@@ -118,7 +118,7 @@ func (cfg *HandlersCartConfig) HandlerUpdateGuestItemQuantity(w http.ResponseWri
 		return
 	}
 
-	if err := cfg.GetCartService().UpdateGuestItemQuantity(ctx, sessionID, req.ProductID, req.Quantity); err != nil {
+	if err := cfg.GetCartService().UpdateGuestItemQuantity(ctx, sessionID, req.ProductID, req.VariantID, req.Quantity); err != nil {
 		cfg.handleCartError(w, r, err, "update_guest_item_quantity", ip, userAgent)
 		return
 	}
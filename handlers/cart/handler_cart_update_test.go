@@ -37,7 +37,7 @@ func TestHandlerUpdateItemQuantity(t *testing.T) {
 			user: database.User{ID: "user1"},
 			body: CartUpdateRequest{ProductID: "prod1", Quantity: 2},
 			setupMock: func(mockService *MockCartService) {
-				mockService.On("UpdateItemQuantity", mock.Anything, "user1", "prod1", 2).Return(nil)
+				mockService.On("UpdateItemQuantity", mock.Anything, "user1", "prod1", "", 2).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   handlers.HandlerResponse{Message: "Item quantity updated"},
@@ -64,7 +64,7 @@ func TestHandlerUpdateItemQuantity(t *testing.T) {
 			body: CartUpdateRequest{ProductID: "prod1", Quantity: 2},
 			setupMock: func(mockService *MockCartService) {
 				err := &handlers.AppError{Code: "product_not_found", Message: "Product not found"}
-				mockService.On("UpdateItemQuantity", mock.Anything, "user1", "prod1", 2).Return(err)
+				mockService.On("UpdateItemQuantity", mock.Anything, "user1", "prod1", "", 2).Return(err)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   map[string]any{"error": "Product not found", "code": "product_not_found"},
@@ -133,7 +133,7 @@ func TestHandlerUpdateGuestItemQuantity(t *testing.T) {
 			sessionID: "sess1",
 			body:      CartUpdateRequest{ProductID: "prod1", Quantity: 2},
 			setupMock: func(mockService *MockCartService) {
-				mockService.On("UpdateGuestItemQuantity", mock.Anything, "sess1", "prod1", 2).Return(nil)
+				mockService.On("UpdateGuestItemQuantity", mock.Anything, "sess1", "prod1", "", 2).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   handlers.HandlerResponse{Message: "Item quantity updated"},
@@ -168,7 +168,7 @@ func TestHandlerUpdateGuestItemQuantity(t *testing.T) {
 			body:      CartUpdateRequest{ProductID: "prod1", Quantity: 2},
 			setupMock: func(mockService *MockCartService) {
 				err := &handlers.AppError{Code: "cart_not_found", Message: "Cart not found"}
-				mockService.On("UpdateGuestItemQuantity", mock.Anything, "sess1", "prod1", 2).Return(err)
+				mockService.On("UpdateGuestItemQuantity", mock.Anything, "sess1", "prod1", "", 2).Return(err)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   map[string]any{"error": "Cart not found", "code": "cart_not_found"},
@@ -0,0 +1,212 @@
+// Package carthandlers implements HTTP handlers for cart operations including user and guest carts.
+package carthandlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// stock_reservation.go: Reserves product and variant stock for checkout using row locks and conditional decrements.
+
+// StockShortage describes a cart item that cannot be fulfilled from the current stock.
+// VariantID and SKU are set when the item is a product variant, whose own stock is reserved.
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// InsufficientStockError lists every cart item that is short on stock.
+// It is carried as the Err of an "insufficient_stock" AppError.
+type InsufficientStockError struct {
+	Items []StockShortage
+}
+
+// Error implements the error interface for InsufficientStockError.
+func (e *InsufficientStockError) Error() string {
+	ids := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		ids = append(ids, item.ProductID)
+	}
+	return fmt.Sprintf("insufficient stock for products: %s", strings.Join(ids, ", "))
+}
+
+// cartLine identifies a cart line: a product, or one of its variants.
+type cartLine struct {
+	productID string
+	variantID string
+}
+
+// lineOf returns the cart line of an item.
+func lineOf(item models.CartItem) cartLine {
+	return cartLine{productID: item.ProductID, variantID: item.VariantID}
+}
+
+// stockRequest is the total quantity requested for a single product or variant.
+type stockRequest struct {
+	cartLine
+	name     string
+	quantity int32
+}
+
+// lockedStock holds the product and variant rows locked by reserveStock, by ID.
+type lockedStock struct {
+	products map[string]database.Product
+	variants map[string]database.ProductVariant
+}
+
+// aggregateStockRequests sums quantities per product variant and sorts the result by product ID, then variant ID.
+// A stable lock order prevents deadlocks between concurrent checkouts sharing products.
+func aggregateStockRequests(items []models.CartItem) ([]stockRequest, error) {
+	totals := make(map[cartLine]int, len(items))
+	names := make(map[cartLine]string, len(items))
+	for _, item := range items {
+		line := lineOf(item)
+		totals[line] += item.Quantity
+		if names[line] == "" {
+			names[line] = item.Name
+		}
+	}
+
+	requests := make([]stockRequest, 0, len(totals))
+	for line, total := range totals {
+		qty32, err := safeIntToInt32(total)
+		if err != nil {
+			return nil, &handlers.AppError{Code: "invalid_quantity", Message: "Quantity too large", Err: err}
+		}
+		requests = append(requests, stockRequest{cartLine: line, name: names[line], quantity: qty32})
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].productID != requests[j].productID {
+			return requests[i].productID < requests[j].productID
+		}
+		return requests[i].variantID < requests[j].variantID
+	})
+
+	return requests, nil
+}
+
+// reserveStock locks the product and variant rows of the cart, verifies that every item is in stock and decrements it.
+// Variant lines draw from the variant's stock and lines without a variant from the product's stock; the latter are
+// only allowed for products without active variants, whose stock is held per variant.
+// The ProductAPI must be bound to the checkout transaction so the locks are held until commit or rollback.
+// Returns the locked rows, a "product_unavailable" AppError if a product or variant is inactive or a product with
+// variants is bought without one, or an "insufficient_stock" AppError listing every short item if any line cannot be
+// fulfilled.
+func reserveStock(ctx context.Context, products ProductAPI, items []models.CartItem, now time.Time) (lockedStock, error) {
+	requests, err := aggregateStockRequests(items)
+	if err != nil {
+		return lockedStock{}, err
+	}
+
+	// Lock rows and collect every shortage before touching stock
+	locked := lockedStock{
+		products: make(map[string]database.Product, len(requests)),
+		variants: make(map[string]database.ProductVariant),
+	}
+	var shortages []StockShortage
+	for _, req := range requests {
+		product, ok := locked.products[req.productID]
+		if !ok {
+			product, err = products.GetProductByIDForUpdate(ctx, req.productID)
+			if err != nil {
+				return lockedStock{}, &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
+			}
+			if !product.IsActive {
+				return lockedStock{}, &handlers.AppError{Code: "product_unavailable", Message: fmt.Sprintf("Product %s is not available", req.productID)}
+			}
+			locked.products[req.productID] = product
+		}
+
+		shortage := StockShortage{ProductID: req.productID, Name: product.Name, Requested: int(req.quantity)}
+		available := product.Stock
+		if req.variantID != "" {
+			variant, err := products.GetProductVariantByIDForUpdate(ctx, req.variantID)
+			if err != nil || variant.ProductID != req.productID {
+				return lockedStock{}, &handlers.AppError{Code: "variant_not_found", Message: "Product variant not found", Err: err}
+			}
+			if !variant.IsActive {
+				return lockedStock{}, &handlers.AppError{Code: "product_unavailable", Message: fmt.Sprintf("Variant %s is not available", variant.Sku)}
+			}
+			locked.variants[req.variantID] = variant
+			shortage.VariantID, shortage.SKU = variant.ID, variant.Sku
+			available = variant.Stock
+		} else {
+			hasVariants, err := products.ProductHasActiveVariants(ctx, req.productID)
+			if err != nil {
+				return lockedStock{}, &handlers.AppError{Code: "database_error", Message: "Failed to check product variants", Err: err}
+			}
+			if hasVariants {
+				return lockedStock{}, &handlers.AppError{Code: "product_unavailable", Message: fmt.Sprintf("Product %s requires a variant", req.productID)}
+			}
+		}
+
+		if available < req.quantity {
+			shortage.Available = int(available)
+			shortages = append(shortages, shortage)
+		}
+	}
+
+	if len(shortages) > 0 {
+		return lockedStock{}, newInsufficientStockError(shortages)
+	}
+
+	// Conditional decrement guards against any writer that bypasses the row lock
+	for _, req := range requests {
+		affected, err := decrementStock(ctx, products, req, now)
+		if err != nil {
+			return lockedStock{}, &handlers.AppError{Code: "update_stock_failed", Message: "Failed to update product stock", Err: err}
+		}
+		if affected == 0 {
+			shortages = append(shortages, StockShortage{
+				ProductID: req.productID,
+				VariantID: req.variantID,
+				SKU:       locked.variants[req.variantID].Sku,
+				Name:      req.name,
+				Requested: int(req.quantity),
+			})
+		}
+	}
+
+	if len(shortages) > 0 {
+		return lockedStock{}, newInsufficientStockError(shortages)
+	}
+
+	return locked, nil
+}
+
+// decrementStock decrements the stock of the variant, or of the product for a line without a variant.
+func decrementStock(ctx context.Context, products ProductAPI, req stockRequest, now time.Time) (int64, error) {
+	if req.variantID != "" {
+		return products.DecrementProductVariantStock(ctx, database.DecrementProductVariantStockParams{
+			Quantity:  req.quantity,
+			UpdatedAt: now,
+			ID:        req.variantID,
+		})
+	}
+	return products.DecrementProductStock(ctx, database.DecrementProductStockParams{
+		Quantity:  req.quantity,
+		UpdatedAt: now,
+		ID:        req.productID,
+	})
+}
+
+// newInsufficientStockError wraps the shortages in an "insufficient_stock" AppError.
+func newInsufficientStockError(shortages []StockShortage) error {
+	return &handlers.AppError{
+		Code:    "insufficient_stock",
+		Message: "Insufficient stock for one or more products",
+		Err:     &InsufficientStockError{Items: shortages},
+	}
+}
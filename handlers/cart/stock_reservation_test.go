@@ -0,0 +1,336 @@
+// Package carthandlers implements HTTP handlers for cart operations including user and guest carts.
+package carthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// stock_reservation_test.go: Tests for stock reservation, shortage reporting, and concurrent checkouts.
+
+// lockingStore is an in-memory product table that mimics SELECT ... FOR UPDATE row locks.
+// Locks taken through a transaction are held until that transaction commits or rolls back.
+type lockingStore struct {
+	mu       sync.Mutex
+	stock    map[string]int32
+	rowLocks map[string]*sync.Mutex
+}
+
+func newLockingStore(stock map[string]int32) *lockingStore {
+	locks := make(map[string]*sync.Mutex, len(stock))
+	for id := range stock {
+		locks[id] = &sync.Mutex{}
+	}
+	return &lockingStore{stock: stock, rowLocks: locks}
+}
+
+func (s *lockingStore) stockOf(id string) int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stock[id]
+}
+
+// lockingTx records the row locks and pending stock changes of a transaction.
+type lockingTx struct {
+	store   *lockingStore
+	held    []*sync.Mutex
+	pending map[string]int32
+	done    bool
+}
+
+func (tx *lockingTx) release() {
+	for _, l := range tx.held {
+		l.Unlock()
+	}
+	tx.held = nil
+	tx.done = true
+}
+
+func (tx *lockingTx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.store.mu.Lock()
+	for id, delta := range tx.pending {
+		tx.store.stock[id] += delta
+	}
+	tx.store.mu.Unlock()
+	tx.release()
+	return nil
+}
+
+func (tx *lockingTx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.release()
+	return nil
+}
+
+type lockingDBConn struct{ store *lockingStore }
+
+func (c *lockingDBConn) BeginTx(_ context.Context, _ *sql.TxOptions) (DBTxAPI, error) {
+	return &lockingTx{store: c.store, pending: map[string]int32{}}, nil
+}
+
+// lockingProductAPI implements ProductAPI on top of lockingStore.
+type lockingProductAPI struct {
+	store *lockingStore
+	tx    *lockingTx
+}
+
+func (p *lockingProductAPI) WithTx(tx DBTxAPI) ProductAPI {
+	return &lockingProductAPI{store: p.store, tx: tx.(*lockingTx)}
+}
+
+func (p *lockingProductAPI) GetProductByID(_ context.Context, productID string) (database.Product, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+	stock, ok := p.store.stock[productID]
+	if !ok {
+		return database.Product{}, sql.ErrNoRows
+	}
+	return database.Product{ID: productID, Name: productID, Price: "10.00", Stock: stock, IsActive: true}, nil
+}
+
+func (p *lockingProductAPI) GetProductByIDForUpdate(ctx context.Context, productID string) (database.Product, error) {
+	p.store.mu.Lock()
+	lock, ok := p.store.rowLocks[productID]
+	p.store.mu.Unlock()
+	if !ok {
+		return database.Product{}, sql.ErrNoRows
+	}
+	lock.Lock()
+	p.tx.held = append(p.tx.held, lock)
+	product, err := p.GetProductByID(ctx, productID)
+	product.Stock += p.tx.pending[productID]
+	return product, err
+}
+
+func (p *lockingProductAPI) DecrementProductStock(_ context.Context, params database.DecrementProductStockParams) (int64, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+	if p.store.stock[params.ID]+p.tx.pending[params.ID] < params.Quantity {
+		return 0, nil
+	}
+	p.tx.pending[params.ID] -= params.Quantity
+	return 1, nil
+}
+
+// The locking store holds no variants; checkouts exercised through it only carry product lines.
+func (p *lockingProductAPI) ProductHasActiveVariants(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (p *lockingProductAPI) GetProductVariantByID(_ context.Context, _ string) (database.ProductVariant, error) {
+	return database.ProductVariant{}, sql.ErrNoRows
+}
+
+func (p *lockingProductAPI) GetProductVariantByIDForUpdate(_ context.Context, _ string) (database.ProductVariant, error) {
+	return database.ProductVariant{}, sql.ErrNoRows
+}
+
+func (p *lockingProductAPI) DecrementProductVariantStock(_ context.Context, _ database.DecrementProductVariantStockParams) (int64, error) {
+	return 0, nil
+}
+
+type noopOrderAPI struct{}
+
+func (o noopOrderAPI) WithTx(_ DBTxAPI) OrderAPI { return o }
+func (o noopOrderAPI) CreateOrder(_ context.Context, _ database.CreateOrderParams) error {
+	return nil
+}
+func (o noopOrderAPI) CreateOrderItem(_ context.Context, _ database.CreateOrderItemParams) error {
+	return nil
+}
+
+// TestProcessCheckout_ConcurrentNoOversell runs many parallel checkouts against a limited stock
+// and verifies that exactly as many succeed as there are units, and stock never goes negative.
+func TestProcessCheckout_ConcurrentNoOversell(t *testing.T) {
+	const (
+		initialStock = 5
+		buyers       = 25
+	)
+
+	store := newLockingStore(map[string]int32{"prod1": initialStock, "prod2": 100})
+	mockCartMongo := new(MockCartMongoAPI)
+	mockCartMongo.On("ClearCart", mock.Anything, mock.Anything).Return(nil)
+
+	svc := &cartServiceImpl{
+		cartMongo: mockCartMongo,
+		product:   &lockingProductAPI{store: store},
+		order:     noopOrderAPI{},
+		dbConn:    &lockingDBConn{store: store},
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		shortages int
+	)
+	start := make(chan struct{})
+	for i := range buyers {
+		wg.Add(1)
+		go func(buyer int) {
+			defer wg.Done()
+			<-start
+			cart := &models.Cart{Items: []models.CartItem{
+				{ProductID: "prod2", Quantity: 1, Price: money.MustParse("10.00", money.USD)},
+				{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD)},
+			}}
+			_, err := svc.processCheckout(context.Background(), cart, fmt.Sprintf("user-%d", buyer))
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+				return
+			}
+			appErr := &handlers.AppError{}
+			if errors.As(err, &appErr) && appErr.Code == "insufficient_stock" {
+				shortages++
+				return
+			}
+			t.Errorf("unexpected checkout error: %v", err)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, initialStock, succeeded)
+	assert.Equal(t, buyers-initialStock, shortages)
+	assert.Equal(t, int32(0), store.stockOf("prod1"))
+	assert.Equal(t, int32(100-initialStock), store.stockOf("prod2"))
+}
+
+// TestReserveStock_ListsEveryShortItem verifies that all short items are reported and no stock is decremented.
+func TestReserveStock_ListsEveryShortItem(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "a").Return(database.Product{ID: "a", Name: "A", Stock: 1, IsActive: true}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "b").Return(database.Product{ID: "b", Name: "B", Stock: 10, IsActive: true}, nil)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "c").Return(database.Product{ID: "c", Name: "C", Stock: 0, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+
+	items := []models.CartItem{
+		{ProductID: "c", Quantity: 2},
+		{ProductID: "a", Quantity: 1},
+		{ProductID: "b", Quantity: 3},
+		{ProductID: "a", Quantity: 2},
+	}
+
+	_, err := reserveStock(context.Background(), mockProduct, items, time.Now().UTC())
+	require.Error(t, err)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "insufficient_stock", appErr.Code)
+
+	var stockErr *InsufficientStockError
+	require.True(t, errors.As(err, &stockErr))
+	assert.Equal(t, []StockShortage{
+		{ProductID: "a", Name: "A", Requested: 3, Available: 1},
+		{ProductID: "c", Name: "C", Requested: 2, Available: 0},
+	}, stockErr.Items)
+	assert.Contains(t, stockErr.Error(), "a, c")
+
+	mockProduct.AssertNotCalled(t, "DecrementProductStock", mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_ConditionalDecrementFails verifies that a decrement affecting no rows is reported as a shortage.
+func TestReserveStock_ConditionalDecrementFails(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "a").Return(database.Product{ID: "a", Name: "A", Stock: 5, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, mock.Anything).Return(false, nil)
+	mockProduct.On("DecrementProductStock", mock.Anything, mock.MatchedBy(func(p database.DecrementProductStockParams) bool {
+		return p.ID == "a" && p.Quantity == 2
+	})).Return(int64(0), nil)
+
+	_, err := reserveStock(context.Background(), mockProduct, []models.CartItem{{ProductID: "a", Name: "A", Quantity: 2}}, time.Now().UTC())
+
+	var stockErr *InsufficientStockError
+	require.True(t, errors.As(err, &stockErr))
+	assert.Equal(t, []StockShortage{{ProductID: "a", Name: "A", Requested: 2}}, stockErr.Items)
+}
+
+// TestReserveStock_QuantityOverflow verifies that aggregated quantities exceeding int32 are rejected.
+func TestReserveStock_QuantityOverflow(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	items := []models.CartItem{
+		{ProductID: "a", Quantity: 1 << 30},
+		{ProductID: "a", Quantity: 1 << 30},
+	}
+
+	_, err := reserveStock(context.Background(), mockProduct, items, time.Now().UTC())
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "invalid_quantity", appErr.Code)
+	mockProduct.AssertNotCalled(t, "GetProductByIDForUpdate", mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_InactiveProduct verifies that an inactive product is rejected before any stock is decremented.
+func TestReserveStock_InactiveProduct(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "a").Return(database.Product{ID: "a", Name: "A", Stock: 5}, nil)
+
+	_, err := reserveStock(context.Background(), mockProduct, []models.CartItem{{ProductID: "a", Quantity: 1}}, time.Now().UTC())
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "product_unavailable", appErr.Code)
+	assert.Equal(t, "Product a is not available", appErr.Message)
+	mockProduct.AssertNotCalled(t, "DecrementProductStock", mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_VariantShortage verifies that variant lines are checked against the variant's own stock
+// and reported with its SKU, independently of the product's stock and of other variants.
+func TestReserveStock_VariantShortage(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "a").Return(database.Product{ID: "a", Name: "A", Stock: 100, IsActive: true}, nil)
+	mockProduct.On("GetProductVariantByIDForUpdate", mock.Anything, "a-m").Return(database.ProductVariant{ID: "a-m", ProductID: "a", Sku: "A-M", Stock: 5, IsActive: true}, nil)
+	mockProduct.On("GetProductVariantByIDForUpdate", mock.Anything, "a-xl").Return(database.ProductVariant{ID: "a-xl", ProductID: "a", Sku: "A-XL", Stock: 1, IsActive: true}, nil)
+
+	items := []models.CartItem{
+		{ProductID: "a", VariantID: "a-xl", Quantity: 2},
+		{ProductID: "a", VariantID: "a-m", Quantity: 2},
+	}
+
+	_, err := reserveStock(context.Background(), mockProduct, items, time.Now().UTC())
+
+	var stockErr *InsufficientStockError
+	require.True(t, errors.As(err, &stockErr))
+	assert.Equal(t, []StockShortage{{ProductID: "a", VariantID: "a-xl", SKU: "A-XL", Name: "A", Requested: 2, Available: 1}}, stockErr.Items)
+	mockProduct.AssertNotCalled(t, "DecrementProductVariantStock", mock.Anything, mock.Anything)
+	mockProduct.AssertNotCalled(t, "DecrementProductStock", mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_VariantRequired verifies that a line without a variant is rejected once its product has active
+// variants, since the product's own stock is no longer sold.
+func TestReserveStock_VariantRequired(t *testing.T) {
+	mockProduct := new(MockProductAPI)
+	mockProduct.On("GetProductByIDForUpdate", mock.Anything, "a").Return(database.Product{ID: "a", Name: "A", Stock: 100, IsActive: true}, nil)
+	mockProduct.On("ProductHasActiveVariants", mock.Anything, "a").Return(true, nil)
+
+	_, err := reserveStock(context.Background(), mockProduct, []models.CartItem{{ProductID: "a", Quantity: 1}}, time.Now().UTC())
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "product_unavailable", appErr.Code)
+	assert.Equal(t, "Product a requires a variant", appErr.Message)
+	mockProduct.AssertNotCalled(t, "DecrementProductStock", mock.Anything, mock.Anything)
+}
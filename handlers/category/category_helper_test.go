@@ -12,6 +12,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // category_helper_test.go: Implements category CRUD HTTP handlers with supporting mocks for unit and integration testing.
@@ -90,7 +91,7 @@ func (cfg *TestHandlersCategoryConfig) HandlerGetAllCategories(w http.ResponseWr
 	ctx := r.Context()
 
 	categoryService := cfg.GetCategoryService()
-	categories, err := categoryService.GetAllCategories(ctx)
+	categories, err := categoryService.GetAllCategories(ctx, pagination.ParseRequest(r))
 	if err != nil {
 		cfg.handleCategoryError(w, r, err, "get_all_categories", ip, userAgent)
 		return
@@ -105,8 +106,8 @@ func (cfg *TestHandlersCategoryConfig) HandlerGetAllCategories(w http.ResponseWr
 		UpdatedAt   time.Time `json:"updated_at"`
 	}
 
-	responseCategories := make([]CategoryResponse, len(categories))
-	for i, cat := range categories {
+	responseCategories := make([]CategoryResponse, len(categories.Data))
+	for i, cat := range categories.Data {
 		responseCategories[i] = CategoryResponse{
 			ID:          cat.ID,
 			Name:        cat.Name,
@@ -116,7 +117,12 @@ func (cfg *TestHandlersCategoryConfig) HandlerGetAllCategories(w http.ResponseWr
 		}
 	}
 
-	err = json.NewEncoder(w).Encode(responseCategories)
+	err = json.NewEncoder(w).Encode(pagination.Page[CategoryResponse]{
+		Data:       responseCategories,
+		Limit:      categories.Limit,
+		NextCursor: categories.NextCursor,
+		HasNext:    categories.HasNext,
+	})
 	if err != nil {
 		println("json.Encode failed:", err.Error())
 	}
@@ -152,8 +158,8 @@ func (m *MockCategoryDBQueries) DeleteCategory(ctx context.Context, id string) e
 	return args.Error(0)
 }
 
-func (m *MockCategoryDBQueries) GetAllCategories(ctx context.Context) ([]database.Category, error) {
-	args := m.Called(ctx)
+func (m *MockCategoryDBQueries) GetAllCategories(ctx context.Context, params database.GetAllCategoriesParams) ([]database.Category, error) {
+	args := m.Called(ctx, params)
 	return args.Get(0).([]database.Category), args.Error(1)
 }
 
@@ -213,9 +219,9 @@ func (m *MockCategoryService) DeleteCategory(ctx context.Context, categoryID str
 	return args.Error(0)
 }
 
-func (m *MockCategoryService) GetAllCategories(ctx context.Context) ([]database.Category, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.Category), args.Error(1)
+func (m *MockCategoryService) GetAllCategories(ctx context.Context, page pagination.Request) (pagination.Page[database.Category], error) {
+	args := m.Called(ctx, page)
+	return args.Get(0).(pagination.Page[database.Category]), args.Error(1)
 }
 
 // MockCategoryService for integration tests
@@ -235,14 +241,14 @@ func (m *MockCategoryServiceForGetIntegration) DeleteCategory(ctx context.Contex
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *MockCategoryServiceForGetIntegration) GetAllCategories(ctx context.Context) ([]database.Category, error) {
+func (m *MockCategoryServiceForGetIntegration) GetAllCategories(ctx context.Context, page pagination.Request) (pagination.Page[database.Category], error) {
 	println("DEBUG: mock called with ctx:", ctx)
-	args := m.Called(ctx)
+	args := m.Called(ctx, page)
 	err := args.Error(1)
 	if err != nil {
 		println("DEBUG: mock returning error:", err.Error())
 	}
-	return args.Get(0).([]database.Category), err
+	return args.Get(0).(pagination.Page[database.Category]), err
 }
 
 // MockLogger for integration tests
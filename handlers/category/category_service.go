@@ -10,6 +10,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -21,7 +22,7 @@ type CategoryDBQueries interface {
 	CreateCategory(ctx context.Context, params database.CreateCategoryParams) error
 	UpdateCategories(ctx context.Context, params database.UpdateCategoriesParams) error
 	DeleteCategory(ctx context.Context, id string) error
-	GetAllCategories(ctx context.Context) ([]database.Category, error)
+	GetAllCategories(ctx context.Context, params database.GetAllCategoriesParams) ([]database.Category, error)
 }
 
 // CategoryDBConn defines the interface for beginning database transactions for category operations.
@@ -64,9 +65,9 @@ func (a *CategoryDBQueriesAdapter) DeleteCategory(ctx context.Context, id string
 	return a.Queries.DeleteCategory(ctx, id)
 }
 
-// GetAllCategories retrieves all categories from the database.
-func (a *CategoryDBQueriesAdapter) GetAllCategories(ctx context.Context) ([]database.Category, error) {
-	return a.Queries.GetAllCategories(ctx)
+// GetAllCategories retrieves one page of categories from the database.
+func (a *CategoryDBQueriesAdapter) GetAllCategories(ctx context.Context, params database.GetAllCategoriesParams) ([]database.Category, error) {
+	return a.Queries.GetAllCategories(ctx, params)
 }
 
 // CategoryDBConnAdapter adapts a sql.DB to the CategoryDBConn interface.
@@ -92,7 +93,7 @@ type CategoryService interface {
 	CreateCategory(ctx context.Context, params CategoryRequest) (string, error)
 	UpdateCategory(ctx context.Context, params CategoryRequest) error
 	DeleteCategory(ctx context.Context, categoryID string) error
-	GetAllCategories(ctx context.Context) ([]database.Category, error)
+	GetAllCategories(ctx context.Context, page pagination.Request) (pagination.Page[database.Category], error)
 }
 
 // CategoryRequest represents the request parameters for category operations.
@@ -267,14 +268,44 @@ func (s *categoryServiceImpl) DeleteCategory(ctx context.Context, categoryID str
 	return nil
 }
 
-// GetAllCategories returns all categories.
-// Returns a list of all categories or an error.
-func (s *categoryServiceImpl) GetAllCategories(ctx context.Context) ([]database.Category, error) {
+// categorySortOptions lists the accepted category list sorts; empty means by name.
+var categorySortOptions = map[string]bool{"": true, "name_asc": true, "name_desc": true, "newest": true, "oldest": true}
+
+// GetAllCategories returns one page of categories, sorted by name unless the page asks otherwise.
+// Returns the page, with a cursor for the next one, or an error.
+func (s *categoryServiceImpl) GetAllCategories(ctx context.Context, page pagination.Request) (pagination.Page[database.Category], error) {
 	if s.db == nil {
-		return nil, &handlers.AppError{Code: "database_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+		return pagination.Page[database.Category]{}, &handlers.AppError{Code: "database_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+	}
+	if !categorySortOptions[page.Sort] {
+		return pagination.Page[database.Category]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid sort option"}
+	}
+	sort := page.Sort
+	if sort == "" {
+		sort = "name_asc"
+	}
+	cursor, err := pagination.Decode(page.Cursor, sort)
+	if err != nil {
+		return pagination.Page[database.Category]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid cursor", Err: err}
 	}
 
-	return s.db.GetAllCategories(ctx)
+	limit := pagination.NormalizeLimit(page.Limit)
+	categories, err := s.db.GetAllCategories(ctx, database.GetAllCategoriesParams{
+		CursorID:        cursor.IDArg(),
+		Sort:            sort,
+		CursorValue:     cursor.ValueArg(),
+		CursorCreatedAt: cursor.CreatedAtArg(),
+		Limit:           pagination.FetchLimit(limit),
+	})
+	if err != nil {
+		return pagination.Page[database.Category]{}, err
+	}
+	return pagination.NewPage(categories, limit, func(c database.Category) pagination.Cursor {
+		if sort == "newest" || sort == "oldest" {
+			return pagination.Cursor{Sort: sort, CreatedAt: c.CreatedAt, ID: c.ID}
+		}
+		return pagination.Cursor{Sort: sort, Value: c.Name, ID: c.ID}
+	}), nil
 }
 
 // CategoryError is an alias for handlers.AppError, used for category-related errors.
@@ -15,6 +15,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -314,7 +315,7 @@ func TestCategoryServiceImpl_GetAllCategories(t *testing.T) {
 						UpdatedAt:   time.Now(),
 					},
 				}
-				mockDB.On("GetAllCategories", mock.Anything).Return(expectedCategories, nil)
+				mockDB.On("GetAllCategories", mock.Anything, mock.Anything).Return(expectedCategories, nil)
 			},
 			expectedResult: []database.Category{
 				{
@@ -337,7 +338,7 @@ func TestCategoryServiceImpl_GetAllCategories(t *testing.T) {
 		{
 			name: "database error",
 			setupMocks: func(mockDB *MockCategoryDBQueries) {
-				mockDB.On("GetAllCategories", mock.Anything).Return([]database.Category{}, errors.New("database error"))
+				mockDB.On("GetAllCategories", mock.Anything, mock.Anything).Return([]database.Category{}, errors.New("database error"))
 			},
 			expectedResult: []database.Category{},
 			expectedError:  true,
@@ -357,7 +358,7 @@ func TestCategoryServiceImpl_GetAllCategories(t *testing.T) {
 				db: mockDB,
 			}
 
-			result, err := service.GetAllCategories(context.Background())
+			result, err := service.GetAllCategories(context.Background(), pagination.Request{})
 
 			if tt.expectedError {
 				require.Error(t, err)
@@ -367,7 +368,7 @@ func TestCategoryServiceImpl_GetAllCategories(t *testing.T) {
 				}
 			} else {
 				require.NoError(t, err)
-				assert.Len(t, result, len(tt.expectedResult))
+				assert.Len(t, result.Data, len(tt.expectedResult))
 			}
 
 			mockDB.AssertExpectations(t)
@@ -375,6 +376,47 @@ func TestCategoryServiceImpl_GetAllCategories(t *testing.T) {
 	}
 }
 
+// TestCategoryServiceImpl_GetAllCategories_Cursor tests that a full page returns a name cursor that the next page passes to the query.
+func TestCategoryServiceImpl_GetAllCategories_Cursor(t *testing.T) {
+	mockDB := &MockCategoryDBQueries{}
+	service := &categoryServiceImpl{db: mockDB}
+	mockDB.On("GetAllCategories", mock.Anything, database.GetAllCategoriesParams{Sort: "name_asc", Limit: 2}).
+		Return([]database.Category{{ID: "cat1", Name: "Books"}, {ID: "cat2", Name: "Games"}}, nil).Once()
+
+	first, err := service.GetAllCategories(context.Background(), pagination.Request{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []database.Category{{ID: "cat1", Name: "Books"}}, first.Data)
+	require.True(t, first.HasNext)
+
+	mockDB.On("GetAllCategories", mock.Anything, database.GetAllCategoriesParams{
+		CursorID:    sql.NullString{String: "cat1", Valid: true},
+		Sort:        "name_asc",
+		CursorValue: sql.NullString{String: "Books", Valid: true},
+		Limit:       2,
+	}).Return([]database.Category{{ID: "cat2", Name: "Games"}}, nil).Once()
+
+	second, err := service.GetAllCategories(context.Background(), pagination.Request{Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []database.Category{{ID: "cat2", Name: "Games"}}, second.Data)
+	assert.False(t, second.HasNext)
+	mockDB.AssertExpectations(t)
+}
+
+// TestCategoryServiceImpl_GetAllCategories_InvalidPage tests that unknown sorts and cursors issued for another sort are rejected.
+func TestCategoryServiceImpl_GetAllCategories_InvalidPage(t *testing.T) {
+	service := &categoryServiceImpl{db: &MockCategoryDBQueries{}}
+	pages := []pagination.Request{
+		{Sort: "price_asc"},
+		{Sort: "newest", Cursor: pagination.Cursor{Sort: "name_asc", ID: "cat1"}.Encode()},
+	}
+	for _, page := range pages {
+		_, err := service.GetAllCategories(context.Background(), page)
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "invalid_request", appErr.Code)
+	}
+}
+
 // TestCategoryServiceImpl_CreateCategory_NilDBConn tests the CreateCategory method when the database connection is nil.
 // This edge case test ensures that the service properly handles the scenario where the database connection
 // hasn't been initialized, returning an appropriate error with the correct error code and message.
@@ -448,7 +490,7 @@ func TestCategoryServiceImpl_GetAllCategories_NilDB(t *testing.T) {
 		dbConn: &CategoryDBConnAdapter{},
 	}
 
-	_, err := service.GetAllCategories(context.Background())
+	_, err := service.GetAllCategories(context.Background(), pagination.Request{})
 
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
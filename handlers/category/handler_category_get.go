@@ -8,26 +8,30 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/middlewares"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
-// handler_category_get.go: Provides HTTP handler to retrieve all categories.
+// handler_category_get.go: Provides HTTP handler to retrieve categories one page at a time.
 
-// HandlerGetAllCategories handles HTTP GET requests to retrieve all categories.
+// HandlerGetAllCategories handles HTTP GET requests to retrieve a page of categories.
 // @Summary      Get all categories
-// @Description  Retrieves all product categories
+// @Description  Retrieves a page of product categories. Pass next_cursor back as cursor to get the next page
 // @Tags         categories
 // @Produce      json
-// @Success      200  {array}  map[string]interface{}
+// @Param        sort    query  string  false  "name_asc (default), name_desc, newest, oldest"
+// @Param        limit   query  int     false  "Categories per page, 1-100 (default 20)"
+// @Param        cursor  query  string  false  "next_cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Router       /v1/categories/ [get]
 func (cfg *HandlersCategoryConfig) HandlerGetAllCategories(w http.ResponseWriter, r *http.Request, user *database.User) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
 	ctx := r.Context()
 
-	// Call the service to get all categories
-	categories, err := cfg.GetCategoryService().GetAllCategories(ctx)
+	// Call the service to get a page of categories
+	categories, err := cfg.GetCategoryService().GetAllCategories(ctx, pagination.ParseRequest(r))
 	if err != nil {
 		log.Printf("HandlerGetAllCategories: error from service: %+v", err)
 		cfg.handleCategoryError(w, r, err, "get_all_categories", ip, userAgent)
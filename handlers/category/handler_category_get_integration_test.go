@@ -11,9 +11,11 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -43,7 +45,7 @@ func TestIntegration_HandlerGetAllCategories(t *testing.T) {
 				UpdatedAt:   now,
 			},
 		}
-		mockService.On("GetAllCategories", mock.Anything).Return(expectedCategories, nil)
+		mockService.On("GetAllCategories", mock.Anything, mock.Anything).Return(pagination.Page[database.Category]{Data: expectedCategories}, nil)
 
 		mockLogger.On(
 			"LogHandlerSuccess",
@@ -61,11 +63,16 @@ func TestIntegration_HandlerGetAllCategories(t *testing.T) {
 		cfg.HandlerGetAllCategories(w, req, user)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var got []map[string]any
+		var got struct {
+			Data    []map[string]any `json:"data"`
+			HasNext bool             `json:"has_next"`
+		}
 		_ = json.Unmarshal(w.Body.Bytes(), &got)
-		assert.Equal(t, "cat1", got[0]["ID"])
-		assert.Equal(t, "Category 1", got[0]["Name"])
-		description := got[0]["Description"].(map[string]any)
+		require.Len(t, got.Data, 1)
+		assert.False(t, got.HasNext)
+		assert.Equal(t, "cat1", got.Data[0]["ID"])
+		assert.Equal(t, "Category 1", got.Data[0]["Name"])
+		description := got.Data[0]["Description"].(map[string]any)
 		assert.Equal(t, "Description 1", description["String"])
 		mockService.AssertExpectations(t)
 	})
@@ -75,7 +82,7 @@ func TestIntegration_HandlerGetAllCategories(t *testing.T) {
 		mockLogger := &MockLoggerForGetIntegration{}
 		cfg.categoryService = mockService
 		cfg.Logger = mockLogger
-		mockService.On("GetAllCategories", mock.Anything).Return([]database.Category{}, &handlers.AppError{
+		mockService.On("GetAllCategories", mock.Anything, mock.Anything).Return(pagination.Page[database.Category]{Data: []database.Category{}}, &handlers.AppError{
 			Code:    "database_error",
 			Message: "Database connection failed",
 		})
@@ -12,6 +12,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -50,7 +51,7 @@ func TestHandlerGetAllCategories(t *testing.T) {
 						UpdatedAt:   time.Now(),
 					},
 				}
-				mockService.On("GetAllCategories", mock.Anything).Return(expectedCategories, nil)
+				mockService.On("GetAllCategories", mock.Anything, mock.Anything).Return(pagination.Page[database.Category]{Data: expectedCategories}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `[{"id":"cat1","name":"Category 1","description":"Description 1","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"},{"id":"cat2","name":"Category 2","description":"Description 2","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}]`,
@@ -68,7 +69,7 @@ func TestHandlerGetAllCategories(t *testing.T) {
 						UpdatedAt:   time.Now(),
 					},
 				}
-				mockService.On("GetAllCategories", mock.Anything).Return(expectedCategories, nil)
+				mockService.On("GetAllCategories", mock.Anything, mock.Anything).Return(pagination.Page[database.Category]{Data: expectedCategories}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `[{"id":"cat1","name":"Category 1","description":"Description 1","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}]`,
@@ -80,7 +81,7 @@ func TestHandlerGetAllCategories(t *testing.T) {
 				Name: "Test User",
 			},
 			setupMocks: func(mockService *MockCategoryService) {
-				mockService.On("GetAllCategories", mock.Anything).Return([]database.Category{}, &handlers.AppError{
+				mockService.On("GetAllCategories", mock.Anything, mock.Anything).Return(pagination.Page[database.Category]{Data: []database.Category{}}, &handlers.AppError{
 					Code:    "database_error",
 					Message: "Database connection failed",
 				})
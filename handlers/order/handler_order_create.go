@@ -23,6 +23,7 @@ import (
 // @Param        order  body  object{}  true  "Order payload"
 // @Success      201  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  PriceChangedResponse
 // @Router       /v1/orders/ [post]
 func (cfg *HandlersOrderConfig) HandlerCreateOrder(w http.ResponseWriter, r *http.Request, user database.User) {
 	// Extract request metadata for logging
@@ -16,6 +16,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // handler_order_create_test.go: Tests for HandlerCreateOrder covering success, validation errors, service errors, and full request scenarios.
@@ -36,8 +37,8 @@ func TestHandlerCreateOrder_Success(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2, Price: 10.50},
-			{ProductID: "prod2", Quantity: 1, Price: 25.00},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.50", money.USD)},
+			{ProductID: "prod2", Quantity: 1, Price: money.MustParse("25.00", money.USD)},
 		},
 		PaymentMethod:   "credit_card",
 		ShippingAddress: "123 Main St",
@@ -147,7 +148,7 @@ func TestHandlerCreateOrder_InvalidQuantity(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 0, Price: 10.50},
+			{ProductID: "prod1", Quantity: 0, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 	appError := &handlers.AppError{Code: "invalid_request", Message: "Quantity must be greater than 0"}
@@ -160,7 +161,7 @@ func TestHandlerCreateOrder_QuantityOverflow(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2147483648, Price: 10.50}, // Exceeds int32 max
+			{ProductID: "prod1", Quantity: 2147483648, Price: money.MustParse("10.50", money.USD)}, // Exceeds int32 max
 		},
 	}
 	appError := &handlers.AppError{
@@ -176,7 +177,7 @@ func TestHandlerCreateOrder_TransactionError(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 1, Price: 10.50},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 	appError := &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction"}
@@ -189,7 +190,7 @@ func TestHandlerCreateOrder_CreateOrderError(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 1, Price: 10.50},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 	appError := &handlers.AppError{Code: "create_order_error", Message: "Error creating order"}
@@ -213,7 +214,7 @@ func TestHandlerCreateOrder_UnknownError(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 1, Price: 10.50},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 
@@ -254,7 +255,7 @@ func TestHandlerCreateOrder_CompleteRequest(t *testing.T) {
 	user := database.User{ID: "user123"}
 	requestBody := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2, Price: 10.50},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.50", money.USD)},
 		},
 		PaymentMethod:     "credit_card",
 		ShippingAddress:   "123 Main St",
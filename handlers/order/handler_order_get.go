@@ -9,18 +9,22 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/middlewares"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
 // handler_order_get.go: HTTP handlers for fetching orders and order items, with service calls and structured logging.
 
-// HandlerGetAllOrders handles HTTP GET requests to retrieve all orders (admin only).
+// HandlerGetAllOrders handles HTTP GET requests to retrieve a page of orders (admin only).
 // @Summary      Get all orders
-// @Description  Retrieves all orders (admin only)
+// @Description  Retrieves a page of orders (admin only). Pass next_cursor back as cursor to get the next page
 // @Tags         orders
 // @Produce      json
-// @Success      200  {array}  map[string]interface{}
+// @Param        sort    query  string  false  "newest (default), oldest"
+// @Param        limit   query  int     false  "Orders per page, 1-100 (default 20)"
+// @Param        cursor  query  string  false  "next_cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Router       /v1/orders/ [get]
 func (cfg *HandlersOrderConfig) HandlerGetAllOrders(w http.ResponseWriter, r *http.Request, _ database.User) {
@@ -28,8 +32,8 @@ func (cfg *HandlersOrderConfig) HandlerGetAllOrders(w http.ResponseWriter, r *ht
 	ip, userAgent := handlers.GetRequestMetadata(r)
 	ctx := r.Context()
 
-	// Call business logic service to retrieve all orders
-	orders, err := cfg.GetOrderService().GetAllOrders(ctx)
+	// Call business logic service to retrieve the requested page of orders
+	orders, err := cfg.GetOrderService().GetAllOrders(ctx, pagination.ParseRequest(r))
 	if err != nil {
 		// Handle and log any errors from the service layer
 		cfg.handleOrderError(w, r, err, "list_all_orders", ip, userAgent)
@@ -39,7 +43,7 @@ func (cfg *HandlersOrderConfig) HandlerGetAllOrders(w http.ResponseWriter, r *ht
 	// Log successful retrieval of all orders
 	cfg.Logger.LogHandlerSuccess(ctx, "list_all_orders", "Listed all orders", ip, userAgent)
 
-	// Respond with complete order list
+	// Respond with the page and its next cursor
 	middlewares.RespondWithJSON(w, http.StatusOK, orders)
 }
 
@@ -15,6 +15,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // handler_order_get_test.go: Tests for order handlers, verifying behavior for admin and user endpoints including success, validation, and error handling.
@@ -32,24 +33,31 @@ func TestHandlerGetAllOrders_Success(t *testing.T) {
 		orderService: mockOrderService,
 	}
 
-	expectedOrders := []database.Order{
-		{ID: "order1", UserID: "user1", TotalAmount: "100.00", Status: "pending"},
-		{ID: "order2", UserID: "user2", TotalAmount: "200.00", Status: "completed"},
+	expectedOrders := pagination.Page[database.Order]{
+		Data: []database.Order{
+			{ID: "order1", UserID: "user1", TotalAmount: "100.00", Status: "pending"},
+			{ID: "order2", UserID: "user2", TotalAmount: "200.00", Status: "completed"},
+		},
+		Limit:      2,
+		NextCursor: "next",
+		HasNext:    true,
 	}
 
-	mockOrderService.On("GetAllOrders", mock.Anything).Return(expectedOrders, nil)
+	mockOrderService.On("GetAllOrders", mock.Anything, pagination.Request{Cursor: "abc", Limit: 2, Sort: "oldest"}).Return(expectedOrders, nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "list_all_orders", "Listed all orders", mock.Anything, mock.Anything).Return()
 
-	req := httptest.NewRequest("GET", "/orders", nil)
+	req := httptest.NewRequest("GET", "/orders?cursor=abc&limit=2&sort=oldest", nil)
 	w := httptest.NewRecorder()
 
 	cfg.HandlerGetAllOrders(w, req, database.User{})
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	var response []database.Order
+	var response pagination.Page[database.Order]
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Len(t, response, 2)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "next", response.NextCursor)
+	assert.True(t, response.HasNext)
 
 	mockOrderService.AssertExpectations(t)
 	mockLogger.AssertExpectations(t)
@@ -69,7 +77,7 @@ func TestHandlerGetAllOrders_ServiceError(t *testing.T) {
 	}
 
 	appError := &handlers.AppError{Code: "database_error", Message: "Database connection failed"}
-	mockOrderService.On("GetAllOrders", mock.Anything).Return(([]database.Order)(nil), appError)
+	mockOrderService.On("GetAllOrders", mock.Anything, mock.Anything).Return(pagination.Page[database.Order]{}, appError)
 	mockLogger.On("LogHandlerError", mock.Anything, "list_all_orders", "internal_error", "Database connection failed", mock.Anything, mock.Anything, nil).Return()
 
 	req := httptest.NewRequest("GET", "/orders", nil)
@@ -392,7 +400,7 @@ func TestHandlerGetAllOrders_UnknownError(t *testing.T) {
 	}
 
 	unknownError := errors.New("unknown database error")
-	mockOrderService.On("GetAllOrders", mock.Anything).Return(([]database.Order)(nil), unknownError)
+	mockOrderService.On("GetAllOrders", mock.Anything, mock.Anything).Return(pagination.Page[database.Order]{}, unknownError)
 	mockLogger.On("LogHandlerError", mock.Anything, "list_all_orders", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, unknownError).Return()
 
 	req := httptest.NewRequest("GET", "/orders", nil)
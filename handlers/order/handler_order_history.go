@@ -0,0 +1,63 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_order_history.go: Handles HTTP request to retrieve the status history of an order for its owner or an admin.
+
+// HandlerGetOrderStatusHistory handles HTTP GET requests to retrieve the status history of an order.
+// @Summary      Get order status history
+// @Description  Retrieves every status change of an order, oldest first (order owner or admin)
+// @Tags         orders
+// @Produce      json
+// @Param        order_id  path  string  true  "Order ID"
+// @Success      200  {array}   OrderStatusHistoryResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/orders/{order_id}/history [get]
+func (cfg *HandlersOrderConfig) HandlerGetOrderStatusHistory(w http.ResponseWriter, r *http.Request, user database.User) {
+	// Extract request metadata for logging
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	// Extract order ID from URL parameters
+	orderID := chi.URLParam(r, "order_id")
+	if orderID == "" {
+		// Log error for missing order ID
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"get_order_history",
+			"missing_order_id",
+			"Order ID not found in URL",
+			ip, userAgent, nil,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Missing order_id")
+		return
+	}
+
+	// Call business logic service to retrieve the status history
+	history, err := cfg.GetOrderService().GetOrderStatusHistory(ctx, orderID, user)
+	if err != nil {
+		// Handle and log any errors from the service layer
+		cfg.handleOrderError(w, r, err, "get_order_history", ip, userAgent)
+		return
+	}
+
+	// Log successful retrieval with user context
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "get_order_history", "Retrieved order status history", ip, userAgent)
+
+	// Respond with status history
+	middlewares.RespondWithJSON(w, http.StatusOK, history)
+}
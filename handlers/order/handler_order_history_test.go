@@ -0,0 +1,127 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_order_history_test.go: Tests for HandlerGetOrderStatusHistory covering success, missing ID, and service errors.
+
+// TestHandlerGetOrderStatusHistory_Success verifies that the status history is returned to the caller.
+func TestHandlerGetOrderStatusHistory_Success(t *testing.T) {
+	mockOrderService := new(MockOrderService)
+	mockLogger := new(mockHandlerLogger)
+	cfg := &HandlersOrderConfig{
+		Config:       &handlers.Config{Logger: logrus.New()},
+		Logger:       mockLogger,
+		orderService: mockOrderService,
+	}
+
+	user := database.User{ID: "user123"}
+	changedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := []OrderStatusHistoryResponse{
+		{ID: "h1", FromStatus: "pending", ToStatus: "paid", CreatedAt: changedAt},
+		{ID: "h2", FromStatus: "paid", ToStatus: "shipped", ActorUserID: "admin1", Reason: "Handed to courier", CreatedAt: changedAt},
+	}
+	mockOrderService.On("GetOrderStatusHistory", mock.Anything, testOrderID, user).Return(history, nil)
+	mockLogger.On("LogHandlerSuccess", mock.Anything, "get_order_history", "Retrieved order status history", mock.Anything, mock.Anything).Return()
+
+	req := setChiURLParam(httptest.NewRequest("GET", "/orders/"+testOrderID+"/history", nil), "order_id", testOrderID)
+	w := httptest.NewRecorder()
+
+	cfg.HandlerGetOrderStatusHistory(w, req, user)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []OrderStatusHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, history, response)
+
+	mockOrderService.AssertExpectations(t)
+	mockLogger.AssertExpectations(t)
+}
+
+// TestHandlerGetOrderStatusHistory_Errors verifies error responses for missing IDs and service failures.
+func TestHandlerGetOrderStatusHistory_Errors(t *testing.T) {
+	cases := []struct {
+		name           string
+		orderID        string
+		serviceErr     error
+		loggerCall     func(*mockHandlerLogger)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:    "MissingOrderID",
+			orderID: "",
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "get_order_history", "missing_order_id", "Order ID not found in URL", mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Missing order_id",
+		},
+		{
+			name:       "OrderNotFound",
+			orderID:    testNonexistent,
+			serviceErr: &handlers.AppError{Code: "order_not_found", Message: "Order not found"},
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "get_order_history", "order_not_found", "Order not found", mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Order not found",
+		},
+		{
+			name:       "NotOwner",
+			orderID:    testOrderID,
+			serviceErr: &handlers.AppError{Code: "unauthorized", Message: "User is not authorized to view this order"},
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "get_order_history", "unauthorized", "User is not authorized to view this order", mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedMsg:    "User is not authorized to view this order",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderService := new(MockOrderService)
+			mockLogger := new(mockHandlerLogger)
+			cfg := &HandlersOrderConfig{
+				Config:       &handlers.Config{Logger: logrus.New()},
+				Logger:       mockLogger,
+				orderService: mockOrderService,
+			}
+			user := database.User{ID: "user123"}
+			if tc.orderID != "" {
+				mockOrderService.On("GetOrderStatusHistory", mock.Anything, tc.orderID, user).Return(nil, tc.serviceErr)
+			}
+			tc.loggerCall(mockLogger)
+
+			req := httptest.NewRequest("GET", "/orders/"+tc.orderID+"/history", nil)
+			if tc.orderID != "" {
+				req = setChiURLParam(req, "order_id", tc.orderID)
+			}
+			w := httptest.NewRecorder()
+
+			cfg.HandlerGetOrderStatusHistory(w, req, user)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			var response map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedMsg, response["error"])
+			mockOrderService.AssertExpectations(t)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
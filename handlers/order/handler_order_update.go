@@ -16,7 +16,7 @@ import (
 
 // HandlerUpdateOrderStatus handles HTTP PUT requests to update an order's status.
 // @Summary      Update order status
-// @Description  Updates the status of an order (admin only)
+// @Description  Moves an order to a new status along the allowed transitions and records the change (admin only). Cancelling restocks the items and voids or refunds the payment
 // @Tags         orders
 // @Accept       json
 // @Produce      json
@@ -24,8 +24,9 @@ import (
 // @Param        status    body  object{}  true  "Order status payload"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /v1/orders/{order_id}/status [put]
-func (cfg *HandlersOrderConfig) HandlerUpdateOrderStatus(w http.ResponseWriter, r *http.Request, _ database.User) {
+func (cfg *HandlersOrderConfig) HandlerUpdateOrderStatus(w http.ResponseWriter, r *http.Request, user database.User) {
 	// Extract request metadata for logging
 	ip, userAgent := handlers.GetRequestMetadata(r)
 	ctx := r.Context()
@@ -61,7 +62,7 @@ func (cfg *HandlersOrderConfig) HandlerUpdateOrderStatus(w http.ResponseWriter,
 	}
 
 	// Call business logic service to update order status
-	err := cfg.GetOrderService().UpdateOrderStatus(ctx, orderID, req.Status)
+	err := cfg.GetOrderService().UpdateOrderStatus(ctx, orderID, req, user)
 	if err != nil {
 		// Handle and log any errors from the service layer
 		cfg.handleOrderError(w, r, err, "update_order_status", ip, userAgent)
@@ -40,7 +40,7 @@ func TestHandlerUpdateOrderStatus_Success(t *testing.T) {
 		Status: "shipped",
 	}
 
-	mockOrderService.On("UpdateOrderStatus", mock.Anything, orderID, "shipped").Return(nil)
+	mockOrderService.On("UpdateOrderStatus", mock.Anything, orderID, requestBody, user).Return(nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "update_order_status", "Order status updated successfully", mock.Anything, mock.Anything).Return()
 
 	jsonBody, _ := json.Marshal(requestBody)
@@ -171,7 +171,7 @@ func TestHandlerUpdateOrderStatus_OrderNotFound(t *testing.T) {
 	}
 
 	appError := &handlers.AppError{Code: "order_not_found", Message: "Order not found"}
-	mockOrderService.On("UpdateOrderStatus", mock.Anything, testOrderID, "shipped").Return(appError)
+	mockOrderService.On("UpdateOrderStatus", mock.Anything, testOrderID, requestBody, user).Return(appError)
 	mockLogger.On("LogHandlerError", mock.Anything, "update_order_status", "order_not_found", "Order not found", mock.Anything, mock.Anything, nil).Return()
 
 	jsonBody, _ := json.Marshal(requestBody)
@@ -220,6 +220,39 @@ func TestHandlerUpdateOrderStatus_ErrorScenarios(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "Invalid order status",
 		},
+		{
+			name:       "InvalidTransition",
+			orderID:    testOrderID,
+			status:     "pending",
+			serviceErr: &handlers.AppError{Code: "invalid_status_transition", Message: "Cannot change order status from delivered to pending"},
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "update_order_status", "invalid_status_transition", "Cannot change order status from delivered to pending", mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Cannot change order status from delivered to pending",
+		},
+		{
+			name:       "RefundPending",
+			orderID:    testOrderID,
+			status:     "cancelled",
+			serviceErr: &handlers.AppError{Code: "refund_pending", Message: "Order cancelled, but refund ref1 could not be sent to the payment provider; retry it from the refunds admin endpoint"},
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "update_order_status", "refund_pending", mock.Anything, mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedMsg:    "Order cancelled, but refund ref1 could not be sent to the payment provider; retry it from the refunds admin endpoint",
+		},
+		{
+			name:       "PaymentCancelPending",
+			orderID:    testOrderID,
+			status:     "cancelled",
+			serviceErr: &handlers.AppError{Code: "payment_cancel_pending", Message: "Order cancelled, but its payment could not be voided with the payment provider; it will be retried"},
+			loggerCall: func(l *mockHandlerLogger) {
+				l.On("LogHandlerError", mock.Anything, "update_order_status", "payment_cancel_pending", mock.Anything, mock.Anything, mock.Anything, nil).Return()
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedMsg:    "Order cancelled, but its payment could not be voided with the payment provider; it will be retried",
+		},
 		{
 			name:       "UpdateFailed",
 			orderID:    testOrderID,
@@ -243,7 +276,7 @@ func TestHandlerUpdateOrderStatus_ErrorScenarios(t *testing.T) {
 				orderService: mockOrderService,
 			}
 			user := database.User{ID: "user123"}
-			mockOrderService.On("UpdateOrderStatus", mock.Anything, tc.orderID, tc.status).Return(tc.serviceErr)
+			mockOrderService.On("UpdateOrderStatus", mock.Anything, tc.orderID, UpdateOrderStatusRequest{Status: tc.status}, user).Return(tc.serviceErr)
 			if tc.loggerCall != nil {
 				tc.loggerCall(mockLogger)
 			}
@@ -293,7 +326,7 @@ func TestHandlerUpdateOrderStatus_ValidStatuses(t *testing.T) {
 				Status: status,
 			}
 
-			mockOrderService.On("UpdateOrderStatus", mock.Anything, testOrderID, status).Return(nil)
+			mockOrderService.On("UpdateOrderStatus", mock.Anything, testOrderID, requestBody, user).Return(nil)
 			mockLogger.On("LogHandlerSuccess", mock.Anything, "update_order_status", "Order status updated successfully", mock.Anything, mock.Anything).Return()
 
 			jsonBody, _ := json.Marshal(requestBody)
@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,10 +18,52 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // order_helper_test.go: Provides mock implementations of database queries, transaction, service, and logger interfaces for unit testing.
 
+var (
+	orderColumns     = []string{"id", "user_id", "total_amount", "status", "payment_method", "external_payment_id", "tracking_number", "shipping_address", "contact_phone", "created_at", "updated_at", "refund_status"}
+	orderItemColumns = []string{"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id"}
+	historyColumns   = []string{"id", "order_id", "from_status", "to_status", "actor_user_id", "reason", "created_at"}
+	variantColumns   = []string{"id", "product_id", "sku", "size", "color", "price", "stock", "is_active", "created_at", "updated_at"}
+	paymentColumns   = []string{"id", "order_id", "user_id", "amount", "currency", "status", "provider", "provider_payment_id", "created_at", "updated_at"}
+	productColumns   = []string{"id", "category_id", "name", "description", "price", "stock", "image_url", "is_active", "created_at", "updated_at", "rating_avg", "rating_count", "rating_1_count", "rating_2_count", "rating_3_count", "rating_4_count", "rating_5_count"}
+
+	testAdmin = database.User{ID: "admin1", Role: "admin"}
+)
+
+// newOrderStatusRows returns a single orders row owned by user123 with the given status.
+func newOrderStatusRows(orderID, status string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(orderColumns).
+		AddRow(orderID, "user123", "100.00", status, nil, nil, nil, nil, nil, now, now, "none")
+}
+
+// newProductRows returns a single products row with the given price and active flag.
+func newProductRows(productID, price string, active bool) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(productColumns).
+		AddRow(productID, nil, "Product "+productID, nil, price, 100, nil, active, now, now, "0.00", 0, 0, 0, 0, 0, 0)
+}
+
+// expectNoVariants expects the check for active variants of a product ordered without a variant, and reports none.
+func expectNoVariants(mock sqlmock.Sqlmock, productID string) {
+	mock.ExpectQuery("FROM product_variants").WithArgs(productID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+}
+
+// newVariantRows returns a single product_variants row with the given price override (empty for none).
+func newVariantRows(variantID, productID, price string, active bool) *sqlmock.Rows {
+	now := time.Now()
+	var priceOverride any
+	if price != "" {
+		priceOverride = price
+	}
+	return sqlmock.NewRows(variantColumns).
+		AddRow(variantID, productID, "SKU-"+variantID, nil, nil, priceOverride, 10, active, now, now)
+}
+
 // MockDBQueries is a mock implementation of database queries for testing
 type MockDBQueries struct {
 	mock.Mock
@@ -35,8 +79,8 @@ func (m *MockDBQueries) CreateOrderItem(ctx context.Context, params database.Cre
 	return args.Error(0)
 }
 
-func (m *MockDBQueries) ListAllOrders(ctx context.Context) ([]database.Order, error) {
-	args := m.Called(ctx)
+func (m *MockDBQueries) ListAllOrders(ctx context.Context, params database.ListAllOrdersParams) ([]database.Order, error) {
+	args := m.Called(ctx, params)
 	return args.Get(0).([]database.Order), args.Error(1)
 }
 
@@ -98,6 +142,26 @@ func (m *MockTx) Rollback() error {
 	return args.Error(0)
 }
 
+// MockPaymentReleaser is a mock implementation of PaymentReleaser for testing.
+type MockPaymentReleaser struct {
+	mock.Mock
+}
+
+func (m *MockPaymentReleaser) CancelPayment(ctx context.Context, payment database.Payment) error {
+	args := m.Called(ctx, payment)
+	return args.Error(0)
+}
+
+func (m *MockPaymentReleaser) ReserveRefund(ctx context.Context, queries *database.Queries, order database.Order, payment database.Payment, actorID, reason string, now time.Time) (string, error) {
+	args := m.Called(ctx, queries, order, payment, actorID, reason, now)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentReleaser) SendRefund(ctx context.Context, refundID string) error {
+	args := m.Called(ctx, refundID)
+	return args.Error(0)
+}
+
 // MockOrderService is a mock implementation of OrderService for testing
 type MockOrderService struct {
 	mock.Mock
@@ -111,9 +175,9 @@ func (m *MockOrderService) CreateOrder(ctx context.Context, user database.User,
 	return args.Get(0).(*OrderResponse), args.Error(1)
 }
 
-func (m *MockOrderService) GetAllOrders(ctx context.Context) ([]database.Order, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.Order), args.Error(1)
+func (m *MockOrderService) GetAllOrders(ctx context.Context, page pagination.Request) (pagination.Page[database.Order], error) {
+	args := m.Called(ctx, page)
+	return args.Get(0).(pagination.Page[database.Order]), args.Error(1)
 }
 
 func (m *MockOrderService) GetUserOrders(ctx context.Context, user database.User) ([]UserOrderResponse, error) {
@@ -134,11 +198,19 @@ func (m *MockOrderService) GetOrderItemsByOrderID(ctx context.Context, orderID s
 	return args.Get(0).([]OrderItemResponse), args.Error(1)
 }
 
-func (m *MockOrderService) UpdateOrderStatus(ctx context.Context, orderID string, status string) error {
-	args := m.Called(ctx, orderID, status)
+func (m *MockOrderService) UpdateOrderStatus(ctx context.Context, orderID string, req UpdateOrderStatusRequest, actor database.User) error {
+	args := m.Called(ctx, orderID, req, actor)
 	return args.Error(0)
 }
 
+func (m *MockOrderService) GetOrderStatusHistory(ctx context.Context, orderID string, user database.User) ([]OrderStatusHistoryResponse, error) {
+	args := m.Called(ctx, orderID, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]OrderStatusHistoryResponse), args.Error(1)
+}
+
 func (m *MockOrderService) DeleteOrder(ctx context.Context, orderID string) error {
 	args := m.Called(ctx, orderID)
 	return args.Error(0)
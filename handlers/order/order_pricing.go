@@ -0,0 +1,210 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// order_pricing.go: Prices order items from the products and variants tables and detects stale client-side prices.
+
+// PriceChange describes an order item whose expected price differs from the current product or variant price.
+type PriceChange struct {
+	ProductID     string       `json:"product_id"`
+	VariantID     string       `json:"variant_id,omitempty"`
+	Name          string       `json:"name"`
+	ExpectedPrice money.Amount `json:"expected_price"`
+	CurrentPrice  money.Amount `json:"current_price"`
+}
+
+// PriceChangedError lists every order item whose price changed.
+// It is carried as the Err of a "price_changed" AppError.
+type PriceChangedError struct {
+	Items []PriceChange
+}
+
+// Error implements the error interface for PriceChangedError.
+func (e *PriceChangedError) Error() string {
+	ids := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		ids = append(ids, item.ProductID)
+	}
+	return fmt.Sprintf("price changed for products: %s", strings.Join(ids, ", "))
+}
+
+// orderLine identifies what an order item draws stock from: a product, or one of its variants.
+type orderLine struct {
+	productID string
+	variantID string
+}
+
+// lineOf returns the line an order item belongs to.
+func lineOf(item OrderItemInput) orderLine {
+	return orderLine{productID: item.ProductID, variantID: item.VariantID}
+}
+
+// pricedItem is an order item priced from the products or variants table.
+type pricedItem struct {
+	orderLine
+	quantity  int32
+	unitPrice money.Amount
+}
+
+// priceOrderItems locks every ordered product and variant and prices the items from them.
+// Products are locked in ID order, then variants in ID order, so each variant is locked under its product's
+// lock as in checkout, and concurrent orders cannot deadlock. Unknown or inactive products and variants are
+// rejected with "invalid_product", as is an item without a variant for a product that has active variants.
+// Items whose expected price differs from the current price are reported together as a "price_changed" AppError.
+func priceOrderItems(ctx context.Context, queries *database.Queries, items []OrderItemInput) ([]pricedItem, error) {
+	productIDs := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	withoutVariant := make(map[string]bool)
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+		if item.VariantID == "" {
+			withoutVariant[item.ProductID] = true
+		}
+	}
+	sort.Strings(productIDs)
+
+	products := make(map[string]database.Product, len(productIDs))
+	for _, productID := range productIDs {
+		product, err := queries.GetProductByIDForUpdate(ctx, productID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Product %s does not exist", productID), Err: err}
+			}
+			return nil, &handlers.AppError{Code: "database_error", Message: "Error fetching product", Err: err}
+		}
+		if !product.IsActive {
+			return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Product %s is not available", productID)}
+		}
+		if withoutVariant[productID] {
+			hasVariants, err := queries.ProductHasActiveVariants(ctx, productID)
+			if err != nil {
+				return nil, &handlers.AppError{Code: "database_error", Message: "Error checking product variants", Err: err}
+			}
+			if hasVariants {
+				return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Product %s requires a variant", productID)}
+			}
+		}
+		products[productID] = product
+	}
+
+	variants, err := lockOrderVariants(ctx, queries, items)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := make([]pricedItem, 0, len(items))
+	var changes []PriceChange
+	reported := make(map[orderLine]bool)
+	for _, item := range items {
+		if item.Quantity > math.MaxInt32 {
+			return nil, &handlers.AppError{Code: "quantity_overflow", Message: fmt.Sprintf("Quantity %d exceeds the max limit for int32", item.Quantity)}
+		}
+
+		product := products[item.ProductID]
+		price := product.Price
+		if variant, ok := variants[item.VariantID]; ok && variant.Price.Valid {
+			price = variant.Price.String
+		}
+		unitPrice, err := money.Parse(price, money.DefaultCurrency)
+		if err != nil {
+			return nil, &handlers.AppError{Code: "database_error", Message: "Invalid product price", Err: err}
+		}
+
+		// A zero price means the client did not send the price it saw
+		line := lineOf(item)
+		if !item.Price.IsZero() && !item.Price.Equal(unitPrice) && !reported[line] {
+			reported[line] = true
+			changes = append(changes, PriceChange{
+				ProductID:     item.ProductID,
+				VariantID:     item.VariantID,
+				Name:          product.Name,
+				ExpectedPrice: item.Price,
+				CurrentPrice:  unitPrice,
+			})
+		}
+
+		priced = append(priced, pricedItem{
+			orderLine: line,
+			quantity:  int32(item.Quantity),
+			unitPrice: unitPrice,
+		})
+	}
+
+	if len(changes) > 0 {
+		return nil, &handlers.AppError{
+			Code:    "price_changed",
+			Message: "Prices have changed since the order was prepared",
+			Err:     &PriceChangedError{Items: changes},
+		}
+	}
+
+	return priced, nil
+}
+
+// lockOrderVariants locks every ordered variant in ID order and checks that it is active and belongs to
+// the item's product.
+func lockOrderVariants(ctx context.Context, queries *database.Queries, items []OrderItemInput) (map[string]database.ProductVariant, error) {
+	productOf := make(map[string]string)
+	variantIDs := make([]string, 0)
+	for _, item := range items {
+		if item.VariantID == "" {
+			continue
+		}
+		if productID, ok := productOf[item.VariantID]; ok {
+			if productID != item.ProductID {
+				return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Variant %s does not exist", item.VariantID)}
+			}
+			continue
+		}
+		productOf[item.VariantID] = item.ProductID
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+	sort.Strings(variantIDs)
+
+	variants := make(map[string]database.ProductVariant, len(variantIDs))
+	for _, variantID := range variantIDs {
+		variant, err := queries.GetProductVariantByIDForUpdate(ctx, variantID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && variant.ProductID != productOf[variantID]) {
+			return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Variant %s does not exist", variantID), Err: err}
+		}
+		if err != nil {
+			return nil, &handlers.AppError{Code: "database_error", Message: "Error fetching product variant", Err: err}
+		}
+		if !variant.IsActive {
+			return nil, &handlers.AppError{Code: "invalid_product", Message: fmt.Sprintf("Variant %s is not available", variant.Sku)}
+		}
+		variants[variantID] = variant
+	}
+	return variants, nil
+}
+
+// orderTotal sums the priced items.
+func orderTotal(items []pricedItem) (money.Amount, error) {
+	total := money.New(0, money.DefaultCurrency)
+	for _, item := range items {
+		line, err := item.unitPrice.Mul(int64(item.quantity))
+		if err == nil {
+			total, err = total.Add(line)
+		}
+		if err != nil {
+			return money.Amount{}, &handlers.AppError{Code: "quantity_overflow", Message: "Order total exceeds the max limit", Err: err}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,76 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// order_pricing_test.go: Tests for pricing order items from the products table.
+
+// TestPriceOrderItems_LocksEachProductOnce tests that repeated products are locked once, in ID order, and priced from the row.
+func TestPriceOrderItems_LocksEachProductOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("FOR UPDATE").WithArgs("a").WillReturnRows(newProductRows("a", "1.10", true))
+	expectNoVariants(mock, "a")
+	mock.ExpectQuery("FOR UPDATE").WithArgs("b").WillReturnRows(newProductRows("b", "2.00", true))
+	expectNoVariants(mock, "b")
+
+	priced, err := priceOrderItems(context.Background(), database.New(db), []OrderItemInput{
+		{ProductID: "b", Quantity: 1},
+		{ProductID: "a", Quantity: 2, Price: money.MustParse("1.10", money.USD)},
+		{ProductID: "b", Quantity: 3, Price: money.MustParse("2.00", money.USD)},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []pricedItem{
+		{orderLine: orderLine{productID: "b"}, quantity: 1, unitPrice: money.New(200, money.USD)},
+		{orderLine: orderLine{productID: "a"}, quantity: 2, unitPrice: money.New(110, money.USD)},
+		{orderLine: orderLine{productID: "b"}, quantity: 3, unitPrice: money.New(200, money.USD)},
+	}, priced)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPriceOrderItems_DatabaseError tests that a failed product lookup is reported as a database error.
+func TestPriceOrderItems_DatabaseError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("FOR UPDATE").WillReturnError(errors.New("db down"))
+
+	_, err = priceOrderItems(context.Background(), database.New(db), []OrderItemInput{{ProductID: "a", Quantity: 1}})
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "database_error", appErr.Code)
+}
+
+// TestOrderTotal tests that totals are summed exactly in minor units and overflow is rejected.
+func TestOrderTotal(t *testing.T) {
+	total, err := orderTotal([]pricedItem{
+		{orderLine: orderLine{productID: "a"}, quantity: 3, unitPrice: money.MustParse("0.10", money.USD)},
+		{orderLine: orderLine{productID: "b"}, quantity: 1, unitPrice: money.MustParse("0.20", money.USD)},
+		{orderLine: orderLine{productID: "c"}, quantity: 7, unitPrice: money.MustParse("19.99", money.USD)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "140.43", total.String())
+
+	_, err = orderTotal([]pricedItem{{orderLine: orderLine{productID: "a"}, quantity: 2, unitPrice: money.New(math.MaxInt64/2+1, money.USD)}})
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "quantity_overflow", appErr.Code)
+}
@@ -0,0 +1,259 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// order_reaper.go: Background job that releases the inventory held by unpaid pending orders.
+
+// Default settings for the pending order reaper.
+const (
+	DefaultOrderHoldWindow     = 30 * time.Minute
+	DefaultOrderReaperInterval = time.Minute
+	DefaultOrderReaperBatch    = 100
+
+	reaperCancelReason = "Payment not received within the hold window"
+
+	// manualPaymentProvider is the provider of payments settled outside the app, such as cash on delivery and bank transfer
+	manualPaymentProvider = "manual"
+)
+
+// OrderReaperConfig holds the settings for the pending order reaper.
+// Zero values are replaced with the package defaults.
+type OrderReaperConfig struct {
+	HoldWindow time.Duration
+	Interval   time.Duration
+	BatchSize  int32
+}
+
+// OrderReaper periodically cancels pending orders older than the hold window; orders awaiting a manual payment are left
+// for an admin to settle. Each expired order is cancelled, its items are restocked, its pending payment is marked
+// cancel_pending and the change is recorded in the status history, all in one transaction. The payment is voided with
+// its provider once that commits; a void that fails is retried on later runs, and a payment the provider captured in
+// the meantime is left for the payment webhook, which files a refund request for it.
+type OrderReaper struct {
+	db       *database.Queries
+	dbConn   *sql.DB
+	payments PaymentReleaser
+	clock    utils.Clock
+	logger   *logrus.Logger
+	config   OrderReaperConfig
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOrderReaper creates a new OrderReaper.
+// A nil clock falls back to the real clock and a nil logger to the standard logrus logger.
+// A nil PaymentReleaser leaves provider payments untouched, which is only safe without card payments.
+func NewOrderReaper(db *database.Queries, dbConn *sql.DB, payments PaymentReleaser, clock utils.Clock, logger *logrus.Logger, config OrderReaperConfig) *OrderReaper {
+	if clock == nil {
+		clock = utils.RealClock{}
+	}
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	if config.HoldWindow <= 0 {
+		config.HoldWindow = DefaultOrderHoldWindow
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultOrderReaperInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultOrderReaperBatch
+	}
+	return &OrderReaper{
+		db:       db,
+		dbConn:   dbConn,
+		payments: payments,
+		clock:    clock,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// Start launches the reaper loop in a background goroutine.
+// The ticker is created before Start returns, so ticks from a fake clock are never missed. Calling Start twice is a no-op.
+func (r *OrderReaper) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := r.clock.NewTicker(r.config.Interval)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go r.run(ctx, ticker, r.done)
+}
+
+// Stop cancels the reaper loop and waits for the current run to finish or the context to expire.
+func (r *OrderReaper) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run reaps expired orders on every tick until the context is cancelled.
+func (r *OrderReaper) run(ctx context.Context, ticker utils.Ticker, done chan struct{}) {
+	defer close(done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := r.ReapOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				r.logger.WithError(err).Error("Failed to reap expired pending orders")
+			}
+		}
+	}
+}
+
+// ReapOnce releases one batch of pending orders whose hold window has expired.
+// Failures on individual orders are logged and skipped; returns the number of orders released.
+func (r *OrderReaper) ReapOnce(ctx context.Context) (int, error) {
+	if r.db == nil || r.dbConn == nil {
+		return 0, errors.New("order reaper database is not initialized")
+	}
+
+	now := r.clock.Now().UTC()
+	orders, err := r.db.ListExpiredPendingOrders(ctx, database.ListExpiredPendingOrdersParams{
+		CreatedAt: now.Add(-r.config.HoldWindow),
+		Limit:     r.config.BatchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list expired pending orders: %w", err)
+	}
+
+	// Retry voids that failed on earlier runs before releasing more orders
+	r.retryPaymentCancels(ctx, now)
+
+	released := 0
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			return released, ctx.Err()
+		}
+
+		ok, err := r.releaseOrder(ctx, order.ID, now)
+		if err != nil {
+			r.logger.WithFields(logrus.Fields{"order_id": order.ID}).WithError(err).Error("Failed to release expired order")
+			continue
+		}
+		if ok {
+			released++
+			r.logger.WithFields(logrus.Fields{"order_id": order.ID}).Info("Released expired pending order")
+		}
+	}
+
+	return released, nil
+}
+
+// releaseOrder cancels a single pending order, restocks its items, records the change and voids its pending payment.
+// The order row is locked and re-checked first, so an order paid or switched to a manual payment since it was listed
+// is left untouched. The payment is voided with its provider after the release commits; a void that fails is logged
+// and left for retryPaymentCancels, and the order still counts as released.
+func (r *OrderReaper) releaseOrder(ctx context.Context, orderID string, now time.Time) (bool, error) {
+	tx, err := r.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.logger.WithError(err).Error("Failed to rollback order release")
+		}
+	}()
+
+	queries := r.db.WithTx(tx)
+
+	order, err := queries.GetOrderByIDForUpdate(ctx, orderID)
+	if err != nil {
+		return false, fmt.Errorf("lock order: %w", err)
+	}
+	if order.Status != OrderStatusPending {
+		return false, nil
+	}
+
+	payment, err := queries.GetPaymentByOrderID(ctx, orderID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("get payment: %w", err)
+	}
+	hasPayment := err == nil
+	if hasPayment && payment.Provider == manualPaymentProvider && payment.Status == "pending" {
+		return false, nil
+	}
+
+	if err := releaseLockedOrder(ctx, queries, order, "", reaperCancelReason, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	// A failed attempt leaves the intent open for another try, so it is voided like a pending one
+	if hasPayment && (payment.Status == "pending" || payment.Status == "failed") {
+		r.cancelPayment(ctx, payment, now)
+	}
+
+	return true, nil
+}
+
+// retryPaymentCancels voids payments left cancel_pending by earlier releases whose void failed.
+// Only payments marked before the previous run are retried, so a release still settling its payment is not raced.
+func (r *OrderReaper) retryPaymentCancels(ctx context.Context, now time.Time) {
+	payments, err := r.db.ListCancelPendingPayments(ctx, database.ListCancelPendingPaymentsParams{
+		UpdatedAt: now.Add(-r.config.Interval),
+		Limit:     r.config.BatchSize,
+	})
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payments awaiting cancellation")
+		return
+	}
+
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return
+		}
+		r.cancelPayment(ctx, payment, now)
+	}
+}
+
+// cancelPayment voids a cancel_pending payment with its provider and logs the outcome.
+func (r *OrderReaper) cancelPayment(ctx context.Context, payment database.Payment, now time.Time) {
+	fields := logrus.Fields{"order_id": payment.OrderID, "payment_id": payment.ID}
+	err := settlePaymentCancel(ctx, r.db, r.payments, payment, now)
+	if errors.Is(err, ErrPaymentCaptured) {
+		r.logger.WithFields(fields).Warn("Payment captured after the order was released; leaving it for the payment webhook to file a refund request")
+		return
+	}
+	if err != nil {
+		r.logger.WithFields(fields).WithError(err).Error("Failed to cancel provider payment; will retry")
+	}
+}
@@ -0,0 +1,315 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	testutil "github.com/STaninnat/ecom-backend/internal/testutil"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// order_reaper_test.go: Tests for the pending order reaper, covering order release, skips, failures, and the run loop.
+
+// newTestReaper creates an OrderReaper backed by sqlmock, a mock payment releaser and a fake clock.
+func newTestReaper(t *testing.T) (*OrderReaper, sqlmock.Sqlmock, *MockPaymentReleaser, *testutil.FakeClock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	clock := testutil.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	payments := new(MockPaymentReleaser)
+	reaper := NewOrderReaper(database.New(db), db, payments, clock, logger, OrderReaperConfig{
+		HoldWindow: 30 * time.Minute,
+		Interval:   time.Minute,
+		BatchSize:  10,
+	})
+	return reaper, mock, payments, clock
+}
+
+// expectRelease sets up the queries and provider call issued when a pending order is released.
+func expectRelease(mock sqlmock.Sqlmock, payments *MockPaymentReleaser, orderID string, now time.Time) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM orders\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow(orderID, "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	mock.ExpectQuery("FROM payments").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).
+			AddRow("pay-"+orderID, orderID, "user1", "30.00", "USD", "pending", "stripe", "pi_"+orderID, now.Add(-time.Hour), now.Add(-time.Hour)))
+	mock.ExpectQuery("FROM order_items").WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).
+			AddRow("item2", orderID, "prod2", 1, "10.00", now, now, nil).
+			AddRow("item3", orderID, "prod1", 1, "12.00", now, now, "var1").
+			AddRow("item1", orderID, "prod1", 2, "10.00", now, now, nil))
+	mock.ExpectExec("UPDATE products").WithArgs(int32(2), now, "prod1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE product_variants").WithArgs(int32(1), now, "var1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE products").WithArgs(int32(1), now, "prod2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE orders").WithArgs(orderID, "cancelled", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(sqlmock.AnyArg(), orderID, "pending", "cancelled", sql.NullString{}, utils.ToNullString(reaperCancelReason), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE payments\\s+SET status = 'cancel_pending'").WithArgs(orderID, now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	payments.On("CancelPayment", testifymock.Anything, testifymock.MatchedBy(func(p database.Payment) bool { return p.ID == "pay-"+orderID })).
+		Return(nil).Once()
+	mock.ExpectExec("UPDATE payments\\s+SET status = 'cancelled'").WithArgs("pay-"+orderID, now).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectCancelRetries sets up the lookup of payments left cancel_pending by earlier runs, returning none.
+func expectCancelRetries(mock sqlmock.Sqlmock, now time.Time) {
+	mock.ExpectQuery("FROM payments\\s+WHERE status = 'cancel_pending' AND updated_at < \\$1").
+		WithArgs(now.Add(-time.Minute), int32(10)).
+		WillReturnRows(sqlmock.NewRows(paymentColumns))
+}
+
+// expectLockedPendingOrder sets up the start of a release whose order is still pending with a pending Stripe payment.
+func expectLockedPendingOrder(mock sqlmock.Sqlmock, now time.Time) {
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	expectCancelRetries(mock, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	mock.ExpectQuery("FROM payments").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(paymentColumns).
+			AddRow("pay1", "order1", "user1", "30.00", "USD", "pending", "stripe", "pi_1", now.Add(-time.Hour), now.Add(-time.Hour)))
+	mock.ExpectQuery("FROM order_items").WithArgs("order1").WillReturnRows(sqlmock.NewRows(orderItemColumns))
+	mock.ExpectExec("UPDATE orders").WithArgs("order1", "cancelled", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE payments\\s+SET status = 'cancel_pending'").WithArgs("order1", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// TestNewOrderReaper_Defaults tests that zero settings fall back to the package defaults.
+func TestNewOrderReaper_Defaults(t *testing.T) {
+	reaper := NewOrderReaper(nil, nil, nil, nil, nil, OrderReaperConfig{})
+
+	assert.Equal(t, DefaultOrderHoldWindow, reaper.config.HoldWindow)
+	assert.Equal(t, DefaultOrderReaperInterval, reaper.config.Interval)
+	assert.Equal(t, int32(DefaultOrderReaperBatch), reaper.config.BatchSize)
+	assert.IsType(t, utils.RealClock{}, reaper.clock)
+	assert.NotNil(t, reaper.logger)
+}
+
+// TestReapOnce_ReleasesExpiredOrder tests that an expired order is cancelled, restocked and the change recorded,
+// and that its payment is voided with the provider only after the release commits.
+func TestReapOnce_ReleasesExpiredOrder(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending' AND created_at < \\$1\\s+AND NOT EXISTS \\(\\s+SELECT 1 FROM payments\\s+"+
+		"WHERE payments.order_id = orders.id AND payments.provider = 'manual' AND payments.status = 'pending'").
+		WithArgs(now.Add(-30*time.Minute), int32(10)).
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	expectCancelRetries(mock, now)
+	expectRelease(mock, payments, "order1", now)
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestReapOnce_SkipsOrderNoLongerPending tests that an order paid after being listed is left untouched.
+func TestReapOnce_SkipsOrderNoLongerPending(t *testing.T) {
+	reaper, mock, _, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	expectCancelRetries(mock, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "paid", nil, nil, nil, nil, nil, now.Add(-time.Hour), now, "none"))
+	mock.ExpectRollback()
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestReapOnce_SkipsManualPaymentOrder tests that an order awaiting a manual payment keeps its stock and status,
+// even when the payment was created after the order was listed.
+func TestReapOnce_SkipsManualPaymentOrder(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	expectCancelRetries(mock, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	mock.ExpectQuery("FROM payments").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(paymentColumns).
+			AddRow("pay1", "order1", "user1", "30.00", "USD", "pending", "manual", "manual_pay1", now.Add(-time.Hour), now.Add(-time.Hour)))
+	mock.ExpectRollback()
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertNotCalled(t, "CancelPayment", testifymock.Anything, testifymock.Anything)
+}
+
+// TestReapOnce_CapturedPaymentLeftForWebhook tests that a payment the provider captured before the void landed stays
+// cancel_pending for the payment webhook, while the order stays released.
+func TestReapOnce_CapturedPaymentLeftForWebhook(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	expectLockedPendingOrder(mock, now)
+	payments.On("CancelPayment", testifymock.Anything, testifymock.Anything).Return(ErrPaymentCaptured).Once()
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestReapOnce_ProviderCancelError tests that an order is still released when the provider cannot void its payment,
+// which stays cancel_pending for a later run to retry.
+func TestReapOnce_ProviderCancelError(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	expectLockedPendingOrder(mock, now)
+	payments.On("CancelPayment", testifymock.Anything, testifymock.Anything).Return(errors.New("provider down")).Once()
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestReapOnce_RetriesPaymentCancels tests that payments left cancel_pending by earlier runs are voided and marked
+// cancelled, and that a failed retry does not stop the rest of the run.
+func TestReapOnce_RetriesPaymentCancels(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").WillReturnRows(sqlmock.NewRows(orderColumns))
+	mock.ExpectQuery("FROM payments\\s+WHERE status = 'cancel_pending'").
+		WithArgs(now.Add(-time.Minute), int32(10)).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).
+			AddRow("pay1", "order1", "user1", "30.00", "USD", "cancel_pending", "stripe", "pi_1", now.Add(-time.Hour), now.Add(-time.Hour)).
+			AddRow("pay2", "order2", "user2", "30.00", "USD", "cancel_pending", "stripe", "pi_2", now.Add(-time.Hour), now.Add(-time.Hour)))
+	payments.On("CancelPayment", testifymock.Anything, testifymock.MatchedBy(func(p database.Payment) bool { return p.ID == "pay1" })).
+		Return(errors.New("provider down")).Once()
+	payments.On("CancelPayment", testifymock.Anything, testifymock.MatchedBy(func(p database.Payment) bool { return p.ID == "pay2" })).
+		Return(nil).Once()
+	mock.ExpectExec("UPDATE payments\\s+SET status = 'cancelled', updated_at = \\$2\\s+WHERE id = \\$1 AND status = 'cancel_pending'").
+		WithArgs("pay2", now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestReapOnce_ContinuesAfterOrderFailure tests that a failing order is rolled back and the rest of the batch is still released.
+func TestReapOnce_ContinuesAfterOrderFailure(t *testing.T) {
+	reaper, mock, payments, clock := newTestReaper(t)
+	now := clock.Now().UTC()
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-2*time.Hour), now.Add(-2*time.Hour), "none").
+			AddRow("order2", "user2", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-time.Hour), now.Add(-time.Hour), "none"))
+	expectCancelRetries(mock, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(orderColumns).
+			AddRow("order1", "user1", "30.00", "pending", nil, nil, nil, nil, nil, now.Add(-2*time.Hour), now.Add(-2*time.Hour), "none"))
+	mock.ExpectQuery("FROM payments").WithArgs("order1").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM order_items").WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow("item1", "order1", "prod1", 1, "30.00", now, now, nil))
+	mock.ExpectExec("UPDATE products").WillReturnError(errors.New("restock failed"))
+	mock.ExpectRollback()
+	expectRelease(mock, payments, "order2", now)
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestReapOnce_ListError tests that a failure listing expired orders is returned.
+func TestReapOnce_ListError(t *testing.T) {
+	reaper, mock, _, _ := newTestReaper(t)
+	mock.ExpectQuery("FROM orders").WillReturnError(errors.New("db down"))
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db down")
+	assert.Equal(t, 0, released)
+}
+
+// TestReapOnce_NilDatabase tests that ReapOnce fails when the database is not initialized.
+func TestReapOnce_NilDatabase(t *testing.T) {
+	reaper := NewOrderReaper(nil, nil, nil, testutil.NewFakeClock(time.Now()), nil, OrderReaperConfig{})
+
+	_, err := reaper.ReapOnce(context.Background())
+	require.Error(t, err)
+}
+
+// TestOrderReaper_RunsOnTickUntilStopped tests that the reaper runs on each clock tick and stops cleanly.
+func TestOrderReaper_RunsOnTickUntilStopped(t *testing.T) {
+	reaper, mock, _, clock := newTestReaper(t)
+
+	reaper.Start()
+	reaper.Start() // second call is a no-op
+	assert.Equal(t, 1, clock.ActiveTickers())
+
+	// Nothing is reaped before the first tick
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectQuery("FROM orders\\s+WHERE status = 'pending'").
+		WithArgs(clock.Now().UTC().Add(time.Minute).Add(-30*time.Minute), int32(10)).
+		WillReturnRows(sqlmock.NewRows(orderColumns))
+	expectCancelRetries(mock, clock.Now().UTC().Add(time.Minute))
+	clock.Advance(time.Minute)
+
+	require.Eventually(t, func() bool {
+		return mock.ExpectationsWereMet() == nil
+	}, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, reaper.Stop(ctx))
+	assert.Equal(t, 0, clock.ActiveTickers())
+}
+
+// TestOrderReaper_StopWithoutStart tests that stopping a reaper that never started is a no-op.
+func TestOrderReaper_StopWithoutStart(t *testing.T) {
+	reaper, _, _, _ := newTestReaper(t)
+	assert.NoError(t, reaper.Stop(context.Background()))
+}
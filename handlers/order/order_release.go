@@ -0,0 +1,138 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// order_release.go: Cancels a released order, returns its items to stock and defines how its payment is settled with the provider.
+
+// ErrPaymentCaptured is returned by a PaymentReleaser when the provider has already taken the payment,
+// so the order must not be released as unpaid.
+var ErrPaymentCaptured = errors.New("payment already captured by the provider")
+
+// PaymentReleaser settles an order's payment with its provider when the order is released.
+// It is implemented by the payment handlers, which own the providers.
+type PaymentReleaser interface {
+	// CancelPayment voids a pending payment at its provider, returning ErrPaymentCaptured if the payment already succeeded.
+	// It is called after the order release commits, so no network call is made while the order row is locked.
+	CancelPayment(ctx context.Context, payment database.Payment) error
+	// ReserveRefund records a refund of what is left of a captured payment and returns its ID, or an empty ID when
+	// nothing is left to refund. Queries must be bound to the transaction that cancels the order, with the order row
+	// already locked; the refund is sent with SendRefund once that transaction commits.
+	ReserveRefund(ctx context.Context, queries *database.Queries, order database.Order, payment database.Payment, actorID, reason string, now time.Time) (string, error)
+	// SendRefund sends a reserved refund to its provider and records the outcome. A refund that fails to send stays
+	// reserved and can be retried.
+	SendRefund(ctx context.Context, refundID string) error
+}
+
+// OrderRestockQueries is the subset of queries needed to return an order's items to stock.
+type OrderRestockQueries interface {
+	GetOrderItemsByOrderID(ctx context.Context, orderID string) ([]database.OrderItem, error)
+	IncrementProductStock(ctx context.Context, arg database.IncrementProductStockParams) error
+	IncrementProductVariantStock(ctx context.Context, arg database.IncrementProductVariantStockParams) error
+}
+
+// RestockOrderItems returns the items of an order to stock, to its variant when the line has one.
+// Queries must be bound to the transaction that cancels the order, with the order row already locked.
+// Items are restocked in product then variant ID order to match the lock order used by checkout.
+func RestockOrderItems(ctx context.Context, queries OrderRestockQueries, orderID string, now time.Time) error {
+	items, err := queries.GetOrderItemsByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order items: %w", err)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ProductID != items[j].ProductID {
+			return items[i].ProductID < items[j].ProductID
+		}
+		return items[i].VariantID.String < items[j].VariantID.String
+	})
+	for _, item := range items {
+		if item.VariantID.Valid {
+			err = queries.IncrementProductVariantStock(ctx, database.IncrementProductVariantStockParams{
+				Quantity:  item.Quantity,
+				UpdatedAt: now,
+				ID:        item.VariantID.String,
+			})
+		} else {
+			err = queries.IncrementProductStock(ctx, database.IncrementProductStockParams{
+				Quantity:  item.Quantity,
+				UpdatedAt: now,
+				ID:        item.ProductID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("restock product %s: %w", item.ProductID, err)
+		}
+	}
+	return nil
+}
+
+// releaseLockedOrder cancels an order, restocks its items, records the change and marks its pending payments
+// cancel_pending. Queries must be bound to a transaction in which the order row is already locked; the payments are
+// voided with settlePaymentCancel once it commits. An empty actorID records the change as made by the system.
+func releaseLockedOrder(ctx context.Context, queries *database.Queries, order database.Order, actorID, reason string, now time.Time) error {
+	if err := RestockOrderItems(ctx, queries, order.ID, now); err != nil {
+		return err
+	}
+
+	err := queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+		ID:        order.ID,
+		Status:    OrderStatusCancelled,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("cancel order: %w", err)
+	}
+
+	err = queries.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		ID:          utils.NewUUIDString(),
+		OrderID:     order.ID,
+		FromStatus:  order.Status,
+		ToStatus:    OrderStatusCancelled,
+		ActorUserID: utils.ToNullString(actorID),
+		Reason:      utils.ToNullString(reason),
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("record status history: %w", err)
+	}
+
+	err = queries.MarkPaymentsCancelPendingByOrderID(ctx, database.MarkPaymentsCancelPendingByOrderIDParams{
+		OrderID:   order.ID,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("cancel payment: %w", err)
+	}
+	return nil
+}
+
+// settlePaymentCancel voids a cancel_pending payment with its provider and marks it cancelled. It must run after the
+// release that marked the payment commits. A payment the provider already captured is left cancel_pending for the
+// payment webhook, which files a refund request for it; other failures leave it for the reaper to retry.
+// A nil PaymentReleaser marks the payment cancelled without contacting the provider.
+func settlePaymentCancel(ctx context.Context, queries *database.Queries, payments PaymentReleaser, payment database.Payment, now time.Time) error {
+	if payments != nil {
+		if err := payments.CancelPayment(ctx, payment); err != nil {
+			return err
+		}
+	}
+
+	_, err := queries.MarkPaymentCancelled(ctx, database.MarkPaymentCancelledParams{
+		ID:        payment.ID,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("mark payment cancelled: %w", err)
+	}
+	return nil
+}
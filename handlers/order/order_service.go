@@ -7,45 +7,52 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
 // order_service.go: Provides a complete implementation of the OrderService interface for managing order operations.
 
 // OrderService defines the business logic interface for order operations.
-// Provides methods for creating, retrieving, updating, and deleting orders and order items.
+// Provides methods for creating, retrieving, updating, and deleting orders and order items, and for reading order status history.
 type OrderService interface {
 	CreateOrder(ctx context.Context, user database.User, params CreateOrderRequest) (*OrderResponse, error)
-	GetAllOrders(ctx context.Context) ([]database.Order, error)
+	GetAllOrders(ctx context.Context, page pagination.Request) (pagination.Page[database.Order], error)
 	GetUserOrders(ctx context.Context, user database.User) ([]UserOrderResponse, error)
 	GetOrderByID(ctx context.Context, orderID string, user database.User) (*OrderDetailResponse, error)
 	GetOrderItemsByOrderID(ctx context.Context, orderID string) ([]OrderItemResponse, error)
-	UpdateOrderStatus(ctx context.Context, orderID string, status string) error
+	UpdateOrderStatus(ctx context.Context, orderID string, req UpdateOrderStatusRequest, actor database.User) error
+	GetOrderStatusHistory(ctx context.Context, orderID string, user database.User) ([]OrderStatusHistoryResponse, error)
 	DeleteOrder(ctx context.Context, orderID string) error
 }
 
 // orderServiceImpl implements OrderService
 type orderServiceImpl struct {
-	db     *database.Queries
-	dbConn *sql.DB
+	db       *database.Queries
+	dbConn   *sql.DB
+	payments PaymentReleaser
 }
 
 // NewOrderService creates a new OrderService instance.
-// Accepts a database.Queries and a database connection, and returns an OrderService implementation.
-func NewOrderService(db *database.Queries, dbConn *sql.DB) OrderService {
+// Accepts a database.Queries, a database connection and the PaymentReleaser used to settle the payment of cancelled
+// orders, and returns an OrderService implementation. A nil PaymentReleaser leaves pending provider payments untouched
+// and refuses to cancel orders whose payment was captured.
+func NewOrderService(db *database.Queries, dbConn *sql.DB, payments PaymentReleaser) OrderService {
 	return &orderServiceImpl{
-		db:     db,
-		dbConn: dbConn,
+		db:       db,
+		dbConn:   dbConn,
+		payments: payments,
 	}
 }
 
 // CreateOrder handles the business logic for creating a new order.
-// Validates the request, calculates totals, creates the order and items, and commits the transaction.
-// Returns the created order response or an error.
+// Validates the request, prices the items from the products table, reserves stock, creates the order and items,
+// and commits the transaction. Returns the created order response or an error.
 func (s *orderServiceImpl) CreateOrder(ctx context.Context, user database.User, params CreateOrderRequest) (*OrderResponse, error) {
 	if s.dbConn == nil {
 		return nil, &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: errors.New("dbConn is nil")}
@@ -56,16 +63,16 @@ func (s *orderServiceImpl) CreateOrder(ctx context.Context, user database.User,
 		return nil, &handlers.AppError{Code: "invalid_request", Message: "Order must contain at least one item"}
 	}
 
-	// Calculate total amount
-	var totalAmount float64
 	for _, item := range params.Items {
+		if item.ProductID == "" {
+			return nil, &handlers.AppError{Code: "invalid_request", Message: "Product ID is required"}
+		}
 		if item.Quantity <= 0 {
 			return nil, &handlers.AppError{Code: "invalid_request", Message: "Quantity must be greater than 0"}
 		}
-		if item.Price < 0 {
+		if item.Price.IsNegative() {
 			return nil, &handlers.AppError{Code: "invalid_request", Message: "Price cannot be negative"}
 		}
-		totalAmount += float64(item.Quantity) * item.Price
 	}
 
 	orderID := utils.NewUUIDString()
@@ -83,12 +90,28 @@ func (s *orderServiceImpl) CreateOrder(ctx context.Context, user database.User,
 
 	queries := s.db.WithTx(tx)
 
+	// Price items from the products table; the request price is only used to detect changes
+	items, err := priceOrderItems(ctx, queries, params.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold stock for the order until it is paid or released
+	if err := reserveOrderStock(ctx, queries, params.Items, timeNow); err != nil {
+		return nil, err
+	}
+
+	totalAmount, err := orderTotal(items)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create order
 	_, err = queries.CreateOrder(ctx, database.CreateOrderParams{
 		ID:                orderID,
 		UserID:            user.ID,
-		TotalAmount:       fmt.Sprintf("%.2f", totalAmount),
-		Status:            "pending",
+		TotalAmount:       totalAmount.String(),
+		Status:            OrderStatusPending,
 		PaymentMethod:     utils.ToNullString(params.PaymentMethod),
 		ExternalPaymentID: utils.ToNullString(params.ExternalPaymentID),
 		TrackingNumber:    utils.ToNullString(params.TrackingNumber),
@@ -102,17 +125,14 @@ func (s *orderServiceImpl) CreateOrder(ctx context.Context, user database.User,
 	}
 
 	// Create order items
-	for _, item := range params.Items {
-		if item.Quantity > math.MaxInt32 || item.Quantity < math.MinInt32 {
-			return nil, &handlers.AppError{Code: "quantity_overflow", Message: fmt.Sprintf("Quantity %d exceeds the max limit for int32", item.Quantity)}
-		}
-
+	for _, item := range items {
 		err := queries.CreateOrderItem(ctx, database.CreateOrderItemParams{
 			ID:        utils.NewUUIDString(),
 			OrderID:   orderID,
-			ProductID: item.ProductID,
-			Quantity:  int32(item.Quantity),
-			Price:     fmt.Sprintf("%.2f", item.Price),
+			ProductID: item.productID,
+			VariantID: utils.ToNullString(item.variantID),
+			Quantity:  item.quantity,
+			Price:     item.unitPrice.String(),
 			CreatedAt: timeNow,
 			UpdatedAt: timeNow,
 		})
@@ -132,19 +152,99 @@ func (s *orderServiceImpl) CreateOrder(ctx context.Context, user database.User,
 	}, nil
 }
 
-// GetAllOrders retrieves all orders (admin only).
-// Returns a list of all orders or an error.
-func (s *orderServiceImpl) GetAllOrders(ctx context.Context) ([]database.Order, error) {
+// reserveOrderStock decrements the stock of every ordered line inside the order transaction.
+// Variant lines draw from the variant's stock, other lines from the product's. Lines are updated in
+// product then variant ID order so concurrent orders and checkouts lock rows in the same order.
+// The conditional decrement fails when stock is short, which is reported as "insufficient_stock".
+func reserveOrderStock(ctx context.Context, queries *database.Queries, items []OrderItemInput, now time.Time) error {
+	totals := make(map[orderLine]int, len(items))
+	for _, item := range items {
+		totals[lineOf(item)] += item.Quantity
+	}
+
+	lines := make([]orderLine, 0, len(totals))
+	for line := range totals {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].productID != lines[j].productID {
+			return lines[i].productID < lines[j].productID
+		}
+		return lines[i].variantID < lines[j].variantID
+	})
+
+	for _, line := range lines {
+		total := totals[line]
+		if total > math.MaxInt32 {
+			return &handlers.AppError{Code: "quantity_overflow", Message: fmt.Sprintf("Quantity %d exceeds the max limit for int32", total)}
+		}
+
+		var (
+			affected int64
+			err      error
+		)
+		if line.variantID != "" {
+			affected, err = queries.DecrementProductVariantStock(ctx, database.DecrementProductVariantStockParams{
+				Quantity:  int32(total),
+				UpdatedAt: now,
+				ID:        line.variantID,
+			})
+		} else {
+			affected, err = queries.DecrementProductStock(ctx, database.DecrementProductStockParams{
+				Quantity:  int32(total),
+				UpdatedAt: now,
+				ID:        line.productID,
+			})
+		}
+		if err != nil {
+			return &handlers.AppError{Code: "update_stock_failed", Message: "Error updating product stock", Err: err}
+		}
+		if affected == 0 {
+			if line.variantID != "" {
+				return &handlers.AppError{Code: "insufficient_stock", Message: fmt.Sprintf("Insufficient stock for variant %s", line.variantID)}
+			}
+			return &handlers.AppError{Code: "insufficient_stock", Message: fmt.Sprintf("Insufficient stock for product %s", line.productID)}
+		}
+	}
+
+	return nil
+}
+
+// orderSortOptions lists the accepted order list sorts; empty means newest first.
+var orderSortOptions = map[string]bool{"": true, "newest": true, "oldest": true}
+
+// GetAllOrders retrieves one page of orders (admin only), newest first unless the page asks for oldest.
+// Returns the page, with a cursor for the next one, or an error.
+func (s *orderServiceImpl) GetAllOrders(ctx context.Context, page pagination.Request) (pagination.Page[database.Order], error) {
 	if s.db == nil {
-		return nil, &handlers.AppError{Code: "database_error", Message: "Database not initialized", Err: errors.New("db is nil")}
+		return pagination.Page[database.Order]{}, &handlers.AppError{Code: "database_error", Message: "Database not initialized", Err: errors.New("db is nil")}
+	}
+	if !orderSortOptions[page.Sort] {
+		return pagination.Page[database.Order]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid sort option"}
+	}
+	sort := page.Sort
+	if sort == "" {
+		sort = "newest"
+	}
+	cursor, err := pagination.Decode(page.Cursor, sort)
+	if err != nil {
+		return pagination.Page[database.Order]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid cursor", Err: err}
 	}
 
-	orders, err := s.db.ListAllOrders(ctx)
+	limit := pagination.NormalizeLimit(page.Limit)
+	orders, err := s.db.ListAllOrders(ctx, database.ListAllOrdersParams{
+		CursorID:        cursor.IDArg(),
+		Sort:            sort,
+		CursorCreatedAt: cursor.CreatedAtArg(),
+		Limit:           pagination.FetchLimit(limit),
+	})
 	if err != nil {
-		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to list orders", Err: err}
+		return pagination.Page[database.Order]{}, &handlers.AppError{Code: "database_error", Message: "Failed to list orders", Err: err}
 	}
 
-	return orders, nil
+	return pagination.NewPage(orders, limit, func(o database.Order) pagination.Cursor {
+		return pagination.Cursor{Sort: sort, CreatedAt: o.CreatedAt, ID: o.ID}
+	}), nil
 }
 
 // GetUserOrders retrieves orders for a specific user.
@@ -171,6 +271,7 @@ func (s *orderServiceImpl) GetUserOrders(ctx context.Context, user database.User
 			itemResponses = append(itemResponses, OrderItemResponse{
 				ID:        item.ID,
 				ProductID: item.ProductID,
+				VariantID: item.VariantID.String,
 				Quantity:  int(item.Quantity),
 				Price:     item.Price,
 			})
@@ -180,6 +281,7 @@ func (s *orderServiceImpl) GetUserOrders(ctx context.Context, user database.User
 			OrderID:         order.ID,
 			TotalAmount:     order.TotalAmount,
 			Status:          order.Status,
+			RefundStatus:    order.RefundStatus,
 			PaymentMethod:   order.PaymentMethod.String,
 			TrackingNumber:  order.TrackingNumber.String,
 			ShippingAddress: order.ShippingAddress.String,
@@ -237,6 +339,7 @@ func (s *orderServiceImpl) GetOrderItemsByOrderID(ctx context.Context, orderID s
 		response = append(response, OrderItemResponse{
 			ID:        item.ID,
 			ProductID: item.ProductID,
+			VariantID: item.VariantID.String,
 			Quantity:  int(item.Quantity),
 			Price:     item.Price,
 		})
@@ -245,23 +348,19 @@ func (s *orderServiceImpl) GetOrderItemsByOrderID(ctx context.Context, orderID s
 	return response, nil
 }
 
-// UpdateOrderStatus updates the status of an order.
-// Validates the status, updates the order, and commits the transaction. Returns an error if unsuccessful.
-func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, orderID string, status string) error {
+// UpdateOrderStatus moves an order to a new status and records the change in the status history.
+// The order row is locked so the transition is checked against its current status. Cancelling an order releases it
+// like the order reaper does: its items are restocked and a pending payment is marked cancel_pending in the same
+// transaction. Once it commits the pending payment is voided with its provider and a captured payment is refunded in
+// full. Returns an error if the status is unknown, the transition is not allowed, or the update fails; a void or
+// refund that fails after the commit is reported with the order already cancelled.
+func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, orderID string, req UpdateOrderStatusRequest, actor database.User) error {
 	if s.dbConn == nil {
 		return &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: errors.New("dbConn is nil")}
 	}
 
 	// Validate status
-	validStatuses := map[string]bool{
-		"pending":   true,
-		"paid":      true,
-		"shipped":   true,
-		"delivered": true,
-		"cancelled": true,
-	}
-
-	if !validStatuses[status] {
+	if !IsValidOrderStatus(req.Status) {
 		return &handlers.AppError{Code: "invalid_status", Message: "Invalid order status"}
 	}
 
@@ -277,23 +376,164 @@ func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, orderID string
 
 	queries := s.db.WithTx(tx)
 
-	err = queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
-		ID:        orderID,
-		Status:    status,
-		UpdatedAt: time.Now().UTC(),
-	})
+	order, err := queries.GetOrderByIDForUpdate(ctx, orderID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+		}
 		return &handlers.AppError{Code: "update_failed", Message: "Failed to update order status", Err: err}
 	}
 
+	if !CanTransitionOrderStatus(order.Status, req.Status) {
+		return &handlers.AppError{
+			Code:    "invalid_status_transition",
+			Message: fmt.Sprintf("Cannot change order status from %s to %s", order.Status, req.Status),
+		}
+	}
+
+	timeNow := time.Now().UTC()
+
+	var cancellation orderCancellation
+	if req.Status == OrderStatusCancelled {
+		cancellation, err = s.cancelOrder(ctx, queries, order, actor.ID, req.Reason, timeNow)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+			ID:        orderID,
+			Status:    req.Status,
+			UpdatedAt: timeNow,
+		})
+		if err != nil {
+			return &handlers.AppError{Code: "update_failed", Message: "Failed to update order status", Err: err}
+		}
+
+		err = queries.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+			ID:          utils.NewUUIDString(),
+			OrderID:     orderID,
+			FromStatus:  order.Status,
+			ToStatus:    req.Status,
+			ActorUserID: utils.ToNullString(actor.ID),
+			Reason:      utils.ToNullString(req.Reason),
+			CreatedAt:   timeNow,
+		})
+		if err != nil {
+			return &handlers.AppError{Code: "update_failed", Message: "Failed to record order status history", Err: err}
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
 	}
 
+	if cancellation.voidPayment != nil {
+		err := settlePaymentCancel(ctx, s.db, s.payments, *cancellation.voidPayment, timeNow)
+		// A payment captured before the void landed is refunded through the refund request the payment webhook files
+		if err != nil && !errors.Is(err, ErrPaymentCaptured) {
+			return &handlers.AppError{
+				Code:    "payment_cancel_pending",
+				Message: "Order cancelled, but its payment could not be voided with the payment provider; it will be retried",
+				Err:     err,
+			}
+		}
+	}
+
+	if refundID := cancellation.refundID; refundID != "" {
+		if err := s.payments.SendRefund(ctx, refundID); err != nil {
+			return &handlers.AppError{
+				Code:    "refund_pending",
+				Message: fmt.Sprintf("Order cancelled, but refund %s could not be sent to the payment provider; retry it from the refunds admin endpoint", refundID),
+				Err:     err,
+			}
+		}
+	}
+
 	return nil
 }
 
+// orderCancellation holds the payment work left once an order cancel commits.
+type orderCancellation struct {
+	// voidPayment is the cancel_pending payment to void with its provider, if any.
+	voidPayment *database.Payment
+	// refundID is the reserved refund of a captured payment to send, if any.
+	refundID string
+}
+
+// cancelOrder releases a locked pending or paid order through the reaper's release path. A pending payment is marked
+// cancel_pending and returned to be voided with its provider once the cancel commits. A captured payment of a paid
+// order has a full refund reserved in the same transaction, and its ID is returned to be sent once the cancel commits.
+func (s *orderServiceImpl) cancelOrder(ctx context.Context, queries *database.Queries, order database.Order, actorID, reason string, now time.Time) (orderCancellation, error) {
+	payment, err := queries.GetPaymentByOrderID(ctx, order.ID)
+	hasPayment := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return orderCancellation{}, &handlers.AppError{Code: "update_failed", Message: "Failed to fetch order payment", Err: err}
+	}
+
+	if err := releaseLockedOrder(ctx, queries, order, actorID, reason, now); err != nil {
+		return orderCancellation{}, &handlers.AppError{Code: "update_failed", Message: "Failed to cancel order", Err: err}
+	}
+
+	if !hasPayment {
+		return orderCancellation{}, nil
+	}
+	// A failed attempt leaves the intent open for another try, so it is voided like a pending one
+	if payment.Status == "pending" || payment.Status == "failed" {
+		return orderCancellation{voidPayment: &payment}, nil
+	}
+	if payment.Status != "succeeded" && payment.Status != "partially_refunded" {
+		return orderCancellation{}, nil
+	}
+	if s.payments == nil {
+		return orderCancellation{}, &handlers.AppError{Code: "payment_release_failed", Message: "Payments are not configured", Err: errors.New("payment releaser is nil")}
+	}
+	cancelled := order
+	cancelled.Status = OrderStatusCancelled
+	refundID, err := s.payments.ReserveRefund(ctx, queries, cancelled, payment, actorID, reason, now)
+	if err != nil {
+		return orderCancellation{}, &handlers.AppError{Code: "payment_release_failed", Message: "Failed to refund the order payment", Err: err}
+	}
+	return orderCancellation{refundID: refundID}, nil
+}
+
+// GetOrderStatusHistory retrieves the status history of an order, oldest change first.
+// Only the order owner or an admin may view it.
+func (s *orderServiceImpl) GetOrderStatusHistory(ctx context.Context, orderID string, user database.User) ([]OrderStatusHistoryResponse, error) {
+	if s.db == nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Database not initialized", Err: errors.New("db is nil")}
+	}
+
+	order, err := s.db.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+	}
+
+	// Check authorization
+	if order.UserID != user.ID && user.Role != "admin" {
+		return nil, &handlers.AppError{Code: "unauthorized", Message: "User is not authorized to view this order"}
+	}
+
+	entries, err := s.db.GetOrderStatusHistoryByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch order status history", Err: err}
+	}
+
+	response := make([]OrderStatusHistoryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, OrderStatusHistoryResponse{
+			ID:          entry.ID,
+			FromStatus:  entry.FromStatus,
+			ToStatus:    entry.ToStatus,
+			ActorUserID: entry.ActorUserID.String,
+			Reason:      entry.Reason.String,
+			CreatedAt:   entry.CreatedAt,
+		})
+	}
+
+	return response, nil
+}
+
 // DeleteOrder deletes an order by ID.
 // Performs the deletion in a transaction and returns an error if unsuccessful.
 func (s *orderServiceImpl) DeleteOrder(ctx context.Context, orderID string) error {
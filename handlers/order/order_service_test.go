@@ -10,10 +10,14 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
+	"github.com/STaninnat/ecom-backend/utils"
 )
 
 // order_service_test.go: Tests for the OrderService implementation, focusing on order creation logic and error handling.
@@ -29,7 +33,7 @@ func TestNewOrderService(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	assert.NotNil(t, service)
 
 	// Test that the service implements the interface
@@ -42,13 +46,13 @@ func TestCreateOrder_Success(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2, Price: 10.50},
-			{ProductID: "prod2", Quantity: 1, Price: 25.00},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.50", money.USD)},
+			{ProductID: "prod2", Quantity: 1, Price: money.MustParse("25.00", money.USD)},
 		},
 		PaymentMethod:   "credit_card",
 		ShippingAddress: "123 Main St",
@@ -67,12 +71,76 @@ func TestCreateOrder_Success(t *testing.T) {
 	assert.Equal(t, "transaction_error", appErr.Code)
 }
 
+// TestCreateOrder_UsesProductPrices tests that orders are priced from the products table, not the request.
+func TestCreateOrder_UsesProductPrices(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	service := NewOrderService(database.New(db), db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM products\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs("prod1").WillReturnRows(newProductRows("prod1", "12.00", true))
+	expectNoVariants(mock, "prod1")
+	mock.ExpectQuery("FROM products\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs("prod2").WillReturnRows(newProductRows("prod2", "5.25", true))
+	expectNoVariants(mock, "prod2")
+	mock.ExpectExec("UPDATE products").WithArgs(int32(3), sqlmock.AnyArg(), "prod1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE products").WithArgs(int32(1), sqlmock.AnyArg(), "prod2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "user123", "41.25", "pending", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newOrderStatusRows("order1", "pending"))
+	mock.ExpectExec("INSERT INTO order_items").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "prod1", nil, int32(2), "12.00", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_items").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "prod2", nil, int32(1), "5.25", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_items").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "prod1", nil, int32(1), "12.00", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := service.CreateOrder(context.Background(), database.User{ID: "user123"}, CreateOrderRequest{
+		Items: []OrderItemInput{
+			{ProductID: "prod1", Quantity: 2},
+			{ProductID: "prod2", Quantity: 1, Price: money.MustParse("5.25", money.USD)},
+			{ProductID: "prod1", Quantity: 1},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.OrderID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateOrder_PriceChangedListsItems tests that every item with a stale price is reported.
+func TestCreateOrder_PriceChangedListsItems(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	service := NewOrderService(database.New(db), db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("prod1").WillReturnRows(newProductRows("prod1", "12.00", true))
+	expectNoVariants(mock, "prod1")
+	mock.ExpectQuery("FOR UPDATE").WithArgs("prod2").WillReturnRows(newProductRows("prod2", "5.00", true))
+	expectNoVariants(mock, "prod2")
+	mock.ExpectRollback()
+
+	_, err := service.CreateOrder(context.Background(), database.User{ID: "user123"}, CreateOrderRequest{
+		Items: []OrderItemInput{
+			{ProductID: "prod2", Quantity: 1, Price: money.MustParse("4.00", money.USD)},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.00", money.USD)},
+		},
+	})
+
+	var priceErr *PriceChangedError
+	require.True(t, errors.As(err, &priceErr))
+	assert.Equal(t, []PriceChange{
+		{ProductID: "prod2", Name: "Product prod2", ExpectedPrice: money.MustParse("4.00", money.USD), CurrentPrice: money.MustParse("5.00", money.USD)},
+		{ProductID: "prod1", Name: "Product prod1", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.00", money.USD)},
+	}, priceErr.Items)
+	assert.Equal(t, "price changed for products: prod2, prod1", priceErr.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // TestCreateOrder_EmptyItems tests order creation with empty items list.
 func TestCreateOrder_EmptyItems(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
@@ -95,12 +163,12 @@ func TestCreateOrder_InvalidQuantity(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 0, Price: 10.50},
+			{ProductID: "prod1", Quantity: 0, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 
@@ -120,12 +188,12 @@ func TestCreateOrder_NegativePrice(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 1, Price: -10.50},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("-10.50", money.USD)},
 		},
 	}
 
@@ -145,12 +213,12 @@ func TestCreateOrder_QuantityOverflow(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2147483648, Price: 10.50}, // Exceeds int32 max
+			{ProductID: "prod1", Quantity: 2147483648, Price: money.MustParse("10.50", money.USD)}, // Exceeds int32 max
 		},
 	}
 
@@ -164,6 +232,104 @@ func TestCreateOrder_QuantityOverflow(t *testing.T) {
 	assert.Equal(t, "transaction_error", appErr.Code)
 }
 
+// TestCreateOrder_VariantLine tests that a variant item is priced at the variant's override, draws from the
+// variant's stock and records the variant on the order item.
+func TestCreateOrder_VariantLine(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	service := NewOrderService(database.New(db), db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM products\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs("prod1").WillReturnRows(newProductRows("prod1", "20.00", true))
+	mock.ExpectQuery("FROM product_variants\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs("var1").WillReturnRows(newVariantRows("var1", "prod1", "24.00", true))
+	mock.ExpectExec("UPDATE product_variants").WithArgs(int32(2), sqlmock.AnyArg(), "var1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "user123", "48.00", "pending", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newOrderStatusRows("order1", "pending"))
+	mock.ExpectExec("INSERT INTO order_items").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "prod1", "var1", int32(2), "24.00", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := service.CreateOrder(context.Background(), database.User{ID: "user123"}, CreateOrderRequest{
+		Items: []OrderItemInput{{ProductID: "prod1", VariantID: "var1", Quantity: 2, Price: money.MustParse("24.00", money.USD)}},
+	})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.OrderID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateOrder_VariantErrors tests that missing, foreign, inactive and out-of-stock variants reject the order.
+func TestCreateOrder_VariantErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		item         OrderItemInput
+		mockSetup    func(mock sqlmock.Sqlmock)
+		expectedCode string
+	}{
+		{
+			name: "VariantRequired",
+			item: OrderItemInput{ProductID: "prod1", Quantity: 1},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM product_variants").WithArgs("prod1").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedCode: "invalid_product",
+		},
+		{
+			name: "UnknownVariant",
+			item: OrderItemInput{ProductID: "prod1", VariantID: "var1", Quantity: 1},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FOR UPDATE").WithArgs("var1").WillReturnError(sql.ErrNoRows)
+			},
+			expectedCode: "invalid_product",
+		},
+		{
+			name: "VariantOfAnotherProduct",
+			item: OrderItemInput{ProductID: "prod1", VariantID: "var1", Quantity: 1},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FOR UPDATE").WithArgs("var1").WillReturnRows(newVariantRows("var1", "prod2", "", true))
+			},
+			expectedCode: "invalid_product",
+		},
+		{
+			name: "InactiveVariant",
+			item: OrderItemInput{ProductID: "prod1", VariantID: "var1", Quantity: 1},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FOR UPDATE").WithArgs("var1").WillReturnRows(newVariantRows("var1", "prod1", "", false))
+			},
+			expectedCode: "invalid_product",
+		},
+		{
+			name: "InsufficientVariantStock",
+			item: OrderItemInput{ProductID: "prod1", VariantID: "var1", Quantity: 50},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FOR UPDATE").WithArgs("var1").WillReturnRows(newVariantRows("var1", "prod1", "", true))
+				mock.ExpectExec("UPDATE product_variants").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedCode: "insufficient_stock",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			service := NewOrderService(database.New(db), db, nil)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("FROM products").WithArgs("prod1").WillReturnRows(newProductRows("prod1", "20.00", true))
+			tc.mockSetup(mock)
+			mock.ExpectRollback()
+
+			_, err := service.CreateOrder(context.Background(), database.User{ID: "user123"}, CreateOrderRequest{Items: []OrderItemInput{tc.item}})
+
+			appErr := &handlers.AppError{}
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tc.expectedCode, appErr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // TestCreateOrder_ErrorScenarios tests order creation with various error scenarios.
 func TestCreateOrder_ErrorScenarios(t *testing.T) {
 	testCases := []struct {
@@ -187,6 +353,9 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 			name: "CreateOrderError",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectExec("UPDATE products").WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectExec("INSERT INTO orders").WillReturnError(errors.New("create order error"))
 				mock.ExpectRollback()
 				mock.ExpectClose()
@@ -199,6 +368,9 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 			name: "CommitError",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectExec("UPDATE products").WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectExec("INSERT INTO order_items").WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
@@ -212,6 +384,9 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 			name: "CreateOrderItemError",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectExec("UPDATE products").WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectExec("INSERT INTO order_items").WillReturnError(errors.New("order item creation error"))
 				mock.ExpectRollback()
@@ -221,6 +396,71 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 			expectedCode: "create_order_error",
 			expectedMsg:  "",
 		},
+		{
+			name: "InsufficientStock",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectExec("UPDATE products").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				mock.ExpectClose()
+			},
+			useNilDB:     false,
+			expectedCode: "insufficient_stock",
+			expectedMsg:  "Insufficient stock for product prod1",
+		},
+		{
+			name: "UpdateStockError",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectExec("UPDATE products").WillReturnError(errors.New("update stock error"))
+				mock.ExpectRollback()
+				mock.ExpectClose()
+			},
+			useNilDB:     false,
+			expectedCode: "update_stock_failed",
+			expectedMsg:  "",
+		},
+		{
+			name: "UnknownProduct",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+				mock.ExpectClose()
+			},
+			useNilDB:     false,
+			expectedCode: "invalid_product",
+			expectedMsg:  "Product prod1 does not exist",
+		},
+		{
+			name: "InactiveProduct",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "10.50", false))
+				mock.ExpectRollback()
+				mock.ExpectClose()
+			},
+			useNilDB:     false,
+			expectedCode: "invalid_product",
+			expectedMsg:  "Product prod1 is not available",
+		},
+		{
+			name: "PriceChanged",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM products").WillReturnRows(newProductRows("prod1", "12.00", true))
+				expectNoVariants(mock, "prod1")
+				mock.ExpectRollback()
+				mock.ExpectClose()
+			},
+			useNilDB:     false,
+			expectedCode: "price_changed",
+			expectedMsg:  "Prices have changed since the order was prepared",
+		},
 		{
 			name:         "NilDBConnection",
 			mockSetup:    nil,
@@ -246,7 +486,7 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 			var db *sql.DB
 			var mock sqlmock.Sqlmock
 			if tc.useNilDB {
-				service = NewOrderService(nil, nil)
+				service = NewOrderService(nil, nil, nil)
 			} else {
 				db, mock, _ = sqlmock.New()
 				queries := database.New(db)
@@ -254,11 +494,11 @@ func TestCreateOrder_ErrorScenarios(t *testing.T) {
 					mock.MatchExpectationsInOrder(false)
 					tc.mockSetup(mock)
 				}
-				service = NewOrderService(queries, db)
+				service = NewOrderService(queries, db, nil)
 			}
 			user := database.User{ID: "user123"}
 			params := CreateOrderRequest{
-				Items: []OrderItemInput{{ProductID: "prod1", Quantity: 2, Price: 10.50}},
+				Items: []OrderItemInput{{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.50", money.USD)}},
 			}
 			result, err := service.CreateOrder(context.Background(), user, params)
 			require.Error(t, err)
@@ -284,27 +524,79 @@ func TestGetAllOrders_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database query with correct 11 columns
 	mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnRows(
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user1", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 
-	orders, err := service.GetAllOrders(context.Background())
+	orders, err := service.GetAllOrders(context.Background(), pagination.Request{})
 
 	require.NoError(t, err)
-	assert.NotNil(t, orders)
-	assert.Len(t, orders, 1)
+	assert.Len(t, orders.Data, 1)
+	assert.False(t, orders.HasNext)
+}
+
+// TestGetAllOrders_Cursor tests that a full page returns a cursor and that the cursor reaches the query for the next page.
+func TestGetAllOrders_Cursor(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	queries := database.New(db)
+	service := NewOrderService(queries, db, nil)
+	columns := []string{
+		"id", "user_id", "total_amount", "status", "payment_method",
+		"external_payment_id", "tracking_number", "shipping_address",
+		"contact_phone", "created_at", "updated_at", "refund_status",
+	}
+	newer := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	older := newer.Add(-time.Hour)
+
+	mock.ExpectQuery("SELECT (.+) FROM orders").
+		WithArgs(sql.NullString{}, "newest", sql.NullTime{}, int32(2)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("order2", "user1", "20.00", "pending", nil, nil, nil, nil, nil, newer, newer, "none").
+			AddRow("order1", "user1", "10.00", "pending", nil, nil, nil, nil, nil, older, older, "none"))
+
+	first, err := service.GetAllOrders(context.Background(), pagination.Request{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first.Data, 1)
+	assert.Equal(t, "order2", first.Data[0].ID)
+	require.True(t, first.HasNext)
+
+	mock.ExpectQuery("SELECT (.+) FROM orders").
+		WithArgs(sql.NullString{String: "order2", Valid: true}, "newest", sql.NullTime{Time: newer, Valid: true}, int32(2)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("order1", "user1", "10.00", "pending", nil, nil, nil, nil, nil, older, older, "none"))
+
+	second, err := service.GetAllOrders(context.Background(), pagination.Request{Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.Data, 1)
+	assert.Equal(t, "order1", second.Data[0].ID)
+	assert.False(t, second.HasNext)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllOrders_InvalidPage tests that unknown sorts and malformed cursors are rejected before querying.
+func TestGetAllOrders_InvalidPage(t *testing.T) {
+	db, _, _ := sqlmock.New()
+	service := NewOrderService(database.New(db), db, nil)
+
+	for _, page := range []pagination.Request{{Sort: "total_desc"}, {Cursor: "%%%"}} {
+		_, err := service.GetAllOrders(context.Background(), page)
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "invalid_request", appErr.Code)
+	}
 }
 
 // TestGetAllOrders_DatabaseError tests error handling when database fails.
@@ -312,15 +604,15 @@ func TestGetAllOrders_DatabaseError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database query to return an error
 	mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnError(errors.New("database error"))
 
-	orders, err := service.GetAllOrders(context.Background())
+	orders, err := service.GetAllOrders(context.Background(), pagination.Request{})
 
 	require.Error(t, err)
-	assert.Nil(t, orders)
+	assert.Nil(t, orders.Data)
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
 	assert.True(t, ok)
@@ -332,7 +624,7 @@ func TestGetUserOrders_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123"}
 
 	// Mock the database queries with correct column structure
@@ -340,19 +632,20 @@ func TestGetUserOrders_Success(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user123", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnRows(
 		sqlmock.NewRows([]string{
-			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at",
+			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id",
 		}).AddRow(
-			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(),
+			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(), nil,
 		),
 	)
 
@@ -368,7 +661,7 @@ func TestGetUserOrders_DatabaseError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123"}
 
 	// Mock the database query to return an error
@@ -390,7 +683,7 @@ func TestGetUserOrders_OrderItemsError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123"}
 
 	// Mock the database queries
@@ -398,12 +691,13 @@ func TestGetUserOrders_OrderItemsError(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user123", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnError(errors.New("order items error"))
@@ -421,7 +715,7 @@ func TestGetUserOrders_OrderItemsError(t *testing.T) {
 
 // TestGetUserOrders_NilDatabase tests user orders retrieval with nil database.
 func TestGetUserOrders_NilDatabase(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 	user := database.User{ID: "user123"}
 
 	orders, err := service.GetUserOrders(context.Background(), user)
@@ -440,7 +734,7 @@ func TestGetOrderByID_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123"}
 
 	// Mock the database queries with correct column structure
@@ -448,19 +742,20 @@ func TestGetOrderByID_Success(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user123", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnRows(
 		sqlmock.NewRows([]string{
-			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at",
+			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id",
 		}).AddRow(
-			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(),
+			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(), nil,
 		),
 	)
 
@@ -476,7 +771,7 @@ func TestGetOrderByID_Unauthorized(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123", Role: "user"}
 
 	// Mock the database query to return an order owned by a different user
@@ -484,12 +779,13 @@ func TestGetOrderByID_Unauthorized(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user456", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 
@@ -508,7 +804,7 @@ func TestGetOrderByID_AdminAccess(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "admin123", Role: "admin"}
 
 	// Mock the database queries with correct column structure
@@ -516,19 +812,20 @@ func TestGetOrderByID_AdminAccess(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user456", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnRows(
 		sqlmock.NewRows([]string{
-			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at",
+			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id",
 		}).AddRow(
-			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(),
+			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(), nil,
 		),
 	)
 
@@ -544,7 +841,7 @@ func TestGetOrderByID_OrderItemsError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 	user := database.User{ID: "user123"}
 
 	// Mock the database queries
@@ -552,12 +849,13 @@ func TestGetOrderByID_OrderItemsError(t *testing.T) {
 		sqlmock.NewRows([]string{
 			"id", "user_id", "total_amount", "status", "payment_method",
 			"external_payment_id", "tracking_number", "shipping_address",
-			"contact_phone", "created_at", "updated_at",
+			"contact_phone", "created_at", "updated_at", "refund_status",
 		}).AddRow(
 			"order1", "user123", "100.00", "pending", sql.NullString{String: "credit_card", Valid: true},
 			sql.NullString{String: "", Valid: false}, sql.NullString{String: "", Valid: false},
 			sql.NullString{String: "123 Main St", Valid: true}, sql.NullString{String: "555-1234", Valid: true},
 			time.Now(), time.Now(),
+			"none",
 		),
 	)
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnError(errors.New("order items error"))
@@ -575,7 +873,7 @@ func TestGetOrderByID_OrderItemsError(t *testing.T) {
 
 // TestGetOrderByID_NilDatabase tests order retrieval with nil database.
 func TestGetOrderByID_NilDatabase(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 	user := database.User{ID: "user123"}
 
 	order, err := service.GetOrderByID(context.Background(), "order1", user)
@@ -594,16 +892,284 @@ func TestUpdateOrderStatus_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database operations
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", "paid"))
+	mock.ExpectExec("UPDATE orders").WithArgs("order123", "shipped", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(sqlmock.AnyArg(), "order123", "paid", "shipped", utils.ToNullString("admin1"), utils.ToNullString("Handed to courier"), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
-	err := service.UpdateOrderStatus(context.Background(), "order123", "shipped")
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "shipped", Reason: "Handed to courier"}, testAdmin)
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUpdateOrderStatus_TransitionRules tests that only transitions in the status graph are accepted.
+func TestUpdateOrderStatus_TransitionRules(t *testing.T) {
+	testCases := []struct {
+		from    string
+		to      string
+		allowed bool
+	}{
+		{"pending", "paid", true},
+		{"pending", "cancelled", true},
+		{"paid", "cancelled", true},
+		{"pending", "shipped", false},
+		{"paid", "shipped", true},
+		{"paid", "refunded", false},
+		{"shipped", "delivered", true},
+		{"delivered", "refunded", false},
+		{"shipped", "refunded", false},
+		{"delivered", "pending", false},
+		{"shipped", "paid", false},
+		{"cancelled", "paid", false},
+		{"refunded", "delivered", false},
+		{"paid", "paid", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.from+"_to_"+tc.to, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			service := NewOrderService(database.New(db), db, nil)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("FOR UPDATE").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", tc.from))
+			if tc.allowed {
+				if tc.to == OrderStatusCancelled {
+					mock.ExpectQuery("FROM payments").WithArgs("order123").WillReturnError(sql.ErrNoRows)
+					mock.ExpectQuery("FROM order_items").WithArgs("order123").WillReturnRows(sqlmock.NewRows(orderItemColumns))
+				}
+				mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(1, 1))
+				if tc.to == OrderStatusCancelled {
+					mock.ExpectExec("UPDATE payments").WithArgs("order123", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+				}
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: tc.to}, testAdmin)
+
+			if tc.allowed {
+				require.NoError(t, err)
+			} else {
+				appErr := &handlers.AppError{}
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, "invalid_status_transition", appErr.Code)
+				assert.Equal(t, "Cannot change order status from "+tc.from+" to "+tc.to, appErr.Message)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectCancelRelease sets up the queries issued when an admin cancels an order whose payment has the given status.
+// The order has one line, which is restocked before the order is marked cancelled.
+func expectCancelRelease(mock sqlmock.Sqlmock, from, paymentStatus string) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", from))
+	mock.ExpectQuery("FROM payments").WithArgs("order123").
+		WillReturnRows(sqlmock.NewRows(paymentColumns).
+			AddRow("pay1", "order123", "user1", "30.00", "USD", paymentStatus, "stripe", "pi_1", now, now))
+}
+
+// expectCancelRestock sets up the restock, status change and payment void of a cancelled order.
+func expectCancelRestock(mock sqlmock.Sqlmock, from string) {
+	now := time.Now()
+	mock.ExpectQuery("FROM order_items").WithArgs("order123").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow("item1", "order123", "prod1", 2, "15.00", now, now, nil))
+	mock.ExpectExec("UPDATE products").WithArgs(int32(2), sqlmock.AnyArg(), "prod1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE orders").WithArgs("order123", "cancelled", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(sqlmock.AnyArg(), "order123", from, "cancelled", utils.ToNullString("admin1"), utils.ToNullString("Customer asked"), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE payments").WithArgs("order123", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// TestUpdateOrderStatus_CancelPending tests that cancelling a pending order restocks its items and voids its payment
+// with the provider only after the cancel commits.
+func TestUpdateOrderStatus_CancelPending(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "pending", "pending")
+	expectCancelRestock(mock, "pending")
+	mock.ExpectCommit()
+	payments.On("CancelPayment", testifymock.Anything, testifymock.MatchedBy(func(p database.Payment) bool { return p.ID == "pay1" })).
+		Run(func(testifymock.Arguments) {
+			assert.NoError(t, mock.ExpectationsWereMet(), "payment voided before the cancel committed")
+			mock.ExpectExec("UPDATE payments\\s+SET status = 'cancelled'").WithArgs("pay1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		}).
+		Return(nil).Once()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestUpdateOrderStatus_CancelPendingCaptured tests that a pending order stays cancelled when the provider captured its
+// payment before the void, leaving the payment cancel_pending for the payment webhook to file a refund request.
+func TestUpdateOrderStatus_CancelPendingCaptured(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "pending", "pending")
+	expectCancelRestock(mock, "pending")
+	mock.ExpectCommit()
+	payments.On("CancelPayment", testifymock.Anything, testifymock.Anything).Return(ErrPaymentCaptured).Once()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestUpdateOrderStatus_CancelPendingVoidFails tests that a void the provider rejects is reported after the cancel
+// commits, leaving the payment cancel_pending for the reaper to retry.
+func TestUpdateOrderStatus_CancelPendingVoidFails(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "pending", "failed")
+	expectCancelRestock(mock, "pending")
+	mock.ExpectCommit()
+	payments.On("CancelPayment", testifymock.Anything, testifymock.Anything).Return(errors.New("provider down")).Once()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "payment_cancel_pending", appErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestUpdateOrderStatus_CancelPaid tests that cancelling a paid order reserves a full refund in the cancel
+// transaction and sends it once the cancel commits.
+func TestUpdateOrderStatus_CancelPaid(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "paid", "succeeded")
+	expectCancelRestock(mock, "paid")
+	payments.On("ReserveRefund", testifymock.Anything, testifymock.Anything,
+		testifymock.MatchedBy(func(o database.Order) bool { return o.ID == "order123" && o.Status == OrderStatusCancelled }),
+		testifymock.MatchedBy(func(p database.Payment) bool { return p.ID == "pay1" }),
+		"admin1", "Customer asked", testifymock.Anything).Return("ref1", nil).Once()
+	mock.ExpectCommit()
+	payments.On("SendRefund", testifymock.Anything, "ref1").Return(nil).Once()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestUpdateOrderStatus_CancelPaidReserveFails tests that a failure reserving the refund rolls back the cancel and
+// the restock.
+func TestUpdateOrderStatus_CancelPaidReserveFails(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "paid", "succeeded")
+	expectCancelRestock(mock, "paid")
+	payments.On("ReserveRefund", testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything).
+		Return("", errors.New("db down")).Once()
+	mock.ExpectRollback()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "payment_release_failed", appErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertNotCalled(t, "SendRefund", testifymock.Anything, testifymock.Anything)
+}
+
+// TestUpdateOrderStatus_CancelPaidSendFails tests that a refund the provider does not accept leaves the order
+// cancelled and reports the pending refund.
+func TestUpdateOrderStatus_CancelPaidSendFails(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	payments := new(MockPaymentReleaser)
+	service := NewOrderService(database.New(db), db, payments)
+
+	expectCancelRelease(mock, "paid", "succeeded")
+	expectCancelRestock(mock, "paid")
+	payments.On("ReserveRefund", testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything, testifymock.Anything).
+		Return("ref1", nil).Once()
+	mock.ExpectCommit()
+	payments.On("SendRefund", testifymock.Anything, "ref1").Return(errors.New("provider down")).Once()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "cancelled", Reason: "Customer asked"}, testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "refund_pending", appErr.Code)
+	assert.Contains(t, appErr.Message, "ref1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	payments.AssertExpectations(t)
+}
+
+// TestUpdateOrderStatus_OrderNotFound tests order status update for a missing order.
+func TestUpdateOrderStatus_OrderNotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	queries := database.New(db)
+
+	service := NewOrderService(queries, db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := service.UpdateOrderStatus(context.Background(), "missing", UpdateOrderStatusRequest{Status: "shipped"}, testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "order_not_found", appErr.Code)
+}
+
+// TestUpdateOrderStatus_HistoryError tests that a failure recording history aborts the status update.
+func TestUpdateOrderStatus_HistoryError(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	queries := database.New(db)
+
+	service := NewOrderService(queries, db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", "paid"))
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "shipped"}, testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "update_failed", appErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 // TestUpdateOrderStatus_InvalidStatus tests order status update with invalid status.
@@ -611,9 +1177,9 @@ func TestUpdateOrderStatus_InvalidStatus(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
-	err := service.UpdateOrderStatus(context.Background(), "order123", "invalid_status")
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "invalid_status"}, testAdmin)
 
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
@@ -628,14 +1194,16 @@ func TestUpdateOrderStatus_CommitError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database operations
 	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", "paid"))
 	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 
-	err := service.UpdateOrderStatus(context.Background(), "order123", "shipped")
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "shipped"}, testAdmin)
 
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
@@ -646,9 +1214,9 @@ func TestUpdateOrderStatus_CommitError(t *testing.T) {
 
 // TestUpdateOrderStatus_NilDBConnection tests order status update with nil database connection.
 func TestUpdateOrderStatus_NilDBConnection(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 
-	err := service.UpdateOrderStatus(context.Background(), "order123", "shipped")
+	err := service.UpdateOrderStatus(context.Background(), "order123", UpdateOrderStatusRequest{Status: "shipped"}, testAdmin)
 
 	require.Error(t, err)
 	appErr := &handlers.AppError{}
@@ -658,12 +1226,117 @@ func TestUpdateOrderStatus_NilDBConnection(t *testing.T) {
 	assert.Equal(t, "DB connection is nil", appErr.Message)
 }
 
+// TestGetOrderStatusHistory_Success tests that the owner can read the status history of an order.
+func TestGetOrderStatusHistory_Success(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	queries := database.New(db)
+
+	service := NewOrderService(queries, db, nil)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM orders").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", "shipped"))
+	mock.ExpectQuery("SELECT (.+) FROM order_status_history").WithArgs("order123").WillReturnRows(
+		sqlmock.NewRows(historyColumns).
+			AddRow("h1", "order123", "pending", "paid", nil, nil, now).
+			AddRow("h2", "order123", "paid", "shipped", "admin1", "Handed to courier", now),
+	)
+
+	history, err := service.GetOrderStatusHistory(context.Background(), "order123", database.User{ID: "user123"})
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, OrderStatusHistoryResponse{ID: "h1", FromStatus: "pending", ToStatus: "paid", CreatedAt: now}, history[0])
+	assert.Equal(t, "admin1", history[1].ActorUserID)
+	assert.Equal(t, "Handed to courier", history[1].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetOrderStatusHistory_AdminAccess tests that an admin can read the history of any order.
+func TestGetOrderStatusHistory_AdminAccess(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	queries := database.New(db)
+
+	service := NewOrderService(queries, db, nil)
+
+	mock.ExpectQuery("SELECT (.+) FROM orders").WithArgs("order123").WillReturnRows(newOrderStatusRows("order123", "pending"))
+	mock.ExpectQuery("SELECT (.+) FROM order_status_history").WithArgs("order123").WillReturnRows(sqlmock.NewRows(historyColumns))
+
+	history, err := service.GetOrderStatusHistory(context.Background(), "order123", testAdmin)
+
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.NotNil(t, history)
+}
+
+// TestGetOrderStatusHistory_Errors tests not found, unauthorized, and database failures.
+func TestGetOrderStatusHistory_Errors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mockSetup    func(mock sqlmock.Sqlmock)
+		user         database.User
+		expectedCode string
+	}{
+		{
+			name: "OrderNotFound",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnError(sql.ErrNoRows)
+			},
+			user:         database.User{ID: "user123"},
+			expectedCode: "order_not_found",
+		},
+		{
+			name: "NotOwner",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnRows(newOrderStatusRows("order123", "paid"))
+			},
+			user:         database.User{ID: "someone-else", Role: "user"},
+			expectedCode: "unauthorized",
+		},
+		{
+			name: "HistoryQueryError",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnRows(newOrderStatusRows("order123", "paid"))
+				mock.ExpectQuery("SELECT (.+) FROM order_status_history").WillReturnError(errors.New("db error"))
+			},
+			user:         database.User{ID: "user123"},
+			expectedCode: "database_error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			service := NewOrderService(database.New(db), db, nil)
+			tc.mockSetup(mock)
+
+			history, err := service.GetOrderStatusHistory(context.Background(), "order123", tc.user)
+
+			assert.Nil(t, history)
+			appErr := &handlers.AppError{}
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tc.expectedCode, appErr.Code)
+		})
+	}
+}
+
+// TestGetOrderStatusHistory_NilDatabase tests that history retrieval fails without a database.
+func TestGetOrderStatusHistory_NilDatabase(t *testing.T) {
+	service := NewOrderService(nil, nil, nil)
+
+	_, err := service.GetOrderStatusHistory(context.Background(), "order123", testAdmin)
+
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "database_error", appErr.Code)
+}
+
 // TestDeleteOrder_Success tests successful order deletion.
 func TestDeleteOrder_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database operations
 	mock.ExpectBegin()
@@ -680,7 +1353,7 @@ func TestDeleteOrder_CommitError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database operations
 	mock.ExpectBegin()
@@ -698,7 +1371,7 @@ func TestDeleteOrder_CommitError(t *testing.T) {
 
 // TestDeleteOrder_NilDBConnection tests order deletion with nil database connection.
 func TestDeleteOrder_NilDBConnection(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 
 	err := service.DeleteOrder(context.Background(), "order123")
 
@@ -712,13 +1385,13 @@ func TestDeleteOrder_NilDBConnection(t *testing.T) {
 
 // TestOrderService_NilDependencies tests service behavior with nil dependencies.
 func TestOrderService_NilDependencies(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 
 	// Test CreateOrder with nil dependencies
 	user := database.User{ID: "user123"}
 	params := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 1, Price: 10.50},
+			{ProductID: "prod1", Quantity: 1, Price: money.MustParse("10.50", money.USD)},
 		},
 	}
 
@@ -731,9 +1404,9 @@ func TestOrderService_NilDependencies(t *testing.T) {
 	assert.Equal(t, "transaction_error", appErr.Code)
 
 	// Test GetAllOrders with nil dependencies
-	orders, err := service.GetAllOrders(context.Background())
+	orders, err := service.GetAllOrders(context.Background(), pagination.Request{})
 	require.Error(t, err)
-	assert.Nil(t, orders)
+	assert.Nil(t, orders.Data)
 	appErr = &handlers.AppError{}
 	ok = errors.As(err, &appErr)
 	assert.True(t, ok)
@@ -745,16 +1418,16 @@ func TestGetOrderItemsByOrderID_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
-	// Mock the database query with correct 7 columns for OrderItem
+	// Mock the database query with correct 8 columns for OrderItem
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnRows(
 		sqlmock.NewRows([]string{
-			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at",
+			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id",
 		}).AddRow(
-			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(),
+			"item1", "order1", "prod1", 2, "50.00", time.Now(), time.Now(), nil,
 		).AddRow(
-			"item2", "order1", "prod2", 1, "25.00", time.Now(), time.Now(),
+			"item2", "order1", "prod2", 1, "25.00", time.Now(), time.Now(), "var1",
 		),
 	)
 
@@ -767,6 +1440,8 @@ func TestGetOrderItemsByOrderID_Success(t *testing.T) {
 	assert.Equal(t, "prod1", items[0].ProductID)
 	assert.Equal(t, 2, items[0].Quantity)
 	assert.Equal(t, "50.00", items[0].Price)
+	assert.Empty(t, items[0].VariantID)
+	assert.Equal(t, "var1", items[1].VariantID)
 }
 
 // TestGetOrderItemsByOrderID_DatabaseError tests order items retrieval with database error.
@@ -774,7 +1449,7 @@ func TestGetOrderItemsByOrderID_DatabaseError(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database query to return an error
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnError(errors.New("database error"))
@@ -792,7 +1467,7 @@ func TestGetOrderItemsByOrderID_DatabaseError(t *testing.T) {
 
 // TestGetOrderItemsByOrderID_NilDatabase tests order items retrieval with nil database.
 func TestGetOrderItemsByOrderID_NilDatabase(t *testing.T) {
-	service := NewOrderService(nil, nil)
+	service := NewOrderService(nil, nil, nil)
 
 	items, err := service.GetOrderItemsByOrderID(context.Background(), "order1")
 
@@ -810,12 +1485,12 @@ func TestGetOrderItemsByOrderID_EmptyResult(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	queries := database.New(db)
 
-	service := NewOrderService(queries, db)
+	service := NewOrderService(queries, db, nil)
 
 	// Mock the database query to return empty result with generic pattern
 	mock.ExpectQuery("SELECT (.+) FROM order_items").WithArgs("order1").WillReturnRows(
 		sqlmock.NewRows([]string{
-			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at",
+			"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id",
 		}),
 	)
 
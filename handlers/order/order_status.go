@@ -0,0 +1,50 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+// order_status.go: Defines the order and refund statuses and the transitions allowed between order statuses.
+
+// Order statuses, matching the CHECK constraint on the orders table.
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// Order refund statuses, matching the CHECK constraint on orders.refund_status.
+// Refunds are tracked apart from the order status, so a refunded order keeps its fulfilment status.
+const (
+	OrderRefundStatusNone              = "none"
+	OrderRefundStatusPartiallyRefunded = "partially_refunded"
+	OrderRefundStatusRefunded          = "refunded"
+)
+
+// orderStatusTransitions lists the statuses each status may move to.
+// Cancelled and refunded are terminal. No status moves to refunded: refunds are recorded in the refund status by the
+// refund flow, and refunded is kept only for orders refunded before that.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// IsValidOrderStatus reports whether status is a known order status.
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionOrderStatus reports whether an order may move from one status to another.
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
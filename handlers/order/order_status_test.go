@@ -0,0 +1,45 @@
+// Package orderhandlers provides HTTP handlers and services for managing orders, including creation, retrieval, updating, deletion, with error handling and logging.
+package orderhandlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// order_status_test.go: Tests for order status validation and the transition graph.
+
+// TestIsValidOrderStatus tests that every status allowed by the orders table is recognized.
+func TestIsValidOrderStatus(t *testing.T) {
+	for _, status := range []string{"pending", "paid", "shipped", "delivered", "cancelled", "refunded"} {
+		assert.True(t, IsValidOrderStatus(status), status)
+	}
+	assert.False(t, IsValidOrderStatus("created"))
+	assert.False(t, IsValidOrderStatus(OrderRefundStatusPartiallyRefunded))
+	assert.False(t, IsValidOrderStatus(""))
+}
+
+// TestCanTransitionOrderStatus_TerminalStatuses tests that cancelled and refunded orders cannot change status.
+func TestCanTransitionOrderStatus_TerminalStatuses(t *testing.T) {
+	for _, from := range []string{OrderStatusCancelled, OrderStatusRefunded} {
+		for to := range orderStatusTransitions {
+			assert.False(t, CanTransitionOrderStatus(from, to), "%s -> %s", from, to)
+		}
+	}
+}
+
+// TestCanTransitionOrderStatus_NoMovesToRefunded tests that no status moves to refunded, which only the refund flow
+// records, in the refund status.
+func TestCanTransitionOrderStatus_NoMovesToRefunded(t *testing.T) {
+	for from := range orderStatusTransitions {
+		assert.False(t, CanTransitionOrderStatus(from, OrderStatusRefunded), "%s -> refunded", from)
+	}
+}
+
+// TestCanTransitionOrderStatus_NoBackwardMoves tests that an order never returns to pending.
+func TestCanTransitionOrderStatus_NoBackwardMoves(t *testing.T) {
+	for from := range orderStatusTransitions {
+		assert.False(t, CanTransitionOrderStatus(from, OrderStatusPending), "%s -> pending", from)
+	}
+	assert.False(t, CanTransitionOrderStatus("unknown", OrderStatusPaid))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/middlewares"
 )
 
@@ -19,6 +20,7 @@ import (
 type HandlersOrderConfig struct {
 	*handlers.Config
 	Logger       handlers.HandlerLogger
+	Payments     PaymentReleaser
 	orderService OrderService
 	orderMutex   sync.RWMutex
 }
@@ -37,7 +39,7 @@ func (cfg *HandlersOrderConfig) InitOrderService() error {
 	}
 	cfg.orderMutex.Lock()
 	defer cfg.orderMutex.Unlock()
-	cfg.orderService = NewOrderService(cfg.DB, cfg.DBConn)
+	cfg.orderService = NewOrderService(cfg.DB, cfg.DBConn, cfg.Payments)
 
 	// Set Logger if not already set
 	if cfg.Logger == nil {
@@ -60,9 +62,9 @@ func (cfg *HandlersOrderConfig) GetOrderService() OrderService {
 	defer cfg.orderMutex.Unlock()
 	if cfg.orderService == nil {
 		if cfg.Config == nil || cfg.DB == nil || cfg.DBConn == nil {
-			cfg.orderService = NewOrderService(nil, nil)
+			cfg.orderService = NewOrderService(nil, nil, cfg.Payments)
 		} else {
-			cfg.orderService = NewOrderService(cfg.DB, cfg.DBConn)
+			cfg.orderService = NewOrderService(cfg.DB, cfg.DBConn, cfg.Payments)
 		}
 	}
 	return cfg.orderService
@@ -76,15 +78,33 @@ func (cfg *HandlersOrderConfig) handleOrderError(w http.ResponseWriter, r *http.
 	var appErr *handlers.AppError
 	if errors.As(err, &appErr) {
 		switch appErr.Code {
-		case "transaction_error", "update_failed", "commit_error", "create_order_error", "delete_order_error", "create_order_item_error":
+		case "transaction_error", "update_failed", "commit_error", "payment_release_failed", "create_order_error", "delete_order_error", "create_order_item_error", "update_stock_failed":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusInternalServerError, "Something went wrong, please try again later")
 		case "order_not_found":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusNotFound, appErr.Message)
-		case "invalid_request", "invalid_status", "quantity_overflow":
+		case "invalid_request", "invalid_status", "quantity_overflow", "insufficient_stock", "invalid_product":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message)
+		case "price_changed":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			var priceErr *PriceChangedError
+			if errors.As(appErr.Err, &priceErr) {
+				middlewares.RespondWithJSON(w, http.StatusConflict, PriceChangedResponse{
+					Error: appErr.Message,
+					Code:  appErr.Code,
+					Items: priceErr.Items,
+				})
+				return
+			}
+			middlewares.RespondWithError(w, http.StatusConflict, appErr.Message)
+		case "refund_pending", "payment_cancel_pending":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusBadGateway, appErr.Message)
+		case "invalid_status_transition":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusConflict, appErr.Message)
 		case "unauthorized":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusForbidden, appErr.Message)
@@ -111,21 +131,27 @@ type CreateOrderRequest struct {
 }
 
 // OrderItemInput represents an item in an order creation request.
+// VariantID is required for products that have active variants and selects the variant's stock and price.
+// Price is the unit price the client expects to pay. Orders are always priced from the products and variants
+// tables; when Price is set and differs from the current price the order is rejected with "price_changed".
 type OrderItemInput struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string       `json:"product_id"`
+	VariantID string       `json:"variant_id,omitempty"`
+	Quantity  int          `json:"quantity"`
+	Price     money.Amount `json:"price,omitzero"`
 }
 
 // UpdateOrderStatusRequest represents the data structure for updating order status.
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // OrderItemResponse represents an order item in responses.
 type OrderItemResponse struct {
 	ID        string `json:"id"`
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 	Price     string `json:"price"`
 }
@@ -135,6 +161,7 @@ type UserOrderResponse struct {
 	OrderID         string              `json:"order_id"`
 	TotalAmount     string              `json:"total_amount"`
 	Status          string              `json:"status"`
+	RefundStatus    string              `json:"refund_status"`
 	PaymentMethod   string              `json:"payment_method,omitempty"`
 	TrackingNumber  string              `json:"tracking_number,omitempty"`
 	ShippingAddress string              `json:"shipping_address,omitempty"`
@@ -143,6 +170,23 @@ type UserOrderResponse struct {
 	Items           []OrderItemResponse `json:"items"`
 }
 
+// OrderStatusHistoryResponse represents a single order status change in responses.
+type OrderStatusHistoryResponse struct {
+	ID          string    `json:"id"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	ActorUserID string    `json:"actor_user_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PriceChangedResponse is returned when an order is rejected because product prices changed.
+type PriceChangedResponse struct {
+	Error string        `json:"error"`
+	Code  string        `json:"code"`
+	Items []PriceChange `json:"items"`
+}
+
 // OrderResponse represents the standard response structure for order operations.
 type OrderResponse struct {
 	Message string `json:"message"`
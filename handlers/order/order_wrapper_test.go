@@ -17,6 +17,8 @@ import (
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/config"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // order_wrapper_test.go: Tests for order handler configuration, service initialization, error handling,
@@ -159,7 +161,7 @@ func TestGetOrderService_NilDependencies(t *testing.T) {
 	assert.NotNil(t, service)
 
 	// Test that service with nil deps doesn't panic
-	_, err := service.GetAllOrders(context.Background())
+	_, err := service.GetAllOrders(context.Background(), pagination.Request{})
 	assert.Error(t, err)
 }
 
@@ -235,6 +237,36 @@ func TestHandleOrderError_AllErrorCodes(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Quantity overflow",
 		},
+		{
+			name: "insufficient stock",
+			err: &handlers.AppError{
+				Code:    "insufficient_stock",
+				Message: "Insufficient stock for product prod1",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Insufficient stock for product prod1",
+		},
+		{
+			name: "invalid product",
+			err: &handlers.AppError{
+				Code:    "invalid_product",
+				Message: "Product prod1 is not available",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Product prod1 is not available",
+		},
+		{
+			name: "price changed",
+			err: &handlers.AppError{
+				Code:    "price_changed",
+				Message: "Prices have changed since the order was prepared",
+				Err: &PriceChangedError{Items: []PriceChange{
+					{ProductID: "prod1", Name: "Product 1", ExpectedPrice: money.MustParse("10.00", money.USD), CurrentPrice: money.MustParse("12.50", money.USD)},
+				}},
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"items":[{"product_id":"prod1","name":"Product 1","expected_price":10.00,"current_price":12.50}]`,
+		},
 		{
 			name: "unauthorized",
 			err: &handlers.AppError{
@@ -272,7 +304,7 @@ func TestRequestResponseStructs(t *testing.T) {
 	// Test CreateOrderRequest
 	createReq := CreateOrderRequest{
 		Items: []OrderItemInput{
-			{ProductID: "prod1", Quantity: 2, Price: 10.50},
+			{ProductID: "prod1", Quantity: 2, Price: money.MustParse("10.50", money.USD)},
 		},
 		PaymentMethod:     "credit_card",
 		ShippingAddress:   "123 Main St",
@@ -291,11 +323,11 @@ func TestRequestResponseStructs(t *testing.T) {
 	itemInput := OrderItemInput{
 		ProductID: "prod1",
 		Quantity:  5,
-		Price:     25.99,
+		Price:     money.MustParse("25.99", money.USD),
 	}
 	assert.Equal(t, "prod1", itemInput.ProductID)
 	assert.Equal(t, 5, itemInput.Quantity)
-	assert.InEpsilon(t, 25.99, itemInput.Price, 0.001)
+	assert.Equal(t, "25.99", itemInput.Price.String())
 
 	// Test UpdateOrderStatusRequest
 	updateReq := UpdateOrderStatusRequest{
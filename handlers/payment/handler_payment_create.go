@@ -16,7 +16,7 @@ import (
 
 // HandlerCreatePayment handles HTTP POST requests to create a new payment intent.
 // @Summary      Create payment intent
-// @Description  Creates a new payment intent for an order
+// @Description  Creates a new payment intent for an order with Stripe (default) or the manual provider
 // @Tags         payments
 // @Accept       json
 // @Produce      json
@@ -46,6 +46,7 @@ func (cfg *HandlersPaymentConfig) HandlerCreatePayment(w http.ResponseWriter, r
 		OrderID:  req.OrderID,
 		UserID:   user.ID,
 		Currency: req.Currency,
+		Provider: req.Provider,
 	})
 
 	if err != nil {
@@ -57,6 +58,8 @@ func (cfg *HandlersPaymentConfig) HandlerCreatePayment(w http.ResponseWriter, r
 	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "create_payment", "Created payment successful", ip, userAgent)
 
 	middlewares.RespondWithJSON(w, http.StatusCreated, CreatePaymentIntentResponse{
-		ClientSecret: result.ClientSecret,
+		ClientSecret:      result.ClientSecret,
+		Provider:          result.Provider,
+		ProviderPaymentID: result.ProviderPaymentID,
 	})
 }
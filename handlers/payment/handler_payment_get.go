@@ -9,6 +9,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/middlewares"
 	"github.com/STaninnat/ecom-backend/utils"
 )
@@ -79,13 +80,16 @@ func (cfg *HandlersPaymentConfig) HandlerGetPaymentHistory(w http.ResponseWriter
 	middlewares.RespondWithJSON(w, http.StatusOK, payments)
 }
 
-// HandlerAdminGetPayments handles HTTP GET requests to retrieve all payments for admin users.
+// HandlerAdminGetPayments handles HTTP GET requests to retrieve a page of payments for admin users.
 // @Summary      Admin get payments by status
-// @Description  Retrieves all payments filtered by status (admin only)
+// @Description  Retrieves a page of payments filtered by status, or "all" (admin only). Pass next_cursor back as cursor to get the next page
 // @Tags         payments
 // @Produce      json
-// @Param        status  path  string  true  "Payment status"
-// @Success      200  {array}  map[string]interface{}
+// @Param        status  path   string  true   "Payment status"
+// @Param        sort    query  string  false  "newest (default), oldest"
+// @Param        limit   query  int     false  "Payments per page, 1-100 (default 20)"
+// @Param        cursor  query  string  false  "next_cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Router       /v1/payments/admin/{status} [get]
 func (cfg *HandlersPaymentConfig) HandlerAdminGetPayments(w http.ResponseWriter, r *http.Request, _ database.User) {
@@ -94,8 +98,8 @@ func (cfg *HandlersPaymentConfig) HandlerAdminGetPayments(w http.ResponseWriter,
 
 	status := chi.URLParam(r, "status")
 
-	// Get all payments using service
-	payments, err := cfg.GetPaymentService().GetAllPayments(ctx, status)
+	// Get the requested page of payments using service
+	payments, err := cfg.GetPaymentService().GetAllPayments(ctx, status, pagination.ParseRequest(r))
 	if err != nil {
 		cfg.handlePaymentError(w, r, err, "admin_get_payments", ip, userAgent)
 		return
@@ -15,6 +15,8 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	testutil "github.com/STaninnat/ecom-backend/internal/testutil"
 )
 
@@ -34,7 +36,7 @@ func TestHandlerGetPayment_Success(t *testing.T) {
 		ID:       "p1",
 		OrderID:  "order1",
 		UserID:   "u1",
-		Amount:   100.0,
+		Amount:   money.MustParse("100.00", money.USD),
 		Currency: "USD",
 		Status:   "succeeded",
 	}
@@ -157,7 +159,8 @@ func runHandlerGetPaymentsSuccessTest(
 	case "GetPaymentHistory":
 		mockService.On("GetPaymentHistory", mock.Anything, user.ID).Return(serviceReturn, nil)
 	case "GetAllPayments":
-		mockService.On("GetAllPayments", mock.Anything, statusFilter).Return(serviceReturn, nil)
+		page := pagination.Page[PaymentHistoryItem]{Data: serviceReturn, Limit: pagination.DefaultLimit}
+		mockService.On("GetAllPayments", mock.Anything, statusFilter, pagination.Request{Limit: pagination.DefaultLimit}).Return(page, nil)
 	}
 	mockLog.On(logMethod, mock.Anything, mock.Anything, logMessage, mock.Anything, mock.Anything).Return()
 
@@ -172,7 +175,13 @@ func runHandlerGetPaymentsSuccessTest(
 	handler(cfg, w, r, user)
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp []PaymentHistoryItem
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+	if serviceMethod == "GetAllPayments" {
+		var page pagination.Page[PaymentHistoryItem]
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Errorf("Failed to decode response: %v", err)
+		}
+		resp = page.Data
+	} else if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Errorf("Failed to decode response: %v", err)
 	}
 	assert.Len(t, resp, expectedLen)
@@ -276,7 +285,7 @@ func runHandlerGetPaymentsErrorTest(
 	case "GetPaymentHistory":
 		mockService.On("GetPaymentHistory", mock.Anything, user.ID).Return(nil, appErr)
 	case "GetAllPayments":
-		mockService.On("GetAllPayments", mock.Anything, "").Return(nil, appErr)
+		mockService.On("GetAllPayments", mock.Anything, "", mock.Anything).Return(nil, appErr)
 	}
 	mockLog.On(logMethod, mock.Anything, mock.Anything, logCode, logMessage, mock.Anything, mock.Anything, appErr.Err).Return()
 
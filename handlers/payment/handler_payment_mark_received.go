@@ -0,0 +1,79 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_payment_mark_received.go: Admin handler for marking cash-on-delivery and bank transfer payments received.
+
+// HandlerAdminMarkPaymentReceived handles HTTP POST requests to mark a manual payment received.
+// @Summary      Admin mark payment received
+// @Description  Marks a pending manual payment (cash on delivery, bank transfer) as received and the order as paid (admin only)
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        order_id  path  string  true  "Order ID"
+// @Param        payment  body  MarkPaymentReceivedRequest  false  "Note"
+// @Success      200  {object}  ConfirmPaymentResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/payments/admin/{order_id}/mark-received [post]
+func (cfg *HandlersPaymentConfig) HandlerAdminMarkPaymentReceived(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	orderID := chi.URLParam(r, "order_id")
+	if orderID == "" {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"admin_mark_payment_received",
+			"missing_order_id",
+			"Order ID not found in URL",
+			ip, userAgent, nil,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Missing order_id")
+		return
+	}
+
+	// The body is optional; it only carries the note
+	var req MarkPaymentReceivedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"admin_mark_payment_received",
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	result, err := cfg.GetPaymentService().MarkPaymentReceived(ctx, MarkPaymentReceivedParams{
+		OrderID: orderID,
+		AdminID: user.ID,
+		Note:    req.Note,
+	})
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_mark_payment_received", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_mark_payment_received", "Payment marked received", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, ConfirmPaymentResponse{Status: result.Status})
+}
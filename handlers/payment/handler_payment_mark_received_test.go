@@ -0,0 +1,116 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_payment_mark_received_test.go: Tests for the admin handler that marks manual payments received.
+
+// newMarkReceivedTestRequest builds a mark-received request for the given order with the given body.
+func newMarkReceivedTestRequest(orderID, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/payments/admin/"+orderID+"/mark-received", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	if orderID != "" {
+		rctx.URLParams.Add("order_id", orderID)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandlerAdminMarkPaymentReceived_Success tests marking a payment received with and without a note.
+func TestHandlerAdminMarkPaymentReceived_Success(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		note string
+	}{
+		{name: "with_note", body: `{"note":"Transfer ref 8841"}`, note: "Transfer ref 8841"},
+		{name: "empty_body", body: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLog := newWebhookEventsTestConfig()
+			params := MarkPaymentReceivedParams{OrderID: "order1", AdminID: "admin1", Note: tt.note}
+			mockService.On("MarkPaymentReceived", mock.Anything, params).Return(&ConfirmPaymentResult{Status: "succeeded"}, nil)
+			mockLog.On("LogHandlerSuccess", mock.Anything, "admin_mark_payment_received", "Payment marked received", mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminMarkPaymentReceived(w, newMarkReceivedTestRequest("order1", tt.body), database.User{ID: "admin1"})
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp ConfirmPaymentResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, "succeeded", resp.Status)
+			mockService.AssertExpectations(t)
+			mockLog.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerAdminMarkPaymentReceived_MissingOrderID tests that the order ID is required.
+func TestHandlerAdminMarkPaymentReceived_MissingOrderID(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockLog.On("LogHandlerError", mock.Anything, "admin_mark_payment_received", "missing_order_id", "Order ID not found in URL", mock.Anything, mock.Anything, nil).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminMarkPaymentReceived(w, newMarkReceivedTestRequest("", ""), database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Missing order_id")
+	mockService.AssertNotCalled(t, "MarkPaymentReceived", mock.Anything, mock.Anything)
+}
+
+// TestHandlerAdminMarkPaymentReceived_InvalidJSON tests that a malformed body is rejected.
+func TestHandlerAdminMarkPaymentReceived_InvalidJSON(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockLog.On("LogHandlerError", mock.Anything, "admin_mark_payment_received", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminMarkPaymentReceived(w, newMarkReceivedTestRequest("order1", "{bad"), database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "MarkPaymentReceived", mock.Anything, mock.Anything)
+}
+
+// TestHandlerAdminMarkPaymentReceived_ServiceErrors tests how service errors map to HTTP statuses.
+func TestHandlerAdminMarkPaymentReceived_ServiceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *handlers.AppError
+		status int
+	}{
+		{name: "not_manual", err: &handlers.AppError{Code: "invalid_provider", Message: "Only manual payments can be marked received"}, status: http.StatusBadRequest},
+		{name: "not_pending", err: &handlers.AppError{Code: "invalid_status", Message: "Payment is already succeeded"}, status: http.StatusBadRequest},
+		{name: "order_not_found", err: &handlers.AppError{Code: "order_not_found", Message: "Order not found"}, status: http.StatusNotFound},
+		{name: "invalid_transition", err: &handlers.AppError{Code: "invalid_status_transition", Message: "Cannot change order status from cancelled to paid"}, status: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLog := newWebhookEventsTestConfig()
+			mockService.On("MarkPaymentReceived", mock.Anything, mock.Anything).Return(nil, tt.err)
+			mockLog.On("LogHandlerError", mock.Anything, "admin_mark_payment_received", tt.err.Code, tt.err.Message, mock.Anything, mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminMarkPaymentReceived(w, newMarkReceivedTestRequest("order1", ""), database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.err.Message)
+			mockLog.AssertExpectations(t)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package paymenthandlers
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -13,18 +14,23 @@ import (
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
-// handler_payment_refund.go: Refund payment request handler delegating to payment service.
+// handler_payment_refund.go: Customer refund request handler delegating to payment service.
 
-// HandlerRefundPayment handles HTTP POST requests to process a payment refund.
-// @Summary      Refund payment
-// @Description  Processes a payment refund for a specific order
+// HandlerRequestRefund handles HTTP POST requests from customers asking for a refund.
+// The request is stored for admin review; no money is refunded until an admin approves it.
+// @Summary      Request refund
+// @Description  Files a refund request for an order. Send a reason and either an amount or a list of order items; without either, the remaining balance is requested.
 // @Tags         payments
+// @Accept       json
 // @Produce      json
 // @Param        order_id  path  string  true  "Order ID"
-// @Success      200  {object}  map[string]string
+// @Param        refund  body  RefundPaymentRequest  true  "Reason and amount or order items to refund"
+// @Success      201  {object}  RefundRequestItem
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
 // @Router       /v1/payments/{order_id}/refund [post]
-func (cfg *HandlersPaymentConfig) HandlerRefundPayment(w http.ResponseWriter, r *http.Request, user database.User) {
+func (cfg *HandlersPaymentConfig) HandlerRequestRefund(w http.ResponseWriter, r *http.Request, user database.User) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
 	ctx := r.Context()
 
@@ -32,7 +38,7 @@ func (cfg *HandlersPaymentConfig) HandlerRefundPayment(w http.ResponseWriter, r
 	if orderID == "" {
 		cfg.Logger.LogHandlerError(
 			ctx,
-			"refund_payment",
+			"request_refund",
 			"missing_order_id",
 			"Order ID not found in URL",
 			ip, userAgent, nil,
@@ -41,19 +47,35 @@ func (cfg *HandlersPaymentConfig) HandlerRefundPayment(w http.ResponseWriter, r
 		return
 	}
 
-	// Process refund using service
-	err := cfg.GetPaymentService().RefundPayment(ctx, RefundPaymentParams{
+	var req RefundPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"request_refund",
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// File refund request using service
+	result, err := cfg.GetPaymentService().RequestRefund(ctx, RefundPaymentParams{
 		OrderID: orderID,
 		UserID:  user.ID,
+		Amount:  req.Amount.String(),
+		Items:   req.Items,
+		Reason:  req.Reason,
 	})
 
 	if err != nil {
-		cfg.handlePaymentError(w, r, err, "refund_payment", ip, userAgent)
+		cfg.handlePaymentError(w, r, err, "request_refund", ip, userAgent)
 		return
 	}
 
 	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
-	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "refund_payment", "Refund successful", ip, userAgent)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "request_refund", "Refund request filed", ip, userAgent)
 
-	middlewares.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Refund processed"})
+	middlewares.RespondWithJSON(w, http.StatusCreated, result)
 }
@@ -0,0 +1,201 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_payment_refund_requests.go: Admin handlers for listing, approving and rejecting refund requests, and for
+// retrying refunds the payment provider did not accept.
+
+// HandlerAdminListRefundRequests handles HTTP GET requests to list refund requests for admin users.
+// @Summary      Admin list refund requests
+// @Description  Lists refund requests by status, pending requests by default (admin only)
+// @Tags         payments
+// @Produce      json
+// @Param        status  query  string  false  "Request status (pending, approved, rejected)"
+// @Success      200  {array}  RefundRequestItem
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/payments/admin/refund-requests [get]
+func (cfg *HandlersPaymentConfig) HandlerAdminListRefundRequests(w http.ResponseWriter, r *http.Request, _ database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	status := r.URL.Query().Get("status")
+
+	requests, err := cfg.GetPaymentService().ListRefundRequests(ctx, status)
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_list_refund_requests", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "admin_list_refund_requests", "List refund requests success", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, requests)
+}
+
+// HandlerAdminApproveRefundRequest handles HTTP POST requests to approve a refund request and issue the refund.
+// @Summary      Admin approve refund request
+// @Description  Approves a pending refund request and refunds the payment with Stripe (admin only)
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        request_id  path  string  true  "Refund request ID"
+// @Param        review  body  ReviewRefundRequestRequest  false  "Review note"
+// @Success      200  {object}  RefundPaymentResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
+// @Router       /v1/payments/admin/refund-requests/{request_id}/approve [post]
+func (cfg *HandlersPaymentConfig) HandlerAdminApproveRefundRequest(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	params, ok := cfg.parseRefundReview(w, r, user, "admin_approve_refund_request")
+	if !ok {
+		return
+	}
+
+	result, err := cfg.GetPaymentService().ApproveRefundRequest(ctx, params)
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_approve_refund_request", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_approve_refund_request", "Refund request approved", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, RefundPaymentResponse{
+		Message:             "Refund processed",
+		RefundPaymentResult: *result,
+	})
+}
+
+// HandlerAdminRejectRefundRequest handles HTTP POST requests to reject a refund request.
+// @Summary      Admin reject refund request
+// @Description  Rejects a pending refund request without refunding anything (admin only)
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        request_id  path  string  true  "Refund request ID"
+// @Param        review  body  ReviewRefundRequestRequest  false  "Review note"
+// @Success      200  {object}  RefundRequestItem
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/payments/admin/refund-requests/{request_id}/reject [post]
+func (cfg *HandlersPaymentConfig) HandlerAdminRejectRefundRequest(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	params, ok := cfg.parseRefundReview(w, r, user, "admin_reject_refund_request")
+	if !ok {
+		return
+	}
+
+	result, err := cfg.GetPaymentService().RejectRefundRequest(ctx, params)
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_reject_refund_request", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_reject_refund_request", "Refund request rejected", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// HandlerAdminRetryRefund handles HTTP POST requests to resend a refund the payment provider did not accept.
+// @Summary      Admin retry refund
+// @Description  Resends a pending refund to its payment provider with the same idempotency key, for example after a cancelled order's refund failed (admin only)
+// @Tags         payments
+// @Produce      json
+// @Param        refund_id  path  string  true  "Refund ID"
+// @Success      200  {object}  RefundPaymentResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/payments/admin/refunds/{refund_id}/retry [post]
+func (cfg *HandlersPaymentConfig) HandlerAdminRetryRefund(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	refundID := chi.URLParam(r, "refund_id")
+	if refundID == "" {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"admin_retry_refund",
+			"missing_refund_id",
+			"Refund ID not found in URL",
+			ip, userAgent, nil,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Missing refund_id")
+		return
+	}
+
+	result, err := cfg.GetPaymentService().RetryRefund(ctx, refundID)
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_retry_refund", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_retry_refund", "Refund resent", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, RefundPaymentResponse{
+		Message:             "Refund processed",
+		RefundPaymentResult: *result,
+	})
+}
+
+// parseRefundReview reads the request ID from the URL and the optional review note from the body.
+// Writes an error response and returns false when either is invalid.
+func (cfg *HandlersPaymentConfig) parseRefundReview(w http.ResponseWriter, r *http.Request, user database.User, operation string) (ReviewRefundRequestParams, bool) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	requestID := chi.URLParam(r, "request_id")
+	if requestID == "" {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			operation,
+			"missing_request_id",
+			"Refund request ID not found in URL",
+			ip, userAgent, nil,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Missing request_id")
+		return ReviewRefundRequestParams{}, false
+	}
+
+	// The body is optional; it only carries the review note
+	var req ReviewRefundRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			operation,
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return ReviewRefundRequestParams{}, false
+	}
+
+	return ReviewRefundRequestParams{
+		RequestID: requestID,
+		AdminID:   user.ID,
+		Note:      req.Note,
+	}, true
+}
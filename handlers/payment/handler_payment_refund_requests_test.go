@@ -0,0 +1,228 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// handler_payment_refund_requests_test.go: Tests for the admin refund request handlers: listing, approving and rejecting requests.
+
+// newRefundReviewTestRequest builds a review request for refund request rr1 with the given body.
+func newRefundReviewTestRequest(action, requestID, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/payments/admin/refund-requests/"+requestID+"/"+action, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	if requestID != "" {
+		rctx.URLParams.Add("request_id", requestID)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandlerAdminListRefundRequests_Success tests listing refund requests filtered by the status query parameter.
+func TestHandlerAdminListRefundRequests_Success(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockService.On("ListRefundRequests", mock.Anything, "approved").Return([]RefundRequestItem{{ID: "rr1", Status: "approved"}}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "admin_list_refund_requests", "List refund requests success", mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/payments/admin/refund-requests?status=approved", nil)
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminListRefundRequests(w, req, database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []RefundRequestItem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "rr1", resp[0].ID)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerAdminListRefundRequests_InvalidStatus tests that an invalid status filter is rejected.
+func TestHandlerAdminListRefundRequests_InvalidStatus(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	appErr := &handlers.AppError{Code: "invalid_status", Message: "Invalid refund request status"}
+	mockService.On("ListRefundRequests", mock.Anything, "bogus").Return(nil, appErr)
+	mockLog.On("LogHandlerError", mock.Anything, "admin_list_refund_requests", "invalid_status", "Invalid refund request status", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/payments/admin/refund-requests?status=bogus", nil)
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminListRefundRequests(w, req, database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestHandlerAdminApproveRefundRequest_Success tests approving a request with a note.
+func TestHandlerAdminApproveRefundRequest_Success(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	params := ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1", Note: "Photos confirm damage"}
+	mockService.On("ApproveRefundRequest", mock.Anything, params).Return(&RefundPaymentResult{
+		RefundID:          "ref1",
+		Amount:            money.New(2550, money.USD),
+		PaymentStatus:     "partially_refunded",
+		OrderRefundStatus: "partially_refunded",
+	}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "admin_approve_refund_request", "Refund request approved", mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminApproveRefundRequest(w, newRefundReviewTestRequest("approve", "rr1", `{"note": "Photos confirm damage"}`), database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp RefundPaymentResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "Refund processed", resp.Message)
+	assert.Equal(t, "ref1", resp.RefundID)
+	assert.Equal(t, "25.50", resp.Amount.String())
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerAdminApproveRefundRequest_Errors tests the errors returned when approving a request.
+func TestHandlerAdminApproveRefundRequest_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		code           string
+		expectedStatus int
+	}{
+		{"not_found", "refund_request_not_found", http.StatusNotFound},
+		{"not_pending", "refund_request_not_pending", http.StatusConflict},
+		{"policy", "refund_not_allowed", http.StatusUnprocessableEntity},
+		{"stripe", "stripe_error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLog := newWebhookEventsTestConfig()
+			mockService.On("ApproveRefundRequest", mock.Anything, ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1"}).
+				Return(nil, &handlers.AppError{Code: tt.code, Message: "fail"})
+			mockLog.On("LogHandlerError", mock.Anything, "admin_approve_refund_request", tt.code, "fail", mock.Anything, mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminApproveRefundRequest(w, newRefundReviewTestRequest("approve", "rr1", ""), database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerAdminRejectRefundRequest_Success tests rejecting a request.
+func TestHandlerAdminRejectRefundRequest_Success(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	params := ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1", Note: "Outside policy"}
+	mockService.On("RejectRefundRequest", mock.Anything, params).Return(&RefundRequestItem{ID: "rr1", Status: "rejected", ReviewNote: "Outside policy"}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "admin_reject_refund_request", "Refund request rejected", mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminRejectRefundRequest(w, newRefundReviewTestRequest("reject", "rr1", `{"note": "Outside policy"}`), database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp RefundRequestItem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "rejected", resp.Status)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerAdminRefundReview_BadInput tests a missing request ID and a malformed body.
+func TestHandlerAdminRefundReview_BadInput(t *testing.T) {
+	t.Run("missing_request_id", func(t *testing.T) {
+		cfg, mockService, mockLog := newWebhookEventsTestConfig()
+		mockLog.On("LogHandlerError", mock.Anything, "admin_reject_refund_request", "missing_request_id", "Refund request ID not found in URL", mock.Anything, mock.Anything, nil).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerAdminRejectRefundRequest(w, newRefundReviewTestRequest("reject", "", ""), database.User{ID: "admin1"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "RejectRefundRequest", mock.Anything, mock.Anything)
+		mockLog.AssertExpectations(t)
+	})
+
+	t.Run("invalid_body", func(t *testing.T) {
+		cfg, mockService, mockLog := newWebhookEventsTestConfig()
+		mockLog.On("LogHandlerError", mock.Anything, "admin_approve_refund_request", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerAdminApproveRefundRequest(w, newRefundReviewTestRequest("approve", "rr1", `{"note": 5}`), database.User{ID: "admin1"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ApproveRefundRequest", mock.Anything, mock.Anything)
+		mockLog.AssertExpectations(t)
+	})
+}
+
+// newRetryRefundTestRequest builds a retry request with the refund_id URL parameter set when refundID is not empty.
+func newRetryRefundTestRequest(refundID string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/payments/admin/refunds/"+refundID+"/retry", nil)
+	rctx := chi.NewRouteContext()
+	if refundID != "" {
+		rctx.URLParams.Add("refund_id", refundID)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandlerAdminRetryRefund tests resending a refund and the responses for a missing ID and service errors.
+func TestHandlerAdminRetryRefund(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cfg, mockService, mockLog := newWebhookEventsTestConfig()
+		mockService.On("RetryRefund", mock.Anything, "ref1").Return(&RefundPaymentResult{
+			RefundID:          "ref1",
+			Amount:            money.New(10000, money.USD),
+			Status:            "succeeded",
+			PaymentStatus:     "refunded",
+			OrderRefundStatus: "refunded",
+		}, nil)
+		mockLog.On("LogHandlerSuccess", mock.Anything, "admin_retry_refund", "Refund resent", mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerAdminRetryRefund(w, newRetryRefundTestRequest("ref1"), database.User{ID: "admin1"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp RefundPaymentResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "ref1", resp.RefundID)
+		assert.Equal(t, "refunded", resp.OrderRefundStatus)
+		mockService.AssertExpectations(t)
+		mockLog.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name       string
+		refundID   string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "missing refund id", wantStatus: http.StatusBadRequest},
+		{name: "not found", refundID: "ref1", serviceErr: &handlers.AppError{Code: "refund_not_found", Message: "Refund not found"}, wantStatus: http.StatusNotFound},
+		{name: "not retryable", refundID: "ref1", serviceErr: &handlers.AppError{Code: "refund_not_retryable", Message: "Refund was already accepted by the provider"}, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLog := newWebhookEventsTestConfig()
+			if tt.refundID != "" {
+				mockService.On("RetryRefund", mock.Anything, tt.refundID).Return(nil, tt.serviceErr)
+			}
+			mockLog.On("LogHandlerError", mock.Anything, "admin_retry_refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminRetryRefund(w, newRetryRefundTestRequest(tt.refundID), database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			mockService.AssertExpectations(t)
+			mockLog.AssertExpectations(t)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -15,13 +16,22 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	testutil "github.com/STaninnat/ecom-backend/internal/testutil"
 )
 
-// handler_payment_refund_test.go: Tests for refund payment HTTP handler including success, missing params, and service errors.
+// handler_payment_refund_test.go: Tests for the customer refund request handler including success, missing params, bad bodies and service errors.
 
-// TestHandlerRefundPayment_Success tests successful refund
-func TestHandlerRefundPayment_Success(t *testing.T) {
+// newRefundRequestTestRequest builds a refund request for order1 with the given JSON body.
+func newRefundRequestTestRequest(body string) *http.Request {
+	r := httptest.NewRequest("POST", "/payments/order1/refund", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("order_id", "order1")
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandlerRequestRefund_Success tests that a refund request is filed and returned
+func TestHandlerRequestRefund_Success(t *testing.T) {
 	mockService := new(MockPaymentServiceForRefund)
 	mockLog := new(MockLoggerForRefund)
 	cfg := &HandlersPaymentConfig{
@@ -30,30 +40,90 @@ func TestHandlerRefundPayment_Success(t *testing.T) {
 		paymentService: mockService,
 	}
 	user := database.User{ID: "u1"}
-	params := RefundPaymentParams{OrderID: "order1", UserID: "u1"}
-	mockService.On("RefundPayment", mock.Anything, params).Return(nil)
-	mockLog.On("LogHandlerSuccess", mock.Anything, "refund_payment", "Refund successful", mock.Anything, mock.Anything).Return()
+	params := RefundPaymentParams{OrderID: "order1", UserID: "u1", Reason: "Arrived broken"}
+	mockService.On("RequestRefund", mock.Anything, params).Return(&RefundRequestItem{
+		ID:      "rr1",
+		OrderID: "order1",
+		Amount:  money.New(10000, money.USD),
+		Status:  "pending",
+	}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "request_refund", "Refund request filed", mock.Anything, mock.Anything).Return()
 
-	r := httptest.NewRequest("POST", "/payments/order1/refund", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("order_id", "order1")
-	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
 	w := httptest.NewRecorder()
+	cfg.HandlerRequestRefund(w, newRefundRequestTestRequest(`{"reason": "Arrived broken"}`), user)
 
-	cfg.HandlerRefundPayment(w, r, user)
-	assert.Equal(t, http.StatusOK, w.Code)
-	var resp map[string]string
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp RefundRequestItem
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Errorf("Failed to decode response: %v", err)
 	}
-	assert.Equal(t, "Refund processed", resp["message"])
+	assert.Equal(t, "rr1", resp.ID)
+	assert.Equal(t, "pending", resp.Status)
 	mockService.AssertExpectations(t)
 	mockLog.AssertExpectations(t)
 }
 
-// Remove the duplicated TestHandlerRefundPayment_MissingOrderID and use the shared helper from handler_payment_get_test.go
+// TestHandlerRequestRefund_PartialBody tests that the amount, items and reason in the body reach the service
+func TestHandlerRequestRefund_PartialBody(t *testing.T) {
+	mockService := new(MockPaymentServiceForRefund)
+	mockLog := new(MockLoggerForRefund)
+	cfg := &HandlersPaymentConfig{
+		Config:         &handlers.Config{},
+		Logger:         mockLog,
+		paymentService: mockService,
+	}
+	user := database.User{ID: "u1"}
+	tests := []struct {
+		name   string
+		body   string
+		params RefundPaymentParams
+	}{
+		{
+			name:   "amount",
+			body:   `{"amount": 12.5, "reason": "Late delivery"}`,
+			params: RefundPaymentParams{OrderID: "order1", UserID: "u1", Amount: "12.5", Reason: "Late delivery"},
+		},
+		{
+			name:   "items",
+			body:   `{"items": [{"order_item_id": "item1", "quantity": 2}], "reason": "Wrong size"}`,
+			params: RefundPaymentParams{OrderID: "order1", UserID: "u1", Items: []RefundItemInput{{OrderItemID: "item1", Quantity: 2}}, Reason: "Wrong size"},
+		},
+	}
+	mockLog.On("LogHandlerSuccess", mock.Anything, "request_refund", "Refund request filed", mock.Anything, mock.Anything).Return()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.On("RequestRefund", mock.Anything, tt.params).Return(&RefundRequestItem{ID: "rr1", Status: "pending"}, nil).Once()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerRequestRefund(w, newRefundRequestTestRequest(tt.body), user)
+			assert.Equal(t, http.StatusCreated, w.Code)
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
+// TestHandlerRequestRefund_InvalidBody tests missing and malformed request bodies
+func TestHandlerRequestRefund_InvalidBody(t *testing.T) {
+	for _, body := range []string{``, `{"amount": "ten"}`} {
+		mockService := new(MockPaymentServiceForRefund)
+		mockLog := new(MockLoggerForRefund)
+		cfg := &HandlersPaymentConfig{
+			Config:         &handlers.Config{},
+			Logger:         mockLog,
+			paymentService: mockService,
+		}
+		mockLog.On("LogHandlerError", mock.Anything, "request_refund", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerRequestRefund(w, newRefundRequestTestRequest(body), database.User{ID: "u1"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "RequestRefund", mock.Anything, mock.Anything)
+		mockLog.AssertExpectations(t)
+	}
+}
 
-func TestHandlerRefundPayment_MissingOrderID(t *testing.T) {
+func TestHandlerRequestRefund_MissingOrderID(t *testing.T) {
 	mockService := new(MockPaymentServiceForRefund)
 	mockLog := new(MockLoggerForRefund)
 	cfg := &HandlersPaymentConfig{
@@ -67,19 +137,19 @@ func TestHandlerRefundPayment_MissingOrderID(t *testing.T) {
 		func(cfg any, w http.ResponseWriter, r *http.Request, user any) {
 			c := cfg.(*HandlersPaymentConfig)
 			u := user.(database.User)
-			c.HandlerRefundPayment(w, r, u)
+			c.HandlerRequestRefund(w, r, u)
 		},
 		cfg,
 		user,
 		"POST",
 		"/payments//refund",
-		"refund_payment",
+		"request_refund",
 		&mockLog.Mock,
 	)
 }
 
-// TestHandlerRefundPayment_ErrorScenarios tests error scenarios for refund payment.
-func TestHandlerRefundPayment_ErrorScenarios(t *testing.T) {
+// TestHandlerRequestRefund_ErrorScenarios tests error scenarios for refund requests.
+func TestHandlerRequestRefund_ErrorScenarios(t *testing.T) {
 	tests := []struct {
 		name           string
 		err            *handlers.AppError
@@ -101,6 +171,20 @@ func TestHandlerRefundPayment_ErrorScenarios(t *testing.T) {
 			logMsg:         "not found",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:           "PolicyBlocked",
+			err:            &handlers.AppError{Code: "refund_not_allowed", Message: "Orders in transit cannot be refunded"},
+			logCode:        "refund_not_allowed",
+			logMsg:         "Orders in transit cannot be refunded",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "AlreadyPending",
+			err:            &handlers.AppError{Code: "refund_request_exists", Message: "A refund request for this order is already pending"},
+			logCode:        "refund_request_exists",
+			logMsg:         "A refund request for this order is already pending",
+			expectedStatus: http.StatusConflict,
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,47 +197,15 @@ func TestHandlerRefundPayment_ErrorScenarios(t *testing.T) {
 				paymentService: mockService,
 			}
 			user := database.User{ID: "u1"}
-			params := RefundPaymentParams{OrderID: "order1", UserID: "u1"}
-			mockService.On("RefundPayment", mock.Anything, params).Return(tt.err)
-			mockLog.On("LogHandlerError", mock.Anything, "refund_payment", tt.logCode, tt.logMsg, mock.Anything, mock.Anything, tt.err.Err).Return()
-
-			r := httptest.NewRequest("POST", "/payments/order1/refund", nil)
-			rctx := chi.NewRouteContext()
-			rctx.URLParams.Add("order_id", "order1")
-			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
-			w := httptest.NewRecorder()
+			params := RefundPaymentParams{OrderID: "order1", UserID: "u1", Reason: "Arrived broken"}
+			mockService.On("RequestRefund", mock.Anything, params).Return(nil, tt.err)
+			mockLog.On("LogHandlerError", mock.Anything, "request_refund", tt.logCode, tt.logMsg, mock.Anything, mock.Anything, tt.err.Err).Return()
 
-			cfg.HandlerRefundPayment(w, r, user)
+			w := httptest.NewRecorder()
+			cfg.HandlerRequestRefund(w, newRefundRequestTestRequest(`{"reason": "Arrived broken"}`), user)
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockService.AssertExpectations(t)
 			mockLog.AssertExpectations(t)
 		})
 	}
 }
-
-// TestHandlerRefundPayment_ValidationError tests validation error from service
-func TestHandlerRefundPayment_ValidationError(t *testing.T) {
-	mockService := new(MockPaymentServiceForRefund)
-	mockLog := new(MockLoggerForRefund)
-	cfg := &HandlersPaymentConfig{
-		Config:         &handlers.Config{},
-		Logger:         mockLog,
-		paymentService: mockService,
-	}
-	user := database.User{ID: "u1"}
-	params := RefundPaymentParams{OrderID: "order1", UserID: "u1"}
-	err := &handlers.AppError{Code: "invalid_request", Message: "Invalid order ID", Err: errors.New("invalid order")}
-	mockService.On("RefundPayment", mock.Anything, params).Return(err)
-	mockLog.On("LogHandlerError", mock.Anything, "refund_payment", "invalid_request", "Invalid order ID", mock.Anything, mock.Anything, mock.Anything).Return()
-
-	r := httptest.NewRequest("POST", "/payments/order1/refund", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("order_id", "order1")
-	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
-	w := httptest.NewRecorder()
-
-	cfg.HandlerRefundPayment(w, r, user)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	mockService.AssertExpectations(t)
-	mockLog.AssertExpectations(t)
-}
@@ -19,6 +19,7 @@ import (
 // @Produce      json
 // @Success      201  {object}  handlers.HandlerResponse
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /v1/payments/webhook [post]
 func (cfg *HandlersPaymentConfig) HandlerStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	const MaxBodyBytes = int64(65536)
@@ -0,0 +1,79 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+)
+
+// handler_payment_webhook_events.go: Admin handlers for listing recorded webhook events and re-running failed ones.
+
+// HandlerAdminListWebhookEvents handles HTTP GET requests to list recorded webhook events for admin users.
+// @Summary      Admin list webhook events
+// @Description  Lists recorded Stripe webhook events by processing status, failed events by default (admin only)
+// @Tags         payments
+// @Produce      json
+// @Param        status  query  string  false  "Event status (received, processed, skipped, failed)"
+// @Success      200  {array}  WebhookEventItem
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/payments/admin/webhook-events [get]
+func (cfg *HandlersPaymentConfig) HandlerAdminListWebhookEvents(w http.ResponseWriter, r *http.Request, _ database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	status := r.URL.Query().Get("status")
+
+	events, err := cfg.GetPaymentService().ListWebhookEvents(ctx, status)
+	if err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_list_webhook_events", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "admin_list_webhook_events", "List webhook events success", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, events)
+}
+
+// HandlerAdminRetryWebhookEvent handles HTTP POST requests to re-run a failed webhook event for admin users.
+// @Summary      Admin re-run webhook event
+// @Description  Re-runs a failed Stripe webhook event from its stored payload (admin only)
+// @Tags         payments
+// @Produce      json
+// @Param        event_id  path  string  true  "Stripe event ID"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/payments/admin/webhook-events/{event_id}/retry [post]
+func (cfg *HandlersPaymentConfig) HandlerAdminRetryWebhookEvent(w http.ResponseWriter, r *http.Request, _ database.User) {
+	ctx := r.Context()
+	ip, userAgent := handlers.GetRequestMetadata(r)
+
+	eventID := chi.URLParam(r, "event_id")
+	if eventID == "" {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"admin_retry_webhook_event",
+			"missing_event_id",
+			"Event ID not found in URL",
+			ip, userAgent, nil,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Missing event_id")
+		return
+	}
+
+	if err := cfg.GetPaymentService().RetryWebhookEvent(ctx, eventID); err != nil {
+		cfg.handlePaymentError(w, r, err, "admin_retry_webhook_event", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "admin_retry_webhook_event", "Webhook event re-run successfully", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Webhook event processed successfully",
+	})
+}
@@ -0,0 +1,121 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_payment_webhook_events_test.go: Tests for the admin webhook event handlers: listing events and re-running failed ones.
+
+// newWebhookEventsTestConfig creates a payment handler config backed by a mock service and logger.
+func newWebhookEventsTestConfig() (*HandlersPaymentConfig, *MockPaymentService, *MockLoggerForWebhook) {
+	mockService := new(MockPaymentService)
+	mockLog := new(MockLoggerForWebhook)
+	cfg := &HandlersPaymentConfig{
+		Config:         &handlers.Config{},
+		Logger:         mockLog,
+		paymentService: mockService,
+	}
+	return cfg, mockService, mockLog
+}
+
+// withEventIDParam adds the event_id URL parameter to the request.
+func withEventIDParam(r *http.Request, eventID string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("event_id", eventID)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestHandlerAdminListWebhookEvents_Success tests listing webhook events filtered by the status query parameter.
+func TestHandlerAdminListWebhookEvents_Success(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockService.On("ListWebhookEvents", mock.Anything, "skipped").Return([]WebhookEventItem{{ID: "evt_123", Status: "skipped"}}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "admin_list_webhook_events", "List webhook events success", mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/payments/admin/webhook-events?status=skipped", nil)
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminListWebhookEvents(w, req, database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []WebhookEventItem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "evt_123", resp[0].ID)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerAdminListWebhookEvents_InvalidStatus tests that an invalid status filter is rejected.
+func TestHandlerAdminListWebhookEvents_InvalidStatus(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockService.On("ListWebhookEvents", mock.Anything, "bogus").Return(nil, &handlers.AppError{Code: "invalid_status", Message: "Invalid webhook event status"})
+	mockLog.On("LogHandlerError", mock.Anything, "admin_list_webhook_events", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/payments/admin/webhook-events?status=bogus", nil)
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminListWebhookEvents(w, req, database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestHandlerAdminRetryWebhookEvent_Success tests re-running a failed webhook event.
+func TestHandlerAdminRetryWebhookEvent_Success(t *testing.T) {
+	cfg, mockService, mockLog := newWebhookEventsTestConfig()
+	mockService.On("RetryWebhookEvent", mock.Anything, "evt_123").Return(nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "admin_retry_webhook_event", "Webhook event re-run successfully", mock.Anything, mock.Anything).Return()
+
+	req := withEventIDParam(httptest.NewRequest(http.MethodPost, "/v1/payments/admin/webhook-events/evt_123/retry", nil), "evt_123")
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminRetryWebhookEvent(w, req, database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Webhook event processed successfully")
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerAdminRetryWebhookEvent_Errors tests the responses for a missing ID and for service errors.
+func TestHandlerAdminRetryWebhookEvent_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventID    string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "missing event id", wantStatus: http.StatusBadRequest},
+		{name: "not found", eventID: "evt_123", serviceErr: &handlers.AppError{Code: "webhook_event_not_found", Message: "Webhook event not found"}, wantStatus: http.StatusNotFound},
+		{name: "not failed", eventID: "evt_123", serviceErr: &handlers.AppError{Code: "webhook_event_not_failed", Message: "Only failed webhook events can be re-run"}, wantStatus: http.StatusConflict},
+		{name: "invalid transition", eventID: "evt_123", serviceErr: &handlers.AppError{Code: "invalid_status_transition", Message: "Cannot change order status"}, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLog := newWebhookEventsTestConfig()
+			if tt.serviceErr != nil {
+				mockService.On("RetryWebhookEvent", mock.Anything, tt.eventID).Return(tt.serviceErr)
+			}
+			mockLog.On("LogHandlerError", mock.Anything, "admin_retry_webhook_event", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+			req := withEventIDParam(httptest.NewRequest(http.MethodPost, "/v1/payments/admin/webhook-events/x/retry", nil), tt.eventID)
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminRetryWebhookEvent(w, req, database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			mockService.AssertExpectations(t)
+			mockLog.AssertExpectations(t)
+		})
+	}
+}
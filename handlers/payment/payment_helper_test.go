@@ -8,12 +8,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stripe/stripe-go/v82"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
+	"github.com/STaninnat/ecom-backend/utils"
 )
 
 // payment_helper_test.go: Mock implementations for payment service, DB, Stripe, and logger interfaces used in tests.
@@ -40,6 +43,14 @@ func (m *MockPaymentService) ConfirmPayment(ctx context.Context, params ConfirmP
 	return args.Get(0).(*ConfirmPaymentResult), args.Error(1)
 }
 
+func (m *MockPaymentService) MarkPaymentReceived(ctx context.Context, params MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ConfirmPaymentResult), args.Error(1)
+}
+
 func (m *MockPaymentService) GetPayment(ctx context.Context, orderID string, userID string) (*GetPaymentResult, error) {
 	args := m.Called(ctx, orderID, userID)
 	if args.Get(0) == nil {
@@ -56,17 +67,44 @@ func (m *MockPaymentService) GetPaymentHistory(ctx context.Context, userID strin
 	return args.Get(0).([]PaymentHistoryItem), args.Error(1)
 }
 
-func (m *MockPaymentService) GetAllPayments(ctx context.Context, status string) ([]PaymentHistoryItem, error) {
+func (m *MockPaymentService) GetAllPayments(ctx context.Context, status string, page pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	args := m.Called(ctx, status, page)
+	if args.Get(0) == nil {
+		return pagination.Page[PaymentHistoryItem]{}, args.Error(1)
+	}
+	return args.Get(0).(pagination.Page[PaymentHistoryItem]), args.Error(1)
+}
+
+func (m *MockPaymentService) RequestRefund(ctx context.Context, params RefundPaymentParams) (*RefundRequestItem, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundRequestItem), args.Error(1)
+}
+
+func (m *MockPaymentService) ListRefundRequests(ctx context.Context, status string) ([]RefundRequestItem, error) {
 	args := m.Called(ctx, status)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]PaymentHistoryItem), args.Error(1)
+	return args.Get(0).([]RefundRequestItem), args.Error(1)
 }
 
-func (m *MockPaymentService) RefundPayment(ctx context.Context, params RefundPaymentParams) error {
+func (m *MockPaymentService) ApproveRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundPaymentResult, error) {
 	args := m.Called(ctx, params)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundPaymentResult), args.Error(1)
+}
+
+func (m *MockPaymentService) RejectRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundRequestItem), args.Error(1)
 }
 
 func (m *MockPaymentService) HandleWebhook(ctx context.Context, payload []byte, signature string, secret string) error {
@@ -74,6 +112,27 @@ func (m *MockPaymentService) HandleWebhook(ctx context.Context, payload []byte,
 	return args.Error(0)
 }
 
+func (m *MockPaymentService) ListWebhookEvents(ctx context.Context, status string) ([]WebhookEventItem, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]WebhookEventItem), args.Error(1)
+}
+
+func (m *MockPaymentService) RetryWebhookEvent(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentService) RetryRefund(ctx context.Context, refundID string) (*RefundPaymentResult, error) {
+	args := m.Called(ctx, refundID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundPaymentResult), args.Error(1)
+}
+
 // --- Service Mock ---
 // mockPaymentDBQueries is a testify-based mock implementation of PaymentDBQueries.
 // It allows tests to mock database query operations without a real database.
@@ -105,16 +164,8 @@ func (m *mockPaymentDBQueries) GetPaymentsByUserID(ctx context.Context, userID s
 	return args.Get(0).([]database.Payment), args.Error(1)
 }
 
-func (m *mockPaymentDBQueries) GetAllPayments(ctx context.Context) ([]database.Payment, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]database.Payment), args.Error(1)
-}
-
-func (m *mockPaymentDBQueries) GetPaymentsByStatus(ctx context.Context, status string) ([]database.Payment, error) {
-	args := m.Called(ctx, status)
+func (m *mockPaymentDBQueries) GetAllPayments(ctx context.Context, params database.GetAllPaymentsParams) ([]database.Payment, error) {
+	args := m.Called(ctx, params)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -151,6 +202,148 @@ func (m *mockPaymentDBQueries) GetPaymentByProviderPaymentID(ctx context.Context
 	return args.Get(0).(database.Payment), args.Error(1)
 }
 
+func (m *mockPaymentDBQueries) GetOrderByIDForUpdate(ctx context.Context, id string) (database.Order, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Order), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) CreateOrderStatusHistory(ctx context.Context, params database.CreateOrderStatusHistoryParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) CreateWebhookEvent(ctx context.Context, params database.CreateWebhookEventParams) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetWebhookEventByID(ctx context.Context, id string) (database.WebhookEvent, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.WebhookEvent), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetWebhookEventByIDForUpdate(ctx context.Context, id string) (database.WebhookEvent, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.WebhookEvent), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetLatestProcessedWebhookEventTime(ctx context.Context, providerPaymentID string) (time.Time, error) {
+	args := m.Called(ctx, providerPaymentID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) ListWebhookEventsByStatus(ctx context.Context, status string) ([]database.WebhookEvent, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.WebhookEvent), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) UpdateWebhookEventResult(ctx context.Context, params database.UpdateWebhookEventResultParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) GetOrderItemsByOrderID(ctx context.Context, orderID string) ([]database.OrderItem, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.OrderItem), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) IncrementProductStock(ctx context.Context, params database.IncrementProductStockParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) IncrementProductVariantStock(ctx context.Context, params database.IncrementProductVariantStockParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) CreateRefund(ctx context.Context, params database.CreateRefundParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) CreateRefundItem(ctx context.Context, params database.CreateRefundItemParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) GetRefundByID(ctx context.Context, id string) (database.Refund, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Refund), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetRefundByIDForUpdate(ctx context.Context, id string) (database.Refund, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Refund), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) UpdateRefundResult(ctx context.Context, params database.UpdateRefundResultParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) UpdateRefundRequestRefundID(ctx context.Context, params database.UpdateRefundRequestRefundIDParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) UpdateOrderRefundStatus(ctx context.Context, params database.UpdateOrderRefundStatusParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) GetRefundedAmountByPaymentID(ctx context.Context, paymentID string) (string, error) {
+	args := m.Called(ctx, paymentID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetRefundedQuantitiesByOrderID(ctx context.Context, orderID string) ([]database.GetRefundedQuantitiesByOrderIDRow, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.GetRefundedQuantitiesByOrderIDRow), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetLatestOrderStatusChangeTime(ctx context.Context, orderID, toStatus string) (time.Time, error) {
+	args := m.Called(ctx, orderID, toStatus)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) CreateRefundRequest(ctx context.Context, params database.CreateRefundRequestParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *mockPaymentDBQueries) GetPendingRefundRequestByOrderID(ctx context.Context, orderID string) (database.RefundRequest, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).(database.RefundRequest), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) GetRefundRequestByIDForUpdate(ctx context.Context, id string) (database.RefundRequest, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.RefundRequest), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) ListRefundRequestsByStatus(ctx context.Context, status string) ([]database.RefundRequest, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.RefundRequest), args.Error(1)
+}
+
+func (m *mockPaymentDBQueries) UpdateRefundRequestReview(ctx context.Context, params database.UpdateRefundRequestReviewParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
 // --- Database Connection Mock ---
 // mockPaymentDBConn is a testify-based mock implementation of PaymentDBConn.
 type mockPaymentDBConn struct{ mock.Mock }
@@ -187,6 +380,13 @@ func (m *mockStripeClient) GetPaymentIntent(id string) (*stripe.PaymentIntent, e
 	}
 	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
 }
+func (m *mockStripeClient) CancelPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
 func (m *mockStripeClient) CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
 	args := m.Called(params)
 	if args.Get(0) == nil {
@@ -218,21 +418,43 @@ func (m *MockPaymentServiceForConfirm) ConfirmPayment(ctx context.Context, param
 	}
 	return args.Get(0).(*ConfirmPaymentResult), args.Error(1)
 }
+func (m *MockPaymentServiceForConfirm) MarkPaymentReceived(_ context.Context, _ MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	return nil, nil
+}
 func (m *MockPaymentServiceForConfirm) GetPayment(_ context.Context, _ string, _ string) (*GetPaymentResult, error) {
 	return nil, nil
 }
 func (m *MockPaymentServiceForConfirm) GetPaymentHistory(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
 	return nil, nil
 }
-func (m *MockPaymentServiceForConfirm) GetAllPayments(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
+func (m *MockPaymentServiceForConfirm) GetAllPayments(_ context.Context, _ string, _ pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	return pagination.Page[PaymentHistoryItem]{}, nil
+}
+func (m *MockPaymentServiceForConfirm) RequestRefund(_ context.Context, _ RefundPaymentParams) (*RefundRequestItem, error) {
 	return nil, nil
 }
-func (m *MockPaymentServiceForConfirm) RefundPayment(_ context.Context, _ RefundPaymentParams) error {
-	return nil
+func (m *MockPaymentServiceForConfirm) ListRefundRequests(_ context.Context, _ string) ([]RefundRequestItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForConfirm) ApproveRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForConfirm) RejectRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	return nil, nil
 }
 func (m *MockPaymentServiceForConfirm) HandleWebhook(_ context.Context, _ []byte, _ string, _ string) error {
 	return nil
 }
+func (m *MockPaymentServiceForConfirm) ListWebhookEvents(_ context.Context, _ string) ([]WebhookEventItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForConfirm) RetryWebhookEvent(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPaymentServiceForConfirm) RetryRefund(_ context.Context, _ string) (*RefundPaymentResult, error) {
+	return nil, nil
+}
 
 // MockLoggerForConfirm is a mock implementation of HandlerLogger for confirm tests
 type MockLoggerForConfirm struct {
@@ -264,6 +486,9 @@ func (m *MockPaymentServiceForCreate) ConfirmPayment(_ context.Context, _ Confir
 	return nil, nil // not used in create tests
 }
 
+func (m *MockPaymentServiceForCreate) MarkPaymentReceived(_ context.Context, _ MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	return nil, nil
+}
 func (m *MockPaymentServiceForCreate) GetPayment(_ context.Context, _ string, _ string) (*GetPaymentResult, error) {
 	return nil, nil // not used in create tests
 }
@@ -272,17 +497,36 @@ func (m *MockPaymentServiceForCreate) GetPaymentHistory(_ context.Context, _ str
 	return nil, nil // not used in create tests
 }
 
-func (m *MockPaymentServiceForCreate) GetAllPayments(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
-	return nil, nil // not used in create tests
+func (m *MockPaymentServiceForCreate) GetAllPayments(_ context.Context, _ string, _ pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	return pagination.Page[PaymentHistoryItem]{}, nil // not used in create tests
 }
 
-func (m *MockPaymentServiceForCreate) RefundPayment(_ context.Context, _ RefundPaymentParams) error {
-	return nil // not used in create tests
+func (m *MockPaymentServiceForCreate) RequestRefund(_ context.Context, _ RefundPaymentParams) (*RefundRequestItem, error) {
+	return nil, nil // not used in create tests
+}
+func (m *MockPaymentServiceForCreate) ListRefundRequests(_ context.Context, _ string) ([]RefundRequestItem, error) {
+	return nil, nil // not used in create tests
+}
+func (m *MockPaymentServiceForCreate) ApproveRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	return nil, nil // not used in create tests
+}
+func (m *MockPaymentServiceForCreate) RejectRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	return nil, nil // not used in create tests
 }
 
 func (m *MockPaymentServiceForCreate) HandleWebhook(_ context.Context, _ []byte, _ string, _ string) error {
 	return nil // not used in create tests
 }
+func (m *MockPaymentServiceForCreate) ListWebhookEvents(_ context.Context, _ string) ([]WebhookEventItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForCreate) RetryWebhookEvent(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPaymentServiceForCreate) RetryRefund(_ context.Context, _ string) (*RefundPaymentResult, error) {
+	return nil, nil
+}
 
 // MockLoggerForCreate is a mock implementation of HandlerLogger
 // specifically for testing payment creation logging
@@ -310,6 +554,9 @@ func (m *MockPaymentServiceForGet) CreatePayment(_ context.Context, _ CreatePaym
 func (m *MockPaymentServiceForGet) ConfirmPayment(_ context.Context, _ ConfirmPaymentParams) (*ConfirmPaymentResult, error) {
 	return nil, nil
 }
+func (m *MockPaymentServiceForGet) MarkPaymentReceived(_ context.Context, _ MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	return nil, nil
+}
 func (m *MockPaymentServiceForGet) GetPayment(ctx context.Context, orderID string, userID string) (*GetPaymentResult, error) {
 	args := m.Called(ctx, orderID, userID)
 	if args.Get(0) == nil {
@@ -324,19 +571,38 @@ func (m *MockPaymentServiceForGet) GetPaymentHistory(ctx context.Context, userID
 	}
 	return args.Get(0).([]PaymentHistoryItem), args.Error(1)
 }
-func (m *MockPaymentServiceForGet) GetAllPayments(ctx context.Context, status string) ([]PaymentHistoryItem, error) {
-	args := m.Called(ctx, status)
+func (m *MockPaymentServiceForGet) GetAllPayments(ctx context.Context, status string, page pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	args := m.Called(ctx, status, page)
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return pagination.Page[PaymentHistoryItem]{}, args.Error(1)
 	}
-	return args.Get(0).([]PaymentHistoryItem), args.Error(1)
+	return args.Get(0).(pagination.Page[PaymentHistoryItem]), args.Error(1)
 }
-func (m *MockPaymentServiceForGet) RefundPayment(_ context.Context, _ RefundPaymentParams) error {
-	return nil
+func (m *MockPaymentServiceForGet) RequestRefund(_ context.Context, _ RefundPaymentParams) (*RefundRequestItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForGet) ListRefundRequests(_ context.Context, _ string) ([]RefundRequestItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForGet) ApproveRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForGet) RejectRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	return nil, nil
 }
 func (m *MockPaymentServiceForGet) HandleWebhook(_ context.Context, _ []byte, _ string, _ string) error {
 	return nil
 }
+func (m *MockPaymentServiceForGet) ListWebhookEvents(_ context.Context, _ string) ([]WebhookEventItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForGet) RetryWebhookEvent(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPaymentServiceForGet) RetryRefund(_ context.Context, _ string) (*RefundPaymentResult, error) {
+	return nil, nil
+}
 
 // MockLoggerForGet is a mock implementation of HandlerLogger for get tests
 type MockLoggerForGet struct {
@@ -362,22 +628,62 @@ func (m *MockPaymentServiceForRefund) CreatePayment(_ context.Context, _ CreateP
 func (m *MockPaymentServiceForRefund) ConfirmPayment(_ context.Context, _ ConfirmPaymentParams) (*ConfirmPaymentResult, error) {
 	return nil, nil
 }
+func (m *MockPaymentServiceForRefund) MarkPaymentReceived(_ context.Context, _ MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	return nil, nil
+}
 func (m *MockPaymentServiceForRefund) GetPayment(_ context.Context, _ string, _ string) (*GetPaymentResult, error) {
 	return nil, nil
 }
 func (m *MockPaymentServiceForRefund) GetPaymentHistory(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
 	return nil, nil
 }
-func (m *MockPaymentServiceForRefund) GetAllPayments(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
-	return nil, nil
+func (m *MockPaymentServiceForRefund) GetAllPayments(_ context.Context, _ string, _ pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	return pagination.Page[PaymentHistoryItem]{}, nil
 }
-func (m *MockPaymentServiceForRefund) RefundPayment(ctx context.Context, params RefundPaymentParams) error {
+func (m *MockPaymentServiceForRefund) RequestRefund(ctx context.Context, params RefundPaymentParams) (*RefundRequestItem, error) {
 	args := m.Called(ctx, params)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundRequestItem), args.Error(1)
+}
+
+func (m *MockPaymentServiceForRefund) ListRefundRequests(ctx context.Context, status string) ([]RefundRequestItem, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]RefundRequestItem), args.Error(1)
+}
+
+func (m *MockPaymentServiceForRefund) ApproveRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundPaymentResult), args.Error(1)
+}
+
+func (m *MockPaymentServiceForRefund) RejectRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundRequestItem), args.Error(1)
 }
 func (m *MockPaymentServiceForRefund) HandleWebhook(_ context.Context, _ []byte, _, _ string) error {
 	return nil
 }
+func (m *MockPaymentServiceForRefund) ListWebhookEvents(_ context.Context, _ string) ([]WebhookEventItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForRefund) RetryWebhookEvent(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPaymentServiceForRefund) RetryRefund(_ context.Context, _ string) (*RefundPaymentResult, error) {
+	return nil, nil
+}
 
 // MockLoggerForRefund is a mock implementation of HandlerLogger for refund tests
 type MockLoggerForRefund struct {
@@ -403,22 +709,44 @@ func (m *MockPaymentServiceForWebhook) CreatePayment(_ context.Context, _ Create
 func (m *MockPaymentServiceForWebhook) ConfirmPayment(_ context.Context, _ ConfirmPaymentParams) (*ConfirmPaymentResult, error) {
 	return nil, nil
 }
+func (m *MockPaymentServiceForWebhook) MarkPaymentReceived(_ context.Context, _ MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	return nil, nil
+}
 func (m *MockPaymentServiceForWebhook) GetPayment(_ context.Context, _ string, _ string) (*GetPaymentResult, error) {
 	return nil, nil
 }
 func (m *MockPaymentServiceForWebhook) GetPaymentHistory(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
 	return nil, nil
 }
-func (m *MockPaymentServiceForWebhook) GetAllPayments(_ context.Context, _ string) ([]PaymentHistoryItem, error) {
+func (m *MockPaymentServiceForWebhook) GetAllPayments(_ context.Context, _ string, _ pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	return pagination.Page[PaymentHistoryItem]{}, nil
+}
+func (m *MockPaymentServiceForWebhook) RequestRefund(_ context.Context, _ RefundPaymentParams) (*RefundRequestItem, error) {
 	return nil, nil
 }
-func (m *MockPaymentServiceForWebhook) RefundPayment(_ context.Context, _ RefundPaymentParams) error {
-	return nil
+func (m *MockPaymentServiceForWebhook) ListRefundRequests(_ context.Context, _ string) ([]RefundRequestItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForWebhook) ApproveRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForWebhook) RejectRefundRequest(_ context.Context, _ ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	return nil, nil
 }
 func (m *MockPaymentServiceForWebhook) HandleWebhook(ctx context.Context, payload []byte, signature string, secret string) error {
 	args := m.Called(ctx, payload, signature, secret)
 	return args.Error(0)
 }
+func (m *MockPaymentServiceForWebhook) ListWebhookEvents(_ context.Context, _ string) ([]WebhookEventItem, error) {
+	return nil, nil
+}
+func (m *MockPaymentServiceForWebhook) RetryWebhookEvent(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPaymentServiceForWebhook) RetryRefund(_ context.Context, _ string) (*RefundPaymentResult, error) {
+	return nil, nil
+}
 
 // MockLoggerForWebhook is a mock implementation of HandlerLogger for webhook tests
 type MockLoggerForWebhook struct {
@@ -468,3 +796,21 @@ func testInvalidPayload(t *testing.T,
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	log.AssertExpectations(t)
 }
+
+// matchWebhookPaymentUpdate matches the payment update a webhook makes for payment intent pi_test_123.
+func matchWebhookPaymentUpdate(status string) any {
+	return mock.MatchedBy(func(p database.UpdatePaymentStatusByProviderPaymentIDParams) bool {
+		return p.Status == status && p.ProviderPaymentID == utils.ToNullString("pi_test_123") && !p.UpdatedAt.IsZero()
+	})
+}
+
+// expectNewWebhookEvent sets up the event log calls for an event delivered for the first time.
+// The result is recorded with the given status; payments start with no previously applied events.
+func expectNewWebhookEvent(mockDB *mockPaymentDBQueries, status string) {
+	mockDB.On("CreateWebhookEvent", mock.Anything, mock.Anything).Return(int64(1), nil)
+	mockDB.On("GetWebhookEventByIDForUpdate", mock.Anything, mock.Anything).Return(database.WebhookEvent{Status: webhookEventReceived}, nil)
+	mockDB.On("GetLatestProcessedWebhookEventTime", mock.Anything, mock.Anything).Return(time.Time{}, sql.ErrNoRows).Maybe()
+	mockDB.On("UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.Status == status
+	})).Return(nil)
+}
@@ -0,0 +1,98 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_manual.go: Admin confirmation of manual payments such as cash on delivery and bank transfer.
+
+// MarkPaymentReceived records that a pending manual payment was received and marks its order paid.
+// The order row is locked before the payment is read, so a payment cannot be marked received twice.
+func (s *paymentServiceImpl) MarkPaymentReceived(ctx context.Context, params MarkPaymentReceivedParams) (*ConfirmPaymentResult, error) {
+	if params.OrderID == "" || params.AdminID == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
+	}
+
+	timeNow := time.Now().UTC()
+
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	order, err := queries.GetOrderByIDForUpdate(ctx, params.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+	}
+	payment, err := queries.GetPaymentByOrderID(ctx, params.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+	}
+	if payment.Provider != ProviderManual {
+		return nil, &handlers.AppError{Code: "invalid_provider", Message: "Only manual payments can be marked received"}
+	}
+	if payment.Status != "pending" {
+		return nil, &handlers.AppError{Code: "invalid_status", Message: fmt.Sprintf("Payment is already %s", payment.Status)}
+	}
+	if !orderhandlers.CanTransitionOrderStatus(order.Status, orderhandlers.OrderStatusPaid) {
+		return nil, &handlers.AppError{
+			Code:    "invalid_status_transition",
+			Message: fmt.Sprintf("Cannot change order status from %s to %s", order.Status, orderhandlers.OrderStatusPaid),
+		}
+	}
+
+	err = queries.UpdatePaymentStatus(ctx, database.UpdatePaymentStatusParams{
+		ID:        payment.ID,
+		Status:    "succeeded",
+		UpdatedAt: timeNow,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to update payment status", Err: err}
+	}
+
+	err = queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+		ID:        order.ID,
+		Status:    orderhandlers.OrderStatusPaid,
+		UpdatedAt: timeNow,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to update order status", Err: err}
+	}
+
+	reason := "Manual payment received"
+	if params.Note != "" {
+		reason = fmt.Sprintf("%s: %s", reason, params.Note)
+	}
+	err = queries.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		ID:          utils.NewUUIDString(),
+		OrderID:     order.ID,
+		FromStatus:  order.Status,
+		ToStatus:    orderhandlers.OrderStatusPaid,
+		ActorUserID: utils.ToNullString(params.AdminID),
+		Reason:      utils.ToNullString(reason),
+		CreatedAt:   timeNow,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to record order status history", Err: err}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return &ConfirmPaymentResult{Status: "succeeded"}, nil
+}
@@ -0,0 +1,107 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_manual_test.go: Tests for marking manual payments received.
+
+// newMarkReceivedTestService returns a service whose order123 has the given status and a payment from the given provider.
+// The transaction is started and the order is locked.
+func newMarkReceivedTestService(provider, paymentStatus, orderStatus string) (*paymentServiceImpl, *mockPaymentDBQueries, *mockPaymentDBTx) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockTx.On("Rollback").Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: orderStatus}, nil)
+	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{
+		ID:                "payment123",
+		OrderID:           "order123",
+		UserID:            "user123",
+		Amount:            "100.00",
+		Currency:          "USD",
+		Status:            paymentStatus,
+		Provider:          provider,
+		ProviderPaymentID: utils.ToNullString("manual_ref"),
+	}, nil)
+
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", nil)}
+	return service, mockDB, mockTx
+}
+
+// TestMarkPaymentReceived_Success tests that the payment succeeds and the order is marked paid by the admin.
+func TestMarkPaymentReceived_Success(t *testing.T) {
+	service, mockDB, mockTx := newMarkReceivedTestService(ProviderManual, "pending", "pending")
+	mockDB.On("UpdatePaymentStatus", mock.Anything, mock.MatchedBy(func(p database.UpdatePaymentStatusParams) bool {
+		return p.ID == "payment123" && p.Status == "succeeded"
+	})).Return(nil)
+	mockDB.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderStatusParams) bool {
+		return p.ID == "order123" && p.Status == "paid"
+	})).Return(nil)
+	mockDB.On("CreateOrderStatusHistory", mock.Anything, mock.MatchedBy(func(p database.CreateOrderStatusHistoryParams) bool {
+		return p.FromStatus == "pending" && p.ToStatus == "paid" && p.ActorUserID == utils.ToNullString("admin1") &&
+			p.Reason.String == "Manual payment received: Cash collected"
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	result, err := service.MarkPaymentReceived(context.Background(), MarkPaymentReceivedParams{OrderID: "order123", AdminID: "admin1", Note: "Cash collected"})
+	require.NoError(t, err)
+	assert.Equal(t, "succeeded", result.Status)
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestMarkPaymentReceived_Rejected tests the payments and orders that cannot be marked received.
+func TestMarkPaymentReceived_Rejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		paymentStatus string
+		orderStatus   string
+		code          string
+	}{
+		{name: "stripe_payment", provider: ProviderStripe, paymentStatus: "pending", orderStatus: "pending", code: "invalid_provider"},
+		{name: "already_received", provider: ProviderManual, paymentStatus: "succeeded", orderStatus: "paid", code: "invalid_status"},
+		{name: "cancelled_order", provider: ProviderManual, paymentStatus: "pending", orderStatus: "cancelled", code: "invalid_status_transition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockDB, _ := newMarkReceivedTestService(tt.provider, tt.paymentStatus, tt.orderStatus)
+
+			_, err := service.MarkPaymentReceived(context.Background(), MarkPaymentReceivedParams{OrderID: "order123", AdminID: "admin1"})
+			requireAppErrorCode(t, err, tt.code)
+			mockDB.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestMarkPaymentReceived_Errors tests request validation and database failures.
+func TestMarkPaymentReceived_Errors(t *testing.T) {
+	t.Run("missing_fields", func(t *testing.T) {
+		service := &paymentServiceImpl{}
+		_, err := service.MarkPaymentReceived(context.Background(), MarkPaymentReceivedParams{OrderID: "order123"})
+		requireAppErrorCode(t, err, "invalid_request")
+	})
+
+	t.Run("update_error", func(t *testing.T) {
+		service, mockDB, _ := newMarkReceivedTestService(ProviderManual, "pending", "pending")
+		mockDB.On("UpdatePaymentStatus", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+		_, err := service.MarkPaymentReceived(context.Background(), MarkPaymentReceivedParams{OrderID: "order123", AdminID: "admin1"})
+		requireAppErrorCode(t, err, "database_error")
+	})
+}
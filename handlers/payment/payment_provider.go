@@ -0,0 +1,97 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// payment_provider.go: Defines the provider-neutral payment provider interface and the registry of available providers.
+
+// Payment providers recorded in payments.provider.
+const (
+	ProviderStripe = "stripe"
+	ProviderManual = "manual"
+)
+
+// PaymentProvider abstracts a payment provider such as Stripe.
+// Payment statuses returned by a provider are the statuses stored in payments.status.
+type PaymentProvider interface {
+	// Name returns the provider name recorded in payments.provider.
+	Name() string
+	// CreateIntent starts a payment with the provider.
+	CreateIntent(ctx context.Context, params ProviderIntentParams) (*ProviderIntent, error)
+	// GetPaymentStatus returns the current status of a payment at the provider.
+	GetPaymentStatus(ctx context.Context, providerPaymentID string) (string, error)
+	// CancelIntent voids a payment that has not been taken yet.
+	// It returns orderhandlers.ErrPaymentCaptured when the payment already succeeded.
+	CancelIntent(ctx context.Context, providerPaymentID string) error
+	// Refund refunds part or all of a payment.
+	Refund(ctx context.Context, params ProviderRefundParams) (*ProviderRefund, error)
+	// ParseWebhook verifies a webhook delivery and returns the event it carries.
+	ParseWebhook(payload []byte, signature, secret string) (WebhookEvent, error)
+	// DecodeWebhookEvent reads an event from a payload whose signature was already verified.
+	DecodeWebhookEvent(payload []byte) (WebhookEvent, error)
+}
+
+// ProviderIntentParams contains the parameters for starting a payment with a provider.
+type ProviderIntentParams struct {
+	Amount   money.Amount
+	Metadata map[string]string
+}
+
+// ProviderIntent is a payment started with a provider.
+// ClientSecret is empty for providers that need no client-side confirmation.
+type ProviderIntent struct {
+	ID           string
+	ClientSecret string
+}
+
+// ProviderRefundParams contains the parameters for refunding a payment with a provider.
+// IdempotencyKey lets the provider recognise a retried refund.
+type ProviderRefundParams struct {
+	ProviderPaymentID string
+	Amount            money.Amount
+	IdempotencyKey    string
+	Metadata          map[string]string
+}
+
+// ProviderRefund is a refund issued by a provider. Status is succeeded or pending.
+type ProviderRefund struct {
+	ID     string
+	Status string
+}
+
+// WebhookEvent is a provider webhook event in provider-neutral form.
+type WebhookEvent struct {
+	ID        string
+	Type      string
+	CreatedAt time.Time
+	// PaymentStatus is the status the event moves its payment to; empty for event types that are not acted on.
+	PaymentStatus string
+	// ProviderPaymentID is the provider's ID of the payment the event refers to.
+	ProviderPaymentID string
+	// Err is set when the event is acted on but its payment cannot be read from the event data.
+	// Such events are still recorded and fail when processed.
+	Err error
+}
+
+// newPaymentProviders returns the providers available to the payment service, keyed by name.
+func newPaymentProviders(apiKey string, stripeClient StripeClient) map[string]PaymentProvider {
+	return map[string]PaymentProvider{
+		ProviderStripe: &stripeProvider{apiKey: apiKey, client: stripeClient},
+		ProviderManual: &manualProvider{},
+	}
+}
+
+// provider returns the payment provider with the given name.
+func (s *paymentServiceImpl) provider(name string) (PaymentProvider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, &handlers.AppError{Code: "invalid_provider", Message: "Unsupported payment provider"}
+	}
+	return p, nil
+}
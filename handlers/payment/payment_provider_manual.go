@@ -0,0 +1,50 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_provider_manual.go: Implements PaymentProvider for payments settled outside the app, such as cash on delivery or bank transfer.
+
+// manualProvider implements PaymentProvider for cash-on-delivery and bank transfer payments.
+// Payments stay pending until an admin marks them received, and refunds are paid back by the merchant directly.
+type manualProvider struct{}
+
+// Name returns the provider name recorded in payments.provider.
+func (p *manualProvider) Name() string {
+	return ProviderManual
+}
+
+// CreateIntent returns a reference the customer can quote with their payment. No client-side confirmation is needed.
+func (p *manualProvider) CreateIntent(_ context.Context, _ ProviderIntentParams) (*ProviderIntent, error) {
+	return &ProviderIntent{ID: "manual_" + utils.NewUUIDString()}, nil
+}
+
+// GetPaymentStatus is not supported; manual payments are confirmed by an admin.
+func (p *manualProvider) GetPaymentStatus(_ context.Context, _ string) (string, error) {
+	return "", &handlers.AppError{Code: "provider_not_supported", Message: "Manual payments are confirmed by an admin"}
+}
+
+// CancelIntent has nothing to void; no money is held for a manual payment.
+func (p *manualProvider) CancelIntent(_ context.Context, _ string) error {
+	return nil
+}
+
+// Refund records a refund paid back outside the app, so it succeeds immediately.
+func (p *manualProvider) Refund(_ context.Context, _ ProviderRefundParams) (*ProviderRefund, error) {
+	return &ProviderRefund{Status: "succeeded"}, nil
+}
+
+// ParseWebhook is not supported; manual payments have no webhooks.
+func (p *manualProvider) ParseWebhook(_ []byte, _, _ string) (WebhookEvent, error) {
+	return WebhookEvent{}, &handlers.AppError{Code: "provider_not_supported", Message: "Manual payments have no webhooks"}
+}
+
+// DecodeWebhookEvent is not supported; manual payments have no webhooks.
+func (p *manualProvider) DecodeWebhookEvent(_ []byte) (WebhookEvent, error) {
+	return WebhookEvent{}, &handlers.AppError{Code: "provider_not_supported", Message: "Manual payments have no webhooks"}
+}
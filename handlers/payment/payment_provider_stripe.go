@@ -0,0 +1,224 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/paymentintent"
+	"github.com/stripe/stripe-go/v82/refund"
+	"github.com/stripe/stripe-go/v82/webhook"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+)
+
+// payment_provider_stripe.go: Implements PaymentProvider with Stripe payment intents, refunds and webhook events.
+
+// StripeClient abstracts Stripe operations for testability.
+// (If you use mockery, otherwise define a manual mock in tests)
+//
+//go:generate mockery --name=StripeClient --output=./mocks --case=underscore
+type StripeClient interface {
+	CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error)
+	GetPaymentIntent(id string) (*stripe.PaymentIntent, error)
+	CancelPaymentIntent(id string) (*stripe.PaymentIntent, error)
+	CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error)
+	ParseWebhook(payload []byte, sigHeader, secret string) (stripe.Event, error)
+}
+
+// realStripeClient implements StripeClient using the stripe-go SDK (used in production).
+type realStripeClient struct{}
+
+func (c *realStripeClient) CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
+	return paymentintent.New(params)
+}
+func (c *realStripeClient) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	return paymentintent.Get(id, nil)
+}
+func (c *realStripeClient) CancelPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	return paymentintent.Cancel(id, nil)
+}
+func (c *realStripeClient) CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
+	return refund.New(params)
+}
+func (c *realStripeClient) ParseWebhook(payload []byte, sigHeader, secret string) (stripe.Event, error) {
+	return webhook.ConstructEvent(payload, sigHeader, secret)
+}
+
+// stripeProvider implements PaymentProvider with Stripe payment intents.
+type stripeProvider struct {
+	apiKey string
+	client StripeClient
+}
+
+// stripeEventPaymentStatuses lists the Stripe events webhooks act on and the payment status each moves a payment to.
+var stripeEventPaymentStatuses = map[stripe.EventType]string{
+	"payment_intent.succeeded":      "succeeded",
+	"payment_intent.payment_failed": "failed",
+	"payment_intent.canceled":       "cancelled",
+	"charge.refunded":               "refunded",
+}
+
+// Name returns the provider name recorded in payments.provider.
+func (p *stripeProvider) Name() string {
+	return ProviderStripe
+}
+
+// CreateIntent creates a Stripe payment intent for the amount in its currency's minor unit.
+func (p *stripeProvider) CreateIntent(_ context.Context, params ProviderIntentParams) (*ProviderIntent, error) {
+	stripe.Key = p.apiKey
+	intent, err := p.client.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:   stripe.Int64(params.Amount.Minor),
+		Currency: stripe.String(string(params.Amount.Currency)),
+		Metadata: params.Metadata,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "stripe_error", Message: "Failed to create payment intent", Err: err}
+	}
+	return &ProviderIntent{ID: intent.ID, ClientSecret: intent.ClientSecret}, nil
+}
+
+// GetPaymentStatus maps the status of a Stripe payment intent to a payment status.
+// Intents still waiting on the customer or on Stripe are pending; refunds are reported by webhook events.
+func (p *stripeProvider) GetPaymentStatus(_ context.Context, providerPaymentID string) (string, error) {
+	stripe.Key = p.apiKey
+	pi, err := p.client.GetPaymentIntent(providerPaymentID)
+	if err != nil {
+		return "", &handlers.AppError{Code: "stripe_error", Message: "Failed to fetch payment intent", Err: err}
+	}
+
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return "succeeded", nil
+	case stripe.PaymentIntentStatusCanceled:
+		return "cancelled", nil
+	case stripe.PaymentIntentStatusRequiresPaymentMethod, stripe.PaymentIntentStatusRequiresConfirmation,
+		stripe.PaymentIntentStatusRequiresAction, stripe.PaymentIntentStatusRequiresCapture,
+		stripe.PaymentIntentStatusProcessing:
+		return orderStatusPending, nil
+	default:
+		return "failed", nil
+	}
+}
+
+// CancelIntent cancels a Stripe payment intent.
+// Stripe refuses to cancel intents that already succeeded or are processing, so on failure the intent is fetched to
+// tell a captured payment from a transient error. An intent that is already cancelled counts as success.
+func (p *stripeProvider) CancelIntent(_ context.Context, providerPaymentID string) error {
+	stripe.Key = p.apiKey
+	_, err := p.client.CancelPaymentIntent(providerPaymentID)
+	if err == nil {
+		return nil
+	}
+
+	pi, getErr := p.client.GetPaymentIntent(providerPaymentID)
+	if getErr == nil {
+		switch pi.Status {
+		case stripe.PaymentIntentStatusCanceled:
+			return nil
+		case stripe.PaymentIntentStatusSucceeded:
+			return orderhandlers.ErrPaymentCaptured
+		}
+	}
+	return &handlers.AppError{Code: "stripe_error", Message: "Failed to cancel payment intent", Err: err}
+}
+
+// Refund creates a Stripe refund against the payment intent.
+func (p *stripeProvider) Refund(_ context.Context, params ProviderRefundParams) (*ProviderRefund, error) {
+	stripe.Key = p.apiKey
+	refundParams := &stripe.RefundParams{
+		PaymentIntent: stripe.String(params.ProviderPaymentID),
+		Amount:        stripe.Int64(params.Amount.Minor),
+	}
+	refundParams.SetIdempotencyKey(params.IdempotencyKey)
+	for key, value := range params.Metadata {
+		refundParams.AddMetadata(key, value)
+	}
+	stripeRefund, err := p.client.CreateRefund(refundParams)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "stripe_error", Message: "Failed to process refund", Err: err}
+	}
+
+	status := "pending"
+	if stripeRefund.Status == stripe.RefundStatusSucceeded {
+		status = "succeeded"
+	}
+	return &ProviderRefund{ID: stripeRefund.ID, Status: status}, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header and returns the event.
+func (p *stripeProvider) ParseWebhook(payload []byte, signature, secret string) (WebhookEvent, error) {
+	stripe.Key = p.apiKey
+	event, err := p.client.ParseWebhook(payload, signature, secret)
+	if err != nil {
+		return WebhookEvent{}, &handlers.AppError{Code: "webhook_error", Message: "Signature verification failed", Err: err}
+	}
+	return toWebhookEvent(event), nil
+}
+
+// DecodeWebhookEvent reads a Stripe event from a stored payload.
+func (p *stripeProvider) DecodeWebhookEvent(payload []byte) (WebhookEvent, error) {
+	var event stripe.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return WebhookEvent{}, &handlers.AppError{Code: "webhook_error", Message: "Bad stored event payload", Err: err}
+	}
+	return toWebhookEvent(event), nil
+}
+
+// toWebhookEvent converts a Stripe event to a provider-neutral webhook event.
+func toWebhookEvent(event stripe.Event) WebhookEvent {
+	result := WebhookEvent{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		CreatedAt: time.Unix(event.Created, 0).UTC(),
+	}
+	status, ok := stripeEventPaymentStatus(event)
+	if !ok {
+		return result
+	}
+	result.PaymentStatus = status
+	result.ProviderPaymentID, result.Err = stripeEventPaymentID(event)
+	return result
+}
+
+// stripeEventPaymentStatus returns the payment status an event moves its payment to. A charge.refunded event is
+// sent for every refund, so a charge that Stripe does not report as fully refunded only moves the payment to
+// partially_refunded.
+func stripeEventPaymentStatus(event stripe.Event) (string, bool) {
+	status, ok := stripeEventPaymentStatuses[event.Type]
+	if !ok || event.Type != "charge.refunded" || event.Data == nil {
+		return status, ok
+	}
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err == nil && !charge.Refunded {
+		return "partially_refunded", true
+	}
+	return status, true
+}
+
+// stripeEventPaymentID extracts the payment intent ID an event refers to.
+// Charge events carry the payment intent as a nested object.
+func stripeEventPaymentID(event stripe.Event) (string, error) {
+	if event.Data == nil {
+		return "", &handlers.AppError{Code: "webhook_error", Message: "Event has no data"}
+	}
+	if event.Type == "charge.refunded" {
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return "", &handlers.AppError{Code: "webhook_error", Message: "Bad charge", Err: err}
+		}
+		if charge.PaymentIntent == nil || charge.PaymentIntent.ID == "" {
+			return "", &handlers.AppError{Code: "webhook_error", Message: "Charge has no payment intent"}
+		}
+		return charge.PaymentIntent.ID, nil
+	}
+
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		return "", &handlers.AppError{Code: "webhook_error", Message: "Bad payment intent", Err: err}
+	}
+	return pi.ID, nil
+}
@@ -0,0 +1,108 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_provider_test.go: Tests for provider selection and the manual payment provider.
+
+// TestManualProvider tests that manual payments get a reference, cancel and refund immediately and have no remote status or webhooks.
+func TestManualProvider(t *testing.T) {
+	p := &manualProvider{}
+	assert.Equal(t, ProviderManual, p.Name())
+
+	intent, err := p.CreateIntent(context.Background(), ProviderIntentParams{Amount: money.New(1000, money.USD)})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(intent.ID, "manual_"))
+	assert.Empty(t, intent.ClientSecret)
+
+	refund, err := p.Refund(context.Background(), ProviderRefundParams{ProviderPaymentID: intent.ID, Amount: money.New(500, money.USD)})
+	require.NoError(t, err)
+	assert.Equal(t, "succeeded", refund.Status)
+
+	require.NoError(t, p.CancelIntent(context.Background(), intent.ID))
+
+	_, err = p.GetPaymentStatus(context.Background(), intent.ID)
+	requireAppErrorCode(t, err, "provider_not_supported")
+	_, err = p.ParseWebhook([]byte(`{}`), "sig", "secret")
+	requireAppErrorCode(t, err, "provider_not_supported")
+	_, err = p.DecodeWebhookEvent([]byte(`{}`))
+	requireAppErrorCode(t, err, "provider_not_supported")
+}
+
+// TestCreatePayment_ManualProvider tests that a manual payment is recorded with its provider and no Stripe call.
+func TestCreatePayment_ManualProvider(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+	mockStripe := new(mockStripeClient)
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+	mockDB.On("GetOrderByID", mock.Anything, "order123").Return(database.Order{ID: "order123", UserID: "user123", Status: "pending", TotalAmount: "100.00"}, nil)
+	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{}, sql.ErrNoRows)
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockDB.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p database.CreatePaymentParams) bool {
+		return p.Provider == ProviderManual && p.Status == "pending" && p.Amount == "100.00" &&
+			strings.HasPrefix(p.ProviderPaymentID.String, "manual_")
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+	mockTx.On("Rollback").Return(nil)
+
+	result, err := service.CreatePayment(context.Background(), CreatePaymentParams{
+		OrderID:  "order123",
+		UserID:   "user123",
+		Currency: "USD",
+		Provider: ProviderManual,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderManual, result.Provider)
+	assert.Empty(t, result.ClientSecret)
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertNotCalled(t, "CreatePaymentIntent", mock.Anything)
+}
+
+// TestCreatePayment_UnknownProvider tests that an unsupported provider is rejected before anything is looked up.
+func TestCreatePayment_UnknownProvider(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", nil)}
+
+	_, err := service.CreatePayment(context.Background(), CreatePaymentParams{
+		OrderID:  "order123",
+		UserID:   "user123",
+		Currency: "USD",
+		Provider: "paypal",
+	})
+	requireAppErrorCode(t, err, "invalid_provider")
+	mockDB.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything)
+}
+
+// TestConfirmPayment_ManualProvider tests that manual payments cannot be confirmed by the customer.
+func TestConfirmPayment_ManualProvider(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", nil)}
+	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{
+		ID:                "payment123",
+		OrderID:           "order123",
+		UserID:            "user123",
+		Status:            "pending",
+		Provider:          ProviderManual,
+		ProviderPaymentID: utils.ToNullString("manual_ref"),
+	}, nil)
+
+	_, err := service.ConfirmPayment(context.Background(), ConfirmPaymentParams{OrderID: "order123", UserID: "user123"})
+	requireAppErrorCode(t, err, "provider_not_supported")
+	mockDB.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything)
+}
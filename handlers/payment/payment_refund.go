@@ -0,0 +1,397 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_refund.go: Works out how much of a payment a refund covers, reserves it against earlier refunds and sends it to the payment provider.
+
+// refundLine is an order line included in a refund.
+type refundLine struct {
+	orderItemID string
+	quantity    int32
+	amount      money.Amount
+}
+
+// refundablePayment checks that a payment can be refunded and returns the amount paid.
+func refundablePayment(payment database.Payment) (money.Amount, error) {
+	if payment.Status != "succeeded" && payment.Status != "partially_refunded" {
+		return money.Amount{}, &handlers.AppError{Code: "invalid_status", Message: "Payment cannot be refunded"}
+	}
+	if !payment.ProviderPaymentID.Valid {
+		return money.Amount{}, &handlers.AppError{Code: "invalid_payment", Message: "Missing provider payment ID"}
+	}
+	paid, err := money.Parse(payment.Amount, money.Currency(payment.Currency))
+	if err != nil {
+		return money.Amount{}, &handlers.AppError{Code: "invalid_amount", Message: "Invalid payment amount", Err: err}
+	}
+	return paid, nil
+}
+
+// Refund statuses, matching the CHECK constraint on the refunds table.
+// A pending refund without a provider refund ID has not reached the provider yet and can be sent again.
+const (
+	refundStatusPending   = "pending"
+	refundStatusSucceeded = "succeeded"
+)
+
+// reserveRefund works out what a refund covers and records it as pending with its order lines, which reserves its
+// amount against the payment's refundable balance. Queries must be bound to a transaction in which the order row is
+// already locked, so concurrent refunds cannot exceed the amount paid. The provider is not contacted; once the
+// transaction commits the refund is sent with sendRefund and recorded with settleRefund.
+func reserveRefund(ctx context.Context, queries PaymentDBQueries, payment database.Payment, order database.Order, paid money.Amount, params RefundPaymentParams, actorID string, now time.Time) (database.Refund, error) {
+	if order.Status == orderhandlers.OrderStatusPending {
+		return database.Refund{}, &handlers.AppError{Code: "invalid_status", Message: "Order has not been paid"}
+	}
+	remaining, err := refundableBalance(ctx, queries, payment.ID, paid)
+	if err != nil {
+		return database.Refund{}, err
+	}
+	amount, lines, err := resolveRefundAmount(ctx, queries, params, order.ID, remaining)
+	if err != nil {
+		return database.Refund{}, err
+	}
+
+	refund := database.Refund{
+		ID:        utils.NewUUIDString(),
+		PaymentID: payment.ID,
+		OrderID:   order.ID,
+		Amount:    amount.String(),
+		Currency:  payment.Currency,
+		Status:    refundStatusPending,
+		Reason:    utils.ToNullString(params.Reason),
+		CreatedBy: utils.ToNullString(actorID),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = queries.CreateRefund(ctx, database.CreateRefundParams{
+		ID:        refund.ID,
+		PaymentID: refund.PaymentID,
+		OrderID:   refund.OrderID,
+		Amount:    refund.Amount,
+		Currency:  refund.Currency,
+		Status:    refund.Status,
+		Reason:    refund.Reason,
+		CreatedBy: refund.CreatedBy,
+		CreatedAt: refund.CreatedAt,
+		UpdatedAt: refund.UpdatedAt,
+	})
+	if err != nil {
+		return database.Refund{}, &handlers.AppError{Code: "database_error", Message: "Failed to record refund", Err: err}
+	}
+	for _, line := range lines {
+		err = queries.CreateRefundItem(ctx, database.CreateRefundItemParams{
+			RefundID:    refund.ID,
+			OrderItemID: line.orderItemID,
+			Quantity:    line.quantity,
+			Amount:      line.amount.String(),
+		})
+		if err != nil {
+			return database.Refund{}, &handlers.AppError{Code: "database_error", Message: "Failed to record refund item", Err: err}
+		}
+	}
+
+	return refund, nil
+}
+
+// issueRefund sends a reserved refund to its provider unless the provider already accepted it, then records the
+// outcome. The provider is called outside any transaction. onSettled, when set, runs in the transaction that
+// records the outcome. A refund whose provider call fails stays pending and keeps its amount reserved; sending it
+// again reuses its idempotency key, so the customer is never refunded twice.
+func (s *paymentServiceImpl) issueRefund(ctx context.Context, refund database.Refund, payment database.Payment, now time.Time, onSettled func(PaymentDBQueries) error) (*RefundPaymentResult, error) {
+	var providerRefund *ProviderRefund
+	if !refund.ProviderRefundID.Valid {
+		var err error
+		providerRefund, err = s.sendRefund(ctx, refund, payment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	result, err := settleRefund(ctx, queries, refund, providerRefund, payment, now)
+	if err != nil {
+		return nil, err
+	}
+	if onSettled != nil {
+		if err := onSettled(queries); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return result, nil
+}
+
+// sendRefund asks the provider to refund a reserved refund. The refund ID doubles as the idempotency key,
+// so sending the same refund again returns the provider's original refund instead of a new one.
+func (s *paymentServiceImpl) sendRefund(ctx context.Context, refund database.Refund, payment database.Payment) (*ProviderRefund, error) {
+	provider, err := s.provider(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := money.Parse(refund.Amount, money.Currency(refund.Currency))
+	if err != nil {
+		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refund amount", Err: err}
+	}
+
+	return provider.Refund(ctx, ProviderRefundParams{
+		ProviderPaymentID: payment.ProviderPaymentID.String,
+		Amount:            amount,
+		IdempotencyKey:    refund.ID,
+		Metadata: map[string]string{
+			"order_id":  refund.OrderID,
+			"refund_id": refund.ID,
+		},
+	})
+}
+
+// settleRefund records the provider's answer to a refund and moves the payment and the order's refund status on.
+// The order row is locked first, as when the refund was reserved. A refund the provider already answered, for
+// example through a concurrent retry, keeps its recorded answer. The payment and order become refunded once
+// nothing is left to refund, and partially_refunded otherwise; the order status itself is left alone.
+func settleRefund(ctx context.Context, queries PaymentDBQueries, refund database.Refund, providerRefund *ProviderRefund, payment database.Payment, now time.Time) (*RefundPaymentResult, error) {
+	order, err := queries.GetOrderByIDForUpdate(ctx, refund.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+	}
+	refund, err = queries.GetRefundByIDForUpdate(ctx, refund.ID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "refund_not_found", Message: "Refund not found", Err: err}
+	}
+
+	if !refund.ProviderRefundID.Valid && providerRefund != nil {
+		refund.Status = providerRefund.Status
+		refund.ProviderRefundID = utils.ToNullString(providerRefund.ID)
+		err = queries.UpdateRefundResult(ctx, database.UpdateRefundResultParams{
+			ID:               refund.ID,
+			Status:           refund.Status,
+			ProviderRefundID: refund.ProviderRefundID,
+			UpdatedAt:        now,
+		})
+		if err != nil {
+			return nil, &handlers.AppError{Code: "database_error", Message: "Failed to record refund", Err: err}
+		}
+	}
+
+	paid, err := money.Parse(payment.Amount, money.Currency(payment.Currency))
+	if err != nil {
+		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid payment amount", Err: err}
+	}
+	remaining, err := refundableBalance(ctx, queries, payment.ID, paid)
+	if err != nil {
+		return nil, err
+	}
+	paymentStatus, refundStatus := "partially_refunded", orderhandlers.OrderRefundStatusPartiallyRefunded
+	if !remaining.IsPositive() {
+		paymentStatus, refundStatus = "refunded", orderhandlers.OrderRefundStatusRefunded
+	}
+
+	err = queries.UpdatePaymentStatus(ctx, database.UpdatePaymentStatusParams{
+		ID:        payment.ID,
+		Status:    paymentStatus,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to update payment status", Err: err}
+	}
+	if order.RefundStatus != refundStatus {
+		err = queries.UpdateOrderRefundStatus(ctx, database.UpdateOrderRefundStatusParams{
+			ID:           order.ID,
+			RefundStatus: refundStatus,
+			UpdatedAt:    now,
+		})
+		if err != nil {
+			return nil, &handlers.AppError{Code: "database_error", Message: "Failed to update order refund status", Err: err}
+		}
+	}
+
+	amount, err := money.Parse(refund.Amount, paid.Currency)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refund amount", Err: err}
+	}
+	return &RefundPaymentResult{
+		RefundID:          refund.ID,
+		Amount:            amount,
+		Status:            refund.Status,
+		PaymentStatus:     paymentStatus,
+		OrderRefundStatus: refundStatus,
+	}, nil
+}
+
+// RetryRefund sends a refund whose provider call failed again, with the same idempotency key, and records the outcome.
+// Only refunds the provider has not accepted yet can be retried.
+func (s *paymentServiceImpl) RetryRefund(ctx context.Context, refundID string) (*RefundPaymentResult, error) {
+	if refundID == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing refund ID"}
+	}
+
+	refund, err := s.db.GetRefundByID(ctx, refundID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &handlers.AppError{Code: "refund_not_found", Message: "Refund not found", Err: err}
+		}
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund", Err: err}
+	}
+	if refund.Status != refundStatusPending || refund.ProviderRefundID.Valid {
+		return nil, &handlers.AppError{Code: "refund_not_retryable", Message: "Refund was already accepted by the provider"}
+	}
+
+	payment, err := s.db.GetPaymentByOrderID(ctx, refund.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+	}
+
+	return s.issueRefund(ctx, refund, payment, time.Now().UTC(), nil)
+}
+
+// refundableBalance returns the part of a payment not yet covered by pending or succeeded refunds.
+func refundableBalance(ctx context.Context, queries PaymentDBQueries, paymentID string, paid money.Amount) (money.Amount, error) {
+	refundedStr, err := queries.GetRefundedAmountByPaymentID(ctx, paymentID)
+	if err != nil {
+		return money.Amount{}, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refunded amount", Err: err}
+	}
+	refunded, err := money.Parse(refundedStr, paid.Currency)
+	if err != nil {
+		return money.Amount{}, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refunded amount", Err: err}
+	}
+	remaining, err := paid.Sub(refunded)
+	if err != nil {
+		return money.Amount{}, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refunded amount", Err: err}
+	}
+	return remaining, nil
+}
+
+// resolveRefundAmount returns the amount a refund request covers and, for item refunds, the order lines it includes.
+// Requests without an amount or items refund the remaining balance. The amount may not exceed the remaining balance.
+func resolveRefundAmount(ctx context.Context, queries PaymentDBQueries, params RefundPaymentParams, orderID string, remaining money.Amount) (money.Amount, []refundLine, error) {
+	var (
+		amount money.Amount
+		lines  []refundLine
+		err    error
+	)
+	switch {
+	case len(params.Items) > 0:
+		amount, lines, err = refundItemsAmount(ctx, queries, orderID, params.Items, remaining.Currency)
+		if err != nil {
+			return money.Amount{}, nil, err
+		}
+	case params.Amount != "":
+		amount, err = money.Parse(params.Amount, remaining.Currency)
+		if err != nil {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refund amount", Err: err}
+		}
+		if !amount.IsPositive() {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_amount", Message: "Refund amount must be positive"}
+		}
+	default:
+		amount = remaining
+	}
+
+	if !remaining.IsPositive() {
+		return money.Amount{}, nil, &handlers.AppError{Code: "refund_exceeds_balance", Message: "Payment has already been fully refunded"}
+	}
+	left, err := remaining.Sub(amount)
+	if err != nil || left.IsNegative() {
+		return money.Amount{}, nil, &handlers.AppError{
+			Code:    "refund_exceeds_balance",
+			Message: fmt.Sprintf("Refund amount exceeds the refundable balance of %s", remaining),
+			Err:     err,
+		}
+	}
+	return amount, lines, nil
+}
+
+// refundItemsAmount prices the requested order lines at the price paid, in the order currency.
+// Each line must belong to the order, be listed once, and not exceed the quantity not yet refunded.
+// The payment must be in the order currency, otherwise its balance and the line prices cannot be compared.
+func refundItemsAmount(ctx context.Context, queries PaymentDBQueries, orderID string, items []RefundItemInput, paymentCurrency money.Currency) (money.Amount, []refundLine, error) {
+	currency := money.DefaultCurrency
+	if paymentCurrency != currency {
+		return money.Amount{}, nil, &handlers.AppError{
+			Code:    "currency_mismatch",
+			Message: fmt.Sprintf("Payment in %s cannot be refunded by item from an order priced in %s", paymentCurrency, currency),
+		}
+	}
+
+	orderItems, err := queries.GetOrderItemsByOrderID(ctx, orderID)
+	if err != nil {
+		return money.Amount{}, nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch order items", Err: err}
+	}
+	refundedRows, err := queries.GetRefundedQuantitiesByOrderID(ctx, orderID)
+	if err != nil {
+		return money.Amount{}, nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refunded quantities", Err: err}
+	}
+	byID := make(map[string]database.OrderItem, len(orderItems))
+	for _, oi := range orderItems {
+		byID[oi.ID] = oi
+	}
+	refunded := make(map[string]int32, len(refundedRows))
+	for _, row := range refundedRows {
+		refunded[row.OrderItemID] = row.Quantity
+	}
+
+	total := money.New(0, currency)
+	lines := make([]refundLine, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, input := range items {
+		if input.OrderItemID == "" || input.Quantity <= 0 {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_request", Message: "Each refund item needs an order_item_id and a positive quantity"}
+		}
+		if seen[input.OrderItemID] {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("Order item %s is listed more than once", input.OrderItemID)}
+		}
+		seen[input.OrderItemID] = true
+
+		item, ok := byID[input.OrderItemID]
+		if !ok {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("Order item %s does not belong to the order", input.OrderItemID)}
+		}
+
+		refundable := item.Quantity - refunded[input.OrderItemID]
+		if input.Quantity > refundable {
+			return money.Amount{}, nil, &handlers.AppError{
+				Code:    "refund_exceeds_quantity",
+				Message: fmt.Sprintf("Only %d of order item %s can still be refunded", refundable, input.OrderItemID),
+			}
+		}
+
+		price, err := money.Parse(item.Price, currency)
+		if err != nil {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid order item price", Err: err}
+		}
+		lineAmount, err := price.Mul(int64(input.Quantity))
+		if err != nil {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid order item price", Err: err}
+		}
+		if total, err = total.Add(lineAmount); err != nil {
+			return money.Amount{}, nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid order item price", Err: err}
+		}
+		lines = append(lines, refundLine{orderItemID: input.OrderItemID, quantity: input.Quantity, amount: lineAmount})
+	}
+	return total, lines, nil
+}
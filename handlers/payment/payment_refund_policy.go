@@ -0,0 +1,99 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// payment_refund_policy.go: Policy hooks that decide whether an order may be refunded, and the default policies.
+
+// DefaultRefundWindow is how long after delivery a refund may be requested under the default policies.
+const DefaultRefundWindow = 30 * 24 * time.Hour
+
+// RefundPolicyInput is what a refund policy sees when deciding whether an order may be refunded.
+// DeliveredAt is zero when the order has never been delivered.
+type RefundPolicyInput struct {
+	Order       database.Order
+	Payment     database.Payment
+	DeliveredAt time.Time
+	RequestedAt time.Time
+}
+
+// RefundPolicy decides whether a refund may go ahead and returns an error to block it.
+// Policies run when a customer files a refund request and again when an admin approves it;
+// RequestedAt is the time the request was filed in both cases.
+type RefundPolicy interface {
+	CheckRefund(ctx context.Context, input RefundPolicyInput) error
+}
+
+// RefundPolicyFunc adapts a function to a RefundPolicy.
+type RefundPolicyFunc func(ctx context.Context, input RefundPolicyInput) error
+
+// CheckRefund calls f(ctx, input).
+func (f RefundPolicyFunc) CheckRefund(ctx context.Context, input RefundPolicyInput) error {
+	return f(ctx, input)
+}
+
+// DefaultRefundPolicies returns the policies used when none are configured:
+// no refunds while an order is shipped, and none later than DefaultRefundWindow after delivery.
+func DefaultRefundPolicies() []RefundPolicy {
+	return []RefundPolicy{
+		BlockShippedRefunds(),
+		RefundWindowAfterDelivery(DefaultRefundWindow),
+	}
+}
+
+// BlockShippedRefunds blocks refunds for orders that are on their way to the customer.
+func BlockShippedRefunds() RefundPolicy {
+	return RefundPolicyFunc(func(_ context.Context, input RefundPolicyInput) error {
+		if input.Order.Status == orderhandlers.OrderStatusShipped {
+			return &handlers.AppError{Code: "refund_not_allowed", Message: "Orders in transit cannot be refunded"}
+		}
+		return nil
+	})
+}
+
+// RefundWindowAfterDelivery blocks refunds requested more than window after the order was delivered.
+func RefundWindowAfterDelivery(window time.Duration) RefundPolicy {
+	return RefundPolicyFunc(func(_ context.Context, input RefundPolicyInput) error {
+		if input.DeliveredAt.IsZero() {
+			return nil
+		}
+		if input.RequestedAt.After(input.DeliveredAt.Add(window)) {
+			return &handlers.AppError{Code: "refund_not_allowed", Message: "The refund window for this order has closed"}
+		}
+		return nil
+	})
+}
+
+// checkRefundPolicies runs the configured refund policies for an order.
+func (s *paymentServiceImpl) checkRefundPolicies(ctx context.Context, queries PaymentDBQueries, order database.Order, payment database.Payment, requestedAt time.Time) error {
+	if len(s.refundPolicies) == 0 {
+		return nil
+	}
+
+	deliveredAt, err := queries.GetLatestOrderStatusChangeTime(ctx, order.ID, orderhandlers.OrderStatusDelivered)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to fetch order status history", Err: err}
+	}
+
+	input := RefundPolicyInput{
+		Order:       order,
+		Payment:     payment,
+		DeliveredAt: deliveredAt,
+		RequestedAt: requestedAt,
+	}
+	for _, policy := range s.refundPolicies {
+		if err := policy.CheckRefund(ctx, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package paymenthandlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// payment_refund_policy_test.go: Tests for the built-in refund policies and how the service runs them.
+
+// TestBlockShippedRefunds tests that only shipped orders are blocked.
+func TestBlockShippedRefunds(t *testing.T) {
+	policy := BlockShippedRefunds()
+	for _, status := range []string{"paid", "delivered", "partially_refunded"} {
+		assert.NoError(t, policy.CheckRefund(context.Background(), RefundPolicyInput{Order: database.Order{Status: status}}), status)
+	}
+	requireAppErrorCode(t, policy.CheckRefund(context.Background(), RefundPolicyInput{Order: database.Order{Status: "shipped"}}), "refund_not_allowed")
+}
+
+// TestRefundWindowAfterDelivery tests the window boundaries and undelivered orders.
+func TestRefundWindowAfterDelivery(t *testing.T) {
+	policy := RefundWindowAfterDelivery(7 * 24 * time.Hour)
+	deliveredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		deliveredAt time.Time
+		requestedAt time.Time
+		allowed     bool
+	}{
+		{"never_delivered", time.Time{}, deliveredAt.Add(365 * 24 * time.Hour), true},
+		{"inside_window", deliveredAt, deliveredAt.Add(3 * 24 * time.Hour), true},
+		{"at_window_end", deliveredAt, deliveredAt.Add(7 * 24 * time.Hour), true},
+		{"after_window", deliveredAt, deliveredAt.Add(7*24*time.Hour + time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckRefund(context.Background(), RefundPolicyInput{DeliveredAt: tt.deliveredAt, RequestedAt: tt.requestedAt})
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				requireAppErrorCode(t, err, "refund_not_allowed")
+			}
+		})
+	}
+}
+
+// TestCheckRefundPolicies tests that custom policies see the order and that history errors stop the check.
+func TestCheckRefundPolicies(t *testing.T) {
+	order := database.Order{ID: "order123", Status: "delivered"}
+
+	t.Run("no_policies", func(t *testing.T) {
+		mockDB := new(mockPaymentDBQueries)
+		service := &paymentServiceImpl{db: mockDB}
+		assert.NoError(t, service.checkRefundPolicies(context.Background(), mockDB, order, database.Payment{}, time.Now()))
+		mockDB.AssertNotCalled(t, "GetLatestOrderStatusChangeTime", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("custom_policy", func(t *testing.T) {
+		mockDB := new(mockPaymentDBQueries)
+		deliveredAt := time.Now().Add(-time.Hour)
+		mockDB.On("GetLatestOrderStatusChangeTime", mock.Anything, "order123", "delivered").Return(deliveredAt, nil)
+		blocked := errors.New("blocked")
+		var seen RefundPolicyInput
+		service := &paymentServiceImpl{db: mockDB, refundPolicies: []RefundPolicy{
+			RefundPolicyFunc(func(_ context.Context, input RefundPolicyInput) error {
+				seen = input
+				return blocked
+			}),
+		}}
+
+		err := service.checkRefundPolicies(context.Background(), mockDB, order, database.Payment{ID: "payment123"}, time.Now())
+		assert.ErrorIs(t, err, blocked)
+		assert.Equal(t, "order123", seen.Order.ID)
+		assert.Equal(t, "payment123", seen.Payment.ID)
+		assert.True(t, seen.DeliveredAt.Equal(deliveredAt))
+	})
+
+	t.Run("history_error", func(t *testing.T) {
+		mockDB := new(mockPaymentDBQueries)
+		mockDB.On("GetLatestOrderStatusChangeTime", mock.Anything, "order123", "delivered").Return(time.Time{}, errors.New("db down"))
+		service := &paymentServiceImpl{db: mockDB, refundPolicies: DefaultRefundPolicies()}
+
+		err := service.checkRefundPolicies(context.Background(), mockDB, order, database.Payment{}, time.Now())
+		requireAppErrorCode(t, err, "database_error")
+	})
+}
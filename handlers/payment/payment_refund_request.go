@@ -0,0 +1,385 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_refund_request.go: Customer refund requests and the admin approval workflow that issues the refunds.
+
+// Refund request statuses.
+const (
+	refundRequestPending  = "pending"
+	refundRequestApproved = "approved"
+	refundRequestRejected = "rejected"
+)
+
+// isValidRefundRequestStatus reports whether status is a refund request status.
+func isValidRefundRequestStatus(status string) bool {
+	switch status {
+	case refundRequestPending, refundRequestApproved, refundRequestRejected:
+		return true
+	}
+	return false
+}
+
+// RequestRefund files a customer's refund request for admin review; no money moves until it is approved.
+// The requested amount or items are validated against earlier refunds and the refund policies,
+// and an order can have only one pending request at a time.
+func (s *paymentServiceImpl) RequestRefund(ctx context.Context, params RefundPaymentParams) (*RefundRequestItem, error) {
+	if params.OrderID == "" || params.UserID == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
+	}
+	if params.Reason == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Reason is required"}
+	}
+	if params.Amount != "" && len(params.Items) > 0 {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Specify either an amount or items to refund, not both"}
+	}
+
+	// Get payment and validate ownership
+	payment, err := s.db.GetPaymentByOrderID(ctx, params.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+	}
+	if payment.UserID != params.UserID {
+		return nil, &handlers.AppError{Code: "unauthorized", Message: "Payment does not belong to user"}
+	}
+	paid, err := refundablePayment(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().UTC()
+
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	order, err := queries.GetOrderByIDForUpdate(ctx, payment.OrderID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+	}
+	if err := s.checkRefundPolicies(ctx, queries, order, payment, timeNow); err != nil {
+		return nil, err
+	}
+
+	_, err = queries.GetPendingRefundRequestByOrderID(ctx, order.ID)
+	if err == nil {
+		return nil, &handlers.AppError{Code: "refund_request_exists", Message: "A refund request for this order is already pending"}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund requests", Err: err}
+	}
+
+	remaining, err := refundableBalance(ctx, queries, payment.ID, paid)
+	if err != nil {
+		return nil, err
+	}
+	amount, _, err := resolveRefundAmount(ctx, queries, params, order.ID, remaining)
+	if err != nil {
+		return nil, err
+	}
+
+	items := params.Items
+	if items == nil {
+		items = []RefundItemInput{}
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Invalid refund items", Err: err}
+	}
+
+	request := database.RefundRequest{
+		ID:        utils.NewUUIDString(),
+		OrderID:   order.ID,
+		PaymentID: payment.ID,
+		UserID:    params.UserID,
+		Amount:    amount.String(),
+		Currency:  payment.Currency,
+		Items:     itemsJSON,
+		Reason:    params.Reason,
+		Status:    refundRequestPending,
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+	}
+	err = queries.CreateRefundRequest(ctx, database.CreateRefundRequestParams{
+		ID:        request.ID,
+		OrderID:   request.OrderID,
+		PaymentID: request.PaymentID,
+		UserID:    request.UserID,
+		Amount:    request.Amount,
+		Currency:  request.Currency,
+		Items:     request.Items,
+		Reason:    request.Reason,
+		CreatedAt: request.CreatedAt,
+		UpdatedAt: request.UpdatedAt,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to record refund request", Err: err}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return toRefundRequestItem(request)
+}
+
+// ListRefundRequests returns refund requests with the given status, oldest first. Defaults to pending requests.
+func (s *paymentServiceImpl) ListRefundRequests(ctx context.Context, status string) ([]RefundRequestItem, error) {
+	if status == "" {
+		status = refundRequestPending
+	}
+	if !isValidRefundRequestStatus(status) {
+		return nil, &handlers.AppError{Code: "invalid_status", Message: "Invalid refund request status"}
+	}
+
+	requests, err := s.db.ListRefundRequestsByStatus(ctx, status)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund requests", Err: err}
+	}
+
+	result := make([]RefundRequestItem, 0, len(requests))
+	for _, r := range requests {
+		item, err := toRefundRequestItem(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *item)
+	}
+
+	return result, nil
+}
+
+// ApproveRefundRequest approves a pending refund request and issues the refund with the payment provider.
+// The refund is first reserved and linked to the request in one transaction, with the request and order rows locked
+// and the refund policies checked again as of the time the request was filed. The provider is then called outside
+// the transaction, and the outcome and the approval are recorded in a second one. A failed provider call leaves the
+// request pending with its refund reserved; approving it again resends the same refund.
+func (s *paymentServiceImpl) ApproveRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundPaymentResult, error) {
+	if params.RequestID == "" || params.AdminID == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
+	}
+
+	timeNow := time.Now().UTC()
+
+	refund, payment, err := s.reserveRequestRefund(ctx, params, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueRefund(ctx, refund, payment, timeNow, func(queries PaymentDBQueries) error {
+		request, err := lockPendingRefundRequest(ctx, queries, params.RequestID)
+		if err != nil {
+			return err
+		}
+		err = queries.UpdateRefundRequestReview(ctx, database.UpdateRefundRequestReviewParams{
+			ID:         request.ID,
+			Status:     refundRequestApproved,
+			RefundID:   utils.ToNullString(refund.ID),
+			ReviewedBy: utils.ToNullString(params.AdminID),
+			ReviewNote: utils.ToNullString(params.Note),
+			ReviewedAt: sql.NullTime{Time: timeNow, Valid: true},
+		})
+		if err != nil {
+			return &handlers.AppError{Code: "database_error", Message: "Failed to update refund request", Err: err}
+		}
+		return nil
+	})
+}
+
+// reserveRequestRefund reserves the refund for a pending refund request and links it to the request.
+// A request whose earlier approval reserved a refund already returns that refund, so it is sent again rather than
+// reserved twice.
+func (s *paymentServiceImpl) reserveRequestRefund(ctx context.Context, params ReviewRefundRequestParams, now time.Time) (database.Refund, database.Payment, error) {
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	request, err := lockPendingRefundRequest(ctx, queries, params.RequestID)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, err
+	}
+
+	payment, err := queries.GetPaymentByOrderID(ctx, request.OrderID)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+	}
+
+	if request.RefundID.Valid {
+		refund, err := queries.GetRefundByID(ctx, request.RefundID.String)
+		if err != nil {
+			return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund", Err: err}
+		}
+		return refund, payment, nil
+	}
+
+	paid, err := refundablePayment(payment)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, err
+	}
+
+	order, err := queries.GetOrderByIDForUpdate(ctx, request.OrderID)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+	}
+	if err := s.checkRefundPolicies(ctx, queries, order, payment, request.CreatedAt); err != nil {
+		return database.Refund{}, database.Payment{}, err
+	}
+
+	refundParams := RefundPaymentParams{
+		OrderID: request.OrderID,
+		UserID:  request.UserID,
+		Reason:  request.Reason,
+	}
+	if err := json.Unmarshal(request.Items, &refundParams.Items); err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "database_error", Message: "Invalid stored refund items", Err: err}
+	}
+	if len(refundParams.Items) == 0 {
+		refundParams.Amount = request.Amount
+	}
+
+	refund, err := reserveRefund(ctx, queries, payment, order, paid, refundParams, params.AdminID, now)
+	if err != nil {
+		return database.Refund{}, database.Payment{}, err
+	}
+
+	err = queries.UpdateRefundRequestRefundID(ctx, database.UpdateRefundRequestRefundIDParams{
+		ID:        request.ID,
+		RefundID:  utils.ToNullString(refund.ID),
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "database_error", Message: "Failed to update refund request", Err: err}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return database.Refund{}, database.Payment{}, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return refund, payment, nil
+}
+
+// RejectRefundRequest rejects a pending refund request without refunding anything.
+// A request whose approval already reserved a refund cannot be rejected, since the provider may have received it;
+// that refund is retried instead.
+func (s *paymentServiceImpl) RejectRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundRequestItem, error) {
+	if params.RequestID == "" || params.AdminID == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
+	}
+
+	timeNow := time.Now().UTC()
+
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	request, err := lockPendingRefundRequest(ctx, queries, params.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.RefundID.Valid {
+		return nil, &handlers.AppError{Code: "refund_in_progress", Message: "A refund was already sent for this request; retry it instead"}
+	}
+
+	request.Status = refundRequestRejected
+	request.ReviewedBy = utils.ToNullString(params.AdminID)
+	request.ReviewNote = utils.ToNullString(params.Note)
+	request.ReviewedAt = sql.NullTime{Time: timeNow, Valid: true}
+	request.UpdatedAt = timeNow
+	err = queries.UpdateRefundRequestReview(ctx, database.UpdateRefundRequestReviewParams{
+		ID:         request.ID,
+		Status:     request.Status,
+		ReviewedBy: request.ReviewedBy,
+		ReviewNote: request.ReviewNote,
+		ReviewedAt: request.ReviewedAt,
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to update refund request", Err: err}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return toRefundRequestItem(request)
+}
+
+// lockPendingRefundRequest locks a refund request for review and checks that it is still pending.
+func lockPendingRefundRequest(ctx context.Context, queries PaymentDBQueries, requestID string) (database.RefundRequest, error) {
+	request, err := queries.GetRefundRequestByIDForUpdate(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.RefundRequest{}, &handlers.AppError{Code: "refund_request_not_found", Message: "Refund request not found", Err: err}
+		}
+		return database.RefundRequest{}, &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund request", Err: err}
+	}
+	if request.Status != refundRequestPending {
+		return database.RefundRequest{}, &handlers.AppError{Code: "refund_request_not_pending", Message: "Refund request has already been " + request.Status}
+	}
+	return request, nil
+}
+
+// toRefundRequestItem converts a stored refund request to its API representation.
+func toRefundRequestItem(r database.RefundRequest) (*RefundRequestItem, error) {
+	amount, err := money.Parse(r.Amount, money.Currency(r.Currency))
+	if err != nil {
+		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid refund request amount", Err: err}
+	}
+	var items []RefundItemInput
+	if err := json.Unmarshal(r.Items, &items); err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Invalid stored refund items", Err: err}
+	}
+
+	item := &RefundRequestItem{
+		ID:         r.ID,
+		OrderID:    r.OrderID,
+		PaymentID:  r.PaymentID,
+		UserID:     r.UserID,
+		Amount:     amount,
+		Currency:   r.Currency,
+		Items:      items,
+		Reason:     r.Reason,
+		Status:     r.Status,
+		RefundID:   r.RefundID.String,
+		ReviewedBy: r.ReviewedBy.String,
+		ReviewNote: r.ReviewNote.String,
+		CreatedAt:  r.CreatedAt,
+	}
+	if r.ReviewedAt.Valid {
+		reviewedAt := r.ReviewedAt.Time
+		item.ReviewedAt = &reviewedAt
+	}
+	return item, nil
+}
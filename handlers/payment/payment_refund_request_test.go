@@ -0,0 +1,222 @@
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v82"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_refund_request_test.go: Tests for filing refund requests and the admin approval workflow.
+
+// TestRequestRefund_Success tests that a refund request is recorded as pending without calling Stripe.
+func TestRequestRefund_Success(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "delivered", "0.00", RefundPaymentParams{})
+	mockDB.On("CreateRefundRequest", mock.Anything, mock.MatchedBy(func(p database.CreateRefundRequestParams) bool {
+		return p.OrderID == "order123" && p.PaymentID == "payment123" && p.UserID == "user123" &&
+			p.Amount == "100.00" && string(p.Items) == "[]" && p.Reason == "Arrived broken"
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	result, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123", Reason: "Arrived broken"})
+	require.NoError(t, err)
+	assert.Equal(t, refundRequestPending, result.Status)
+	assert.Equal(t, "100.00", result.Amount.String())
+	assert.NotEmpty(t, result.ID)
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
+
+// TestRequestRefund_MissingReason tests that a reason is required.
+func TestRequestRefund_MissingReason(t *testing.T) {
+	service := &paymentServiceImpl{}
+	_, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123"})
+	requireAppErrorCode(t, err, "invalid_request")
+	assert.Contains(t, err.Error(), "Reason is required")
+}
+
+// TestRequestRefund_AlreadyPending tests that an order can have only one pending request.
+func TestRequestRefund_AlreadyPending(t *testing.T) {
+	service, mockDB, _, _ := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetPendingRefundRequestByOrderID")
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(refundTestRequest(RefundPaymentParams{}), nil)
+
+	_, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123", Reason: "Arrived broken"})
+	requireAppErrorCode(t, err, "refund_request_exists")
+	mockDB.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+}
+
+// TestRequestRefund_PolicyBlocked tests that the default policies block shipped orders and late requests.
+func TestRequestRefund_PolicyBlocked(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderStatus string
+		deliveredAt time.Time
+		deliveryErr error
+	}{
+		{"shipped", "shipped", time.Time{}, sql.ErrNoRows},
+		{"window_closed", "delivered", time.Now().Add(-DefaultRefundWindow - time.Hour), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockDB, _, _ := newRefundTestService("succeeded", tt.orderStatus, "0.00", RefundPaymentParams{})
+			service.refundPolicies = DefaultRefundPolicies()
+			mockDB.On("GetLatestOrderStatusChangeTime", mock.Anything, "order123", "delivered").Return(tt.deliveredAt, tt.deliveryErr)
+
+			_, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123", Reason: "Changed my mind"})
+			requireAppErrorCode(t, err, "refund_not_allowed")
+			mockDB.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestApproveRefundRequest_PolicyUsesRequestTime tests that a request filed inside the refund window
+// can still be approved after the window has closed.
+func TestApproveRefundRequest_PolicyUsesRequestTime(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "delivered", "0.00", RefundPaymentParams{})
+	service.refundPolicies = DefaultRefundPolicies()
+	request := refundTestRequest(RefundPaymentParams{})
+	deliveredAt := time.Now().Add(-DefaultRefundWindow - 48*time.Hour)
+	request.CreatedAt = deliveredAt.Add(24 * time.Hour)
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundRequestByIDForUpdate")
+	mockDB.On("GetRefundRequestByIDForUpdate", mock.Anything, "rr1").Return(request, nil)
+	mockDB.On("GetLatestOrderStatusChangeTime", mock.Anything, "order123", "delivered").Return(deliveredAt, nil)
+	expectStripeRefund(mockStripe, 10000, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "100.00", "succeeded", "100.00", "refunded")
+	mockTx.On("Commit").Return(nil)
+
+	_, err := approveTestRefund(service)
+	require.NoError(t, err)
+	mockStripe.AssertExpectations(t)
+}
+
+// TestApproveRefundRequest_NotReviewable tests requests that are missing or already reviewed.
+func TestApproveRefundRequest_NotReviewable(t *testing.T) {
+	approved := refundTestRequest(RefundPaymentParams{})
+	approved.Status = refundRequestApproved
+
+	tests := []struct {
+		name    string
+		request database.RefundRequest
+		err     error
+		code    string
+	}{
+		{"not_found", database.RefundRequest{}, sql.ErrNoRows, "refund_request_not_found"},
+		{"lookup_error", database.RefundRequest{}, errors.New("db down"), "database_error"},
+		{"already_approved", approved, nil, "refund_request_not_pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockDB, _, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+			mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundRequestByIDForUpdate")
+			mockDB.On("GetRefundRequestByIDForUpdate", mock.Anything, "rr1").Return(tt.request, tt.err)
+
+			_, err := approveTestRefund(service)
+			requireAppErrorCode(t, err, tt.code)
+			mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+		})
+	}
+}
+
+// TestApproveRefundRequest_StripeErrorLeavesPending tests that a failed refund does not mark the request approved.
+// The refund stays reserved and linked to the request, so approving it again resends the same refund.
+func TestApproveRefundRequest_StripeErrorLeavesPending(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectRefundReserved(mockDB, "100.00")
+	mockStripe.On("CreateRefund", mock.Anything).Return(nil, errors.New("card network down"))
+	mockTx.On("Commit").Return(nil)
+
+	_, err := approveTestRefund(service)
+	requireAppErrorCode(t, err, "stripe_error")
+	mockDB.AssertNotCalled(t, "UpdateRefundResult", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "UpdateRefundRequestReview", mock.Anything, mock.Anything)
+	mockTx.AssertNumberOfCalls(t, "Commit", 1)
+	mockDB.AssertExpectations(t)
+}
+
+// TestRejectRefundRequest_Success tests that rejecting records the review and refunds nothing.
+func TestRejectRefundRequest_Success(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{Reason: "Changed my mind"})
+	mockDB.On("UpdateRefundRequestReview", mock.Anything, mock.MatchedBy(func(p database.UpdateRefundRequestReviewParams) bool {
+		return p.ID == "rr1" && p.Status == refundRequestRejected && !p.RefundID.Valid &&
+			p.ReviewedBy == utils.ToNullString("admin1") && p.ReviewNote == utils.ToNullString("Outside policy") && p.ReviewedAt.Valid
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	result, err := service.RejectRefundRequest(context.Background(), ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1", Note: "Outside policy"})
+	require.NoError(t, err)
+	assert.Equal(t, refundRequestRejected, result.Status)
+	assert.Equal(t, "Outside policy", result.ReviewNote)
+	require.NotNil(t, result.ReviewedAt)
+	mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+	mockDB.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything)
+	mockDB.AssertCalled(t, "UpdateRefundRequestReview", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
+
+// TestRejectRefundRequest_RefundInProgress tests that a request whose approval already reserved a refund cannot be
+// rejected.
+func TestRejectRefundRequest_RefundInProgress(t *testing.T) {
+	service, mockDB, _, _ := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	request := refundTestRequest(RefundPaymentParams{})
+	request.RefundID = utils.ToNullString("ref_test_123")
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundRequestByIDForUpdate")
+	mockDB.On("GetRefundRequestByIDForUpdate", mock.Anything, "rr1").Return(request, nil)
+
+	_, err := service.RejectRefundRequest(context.Background(), ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1"})
+	requireAppErrorCode(t, err, "refund_in_progress")
+	mockDB.AssertNotCalled(t, "UpdateRefundRequestReview", mock.Anything, mock.Anything)
+}
+
+// TestRejectRefundRequest_MissingFields tests that the request and admin IDs are required.
+func TestRejectRefundRequest_MissingFields(t *testing.T) {
+	service := &paymentServiceImpl{}
+	_, err := service.RejectRefundRequest(context.Background(), ReviewRefundRequestParams{RequestID: "rr1"})
+	requireAppErrorCode(t, err, "invalid_request")
+	_, err = service.ApproveRefundRequest(context.Background(), ReviewRefundRequestParams{AdminID: "admin1"})
+	requireAppErrorCode(t, err, "invalid_request")
+}
+
+// TestListRefundRequests tests the status filter and the conversion of stored requests.
+func TestListRefundRequests(t *testing.T) {
+	t.Run("default_pending", func(t *testing.T) {
+		mockDB := new(mockPaymentDBQueries)
+		service := &paymentServiceImpl{db: mockDB}
+		mockDB.On("ListRefundRequestsByStatus", mock.Anything, refundRequestPending).Return([]database.RefundRequest{
+			refundTestRequest(RefundPaymentParams{Items: []RefundItemInput{{OrderItemID: "item1", Quantity: 2}}, Amount: "40.00"}),
+		}, nil)
+
+		result, err := service.ListRefundRequests(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "40.00", result[0].Amount.String())
+		assert.Equal(t, []RefundItemInput{{OrderItemID: "item1", Quantity: 2}}, result[0].Items)
+		assert.Nil(t, result[0].ReviewedAt)
+	})
+
+	t.Run("invalid_status", func(t *testing.T) {
+		service := &paymentServiceImpl{}
+		_, err := service.ListRefundRequests(context.Background(), "bogus")
+		requireAppErrorCode(t, err, "invalid_status")
+	})
+
+	t.Run("database_error", func(t *testing.T) {
+		mockDB := new(mockPaymentDBQueries)
+		service := &paymentServiceImpl{db: mockDB}
+		mockDB.On("ListRefundRequestsByStatus", mock.Anything, refundRequestRejected).Return(nil, errors.New("db down"))
+
+		_, err := service.ListRefundRequests(context.Background(), refundRequestRejected)
+		requireAppErrorCode(t, err, "database_error")
+	})
+}
@@ -0,0 +1,429 @@
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v82"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_refund_test.go: Tests for partial refunds by amount and by order line.
+
+// newRefundTestService returns a service whose payment for order123 is a 100.00 USD Stripe payment owned by user123.
+// Transactions start, the order is locked, and the given amount had been refunded before the refund under test.
+// The pending refund request rr1 asks for the given refund, and order123 has no other pending request.
+func newRefundTestService(paymentStatus, orderStatus, refunded string, refund RefundPaymentParams) (*paymentServiceImpl, *mockPaymentDBQueries, *mockPaymentDBTx, *mockStripeClient) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+	mockStripe := new(mockStripeClient)
+
+	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{
+		ID:                "payment123",
+		OrderID:           "order123",
+		UserID:            "user123",
+		Amount:            "100.00",
+		Currency:          "USD",
+		Status:            paymentStatus,
+		Provider:          "stripe",
+		ProviderPaymentID: utils.ToNullString("pi_test_123"),
+	}, nil)
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockTx.On("Rollback").Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: orderStatus}, nil)
+	mockDB.On("GetRefundedAmountByPaymentID", mock.Anything, "payment123").Return(refunded, nil).Once()
+	mockDB.On("GetRefundRequestByIDForUpdate", mock.Anything, "rr1").Return(refundTestRequest(refund), nil).Maybe()
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(database.RefundRequest{}, sql.ErrNoRows).Maybe()
+
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+	return service, mockDB, mockTx, mockStripe
+}
+
+// refundTestRequest returns the pending refund request rr1 for order123 asking for the given refund.
+func refundTestRequest(refund RefundPaymentParams) database.RefundRequest {
+	items := refund.Items
+	if items == nil {
+		items = []RefundItemInput{}
+	}
+	itemsJSON, _ := json.Marshal(items)
+	amount := refund.Amount
+	if amount == "" {
+		amount = "100.00"
+	}
+	return database.RefundRequest{
+		ID:        "rr1",
+		OrderID:   "order123",
+		PaymentID: "payment123",
+		UserID:    "user123",
+		Amount:    amount,
+		Currency:  "USD",
+		Items:     itemsJSON,
+		Reason:    refund.Reason,
+		Status:    refundRequestPending,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// approveTestRefund approves refund request rr1 as admin1.
+func approveTestRefund(service *paymentServiceImpl) (*RefundPaymentResult, error) {
+	return service.ApproveRefundRequest(context.Background(), ReviewRefundRequestParams{RequestID: "rr1", AdminID: "admin1"})
+}
+
+// expectStripeRefund expects a Stripe refund of the given minor-unit amount against pi_test_123.
+func expectStripeRefund(mockStripe *mockStripeClient, minor int64, status stripe.RefundStatus) {
+	mockStripe.On("CreateRefund", mock.MatchedBy(func(p *stripe.RefundParams) bool {
+		return p.Amount != nil && *p.Amount == minor && *p.PaymentIntent == "pi_test_123" && p.IdempotencyKey != nil
+	})).Return(&stripe.Refund{ID: "re_test_123", Status: status}, nil)
+}
+
+// expectRefundReserved expects a pending refund of the given amount, not yet sent to the provider, to be recorded
+// for admin1 and linked to refund request rr1.
+func expectRefundReserved(mockDB *mockPaymentDBQueries, amount string) {
+	mockDB.On("CreateRefund", mock.Anything, mock.MatchedBy(func(p database.CreateRefundParams) bool {
+		return p.Amount == amount && p.Status == refundStatusPending && p.PaymentID == "payment123" &&
+			!p.ProviderRefundID.Valid && p.CreatedBy == utils.ToNullString("admin1")
+	})).Return(nil)
+	mockDB.On("UpdateRefundRequestRefundID", mock.Anything, mock.MatchedBy(func(p database.UpdateRefundRequestRefundIDParams) bool {
+		return p.ID == "rr1" && p.RefundID.Valid
+	})).Return(nil)
+}
+
+// expectRefundSettled expects the provider's answer to the reserved refund to be recorded, the payment and the
+// order's refund status to move to status once refundedAfter is refunded, and refund request rr1 to be approved.
+func expectRefundSettled(mockDB *mockPaymentDBQueries, amount, refundStatus, refundedAfter, status string) {
+	mockDB.On("GetRefundByIDForUpdate", mock.Anything, mock.Anything).Return(database.Refund{
+		ID:        "ref_test_123",
+		PaymentID: "payment123",
+		OrderID:   "order123",
+		Amount:    amount,
+		Currency:  "USD",
+		Status:    refundStatusPending,
+	}, nil)
+	mockDB.On("UpdateRefundResult", mock.Anything, mock.MatchedBy(func(p database.UpdateRefundResultParams) bool {
+		return p.Status == refundStatus && p.ProviderRefundID == utils.ToNullString("re_test_123")
+	})).Return(nil)
+	mockDB.On("GetRefundedAmountByPaymentID", mock.Anything, "payment123").Return(refundedAfter, nil).Once()
+	mockDB.On("UpdatePaymentStatus", mock.Anything, mock.MatchedBy(func(p database.UpdatePaymentStatusParams) bool {
+		return p.ID == "payment123" && p.Status == status && !p.UpdatedAt.IsZero()
+	})).Return(nil)
+	mockDB.On("UpdateOrderRefundStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderRefundStatusParams) bool {
+		return p.ID == "order123" && p.RefundStatus == status
+	})).Return(nil)
+	mockDB.On("UpdateRefundRequestReview", mock.Anything, mock.MatchedBy(func(p database.UpdateRefundRequestReviewParams) bool {
+		return p.ID == "rr1" && p.Status == refundRequestApproved && p.RefundID.Valid && p.ReviewedBy == utils.ToNullString("admin1")
+	})).Return(nil)
+}
+
+// expectRefundRecorded expects the refund to be reserved and then settled with the provider's answer.
+func expectRefundRecorded(mockDB *mockPaymentDBQueries, amount, refundStatus, refundedAfter, status string) {
+	expectRefundReserved(mockDB, amount)
+	expectRefundSettled(mockDB, amount, refundStatus, refundedAfter, status)
+}
+
+// TestRefundPayment_PartialAmount tests that refunding part of the payment marks it and the order's refunds
+// partially refunded while the order keeps its delivered status.
+func TestRefundPayment_PartialAmount(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "delivered", "0.00", RefundPaymentParams{Amount: "25.50", Reason: "Damaged box"})
+	expectStripeRefund(mockStripe, 2550, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "25.50", "succeeded", "25.50", "partially_refunded")
+	mockTx.On("Commit").Return(nil)
+
+	result, err := approveTestRefund(service)
+	require.NoError(t, err)
+	assert.Equal(t, "25.50", result.Amount.String())
+	assert.Equal(t, "succeeded", result.Status)
+	assert.Equal(t, "partially_refunded", result.PaymentStatus)
+	assert.Equal(t, "partially_refunded", result.OrderRefundStatus)
+	assert.NotEmpty(t, result.RefundID)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestRefundPayment_RemainingBalance tests that refunding what is left completes the refund.
+func TestRefundPayment_RemainingBalance(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("partially_refunded", "shipped", "25.50", RefundPaymentParams{Amount: "74.50"})
+	expectStripeRefund(mockStripe, 7450, stripe.RefundStatusPending)
+	expectRefundRecorded(mockDB, "74.50", "pending", "100.00", "refunded")
+	mockTx.On("Commit").Return(nil)
+
+	result, err := approveTestRefund(service)
+	require.NoError(t, err)
+	assert.Equal(t, "74.50", result.Amount.String())
+	assert.Equal(t, "refunded", result.PaymentStatus)
+	assert.Equal(t, "refunded", result.OrderRefundStatus)
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertExpectations(t)
+}
+
+// TestRefundPayment_Items tests that an item refund is priced from the order lines and records each line.
+func TestRefundPayment_Items(t *testing.T) {
+	items := []RefundItemInput{{OrderItemID: "item1", Quantity: 2}}
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{Items: items})
+	mockDB.On("GetOrderItemsByOrderID", mock.Anything, "order123").Return([]database.OrderItem{
+		{ID: "item1", OrderID: "order123", Quantity: 3, Price: "20.00"},
+		{ID: "item2", OrderID: "order123", Quantity: 1, Price: "40.00"},
+	}, nil)
+	mockDB.On("GetRefundedQuantitiesByOrderID", mock.Anything, "order123").Return([]database.GetRefundedQuantitiesByOrderIDRow{
+		{OrderItemID: "item1", Quantity: 1},
+	}, nil)
+	expectStripeRefund(mockStripe, 4000, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "40.00", "succeeded", "40.00", "partially_refunded")
+	mockDB.On("CreateRefundItem", mock.Anything, mock.MatchedBy(func(p database.CreateRefundItemParams) bool {
+		return p.OrderItemID == "item1" && p.Quantity == 2 && p.Amount == "40.00" && p.RefundID != ""
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	result, err := approveTestRefund(service)
+	require.NoError(t, err)
+	assert.Equal(t, "40.00", result.Amount.String())
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertExpectations(t)
+}
+
+// TestRefundPayment_ItemValidation tests the rejected item lists.
+func TestRefundPayment_ItemValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []RefundItemInput
+		code  string
+	}{
+		{"quantity_exceeds_remaining", []RefundItemInput{{OrderItemID: "item1", Quantity: 3}}, "refund_exceeds_quantity"},
+		{"unknown_item", []RefundItemInput{{OrderItemID: "other", Quantity: 1}}, "invalid_request"},
+		{"zero_quantity", []RefundItemInput{{OrderItemID: "item1", Quantity: 0}}, "invalid_request"},
+		{"duplicate_item", []RefundItemInput{{OrderItemID: "item1", Quantity: 1}, {OrderItemID: "item1", Quantity: 1}}, "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockDB, _, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+			mockDB.On("GetOrderItemsByOrderID", mock.Anything, "order123").Return([]database.OrderItem{
+				{ID: "item1", OrderID: "order123", Quantity: 3, Price: "20.00"},
+			}, nil)
+			mockDB.On("GetRefundedQuantitiesByOrderID", mock.Anything, "order123").Return([]database.GetRefundedQuantitiesByOrderIDRow{
+				{OrderItemID: "item1", Quantity: 1},
+			}, nil)
+
+			_, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123", Items: tt.items, Reason: "Wrong size"})
+			requireAppErrorCode(t, err, tt.code)
+			mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+		})
+	}
+}
+
+// TestRefundItemsAmount_CurrencyMismatch tests that order lines are not priced in the currency of a payment made in
+// another currency than the order's.
+func TestRefundItemsAmount_CurrencyMismatch(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+
+	_, _, err := refundItemsAmount(context.Background(), mockDB, "order123", []RefundItemInput{{OrderItemID: "item1", Quantity: 1}}, money.JPY)
+	requireAppErrorCode(t, err, "currency_mismatch")
+	mockDB.AssertNotCalled(t, "GetOrderItemsByOrderID", mock.Anything, mock.Anything)
+}
+
+// TestRefundPayment_AmountValidation tests the rejected refund amounts.
+func TestRefundPayment_AmountValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		refunded string
+		code     string
+	}{
+		{"exceeds_balance", "80.00", "25.50", "refund_exceeds_balance"},
+		{"not_positive", "0", "0.00", "invalid_amount"},
+		{"too_many_decimals", "1.005", "0.00", "invalid_amount"},
+		{"fully_refunded", "1.00", "100.00", "refund_exceeds_balance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _, mockStripe := newRefundTestService("partially_refunded", "delivered", tt.refunded, RefundPaymentParams{})
+
+			_, err := service.RequestRefund(context.Background(), RefundPaymentParams{OrderID: "order123", UserID: "user123", Amount: tt.amount, Reason: "Wrong size"})
+			requireAppErrorCode(t, err, tt.code)
+			mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+		})
+	}
+}
+
+// TestRefundPayment_AmountAndItems tests that an amount and an item list cannot be combined.
+func TestRefundPayment_AmountAndItems(t *testing.T) {
+	service := &paymentServiceImpl{}
+	_, err := service.RequestRefund(context.Background(), RefundPaymentParams{
+		OrderID: "order123",
+		UserID:  "user123",
+		Amount:  "10.00",
+		Items:   []RefundItemInput{{OrderItemID: "item1", Quantity: 1}},
+		Reason:  "Wrong size",
+	})
+	requireAppErrorCode(t, err, "invalid_request")
+}
+
+// TestRefundPayment_UnpaidOrder tests that an order that was never paid is rejected before Stripe is called.
+func TestRefundPayment_UnpaidOrder(t *testing.T) {
+	service, _, _, mockStripe := newRefundTestService("succeeded", "pending", "0.00", RefundPaymentParams{Amount: "10.00"})
+
+	_, err := approveTestRefund(service)
+	requireAppErrorCode(t, err, "invalid_status")
+	mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
+
+// TestRefundPayment_CancelledOrder tests that refunding a payment captured after its order was cancelled refunds the
+// payment but leaves the order cancelled.
+func TestRefundPayment_CancelledOrder(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "cancelled", "0.00", RefundPaymentParams{})
+	expectStripeRefund(mockStripe, 10000, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "100.00", "succeeded", "100.00", "refunded")
+	mockTx.On("Commit").Return(nil)
+
+	result, err := approveTestRefund(service)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", result.PaymentStatus)
+	assert.Equal(t, "refunded", result.OrderRefundStatus)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "CreateOrderStatusHistory", mock.Anything, mock.Anything)
+	mockStripe.AssertExpectations(t)
+}
+
+// TestRefundPayment_RefundedAmountError tests a failure to read earlier refunds.
+func TestRefundPayment_RefundedAmountError(t *testing.T) {
+	service, mockDB, _, _ := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundedAmountByPaymentID")
+	mockDB.On("GetRefundedAmountByPaymentID", mock.Anything, "payment123").Return("", errors.New("db down"))
+
+	_, err := approveTestRefund(service)
+	requireAppErrorCode(t, err, "database_error")
+}
+
+// TestRefundPayment_RecordRefundErrors tests failures writing the refund and its lines, which stop the refund before
+// the provider is called.
+func TestRefundPayment_RecordRefundErrors(t *testing.T) {
+	t.Run("refund", func(t *testing.T) {
+		service, mockDB, _, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{Amount: "10.00"})
+		mockDB.On("CreateRefund", mock.Anything, mock.Anything).Return(errors.New("insert failed"))
+
+		_, err := approveTestRefund(service)
+		requireAppErrorCode(t, err, "database_error")
+		assert.Contains(t, err.Error(), "Failed to record refund")
+		mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+	})
+
+	t.Run("refund_item", func(t *testing.T) {
+		service, mockDB, _, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{Items: []RefundItemInput{{OrderItemID: "item1", Quantity: 1}}})
+		mockDB.On("GetOrderItemsByOrderID", mock.Anything, "order123").Return([]database.OrderItem{{ID: "item1", Quantity: 1, Price: "10.00"}}, nil)
+		mockDB.On("GetRefundedQuantitiesByOrderID", mock.Anything, "order123").Return([]database.GetRefundedQuantitiesByOrderIDRow{}, nil)
+		mockDB.On("CreateRefund", mock.Anything, mock.Anything).Return(nil)
+		mockDB.On("CreateRefundItem", mock.Anything, mock.Anything).Return(errors.New("insert failed"))
+
+		_, err := approveTestRefund(service)
+		requireAppErrorCode(t, err, "database_error")
+		assert.Contains(t, err.Error(), "Failed to record refund item")
+		mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+	})
+}
+
+// TestRefundPayment_ReapprovalResendsRefund tests that approving a request whose refund was reserved earlier sends
+// that refund again with its ID as the idempotency key instead of reserving another one.
+func TestRefundPayment_ReapprovalResendsRefund(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{Amount: "10.00"})
+	request := refundTestRequest(RefundPaymentParams{Amount: "10.00"})
+	request.RefundID = utils.ToNullString("ref_test_123")
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundRequestByIDForUpdate")
+	mockDB.On("GetRefundRequestByIDForUpdate", mock.Anything, "rr1").Return(request, nil)
+	mockDB.On("GetRefundByID", mock.Anything, "ref_test_123").Return(database.Refund{
+		ID:        "ref_test_123",
+		PaymentID: "payment123",
+		OrderID:   "order123",
+		Amount:    "10.00",
+		Currency:  "USD",
+		Status:    refundStatusPending,
+	}, nil)
+	mockStripe.On("CreateRefund", mock.MatchedBy(func(p *stripe.RefundParams) bool {
+		return *p.Amount == 1000 && p.IdempotencyKey != nil && *p.IdempotencyKey == "ref_test_123"
+	})).Return(&stripe.Refund{ID: "re_test_123", Status: stripe.RefundStatusSucceeded}, nil)
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundedAmountByPaymentID")
+	expectRefundSettled(mockDB, "10.00", "succeeded", "10.00", "partially_refunded")
+	mockTx.On("Commit").Return(nil)
+
+	result, err := approveTestRefund(service)
+	require.NoError(t, err)
+	assert.Equal(t, "ref_test_123", result.RefundID)
+	mockDB.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+	mockStripe.AssertExpectations(t)
+}
+
+// TestRetryRefund tests resending a pending refund and the refunds that cannot be retried.
+func TestRetryRefund(t *testing.T) {
+	pending := database.Refund{
+		ID:        "ref_test_123",
+		PaymentID: "payment123",
+		OrderID:   "order123",
+		Amount:    "100.00",
+		Currency:  "USD",
+		Status:    refundStatusPending,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "cancelled", "100.00", RefundPaymentParams{})
+		mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetRefundedAmountByPaymentID")
+		mockDB.On("GetRefundByID", mock.Anything, "ref_test_123").Return(pending, nil)
+		mockStripe.On("CreateRefund", mock.MatchedBy(func(p *stripe.RefundParams) bool {
+			return *p.Amount == 10000 && *p.IdempotencyKey == "ref_test_123"
+		})).Return(&stripe.Refund{ID: "re_test_123", Status: stripe.RefundStatusSucceeded}, nil)
+		mockDB.On("GetRefundByIDForUpdate", mock.Anything, "ref_test_123").Return(pending, nil)
+		mockDB.On("UpdateRefundResult", mock.Anything, mock.MatchedBy(func(p database.UpdateRefundResultParams) bool {
+			return p.ID == "ref_test_123" && p.Status == "succeeded" && p.ProviderRefundID == utils.ToNullString("re_test_123")
+		})).Return(nil)
+		mockDB.On("GetRefundedAmountByPaymentID", mock.Anything, "payment123").Return("100.00", nil)
+		mockDB.On("UpdatePaymentStatus", mock.Anything, mock.Anything).Return(nil)
+		mockDB.On("UpdateOrderRefundStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderRefundStatusParams) bool {
+			return p.RefundStatus == "refunded"
+		})).Return(nil)
+		mockTx.On("Commit").Return(nil)
+
+		result, err := service.RetryRefund(context.Background(), "ref_test_123")
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", result.Status)
+		assert.Equal(t, "refunded", result.OrderRefundStatus)
+		mockDB.AssertExpectations(t)
+		mockStripe.AssertExpectations(t)
+	})
+
+	t.Run("already_sent", func(t *testing.T) {
+		service, mockDB, _, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+		sent := pending
+		sent.ProviderRefundID = utils.ToNullString("re_test_123")
+		mockDB.On("GetRefundByID", mock.Anything, "ref_test_123").Return(sent, nil)
+
+		_, err := service.RetryRefund(context.Background(), "ref_test_123")
+		requireAppErrorCode(t, err, "refund_not_retryable")
+		mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		service, mockDB, _, _ := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+		mockDB.On("GetRefundByID", mock.Anything, "missing").Return(database.Refund{}, sql.ErrNoRows)
+
+		_, err := service.RetryRefund(context.Background(), "missing")
+		requireAppErrorCode(t, err, "refund_not_found")
+	})
+
+	t.Run("missing_id", func(t *testing.T) {
+		_, err := (&paymentServiceImpl{}).RetryRefund(context.Background(), "")
+		requireAppErrorCode(t, err, "invalid_request")
+	})
+}
@@ -0,0 +1,74 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// payment_release.go: Settles the payment of a cancelled order, for the order reaper and order service.
+
+// paymentReleaser implements orderhandlers.PaymentReleaser with the payment service's providers and refund logic.
+type paymentReleaser struct {
+	service *paymentServiceImpl
+}
+
+// NewPaymentReleaser returns the orderhandlers.PaymentReleaser backed by the same providers and database as the payment service.
+func NewPaymentReleaser(db *database.Queries, dbConn *sql.DB, apiKey string) orderhandlers.PaymentReleaser {
+	return &paymentReleaser{service: &paymentServiceImpl{
+		db:        &PaymentDBQueriesAdapter{db},
+		dbConn:    &PaymentDBConnAdapter{dbConn},
+		providers: newPaymentProviders(apiKey, &realStripeClient{}),
+	}}
+}
+
+// CancelPayment voids the payment's intent with its provider.
+// Returns orderhandlers.ErrPaymentCaptured when the provider already took the payment.
+func (r *paymentReleaser) CancelPayment(ctx context.Context, payment database.Payment) error {
+	provider, err := r.service.provider(payment.Provider)
+	if err != nil {
+		return err
+	}
+	if !payment.ProviderPaymentID.Valid {
+		return nil
+	}
+	return provider.CancelIntent(ctx, payment.ProviderPaymentID.String)
+}
+
+// ReserveRefund reserves a refund of the balance of a captured payment.
+// Refund policies do not apply; an admin cancelling the order has already decided the customer is owed the money.
+func (r *paymentReleaser) ReserveRefund(ctx context.Context, queries *database.Queries, order database.Order, payment database.Payment, actorID, reason string, now time.Time) (string, error) {
+	paid, err := refundablePayment(payment)
+	if err != nil {
+		return "", err
+	}
+	adapter := &PaymentDBQueriesAdapter{queries}
+	remaining, err := refundableBalance(ctx, adapter, payment.ID, paid)
+	if err != nil {
+		return "", err
+	}
+	if !remaining.IsPositive() {
+		return "", nil
+	}
+
+	params := RefundPaymentParams{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Reason:  reason,
+	}
+	refund, err := reserveRefund(ctx, adapter, payment, order, paid, params, actorID, now)
+	if err != nil {
+		return "", err
+	}
+	return refund.ID, nil
+}
+
+// SendRefund sends a reserved refund to its provider and records the outcome, like an admin retry would.
+func (r *paymentReleaser) SendRefund(ctx context.Context, refundID string) error {
+	_, err := r.service.RetryRefund(ctx, refundID)
+	return err
+}
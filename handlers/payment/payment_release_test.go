@@ -0,0 +1,168 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v82"
+
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_release_test.go: Tests for voiding or refunding the payment of a cancelled order with its provider.
+
+// newTestPaymentReleaser creates a paymentReleaser whose Stripe provider uses a mock client.
+func newTestPaymentReleaser() (*paymentReleaser, *mockStripeClient) {
+	client := new(mockStripeClient)
+	return &paymentReleaser{service: &paymentServiceImpl{providers: newPaymentProviders("sk_test", client)}}, client
+}
+
+// TestCancelPayment_Stripe tests how Stripe cancellation outcomes map to CancelPayment results.
+func TestCancelPayment_Stripe(t *testing.T) {
+	payment := database.Payment{ID: "pay1", Provider: ProviderStripe, ProviderPaymentID: utils.ToNullString("pi_1")}
+	cancelErr := errors.New("payment_intent_unexpected_state")
+
+	tests := []struct {
+		name      string
+		cancelErr error
+		status    stripe.PaymentIntentStatus
+		wantErr   error
+		wantCode  string
+	}{
+		{name: "cancelled", cancelErr: nil},
+		{name: "already_cancelled", cancelErr: cancelErr, status: stripe.PaymentIntentStatusCanceled},
+		{name: "captured", cancelErr: cancelErr, status: stripe.PaymentIntentStatusSucceeded, wantErr: orderhandlers.ErrPaymentCaptured},
+		{name: "processing", cancelErr: cancelErr, status: stripe.PaymentIntentStatusProcessing, wantCode: "stripe_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaser, client := newTestPaymentReleaser()
+			if tt.cancelErr == nil {
+				client.On("CancelPaymentIntent", "pi_1").Return(&stripe.PaymentIntent{ID: "pi_1", Status: stripe.PaymentIntentStatusCanceled}, nil)
+			} else {
+				client.On("CancelPaymentIntent", "pi_1").Return(nil, tt.cancelErr)
+				client.On("GetPaymentIntent", "pi_1").Return(&stripe.PaymentIntent{ID: "pi_1", Status: tt.status}, nil)
+			}
+
+			err := releaser.CancelPayment(context.Background(), payment)
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.wantCode != "":
+				requireAppErrorCode(t, err, tt.wantCode)
+			default:
+				require.NoError(t, err)
+			}
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCancelPayment_StripeLookupFails tests that a failed cancel is reported when the intent cannot be fetched either.
+func TestCancelPayment_StripeLookupFails(t *testing.T) {
+	releaser, client := newTestPaymentReleaser()
+	client.On("CancelPaymentIntent", "pi_1").Return(nil, errors.New("network"))
+	client.On("GetPaymentIntent", "pi_1").Return(nil, errors.New("network"))
+
+	err := releaser.CancelPayment(context.Background(), database.Payment{Provider: ProviderStripe, ProviderPaymentID: utils.ToNullString("pi_1")})
+	requireAppErrorCode(t, err, "stripe_error")
+}
+
+// TestCancelPayment_Manual tests that manual payments are released without contacting Stripe.
+func TestCancelPayment_Manual(t *testing.T) {
+	releaser, client := newTestPaymentReleaser()
+
+	err := releaser.CancelPayment(context.Background(), database.Payment{Provider: ProviderManual, ProviderPaymentID: utils.ToNullString("manual_1")})
+	require.NoError(t, err)
+	client.AssertNotCalled(t, "CancelPaymentIntent")
+}
+
+// TestCancelPayment_UnknownProvider tests that a payment with an unregistered provider is rejected.
+func TestCancelPayment_UnknownProvider(t *testing.T) {
+	releaser, _ := newTestPaymentReleaser()
+
+	err := releaser.CancelPayment(context.Background(), database.Payment{Provider: "paypal"})
+	requireAppErrorCode(t, err, "invalid_provider")
+}
+
+// TestReleaserReserveRefund tests that the balance of a captured payment is reserved as a pending refund without
+// contacting the provider.
+func TestReleaserReserveRefund(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	releaser, client := newTestPaymentReleaser()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	order := database.Order{ID: "order1", UserID: "user1", Status: orderhandlers.OrderStatusCancelled}
+	payment := database.Payment{
+		ID:                "pay1",
+		OrderID:           "order1",
+		Amount:            "30.00",
+		Currency:          "USD",
+		Status:            "succeeded",
+		Provider:          ProviderStripe,
+		ProviderPaymentID: utils.ToNullString("pi_1"),
+	}
+
+	dbMock.ExpectQuery("FROM refunds").WithArgs("pay1").WillReturnRows(sqlmock.NewRows([]string{"refunded_amount"}).AddRow("10.00"))
+	dbMock.ExpectQuery("FROM refunds").WithArgs("pay1").WillReturnRows(sqlmock.NewRows([]string{"refunded_amount"}).AddRow("10.00"))
+	dbMock.ExpectExec("INSERT INTO refunds").
+		WithArgs(sqlmock.AnyArg(), "pay1", "order1", "20.00", "USD", refundStatusPending, sql.NullString{},
+			utils.ToNullString("Out of stock"), utils.ToNullString("admin1"), now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	refundID, err := releaser.ReserveRefund(context.Background(), database.New(db), order, payment, "admin1", "Out of stock", now)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, refundID)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+	client.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
+
+// TestReleaserReserveRefund_NothingLeft tests that a fully refunded payment reserves no refund.
+func TestReleaserReserveRefund_NothingLeft(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	releaser, _ := newTestPaymentReleaser()
+
+	dbMock.ExpectQuery("FROM refunds").WithArgs("pay1").WillReturnRows(sqlmock.NewRows([]string{"refunded_amount"}).AddRow("30.00"))
+
+	refundID, err := releaser.ReserveRefund(context.Background(), database.New(db), database.Order{ID: "order1"},
+		database.Payment{ID: "pay1", Amount: "30.00", Currency: "USD", Status: "partially_refunded", ProviderPaymentID: utils.ToNullString("pi_1")},
+		"admin1", "", time.Now())
+
+	require.NoError(t, err)
+	assert.Empty(t, refundID)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+// TestReleaserReserveRefund_NotCaptured tests that a payment that was never captured is not refunded.
+func TestReleaserReserveRefund_NotCaptured(t *testing.T) {
+	releaser, client := newTestPaymentReleaser()
+
+	_, err := releaser.ReserveRefund(context.Background(), nil, database.Order{ID: "order1"},
+		database.Payment{ID: "pay1", Status: "pending", Provider: ProviderStripe}, "admin1", "", time.Now())
+
+	requireAppErrorCode(t, err, "invalid_status")
+	client.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
+
+// TestNewPaymentReleaser tests that the releaser has every payment provider registered.
+func TestNewPaymentReleaser(t *testing.T) {
+	releaser, ok := NewPaymentReleaser(nil, nil, "sk_test").(*paymentReleaser)
+	require.True(t, ok)
+	assert.Contains(t, releaser.service.providers, ProviderStripe)
+	assert.Contains(t, releaser.service.providers, ProviderManual)
+}
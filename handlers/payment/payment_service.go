@@ -5,23 +5,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"math"
-	"strconv"
-	"time"
-
 	"errors"
-
-	"github.com/stripe/stripe-go/v82"
-	"github.com/stripe/stripe-go/v82/paymentintent"
-	"github.com/stripe/stripe-go/v82/refund"
-	"github.com/stripe/stripe-go/v82/webhook"
+	"fmt"
+	"time"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
-// payment_service.go: Implements payment database query adapters, transaction handling, and Stripe client interface.
+// payment_service.go: Implements payment database query adapters, transaction handling, and the payment service.
 
 const (
 	orderStatusPending = "pending"
@@ -31,16 +26,41 @@ const (
 type PaymentDBQueries interface {
 	WithTx(tx PaymentDBTx) PaymentDBQueries
 	GetOrderByID(ctx context.Context, id string) (database.Order, error)
+	GetOrderByIDForUpdate(ctx context.Context, id string) (database.Order, error)
 	GetPaymentByOrderID(ctx context.Context, orderID string) (database.Payment, error)
 	GetPaymentByProviderPaymentID(ctx context.Context, providerPaymentID string) (database.Payment, error)
 	GetPaymentsByUserID(ctx context.Context, userID string) ([]database.Payment, error)
-	GetAllPayments(ctx context.Context) ([]database.Payment, error)
-	GetPaymentsByStatus(ctx context.Context, status string) ([]database.Payment, error)
+	GetAllPayments(ctx context.Context, params database.GetAllPaymentsParams) ([]database.Payment, error)
 	CreatePayment(ctx context.Context, params database.CreatePaymentParams) error
 	UpdatePaymentStatus(ctx context.Context, params database.UpdatePaymentStatusParams) error
 	UpdatePaymentStatusByID(ctx context.Context, params database.UpdatePaymentStatusByIDParams) error
 	UpdatePaymentStatusByProviderPaymentID(ctx context.Context, params database.UpdatePaymentStatusByProviderPaymentIDParams) error
 	UpdateOrderStatus(ctx context.Context, params database.UpdateOrderStatusParams) error
+	UpdateOrderRefundStatus(ctx context.Context, params database.UpdateOrderRefundStatusParams) error
+	CreateOrderStatusHistory(ctx context.Context, params database.CreateOrderStatusHistoryParams) error
+	CreateWebhookEvent(ctx context.Context, params database.CreateWebhookEventParams) (int64, error)
+	GetWebhookEventByID(ctx context.Context, id string) (database.WebhookEvent, error)
+	GetWebhookEventByIDForUpdate(ctx context.Context, id string) (database.WebhookEvent, error)
+	GetLatestProcessedWebhookEventTime(ctx context.Context, providerPaymentID string) (time.Time, error)
+	ListWebhookEventsByStatus(ctx context.Context, status string) ([]database.WebhookEvent, error)
+	UpdateWebhookEventResult(ctx context.Context, params database.UpdateWebhookEventResultParams) error
+	GetOrderItemsByOrderID(ctx context.Context, orderID string) ([]database.OrderItem, error)
+	IncrementProductStock(ctx context.Context, params database.IncrementProductStockParams) error
+	IncrementProductVariantStock(ctx context.Context, params database.IncrementProductVariantStockParams) error
+	CreateRefund(ctx context.Context, params database.CreateRefundParams) error
+	CreateRefundItem(ctx context.Context, params database.CreateRefundItemParams) error
+	GetRefundByID(ctx context.Context, id string) (database.Refund, error)
+	GetRefundByIDForUpdate(ctx context.Context, id string) (database.Refund, error)
+	UpdateRefundResult(ctx context.Context, params database.UpdateRefundResultParams) error
+	GetRefundedAmountByPaymentID(ctx context.Context, paymentID string) (string, error)
+	GetRefundedQuantitiesByOrderID(ctx context.Context, orderID string) ([]database.GetRefundedQuantitiesByOrderIDRow, error)
+	GetLatestOrderStatusChangeTime(ctx context.Context, orderID, toStatus string) (time.Time, error)
+	CreateRefundRequest(ctx context.Context, params database.CreateRefundRequestParams) error
+	GetPendingRefundRequestByOrderID(ctx context.Context, orderID string) (database.RefundRequest, error)
+	GetRefundRequestByIDForUpdate(ctx context.Context, id string) (database.RefundRequest, error)
+	ListRefundRequestsByStatus(ctx context.Context, status string) ([]database.RefundRequest, error)
+	UpdateRefundRequestRefundID(ctx context.Context, params database.UpdateRefundRequestRefundIDParams) error
+	UpdateRefundRequestReview(ctx context.Context, params database.UpdateRefundRequestReviewParams) error
 }
 
 // PaymentDBConn defines the interface for beginning database transactions for payment operations.
@@ -73,14 +93,19 @@ func (a *PaymentDBQueriesAdapter) GetOrderByID(ctx context.Context, id string) (
 	return a.Queries.GetOrderByID(ctx, id)
 }
 
+// GetOrderByIDForUpdate retrieves an order by its ID and locks the row until the transaction ends.
+func (a *PaymentDBQueriesAdapter) GetOrderByIDForUpdate(ctx context.Context, id string) (database.Order, error) {
+	return a.Queries.GetOrderByIDForUpdate(ctx, id)
+}
+
 // GetPaymentByOrderID retrieves a payment by its order ID.
 func (a *PaymentDBQueriesAdapter) GetPaymentByOrderID(ctx context.Context, orderID string) (database.Payment, error) {
 	return a.Queries.GetPaymentByOrderID(ctx, orderID)
 }
 
-// GetPaymentByProviderPaymentID is a placeholder and not implemented in the real adapter.
-func (a *PaymentDBQueriesAdapter) GetPaymentByProviderPaymentID(_ context.Context, _ string) (database.Payment, error) {
-	return database.Payment{}, errors.New("GetPaymentByProviderPaymentID not implemented in real adapter")
+// GetPaymentByProviderPaymentID retrieves a payment by its provider payment ID.
+func (a *PaymentDBQueriesAdapter) GetPaymentByProviderPaymentID(ctx context.Context, providerPaymentID string) (database.Payment, error) {
+	return a.Queries.GetPaymentByProviderPaymentID(ctx, utils.ToNullString(providerPaymentID))
 }
 
 // GetPaymentsByUserID retrieves all payments for a specific user.
@@ -88,14 +113,9 @@ func (a *PaymentDBQueriesAdapter) GetPaymentsByUserID(ctx context.Context, userI
 	return a.Queries.GetPaymentsByUserID(ctx, userID)
 }
 
-// GetAllPayments retrieves all payments from the database.
-func (a *PaymentDBQueriesAdapter) GetAllPayments(ctx context.Context) ([]database.Payment, error) {
-	return a.Queries.GetAllPayments(ctx)
-}
-
-// GetPaymentsByStatus retrieves all payments with a specific status.
-func (a *PaymentDBQueriesAdapter) GetPaymentsByStatus(ctx context.Context, status string) ([]database.Payment, error) {
-	return a.Queries.GetPaymentsByStatus(ctx, status)
+// GetAllPayments retrieves one page of payments from the database, optionally with a specific status.
+func (a *PaymentDBQueriesAdapter) GetAllPayments(ctx context.Context, params database.GetAllPaymentsParams) ([]database.Payment, error) {
+	return a.Queries.GetAllPayments(ctx, params)
 }
 
 // CreatePayment creates a new payment record in the database.
@@ -124,50 +144,151 @@ func (a *PaymentDBQueriesAdapter) UpdateOrderStatus(ctx context.Context, params
 	return a.Queries.UpdateOrderStatus(ctx, params)
 }
 
-// PaymentDBConnAdapter adapts a sql.DB to the PaymentDBConn interface.
-type PaymentDBConnAdapter struct {
-	*sql.DB
+// UpdateOrderRefundStatus updates the refund status of an order.
+func (a *PaymentDBQueriesAdapter) UpdateOrderRefundStatus(ctx context.Context, params database.UpdateOrderRefundStatusParams) error {
+	return a.Queries.UpdateOrderRefundStatus(ctx, params)
 }
 
-// BeginTx begins a new database transaction.
-func (a *PaymentDBConnAdapter) BeginTx(ctx context.Context, opts *sql.TxOptions) (PaymentDBTx, error) {
-	tx, err := a.DB.BeginTx(ctx, opts)
-	return tx, err
+// CreateOrderStatusHistory records an order status change.
+func (a *PaymentDBQueriesAdapter) CreateOrderStatusHistory(ctx context.Context, params database.CreateOrderStatusHistoryParams) error {
+	return a.Queries.CreateOrderStatusHistory(ctx, params)
+}
+
+// CreateWebhookEvent records a received webhook event. Returns 0 if the event was already recorded.
+func (a *PaymentDBQueriesAdapter) CreateWebhookEvent(ctx context.Context, params database.CreateWebhookEventParams) (int64, error) {
+	return a.Queries.CreateWebhookEvent(ctx, params)
+}
+
+// GetWebhookEventByID retrieves a webhook event by its provider event ID.
+func (a *PaymentDBQueriesAdapter) GetWebhookEventByID(ctx context.Context, id string) (database.WebhookEvent, error) {
+	return a.Queries.GetWebhookEventByID(ctx, id)
+}
+
+// GetWebhookEventByIDForUpdate retrieves a webhook event and locks the row until the transaction ends.
+func (a *PaymentDBQueriesAdapter) GetWebhookEventByIDForUpdate(ctx context.Context, id string) (database.WebhookEvent, error) {
+	return a.Queries.GetWebhookEventByIDForUpdate(ctx, id)
+}
+
+// GetLatestProcessedWebhookEventTime returns the creation time of the newest event applied to a payment.
+func (a *PaymentDBQueriesAdapter) GetLatestProcessedWebhookEventTime(ctx context.Context, providerPaymentID string) (time.Time, error) {
+	return a.Queries.GetLatestProcessedWebhookEventTime(ctx, utils.ToNullString(providerPaymentID))
+}
+
+// ListWebhookEventsByStatus retrieves all webhook events with a specific status.
+func (a *PaymentDBQueriesAdapter) ListWebhookEventsByStatus(ctx context.Context, status string) ([]database.WebhookEvent, error) {
+	return a.Queries.ListWebhookEventsByStatus(ctx, status)
+}
+
+// UpdateWebhookEventResult stores the outcome of a processing attempt and increments the attempt count.
+func (a *PaymentDBQueriesAdapter) UpdateWebhookEventResult(ctx context.Context, params database.UpdateWebhookEventResultParams) error {
+	return a.Queries.UpdateWebhookEventResult(ctx, params)
+}
+
+// GetOrderItemsByOrderID retrieves the items of an order.
+func (a *PaymentDBQueriesAdapter) GetOrderItemsByOrderID(ctx context.Context, orderID string) ([]database.OrderItem, error) {
+	return a.Queries.GetOrderItemsByOrderID(ctx, orderID)
+}
+
+// IncrementProductStock returns quantity to a product's stock.
+func (a *PaymentDBQueriesAdapter) IncrementProductStock(ctx context.Context, params database.IncrementProductStockParams) error {
+	return a.Queries.IncrementProductStock(ctx, params)
+}
+
+// IncrementProductVariantStock returns quantity to a product variant's stock.
+func (a *PaymentDBQueriesAdapter) IncrementProductVariantStock(ctx context.Context, params database.IncrementProductVariantStockParams) error {
+	return a.Queries.IncrementProductVariantStock(ctx, params)
 }
 
-// StripeClient abstracts Stripe operations for testability.
-// (If you use mockery, otherwise define a manual mock in tests)
-//
-//go:generate mockery --name=StripeClient --output=./mocks --case=underscore
-type StripeClient interface {
-	CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error)
-	GetPaymentIntent(id string) (*stripe.PaymentIntent, error)
-	CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error)
-	ParseWebhook(payload []byte, sigHeader, secret string) (stripe.Event, error)
+// CreateRefund records a refund against a payment.
+func (a *PaymentDBQueriesAdapter) CreateRefund(ctx context.Context, params database.CreateRefundParams) error {
+	return a.Queries.CreateRefund(ctx, params)
 }
 
-// realStripeClient implements StripeClient using the stripe-go SDK (used in production).
-type realStripeClient struct{}
+// CreateRefundItem records an order line included in a refund.
+func (a *PaymentDBQueriesAdapter) CreateRefundItem(ctx context.Context, params database.CreateRefundItemParams) error {
+	return a.Queries.CreateRefundItem(ctx, params)
+}
+
+// GetRefundByID retrieves a refund by its ID.
+func (a *PaymentDBQueriesAdapter) GetRefundByID(ctx context.Context, id string) (database.Refund, error) {
+	return a.Queries.GetRefundByID(ctx, id)
+}
 
-func (c *realStripeClient) CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
-	return paymentintent.New(params)
+// GetRefundByIDForUpdate retrieves a refund by its ID and locks the row until the transaction ends.
+func (a *PaymentDBQueriesAdapter) GetRefundByIDForUpdate(ctx context.Context, id string) (database.Refund, error) {
+	return a.Queries.GetRefundByIDForUpdate(ctx, id)
 }
-func (c *realStripeClient) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
-	return paymentintent.Get(id, nil)
+
+// UpdateRefundResult records the provider's answer to a refund.
+func (a *PaymentDBQueriesAdapter) UpdateRefundResult(ctx context.Context, params database.UpdateRefundResultParams) error {
+	return a.Queries.UpdateRefundResult(ctx, params)
 }
-func (c *realStripeClient) CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
-	return refund.New(params)
+
+// GetRefundedAmountByPaymentID returns the total of the pending and succeeded refunds of a payment as a decimal string.
+func (a *PaymentDBQueriesAdapter) GetRefundedAmountByPaymentID(ctx context.Context, paymentID string) (string, error) {
+	return a.Queries.GetRefundedAmountByPaymentID(ctx, paymentID)
 }
-func (c *realStripeClient) ParseWebhook(payload []byte, sigHeader, secret string) (stripe.Event, error) {
-	return webhook.ConstructEvent(payload, sigHeader, secret)
+
+// GetRefundedQuantitiesByOrderID returns the quantity already refunded for each order item of an order.
+func (a *PaymentDBQueriesAdapter) GetRefundedQuantitiesByOrderID(ctx context.Context, orderID string) ([]database.GetRefundedQuantitiesByOrderIDRow, error) {
+	return a.Queries.GetRefundedQuantitiesByOrderID(ctx, orderID)
+}
+
+// GetLatestOrderStatusChangeTime returns when an order last moved to the given status.
+func (a *PaymentDBQueriesAdapter) GetLatestOrderStatusChangeTime(ctx context.Context, orderID, toStatus string) (time.Time, error) {
+	return a.Queries.GetLatestOrderStatusChangeTime(ctx, database.GetLatestOrderStatusChangeTimeParams{
+		OrderID:  orderID,
+		ToStatus: toStatus,
+	})
+}
+
+// CreateRefundRequest records a customer's refund request.
+func (a *PaymentDBQueriesAdapter) CreateRefundRequest(ctx context.Context, params database.CreateRefundRequestParams) error {
+	return a.Queries.CreateRefundRequest(ctx, params)
+}
+
+// GetPendingRefundRequestByOrderID retrieves the pending refund request of an order.
+func (a *PaymentDBQueriesAdapter) GetPendingRefundRequestByOrderID(ctx context.Context, orderID string) (database.RefundRequest, error) {
+	return a.Queries.GetPendingRefundRequestByOrderID(ctx, orderID)
+}
+
+// GetRefundRequestByIDForUpdate retrieves a refund request and locks it for the rest of the transaction.
+func (a *PaymentDBQueriesAdapter) GetRefundRequestByIDForUpdate(ctx context.Context, id string) (database.RefundRequest, error) {
+	return a.Queries.GetRefundRequestByIDForUpdate(ctx, id)
+}
+
+// ListRefundRequestsByStatus retrieves refund requests with the given status, oldest first.
+func (a *PaymentDBQueriesAdapter) ListRefundRequestsByStatus(ctx context.Context, status string) ([]database.RefundRequest, error) {
+	return a.Queries.ListRefundRequestsByStatus(ctx, status)
+}
+
+// UpdateRefundRequestRefundID links a refund request to the refund issued for it.
+func (a *PaymentDBQueriesAdapter) UpdateRefundRequestRefundID(ctx context.Context, params database.UpdateRefundRequestRefundIDParams) error {
+	return a.Queries.UpdateRefundRequestRefundID(ctx, params)
+}
+
+// UpdateRefundRequestReview records the review of a refund request.
+func (a *PaymentDBQueriesAdapter) UpdateRefundRequestReview(ctx context.Context, params database.UpdateRefundRequestReviewParams) error {
+	return a.Queries.UpdateRefundRequestReview(ctx, params)
+}
+
+// PaymentDBConnAdapter adapts a sql.DB to the PaymentDBConn interface.
+type PaymentDBConnAdapter struct {
+	*sql.DB
+}
+
+// BeginTx begins a new database transaction.
+func (a *PaymentDBConnAdapter) BeginTx(ctx context.Context, opts *sql.TxOptions) (PaymentDBTx, error) {
+	tx, err := a.DB.BeginTx(ctx, opts)
+	return tx, err
 }
 
 // --- Service Implementation ---
 type paymentServiceImpl struct {
-	db     PaymentDBQueries
-	dbConn PaymentDBConn
-	apiKey string
-	stripe StripeClient
+	db             PaymentDBQueries
+	dbConn         PaymentDBConn
+	providers      map[string]PaymentProvider
+	refundPolicies []RefundPolicy
 }
 
 // PaymentService defines the business logic interface for payment operations.
@@ -175,24 +296,36 @@ type paymentServiceImpl struct {
 type PaymentService interface {
 	CreatePayment(ctx context.Context, params CreatePaymentParams) (*CreatePaymentResult, error)
 	ConfirmPayment(ctx context.Context, params ConfirmPaymentParams) (*ConfirmPaymentResult, error)
+	MarkPaymentReceived(ctx context.Context, params MarkPaymentReceivedParams) (*ConfirmPaymentResult, error)
 	GetPayment(ctx context.Context, orderID string, userID string) (*GetPaymentResult, error)
 	GetPaymentHistory(ctx context.Context, userID string) ([]PaymentHistoryItem, error)
-	GetAllPayments(ctx context.Context, status string) ([]PaymentHistoryItem, error)
-	RefundPayment(ctx context.Context, params RefundPaymentParams) error
+	GetAllPayments(ctx context.Context, status string, page pagination.Request) (pagination.Page[PaymentHistoryItem], error)
+	RequestRefund(ctx context.Context, params RefundPaymentParams) (*RefundRequestItem, error)
+	ListRefundRequests(ctx context.Context, status string) ([]RefundRequestItem, error)
+	ApproveRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundPaymentResult, error)
+	RejectRefundRequest(ctx context.Context, params ReviewRefundRequestParams) (*RefundRequestItem, error)
 	HandleWebhook(ctx context.Context, payload []byte, signature string, secret string) error
+	ListWebhookEvents(ctx context.Context, status string) ([]WebhookEventItem, error)
+	RetryWebhookEvent(ctx context.Context, eventID string) error
+	RetryRefund(ctx context.Context, refundID string) (*RefundPaymentResult, error)
 }
 
 // CreatePaymentParams contains parameters for creating a payment.
+// Provider selects the payment provider; Stripe is used when it is empty.
 type CreatePaymentParams struct {
 	OrderID  string
 	UserID   string
 	Currency string
+	Provider string
 }
 
 // CreatePaymentResult represents the result of creating a payment.
+// ClientSecret is empty for providers that need no client-side confirmation.
 type CreatePaymentResult struct {
-	PaymentID    string
-	ClientSecret string
+	PaymentID         string
+	Provider          string
+	ProviderPaymentID string
+	ClientSecret      string
 }
 
 // ConfirmPaymentParams represents the parameters for confirming a payment.
@@ -201,6 +334,13 @@ type ConfirmPaymentParams struct {
 	UserID  string
 }
 
+// MarkPaymentReceivedParams represents an admin recording that a manual payment was received.
+type MarkPaymentReceivedParams struct {
+	OrderID string
+	AdminID string
+	Note    string
+}
+
 // ConfirmPaymentResult represents the result of confirming a payment.
 type ConfirmPaymentResult struct {
 	Status string
@@ -208,45 +348,122 @@ type ConfirmPaymentResult struct {
 
 // GetPaymentResult represents the result of retrieving a payment.
 type GetPaymentResult struct {
-	ID                string    `json:"id"`
-	OrderID           string    `json:"order_id"`
-	UserID            string    `json:"user_id"`
-	Amount            float64   `json:"amount"`
-	Currency          string    `json:"currency"`
-	Status            string    `json:"status"`
-	Provider          string    `json:"provider"`
-	ProviderPaymentID string    `json:"provider_payment_id"`
-	CreatedAt         time.Time `json:"created_at"`
-}
-
-// RefundPaymentParams represents the parameters for refunding a payment.
+	ID                string       `json:"id"`
+	OrderID           string       `json:"order_id"`
+	UserID            string       `json:"user_id"`
+	Amount            money.Amount `json:"amount"`
+	Currency          string       `json:"currency"`
+	Status            string       `json:"status"`
+	Provider          string       `json:"provider"`
+	ProviderPaymentID string       `json:"provider_payment_id"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
+
+// WebhookEventItem represents a recorded webhook event in admin listings.
+type WebhookEventItem struct {
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	Status            string          `json:"status"`
+	ProviderPaymentID string          `json:"provider_payment_id,omitempty"`
+	LastError         string          `json:"last_error,omitempty"`
+	Attempts          int32           `json:"attempts"`
+	Payload           json.RawMessage `json:"payload"`
+	EventCreatedAt    time.Time       `json:"event_created_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// RefundPaymentParams represents a customer's request to refund a payment.
+// Either Amount or Items may be set; when neither is, the remaining refundable balance is requested.
 type RefundPaymentParams struct {
 	OrderID string
 	UserID  string
+	Amount  string
+	Items   []RefundItemInput
+	Reason  string
+}
+
+// RefundItemInput is an order line and quantity to refund.
+type RefundItemInput struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int32  `json:"quantity"`
+}
+
+// RefundPaymentResult represents the result of refunding a payment.
+// Status is the refund's own status, which stays pending while the provider processes it.
+type RefundPaymentResult struct {
+	RefundID          string       `json:"refund_id"`
+	Amount            money.Amount `json:"amount"`
+	Status            string       `json:"status"`
+	PaymentStatus     string       `json:"payment_status"`
+	OrderRefundStatus string       `json:"order_refund_status"`
+}
+
+// ReviewRefundRequestParams represents an admin's decision on a refund request.
+type ReviewRefundRequestParams struct {
+	RequestID string
+	AdminID   string
+	Note      string
+}
+
+// RefundRequestItem represents a refund request and its review.
+type RefundRequestItem struct {
+	ID         string            `json:"id"`
+	OrderID    string            `json:"order_id"`
+	PaymentID  string            `json:"payment_id"`
+	UserID     string            `json:"user_id"`
+	Amount     money.Amount      `json:"amount"`
+	Currency   string            `json:"currency"`
+	Items      []RefundItemInput `json:"items,omitempty"`
+	Reason     string            `json:"reason"`
+	Status     string            `json:"status"`
+	RefundID   string            `json:"refund_id,omitempty"`
+	ReviewedBy string            `json:"reviewed_by,omitempty"`
+	ReviewNote string            `json:"review_note,omitempty"`
+	ReviewedAt *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
 }
 
 // NewPaymentService creates a new PaymentService with the provided database query and connection adapters.
+// Refund requests are checked against the given policies, or DefaultRefundPolicies when none are given.
 // Returns a PaymentService implementation.
-func NewPaymentService(db *database.Queries, dbConn *sql.DB, apiKey string) PaymentService {
+func NewPaymentService(db *database.Queries, dbConn *sql.DB, apiKey string, policies ...RefundPolicy) PaymentService {
+	if len(policies) == 0 {
+		policies = DefaultRefundPolicies()
+	}
 	return &paymentServiceImpl{
-		db:     &PaymentDBQueriesAdapter{db},
-		dbConn: &PaymentDBConnAdapter{dbConn},
-		apiKey: apiKey,
-		stripe: &realStripeClient{}, // use real client by default
+		db:             &PaymentDBQueriesAdapter{db},
+		dbConn:         &PaymentDBConnAdapter{dbConn},
+		providers:      newPaymentProviders(apiKey, &realStripeClient{}), // use real Stripe client by default
+		refundPolicies: policies,
 	}
 }
 
 // CreatePayment creates a new payment intent and records it in the database.
-// Validates the request, creates a Stripe payment intent, and records the payment in a transaction.
+// Validates the request, starts the payment with the selected provider, and records the payment in a transaction.
 func (s *paymentServiceImpl) CreatePayment(ctx context.Context, params CreatePaymentParams) (*CreatePaymentResult, error) {
 	if params.OrderID == "" || params.UserID == "" || params.Currency == "" {
 		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
 	}
 
-	// Validate currency
+	// Validate currency; order totals are kept in the store currency, so payments must be made in it too
 	if !s.isValidCurrency(params.Currency) {
 		return nil, &handlers.AppError{Code: "invalid_currency", Message: "Unsupported currency"}
 	}
+	if money.Currency(params.Currency) != money.DefaultCurrency {
+		return nil, &handlers.AppError{
+			Code:    "currency_mismatch",
+			Message: fmt.Sprintf("Orders are charged in %s", money.DefaultCurrency),
+		}
+	}
+
+	if params.Provider == "" {
+		params.Provider = ProviderStripe
+	}
+	provider, err := s.provider(params.Provider)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get order and validate ownership
 	order, err := s.db.GetOrderByID(ctx, params.OrderID)
@@ -266,29 +483,22 @@ func (s *paymentServiceImpl) CreatePayment(ctx context.Context, params CreatePay
 		return nil, &handlers.AppError{Code: "payment_exists", Message: "Payment already exists for this order"}
 	}
 
-	// Parse amount with proper precision
-	totalAmount, err := strconv.ParseFloat(order.TotalAmount, 64)
+	// Parse the total exactly in the order currency's minor unit (cents for USD, yen for JPY)
+	amount, err := money.Parse(order.TotalAmount, money.DefaultCurrency)
 	if err != nil {
 		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid total amount", Err: err}
 	}
 
-	// Convert to smallest currency unit (cents for USD, etc.) with proper rounding
-	amountInSmallestUnit := int64(math.Round(totalAmount * 100))
-
-	// Create Stripe payment intent
-	stripe.Key = s.apiKey
-	metadata := map[string]string{
-		"order_id": order.ID,
-		"user_id":  params.UserID,
-	}
-	stripeParams := &stripe.PaymentIntentParams{
-		Amount:   stripe.Int64(amountInSmallestUnit),
-		Currency: stripe.String(params.Currency),
-		Metadata: metadata,
-	}
-	intent, err := s.stripe.CreatePaymentIntent(stripeParams)
+	// Start the payment with the provider
+	intent, err := provider.CreateIntent(ctx, ProviderIntentParams{
+		Amount: amount,
+		Metadata: map[string]string{
+			"order_id": order.ID,
+			"user_id":  params.UserID,
+		},
+	})
 	if err != nil {
-		return nil, &handlers.AppError{Code: "stripe_error", Message: "Failed to create payment intent", Err: err}
+		return nil, err
 	}
 
 	// Record payment in database
@@ -310,10 +520,10 @@ func (s *paymentServiceImpl) CreatePayment(ctx context.Context, params CreatePay
 		ID:                paymentID,
 		OrderID:           order.ID,
 		UserID:            params.UserID,
-		Amount:            order.TotalAmount,
+		Amount:            amount.String(),
 		Currency:          params.Currency,
-		Status:            "created",
-		Provider:          "stripe",
+		Status:            "pending",
+		Provider:          provider.Name(),
 		ProviderPaymentID: utils.ToNullString(intent.ID),
 		CreatedAt:         timeNow,
 		UpdatedAt:         timeNow,
@@ -327,22 +537,20 @@ func (s *paymentServiceImpl) CreatePayment(ctx context.Context, params CreatePay
 	}
 
 	return &CreatePaymentResult{
-		PaymentID:    paymentID,
-		ClientSecret: intent.ClientSecret,
+		PaymentID:         paymentID,
+		Provider:          provider.Name(),
+		ProviderPaymentID: intent.ID,
+		ClientSecret:      intent.ClientSecret,
 	}, nil
 }
 
 // isValidCurrency checks if the currency is supported.
 func (s *paymentServiceImpl) isValidCurrency(currency string) bool {
-	valid := map[string]struct{}{
-		"USD": {}, "EUR": {}, "GBP": {}, "JPY": {}, "CAD": {}, "AUD": {}, "CHF": {}, "CNY": {}, "SEK": {}, "NZD": {},
-	}
-	_, ok := valid[currency]
-	return ok
+	return money.Currency(currency).IsSupported()
 }
 
 // ConfirmPayment confirms a payment and updates its status.
-// Validates the payment, checks Stripe status, and updates both payment and order status in a transaction.
+// Validates the payment, checks its status with the provider, and updates both payment and order status in a transaction.
 func (s *paymentServiceImpl) ConfirmPayment(ctx context.Context, params ConfirmPaymentParams) (*ConfirmPaymentResult, error) {
 	if params.OrderID == "" || params.UserID == "" {
 		return nil, &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
@@ -357,42 +565,18 @@ func (s *paymentServiceImpl) ConfirmPayment(ctx context.Context, params ConfirmP
 		return nil, &handlers.AppError{Code: "unauthorized", Message: "Payment does not belong to user"}
 	}
 
-	// Get payment intent from Stripe
+	// Get payment status from the provider
 	if !payment.ProviderPaymentID.Valid {
 		return nil, &handlers.AppError{Code: "invalid_payment", Message: "Missing provider payment ID"}
 	}
 
-	stripe.Key = s.apiKey
-	pi, err := s.stripe.GetPaymentIntent(payment.ProviderPaymentID.String)
+	provider, err := s.provider(payment.Provider)
 	if err != nil {
-		return nil, &handlers.AppError{Code: "stripe_error", Message: "Failed to fetch payment intent", Err: err}
+		return nil, err
 	}
-
-	// Check for refunds first
-	hasRefund := false
-	// No-op: refund status is handled by webhook events
-
-	// Determine new status
-	var newStatus string
-	switch pi.Status {
-	case stripe.PaymentIntentStatusSucceeded:
-		if hasRefund {
-			newStatus = "refunded"
-		} else {
-			newStatus = "succeeded"
-		}
-	case stripe.PaymentIntentStatusCanceled:
-		newStatus = "cancelled"
-	case stripe.PaymentIntentStatusRequiresPaymentMethod, stripe.PaymentIntentStatusRequiresConfirmation:
-		newStatus = orderStatusPending
-	case stripe.PaymentIntentStatusRequiresAction:
-		newStatus = orderStatusPending
-	case stripe.PaymentIntentStatusRequiresCapture:
-		newStatus = orderStatusPending
-	case stripe.PaymentIntentStatusProcessing:
-		newStatus = orderStatusPending
-	default:
-		newStatus = "failed"
+	newStatus, err := provider.GetPaymentStatus(ctx, payment.ProviderPaymentID.String)
+	if err != nil {
+		return nil, err
 	}
 
 	// Update payment and order status
@@ -457,7 +641,7 @@ func (s *paymentServiceImpl) GetPayment(ctx context.Context, orderID string, use
 		return nil, &handlers.AppError{Code: "unauthorized", Message: "Payment does not belong to user"}
 	}
 
-	amount, err := strconv.ParseFloat(payment.Amount, 64)
+	amount, err := money.Parse(payment.Amount, money.Currency(payment.Currency))
 	if err != nil {
 		return nil, &handlers.AppError{Code: "invalid_amount", Message: "Invalid payment amount", Err: err}
 	}
@@ -504,25 +688,52 @@ func (s *paymentServiceImpl) GetPaymentHistory(ctx context.Context, userID strin
 	return result, nil
 }
 
-// GetAllPayments retrieves all payments with optional status filter.
-// Returns a list of payment history items or an error.
-func (s *paymentServiceImpl) GetAllPayments(ctx context.Context, status string) ([]PaymentHistoryItem, error) {
-	var payments []database.Payment
-	var err error
+// paymentSortOptions lists the accepted payment list sorts; empty means newest first.
+var paymentSortOptions = map[string]bool{"": true, "newest": true, "oldest": true}
 
-	if status == "all" {
-		payments, err = s.db.GetAllPayments(ctx)
-	} else {
-		payments, err = s.db.GetPaymentsByStatus(ctx, status)
+// GetAllPayments retrieves one page of payments with optional status filter ("all" for every status).
+// Returns the page of payment history items, with a cursor for the next one, or an error.
+func (s *paymentServiceImpl) GetAllPayments(ctx context.Context, status string, page pagination.Request) (pagination.Page[PaymentHistoryItem], error) {
+	if !paymentSortOptions[page.Sort] {
+		return pagination.Page[PaymentHistoryItem]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid sort option"}
+	}
+	sort := page.Sort
+	if sort == "" {
+		sort = "newest"
+	}
+	cursor, err := pagination.Decode(page.Cursor, sort)
+	if err != nil {
+		return pagination.Page[PaymentHistoryItem]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid cursor", Err: err}
+	}
+
+	var statusFilter sql.NullString
+	if status != "all" {
+		statusFilter = sql.NullString{String: status, Valid: true}
 	}
 
+	limit := pagination.NormalizeLimit(page.Limit)
+	payments, err := s.db.GetAllPayments(ctx, database.GetAllPaymentsParams{
+		Status:          statusFilter,
+		CursorID:        cursor.IDArg(),
+		Sort:            sort,
+		CursorCreatedAt: cursor.CreatedAtArg(),
+		Limit:           pagination.FetchLimit(limit),
+	})
 	if err != nil {
-		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch payments", Err: err}
+		return pagination.Page[PaymentHistoryItem]{}, &handlers.AppError{Code: "database_error", Message: "Failed to fetch payments", Err: err}
 	}
 
-	result := make([]PaymentHistoryItem, 0, len(payments))
-	for _, p := range payments {
-		result = append(result, PaymentHistoryItem{
+	paymentPage := pagination.NewPage(payments, limit, func(p database.Payment) pagination.Cursor {
+		return pagination.Cursor{Sort: sort, CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	result := pagination.Page[PaymentHistoryItem]{
+		Data:       make([]PaymentHistoryItem, 0, len(paymentPage.Data)),
+		Limit:      paymentPage.Limit,
+		NextCursor: paymentPage.NextCursor,
+		HasNext:    paymentPage.HasNext,
+	}
+	for _, p := range paymentPage.Data {
+		result.Data = append(result.Data, PaymentHistoryItem{
 			ID:                p.ID,
 			OrderID:           p.OrderID,
 			Amount:            p.Amount,
@@ -537,162 +748,102 @@ func (s *paymentServiceImpl) GetAllPayments(ctx context.Context, status string)
 	return result, nil
 }
 
-// RefundPayment processes a refund for a payment.
-// Validates the payment, processes the refund with Stripe, and updates both payment and order status in a transaction.
-func (s *paymentServiceImpl) RefundPayment(ctx context.Context, params RefundPaymentParams) error {
-	if params.OrderID == "" || params.UserID == "" {
-		return &handlers.AppError{Code: "invalid_request", Message: "Missing required fields"}
-	}
+// PaymentError represents payment-specific errors.
+type PaymentError = handlers.AppError
 
-	// Get payment and validate ownership
-	payment, err := s.db.GetPaymentByOrderID(ctx, params.OrderID)
+// HandleWebhook processes Stripe webhook events.
+// Validates the webhook signature, records the event in the webhook event log and applies it once.
+// Redelivered events that were already processed or skipped are acknowledged without changes.
+// A malformed event object is still recorded; it fails when processed and shows up in the failed list.
+func (s *paymentServiceImpl) HandleWebhook(ctx context.Context, payload []byte, signature string, secret string) error {
+	provider, err := s.provider(ProviderStripe)
 	if err != nil {
-		return &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+		return err
 	}
-	if payment.UserID != params.UserID {
-		return &handlers.AppError{Code: "unauthorized", Message: "Payment does not belong to user"}
-	}
-
-	if payment.Status != "succeeded" {
-		return &handlers.AppError{Code: "invalid_status", Message: "Payment cannot be refunded"}
-	}
-
-	if !payment.ProviderPaymentID.Valid {
-		return &handlers.AppError{Code: "invalid_payment", Message: "Missing provider payment ID"}
-	}
-
-	// Process refund with Stripe
-	refundParams := &stripe.RefundParams{
-		PaymentIntent: stripe.String(payment.ProviderPaymentID.String),
-	}
-	_, err = s.stripe.CreateRefund(refundParams)
+	event, err := provider.ParseWebhook(payload, signature, secret)
 	if err != nil {
-		return &handlers.AppError{Code: "stripe_error", Message: "Failed to process refund", Err: err}
+		return err
 	}
 
-	// Update payment and order status
 	timeNow := time.Now().UTC()
 
-	tx, err := s.dbConn.BeginTx(ctx, nil)
+	_, err = s.db.CreateWebhookEvent(ctx, database.CreateWebhookEventParams{
+		ID:                event.ID,
+		Type:              event.Type,
+		Payload:           payload,
+		ProviderPaymentID: utils.ToNullString(event.ProviderPaymentID),
+		EventCreatedAt:    event.CreatedAt,
+		CreatedAt:         timeNow,
+		UpdatedAt:         timeNow,
+	})
 	if err != nil {
-		return &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+		return &handlers.AppError{Code: "database_error", Message: "Failed to record webhook event", Err: err}
 	}
-	defer func() {
-		// Log error but don't return it since we're in defer
-		_ = tx.Rollback()
-	}()
 
-	queries := s.db.WithTx(tx)
+	return s.processWebhookEvent(ctx, event, timeNow)
+}
 
-	err = queries.UpdatePaymentStatusByID(ctx, database.UpdatePaymentStatusByIDParams{
-		ID:     payment.ID,
-		Status: "refunded",
-	})
-	if err != nil {
-		return &handlers.AppError{Code: "database_error", Message: "Failed to update payment status", Err: err}
+// ListWebhookEvents retrieves recorded webhook events with the given status, most recently updated first.
+// An empty status lists failed events.
+func (s *paymentServiceImpl) ListWebhookEvents(ctx context.Context, status string) ([]WebhookEventItem, error) {
+	if status == "" {
+		status = webhookEventFailed
 	}
-
-	err = queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
-		ID:        payment.OrderID,
-		Status:    "cancelled",
-		UpdatedAt: timeNow,
-	})
-	if err != nil {
-		return &handlers.AppError{Code: "database_error", Message: "Failed to update order status", Err: err}
+	if !isValidWebhookEventStatus(status) {
+		return nil, &handlers.AppError{Code: "invalid_status", Message: "Invalid webhook event status"}
 	}
 
-	if err = tx.Commit(); err != nil {
-		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	events, err := s.db.ListWebhookEventsByStatus(ctx, status)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "database_error", Message: "Failed to fetch webhook events", Err: err}
+	}
+
+	result := make([]WebhookEventItem, 0, len(events))
+	for _, e := range events {
+		result = append(result, WebhookEventItem{
+			ID:                e.ID,
+			Type:              e.Type,
+			Status:            e.Status,
+			ProviderPaymentID: e.ProviderPaymentID.String,
+			LastError:         e.LastError.String,
+			Attempts:          e.Attempts,
+			Payload:           e.Payload,
+			EventCreatedAt:    e.EventCreatedAt,
+			CreatedAt:         e.CreatedAt,
+			UpdatedAt:         e.UpdatedAt,
+		})
 	}
 
-	return nil
+	return result, nil
 }
 
-// PaymentError represents payment-specific errors.
-type PaymentError = handlers.AppError
-
-// HandleWebhook processes Stripe webhook events.
-// Validates the webhook signature, processes different event types, and updates payment statuses in a transaction.
-func (s *paymentServiceImpl) HandleWebhook(ctx context.Context, payload []byte, signature string, secret string) error {
-	stripe.Key = s.apiKey
-	event, err := s.stripe.ParseWebhook(payload, signature, secret)
-	if err != nil {
-		return &handlers.AppError{Code: "webhook_error", Message: "Signature verification failed", Err: err}
+// RetryWebhookEvent re-runs a failed webhook event from its stored payload.
+// The signature was verified when the event was received, so it is not checked again.
+func (s *paymentServiceImpl) RetryWebhookEvent(ctx context.Context, eventID string) error {
+	if eventID == "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "Event ID is required"}
 	}
 
-	tx, err := s.dbConn.BeginTx(ctx, nil)
+	stored, err := s.db.GetWebhookEventByID(ctx, eventID)
 	if err != nil {
-		return &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
-	}
-	defer func() {
-		// Log error but don't return it since we're in defer
-		_ = tx.Rollback()
-	}()
-
-	queries := s.db.WithTx(tx)
-
-	switch event.Type {
-	case "payment_intent.succeeded":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
-			return &handlers.AppError{Code: "webhook_error", Message: "Bad payment intent", Err: err}
-		}
-		if _, err := s.db.GetPaymentByProviderPaymentID(ctx, pi.ID); err != nil {
-			return &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
-		}
-		err = queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
-			Status:            "succeeded",
-			ProviderPaymentID: utils.ToNullString(pi.ID),
-		})
-		if err != nil {
-			return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "webhook_event_not_found", Message: "Webhook event not found", Err: err}
 		}
-
-	case "payment_intent.payment_failed":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
-			return &handlers.AppError{Code: "webhook_error", Message: "Bad payment intent", Err: err}
-		}
-		err = queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
-			Status:            "failed",
-			ProviderPaymentID: utils.ToNullString(pi.ID),
-		})
-		if err != nil {
-			return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
-		}
-
-	case "payment_intent.canceled":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
-			return &handlers.AppError{Code: "webhook_error", Message: "Bad payment intent", Err: err}
-		}
-		err = queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
-			Status:            "cancelled",
-			ProviderPaymentID: utils.ToNullString(pi.ID),
-		})
-		if err != nil {
-			return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
-		}
-
-	case "charge.refunded":
-		var charge stripe.Charge
-		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
-			return &handlers.AppError{Code: "webhook_error", Message: "Bad charge", Err: err}
-		}
-		// Find payment by charge ID and update status
-		err = queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
-			Status:            "refunded",
-			ProviderPaymentID: utils.ToNullString(charge.PaymentIntent.ID),
-		})
-		if err != nil {
-			return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
-		}
-
+		return &handlers.AppError{Code: "database_error", Message: "Failed to fetch webhook event", Err: err}
+	}
+	if stored.Status != webhookEventFailed {
+		return &handlers.AppError{Code: "webhook_event_not_failed", Message: "Only failed webhook events can be re-run"}
 	}
 
-	if err = tx.Commit(); err != nil {
-		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	// Only Stripe delivers webhooks, so stored events are Stripe events
+	provider, err := s.provider(ProviderStripe)
+	if err != nil {
+		return err
+	}
+	event, err := provider.DecodeWebhookEvent(stored.Payload)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return s.processWebhookEvent(ctx, event, time.Now().UTC())
 }
@@ -14,6 +14,8 @@ import (
 	"github.com/stripe/stripe-go/v82"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -64,7 +66,7 @@ func TestCreatePayment_InvalidCurrency(t *testing.T) {
 // TestCreatePayment_OrderNotFound tests when order doesn't exist.
 func TestCreatePayment_OrderNotFound(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil, providers: newPaymentProviders("sk_test_123", nil)}
 	params := CreatePaymentParams{
 		OrderID:  "nonexistent",
 		UserID:   "user123",
@@ -82,7 +84,7 @@ func TestCreatePayment_OrderNotFound(t *testing.T) {
 // Helper for CreatePayment error scenarios
 func runCreatePaymentOrderErrorTest(t *testing.T, order database.Order, params CreatePaymentParams, expectedMsg string) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil, providers: newPaymentProviders("sk_test_123", nil)}
 	mockDB.On("GetOrderByID", mock.Anything, params.OrderID).Return(order, nil)
 
 	_, err := service.CreatePayment(context.Background(), params)
@@ -122,7 +124,7 @@ func TestCreatePayment_InvalidOrderStatus(t *testing.T) {
 // TestCreatePayment_PaymentExists tests when payment already exists for order.
 func TestCreatePayment_PaymentExists(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil, providers: newPaymentProviders("sk_test_123", nil)}
 	params := CreatePaymentParams{
 		OrderID:  "order123",
 		UserID:   "user123",
@@ -302,17 +304,66 @@ func TestGetAllPayments_Success(t *testing.T) {
 		},
 	}
 
-	mockDB.On("GetPaymentsByStatus", mock.Anything, "succeeded").Return(payments, nil)
+	mockDB.On("GetAllPayments", mock.Anything, database.GetAllPaymentsParams{
+		Status: sql.NullString{String: "succeeded", Valid: true},
+		Sort:   "newest",
+		Limit:  pagination.DefaultLimit + 1,
+	}).Return(payments, nil)
 
-	result, err := service.GetAllPayments(context.Background(), "succeeded")
+	result, err := service.GetAllPayments(context.Background(), "succeeded", pagination.Request{})
 	require.NoError(t, err)
-	assert.Len(t, result, 1)
-	assert.Equal(t, "payment1", result[0].ID)
+	assert.Len(t, result.Data, 1)
+	assert.Equal(t, "payment1", result.Data[0].ID)
+	assert.False(t, result.HasNext)
 	mockDB.AssertExpectations(t)
 }
 
-// TestRefundPayment_InvalidRequest tests validation of required fields.
-func TestRefundPayment_InvalidRequest(t *testing.T) {
+// TestGetAllPayments_Cursor tests that a full page returns a cursor and that the cursor reaches the query for the next page.
+func TestGetAllPayments_Cursor(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
+	older := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	mockDB.On("GetAllPayments", mock.Anything, database.GetAllPaymentsParams{Sort: "oldest", Limit: 2}).
+		Return([]database.Payment{{ID: "payment1", CreatedAt: older}, {ID: "payment2", CreatedAt: newer}}, nil).Once()
+
+	first, err := service.GetAllPayments(context.Background(), "all", pagination.Request{Sort: "oldest", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first.Data, 1)
+	assert.Equal(t, "payment1", first.Data[0].ID)
+	require.True(t, first.HasNext)
+
+	mockDB.On("GetAllPayments", mock.Anything, database.GetAllPaymentsParams{
+		CursorID:        sql.NullString{String: "payment1", Valid: true},
+		Sort:            "oldest",
+		CursorCreatedAt: sql.NullTime{Time: older, Valid: true},
+		Limit:           2,
+	}).Return([]database.Payment{{ID: "payment2", CreatedAt: newer}}, nil).Once()
+
+	second, err := service.GetAllPayments(context.Background(), "all", pagination.Request{Sort: "oldest", Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.Data, 1)
+	assert.Equal(t, "payment2", second.Data[0].ID)
+	assert.False(t, second.HasNext)
+	mockDB.AssertExpectations(t)
+}
+
+// TestGetAllPayments_InvalidPage tests that unknown sorts and cursors issued for another sort are rejected.
+func TestGetAllPayments_InvalidPage(t *testing.T) {
+	service := &paymentServiceImpl{db: new(mockPaymentDBQueries), dbConn: nil}
+	pages := []pagination.Request{
+		{Sort: "amount_desc"},
+		{Cursor: pagination.Cursor{Sort: "oldest", ID: "payment1"}.Encode()},
+	}
+	for _, page := range pages {
+		_, err := service.GetAllPayments(context.Background(), "all", page)
+		requireAppErrorCode(t, err, "invalid_request")
+	}
+}
+
+// TestRequestRefund_InvalidRequest tests validation of required fields.
+func TestRequestRefund_InvalidRequest(t *testing.T) {
 	service := &paymentServiceImpl{db: nil, dbConn: nil}
 
 	tests := []struct {
@@ -325,37 +376,39 @@ func TestRefundPayment_InvalidRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.RefundPayment(context.Background(), tt.params)
+			_, err := service.RequestRefund(context.Background(), tt.params)
 			require.Error(t, err)
 			assert.Contains(t, err.Error(), "Missing required fields")
 		})
 	}
 }
 
-// TestRefundPayment_PaymentNotFound tests when payment doesn't exist.
-func TestRefundPayment_PaymentNotFound(t *testing.T) {
+// TestRequestRefund_PaymentNotFound tests when payment doesn't exist.
+func TestRequestRefund_PaymentNotFound(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	params := RefundPaymentParams{
 		OrderID: "order123",
 		UserID:  "user123",
+		Reason:  "Damaged",
 	}
 
 	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{}, sql.ErrNoRows)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := service.RequestRefund(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Payment not found")
 	mockDB.AssertExpectations(t)
 }
 
-// TestRefundPayment_UnauthorizedPayment tests when payment doesn't belong to user.
-func TestRefundPayment_UnauthorizedPayment(t *testing.T) {
+// TestRequestRefund_UnauthorizedPayment tests when payment doesn't belong to user.
+func TestRequestRefund_UnauthorizedPayment(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	params := RefundPaymentParams{
 		OrderID: "order123",
 		UserID:  "user123",
+		Reason:  "Damaged",
 	}
 
 	payment := database.Payment{
@@ -366,19 +419,20 @@ func TestRefundPayment_UnauthorizedPayment(t *testing.T) {
 
 	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := service.RequestRefund(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Payment does not belong to user")
 	mockDB.AssertExpectations(t)
 }
 
-// TestRefundPayment_InvalidStatus tests when payment status is not refundable.
-func TestRefundPayment_InvalidStatus(t *testing.T) {
+// TestRequestRefund_InvalidStatus tests when payment status is not refundable.
+func TestRequestRefund_InvalidStatus(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	params := RefundPaymentParams{
 		OrderID: "order123",
 		UserID:  "user123",
+		Reason:  "Damaged",
 	}
 
 	payment := database.Payment{
@@ -390,7 +444,7 @@ func TestRefundPayment_InvalidStatus(t *testing.T) {
 
 	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := service.RequestRefund(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Payment cannot be refunded")
 	mockDB.AssertExpectations(t)
@@ -401,10 +455,9 @@ func TestHandleWebhook_InvalidSignature(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockStripe := new(mockStripeClient)
 	service := &paymentServiceImpl{
-		db:     mockDB,
-		dbConn: nil,
-		apiKey: "test_key",
-		stripe: mockStripe,
+		db:        mockDB,
+		dbConn:    nil,
+		providers: newPaymentProviders("test_key", mockStripe),
 	}
 
 	payload := []byte(`{"type":"payment_intent.succeeded"}`)
@@ -474,12 +527,7 @@ func TestPaymentDBAdapters_Coverage(t *testing.T) {
 			})
 
 			assert.Panics(t, func() {
-				_, err := adapter.GetAllPayments(ctx)
-				_ = err
-			})
-
-			assert.Panics(t, func() {
-				_, err := adapter.GetPaymentsByStatus(ctx, "pending")
+				_, err := adapter.GetAllPayments(ctx, database.GetAllPaymentsParams{})
 				_ = err
 			})
 
@@ -554,7 +602,7 @@ func TestCreatePayment_Success(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := CreatePaymentParams{
 		OrderID:  "order123",
@@ -590,6 +638,8 @@ func TestCreatePayment_Success(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.NotEmpty(t, result.PaymentID)
 	assert.NotEmpty(t, result.ClientSecret)
+	assert.Equal(t, ProviderStripe, result.Provider)
+	assert.Equal(t, "pi_test_123", result.ProviderPaymentID)
 
 	mockDB.AssertExpectations(t)
 	mockDBConn.AssertExpectations(t)
@@ -597,10 +647,68 @@ func TestCreatePayment_Success(t *testing.T) {
 	mockStripe.AssertExpectations(t)
 }
 
+// TestCreatePayment_StripeMinorUnits tests that the order total is sent to Stripe in exact minor units of the order currency
+func TestCreatePayment_StripeMinorUnits(t *testing.T) {
+	cases := []struct {
+		name     string
+		currency string
+		total    string
+		minor    int64
+		stored   string
+	}{
+		// 19.99 * 100 is 1998.9999999999998 in float64
+		{name: "USD cents", currency: "USD", total: "19.99", minor: 1999, stored: "19.99"},
+		{name: "USD large", currency: "USD", total: "99999999.99", minor: 9999999999, stored: "99999999.99"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(mockPaymentDBQueries)
+			mockDBConn := new(mockPaymentDBConn)
+			mockTx := new(mockPaymentDBTx)
+			mockStripe := new(mockStripeClient)
+			service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+			order := database.Order{ID: "order123", UserID: "user123", Status: "pending", TotalAmount: tc.total}
+			mockDB.On("GetOrderByID", mock.Anything, "order123").Return(order, nil)
+			mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(database.Payment{}, sql.ErrNoRows)
+			mockStripe.On("CreatePaymentIntent", mock.MatchedBy(func(p *stripe.PaymentIntentParams) bool {
+				return *p.Amount == tc.minor && *p.Currency == tc.currency
+			})).Return(&stripe.PaymentIntent{ID: "pi_test_123", ClientSecret: "secret"}, nil)
+			mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+			mockDB.On("WithTx", mockTx).Return(mockDB)
+			mockDB.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p database.CreatePaymentParams) bool {
+				return p.Amount == tc.stored
+			})).Return(nil)
+			mockTx.On("Commit").Return(nil)
+			mockTx.On("Rollback").Return(nil)
+
+			_, err := service.CreatePayment(context.Background(), CreatePaymentParams{OrderID: "order123", UserID: "user123", Currency: tc.currency})
+			require.NoError(t, err)
+			mockStripe.AssertExpectations(t)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCreatePayment_CurrencyMismatch tests that a payment in another currency than the order's is rejected before the order
+// total is read, so a total is never reinterpreted in the wrong currency
+func TestCreatePayment_CurrencyMismatch(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	mockStripe := new(mockStripeClient)
+	service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+	_, err := service.CreatePayment(context.Background(), CreatePaymentParams{OrderID: "order123", UserID: "user123", Currency: "JPY"})
+
+	requireAppErrorCode(t, err, "currency_mismatch")
+	mockDB.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything)
+	mockStripe.AssertNotCalled(t, "CreatePaymentIntent", mock.Anything)
+}
+
 // TestCreatePayment_InvalidAmount tests when order has invalid amount
 func TestCreatePayment_InvalidAmount(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil, providers: newPaymentProviders("sk_test_123", nil)}
 
 	params := CreatePaymentParams{
 		OrderID:  "order123",
@@ -630,7 +738,7 @@ func TestCreatePayment_TransactionError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockDBConn := new(mockPaymentDBConn)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := CreatePaymentParams{
 		OrderID:  "order123",
@@ -669,7 +777,7 @@ func TestCreatePayment_CreatePaymentError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := CreatePaymentParams{
 		OrderID:  "order123",
@@ -714,7 +822,7 @@ func TestConfirmPayment_Success(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -761,7 +869,7 @@ func TestConfirmPayment_Success(t *testing.T) {
 // Helper for ConfirmPayment error scenarios with missing provider payment ID
 func runConfirmPaymentMissingProviderIDTest(t *testing.T, payment database.Payment, params ConfirmPaymentParams) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	mockDB.On("GetPaymentByOrderID", mock.Anything, params.OrderID).Return(payment, nil)
 
 	_, err := service.ConfirmPayment(context.Background(), params)
@@ -799,7 +907,7 @@ func TestConfirmPayment_InvalidOrderStatus(t *testing.T) {
 // TestGetPayment_Success tests successful payment retrieval
 func TestGetPayment_Success(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	payment := database.Payment{
 		ID:                "payment123",
@@ -821,7 +929,7 @@ func TestGetPayment_Success(t *testing.T) {
 	assert.Equal(t, "payment123", result.ID)
 	assert.Equal(t, "order123", result.OrderID)
 	assert.Equal(t, "user123", result.UserID)
-	assert.InEpsilon(t, 100.0, result.Amount, 0.001)
+	assert.Equal(t, money.New(10000, money.USD), result.Amount)
 	assert.Equal(t, "USD", result.Currency)
 	assert.Equal(t, "succeeded", result.Status)
 	assert.Equal(t, "stripe", result.Provider)
@@ -833,7 +941,7 @@ func TestGetPayment_Success(t *testing.T) {
 // Helper for GetPayment invalid amount tests
 func runGetPaymentInvalidAmountTest(t *testing.T, payment database.Payment) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	mockDB.On("GetPaymentByOrderID", mock.Anything, payment.OrderID).Return(payment, nil)
 
 	_, err := service.GetPayment(context.Background(), payment.OrderID, payment.UserID)
@@ -853,61 +961,33 @@ func TestGetPayment_InvalidAmount(t *testing.T) {
 	runGetPaymentInvalidAmountTest(t, payment)
 }
 
-// TestRefundPayment_Success tests successful payment refund
-func TestRefundPayment_Success(t *testing.T) {
-	mockDB := new(mockPaymentDBQueries)
-	mockDBConn := new(mockPaymentDBConn)
-	mockTx := new(mockPaymentDBTx)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
-
-	params := RefundPaymentParams{
-		OrderID: "order123",
-		UserID:  "user123",
-	}
-
-	payment := database.Payment{
-		ID:                "payment123",
-		OrderID:           "order123",
-		UserID:            "user123",
-		Amount:            "100.00",
-		Currency:          "USD",
-		Status:            "succeeded",
-		Provider:          "stripe",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}
-
-	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
-	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
-	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByID", mock.Anything, mock.Anything).Return(nil)
-	mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(nil)
+// TestApproveRefundRequest_Success tests approving a full refund of a paid order
+func TestApproveRefundRequest_Success(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectStripeRefund(mockStripe, 10000, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "100.00", "succeeded", "100.00", "refunded")
 	mockTx.On("Commit").Return(nil)
-	mockTx.On("Rollback").Return(nil)
 
-	// Mock Stripe refund call
-	mockStripe.On("CreateRefund", mock.Anything).Return(&stripe.Refund{
-		ID:     "re_test_123",
-		Status: stripe.RefundStatusSucceeded,
-	}, nil)
-
-	err := service.RefundPayment(context.Background(), params)
+	result, err := approveTestRefund(service)
 	require.NoError(t, err)
+	assert.Equal(t, "100.00", result.Amount.String())
+	assert.Equal(t, "refunded", result.PaymentStatus)
+	assert.Equal(t, "refunded", result.OrderRefundStatus)
 
 	mockDB.AssertExpectations(t)
-	mockDBConn.AssertExpectations(t)
 	mockTx.AssertExpectations(t)
 	mockStripe.AssertExpectations(t)
 }
 
-// TestRefundPayment_InvalidAmount tests when payment has invalid amount for refund
-func TestRefundPayment_InvalidAmount(t *testing.T) {
+// TestRequestRefund_InvalidAmount tests when payment has invalid amount for refund
+func TestRequestRefund_InvalidAmount(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	params := RefundPaymentParams{
 		OrderID: "order123",
 		UserID:  "user123",
+		Reason:  "Damaged",
 	}
 
 	payment := database.Payment{
@@ -920,7 +1000,7 @@ func TestRefundPayment_InvalidAmount(t *testing.T) {
 
 	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := service.RequestRefund(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Missing provider payment ID")
 
@@ -933,7 +1013,7 @@ func TestHandleWebhook_Success(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	// Mock webhook payload and signature
 	payload := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_test_123","metadata":{"order_id":"order123","user_id":"user123"}}}}`)
@@ -949,15 +1029,23 @@ func TestHandleWebhook_Success(t *testing.T) {
 	}
 	mockStripe.On("ParseWebhook", payload, signature, secret).Return(event, nil)
 
-	// Add missing mock for GetPaymentByProviderPaymentID
 	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{
+		OrderID:           "order123",
 		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-		// Add other fields as needed for your logic
 	}, nil)
 
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, mock.Anything).Return(nil)
+	expectNewWebhookEvent(mockDB, webhookEventProcessed)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderStatusParams) bool {
+		return p.ID == "order123" && p.Status == "paid" && !p.UpdatedAt.IsZero()
+	})).Return(nil)
+	mockDB.On("CreateOrderStatusHistory", mock.Anything, mock.MatchedBy(func(p database.CreateOrderStatusHistoryParams) bool {
+		return p.OrderID == "order123" && p.FromStatus == "pending" && p.ToStatus == "paid" &&
+			!p.ActorUserID.Valid && p.Reason.String == "Stripe event payment_intent.succeeded"
+	})).Return(nil)
 	mockTx.On("Commit").Return(nil)
 	mockTx.On("Rollback").Return(nil)
 
@@ -976,7 +1064,7 @@ func TestHandleWebhook_PaymentNotFound(t *testing.T) {
 	mockStripe := new(mockStripeClient)
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	payload := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_test_123"}}}`)
 	signature := testSignatureService
@@ -993,6 +1081,7 @@ func TestHandleWebhook_PaymentNotFound(t *testing.T) {
 
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
+	expectNewWebhookEvent(mockDB, webhookEventFailed)
 	mockTx.On("Rollback").Return(nil)
 
 	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{}, sql.ErrNoRows)
@@ -1005,71 +1094,103 @@ func TestHandleWebhook_PaymentNotFound(t *testing.T) {
 	mockStripe.AssertExpectations(t)
 }
 
-// Helper for webhook event status update tests
-func runHandleWebhookStatusUpdateTest(t *testing.T, eventType, payloadStr, eventRawStr, status string) {
-	mockDB := new(mockPaymentDBQueries)
-	mockDBConn := new(mockPaymentDBConn)
-	mockTx := new(mockPaymentDBTx)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+// TestHandleWebhook_PaymentFailed tests that a failed attempt marks the payment failed but keeps the order pending and its stock held,
+// so the customer can retry until the hold window ends.
+func TestHandleWebhook_PaymentFailed(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.payment_failed", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("failed")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockTx.On("Commit").Return(nil)
 
-	payload := []byte(payloadStr)
-	signature := testSignatureService
-	secret := testSecret
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
 
-	event := stripe.Event{
-		Type: stripe.EventType(eventType),
-		Data: &stripe.EventData{
-			Raw: []byte(eventRawStr),
-		},
-	}
-	mockStripe.On("ParseWebhook", payload, signature, secret).Return(event, nil)
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "GetOrderItemsByOrderID", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
 
-	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
-	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, database.UpdatePaymentStatusByProviderPaymentIDParams{
-		Status:            status,
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}).Return(nil)
+// TestHandleWebhook_PaymentCanceled tests that a cancelled intent cancels its pending order and restocks its items in the same transaction.
+func TestHandleWebhook_PaymentCanceled(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.canceled", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("cancelled")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderStatusParams) bool {
+		return p.ID == "order123" && p.Status == "cancelled"
+	})).Return(nil)
+	mockDB.On("GetOrderItemsByOrderID", mock.Anything, "order123").Return([]database.OrderItem{
+		{ID: "item1", OrderID: "order123", ProductID: "prod1", Quantity: 2},
+		{ID: "item2", OrderID: "order123", ProductID: "prod1", VariantID: utils.ToNullString("var1"), Quantity: 1},
+	}, nil)
+	mockDB.On("IncrementProductStock", mock.Anything, mock.MatchedBy(func(p database.IncrementProductStockParams) bool {
+		return p.ID == "prod1" && p.Quantity == 2
+	})).Return(nil)
+	mockDB.On("IncrementProductVariantStock", mock.Anything, mock.MatchedBy(func(p database.IncrementProductVariantStockParams) bool {
+		return p.ID == "var1" && p.Quantity == 1
+	})).Return(nil)
+	mockDB.On("CreateOrderStatusHistory", mock.Anything, mock.MatchedBy(func(p database.CreateOrderStatusHistoryParams) bool {
+		return p.OrderID == "order123" && p.FromStatus == "pending" && p.ToStatus == "cancelled"
+	})).Return(nil)
 	mockTx.On("Commit").Return(nil)
-	mockTx.On("Rollback").Return(nil)
 
-	err := service.HandleWebhook(context.Background(), payload, signature, secret)
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
 	require.NoError(t, err)
 
 	mockDB.AssertExpectations(t)
-	mockDBConn.AssertExpectations(t)
 	mockTx.AssertExpectations(t)
-	mockStripe.AssertExpectations(t)
 }
 
-func TestHandleWebhook_PaymentFailed(t *testing.T) {
-	runHandleWebhookStatusUpdateTest(
-		t,
-		"payment_intent.payment_failed",
-		`{"type":"payment_intent.payment_failed","data":{"object":{"id":"pi_test_123"}}}`,
-		`{"id":"pi_test_123"}`,
-		"failed",
-	)
-}
+// TestHandleWebhook_PaymentCanceledRestockError tests that a failed restock rolls back the cancellation.
+func TestHandleWebhook_PaymentCanceledRestockError(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.canceled", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("cancelled")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("GetOrderItemsByOrderID", mock.Anything, "order123").Return(nil, errors.New("db error"))
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "database_error")
+	assert.Contains(t, err.Error(), "Failed to restock order items")
+	mockTx.AssertNotCalled(t, "Commit")
+}
+
+// runHandleWebhookRefundTest runs a charge.refunded event for a delivered order and checks that the payment and the
+// order's refund status move on while the order keeps its fulfilment status.
+func runHandleWebhookRefundTest(t *testing.T, raw, paymentStatus, refundStatus string) {
+	service, mockDB, mockTx := newWebhookTestService("charge.refunded", raw, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "succeeded"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate(paymentStatus)).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "delivered", RefundStatus: "none"}, nil)
+	mockDB.On("UpdateOrderRefundStatus", mock.Anything, mock.MatchedBy(func(p database.UpdateOrderRefundStatusParams) bool {
+		return p.ID == "order123" && p.RefundStatus == refundStatus
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
 
-func TestHandleWebhook_PaymentCanceled(t *testing.T) {
-	runHandleWebhookStatusUpdateTest(
-		t,
-		"payment_intent.canceled",
-		`{"type":"payment_intent.canceled","data":{"object":{"id":"pi_test_123"}}}`,
-		`{"id":"pi_test_123"}`,
-		"cancelled",
-	)
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
 }
 
 func TestHandleWebhook_ChargeRefunded(t *testing.T) {
-	runHandleWebhookStatusUpdateTest(
-		t,
-		"charge.refunded",
-		`{"type":"charge.refunded","data":{"object":{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}}}`,
-		`{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}`,
+	runHandleWebhookRefundTest(t,
+		`{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}`,
 		"refunded",
+		"refunded",
+	)
+}
+
+func TestHandleWebhook_ChargePartiallyRefunded(t *testing.T) {
+	runHandleWebhookRefundTest(t,
+		`{"id":"ch_test_123","refunded":false,"amount_refunded":2550,"payment_intent":{"id":"pi_test_123"}}`,
+		"partially_refunded",
+		"partially_refunded",
 	)
 }
 
@@ -1079,7 +1200,7 @@ func TestHandleWebhook_DatabaseUpdateError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	payload := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_test_123"}}}`)
 	signature := testSignatureService
@@ -1095,11 +1216,10 @@ func TestHandleWebhook_DatabaseUpdateError(t *testing.T) {
 
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{}, nil)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, database.UpdatePaymentStatusByProviderPaymentIDParams{
-		Status:            "succeeded",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}).Return(errors.New("database error"))
+	expectNewWebhookEvent(mockDB, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(errors.New("database error"))
 	mockTx.On("Rollback").Return(nil)
 
 	err := service.HandleWebhook(context.Background(), payload, signature, secret)
@@ -1118,7 +1238,7 @@ func TestHandleWebhook_TransactionCommitError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	payload := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_test_123"}}}`)
 	signature := testSignatureService
@@ -1134,11 +1254,10 @@ func TestHandleWebhook_TransactionCommitError(t *testing.T) {
 
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{}, nil)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, database.UpdatePaymentStatusByProviderPaymentIDParams{
-		Status:            "succeeded",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}).Return(nil)
+	expectNewWebhookEvent(mockDB, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "paid"}, nil)
 	mockTx.On("Commit").Return(errors.New("commit error"))
 	mockTx.On("Rollback").Return(nil)
 
@@ -1158,7 +1277,7 @@ func TestHandleWebhook_UnknownEventType(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	payload := []byte(`{"type":"unknown.event","data":{"object":{"id":"pi_test_123"}}}`)
 	signature := testSignatureService
@@ -1174,6 +1293,7 @@ func TestHandleWebhook_UnknownEventType(t *testing.T) {
 
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
+	expectNewWebhookEvent(mockDB, webhookEventSkipped)
 	mockTx.On("Commit").Return(nil)
 	mockTx.On("Rollback").Return(nil)
 
@@ -1220,7 +1340,7 @@ func TestConfirmPayment_PendingStatuses(t *testing.T) {
 			mockDBConn := new(mockPaymentDBConn)
 			mockTx := new(mockPaymentDBTx)
 			mockStripe := new(mockStripeClient)
-			service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+			service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 			params := ConfirmPaymentParams{
 				OrderID: "order123",
@@ -1266,7 +1386,7 @@ func TestConfirmPayment_PendingStatuses(t *testing.T) {
 func TestConfirmPayment_StripeError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -1300,7 +1420,7 @@ func TestConfirmPayment_TransactionError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockDBConn := new(mockPaymentDBConn)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -1341,7 +1461,7 @@ func TestConfirmPayment_DatabaseUpdateError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -1386,7 +1506,7 @@ func TestConfirmPayment_OrderUpdateError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -1432,7 +1552,7 @@ func TestConfirmPayment_CommitError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := ConfirmPaymentParams{
 		OrderID: "order123",
@@ -1476,7 +1596,7 @@ func TestConfirmPayment_CommitError(t *testing.T) {
 // TestGetPaymentHistory_DatabaseError tests when database query fails
 func TestGetPaymentHistory_DatabaseError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	mockDB.On("GetPaymentsByUserID", mock.Anything, "user123").Return(nil, errors.New("database error"))
 
@@ -1490,7 +1610,7 @@ func TestGetPaymentHistory_DatabaseError(t *testing.T) {
 // TestGetPaymentHistory_EmptyResult tests when user has no payment history
 func TestGetPaymentHistory_EmptyResult(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	mockDB.On("GetPaymentsByUserID", mock.Anything, "user123").Return([]database.Payment{}, nil)
 
@@ -1504,7 +1624,7 @@ func TestGetPaymentHistory_EmptyResult(t *testing.T) {
 // TestGetAllPayments_WithStatusFilter tests when status filter is provided
 func TestGetAllPayments_WithStatusFilter(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	payments := []database.Payment{
 		{
@@ -1520,12 +1640,14 @@ func TestGetAllPayments_WithStatusFilter(t *testing.T) {
 		},
 	}
 
-	mockDB.On("GetPaymentsByStatus", mock.Anything, "succeeded").Return(payments, nil)
+	mockDB.On("GetAllPayments", mock.Anything, mock.MatchedBy(func(p database.GetAllPaymentsParams) bool {
+		return p.Status == sql.NullString{String: "succeeded", Valid: true}
+	})).Return(payments, nil)
 
-	result, err := service.GetAllPayments(context.Background(), "succeeded")
+	result, err := service.GetAllPayments(context.Background(), "succeeded", pagination.Request{})
 	require.NoError(t, err)
-	assert.Len(t, result, 1)
-	assert.Equal(t, "payment123", result[0].ID)
+	assert.Len(t, result.Data, 1)
+	assert.Equal(t, "payment123", result.Data[0].ID)
 
 	mockDB.AssertExpectations(t)
 }
@@ -1533,11 +1655,13 @@ func TestGetAllPayments_WithStatusFilter(t *testing.T) {
 // TestGetAllPayments_DatabaseError tests when database query fails
 func TestGetAllPayments_DatabaseError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
-	mockDB.On("GetAllPayments", mock.Anything).Return(nil, errors.New("database error"))
+	mockDB.On("GetAllPayments", mock.Anything, mock.MatchedBy(func(p database.GetAllPaymentsParams) bool {
+		return !p.Status.Valid
+	})).Return(nil, errors.New("database error"))
 
-	_, err := service.GetAllPayments(context.Background(), "all")
+	_, err := service.GetAllPayments(context.Background(), "all", pagination.Request{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to fetch payments")
 
@@ -1547,11 +1671,11 @@ func TestGetAllPayments_DatabaseError(t *testing.T) {
 // TestGetAllPayments_StatusFilterDatabaseError tests when status filter database query fails
 func TestGetAllPayments_StatusFilterDatabaseError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
-	mockDB.On("GetPaymentsByStatus", mock.Anything, "succeeded").Return(nil, errors.New("database error"))
+	mockDB.On("GetAllPayments", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 
-	_, err := service.GetAllPayments(context.Background(), "succeeded")
+	_, err := service.GetAllPayments(context.Background(), "succeeded", pagination.Request{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to fetch payments")
 
@@ -1561,60 +1685,45 @@ func TestGetAllPayments_StatusFilterDatabaseError(t *testing.T) {
 // TestGetAllPayments_EmptyResult tests when no payments match the filter
 func TestGetAllPayments_EmptyResult(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
-	mockDB.On("GetAllPayments", mock.Anything).Return([]database.Payment{}, nil)
+	mockDB.On("GetAllPayments", mock.Anything, mock.Anything).Return([]database.Payment{}, nil)
 
-	result, err := service.GetAllPayments(context.Background(), "all")
+	result, err := service.GetAllPayments(context.Background(), "all", pagination.Request{})
 	require.NoError(t, err)
-	assert.Empty(t, result)
+	assert.Empty(t, result.Data)
+	assert.False(t, result.HasNext)
 
 	mockDB.AssertExpectations(t)
 }
 
-// TestRefundPayment_StripeError tests when Stripe refund fails
-func TestRefundPayment_StripeError(t *testing.T) {
-	mockDB := new(mockPaymentDBQueries)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123", stripe: mockStripe}
-
-	params := RefundPaymentParams{
-		OrderID: "order123",
-		UserID:  "user123",
-	}
-
-	payment := database.Payment{
-		ID:                "payment123",
-		OrderID:           "order123",
-		UserID:            "user123",
-		Amount:            "100.00",
-		Currency:          "USD",
-		Status:            "succeeded",
-		Provider:          "stripe",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}
-
-	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
+// TestApproveRefundRequest_StripeError tests when Stripe refund fails
+func TestApproveRefundRequest_StripeError(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectRefundReserved(mockDB, "100.00")
 	mockStripe.On("CreateRefund", mock.Anything).Return(nil, errors.New("stripe error"))
+	mockTx.On("Commit").Return(nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := approveTestRefund(service)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to process refund")
 
 	mockDB.AssertExpectations(t)
+	mockTx.AssertNumberOfCalls(t, "Commit", 1)
 	mockStripe.AssertExpectations(t)
 }
 
-// TestRefundPayment_TransactionError tests when transaction fails to start
-func TestRefundPayment_TransactionError(t *testing.T) {
+// TestRequestRefund_TransactionError tests when transaction fails to start
+func TestRequestRefund_TransactionError(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockDBConn := new(mockPaymentDBConn)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	params := RefundPaymentParams{
 		OrderID: "order123",
 		UserID:  "user123",
+		Reason:  "Damaged",
 	}
 
 	payment := database.Payment{
@@ -1629,161 +1738,80 @@ func TestRefundPayment_TransactionError(t *testing.T) {
 	}
 
 	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
-	mockStripe.On("CreateRefund", mock.Anything).Return(&stripe.Refund{
-		ID:     "re_test_123",
-		Status: stripe.RefundStatusSucceeded,
-	}, nil)
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(nil, errors.New("transaction error"))
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := service.RequestRefund(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Error starting transaction")
 
 	mockDB.AssertExpectations(t)
 	mockDBConn.AssertExpectations(t)
-	mockStripe.AssertExpectations(t)
+	mockStripe.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
+
+// expectRefundSendSettling expects the full refund to be reserved and sent, and the provider's answer to be recorded
+// in the second transaction up to the payment update.
+func expectRefundSendSettling(mockDB *mockPaymentDBQueries, mockStripe *mockStripeClient) {
+	expectRefundReserved(mockDB, "100.00")
+	expectStripeRefund(mockStripe, 10000, stripe.RefundStatusSucceeded)
+	mockDB.On("GetRefundByIDForUpdate", mock.Anything, mock.Anything).Return(database.Refund{
+		ID:        "ref_test_123",
+		PaymentID: "payment123",
+		OrderID:   "order123",
+		Amount:    "100.00",
+		Currency:  "USD",
+		Status:    refundStatusPending,
+	}, nil)
+	mockDB.On("UpdateRefundResult", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("GetRefundedAmountByPaymentID", mock.Anything, "payment123").Return("100.00", nil).Once()
 }
 
-// TestRefundPayment_PaymentUpdateError tests when payment status update fails
-func TestRefundPayment_PaymentUpdateError(t *testing.T) {
-	mockDB := new(mockPaymentDBQueries)
-	mockDBConn := new(mockPaymentDBConn)
-	mockTx := new(mockPaymentDBTx)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
-
-	params := RefundPaymentParams{
-		OrderID: "order123",
-		UserID:  "user123",
-	}
-
-	payment := database.Payment{
-		ID:                "payment123",
-		OrderID:           "order123",
-		UserID:            "user123",
-		Amount:            "100.00",
-		Currency:          "USD",
-		Status:            "succeeded",
-		Provider:          "stripe",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}
-
-	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
-	mockStripe.On("CreateRefund", mock.Anything).Return(&stripe.Refund{
-		ID:     "re_test_123",
-		Status: stripe.RefundStatusSucceeded,
-	}, nil)
-	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
-	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByID", mock.Anything, database.UpdatePaymentStatusByIDParams{
-		ID:     "payment123",
-		Status: "refunded",
-	}).Return(errors.New("payment update error"))
-	mockTx.On("Rollback").Return(nil)
+// TestApproveRefundRequest_PaymentUpdateError tests when payment status update fails
+func TestApproveRefundRequest_PaymentUpdateError(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectRefundSendSettling(mockDB, mockStripe)
+	mockDB.On("UpdatePaymentStatus", mock.Anything, mock.Anything).Return(errors.New("payment update error"))
+	mockTx.On("Commit").Return(nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := approveTestRefund(service)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to update payment status")
 
 	mockDB.AssertExpectations(t)
-	mockDBConn.AssertExpectations(t)
-	mockTx.AssertExpectations(t)
+	mockTx.AssertNumberOfCalls(t, "Commit", 1)
 	mockStripe.AssertExpectations(t)
 }
 
-// TestRefundPayment_OrderUpdateError tests when order status update fails
-func TestRefundPayment_OrderUpdateError(t *testing.T) {
-	mockDB := new(mockPaymentDBQueries)
-	mockDBConn := new(mockPaymentDBConn)
-	mockTx := new(mockPaymentDBTx)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
-
-	params := RefundPaymentParams{
-		OrderID: "order123",
-		UserID:  "user123",
-	}
-
-	payment := database.Payment{
-		ID:                "payment123",
-		OrderID:           "order123",
-		UserID:            "user123",
-		Amount:            "100.00",
-		Currency:          "USD",
-		Status:            "succeeded",
-		Provider:          "stripe",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}
-
-	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
-	mockStripe.On("CreateRefund", mock.Anything).Return(&stripe.Refund{
-		ID:     "re_test_123",
-		Status: stripe.RefundStatusSucceeded,
-	}, nil)
-	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
-	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByID", mock.Anything, database.UpdatePaymentStatusByIDParams{
-		ID:     "payment123",
-		Status: "refunded",
-	}).Return(nil)
-	mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(errors.New("order update error"))
-	mockTx.On("Rollback").Return(nil)
+// TestApproveRefundRequest_OrderUpdateError tests when the order refund status update fails
+func TestApproveRefundRequest_OrderUpdateError(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectRefundSendSettling(mockDB, mockStripe)
+	mockDB.On("UpdatePaymentStatus", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("UpdateOrderRefundStatus", mock.Anything, mock.Anything).Return(errors.New("order update error"))
+	mockTx.On("Commit").Return(nil)
 
-	err := service.RefundPayment(context.Background(), params)
+	_, err := approveTestRefund(service)
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "Failed to update order status")
+	assert.Contains(t, err.Error(), "Failed to update order refund status")
 
 	mockDB.AssertExpectations(t)
-	mockDBConn.AssertExpectations(t)
-	mockTx.AssertExpectations(t)
+	mockTx.AssertNumberOfCalls(t, "Commit", 1)
 	mockStripe.AssertExpectations(t)
 }
 
-// TestRefundPayment_CommitError tests when transaction commit fails
-func TestRefundPayment_CommitError(t *testing.T) {
-	mockDB := new(mockPaymentDBQueries)
-	mockDBConn := new(mockPaymentDBConn)
-	mockTx := new(mockPaymentDBTx)
-	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
-
-	params := RefundPaymentParams{
-		OrderID: "order123",
-		UserID:  "user123",
-	}
+// TestApproveRefundRequest_CommitError tests when committing the refund outcome fails
+func TestApproveRefundRequest_CommitError(t *testing.T) {
+	service, mockDB, mockTx, mockStripe := newRefundTestService("succeeded", "paid", "0.00", RefundPaymentParams{})
+	expectStripeRefund(mockStripe, 10000, stripe.RefundStatusSucceeded)
+	expectRefundRecorded(mockDB, "100.00", "succeeded", "100.00", "refunded")
+	mockTx.On("Commit").Return(nil).Once()
+	mockTx.On("Commit").Return(errors.New("commit error")).Once()
 
-	payment := database.Payment{
-		ID:                "payment123",
-		OrderID:           "order123",
-		UserID:            "user123",
-		Amount:            "100.00",
-		Currency:          "USD",
-		Status:            "succeeded",
-		Provider:          "stripe",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}
-
-	mockDB.On("GetPaymentByOrderID", mock.Anything, "order123").Return(payment, nil)
-	mockStripe.On("CreateRefund", mock.Anything).Return(&stripe.Refund{
-		ID:     "re_test_123",
-		Status: stripe.RefundStatusSucceeded,
-	}, nil)
-	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
-	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByID", mock.Anything, database.UpdatePaymentStatusByIDParams{
-		ID:     "payment123",
-		Status: "refunded",
-	}).Return(nil)
-	mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(nil)
-	mockTx.On("Commit").Return(errors.New("commit error"))
-	mockTx.On("Rollback").Return(nil)
-
-	err := service.RefundPayment(context.Background(), params)
+	_, err := approveTestRefund(service)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Error committing transaction")
 
 	mockDB.AssertExpectations(t)
-	mockDBConn.AssertExpectations(t)
 	mockTx.AssertExpectations(t)
 	mockStripe.AssertExpectations(t)
 }
@@ -1794,25 +1822,25 @@ func TestHandleWebhook_ChargeRefunded_DatabaseUpdateError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
-	payload := []byte(`{"type":"charge.refunded","data":{"object":{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}}}`)
+	payload := []byte(`{"type":"charge.refunded","data":{"object":{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}}}`)
 	signature := testSignatureService
 	secret := testSecret
 
 	event := stripe.Event{
 		Type: "charge.refunded",
 		Data: &stripe.EventData{
-			Raw: []byte(`{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}`),
+			Raw: []byte(`{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}`),
 		},
 	}
 	mockStripe.On("ParseWebhook", payload, signature, secret).Return(event, nil)
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, database.UpdatePaymentStatusByProviderPaymentIDParams{
-		Status:            "refunded",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}).Return(errors.New("db error"))
+	expectNewWebhookEvent(mockDB, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "delivered"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("refunded")).Return(errors.New("db error"))
 	mockTx.On("Rollback").Return(nil)
 
 	err := service.HandleWebhook(context.Background(), payload, signature, secret)
@@ -1831,25 +1859,25 @@ func TestHandleWebhook_ChargeRefunded_CommitError(t *testing.T) {
 	mockDBConn := new(mockPaymentDBConn)
 	mockTx := new(mockPaymentDBTx)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
-	payload := []byte(`{"type":"charge.refunded","data":{"object":{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}}}`)
+	payload := []byte(`{"type":"charge.refunded","data":{"object":{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}}}`)
 	signature := testSignatureService
 	secret := testSecret
 
 	event := stripe.Event{
 		Type: "charge.refunded",
 		Data: &stripe.EventData{
-			Raw: []byte(`{"id":"ch_test_123","payment_intent":{"id":"pi_test_123"}}`),
+			Raw: []byte(`{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}`),
 		},
 	}
 	mockStripe.On("ParseWebhook", payload, signature, secret).Return(event, nil)
 	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, database.UpdatePaymentStatusByProviderPaymentIDParams{
-		Status:            "refunded",
-		ProviderPaymentID: utils.ToNullString("pi_test_123"),
-	}).Return(nil)
+	expectNewWebhookEvent(mockDB, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("refunded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "delivered", RefundStatus: "refunded"}, nil)
 	mockTx.On("Commit").Return(errors.New("commit error"))
 	mockTx.On("Rollback").Return(nil)
 
@@ -1868,7 +1896,7 @@ func TestHandleWebhook_MalformedPayload(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
 	mockDBConn := new(mockPaymentDBConn)
 	mockStripe := new(mockStripeClient)
-	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, apiKey: "sk_test_123", stripe: mockStripe}
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
 
 	payload := []byte(`not a json`)
 	signature := testSignatureService
@@ -1886,7 +1914,7 @@ func TestHandleWebhook_MalformedPayload(t *testing.T) {
 // TestGetPaymentHistory_EmptyUserID tests empty user ID
 func TestGetPaymentHistory_EmptyUserID(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	_, err := service.GetPaymentHistory(context.Background(), "")
 	require.Error(t, err)
@@ -1896,7 +1924,7 @@ func TestGetPaymentHistory_EmptyUserID(t *testing.T) {
 // TestGetPayment_EmptyOrderID tests empty order ID
 func TestGetPayment_EmptyOrderID(t *testing.T) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 
 	_, err := service.GetPayment(context.Background(), "", "user123")
 	require.Error(t, err)
@@ -1906,7 +1934,7 @@ func TestGetPayment_EmptyOrderID(t *testing.T) {
 // Helper for GetPayment error scenarios
 func runGetPaymentErrorTest(t *testing.T, payment database.Payment, orderID, userID, expectedMsg string) {
 	mockDB := new(mockPaymentDBQueries)
-	service := &paymentServiceImpl{db: mockDB, dbConn: nil, apiKey: "sk_test_123"}
+	service := &paymentServiceImpl{db: mockDB, dbConn: nil}
 	mockDB.On("GetPaymentByOrderID", mock.Anything, orderID).Return(payment, nil)
 
 	_, err := service.GetPayment(context.Background(), orderID, userID)
@@ -1936,3 +1964,103 @@ func TestGetPayment_InvalidAmountParsing(t *testing.T) {
 	}
 	runGetPaymentErrorTest(t, payment, "order123", "user123", "Invalid payment amount")
 }
+
+// TestListWebhookEvents_DefaultsToFailed tests that listing without a status returns failed events mapped for the response.
+func TestListWebhookEvents_DefaultsToFailed(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", nil)}
+
+	mockDB.On("ListWebhookEventsByStatus", mock.Anything, "failed").Return([]database.WebhookEvent{{
+		ID:                "evt_123",
+		Type:              "payment_intent.succeeded",
+		Payload:           []byte(`{"id":"evt_123"}`),
+		ProviderPaymentID: utils.ToNullString("pi_test_123"),
+		Status:            "failed",
+		LastError:         utils.ToNullString("Payment not found"),
+		Attempts:          2,
+	}}, nil)
+
+	events, err := service.ListWebhookEvents(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "evt_123", events[0].ID)
+	assert.Equal(t, "pi_test_123", events[0].ProviderPaymentID)
+	assert.Equal(t, "Payment not found", events[0].LastError)
+	assert.Equal(t, int32(2), events[0].Attempts)
+	assert.JSONEq(t, `{"id":"evt_123"}`, string(events[0].Payload))
+	mockDB.AssertExpectations(t)
+}
+
+// TestListWebhookEvents_Errors tests invalid statuses and database failures.
+func TestListWebhookEvents_Errors(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", nil)}
+
+	_, err := service.ListWebhookEvents(context.Background(), "bogus")
+	requireAppErrorCode(t, err, "invalid_status")
+
+	mockDB.On("ListWebhookEventsByStatus", mock.Anything, "skipped").Return(nil, errors.New("db down"))
+	_, err = service.ListWebhookEvents(context.Background(), "skipped")
+	requireAppErrorCode(t, err, "database_error")
+}
+
+// TestRetryWebhookEvent_Success tests that a failed event is re-run from its stored payload without a signature check.
+func TestRetryWebhookEvent_Success(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+	mockStripe := new(mockStripeClient)
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+	payload := []byte(`{"id":"evt_123","object":"event","type":"payment_intent.succeeded","created":1700000000,"data":{"object":{"id":"pi_test_123","object":"payment_intent"}}}`)
+	mockDB.On("GetWebhookEventByID", mock.Anything, "evt_123").Return(database.WebhookEvent{ID: "evt_123", Status: "failed", Payload: payload}, nil)
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockDB.On("GetWebhookEventByIDForUpdate", mock.Anything, "evt_123").Return(database.WebhookEvent{ID: "evt_123", Status: "failed"}, nil)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	mockDB.On("GetLatestProcessedWebhookEventTime", mock.Anything, "pi_test_123").Return(time.Time{}, sql.ErrNoRows)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("CreateOrderStatusHistory", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.ID == "evt_123" && p.Status == "processed"
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+	mockTx.On("Rollback").Return(nil)
+
+	err := service.RetryWebhookEvent(context.Background(), "evt_123")
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+	mockStripe.AssertNotCalled(t, "ParseWebhook", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestRetryWebhookEvent_Errors tests the checks made before a stored event is re-run.
+func TestRetryWebhookEvent_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		eventID string
+		stored  database.WebhookEvent
+		err     error
+		code    string
+	}{
+		{name: "missing id", code: "invalid_request"},
+		{name: "not found", eventID: "evt_123", err: sql.ErrNoRows, code: "webhook_event_not_found"},
+		{name: "database error", eventID: "evt_123", err: errors.New("db down"), code: "database_error"},
+		{name: "not failed", eventID: "evt_123", stored: database.WebhookEvent{Status: "processed"}, code: "webhook_event_not_failed"},
+		{name: "bad payload", eventID: "evt_123", stored: database.WebhookEvent{Status: "failed", Payload: []byte(`not json`)}, code: "webhook_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(mockPaymentDBQueries)
+			service := &paymentServiceImpl{db: mockDB, providers: newPaymentProviders("sk_test_123", nil)}
+			mockDB.On("GetWebhookEventByID", mock.Anything, tt.eventID).Return(tt.stored, tt.err).Maybe()
+
+			err := service.RetryWebhookEvent(context.Background(), tt.eventID)
+			requireAppErrorCode(t, err, tt.code)
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// payment_webhook.go: Records provider webhook events, maps them to payment and order status changes and applies them once.
+
+// Processing results stored in the webhook event log.
+const (
+	webhookEventReceived  = "received"
+	webhookEventProcessed = "processed"
+	webhookEventSkipped   = "skipped"
+	webhookEventFailed    = "failed"
+)
+
+// capturedAfterCancelReason is the reason on refund requests filed for payments captured after their order was cancelled.
+const capturedAfterCancelReason = "Payment captured after the order was cancelled"
+
+// isValidWebhookEventStatus reports whether status is a webhook event log status.
+func isValidWebhookEventStatus(status string) bool {
+	switch status {
+	case webhookEventReceived, webhookEventProcessed, webhookEventSkipped, webhookEventFailed:
+		return true
+	}
+	return false
+}
+
+// webhookOrderStatuses maps the payment status a webhook event moves a payment to onto the status of its order.
+// A failed attempt leaves the order pending: the customer can retry the same intent until the hold window ends.
+var webhookOrderStatuses = map[string]string{
+	"succeeded": orderhandlers.OrderStatusPaid,
+	"failed":    orderhandlers.OrderStatusPending,
+	"cancelled": orderhandlers.OrderStatusCancelled,
+}
+
+// webhookRefundStatuses maps the payment status a refund event moves a payment to onto the refund status of its order.
+// Refund events leave the order status alone.
+var webhookRefundStatuses = map[string]string{
+	"partially_refunded": orderhandlers.OrderRefundStatusPartiallyRefunded,
+	"refunded":           orderhandlers.OrderRefundStatusRefunded,
+}
+
+// paymentStatusRank orders payment statuses so a webhook never moves a payment backwards,
+// for example from succeeded to failed. A payment that succeeds after it was cancelled is not a regression
+// but a captured charge; see flagCapturedAfterCancel. A cancel_pending payment belongs to a released order whose
+// intent is still being voided, so only the provider's cancelled or succeeded events move it on.
+var paymentStatusRank = map[string]int{
+	"pending":            0,
+	"failed":             1,
+	"cancel_pending":     1,
+	"succeeded":          2,
+	"cancelled":          2,
+	"partially_refunded": 3,
+	"refunded":           4,
+}
+
+// isPaymentStatusRegression reports whether moving a payment from one status to another goes backwards.
+// Unknown statuses are never treated as a regression.
+func isPaymentStatusRegression(from, to string) bool {
+	fromRank, okFrom := paymentStatusRank[from]
+	toRank, okTo := paymentStatusRank[to]
+	if !okFrom || !okTo || from == to {
+		return false
+	}
+	return toRank <= fromRank
+}
+
+// processWebhookEvent applies a recorded event in one transaction and stores the result in the event log.
+// The event row is locked first, so concurrent deliveries of the same event are applied once.
+// When applying fails, the changes are rolled back and the event is marked failed with the error.
+func (s *paymentServiceImpl) processWebhookEvent(ctx context.Context, event WebhookEvent, now time.Time) error {
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		// Log error but don't return it since we're in defer
+		_ = tx.Rollback()
+	}()
+
+	queries := s.db.WithTx(tx)
+
+	stored, err := queries.GetWebhookEventByIDForUpdate(ctx, event.ID)
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to fetch webhook event", Err: err}
+	}
+	if stored.Status == webhookEventProcessed || stored.Status == webhookEventSkipped {
+		return nil
+	}
+
+	status, reason, err := applyWebhookEvent(ctx, queries, event, now)
+	if err != nil {
+		// Release the event row before recording the failure outside the transaction
+		_ = tx.Rollback()
+		// The original error is returned even if recording fails; the event then stays retryable in its previous state
+		_ = s.db.UpdateWebhookEventResult(ctx, database.UpdateWebhookEventResultParams{
+			ID:        event.ID,
+			Status:    webhookEventFailed,
+			LastError: utils.ToNullString(err.Error()),
+			UpdatedAt: now,
+		})
+		return err
+	}
+
+	err = queries.UpdateWebhookEventResult(ctx, database.UpdateWebhookEventResultParams{
+		ID:        event.ID,
+		Status:    status,
+		LastError: utils.ToNullString(reason),
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to update webhook event", Err: err}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+
+	return nil
+}
+
+// applyWebhookEvent applies an event to its payment and order using queries bound to the processing transaction.
+// Returns the event log status and, for skipped events, the reason. Events of unhandled types, events older than
+// the last event applied to the payment, and events that would move the payment backwards are skipped.
+//
+// The order row is locked before the payment is read for its status or updated. Releasing and cancelling an order
+// lock it before touching its payments too, so a webhook racing them waits instead of deadlocking.
+func applyWebhookEvent(ctx context.Context, queries PaymentDBQueries, event WebhookEvent, now time.Time) (string, string, error) {
+	orderStatus, ok := webhookOrderStatuses[event.PaymentStatus]
+	refundStatus, isRefund := webhookRefundStatuses[event.PaymentStatus]
+	if !ok && !isRefund {
+		return webhookEventSkipped, "Unhandled event type", nil
+	}
+	if event.Err != nil {
+		return "", "", event.Err
+	}
+	providerPaymentID := event.ProviderPaymentID
+
+	// The payment is read once without a lock to find its order, and again once the order is locked,
+	// since a release holding the lock may have changed it in between.
+	payment, err := getWebhookPayment(ctx, queries, providerPaymentID)
+	if err != nil {
+		return "", "", err
+	}
+	order, err := queries.GetOrderByIDForUpdate(ctx, payment.OrderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", &handlers.AppError{Code: "order_not_found", Message: "Order not found", Err: err}
+		}
+		return "", "", &handlers.AppError{Code: "database_error", Message: "Failed to fetch order", Err: err}
+	}
+	payment, err = getWebhookPayment(ctx, queries, providerPaymentID)
+	if err != nil {
+		return "", "", err
+	}
+
+	latest, err := queries.GetLatestProcessedWebhookEventTime(ctx, providerPaymentID)
+	switch {
+	case err == nil:
+		if event.CreatedAt.Before(latest) {
+			return webhookEventSkipped, "Event is older than the last event applied to the payment", nil
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", "", &handlers.AppError{Code: "database_error", Message: "Failed to fetch webhook events", Err: err}
+	}
+
+	// A cancel_pending payment's order is already cancelled, so a capture before the void lands is handled the same way
+	if (payment.Status == "cancelled" || payment.Status == "cancel_pending") && event.PaymentStatus == "succeeded" {
+		note, err := flagCapturedAfterCancel(ctx, queries, event, payment, now)
+		if err != nil {
+			return "", "", err
+		}
+		return webhookEventProcessed, note, nil
+	}
+
+	if isPaymentStatusRegression(payment.Status, event.PaymentStatus) {
+		return webhookEventSkipped, fmt.Sprintf("Payment is already %s", payment.Status), nil
+	}
+
+	if isRefund {
+		err = applyWebhookRefund(ctx, queries, event, order, refundStatus, now)
+	} else {
+		err = applyWebhookTransition(ctx, queries, event, order, orderStatus, now)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return webhookEventProcessed, "", nil
+}
+
+// getWebhookPayment returns the payment an event is about.
+func getWebhookPayment(ctx context.Context, queries PaymentDBQueries, providerPaymentID string) (database.Payment, error) {
+	payment, err := queries.GetPaymentByProviderPaymentID(ctx, providerPaymentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Payment{}, &handlers.AppError{Code: "payment_not_found", Message: "Payment not found", Err: err}
+		}
+		return database.Payment{}, &handlers.AppError{Code: "database_error", Message: "Failed to fetch payment", Err: err}
+	}
+	return payment, nil
+}
+
+// flagCapturedAfterCancel handles a payment the provider captured after its order was cancelled, for example when the
+// customer confirmed the payment just as the order was released. The order stays cancelled and its stock released;
+// the payment is recorded as succeeded so the charge is on the books, and a refund request for the full amount is
+// filed for an admin to review unless one is already pending. Returns the note stored with the webhook event.
+// The order row must already be locked.
+func flagCapturedAfterCancel(ctx context.Context, queries PaymentDBQueries, event WebhookEvent, payment database.Payment, now time.Time) (string, error) {
+	err := queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
+		ProviderPaymentID: utils.ToNullString(event.ProviderPaymentID),
+		Status:            event.PaymentStatus,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		return "", &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
+	}
+
+	pending, err := queries.GetPendingRefundRequestByOrderID(ctx, payment.OrderID)
+	if err == nil {
+		return fmt.Sprintf("Payment captured after the order was cancelled; refund request %s is pending", pending.ID), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", &handlers.AppError{Code: "database_error", Message: "Failed to fetch refund requests", Err: err}
+	}
+
+	requestID := utils.NewUUIDString()
+	err = queries.CreateRefundRequest(ctx, database.CreateRefundRequestParams{
+		ID:        requestID,
+		OrderID:   payment.OrderID,
+		PaymentID: payment.ID,
+		UserID:    payment.UserID,
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Items:     []byte("[]"),
+		Reason:    capturedAfterCancelReason,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return "", &handlers.AppError{Code: "database_error", Message: "Failed to record refund request", Err: err}
+	}
+	return fmt.Sprintf("Payment captured after the order was cancelled; refund request %s filed for review", requestID), nil
+}
+
+// applyWebhookTransition moves the payment to the event's payment status and its parent order to orderStatus.
+// Queries must be bound to the webhook transaction, in which order was read and locked.
+// An order already in the target status is left untouched. An order cancelled here has its items restocked
+// in the same transaction.
+func applyWebhookTransition(ctx context.Context, queries PaymentDBQueries, event WebhookEvent, order database.Order, orderStatus string, now time.Time) error {
+	err := queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
+		ProviderPaymentID: utils.ToNullString(event.ProviderPaymentID),
+		Status:            event.PaymentStatus,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
+	}
+
+	if order.Status == orderStatus {
+		return nil
+	}
+	if !orderhandlers.CanTransitionOrderStatus(order.Status, orderStatus) {
+		return &handlers.AppError{
+			Code:    "invalid_status_transition",
+			Message: fmt.Sprintf("Cannot change order status from %s to %s", order.Status, orderStatus),
+		}
+	}
+
+	err = queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+		ID:        order.ID,
+		Status:    orderStatus,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to update order status", Err: err}
+	}
+
+	if orderStatus == orderhandlers.OrderStatusCancelled {
+		if err := orderhandlers.RestockOrderItems(ctx, queries, order.ID, now); err != nil {
+			return &handlers.AppError{Code: "database_error", Message: "Failed to restock order items", Err: err}
+		}
+	}
+
+	err = queries.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		ID:         utils.NewUUIDString(),
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   orderStatus,
+		Reason:     utils.ToNullString(fmt.Sprintf("Stripe event %s", event.Type)),
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to record order status history", Err: err}
+	}
+
+	return nil
+}
+
+// applyWebhookRefund moves the payment to the event's refund payment status and its order to refundStatus.
+// Queries must be bound to the webhook transaction, in which order was read and locked.
+func applyWebhookRefund(ctx context.Context, queries PaymentDBQueries, event WebhookEvent, order database.Order, refundStatus string, now time.Time) error {
+	err := queries.UpdatePaymentStatusByProviderPaymentID(ctx, database.UpdatePaymentStatusByProviderPaymentIDParams{
+		ProviderPaymentID: utils.ToNullString(event.ProviderPaymentID),
+		Status:            event.PaymentStatus,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to update payment", Err: err}
+	}
+
+	if order.RefundStatus == refundStatus {
+		return nil
+	}
+
+	err = queries.UpdateOrderRefundStatus(ctx, database.UpdateOrderRefundStatusParams{
+		ID:           order.ID,
+		RefundStatus: refundStatus,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to update order refund status", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+// Package paymenthandlers provides HTTP handlers and configurations for processing payments, including Stripe integration, error handling, and payment-related request and response management.
+package paymenthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v82"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// payment_webhook_test.go: Tests for applying Stripe webhook events to payments and their orders.
+
+// newWebhookTestService creates a payment service whose Stripe client returns the given event inside a mocked transaction.
+// The event is expected to be recorded in the event log with the given result.
+func newWebhookTestService(eventType, raw, result string) (*paymentServiceImpl, *mockPaymentDBQueries, *mockPaymentDBTx) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+	mockStripe := new(mockStripeClient)
+
+	mockStripe.On("ParseWebhook", mock.Anything, testSignatureService, testSecret).Return(stripe.Event{
+		Type: stripe.EventType(eventType),
+		Data: &stripe.EventData{Raw: []byte(raw)},
+	}, nil)
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockTx.On("Rollback").Return(nil)
+	expectNewWebhookEvent(mockDB, result)
+
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+	return service, mockDB, mockTx
+}
+
+// requireAppErrorCode asserts that err is an AppError with the given code.
+func requireAppErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var appErr *handlers.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, code, appErr.Code)
+}
+
+// TestHandleWebhook_OrderAlreadyInTargetStatus tests that a redelivered event updates the payment but leaves the order and history alone.
+func TestHandleWebhook_OrderAlreadyInTargetStatus(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "paid"}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "CreateOrderStatusHistory", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_LocksOrderBeforePayment tests that the order row is locked before the payment is reread and updated,
+// the same order releasing and cancelling an order use, so the two cannot deadlock.
+func TestHandleWebhook_LocksOrderBeforePayment(t *testing.T) {
+	for _, tt := range []struct {
+		eventType string
+		raw       string
+		status    string
+		order     database.Order
+	}{
+		{eventType: "payment_intent.canceled", raw: `{"id":"pi_test_123"}`, status: "cancelled", order: database.Order{ID: "order123", Status: "cancelled"}},
+		{eventType: "charge.refunded", raw: `{"id":"ch_test_123","refunded":true,"payment_intent":{"id":"pi_test_123"}}`, status: "refunded", order: database.Order{ID: "order123", Status: "delivered", RefundStatus: "refunded"}},
+	} {
+		t.Run(tt.eventType, func(t *testing.T) {
+			service, mockDB, mockTx := newWebhookTestService(tt.eventType, tt.raw, webhookEventProcessed)
+			var calls []string
+			record := func(name string) func(mock.Arguments) {
+				return func(mock.Arguments) { calls = append(calls, name) }
+			}
+			mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").
+				Run(record("GetPaymentByProviderPaymentID")).Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+			mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Run(record("GetOrderByIDForUpdate")).Return(tt.order, nil)
+			mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate(tt.status)).
+				Run(record("UpdatePaymentStatusByProviderPaymentID")).Return(nil)
+			mockTx.On("Commit").Return(nil)
+
+			require.NoError(t, service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret))
+			assert.Equal(t, []string{
+				"GetPaymentByProviderPaymentID",
+				"GetOrderByIDForUpdate",
+				"GetPaymentByProviderPaymentID",
+				"UpdatePaymentStatusByProviderPaymentID",
+			}, calls)
+		})
+	}
+}
+
+// TestHandleWebhook_InvalidOrderTransition tests that an event the order cannot follow rolls back the payment update.
+func TestHandleWebhook_InvalidOrderTransition(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.payment_failed", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("failed")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "delivered"}, nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "invalid_status_transition")
+	assert.Contains(t, err.Error(), "Cannot change order status from delivered to pending")
+
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockTx.AssertNotCalled(t, "Commit")
+	mockTx.AssertCalled(t, "Rollback")
+}
+
+// TestHandleWebhook_PaymentLookupError tests that a failed payment lookup is reported as a database error.
+func TestHandleWebhook_PaymentLookupError(t *testing.T) {
+	service, mockDB, _ := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{}, errors.New("db down"))
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "database_error")
+}
+
+// TestHandleWebhook_OrderNotFound tests that a payment whose order is missing is rejected.
+func TestHandleWebhook_OrderNotFound(t *testing.T) {
+	service, mockDB, _ := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{}, sql.ErrNoRows)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "order_not_found")
+}
+
+// TestHandleWebhook_OrderUpdateErrors tests that failures updating the order or recording its history are returned.
+func TestHandleWebhook_OrderUpdateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		updateErr  error
+		historyErr error
+		message    string
+	}{
+		{name: "update order", updateErr: errors.New("db error"), message: "Failed to update order status"},
+		{name: "record history", historyErr: errors.New("db error"), message: "Failed to record order status history"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventFailed)
+			mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123"}, nil)
+			mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+			mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+			mockDB.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(tt.updateErr)
+			mockDB.On("CreateOrderStatusHistory", mock.Anything, mock.Anything).Return(tt.historyErr).Maybe()
+
+			err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+			requireAppErrorCode(t, err, "database_error")
+			assert.Contains(t, err.Error(), tt.message)
+			mockTx.AssertNotCalled(t, "Commit")
+		})
+	}
+}
+
+// TestHandleWebhook_ChargeWithoutPaymentIntent tests that a refunded charge with no payment intent is rejected.
+func TestHandleWebhook_ChargeWithoutPaymentIntent(t *testing.T) {
+	service, mockDB, _ := newWebhookTestService("charge.refunded", `{"id":"ch_test_123"}`, webhookEventFailed)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "webhook_error")
+	mockDB.AssertNotCalled(t, "GetPaymentByProviderPaymentID", mock.Anything, mock.Anything)
+}
+
+// TestHandleWebhook_RecordsEvent tests that a delivered event is stored with its ID, type, payload, payment and creation time.
+func TestHandleWebhook_RecordsEvent(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockTx := new(mockPaymentDBTx)
+	mockStripe := new(mockStripeClient)
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+	payload := []byte(`{"id":"evt_123","type":"payment_intent.succeeded","created":1700000000,"data":{"object":{"id":"pi_test_123"}}}`)
+	mockStripe.On("ParseWebhook", payload, testSignatureService, testSecret).Return(stripe.Event{
+		ID:      "evt_123",
+		Type:    "payment_intent.succeeded",
+		Created: 1700000000,
+		Data:    &stripe.EventData{Raw: []byte(`{"id":"pi_test_123"}`)},
+	}, nil)
+	mockDB.On("CreateWebhookEvent", mock.Anything, mock.MatchedBy(func(p database.CreateWebhookEventParams) bool {
+		return p.ID == "evt_123" && p.Type == "payment_intent.succeeded" && string(p.Payload) == string(payload) &&
+			p.ProviderPaymentID.String == "pi_test_123" && p.EventCreatedAt.Equal(time.Unix(1700000000, 0))
+	})).Return(int64(1), nil)
+	mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockDB.On("GetWebhookEventByIDForUpdate", mock.Anything, "evt_123").Return(database.WebhookEvent{ID: "evt_123", Status: webhookEventReceived}, nil)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	mockDB.On("GetLatestProcessedWebhookEventTime", mock.Anything, "pi_test_123").Return(time.Time{}, sql.ErrNoRows)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "paid"}, nil)
+	mockDB.On("UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.ID == "evt_123" && p.Status == webhookEventProcessed && !p.LastError.Valid
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+	mockTx.On("Rollback").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), payload, testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_RecordEventError tests that a failure storing the event is reported before anything is applied.
+func TestHandleWebhook_RecordEventError(t *testing.T) {
+	mockDB := new(mockPaymentDBQueries)
+	mockDBConn := new(mockPaymentDBConn)
+	mockStripe := new(mockStripeClient)
+	service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+	mockStripe.On("ParseWebhook", mock.Anything, testSignatureService, testSecret).Return(stripe.Event{
+		ID:   "evt_123",
+		Type: "payment_intent.succeeded",
+		Data: &stripe.EventData{Raw: []byte(`{"id":"pi_test_123"}`)},
+	}, nil)
+	mockDB.On("CreateWebhookEvent", mock.Anything, mock.Anything).Return(int64(0), errors.New("db down"))
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "database_error")
+	mockDBConn.AssertNotCalled(t, "BeginTx", mock.Anything, mock.Anything)
+}
+
+// TestHandleWebhook_DuplicateEvent tests that a redelivered event that was already handled is acknowledged without changes.
+func TestHandleWebhook_DuplicateEvent(t *testing.T) {
+	for _, status := range []string{webhookEventProcessed, webhookEventSkipped} {
+		t.Run(status, func(t *testing.T) {
+			mockDB := new(mockPaymentDBQueries)
+			mockDBConn := new(mockPaymentDBConn)
+			mockTx := new(mockPaymentDBTx)
+			mockStripe := new(mockStripeClient)
+			service := &paymentServiceImpl{db: mockDB, dbConn: mockDBConn, providers: newPaymentProviders("sk_test_123", mockStripe)}
+
+			mockStripe.On("ParseWebhook", mock.Anything, testSignatureService, testSecret).Return(stripe.Event{
+				ID:   "evt_123",
+				Type: "payment_intent.payment_failed",
+				Data: &stripe.EventData{Raw: []byte(`{"id":"pi_test_123"}`)},
+			}, nil)
+			mockDB.On("CreateWebhookEvent", mock.Anything, mock.Anything).Return(int64(0), nil)
+			mockDBConn.On("BeginTx", mock.Anything, mock.Anything).Return(mockTx, nil)
+			mockDB.On("WithTx", mockTx).Return(mockDB)
+			mockDB.On("GetWebhookEventByIDForUpdate", mock.Anything, "evt_123").Return(database.WebhookEvent{ID: "evt_123", Status: status}, nil)
+			mockTx.On("Rollback").Return(nil)
+
+			err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+			require.NoError(t, err)
+
+			mockDB.AssertExpectations(t)
+			mockDB.AssertNotCalled(t, "GetPaymentByProviderPaymentID", mock.Anything, mock.Anything)
+			mockDB.AssertNotCalled(t, "UpdateWebhookEventResult", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestHandleWebhook_StaleEventSkipped tests that an event older than the last applied event for the payment is skipped.
+func TestHandleWebhook_StaleEventSkipped(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.payment_failed", `{"id":"pi_test_123"}`, webhookEventSkipped)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "pending"}, nil)
+	// Overrides the default of no previously applied events
+	mockDB.ExpectedCalls = removeExpectedCall(mockDB.ExpectedCalls, "GetLatestProcessedWebhookEventTime")
+	mockDB.On("GetLatestProcessedWebhookEventTime", mock.Anything, "pi_test_123").Return(time.Unix(100, 0).UTC(), nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "pending"}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertNotCalled(t, "UpdatePaymentStatusByProviderPaymentID", mock.Anything, mock.Anything)
+	mockDB.AssertCalled(t, "UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.LastError.String == "Event is older than the last event applied to the payment"
+	}))
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_StatusRegressionSkipped tests that an event that would move a succeeded payment back to failed is skipped.
+func TestHandleWebhook_StatusRegressionSkipped(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.payment_failed", `{"id":"pi_test_123"}`, webhookEventSkipped)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{OrderID: "order123", Status: "succeeded"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "paid"}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertNotCalled(t, "UpdatePaymentStatusByProviderPaymentID", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertCalled(t, "UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.LastError.String == "Payment is already succeeded"
+	}))
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_CapturedAfterCancel tests that a payment succeeding after its order was cancelled is recorded as
+// succeeded and flagged with a full refund request, while the order stays cancelled.
+func TestHandleWebhook_CapturedAfterCancel(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{
+		ID: "payment123", OrderID: "order123", UserID: "user123", Amount: "30.00", Currency: "USD", Status: "cancelled",
+	}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(database.RefundRequest{}, sql.ErrNoRows)
+	mockDB.On("CreateRefundRequest", mock.Anything, mock.MatchedBy(func(p database.CreateRefundRequestParams) bool {
+		return p.OrderID == "order123" && p.PaymentID == "payment123" && p.UserID == "user123" &&
+			p.Amount == "30.00" && p.Currency == "USD" && string(p.Items) == "[]" && p.Reason == capturedAfterCancelReason
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertCalled(t, "UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return strings.HasPrefix(p.LastError.String, "Payment captured after the order was cancelled; refund request")
+	}))
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_CapturedWhileCancelPending tests that a payment captured before the void of its released order
+// landed is flagged with a refund request like one captured after the void.
+func TestHandleWebhook_CapturedWhileCancelPending(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{ID: "payment123", OrderID: "order123", Status: "cancel_pending"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(database.RefundRequest{}, sql.ErrNoRows)
+	mockDB.On("CreateRefundRequest", mock.Anything, mock.MatchedBy(func(p database.CreateRefundRequestParams) bool {
+		return p.OrderID == "order123" && p.PaymentID == "payment123" && p.Reason == capturedAfterCancelReason
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_CancelPendingVoided tests that the provider's cancel event settles a cancel_pending payment
+// without touching its already cancelled order.
+func TestHandleWebhook_CancelPendingVoided(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.canceled", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{ID: "payment123", OrderID: "order123", Status: "cancel_pending"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("cancelled")).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "CreateOrderStatusHistory", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_CapturedAfterCancelRequestPending tests that no second refund request is filed when one is pending.
+func TestHandleWebhook_CapturedAfterCancelRequestPending(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventProcessed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{ID: "payment123", OrderID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(database.RefundRequest{ID: "rr1"}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	require.NoError(t, err)
+
+	mockDB.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+	mockTx.AssertExpectations(t)
+}
+
+// TestHandleWebhook_CapturedAfterCancelError tests that a failure filing the refund request rolls the event back.
+func TestHandleWebhook_CapturedAfterCancelError(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{ID: "payment123", OrderID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("GetOrderByIDForUpdate", mock.Anything, "order123").Return(database.Order{ID: "order123", Status: "cancelled"}, nil)
+	mockDB.On("UpdatePaymentStatusByProviderPaymentID", mock.Anything, matchWebhookPaymentUpdate("succeeded")).Return(nil)
+	mockDB.On("GetPendingRefundRequestByOrderID", mock.Anything, "order123").Return(database.RefundRequest{}, sql.ErrNoRows)
+	mockDB.On("CreateRefundRequest", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "database_error")
+	mockTx.AssertNotCalled(t, "Commit")
+}
+
+// TestHandleWebhook_FailureRecorded tests that a failed event is marked failed with the error after the transaction is rolled back.
+func TestHandleWebhook_FailureRecorded(t *testing.T) {
+	service, mockDB, mockTx := newWebhookTestService("payment_intent.succeeded", `{"id":"pi_test_123"}`, webhookEventFailed)
+	mockDB.On("GetPaymentByProviderPaymentID", mock.Anything, "pi_test_123").Return(database.Payment{}, sql.ErrNoRows)
+
+	err := service.HandleWebhook(context.Background(), []byte(`{}`), testSignatureService, testSecret)
+	requireAppErrorCode(t, err, "payment_not_found")
+
+	mockDB.AssertCalled(t, "UpdateWebhookEventResult", mock.Anything, mock.MatchedBy(func(p database.UpdateWebhookEventResultParams) bool {
+		return p.LastError.Valid && p.LastError.String == err.Error()
+	}))
+	mockTx.AssertNotCalled(t, "Commit")
+}
+
+// TestIsPaymentStatusRegression tests which payment status changes count as moving backwards.
+func TestIsPaymentStatusRegression(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"pending", "succeeded", false},
+		{"pending", "failed", false},
+		{"failed", "succeeded", false},
+		{"succeeded", "refunded", false},
+		{"succeeded", "partially_refunded", false},
+		{"partially_refunded", "partially_refunded", false},
+		{"partially_refunded", "refunded", false},
+		{"refunded", "partially_refunded", true},
+		{"succeeded", "succeeded", false},
+		{"succeeded", "failed", true},
+		{"succeeded", "cancelled", true},
+		{"refunded", "succeeded", true},
+		{"cancelled", "failed", true},
+		{"cancel_pending", "cancelled", false},
+		{"cancel_pending", "succeeded", false},
+		{"cancel_pending", "failed", true},
+		{"unknown", "failed", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isPaymentStatusRegression(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+// removeExpectedCall drops the expectations for a method so a test can replace a shared default.
+func removeExpectedCall(calls []*mock.Call, method string) []*mock.Call {
+	kept := calls[:0]
+	for _, call := range calls {
+		if call.Method != method {
+			kept = append(kept, call)
+		}
+	}
+	return kept
+}
@@ -2,6 +2,7 @@
 package paymenthandlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"sync"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	userhandlers "github.com/STaninnat/ecom-backend/handlers/user"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // payment_wrapper.go: Provides payment handler configuration, service initialization, and error handling.
@@ -19,7 +21,9 @@ import (
 // Embeds the base handlers config and provides access to the payment service for business logic operations.
 type HandlersPaymentConfig struct {
 	*handlers.Config
-	Logger         handlers.HandlerLogger
+	Logger handlers.HandlerLogger
+	// RefundPolicies decide whether refund requests may be filed and approved; DefaultRefundPolicies apply when empty.
+	RefundPolicies []RefundPolicy
 	paymentService PaymentService
 	paymentMutex   sync.RWMutex
 }
@@ -52,6 +56,7 @@ func (cfg *HandlersPaymentConfig) InitPaymentService() error {
 		cfg.DB,
 		cfg.DBConn,
 		cfg.StripeSecretKey,
+		cfg.RefundPolicies...,
 	)
 
 	// Set Logger if not already set
@@ -87,6 +92,7 @@ func (cfg *HandlersPaymentConfig) GetPaymentService() PaymentService {
 				cfg.DB,
 				cfg.DBConn,
 				cfg.StripeSecretKey,
+				cfg.RefundPolicies...,
 			)
 		}
 	}
@@ -103,40 +109,60 @@ func (cfg *HandlersPaymentConfig) SetupStripeAPI() {
 // Categorizes errors and provides appropriate HTTP status codes and messages. All errors are logged with context information for debugging.
 func (cfg *HandlersPaymentConfig) handlePaymentError(w http.ResponseWriter, r *http.Request, err error, operation, ip, userAgent string) {
 	codeMap := map[string]userhandlers.ErrorResponseConfig{
-		"invalid_request":      {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
-		"missing_order_id":     {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
-		"missing_user_id":      {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
-		"invalid_currency":     {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
-		"payment_exists":       {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
-		"order_not_found":      {Status: http.StatusNotFound, Message: "", UseAppErr: true},
-		"payment_not_found":    {Status: http.StatusNotFound, Message: "", UseAppErr: true},
-		"unauthorized":         {Status: http.StatusForbidden, Message: "", UseAppErr: true},
-		"invalid_order_status": {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
-		"invalid_status":       {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
-		"invalid_amount":       {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
-		"invalid_payment":      {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
-		"database_error":       {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
-		"transaction_error":    {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
-		"commit_error":         {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
-		"stripe_error":         {Status: http.StatusInternalServerError, Message: "Payment service error", UseAppErr: true},
-		"webhook_error":        {Status: http.StatusInternalServerError, Message: "Payment service error", UseAppErr: true},
-		"unauthorized_payment": {Status: http.StatusForbidden, Message: "", UseAppErr: true},
-		"unauthorized_order":   {Status: http.StatusForbidden, Message: "", UseAppErr: true},
-		"unauthorized_user":    {Status: http.StatusForbidden, Message: "", UseAppErr: true},
-		"user_not_found":       {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"invalid_request":            {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
+		"missing_order_id":           {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
+		"missing_user_id":            {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
+		"invalid_currency":           {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
+		"currency_mismatch":          {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"payment_exists":             {Status: http.StatusBadRequest, Message: "", UseAppErr: false},
+		"order_not_found":            {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"payment_not_found":          {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"webhook_event_not_found":    {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"webhook_event_not_failed":   {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"unauthorized":               {Status: http.StatusForbidden, Message: "", UseAppErr: true},
+		"invalid_order_status":       {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"invalid_status":             {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"invalid_status_transition":  {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_request_not_found":   {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"refund_request_not_pending": {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_request_exists":      {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_in_progress":         {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_not_found":           {Status: http.StatusNotFound, Message: "", UseAppErr: true},
+		"refund_not_retryable":       {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_not_allowed":         {Status: http.StatusUnprocessableEntity, Message: "", UseAppErr: true},
+		"refund_exceeds_balance":     {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"refund_exceeds_quantity":    {Status: http.StatusConflict, Message: "", UseAppErr: true},
+		"invalid_amount":             {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"invalid_payment":            {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"invalid_provider":           {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"provider_not_supported":     {Status: http.StatusBadRequest, Message: "", UseAppErr: true},
+		"database_error":             {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
+		"transaction_error":          {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
+		"commit_error":               {Status: http.StatusInternalServerError, Message: "Something went wrong, please try again later", UseAppErr: true},
+		"stripe_error":               {Status: http.StatusInternalServerError, Message: "Payment service error", UseAppErr: true},
+		"webhook_error":              {Status: http.StatusInternalServerError, Message: "Payment service error", UseAppErr: true},
+		"unauthorized_payment":       {Status: http.StatusForbidden, Message: "", UseAppErr: true},
+		"unauthorized_order":         {Status: http.StatusForbidden, Message: "", UseAppErr: true},
+		"unauthorized_user":          {Status: http.StatusForbidden, Message: "", UseAppErr: true},
+		"user_not_found":             {Status: http.StatusNotFound, Message: "", UseAppErr: true},
 	}
 	userhandlers.HandleErrorWithCodeMap(cfg.Logger, w, r, err, operation, ip, userAgent, codeMap, http.StatusInternalServerError, "Internal server error")
 }
 
 // CreatePaymentIntentRequest represents the request structure for creating a payment intent.
+// Provider is stripe or manual; stripe is used when it is omitted.
 type CreatePaymentIntentRequest struct {
 	OrderID  string `json:"order_id"`
 	Currency string `json:"currency"`
+	Provider string `json:"provider,omitempty"`
 }
 
 // CreatePaymentIntentResponse represents the response structure for a created payment intent.
+// ClientSecret is only set for Stripe payments; manual payments quote ProviderPaymentID as their reference.
 type CreatePaymentIntentResponse struct {
-	ClientSecret string `json:"client_secret"`
+	ClientSecret      string `json:"client_secret"`
+	Provider          string `json:"provider"`
+	ProviderPaymentID string `json:"provider_payment_id"`
 }
 
 // ConfirmPaymentRequest represents the request structure for confirming a payment.
@@ -149,17 +175,41 @@ type ConfirmPaymentResponse struct {
 	Status string `json:"status"`
 }
 
+// RefundPaymentRequest represents the request structure for a customer's refund request.
+// Reason is required. Amount and Items are mutually exclusive; when both are omitted the remaining balance is requested.
+type RefundPaymentRequest struct {
+	Amount json.Number       `json:"amount,omitempty" swaggertype:"string"`
+	Items  []RefundItemInput `json:"items,omitempty"`
+	Reason string            `json:"reason,omitempty"`
+}
+
+// RefundPaymentResponse represents the response structure for a processed refund.
+type RefundPaymentResponse struct {
+	Message string `json:"message"`
+	RefundPaymentResult
+}
+
+// MarkPaymentReceivedRequest represents the request structure for marking a manual payment received.
+type MarkPaymentReceivedRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// ReviewRefundRequestRequest represents the request structure for approving or rejecting a refund request.
+type ReviewRefundRequestRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
 // GetPaymentResponse represents the response structure for payment details.
 type GetPaymentResponse struct {
-	ID                string    `json:"id"`
-	OrderID           string    `json:"order_id"`
-	UserID            string    `json:"user_id"`
-	Amount            float64   `json:"amount"`
-	Currency          string    `json:"currency"`
-	Status            string    `json:"status"`
-	Provider          string    `json:"provider"`
-	ProviderPaymentID string    `json:"provider_payment_id"`
-	CreatedAt         time.Time `json:"created_at"`
+	ID                string       `json:"id"`
+	OrderID           string       `json:"order_id"`
+	UserID            string       `json:"user_id"`
+	Amount            money.Amount `json:"amount"`
+	Currency          string       `json:"currency"`
+	Status            string       `json:"status"`
+	Provider          string       `json:"provider"`
+	ProviderPaymentID string       `json:"provider_payment_id"`
+	CreatedAt         time.Time    `json:"created_at"`
 }
 
 // PaymentHistoryItem represents a payment item in the payment history.
@@ -16,6 +16,7 @@ import (
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/config"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // payment_wrapper_test.go: Tests for payment handler setup, initialization, and thread safety.
@@ -233,7 +234,7 @@ func TestHandlePaymentError_AllErrorCodes(t *testing.T) {
 	runHandlePaymentErrorStatusTest(t, forbiddenCodes, http.StatusForbidden, cfg, req, mockHandlersConfig, "test_op")
 
 	// Test all error codes that should return 400 Bad Request
-	badRequestCodes := []string{"invalid_request", "missing_order_id", "missing_user_id", "invalid_currency", "payment_exists", "invalid_order_status", "invalid_status", "invalid_amount", "invalid_payment"}
+	badRequestCodes := []string{"invalid_request", "missing_order_id", "missing_user_id", "invalid_currency", "currency_mismatch", "payment_exists", "invalid_order_status", "invalid_status", "invalid_amount", "invalid_payment"}
 	for _, code := range badRequestCodes {
 		t.Run("BadRequest_"+code, func(t *testing.T) {
 			w := httptest.NewRecorder()
@@ -310,6 +311,18 @@ func TestHandlePaymentError_StatusCodes(t *testing.T) {
 		{name: "Forbidden_unauthorized_payment", code: "unauthorized_payment", expectedStatus: http.StatusForbidden},
 		{name: "Forbidden_unauthorized_order", code: "unauthorized_order", expectedStatus: http.StatusForbidden},
 		{name: "Forbidden_unauthorized_user", code: "unauthorized_user", expectedStatus: http.StatusForbidden},
+		// Conflict codes
+		{name: "Conflict_invalid_status_transition", code: "invalid_status_transition", expectedStatus: http.StatusConflict},
+		{name: "Conflict_refund_exceeds_balance", code: "refund_exceeds_balance", expectedStatus: http.StatusConflict},
+		{name: "Conflict_refund_exceeds_quantity", code: "refund_exceeds_quantity", expectedStatus: http.StatusConflict},
+		{name: "NotFound_refund_request_not_found", code: "refund_request_not_found", expectedStatus: http.StatusNotFound},
+		{name: "Conflict_refund_request_not_pending", code: "refund_request_not_pending", expectedStatus: http.StatusConflict},
+		{name: "Conflict_refund_request_exists", code: "refund_request_exists", expectedStatus: http.StatusConflict},
+		{name: "Unprocessable_refund_not_allowed", code: "refund_not_allowed", expectedStatus: http.StatusUnprocessableEntity},
+		{name: "BadRequest_invalid_provider", code: "invalid_provider", expectedStatus: http.StatusBadRequest},
+		{name: "BadRequest_provider_not_supported", code: "provider_not_supported", expectedStatus: http.StatusBadRequest},
+		{name: "Conflict_webhook_event_not_failed", code: "webhook_event_not_failed", expectedStatus: http.StatusConflict},
+		{name: "NotFound_webhook_event_not_found", code: "webhook_event_not_found", expectedStatus: http.StatusNotFound},
 	}
 
 	for _, tc := range testCases {
@@ -366,7 +379,7 @@ func TestPaymentRequestResponseTypes(t *testing.T) {
 		ID:                "pay_123",
 		OrderID:           "order_123",
 		UserID:            "user_123",
-		Amount:            100.50,
+		Amount:            money.MustParse("100.50", money.USD),
 		Currency:          "USD",
 		Status:            "succeeded",
 		Provider:          "stripe",
@@ -375,7 +388,7 @@ func TestPaymentRequestResponseTypes(t *testing.T) {
 	assert.Equal(t, "pay_123", getResp.ID)
 	assert.Equal(t, "order_123", getResp.OrderID)
 	assert.Equal(t, "user_123", getResp.UserID)
-	assert.InEpsilon(t, 100.50, getResp.Amount, 0.001)
+	assert.Equal(t, "100.50", getResp.Amount.String())
 	assert.Equal(t, "USD", getResp.Currency)
 	assert.Equal(t, "succeeded", getResp.Status)
 	assert.Equal(t, "stripe", getResp.Provider)
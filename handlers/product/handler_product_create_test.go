@@ -15,6 +15,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // handler_product_create_test.go: Tests the product creation handler for success, invalid input, and service error scenarios with proper responses and logging.
@@ -31,7 +32,7 @@ func TestHandlerCreateProduct_Success(t *testing.T) {
 		productService: mockService,
 	}
 	user := database.User{ID: "u1"}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	jsonBody, _ := json.Marshal(params)
 	mockService.On("CreateProduct", mock.Anything, params).Return("pid1", nil)
 	mockLog.On("LogHandlerSuccess", mock.Anything, "create_product", "Created product successful", mock.Anything, mock.Anything).Return()
@@ -115,7 +116,7 @@ func TestHandlerCreateProduct_ServiceError(t *testing.T) {
 		productService: mockService,
 	}
 	user := database.User{ID: "u1"}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	jsonBody, _ := json.Marshal(params)
 	err := &handlers.AppError{Code: "create_product_error", Message: "fail", Err: errors.New("fail")}
 	mockService.On("CreateProduct", mock.Anything, params).Return("", err)
@@ -17,7 +17,7 @@ import (
 
 // HandlerDeleteProduct handles HTTP DELETE requests to remove a product by its ID.
 // @Summary      Delete product
-// @Description  Deletes a product by its ID
+// @Description  Deletes a product by its ID, along with its image gallery and image files
 // @Tags         products
 // @Produce      json
 // @Param        id  path  string  true  "Product ID"
@@ -8,19 +8,23 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/middlewares"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
-// handler_product_filter.go: Handles filtering products: parses filter params, calls service, logs result, and returns matching products.
+// handler_product_filter.go: Handles filtering products: parses filter params, calls service, logs result, and returns a page of matching products.
 
-// HandlerFilterProducts handles HTTP POST requests to filter products based on provided criteria.
+// HandlerFilterProducts handles HTTP GET requests to filter products based on provided criteria, one page at a time.
+// The cursor and limit are query parameters so each page has its own cache entry.
 // @Summary      Filter products
-// @Description  Filters products based on provided criteria
+// @Description  Filters products based on provided criteria. Supports min_rating and sort (newest, oldest, price_asc, price_desc, name_asc, name_desc, rating_desc, rating_asc, reviews_desc)
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        filter  body  object{}  true  "Filter payload"
+// @Param        filter  body   object{}  true   "Filter payload"
+// @Param        limit   query  int       false  "Products per page, 1-100 (default 20)"
+// @Param        cursor  query  string    false  "next_cursor from the previous page"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Router       /v1/products/filter [get]
@@ -41,18 +45,12 @@ func (cfg *HandlersProductConfig) HandlerFilterProducts(w http.ResponseWriter, r
 		return
 	}
 
-	products, err := cfg.GetProductService().FilterProducts(ctx, params)
+	products, err := cfg.GetProductService().FilterProducts(ctx, params, pagination.ParseRequest(r))
 	if err != nil {
 		cfg.handleProductError(w, r, err, "filter_products", ip, userAgent)
 		return
 	}
 
-	productResp := struct {
-		Products []database.Product `json:"products"`
-	}{
-		Products: products,
-	}
-
 	userID := ""
 	if user != nil {
 		userID = user.ID
@@ -61,5 +59,5 @@ func (cfg *HandlersProductConfig) HandlerFilterProducts(w http.ResponseWriter, r
 	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, userID)
 	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "filter_products", "Filter products success", ip, userAgent)
 
-	middlewares.RespondWithJSON(w, http.StatusOK, productResp)
+	middlewares.RespondWithJSON(w, http.StatusOK, products)
 }
@@ -11,9 +11,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // handler_product_filter_test.go: Tests the filter products handler for success, invalid input, and service error with expected responses and logging.
@@ -31,16 +33,19 @@ func TestHandlerFilterProducts_Success(t *testing.T) {
 	}
 	user := &database.User{ID: "u1"}
 	params := FilterProductsRequest{}
-	products := []database.Product{{ID: "p1"}, {ID: "p2"}}
-	mockService.On("FilterProducts", mock.Anything, params).Return(products, nil)
+	products := pagination.Page[ProductResponse]{Data: []ProductResponse{{Product: database.Product{ID: "p1"}}, {Product: database.Product{ID: "p2"}}}, Limit: 2}
+	mockService.On("FilterProducts", mock.Anything, params, pagination.Request{Cursor: "abc", Limit: 2}).Return(products, nil)
 	mockLog.On("LogHandlerSuccess", mock.Anything, "filter_products", "Filter products success", mock.Anything, mock.Anything).Return()
 	jsonBody, _ := json.Marshal(params)
 
-	req := httptest.NewRequest("POST", "/products/filter", bytes.NewBuffer(jsonBody))
+	req := httptest.NewRequest("POST", "/products/filter?cursor=abc&limit=2", bytes.NewBuffer(jsonBody))
 	w := httptest.NewRecorder()
 
 	cfg.HandlerFilterProducts(w, req, user)
 	assert.Equal(t, http.StatusOK, w.Code)
+	var resp pagination.Page[ProductResponse]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, products, resp)
 	mockService.AssertExpectations(t)
 	mockLog.AssertExpectations(t)
 }
@@ -82,7 +87,7 @@ func TestHandlerFilterProducts_ServiceError(t *testing.T) {
 	user := &database.User{ID: "u1"}
 	params := FilterProductsRequest{}
 	err := &handlers.AppError{Code: "transaction_error", Message: "fail", Err: errors.New("fail")}
-	mockService.On("FilterProducts", mock.Anything, params).Return(nil, err)
+	mockService.On("FilterProducts", mock.Anything, params, pagination.Request{Limit: pagination.DefaultLimit}).Return(nil, err)
 	mockLog.On("LogHandlerError", mock.Anything, "filter_products", "transaction_error", "fail", mock.Anything, mock.Anything, err.Err).Return()
 	jsonBody, _ := json.Marshal(params)
 
@@ -9,18 +9,22 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 	"github.com/STaninnat/ecom-backend/middlewares"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
-// handler_product_get.go: Handles retrieving all products or by ID with admin check, logging, and JSON response.
+// handler_product_get.go: Handles retrieving a page of products or one by ID with admin check, logging, and JSON response.
 
-// HandlerGetAllProducts handles HTTP GET requests to retrieve all products.
+// HandlerGetAllProducts handles HTTP GET requests to retrieve products one page at a time.
 // @Summary      Get all products
-// @Description  Retrieves all products
+// @Description  Retrieves a page of products. Pass next_cursor back as cursor to get the next page
 // @Tags         products
 // @Produce      json
-// @Success      200  {array}  map[string]interface{}
+// @Param        sort    query  string  false  "newest (default), oldest, price_asc, price_desc, name_asc, name_desc, rating_desc, rating_asc, reviews_desc"
+// @Param        limit   query  int     false  "Products per page, 1-100 (default 20)"
+// @Param        cursor  query  string  false  "next_cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
 // @Router       /v1/products/ [get]
 func (cfg *HandlersProductConfig) HandlerGetAllProducts(w http.ResponseWriter, r *http.Request, user *database.User) {
@@ -28,7 +32,7 @@ func (cfg *HandlersProductConfig) HandlerGetAllProducts(w http.ResponseWriter, r
 	ctx := r.Context()
 
 	isAdmin := user != nil && user.Role == "admin"
-	products, err := cfg.GetProductService().GetAllProducts(ctx, isAdmin)
+	products, err := cfg.GetProductService().GetAllProducts(ctx, isAdmin, pagination.ParseRequest(r))
 	if err != nil {
 		cfg.handleProductError(w, r, err, "get_products", ip, userAgent)
 		return
@@ -3,6 +3,7 @@ package producthandlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -11,12 +12,14 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
-// handler_product_get_test.go: Tests handlers for retrieving all products and by ID, covering success, missing ID, and service error cases.
+// handler_product_get_test.go: Tests handlers for retrieving a page of products and by ID, covering success, missing ID, and service error cases.
 
 // TestHandlerGetAllProducts_Success tests the successful retrieval of all products via the handler.
 // It verifies that the handler returns HTTP 200 and logs success when the service returns products without error.
@@ -30,15 +33,19 @@ func TestHandlerGetAllProducts_Success(t *testing.T) {
 		productService: mockService,
 	}
 	user := &database.User{ID: "u1", Role: "admin"}
-	products := []database.Product{{ID: "p1"}, {ID: "p2"}}
-	mockService.On("GetAllProducts", mock.Anything, true).Return(products, nil)
+	products := pagination.Page[ProductResponse]{Data: []ProductResponse{{Product: database.Product{ID: "p1"}}, {Product: database.Product{ID: "p2"}}}, Limit: 2, NextCursor: "next", HasNext: true}
+	page := pagination.Request{Cursor: "abc", Limit: 2, Sort: "price_asc"}
+	mockService.On("GetAllProducts", mock.Anything, true, page).Return(products, nil)
 	mockLog.On("LogHandlerSuccess", mock.Anything, "get_products", "Get all products success", mock.Anything, mock.Anything).Return()
 
-	req := httptest.NewRequest("GET", "/products", nil)
+	req := httptest.NewRequest("GET", "/products?cursor=abc&limit=2&sort=price_asc", nil)
 	w := httptest.NewRecorder()
 
 	cfg.HandlerGetAllProducts(w, req, user)
 	assert.Equal(t, http.StatusOK, w.Code)
+	var resp pagination.Page[ProductResponse]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, products, resp)
 	mockService.AssertExpectations(t)
 	mockLog.AssertExpectations(t)
 }
@@ -56,7 +63,7 @@ func TestHandlerGetAllProducts_ServiceError(t *testing.T) {
 	}
 	user := &database.User{ID: "u1", Role: "admin"}
 	err := &handlers.AppError{Code: "transaction_error", Message: "fail", Err: errors.New("fail")}
-	mockService.On("GetAllProducts", mock.Anything, true).Return(nil, err)
+	mockService.On("GetAllProducts", mock.Anything, true, pagination.Request{Limit: pagination.DefaultLimit}).Return(nil, err)
 	mockLog.On("LogHandlerError", mock.Anything, "get_products", "transaction_error", "fail", mock.Anything, mock.Anything, err.Err).Return()
 
 	req := httptest.NewRequest("GET", "/products", nil)
@@ -80,7 +87,7 @@ func TestHandlerGetProductByID_Success(t *testing.T) {
 		productService: mockService,
 	}
 	user := database.User{ID: "u1", Role: "admin"}
-	product := database.Product{ID: "p1"}
+	product := ProductResponse{Product: database.Product{ID: "p1"}}
 	mockService.On("GetProductByID", mock.Anything, "p1", true).Return(product, nil)
 	mockLog.On("LogHandlerSuccess", mock.Anything, "get_product_by_id", "Get products success", mock.Anything, mock.Anything).Return()
 
@@ -131,7 +138,7 @@ func TestHandlerGetProductByID_ServiceError(t *testing.T) {
 	}
 	user := database.User{ID: "u1", Role: "admin"}
 	err := &handlers.AppError{Code: "product_not_found", Message: "fail", Err: errors.New("fail")}
-	mockService.On("GetProductByID", mock.Anything, "p1", true).Return(database.Product{}, err)
+	mockService.On("GetProductByID", mock.Anything, "p1", true).Return(ProductResponse{}, err)
 	mockLog.On("LogHandlerError", mock.Anything, "get_product_by_id", "product_not_found", "fail", mock.Anything, mock.Anything, err.Err).Return()
 
 	routeCtx := chi.NewRouteContext()
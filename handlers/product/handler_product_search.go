@@ -0,0 +1,107 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_product_search.go: Handles keyword search over products: parses query params, calls service, logs result, and returns ranked matches.
+
+// HandlerSearchProducts handles HTTP GET requests to search active products by keyword.
+// @Summary      Search products
+// @Description  Full-text search over product names and descriptions, ranked by relevance with highlighted snippets. Combines with the filter fields
+// @Tags         products
+// @Produce      json
+// @Param        q           query  string  true   "Keywords; supports quoted phrases, OR and -exclusions"
+// @Param        category_id query  string  false  "Category ID"
+// @Param        min_price   query  number  false  "Minimum price"
+// @Param        max_price   query  number  false  "Maximum price"
+// @Param        min_rating  query  number  false  "Minimum average rating"
+// @Param        sort        query  string  false  "relevance (default), newest, rating_desc, rating_asc, reviews_desc"
+// @Param        limit       query  int     false  "Results to return, 1-100 (default 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/products/search [get]
+func (cfg *HandlersProductConfig) HandlerSearchProducts(w http.ResponseWriter, r *http.Request, user *database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	params, err := parseSearchProductsRequest(r)
+	if err != nil {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"search_products",
+			"invalid_request",
+			"Invalid search parameters",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := cfg.GetProductService().SearchProducts(ctx, params)
+	if err != nil {
+		cfg.handleProductError(w, r, err, "search_products", ip, userAgent)
+		return
+	}
+
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, userID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "search_products", "Search products success", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, struct {
+		Products []ProductSearchResult `json:"products"`
+	}{
+		Products: results,
+	})
+}
+
+// parseSearchProductsRequest reads the search and filter query parameters. Empty parameters are left unset.
+func parseSearchProductsRequest(r *http.Request) (SearchProductsRequest, error) {
+	q := r.URL.Query()
+	params := SearchProductsRequest{
+		Query: q.Get("q"),
+		Sort:  q.Get("sort"),
+	}
+	if v := q.Get("category_id"); v != "" {
+		params.CategoryID = utils.NullString{NullString: sql.NullString{String: v, Valid: true}}
+	}
+
+	for name, dst := range map[string]*utils.NullFloat64{
+		"min_price":  &params.MinPrice,
+		"max_price":  &params.MaxPrice,
+		"min_rating": &params.MinRating,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return SearchProductsRequest{}, fmt.Errorf("%s must be a non-negative number", name)
+		}
+		dst.NullFloat64 = sql.NullFloat64{Float64: f, Valid: true}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return SearchProductsRequest{}, fmt.Errorf("limit must be a positive integer")
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
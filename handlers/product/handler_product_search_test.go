@@ -0,0 +1,86 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_product_search_test.go: Tests the search products handler for query parsing, success, invalid parameters, and service errors.
+
+// TestHandlerSearchProducts_Success tests that query parameters reach the service and results are returned with their highlights.
+func TestHandlerSearchProducts_Success(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+
+	var want SearchProductsRequest
+	want.Query = "red shoes"
+	want.CategoryID.String, want.CategoryID.Valid = "c1", true
+	want.MinPrice.Float64, want.MinPrice.Valid = 10, true
+	want.MinRating.Float64, want.MinRating.Valid = 4.5, true
+	want.Sort = "newest"
+	want.Limit = 5
+	mockService.On("SearchProducts", mock.Anything, want).Return([]ProductSearchResult{
+		{Product: database.Product{ID: "p1", Name: "Red Shoes"}, Rank: 0.5, NameHighlight: "<mark>Red</mark> <mark>Shoes</mark>"},
+	}, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "search_products", "Search products success", mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest("GET", "/products/search?q=red+shoes&category_id=c1&min_price=10&min_rating=4.5&sort=newest&limit=5", nil)
+	w := httptest.NewRecorder()
+	cfg.HandlerSearchProducts(w, req, nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Products []ProductSearchResult `json:"products"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Products, 1)
+	assert.Equal(t, "p1", resp.Products[0].ID)
+	assert.Equal(t, "<mark>Red</mark> <mark>Shoes</mark>", resp.Products[0].NameHighlight)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerSearchProducts_InvalidParams tests that malformed numbers are rejected without calling the service.
+func TestHandlerSearchProducts_InvalidParams(t *testing.T) {
+	for _, query := range []string{"q=shoes&min_price=cheap", "q=shoes&max_price=-1", "q=shoes&limit=0", "q=shoes&limit=ten"} {
+		mockService := new(MockProductService)
+		mockLog := new(mockLogger)
+		cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+		mockLog.On("LogHandlerError", mock.Anything, "search_products", "invalid_request", "Invalid search parameters", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerSearchProducts(w, httptest.NewRequest("GET", "/products/search?"+query, nil), nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		mockService.AssertNotCalled(t, "SearchProducts", mock.Anything, mock.Anything)
+		mockLog.AssertExpectations(t)
+	}
+}
+
+// TestHandlerSearchProducts_ServiceError tests that service errors are mapped to status codes.
+func TestHandlerSearchProducts_ServiceError(t *testing.T) {
+	for code, status := range map[string]int{"invalid_request": http.StatusBadRequest, "search_error": http.StatusInternalServerError} {
+		mockService := new(MockProductService)
+		mockLog := new(mockLogger)
+		cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+		mockService.On("SearchProducts", mock.Anything, mock.Anything).Return(nil, &handlers.AppError{Code: code, Message: "failed"})
+		mockLog.On("LogHandlerError", mock.Anything, "search_products", code, "failed", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerSearchProducts(w, httptest.NewRequest("GET", "/products/search?q=shoes", nil), &database.User{ID: "u1"})
+
+		assert.Equal(t, status, w.Code, code)
+		mockLog.AssertExpectations(t)
+	}
+}
@@ -16,7 +16,7 @@ import (
 
 // HandlerUpdateProduct handles HTTP PUT requests to update an existing product.
 // @Summary      Update product
-// @Description  Updates an existing product
+// @Description  Updates an existing product. image_url is ignored once the product has an image gallery
 // @Tags         products
 // @Accept       json
 // @Produce      json
@@ -15,6 +15,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 )
 
 // handler_product_update_test.go: Tests the update product handler for success, invalid input, and service error with expected responses and logging.
@@ -31,7 +32,7 @@ func TestHandlerUpdateProduct_Success(t *testing.T) {
 		productService: mockService,
 	}
 	user := database.User{ID: "u1"}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	jsonBody, _ := json.Marshal(params)
 	mockService.On("UpdateProduct", mock.Anything, params).Return(nil)
 	mockLog.On("LogHandlerSuccess", mock.Anything, "update_product", "Updated product successfully", mock.Anything, mock.Anything).Return()
@@ -61,7 +62,7 @@ func TestHandlerUpdateProduct_ServiceError(t *testing.T) {
 		productService: mockService,
 	}
 	user := database.User{ID: "u1"}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	jsonBody, _ := json.Marshal(params)
 	err := &handlers.AppError{Code: "update_failed", Message: "fail", Err: errors.New("fail")}
 	mockService.On("UpdateProduct", mock.Anything, params).Return(err)
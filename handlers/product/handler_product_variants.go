@@ -0,0 +1,155 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_product_variants.go: Handles listing, creating, updating, and deleting the variants of a product.
+
+// HandlerListProductVariants handles HTTP GET requests to list a product's variants.
+// @Summary      List product variants
+// @Description  Lists the variants (SKU, size, color, price, stock) of a product. Non-admins only see active variants of active products
+// @Tags         products
+// @Produce      json
+// @Param        id  path  string  true  "Product ID"
+// @Success      200  {array}   ProductVariantResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/products/{id}/variants [get]
+func (cfg *HandlersProductConfig) HandlerListProductVariants(w http.ResponseWriter, r *http.Request, user *database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	isAdmin := user != nil && user.Role == "admin"
+	variants, err := cfg.GetProductService().ListProductVariants(ctx, chi.URLParam(r, "id"), isAdmin)
+	if err != nil {
+		cfg.handleProductError(w, r, err, "list_product_variants", ip, userAgent)
+		return
+	}
+
+	cfg.Logger.LogHandlerSuccess(ctx, "list_product_variants", "List product variants success", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, variants)
+}
+
+// HandlerCreateProductVariant handles HTTP POST requests to add a variant to a product.
+// @Summary      Create product variant
+// @Description  Adds a variant to a product. The SKU must be unique across all variants
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                 true  "Product ID"
+// @Param        variant  body  ProductVariantRequest  true  "Variant payload"
+// @Success      201  {object}  ProductVariantResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/products/{id}/variants [post]
+func (cfg *HandlersProductConfig) HandlerCreateProductVariant(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	var params ProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"create_product_variant",
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	variant, err := cfg.GetProductService().CreateProductVariant(ctx, chi.URLParam(r, "id"), params)
+	if err != nil {
+		cfg.handleProductError(w, r, err, "create_product_variant", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "create_product_variant", "Created product variant successful", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusCreated, variant)
+}
+
+// HandlerUpdateProductVariant handles HTTP PUT requests to update a product's variant.
+// @Summary      Update product variant
+// @Description  Replaces the SKU, options, price override, stock, and status of a product's variant
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id          path  string                 true  "Product ID"
+// @Param        variant_id  path  string                 true  "Variant ID"
+// @Param        variant     body  ProductVariantRequest  true  "Variant payload"
+// @Success      200  {object}  ProductVariantResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/products/{id}/variants/{variant_id} [put]
+func (cfg *HandlersProductConfig) HandlerUpdateProductVariant(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	var params ProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		cfg.Logger.LogHandlerError(
+			ctx,
+			"update_product_variant",
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	variant, err := cfg.GetProductService().UpdateProductVariant(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), params)
+	if err != nil {
+		cfg.handleProductError(w, r, err, "update_product_variant", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "update_product_variant", "Updated product variant successful", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, variant)
+}
+
+// HandlerDeleteProductVariant handles HTTP DELETE requests to retire a product's variant.
+// @Summary      Delete product variant
+// @Description  Deactivates a product's variant so past order items keep it. Refused while an open order contains the variant
+// @Tags         products
+// @Produce      json
+// @Param        id          path  string  true  "Product ID"
+// @Param        variant_id  path  string  true  "Variant ID"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/products/{id}/variants/{variant_id} [delete]
+func (cfg *HandlersProductConfig) HandlerDeleteProductVariant(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	err := cfg.GetProductService().DeleteProductVariant(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"))
+	if err != nil {
+		cfg.handleProductError(w, r, err, "delete_product_variant", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "delete_product_variant", "Delete product variant success", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Product variant deleted successfully",
+	})
+}
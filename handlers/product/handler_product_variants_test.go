@@ -0,0 +1,168 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_product_variants_test.go: Tests the product variant handlers for success, invalid payloads, and service errors mapped to status codes.
+
+// newVariantRequest builds a request carrying the product and, if set, variant ID route parameters.
+func newVariantRequest(method, body, productID, variantID string) *http.Request {
+	req := httptest.NewRequest(method, "/products/"+productID+"/variants", strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", productID)
+	if variantID != "" {
+		routeCtx.URLParams.Add("variant_id", variantID)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+// TestHandlerListProductVariants tests that guests list variants as non-admins and admins see all variants.
+func TestHandlerListProductVariants(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	variants := []ProductVariantResponse{{ID: "v1", ProductID: "p1", SKU: "TEE-XL", Price: "24.00"}}
+	mockService.On("ListProductVariants", mock.Anything, "p1", false).Return(variants, nil)
+	mockService.On("ListProductVariants", mock.Anything, "p1", true).Return(variants, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "list_product_variants", "List product variants success", mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerListProductVariants(w, newVariantRequest("GET", "", "p1", ""), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []ProductVariantResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, variants, resp)
+
+	w = httptest.NewRecorder()
+	cfg.HandlerListProductVariants(w, newVariantRequest("GET", "", "p1", ""), &database.User{ID: "u1", Role: "admin"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestHandlerListProductVariants_NotFound tests that an unknown product returns HTTP 404.
+func TestHandlerListProductVariants_NotFound(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	mockService.On("ListProductVariants", mock.Anything, "p1", false).Return(nil, &handlers.AppError{Code: "product_not_found", Message: "Product not found"})
+	mockLog.On("LogHandlerError", mock.Anything, "list_product_variants", "product_not_found", "Product not found", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerListProductVariants(w, newVariantRequest("GET", "", "p1", ""), &database.User{ID: "u1", Role: "user"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerCreateProductVariant_Success tests that a created variant is returned with HTTP 201.
+func TestHandlerCreateProductVariant_Success(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	created := ProductVariantResponse{ID: "v1", ProductID: "p1", SKU: "TEE-XL", Size: "XL", Price: "20.00", Stock: 3, IsActive: true}
+	mockService.On("CreateProductVariant", mock.Anything, "p1", ProductVariantRequest{SKU: "TEE-XL", Size: "XL", Stock: 3}).Return(created, nil)
+	mockLog.On("LogHandlerSuccess", mock.Anything, "create_product_variant", "Created product variant successful", mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerCreateProductVariant(w, newVariantRequest("POST", `{"sku":"TEE-XL","size":"XL","stock":3}`, "p1", ""), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp ProductVariantResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, created, resp)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerCreateProductVariant_InvalidPayload tests that a malformed body returns HTTP 400 without calling the service.
+func TestHandlerCreateProductVariant_InvalidPayload(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	mockLog.On("LogHandlerError", mock.Anything, "create_product_variant", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerCreateProductVariant(w, newVariantRequest("POST", `{"sku":`, "p1", ""), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "CreateProductVariant", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestHandlerCreateProductVariant_SKUConflict tests that a taken SKU returns HTTP 409.
+func TestHandlerCreateProductVariant_SKUConflict(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	mockService.On("CreateProductVariant", mock.Anything, "p1", mock.Anything).Return(ProductVariantResponse{}, &handlers.AppError{Code: "sku_conflict", Message: "SKU is already in use"})
+	mockLog.On("LogHandlerError", mock.Anything, "create_product_variant", "sku_conflict", "SKU is already in use", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerCreateProductVariant(w, newVariantRequest("POST", `{"sku":"TAKEN"}`, "p1", ""), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "SKU is already in use")
+}
+
+// TestHandlerUpdateProductVariant tests a successful update and an unknown variant returning HTTP 404.
+func TestHandlerUpdateProductVariant(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	updated := ProductVariantResponse{ID: "v1", ProductID: "p1", SKU: "TEE-XL", Stock: 7}
+	mockService.On("UpdateProductVariant", mock.Anything, "p1", "v1", ProductVariantRequest{SKU: "TEE-XL", Stock: 7}).Return(updated, nil)
+	mockService.On("UpdateProductVariant", mock.Anything, "p1", "v2", mock.Anything).Return(ProductVariantResponse{}, &handlers.AppError{Code: "variant_not_found", Message: "Product variant not found"})
+	mockLog.On("LogHandlerSuccess", mock.Anything, "update_product_variant", "Updated product variant successful", mock.Anything, mock.Anything).Return()
+	mockLog.On("LogHandlerError", mock.Anything, "update_product_variant", "variant_not_found", "Product variant not found", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerUpdateProductVariant(w, newVariantRequest("PUT", `{"sku":"TEE-XL","stock":7}`, "p1", "v1"), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	cfg.HandlerUpdateProductVariant(w, newVariantRequest("PUT", `{"sku":"TEE-XL"}`, "p1", "v2"), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
+
+// TestHandlerDeleteProductVariant tests a successful deletion, a variant in an open order returning HTTP 409,
+// and a service failure returning HTTP 500.
+func TestHandlerDeleteProductVariant(t *testing.T) {
+	mockService := new(MockProductService)
+	mockLog := new(mockLogger)
+	cfg := &HandlersProductConfig{Logger: mockLog, productService: mockService}
+	mockService.On("DeleteProductVariant", mock.Anything, "p1", "v1").Return(nil)
+	mockService.On("DeleteProductVariant", mock.Anything, "p1", "v2").Return(&handlers.AppError{Code: "delete_variant_error", Message: "Error deleting product variant"})
+	mockService.On("DeleteProductVariant", mock.Anything, "p1", "v3").Return(&handlers.AppError{Code: "variant_in_use", Message: "Product variant is part of an open order"})
+	mockLog.On("LogHandlerError", mock.Anything, "delete_product_variant", "variant_in_use", "Product variant is part of an open order", mock.Anything, mock.Anything, mock.Anything).Return()
+	mockLog.On("LogHandlerSuccess", mock.Anything, "delete_product_variant", "Delete product variant success", mock.Anything, mock.Anything).Return()
+	mockLog.On("LogHandlerError", mock.Anything, "delete_product_variant", "delete_variant_error", "Error deleting product variant", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerDeleteProductVariant(w, newVariantRequest("DELETE", "", "p1", "v1"), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.HandlerResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Product variant deleted successfully", resp.Message)
+
+	w = httptest.NewRecorder()
+	cfg.HandlerDeleteProductVariant(w, newVariantRequest("DELETE", "", "p1", "v2"), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	cfg.HandlerDeleteProductVariant(w, newVariantRequest("DELETE", "", "p1", "v3"), database.User{ID: "u1"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+	mockLog.AssertExpectations(t)
+}
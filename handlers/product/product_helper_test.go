@@ -4,10 +4,12 @@ package producthandlers
 import (
 	"context"
 	"database/sql"
+	"mime/multipart"
 
 	"github.com/stretchr/testify/mock"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
 )
 
 // product_helper_test.go: Provides testify-based mocks for ProductService, Logger, and database queries to support unit testing without real dependencies.
@@ -33,25 +35,56 @@ func (m *MockProductService) DeleteProduct(ctx context.Context, productID string
 	return args.Error(0)
 }
 
-func (m *MockProductService) GetAllProducts(ctx context.Context, isAdmin bool) ([]database.Product, error) {
-	args := m.Called(ctx, isAdmin)
+func (m *MockProductService) GetAllProducts(ctx context.Context, isAdmin bool, page pagination.Request) (pagination.Page[ProductResponse], error) {
+	args := m.Called(ctx, isAdmin, page)
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return pagination.Page[ProductResponse]{}, args.Error(1)
 	}
-	return args.Get(0).([]database.Product), args.Error(1)
+	return args.Get(0).(pagination.Page[ProductResponse]), args.Error(1)
 }
 
-func (m *MockProductService) GetProductByID(ctx context.Context, productID string, isAdmin bool) (database.Product, error) {
+func (m *MockProductService) GetProductByID(ctx context.Context, productID string, isAdmin bool) (ProductResponse, error) {
 	args := m.Called(ctx, productID, isAdmin)
-	return args.Get(0).(database.Product), args.Error(1)
+	return args.Get(0).(ProductResponse), args.Error(1)
+}
+
+func (m *MockProductService) FilterProducts(ctx context.Context, params FilterProductsRequest, page pagination.Request) (pagination.Page[ProductResponse], error) {
+	args := m.Called(ctx, params, page)
+	if args.Get(0) == nil {
+		return pagination.Page[ProductResponse]{}, args.Error(1)
+	}
+	return args.Get(0).(pagination.Page[ProductResponse]), args.Error(1)
 }
 
-func (m *MockProductService) FilterProducts(ctx context.Context, params FilterProductsRequest) ([]database.Product, error) {
+func (m *MockProductService) SearchProducts(ctx context.Context, params SearchProductsRequest) ([]ProductSearchResult, error) {
 	args := m.Called(ctx, params)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]database.Product), args.Error(1)
+	return args.Get(0).([]ProductSearchResult), args.Error(1)
+}
+
+func (m *MockProductService) ListProductVariants(ctx context.Context, productID string, isAdmin bool) ([]ProductVariantResponse, error) {
+	args := m.Called(ctx, productID, isAdmin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ProductVariantResponse), args.Error(1)
+}
+
+func (m *MockProductService) CreateProductVariant(ctx context.Context, productID string, params ProductVariantRequest) (ProductVariantResponse, error) {
+	args := m.Called(ctx, productID, params)
+	return args.Get(0).(ProductVariantResponse), args.Error(1)
+}
+
+func (m *MockProductService) UpdateProductVariant(ctx context.Context, productID, variantID string, params ProductVariantRequest) (ProductVariantResponse, error) {
+	args := m.Called(ctx, productID, variantID, params)
+	return args.Get(0).(ProductVariantResponse), args.Error(1)
+}
+
+func (m *MockProductService) DeleteProductVariant(ctx context.Context, productID, variantID string) error {
+	args := m.Called(ctx, productID, variantID)
+	return args.Error(0)
 }
 
 // --- Mock Logger ---
@@ -99,18 +132,14 @@ func (m *mockDBQueries) DeleteProductByID(ctx context.Context, id string) error
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *mockDBQueries) GetAllProducts(ctx context.Context) ([]database.Product, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.Product), args.Error(1)
-}
-func (m *mockDBQueries) GetAllActiveProducts(ctx context.Context) ([]database.Product, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.Product), args.Error(1)
-}
 func (m *mockDBQueries) GetProductByID(ctx context.Context, id string) (database.Product, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(database.Product), args.Error(1)
 }
+func (m *mockDBQueries) GetProductByIDForUpdate(ctx context.Context, id string) (database.Product, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Product), args.Error(1)
+}
 func (m *mockDBQueries) GetActiveProductByID(ctx context.Context, id string) (database.Product, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(database.Product), args.Error(1)
@@ -119,3 +148,62 @@ func (m *mockDBQueries) FilterProducts(ctx context.Context, params database.Filt
 	args := m.Called(ctx, params)
 	return args.Get(0).([]database.Product), args.Error(1)
 }
+func (m *mockDBQueries) SearchProducts(ctx context.Context, params database.SearchProductsParams) ([]database.SearchProductsRow, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.SearchProductsRow), args.Error(1)
+}
+func (m *mockDBQueries) ListProductVariantsByProductID(ctx context.Context, productID string) ([]database.ProductVariant, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.ProductVariant), args.Error(1)
+}
+func (m *mockDBQueries) CreateProductVariant(ctx context.Context, params database.CreateProductVariantParams) (database.ProductVariant, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(database.ProductVariant), args.Error(1)
+}
+func (m *mockDBQueries) UpdateProductVariant(ctx context.Context, params database.UpdateProductVariantParams) (database.ProductVariant, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(database.ProductVariant), args.Error(1)
+}
+func (m *mockDBQueries) GetProductVariantByIDForUpdate(ctx context.Context, id string) (database.ProductVariant, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.ProductVariant), args.Error(1)
+}
+func (m *mockDBQueries) VariantHasOpenOrders(ctx context.Context, variantID sql.NullString) (bool, error) {
+	args := m.Called(ctx, variantID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockDBQueries) DeactivateProductVariant(ctx context.Context, params database.DeactivateProductVariantParams) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *mockDBQueries) ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.ProductImage), args.Error(1)
+}
+func (m *mockDBQueries) ListProductImagesByProductIDs(ctx context.Context, productIds []string) ([]database.ProductImage, error) {
+	args := m.Called(ctx, productIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.ProductImage), args.Error(1)
+}
+
+type mockFileStorage struct{ mock.Mock }
+
+func (m *mockFileStorage) Save(file multipart.File, fileHeader *multipart.FileHeader, uploadPath string) (string, error) {
+	args := m.Called(file, fileHeader, uploadPath)
+	return args.String(0), args.Error(1)
+}
+func (m *mockFileStorage) Delete(imageURL, uploadPath string) error {
+	args := m.Called(imageURL, uploadPath)
+	return args.Error(0)
+}
@@ -6,10 +6,17 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/STaninnat/ecom-backend/handlers"
+	uploadhandlers "github.com/STaninnat/ecom-backend/handlers/upload"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
+	"github.com/STaninnat/ecom-backend/models"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -21,11 +28,19 @@ type ProductDBQueries interface {
 	CreateProduct(ctx context.Context, params database.CreateProductParams) error
 	UpdateProduct(ctx context.Context, params database.UpdateProductParams) error
 	DeleteProductByID(ctx context.Context, id string) error
-	GetAllProducts(ctx context.Context) ([]database.Product, error)
-	GetAllActiveProducts(ctx context.Context) ([]database.Product, error)
 	GetProductByID(ctx context.Context, id string) (database.Product, error)
+	GetProductByIDForUpdate(ctx context.Context, id string) (database.Product, error)
 	GetActiveProductByID(ctx context.Context, id string) (database.Product, error)
 	FilterProducts(ctx context.Context, params database.FilterProductsParams) ([]database.Product, error)
+	SearchProducts(ctx context.Context, params database.SearchProductsParams) ([]database.SearchProductsRow, error)
+	ListProductVariantsByProductID(ctx context.Context, productID string) ([]database.ProductVariant, error)
+	CreateProductVariant(ctx context.Context, params database.CreateProductVariantParams) (database.ProductVariant, error)
+	UpdateProductVariant(ctx context.Context, params database.UpdateProductVariantParams) (database.ProductVariant, error)
+	GetProductVariantByIDForUpdate(ctx context.Context, id string) (database.ProductVariant, error)
+	VariantHasOpenOrders(ctx context.Context, variantID sql.NullString) (bool, error)
+	DeactivateProductVariant(ctx context.Context, params database.DeactivateProductVariantParams) (int64, error)
+	ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error)
+	ListProductImagesByProductIDs(ctx context.Context, productIds []string) ([]database.ProductImage, error)
 }
 
 // ProductDBConn defines the interface for beginning database transactions for product operations.
@@ -68,31 +83,71 @@ func (a *ProductDBQueriesAdapter) DeleteProductByID(ctx context.Context, id stri
 	return a.Queries.DeleteProductByID(ctx, id)
 }
 
-// GetAllProducts retrieves all products from the database.
-func (a *ProductDBQueriesAdapter) GetAllProducts(ctx context.Context) ([]database.Product, error) {
-	return a.Queries.GetAllProducts(ctx)
-}
-
-// GetAllActiveProducts retrieves all active products from the database.
-func (a *ProductDBQueriesAdapter) GetAllActiveProducts(ctx context.Context) ([]database.Product, error) {
-	return a.Queries.GetAllActiveProducts(ctx)
-}
-
 // GetProductByID retrieves a product by its ID from the database.
 func (a *ProductDBQueriesAdapter) GetProductByID(ctx context.Context, id string) (database.Product, error) {
 	return a.Queries.GetProductByID(ctx, id)
 }
 
+// GetProductByIDForUpdate retrieves a product by its ID and locks its row until the transaction ends.
+func (a *ProductDBQueriesAdapter) GetProductByIDForUpdate(ctx context.Context, id string) (database.Product, error) {
+	return a.Queries.GetProductByIDForUpdate(ctx, id)
+}
+
 // GetActiveProductByID retrieves an active product by its ID from the database.
 func (a *ProductDBQueriesAdapter) GetActiveProductByID(ctx context.Context, id string) (database.Product, error) {
 	return a.Queries.GetActiveProductByID(ctx, id)
 }
 
-// FilterProducts filters products based on the provided parameters.
+// FilterProducts retrieves one page of products matching the provided parameters.
 func (a *ProductDBQueriesAdapter) FilterProducts(ctx context.Context, params database.FilterProductsParams) ([]database.Product, error) {
 	return a.Queries.FilterProducts(ctx, params)
 }
 
+// SearchProducts runs a full-text search over active products.
+func (a *ProductDBQueriesAdapter) SearchProducts(ctx context.Context, params database.SearchProductsParams) ([]database.SearchProductsRow, error) {
+	return a.Queries.SearchProducts(ctx, params)
+}
+
+// ListProductVariantsByProductID retrieves every variant of a product, oldest first.
+func (a *ProductDBQueriesAdapter) ListProductVariantsByProductID(ctx context.Context, productID string) ([]database.ProductVariant, error) {
+	return a.Queries.ListProductVariantsByProductID(ctx, productID)
+}
+
+// CreateProductVariant creates a product variant and returns the stored row.
+func (a *ProductDBQueriesAdapter) CreateProductVariant(ctx context.Context, params database.CreateProductVariantParams) (database.ProductVariant, error) {
+	return a.Queries.CreateProductVariant(ctx, params)
+}
+
+// UpdateProductVariant updates a product's variant and returns the stored row.
+func (a *ProductDBQueriesAdapter) UpdateProductVariant(ctx context.Context, params database.UpdateProductVariantParams) (database.ProductVariant, error) {
+	return a.Queries.UpdateProductVariant(ctx, params)
+}
+
+// GetProductVariantByIDForUpdate retrieves a variant by its ID and locks its row until the transaction ends.
+func (a *ProductDBQueriesAdapter) GetProductVariantByIDForUpdate(ctx context.Context, id string) (database.ProductVariant, error) {
+	return a.Queries.GetProductVariantByIDForUpdate(ctx, id)
+}
+
+// VariantHasOpenOrders reports whether a pending, paid or shipped order contains the variant.
+func (a *ProductDBQueriesAdapter) VariantHasOpenOrders(ctx context.Context, variantID sql.NullString) (bool, error) {
+	return a.Queries.VariantHasOpenOrders(ctx, variantID)
+}
+
+// DeactivateProductVariant deactivates a product's variant and returns the number of updated rows.
+func (a *ProductDBQueriesAdapter) DeactivateProductVariant(ctx context.Context, params database.DeactivateProductVariantParams) (int64, error) {
+	return a.Queries.DeactivateProductVariant(ctx, params)
+}
+
+// ListProductImagesByProductID delegates to the underlying database.Queries.
+func (a *ProductDBQueriesAdapter) ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error) {
+	return a.Queries.ListProductImagesByProductID(ctx, productID)
+}
+
+// ListProductImagesByProductIDs delegates to the underlying database.Queries.
+func (a *ProductDBQueriesAdapter) ListProductImagesByProductIDs(ctx context.Context, productIds []string) ([]database.ProductImage, error) {
+	return a.Queries.ListProductImagesByProductIDs(ctx, productIds)
+}
+
 // ProductDBConnAdapter adapts a sql.DB to the ProductDBConn interface.
 type ProductDBConnAdapter struct {
 	*sql.DB
@@ -106,27 +161,37 @@ func (a *ProductDBConnAdapter) BeginTx(ctx context.Context, opts *sql.TxOptions)
 
 // --- Service Implementation ---
 type productServiceImpl struct {
-	db     ProductDBQueries
-	dbConn ProductDBConn
+	db        ProductDBQueries
+	dbConn    ProductDBConn
+	storage   uploadhandlers.FileStorage
+	uploadDir string
 }
 
 // ProductService defines the business logic interface for product operations.
-// Provides methods for creating, updating, deleting, retrieving, and filtering products.
+// Provides methods for creating, updating, deleting, retrieving, filtering, and searching products, and for managing their variants.
 type ProductService interface {
 	CreateProduct(ctx context.Context, params ProductRequest) (string, error)
 	UpdateProduct(ctx context.Context, params ProductRequest) error
 	DeleteProduct(ctx context.Context, productID string) error
-	GetAllProducts(ctx context.Context, isAdmin bool) ([]database.Product, error)
-	GetProductByID(ctx context.Context, productID string, isAdmin bool) (database.Product, error)
-	FilterProducts(ctx context.Context, params FilterProductsRequest) ([]database.Product, error)
+	GetAllProducts(ctx context.Context, isAdmin bool, page pagination.Request) (pagination.Page[ProductResponse], error)
+	GetProductByID(ctx context.Context, productID string, isAdmin bool) (ProductResponse, error)
+	FilterProducts(ctx context.Context, params FilterProductsRequest, page pagination.Request) (pagination.Page[ProductResponse], error)
+	SearchProducts(ctx context.Context, params SearchProductsRequest) ([]ProductSearchResult, error)
+	ListProductVariants(ctx context.Context, productID string, isAdmin bool) ([]ProductVariantResponse, error)
+	CreateProductVariant(ctx context.Context, productID string, params ProductVariantRequest) (ProductVariantResponse, error)
+	UpdateProductVariant(ctx context.Context, productID, variantID string, params ProductVariantRequest) (ProductVariantResponse, error)
+	DeleteProductVariant(ctx context.Context, productID, variantID string) error
 }
 
 // NewProductService creates a new ProductService with the provided database query and connection adapters.
+// Deleting a product removes its image files from storage under uploadDir; a nil storage leaves them in place.
 // Returns a ProductService implementation.
-func NewProductService(db *database.Queries, dbConn *sql.DB) ProductService {
+func NewProductService(db *database.Queries, dbConn *sql.DB, storage uploadhandlers.FileStorage, uploadDir string) ProductService {
 	return &productServiceImpl{
-		db:     &ProductDBQueriesAdapter{db},
-		dbConn: &ProductDBConnAdapter{dbConn},
+		db:        &ProductDBQueriesAdapter{db},
+		dbConn:    &ProductDBConnAdapter{dbConn},
+		storage:   storage,
+		uploadDir: uploadDir,
 	}
 }
 
@@ -136,7 +201,7 @@ func (s *productServiceImpl) CreateProduct(ctx context.Context, params ProductRe
 	if s.dbConn == nil {
 		return "", &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: fmt.Errorf("dbConn is nil")}
 	}
-	if params.CategoryID == "" || params.Name == "" || params.Price <= 0 || params.Stock < 0 {
+	if params.CategoryID == "" || params.Name == "" || !params.Price.IsPositive() || params.Stock < 0 {
 		return "", &handlers.AppError{Code: "invalid_request", Message: "Missing or invalid required fields"}
 	}
 	id := utils.NewUUIDString()
@@ -161,7 +226,7 @@ func (s *productServiceImpl) CreateProduct(ctx context.Context, params ProductRe
 		CategoryID:  utils.ToNullString(params.CategoryID),
 		Name:        params.Name,
 		Description: utils.ToNullString(params.Description),
-		Price:       fmt.Sprintf("%.2f", params.Price),
+		Price:       params.Price.String(),
 		Stock:       params.Stock,
 		ImageUrl:    utils.ToNullString(params.ImageURL),
 		IsActive:    isActive,
@@ -179,11 +244,12 @@ func (s *productServiceImpl) CreateProduct(ctx context.Context, params ProductRe
 
 // UpdateProduct updates an existing product.
 // Validates the request, updates the product in a transaction, and returns an error if unsuccessful.
+// The image URL is ignored once the product has an image gallery, whose primary image owns it.
 func (s *productServiceImpl) UpdateProduct(ctx context.Context, params ProductRequest) error {
 	if s.dbConn == nil {
 		return &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: fmt.Errorf("dbConn is nil")}
 	}
-	if params.ID == "" || params.CategoryID == "" || params.Name == "" || params.Price <= 0 || params.Stock < 0 {
+	if params.ID == "" || params.CategoryID == "" || params.Name == "" || !params.Price.IsPositive() || params.Stock < 0 {
 		return &handlers.AppError{Code: "invalid_request", Message: "Missing or invalid required fields"}
 	}
 	isActive := true
@@ -206,7 +272,7 @@ func (s *productServiceImpl) UpdateProduct(ctx context.Context, params ProductRe
 		CategoryID:  utils.ToNullString(params.CategoryID),
 		Name:        params.Name,
 		Description: utils.ToNullString(params.Description),
-		Price:       fmt.Sprintf("%.2f", params.Price),
+		Price:       params.Price.String(),
 		Stock:       params.Stock,
 		ImageUrl:    utils.ToNullString(params.ImageURL),
 		IsActive:    isActive,
@@ -222,7 +288,9 @@ func (s *productServiceImpl) UpdateProduct(ctx context.Context, params ProductRe
 }
 
 // DeleteProduct deletes a product by ID.
-// Validates the ID, checks if product exists, deletes it in a transaction, and returns an error if unsuccessful.
+// Validates the ID, locks the product, deletes it with its image gallery in a transaction, and returns an error if
+// unsuccessful. The gallery's image files, including their renditions, are removed from storage once the delete is
+// committed.
 func (s *productServiceImpl) DeleteProduct(ctx context.Context, productID string) error {
 	if s.dbConn == nil {
 		return &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: fmt.Errorf("dbConn is nil")}
@@ -241,10 +309,15 @@ func (s *productServiceImpl) DeleteProduct(ctx context.Context, productID string
 		}
 	}()
 	queries := s.db.WithTx(tx)
-	_, err = queries.GetProductByID(ctx, productID)
+	// The row lock keeps image uploads from adding to the gallery between reading it and deleting the product
+	_, err = queries.GetProductByIDForUpdate(ctx, productID)
 	if err != nil {
 		return &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
 	}
+	images, err := queries.ListProductImagesByProductID(ctx, productID)
+	if err != nil {
+		return &handlers.AppError{Code: "list_images_error", Message: "Error listing product images", Err: err}
+	}
 	err = queries.DeleteProductByID(ctx, productID)
 	if err != nil {
 		return &handlers.AppError{Code: "delete_product_error", Message: "Error deleting product", Err: err}
@@ -252,43 +325,93 @@ func (s *productServiceImpl) DeleteProduct(ctx context.Context, productID string
 	if err = tx.Commit(); err != nil {
 		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
 	}
+
+	// The rows are gone, so a file left behind by a failed delete is only orphaned, never shown
+	s.deleteImageFiles(images)
 	return nil
 }
 
-// GetAllProducts returns all products (admin: all, non-admin: only active).
-// Returns a list of products based on admin status or an error.
-func (s *productServiceImpl) GetAllProducts(ctx context.Context, isAdmin bool) ([]database.Product, error) {
-	if s.db == nil {
-		return nil, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+// deleteImageFiles removes the original and rendition files of each image from storage, ignoring errors.
+func (s *productServiceImpl) deleteImageFiles(images []database.ProductImage) {
+	if s.storage == nil {
+		return
 	}
-	if isAdmin {
-		return s.db.GetAllProducts(ctx)
+	for _, image := range images {
+		for _, url := range []string{image.ImageUrl, image.ThumbnailUrl.String, image.MediumUrl.String, image.LargeUrl.String} {
+			if url != "" {
+				_ = s.storage.Delete(url, s.uploadDir)
+			}
+		}
 	}
-	return s.db.GetAllActiveProducts(ctx)
 }
 
-// GetProductByID returns a product by ID (admin: all, non-admin: only active).
+// GetAllProducts returns one page of products (admin: all, non-admin: only active).
+// Returns the page, with a cursor for the next one, or an error.
+func (s *productServiceImpl) GetAllProducts(ctx context.Context, isAdmin bool, page pagination.Request) (pagination.Page[ProductResponse], error) {
+	var params FilterProductsRequest
+	if !isAdmin {
+		params.IsActive = utils.NullBool{NullBool: sql.NullBool{Bool: true, Valid: true}}
+	}
+	return s.FilterProducts(ctx, params, page)
+}
+
+// GetProductByID returns a product by ID with its image gallery (admin: all, non-admin: only active).
 // Validates the ID and returns product details based on admin status or an error.
-func (s *productServiceImpl) GetProductByID(ctx context.Context, productID string, isAdmin bool) (database.Product, error) {
+func (s *productServiceImpl) GetProductByID(ctx context.Context, productID string, isAdmin bool) (ProductResponse, error) {
 	if s.db == nil {
-		return database.Product{}, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+		return ProductResponse{}, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
 	}
 	if productID == "" {
-		return database.Product{}, &handlers.AppError{Code: "invalid_request", Message: "Missing product ID"}
+		return ProductResponse{}, &handlers.AppError{Code: "invalid_request", Message: "Missing product ID"}
 	}
+	var (
+		product database.Product
+		err     error
+	)
 	if isAdmin {
-		return s.db.GetProductByID(ctx, productID)
+		product, err = s.db.GetProductByID(ctx, productID)
+	} else {
+		product, err = s.db.GetActiveProductByID(ctx, productID)
+	}
+	if err != nil {
+		return ProductResponse{}, err
 	}
-	return s.db.GetActiveProductByID(ctx, productID)
+	images, err := s.db.ListProductImagesByProductID(ctx, productID)
+	if err != nil {
+		return ProductResponse{}, &handlers.AppError{Code: "list_images_error", Message: "Error listing product images", Err: err}
+	}
+	return ProductResponse{Product: product, Images: toProductImages(images)}, nil
+}
+
+// productSortOptions lists the accepted product list sorts; empty means newest first.
+var productSortOptions = map[string]bool{
+	"": true, "newest": true, "oldest": true, "price_asc": true, "price_desc": true, "name_asc": true, "name_desc": true,
+	"rating_desc": true, "rating_asc": true, "reviews_desc": true,
 }
 
-// FilterProducts filters products by various criteria.
-// Returns a filtered list of products based on the provided criteria or an error.
-func (s *productServiceImpl) FilterProducts(ctx context.Context, params FilterProductsRequest) ([]database.Product, error) {
+// FilterProducts returns one page of the products matching the criteria.
+// The sort is page.Sort, or params.Sort when that is empty; a cursor is only valid under the sort it was issued for.
+func (s *productServiceImpl) FilterProducts(ctx context.Context, params FilterProductsRequest, page pagination.Request) (pagination.Page[ProductResponse], error) {
 	if s.db == nil {
-		return nil, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+		return pagination.Page[ProductResponse]{}, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+	}
+	sort := page.Sort
+	if sort == "" {
+		sort = params.Sort
+	}
+	if !productSortOptions[sort] {
+		return pagination.Page[ProductResponse]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid sort option"}
 	}
-	return s.db.FilterProducts(ctx, database.FilterProductsParams{
+	if sort == "" {
+		sort = "newest"
+	}
+	cursor, err := decodeProductCursor(page.Cursor, sort)
+	if err != nil {
+		return pagination.Page[ProductResponse]{}, &handlers.AppError{Code: "invalid_request", Message: "Invalid cursor", Err: err}
+	}
+
+	limit := pagination.NormalizeLimit(page.Limit)
+	products, err := s.db.FilterProducts(ctx, database.FilterProductsParams{
 		CategoryID: params.CategoryID.NullString,
 		IsActive:   params.IsActive.NullBool,
 		MinPrice: sql.NullString{
@@ -299,5 +422,220 @@ func (s *productServiceImpl) FilterProducts(ctx context.Context, params FilterPr
 			String: fmt.Sprintf("%f", params.MaxPrice.Float64),
 			Valid:  params.MaxPrice.Valid,
 		},
+		MinRating: sql.NullString{
+			String: fmt.Sprintf("%f", params.MinRating.Float64),
+			Valid:  params.MinRating.Valid,
+		},
+		Sort:            sort,
+		CursorID:        cursor.IDArg(),
+		CursorCreatedAt: cursor.CreatedAtArg(),
+		CursorValue:     cursor.ValueArg(),
+		CursorCount:     cursor.CountArg(),
+		Limit:           pagination.FetchLimit(limit),
+	})
+	if err != nil {
+		return pagination.Page[ProductResponse]{}, err
+	}
+	productPage := pagination.NewPage(products, limit, func(p database.Product) pagination.Cursor {
+		return productCursor(p, sort)
+	})
+	data, err := s.withImages(ctx, productPage.Data)
+	if err != nil {
+		return pagination.Page[ProductResponse]{}, err
+	}
+	return pagination.Page[ProductResponse]{
+		Data:       data,
+		Limit:      productPage.Limit,
+		NextCursor: productPage.NextCursor,
+		HasNext:    productPage.HasNext,
+	}, nil
+}
+
+// withImages attaches each product's image gallery, loading the galleries of the whole page in one query.
+func (s *productServiceImpl) withImages(ctx context.Context, products []database.Product) ([]ProductResponse, error) {
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	galleries, err := s.productGalleries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, ProductResponse{Product: p, Images: galleries[p.ID]})
+	}
+	return responses, nil
+}
+
+// productGalleries returns the image galleries of the given products by product ID; every product gets a non-nil gallery.
+func (s *productServiceImpl) productGalleries(ctx context.Context, productIDs []string) (map[string][]models.ProductImage, error) {
+	galleries := make(map[string][]models.ProductImage, len(productIDs))
+	if len(productIDs) == 0 {
+		return galleries, nil
+	}
+	images, err := s.db.ListProductImagesByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "list_images_error", Message: "Error listing product images", Err: err}
+	}
+	byProduct := make(map[string][]database.ProductImage, len(productIDs))
+	for _, image := range images {
+		byProduct[image.ProductID] = append(byProduct[image.ProductID], image)
+	}
+	for _, id := range productIDs {
+		galleries[id] = toProductImages(byProduct[id])
+	}
+	return galleries, nil
+}
+
+// toProductImages converts gallery rows, already in display order, to response models.
+func toProductImages(images []database.ProductImage) []models.ProductImage {
+	result := make([]models.ProductImage, 0, len(images))
+	for _, image := range images {
+		result = append(result, models.MapProductImageToResponse(image))
+	}
+	return result
+}
+
+// productCursor returns the cursor after p: its ID and the values the sort orders by.
+func productCursor(p database.Product, sort string) pagination.Cursor {
+	c := pagination.Cursor{Sort: sort, ID: p.ID}
+	switch sort {
+	case "price_asc", "price_desc":
+		c.Value = p.Price
+	case "name_asc", "name_desc":
+		c.Value = p.Name
+	case "rating_desc":
+		c.Value, c.Count = p.RatingAvg, p.RatingCount
+	case "rating_asc":
+		c.Value = p.RatingAvg
+	case "reviews_desc":
+		c.Count = p.RatingCount
+	default:
+		c.CreatedAt = p.CreatedAt
+	}
+	return c
+}
+
+// decodeProductCursor decodes a cursor token for sort and checks that numeric sort values are numbers,
+// so a tampered cursor is rejected rather than failing the query.
+func decodeProductCursor(token, sort string) (*pagination.Cursor, error) {
+	cursor, err := pagination.Decode(token, sort)
+	if err != nil || cursor == nil {
+		return cursor, err
+	}
+	switch sort {
+	case "price_asc", "price_desc", "rating_desc", "rating_asc":
+		if _, err := strconv.ParseFloat(cursor.Value, 64); err != nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// Search limits: results per request and query length.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQueryLen  = 200
+)
+
+// searchSortOptions lists the accepted values of SearchProductsRequest.Sort; empty means most relevant first.
+var searchSortOptions = map[string]bool{"": true, "relevance": true, "newest": true, "rating_desc": true, "rating_asc": true, "reviews_desc": true}
+
+// SearchProducts returns the active products matching the keywords, most relevant first unless another sort is given.
+// Matches are highlighted with <mark> in the name and in a snippet of the description; the rest of the text is HTML-escaped.
+func (s *productServiceImpl) SearchProducts(ctx context.Context, params SearchProductsRequest) ([]ProductSearchResult, error) {
+	if s.db == nil {
+		return nil, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+	}
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Search query is required"}
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLen {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("Search query must be at most %d characters", maxSearchQueryLen)}
+	}
+	if !searchSortOptions[params.Sort] {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Invalid sort option"}
+	}
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 0 || limit > maxSearchLimit {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("Limit must be between 1 and %d", maxSearchLimit)}
+	}
+
+	rows, err := s.db.SearchProducts(ctx, database.SearchProductsParams{
+		Query:      query,
+		CategoryID: params.CategoryID.NullString,
+		MinPrice: sql.NullString{
+			String: fmt.Sprintf("%f", params.MinPrice.Float64),
+			Valid:  params.MinPrice.Valid,
+		},
+		MaxPrice: sql.NullString{
+			String: fmt.Sprintf("%f", params.MaxPrice.Float64),
+			Valid:  params.MaxPrice.Valid,
+		},
+		MinRating: sql.NullString{
+			String: fmt.Sprintf("%f", params.MinRating.Float64),
+			Valid:  params.MinRating.Valid,
+		},
+		Sort:  params.Sort,
+		Limit: int32(limit),
 	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "search_error", Message: "Error searching products", Err: err}
+	}
+
+	results := make([]ProductSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, ProductSearchResult{
+			Product: database.Product{
+				ID:           row.ID,
+				CategoryID:   row.CategoryID,
+				Name:         row.Name,
+				Description:  row.Description,
+				Price:        row.Price,
+				Stock:        row.Stock,
+				ImageUrl:     row.ImageUrl,
+				IsActive:     row.IsActive,
+				CreatedAt:    row.CreatedAt,
+				UpdatedAt:    row.UpdatedAt,
+				RatingAvg:    row.RatingAvg,
+				RatingCount:  row.RatingCount,
+				Rating1Count: row.Rating1Count,
+				Rating2Count: row.Rating2Count,
+				Rating3Count: row.Rating3Count,
+				Rating4Count: row.Rating4Count,
+				Rating5Count: row.Rating5Count,
+			},
+			Rank:          row.Rank,
+			NameHighlight: highlightHTML(row.NameHighlight),
+			Snippet:       highlightHTML(row.Snippet),
+		})
+	}
+
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	galleries, err := s.productGalleries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Images = galleries[results[i].ID]
+	}
+	return results, nil
+}
+
+// searchHighlightMarkers maps the private-use characters SearchProducts wraps matches in to <mark> tags.
+var searchHighlightMarkers = strings.NewReplacer("\uE000", "<mark>", "\uE001", "</mark>")
+
+// highlightHTML escapes text highlighted by the search query and turns its match markers into <mark> tags,
+// so product text can never inject markup into the snippet.
+func highlightHTML(text string) string {
+	return searchHighlightMarkers.Replace(html.EscapeString(text))
 }
@@ -4,13 +4,21 @@ package producthandlers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+	"github.com/STaninnat/ecom-backend/internal/pagination"
+	"github.com/STaninnat/ecom-backend/models"
 )
 
 // product_service_test.go: Tests covering successful operations, error cases, input validation, and adapter coverage for product service business logic.
@@ -22,7 +30,7 @@ func TestCreateProduct_Success(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
@@ -45,7 +53,7 @@ func TestUpdateProduct_Success(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
@@ -61,51 +69,189 @@ func TestUpdateProduct_Success(t *testing.T) {
 }
 
 // TestDeleteProduct_Success tests the successful deletion of a product in the business logic layer.
-// It verifies that the service calls the correct DB methods and returns no error on success.
+// It verifies that the product is locked and deleted, and that its image files are removed only after the commit.
 func TestDeleteProduct_Success(t *testing.T) {
 	mockDB := new(mockDBQueries)
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
-	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
+	storage := new(mockFileStorage)
+	service := &productServiceImpl{db: mockDB, dbConn: mockConn, storage: storage, uploadDir: "uploads"}
 	productID := "pid1"
 
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetProductByID", mock.Anything, productID).Return(database.Product{ID: productID}, nil)
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, productID).Return(database.Product{ID: productID}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, productID).Return([]database.ProductImage{
+		{ID: "i1", ImageUrl: "/static/a.png", ThumbnailUrl: sql.NullString{String: "/static/a_thumb.jpg", Valid: true},
+			MediumUrl: sql.NullString{String: "/static/a_medium.jpg", Valid: true}, LargeUrl: sql.NullString{String: "/static/a_large.jpg", Valid: true}},
+		{ID: "i2", ImageUrl: "/static/legacy.png"},
+	}, nil)
 	mockDB.On("DeleteProductByID", mock.Anything, productID).Return(nil)
 	mockTx.On("Commit").Return(nil)
 	mockTx.On("Rollback").Return(nil)
+	for _, url := range []string{"/static/a.png", "/static/a_thumb.jpg", "/static/a_medium.jpg", "/static/a_large.jpg", "/static/legacy.png"} {
+		storage.On("Delete", url, "uploads").Return(nil).Once()
+	}
 
 	err := service.DeleteProduct(context.Background(), productID)
 	require.NoError(t, err)
 	mockConn.AssertExpectations(t)
 	mockDB.AssertExpectations(t)
 	mockTx.AssertExpectations(t)
+	storage.AssertExpectations(t)
+	storage.AssertNumberOfCalls(t, "Delete", 5)
 }
 
-// TestGetAllProducts_Success tests the successful retrieval of all products in the business logic layer.
-// It verifies that the service returns the expected product list with no error.
+// TestGetAllProducts_Success tests the successful retrieval of a page of products in the business logic layer.
+// It verifies that admins list every product, newest first, with the default limit.
 func TestGetAllProducts_Success(t *testing.T) {
 	mockDB := new(mockDBQueries)
 	service := &productServiceImpl{db: mockDB}
 	products := []database.Product{{ID: "p1"}, {ID: "p2"}}
-	mockDB.On("GetAllProducts", mock.Anything).Return(products, nil)
-	res, err := service.GetAllProducts(context.Background(), true)
+	mockDB.On("FilterProducts", mock.Anything, database.FilterProductsParams{
+		MinPrice:  sql.NullString{String: "0.000000"},
+		MaxPrice:  sql.NullString{String: "0.000000"},
+		MinRating: sql.NullString{String: "0.000000"},
+		Sort:      "newest",
+		Limit:     pagination.DefaultLimit + 1,
+	}).Return(products, nil)
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, []string{"p1", "p2"}).Return([]database.ProductImage{
+		{ID: "i1", ProductID: "p2", ImageUrl: "/static/a.png", Position: 0, IsPrimary: true},
+	}, nil)
+	res, err := service.GetAllProducts(context.Background(), true, pagination.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, []ProductResponse{
+		{Product: products[0], Images: []models.ProductImage{}},
+		{Product: products[1], Images: []models.ProductImage{{ID: "i1", ImageURL: "/static/a.png", IsPrimary: true}}},
+	}, res.Data)
+	assert.False(t, res.HasNext)
+	mockDB.AssertExpectations(t)
+}
+
+// TestGetAllProducts_ActiveOnly tests that non-admins only list active products.
+func TestGetAllProducts_ActiveOnly(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("FilterProducts", mock.Anything, mock.MatchedBy(func(p database.FilterProductsParams) bool {
+		return p.IsActive == sql.NullBool{Bool: true, Valid: true}
+	})).Return([]database.Product{}, nil)
+	_, err := service.GetAllProducts(context.Background(), false, pagination.Request{})
+	require.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+// TestFilterProducts_Cursor tests that a full page returns a cursor and that the cursor is passed to the query for the next page.
+func TestFilterProducts_Cursor(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("FilterProducts", mock.Anything, mock.MatchedBy(func(p database.FilterProductsParams) bool {
+		return !p.CursorID.Valid
+	})).Return([]database.Product{
+		{ID: "p1", Price: "5.00"}, {ID: "p2", Price: "7.50"}, {ID: "p3", Price: "9.00"},
+	}, nil).Once()
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, mock.Anything).Return([]database.ProductImage{}, nil)
+
+	first, err := service.FilterProducts(context.Background(), FilterProductsRequest{}, pagination.Request{Sort: "price_asc", Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, first.Data, 2)
+	assert.True(t, first.HasNext)
+	require.NotEmpty(t, first.NextCursor)
+
+	mockDB.On("FilterProducts", mock.Anything, mock.MatchedBy(func(p database.FilterProductsParams) bool {
+		return p.Sort == "price_asc" && p.Limit == 3 &&
+			p.CursorID == sql.NullString{String: "p2", Valid: true} &&
+			p.CursorValue == sql.NullString{String: "7.50", Valid: true}
+	})).Return([]database.Product{{ID: "p3", Price: "9.00"}}, nil).Once()
+
+	second, err := service.FilterProducts(context.Background(), FilterProductsRequest{}, pagination.Request{Sort: "price_asc", Limit: 2, Cursor: first.NextCursor})
 	require.NoError(t, err)
-	assert.Equal(t, products, res)
+	assert.Equal(t, []ProductResponse{{Product: database.Product{ID: "p3", Price: "9.00"}, Images: []models.ProductImage{}}}, second.Data)
+	assert.False(t, second.HasNext)
+	assert.Empty(t, second.NextCursor)
 	mockDB.AssertExpectations(t)
 }
 
+// TestFilterProducts_InvalidCursor tests that malformed cursors, cursors from another sort and non-numeric price cursors are rejected.
+func TestFilterProducts_InvalidCursor(t *testing.T) {
+	service := &productServiceImpl{db: new(mockDBQueries)}
+	cursors := map[string]pagination.Request{
+		"malformed":    {Cursor: "%%%"},
+		"another sort": {Cursor: pagination.Cursor{Sort: "oldest", ID: "p1"}.Encode()},
+		"bad price":    {Sort: "price_desc", Cursor: pagination.Cursor{Sort: "price_desc", Value: "cheap", ID: "p1"}.Encode()},
+	}
+	for name, page := range cursors {
+		_, err := service.FilterProducts(context.Background(), FilterProductsRequest{}, page)
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr, name)
+		assert.Equal(t, "invalid_request", appErr.Code, name)
+		assert.Equal(t, "Invalid cursor", appErr.Message, name)
+	}
+}
+
+// TestProductCursor tests that the cursor holds the values each sort orders by.
+func TestProductCursor(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	p := database.Product{ID: "p1", Name: "Mug", Price: "9.99", RatingAvg: "4.20", RatingCount: 7, CreatedAt: createdAt}
+
+	assert.Equal(t, pagination.Cursor{Sort: "newest", CreatedAt: createdAt, ID: "p1"}, productCursor(p, "newest"))
+	assert.Equal(t, pagination.Cursor{Sort: "price_desc", Value: "9.99", ID: "p1"}, productCursor(p, "price_desc"))
+	assert.Equal(t, pagination.Cursor{Sort: "name_asc", Value: "Mug", ID: "p1"}, productCursor(p, "name_asc"))
+	assert.Equal(t, pagination.Cursor{Sort: "rating_desc", Value: "4.20", Count: 7, ID: "p1"}, productCursor(p, "rating_desc"))
+	assert.Equal(t, pagination.Cursor{Sort: "reviews_desc", Count: 7, ID: "p1"}, productCursor(p, "reviews_desc"))
+}
+
 // TestGetProductByID_Success tests the successful retrieval of a product by ID in the business logic layer.
-// It verifies that the service returns the expected product with no error.
+// It verifies that the service returns the expected product and gallery, with renditions only for images that have them.
 func TestGetProductByID_Success(t *testing.T) {
 	mockDB := new(mockDBQueries)
 	service := &productServiceImpl{db: mockDB}
 	product := database.Product{ID: "p1"}
 	mockDB.On("GetProductByID", mock.Anything, "p1").Return(product, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "p1").Return([]database.ProductImage{
+		{
+			ID:           "i1",
+			ProductID:    "p1",
+			ImageUrl:     "/static/a.png",
+			AltText:      sql.NullString{String: "Front", Valid: true},
+			Position:     0,
+			IsPrimary:    true,
+			ThumbnailUrl: sql.NullString{String: "/static/a_thumbnail.jpg", Valid: true},
+			MediumUrl:    sql.NullString{String: "/static/a_medium.jpg", Valid: true},
+			LargeUrl:     sql.NullString{String: "/static/a_large.jpg", Valid: true},
+		},
+		{ID: "i2", ProductID: "p1", ImageUrl: "/static/b.png", Position: 1},
+	}, nil)
 	res, err := service.GetProductByID(context.Background(), "p1", true)
 	require.NoError(t, err)
-	assert.Equal(t, product, res)
+	assert.Equal(t, product, res.Product)
+	assert.Equal(t, []models.ProductImage{
+		{
+			ID:        "i1",
+			ImageURL:  "/static/a.png",
+			AltText:   "Front",
+			Position:  0,
+			IsPrimary: true,
+			Renditions: &models.ImageRenditions{
+				Thumbnail: "/static/a_thumbnail.jpg",
+				Medium:    "/static/a_medium.jpg",
+				Large:     "/static/a_large.jpg",
+			},
+		},
+		{ID: "i2", ImageURL: "/static/b.png", Position: 1},
+	}, res.Images)
+	mockDB.AssertExpectations(t)
+}
+
+// TestGetProductByID_ImagesError tests that a failure to load the gallery is reported as "list_images_error".
+func TestGetProductByID_ImagesError(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("GetActiveProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "p1").Return(nil, errors.New("db down"))
+	_, err := service.GetProductByID(context.Background(), "p1", false)
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "list_images_error", appErr.Code)
 	mockDB.AssertExpectations(t)
 }
 
@@ -117,12 +263,54 @@ func TestFilterProducts_Success(t *testing.T) {
 	params := FilterProductsRequest{}
 	products := []database.Product{{ID: "p1"}, {ID: "p2"}}
 	mockDB.On("FilterProducts", mock.Anything, mock.Anything).Return(products, nil)
-	res, err := service.FilterProducts(context.Background(), params)
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, []string{"p1", "p2"}).Return([]database.ProductImage{}, nil)
+	res, err := service.FilterProducts(context.Background(), params, pagination.Request{})
 	require.NoError(t, err)
-	assert.Equal(t, products, res)
+	require.Len(t, res.Data, 2)
+	assert.Equal(t, products[0], res.Data[0].Product)
+	assert.Equal(t, products[1], res.Data[1].Product)
+	mockDB.AssertExpectations(t)
+}
+
+// TestFilterProducts_ImagesError tests that a failure to load the page's galleries fails the listing.
+func TestFilterProducts_ImagesError(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("FilterProducts", mock.Anything, mock.Anything).Return([]database.Product{{ID: "p1"}}, nil)
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, []string{"p1"}).Return(nil, errors.New("db down"))
+	_, err := service.FilterProducts(context.Background(), FilterProductsRequest{}, pagination.Request{})
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "list_images_error", appErr.Code)
 	mockDB.AssertExpectations(t)
 }
 
+// TestFilterProducts_Rating tests that the rating filter and sort are passed to the query and unknown sorts are rejected.
+func TestFilterProducts_Rating(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("FilterProducts", mock.Anything, database.FilterProductsParams{
+		MinPrice:  sql.NullString{String: "0.000000"},
+		MaxPrice:  sql.NullString{String: "0.000000"},
+		MinRating: sql.NullString{String: "4.000000", Valid: true},
+		Sort:      "rating_desc",
+		Limit:     pagination.DefaultLimit + 1,
+	}).Return([]database.Product{{ID: "p1", RatingAvg: "4.50", RatingCount: 2}}, nil)
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, []string{"p1"}).Return([]database.ProductImage{}, nil)
+
+	var params FilterProductsRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"min_rating":4,"sort":"rating_desc"}`), &params))
+	res, err := service.FilterProducts(context.Background(), params, pagination.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "4.50", res.Data[0].RatingAvg)
+	mockDB.AssertExpectations(t)
+
+	_, err = service.FilterProducts(context.Background(), FilterProductsRequest{Sort: "price"}, pagination.Request{})
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "invalid_request", appErr.Code)
+}
+
 // The following tests cover error and edge cases for CreateProduct:
 // - DBConn is nil
 // - Invalid input parameters
@@ -131,14 +319,14 @@ func TestFilterProducts_Success(t *testing.T) {
 // - Error committing transaction
 func TestCreateProduct_DBConnNil(t *testing.T) {
 	service := &productServiceImpl{db: nil, dbConn: nil}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	_, err := service.CreateProduct(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "DB connection is nil")
 }
 func TestCreateProduct_InvalidInput(t *testing.T) {
 	service := &productServiceImpl{db: nil, dbConn: new(mockDBConn)}
-	params := ProductRequest{CategoryID: "", Name: "", Price: 0, Stock: -1}
+	params := ProductRequest{CategoryID: "", Name: "", Price: money.MustParse("0.00", money.USD), Stock: -1}
 	_, err := service.CreateProduct(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Missing or invalid required fields")
@@ -147,7 +335,7 @@ func TestCreateProduct_BeginTxError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: new(mockDBQueries), dbConn: mockConn}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, assert.AnError)
 	_, err := service.CreateProduct(context.Background(), params)
 	require.Error(t, err)
@@ -158,7 +346,7 @@ func TestCreateProduct_CreateProductError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
 	mockDB.On("CreateProduct", mock.Anything, mock.Anything).Return(assert.AnError)
@@ -172,7 +360,7 @@ func TestCreateProduct_CommitError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
 	mockDB.On("CreateProduct", mock.Anything, mock.Anything).Return(nil)
@@ -191,14 +379,14 @@ func TestCreateProduct_CommitError(t *testing.T) {
 // - Error committing transaction
 func TestUpdateProduct_DBConnNil(t *testing.T) {
 	service := &productServiceImpl{db: nil, dbConn: nil}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	err := service.UpdateProduct(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "DB connection is nil")
 }
 func TestUpdateProduct_InvalidInput(t *testing.T) {
 	service := &productServiceImpl{db: nil, dbConn: new(mockDBConn)}
-	params := ProductRequest{ID: "", CategoryID: "", Name: "", Price: 0, Stock: -1}
+	params := ProductRequest{ID: "", CategoryID: "", Name: "", Price: money.MustParse("0.00", money.USD), Stock: -1}
 	err := service.UpdateProduct(context.Background(), params)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Missing or invalid required fields")
@@ -207,7 +395,7 @@ func TestUpdateProduct_BeginTxError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: new(mockDBQueries), dbConn: mockConn}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, assert.AnError)
 	err := service.UpdateProduct(context.Background(), params)
 	require.Error(t, err)
@@ -218,7 +406,7 @@ func TestUpdateProduct_UpdateProductError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
 	mockDB.On("UpdateProduct", mock.Anything, mock.Anything).Return(assert.AnError)
@@ -232,7 +420,7 @@ func TestUpdateProduct_CommitError(t *testing.T) {
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
-	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: 10, Stock: 1}
+	params := ProductRequest{ID: "pid1", CategoryID: "c1", Name: "P", Price: money.MustParse("10.00", money.USD), Stock: 1}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
 	mockDB.On("UpdateProduct", mock.Anything, mock.Anything).Return(nil)
@@ -247,7 +435,8 @@ func TestUpdateProduct_CommitError(t *testing.T) {
 // - DBConn is nil
 // - Invalid input parameters
 // - Error starting transaction
-// - Error from GetProductByID DB call
+// - Error from GetProductByIDForUpdate DB call
+// - Error from ListProductImagesByProductID DB call
 // - Error from DeleteProductByID DB call
 // - Error committing transaction
 func TestDeleteProduct_DBConnNil(t *testing.T) {
@@ -278,7 +467,7 @@ func TestDeleteProduct_GetProductByIDError(t *testing.T) {
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetProductByID", mock.Anything, "pid1").Return(database.Product{}, assert.AnError)
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, "pid1").Return(database.Product{}, assert.AnError)
 	mockTx.On("Rollback").Return(nil)
 	err := service.DeleteProduct(context.Background(), "pid1")
 	require.Error(t, err)
@@ -291,27 +480,46 @@ func TestDeleteProduct_DeleteProductByIDError(t *testing.T) {
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetProductByID", mock.Anything, "pid1").Return(database.Product{ID: "pid1"}, nil)
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, "pid1").Return(database.Product{ID: "pid1"}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "pid1").Return([]database.ProductImage{}, nil)
 	mockDB.On("DeleteProductByID", mock.Anything, "pid1").Return(assert.AnError)
 	mockTx.On("Rollback").Return(nil)
 	err := service.DeleteProduct(context.Background(), "pid1")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Error deleting product")
 }
-func TestDeleteProduct_CommitError(t *testing.T) {
+func TestDeleteProduct_ListImagesError(t *testing.T) {
 	mockDB := new(mockDBQueries)
 	mockConn := new(mockDBConn)
 	mockTx := new(mockTx)
 	service := &productServiceImpl{db: mockDB, dbConn: mockConn}
 	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
 	mockDB.On("WithTx", mockTx).Return(mockDB)
-	mockDB.On("GetProductByID", mock.Anything, "pid1").Return(database.Product{ID: "pid1"}, nil)
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, "pid1").Return(database.Product{ID: "pid1"}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "pid1").Return(nil, assert.AnError)
+	mockTx.On("Rollback").Return(nil)
+	err := service.DeleteProduct(context.Background(), "pid1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Error listing product images")
+	mockDB.AssertNotCalled(t, "DeleteProductByID", mock.Anything, mock.Anything)
+}
+func TestDeleteProduct_CommitError(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	mockConn := new(mockDBConn)
+	mockTx := new(mockTx)
+	storage := new(mockFileStorage)
+	service := &productServiceImpl{db: mockDB, dbConn: mockConn, storage: storage}
+	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(mockTx, nil)
+	mockDB.On("WithTx", mockTx).Return(mockDB)
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, "pid1").Return(database.Product{ID: "pid1"}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "pid1").Return([]database.ProductImage{{ID: "i1", ImageUrl: "/static/a.png"}}, nil)
 	mockDB.On("DeleteProductByID", mock.Anything, "pid1").Return(nil)
 	mockTx.On("Commit").Return(assert.AnError)
 	mockTx.On("Rollback").Return(nil)
 	err := service.DeleteProduct(context.Background(), "pid1")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Error committing transaction")
+	storage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 }
 
 // The following tests cover error and edge cases for GetAllProducts, GetProductByID, and FilterProducts:
@@ -319,7 +527,7 @@ func TestDeleteProduct_CommitError(t *testing.T) {
 // - Invalid input parameters
 func TestGetAllProducts_DBNil(t *testing.T) {
 	service := &productServiceImpl{db: nil}
-	_, err := service.GetAllProducts(context.Background(), true)
+	_, err := service.GetAllProducts(context.Background(), true, pagination.Request{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "DB is nil")
 }
@@ -338,11 +546,85 @@ func TestGetProductByID_InvalidInput(t *testing.T) {
 }
 func TestFilterProducts_DBNil(t *testing.T) {
 	service := &productServiceImpl{db: nil}
-	_, err := service.FilterProducts(context.Background(), FilterProductsRequest{})
+	_, err := service.FilterProducts(context.Background(), FilterProductsRequest{}, pagination.Request{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "DB is nil")
 }
 
+// TestSearchProducts_Success tests that the query and filters reach the database and that matches are highlighted as escaped HTML.
+func TestSearchProducts_Success(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("SearchProducts", mock.Anything, database.SearchProductsParams{
+		Query:      "red shoes",
+		CategoryID: sql.NullString{String: "c1", Valid: true},
+		MinPrice:   sql.NullString{String: "0.000000"},
+		MaxPrice:   sql.NullString{String: "50.000000", Valid: true},
+		MinRating:  sql.NullString{String: "0.000000"},
+		Sort:       "rating_desc",
+		Limit:      defaultSearchLimit,
+	}).Return([]database.SearchProductsRow{{
+		ID:            "p1",
+		Name:          "Red <b>Shoes</b>",
+		Rank:          0.6,
+		NameHighlight: "\uE000Red\uE001 <b>\uE000Shoes\uE001</b>",
+		Snippet:       "Comfy \uE000red\uE001 running shoes & socks",
+	}}, nil)
+	mockDB.On("ListProductImagesByProductIDs", mock.Anything, []string{"p1"}).Return([]database.ProductImage{
+		{ID: "i1", ProductID: "p1", ImageUrl: "/static/shoes.png", IsPrimary: true},
+	}, nil)
+
+	var params SearchProductsRequest
+	params.Query = "  red shoes "
+	params.CategoryID.String, params.CategoryID.Valid = "c1", true
+	params.MaxPrice.Float64, params.MaxPrice.Valid = 50, true
+	params.Sort = "rating_desc"
+
+	res, err := service.SearchProducts(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "p1", res[0].ID)
+	assert.Equal(t, "Red <b>Shoes</b>", res[0].Name)
+	assert.InDelta(t, 0.6, res[0].Rank, 0.001)
+	assert.Equal(t, "<mark>Red</mark> &lt;b&gt;<mark>Shoes</mark>&lt;/b&gt;", res[0].NameHighlight)
+	assert.Equal(t, "Comfy <mark>red</mark> running shoes &amp; socks", res[0].Snippet)
+	assert.Equal(t, []models.ProductImage{{ID: "i1", ImageURL: "/static/shoes.png", IsPrimary: true}}, res[0].Images)
+	mockDB.AssertExpectations(t)
+}
+
+// TestSearchProducts_InvalidInput tests that empty or long queries, unknown sorts and out-of-range limits are rejected before querying.
+func TestSearchProducts_InvalidInput(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+
+	for name, params := range map[string]SearchProductsRequest{
+		"empty_query": {Query: "   "},
+		"long_query":  {Query: strings.Repeat("a", maxSearchQueryLen+1)},
+		"bad_sort":    {Query: "shoes", Sort: "price"},
+		"big_limit":   {Query: "shoes", Limit: maxSearchLimit + 1},
+		"neg_limit":   {Query: "shoes", Limit: -1},
+	} {
+		_, err := service.SearchProducts(context.Background(), params)
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr, name)
+		assert.Equal(t, "invalid_request", appErr.Code, name)
+	}
+	mockDB.AssertNotCalled(t, "SearchProducts", mock.Anything, mock.Anything)
+}
+
+// TestSearchProducts_Errors tests a missing database and a failing query.
+func TestSearchProducts_Errors(t *testing.T) {
+	_, err := (&productServiceImpl{}).SearchProducts(context.Background(), SearchProductsRequest{Query: "shoes"})
+	require.ErrorContains(t, err, "DB is nil")
+
+	mockDB := new(mockDBQueries)
+	mockDB.On("SearchProducts", mock.Anything, mock.Anything).Return(nil, errors.New("syntax error"))
+	_, err = (&productServiceImpl{db: mockDB}).SearchProducts(context.Background(), SearchProductsRequest{Query: "shoes"})
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "search_error", appErr.Code)
+}
+
 // TestProductDBAdapters_Coverage is a minimal test that calls each ProductDBQueriesAdapter and ProductDBConnAdapter method with dummy or nil arguments.
 // Its sole purpose is to exercise all adapter code paths for coverage, catching panics to avoid test failures.
 // This does not verify business logic or DB interaction, but ensures all wrappers are covered.
@@ -366,18 +648,14 @@ func TestProductDBAdapters_Coverage(t *testing.T) {
 		defer func() { _ = recover() }()
 		_ = adapter.DeleteProductByID(ctx, "")
 	})
-	t.Run("GetAllProducts", func(_ *testing.T) {
-		defer func() { _ = recover() }()
-		_, _ = adapter.GetAllProducts(ctx)
-	})
-	t.Run("GetAllActiveProducts", func(_ *testing.T) {
-		defer func() { _ = recover() }()
-		_, _ = adapter.GetAllActiveProducts(ctx)
-	})
 	t.Run("GetProductByID", func(_ *testing.T) {
 		defer func() { _ = recover() }()
 		_, _ = adapter.GetProductByID(ctx, "")
 	})
+	t.Run("GetProductByIDForUpdate", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.GetProductByIDForUpdate(ctx, "")
+	})
 	t.Run("GetActiveProductByID", func(_ *testing.T) {
 		defer func() { _ = recover() }()
 		_, _ = adapter.GetActiveProductByID(ctx, "")
@@ -386,6 +664,42 @@ func TestProductDBAdapters_Coverage(t *testing.T) {
 		defer func() { _ = recover() }()
 		_, _ = adapter.FilterProducts(ctx, database.FilterProductsParams{})
 	})
+	t.Run("SearchProducts", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.SearchProducts(ctx, database.SearchProductsParams{})
+	})
+	t.Run("ListProductVariantsByProductID", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.ListProductVariantsByProductID(ctx, "")
+	})
+	t.Run("CreateProductVariant", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.CreateProductVariant(ctx, database.CreateProductVariantParams{})
+	})
+	t.Run("UpdateProductVariant", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.UpdateProductVariant(ctx, database.UpdateProductVariantParams{})
+	})
+	t.Run("GetProductVariantByIDForUpdate", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.GetProductVariantByIDForUpdate(ctx, "")
+	})
+	t.Run("VariantHasOpenOrders", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.VariantHasOpenOrders(ctx, sql.NullString{})
+	})
+	t.Run("DeactivateProductVariant", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.DeactivateProductVariant(ctx, database.DeactivateProductVariantParams{})
+	})
+	t.Run("ListProductImagesByProductID", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.ListProductImagesByProductID(ctx, "")
+	})
+	t.Run("ListProductImagesByProductIDs", func(_ *testing.T) {
+		defer func() { _ = recover() }()
+		_, _ = adapter.ListProductImagesByProductIDs(ctx, nil)
+	})
 
 	connAdapter := &ProductDBConnAdapter{DB: nil}
 	t.Run("BeginTx", func(_ *testing.T) {
@@ -0,0 +1,249 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// product_variant_service.go: Implements product variant CRUD: validation, SKU uniqueness, and effective variant prices.
+
+// maxVariantFieldLen caps the length of a variant's SKU, size, and color.
+const maxVariantFieldLen = 64
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation.
+const uniqueViolation = "23505"
+
+// ListProductVariants returns the variants of a product, oldest first (admin: all, non-admin: only active
+// variants of an active product). Each variant's price is its override or, if unset, the product price.
+func (s *productServiceImpl) ListProductVariants(ctx context.Context, productID string, isAdmin bool) ([]ProductVariantResponse, error) {
+	product, err := s.variantProduct(ctx, productID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.db.ListProductVariantsByProductID(ctx, productID)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "list_variants_error", Message: "Error listing product variants", Err: err}
+	}
+	responses := make([]ProductVariantResponse, 0, len(variants))
+	for _, v := range variants {
+		if !isAdmin && !v.IsActive {
+			continue
+		}
+		responses = append(responses, toVariantResponse(v, product))
+	}
+	return responses, nil
+}
+
+// CreateProductVariant adds a variant to a product. Returns "sku_conflict" if the SKU is already used by any variant.
+func (s *productServiceImpl) CreateProductVariant(ctx context.Context, productID string, params ProductVariantRequest) (ProductVariantResponse, error) {
+	if err := validateVariantRequest(&params); err != nil {
+		return ProductVariantResponse{}, err
+	}
+	product, err := s.variantProduct(ctx, productID, true)
+	if err != nil {
+		return ProductVariantResponse{}, err
+	}
+
+	isActive := true
+	if params.IsActive != nil {
+		isActive = *params.IsActive
+	}
+	timeNow := time.Now().UTC()
+	variant, err := s.db.CreateProductVariant(ctx, database.CreateProductVariantParams{
+		ID:        utils.NewUUIDString(),
+		ProductID: productID,
+		Sku:       params.SKU,
+		Size:      utils.ToNullString(params.Size),
+		Color:     utils.ToNullString(params.Color),
+		Price:     variantPriceArg(params),
+		Stock:     params.Stock,
+		IsActive:  isActive,
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+	})
+	if err != nil {
+		return ProductVariantResponse{}, variantWriteError(err, "create_variant_error", "Error creating product variant")
+	}
+	return toVariantResponse(variant, product), nil
+}
+
+// UpdateProductVariant replaces the fields of a product's variant.
+// Returns "variant_not_found" if the variant does not exist or belongs to another product.
+func (s *productServiceImpl) UpdateProductVariant(ctx context.Context, productID, variantID string, params ProductVariantRequest) (ProductVariantResponse, error) {
+	if variantID == "" {
+		return ProductVariantResponse{}, &handlers.AppError{Code: "invalid_request", Message: "Variant ID is required"}
+	}
+	if err := validateVariantRequest(&params); err != nil {
+		return ProductVariantResponse{}, err
+	}
+	product, err := s.variantProduct(ctx, productID, true)
+	if err != nil {
+		return ProductVariantResponse{}, err
+	}
+
+	isActive := true
+	if params.IsActive != nil {
+		isActive = *params.IsActive
+	}
+	variant, err := s.db.UpdateProductVariant(ctx, database.UpdateProductVariantParams{
+		ID:        variantID,
+		ProductID: productID,
+		Sku:       params.SKU,
+		Size:      utils.ToNullString(params.Size),
+		Color:     utils.ToNullString(params.Color),
+		Price:     variantPriceArg(params),
+		Stock:     params.Stock,
+		IsActive:  isActive,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProductVariantResponse{}, &handlers.AppError{Code: "variant_not_found", Message: "Product variant not found", Err: err}
+	}
+	if err != nil {
+		return ProductVariantResponse{}, variantWriteError(err, "update_variant_error", "Error updating product variant")
+	}
+	return toVariantResponse(variant, product), nil
+}
+
+// DeleteProductVariant retires a product's variant by deactivating it, so past order items keep their variant.
+// The variant row is locked first, which serializes the delete with checkouts reserving its stock.
+// Returns "variant_in_use" while a pending, paid or shipped order contains the variant.
+func (s *productServiceImpl) DeleteProductVariant(ctx context.Context, productID, variantID string) error {
+	if s.db == nil || s.dbConn == nil {
+		return &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+	}
+	if productID == "" || variantID == "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "Product ID and variant ID are required"}
+	}
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			fmt.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+	queries := s.db.WithTx(tx)
+
+	variant, err := queries.GetProductVariantByIDForUpdate(ctx, variantID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && variant.ProductID != productID) {
+		return &handlers.AppError{Code: "variant_not_found", Message: "Product variant not found", Err: err}
+	}
+	if err != nil {
+		return &handlers.AppError{Code: "delete_variant_error", Message: "Error deleting product variant", Err: err}
+	}
+	inUse, err := queries.VariantHasOpenOrders(ctx, utils.ToNullString(variantID))
+	if err != nil {
+		return &handlers.AppError{Code: "delete_variant_error", Message: "Error deleting product variant", Err: err}
+	}
+	if inUse {
+		return &handlers.AppError{Code: "variant_in_use", Message: "Product variant is part of an open order"}
+	}
+	if _, err := queries.DeactivateProductVariant(ctx, database.DeactivateProductVariantParams{
+		ID:        variantID,
+		ProductID: productID,
+		UpdatedAt: time.Now().UTC(),
+	}); err != nil {
+		return &handlers.AppError{Code: "delete_variant_error", Message: "Error deleting product variant", Err: err}
+	}
+	if err := tx.Commit(); err != nil {
+		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+	return nil
+}
+
+// variantProduct returns the product a variant operation applies to (admin: any, non-admin: only active).
+func (s *productServiceImpl) variantProduct(ctx context.Context, productID string, isAdmin bool) (database.Product, error) {
+	if s.db == nil {
+		return database.Product{}, &handlers.AppError{Code: "transaction_error", Message: "DB is nil", Err: fmt.Errorf("db is nil")}
+	}
+	if productID == "" {
+		return database.Product{}, &handlers.AppError{Code: "invalid_request", Message: "Product ID is required"}
+	}
+	var (
+		product database.Product
+		err     error
+	)
+	if isAdmin {
+		product, err = s.db.GetProductByID(ctx, productID)
+	} else {
+		product, err = s.db.GetActiveProductByID(ctx, productID)
+	}
+	if err != nil {
+		return database.Product{}, &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
+	}
+	return product, nil
+}
+
+// validateVariantRequest trims the text fields in place and checks the SKU, price override, and stock.
+func validateVariantRequest(params *ProductVariantRequest) error {
+	params.SKU = strings.TrimSpace(params.SKU)
+	params.Size = strings.TrimSpace(params.Size)
+	params.Color = strings.TrimSpace(params.Color)
+	if params.SKU == "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "SKU is required"}
+	}
+	for _, field := range []string{params.SKU, params.Size, params.Color} {
+		if utf8.RuneCountInString(field) > maxVariantFieldLen {
+			return &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("SKU, size, and color must be at most %d characters", maxVariantFieldLen)}
+		}
+	}
+	if params.Price != nil && !params.Price.IsPositive() {
+		return &handlers.AppError{Code: "invalid_request", Message: "Variant price must be greater than 0"}
+	}
+	if params.Stock < 0 {
+		return &handlers.AppError{Code: "invalid_request", Message: "Stock cannot be negative"}
+	}
+	return nil
+}
+
+// variantPriceArg returns the price override as a query argument, null when the variant uses the product price.
+func variantPriceArg(params ProductVariantRequest) sql.NullString {
+	if params.Price == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: params.Price.String(), Valid: true}
+}
+
+// variantWriteError maps a failed variant insert or update to "sku_conflict" when the SKU is taken.
+func variantWriteError(err error, code, message string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return &handlers.AppError{Code: "sku_conflict", Message: "SKU is already in use", Err: err}
+	}
+	return &handlers.AppError{Code: code, Message: message, Err: err}
+}
+
+// toVariantResponse converts a variant row, falling back to the product price when it has no override.
+func toVariantResponse(v database.ProductVariant, product database.Product) ProductVariantResponse {
+	price := product.Price
+	if v.Price.Valid {
+		price = v.Price.String
+	}
+	return ProductVariantResponse{
+		ID:               v.ID,
+		ProductID:        v.ProductID,
+		SKU:              v.Sku,
+		Size:             v.Size.String,
+		Color:            v.Color.String,
+		Price:            price,
+		HasPriceOverride: v.Price.Valid,
+		Stock:            v.Stock,
+		IsActive:         v.IsActive,
+		CreatedAt:        v.CreatedAt,
+		UpdatedAt:        v.UpdatedAt,
+	}
+}
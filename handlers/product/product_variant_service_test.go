@@ -0,0 +1,237 @@
+// Package producthandlers provides HTTP handlers and business logic for managing products, including CRUD operations and filtering.
+package producthandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// product_variant_service_test.go: Tests for product variant listing, validation, SKU conflicts, and effective prices.
+
+func requireAppErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	appErr := &handlers.AppError{}
+	require.True(t, errors.As(err, &appErr), "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+// TestListProductVariants_NonAdmin verifies that non-admins only see active variants, priced at their
+// override or the product price.
+func TestListProductVariants_NonAdmin(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("GetActiveProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1", Price: "20.00"}, nil)
+	mockDB.On("ListProductVariantsByProductID", mock.Anything, "p1").Return([]database.ProductVariant{
+		{ID: "v1", ProductID: "p1", Sku: "TEE-M", IsActive: true},
+		{ID: "v2", ProductID: "p1", Sku: "TEE-XL", Price: sql.NullString{String: "24.00", Valid: true}, IsActive: true},
+		{ID: "v3", ProductID: "p1", Sku: "TEE-XXL"},
+	}, nil)
+
+	variants, err := service.ListProductVariants(context.Background(), "p1", false)
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+	assert.Equal(t, "20.00", variants[0].Price)
+	assert.False(t, variants[0].HasPriceOverride)
+	assert.Equal(t, "24.00", variants[1].Price)
+	assert.True(t, variants[1].HasPriceOverride)
+	mockDB.AssertExpectations(t)
+}
+
+// TestListProductVariants_Admin verifies that admins see inactive variants of any product.
+func TestListProductVariants_Admin(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1", Price: "20.00"}, nil)
+	mockDB.On("ListProductVariantsByProductID", mock.Anything, "p1").Return([]database.ProductVariant{
+		{ID: "v3", ProductID: "p1", Sku: "TEE-XXL"},
+	}, nil)
+
+	variants, err := service.ListProductVariants(context.Background(), "p1", true)
+	require.NoError(t, err)
+	assert.Len(t, variants, 1)
+}
+
+// TestListProductVariants_Errors verifies the errors returned when the product or its variants cannot be read.
+func TestListProductVariants_Errors(t *testing.T) {
+	_, err := (&productServiceImpl{}).ListProductVariants(context.Background(), "p1", false)
+	requireAppErrorCode(t, err, "transaction_error")
+
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	_, err = service.ListProductVariants(context.Background(), "", false)
+	requireAppErrorCode(t, err, "invalid_request")
+
+	mockDB.On("GetActiveProductByID", mock.Anything, "gone").Return(database.Product{}, sql.ErrNoRows)
+	_, err = service.ListProductVariants(context.Background(), "gone", false)
+	requireAppErrorCode(t, err, "product_not_found")
+
+	mockDB.On("GetActiveProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	mockDB.On("ListProductVariantsByProductID", mock.Anything, "p1").Return(nil, errors.New("db down"))
+	_, err = service.ListProductVariants(context.Background(), "p1", false)
+	requireAppErrorCode(t, err, "list_variants_error")
+}
+
+// TestCreateProductVariant_Success verifies that a variant is created with trimmed fields, a price override,
+// and active by default.
+func TestCreateProductVariant_Success(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	price := money.MustParse("24.00", money.USD)
+
+	mockDB.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1", Price: "20.00"}, nil)
+	mockDB.On("CreateProductVariant", mock.Anything, mock.MatchedBy(func(p database.CreateProductVariantParams) bool {
+		return p.ID != "" && p.ProductID == "p1" && p.Sku == "TEE-XL" && p.Size.String == "XL" && !p.Color.Valid &&
+			p.Price == sql.NullString{String: "24.00", Valid: true} && p.Stock == 5 && p.IsActive
+	})).Return(database.ProductVariant{ID: "v1", ProductID: "p1", Sku: "TEE-XL", Price: sql.NullString{String: "24.00", Valid: true}, Stock: 5, IsActive: true}, nil)
+
+	variant, err := service.CreateProductVariant(context.Background(), "p1", ProductVariantRequest{SKU: " TEE-XL ", Size: "XL ", Price: &price, Stock: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "24.00", variant.Price)
+	assert.Equal(t, "TEE-XL", variant.SKU)
+	mockDB.AssertExpectations(t)
+}
+
+// TestCreateProductVariant_InvalidInput verifies the validation of the variant payload.
+func TestCreateProductVariant_InvalidInput(t *testing.T) {
+	zero := money.New(0, money.USD)
+	tests := []struct {
+		name   string
+		params ProductVariantRequest
+	}{
+		{"missing SKU", ProductVariantRequest{SKU: "  "}},
+		{"SKU too long", ProductVariantRequest{SKU: strings.Repeat("x", maxVariantFieldLen+1)}},
+		{"color too long", ProductVariantRequest{SKU: "S", Color: strings.Repeat("x", maxVariantFieldLen+1)}},
+		{"zero price", ProductVariantRequest{SKU: "S", Price: &zero}},
+		{"negative stock", ProductVariantRequest{SKU: "S", Stock: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(mockDBQueries)
+			service := &productServiceImpl{db: mockDB}
+			_, err := service.CreateProductVariant(context.Background(), "p1", tt.params)
+			requireAppErrorCode(t, err, "invalid_request")
+			mockDB.AssertNotCalled(t, "CreateProductVariant", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestCreateProductVariant_WriteErrors verifies that a taken SKU maps to "sku_conflict" and other failures to
+// "create_variant_error".
+func TestCreateProductVariant_WriteErrors(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	mockDB.On("CreateProductVariant", mock.Anything, mock.MatchedBy(func(p database.CreateProductVariantParams) bool {
+		return p.Sku == "TAKEN"
+	})).Return(database.ProductVariant{}, &pq.Error{Code: uniqueViolation})
+	mockDB.On("CreateProductVariant", mock.Anything, mock.Anything).Return(database.ProductVariant{}, errors.New("db down"))
+
+	_, err := service.CreateProductVariant(context.Background(), "p1", ProductVariantRequest{SKU: "TAKEN"})
+	requireAppErrorCode(t, err, "sku_conflict")
+
+	_, err = service.CreateProductVariant(context.Background(), "p1", ProductVariantRequest{SKU: "FREE"})
+	requireAppErrorCode(t, err, "create_variant_error")
+}
+
+// TestUpdateProductVariant_Success verifies that clearing the price override falls back to the product price.
+func TestUpdateProductVariant_Success(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	inactive := false
+
+	mockDB.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1", Price: "20.00"}, nil)
+	mockDB.On("UpdateProductVariant", mock.Anything, mock.MatchedBy(func(p database.UpdateProductVariantParams) bool {
+		return p.ID == "v1" && p.ProductID == "p1" && !p.Price.Valid && !p.IsActive
+	})).Return(database.ProductVariant{ID: "v1", ProductID: "p1", Sku: "TEE-XL"}, nil)
+
+	variant, err := service.UpdateProductVariant(context.Background(), "p1", "v1", ProductVariantRequest{SKU: "TEE-XL", IsActive: &inactive})
+	require.NoError(t, err)
+	assert.Equal(t, "20.00", variant.Price)
+	assert.False(t, variant.HasPriceOverride)
+	assert.False(t, variant.IsActive)
+}
+
+// TestUpdateProductVariant_Errors verifies the errors returned for a missing ID, an unknown variant, and a taken SKU.
+func TestUpdateProductVariant_Errors(t *testing.T) {
+	mockDB := new(mockDBQueries)
+	service := &productServiceImpl{db: mockDB}
+	mockDB.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	mockDB.On("UpdateProductVariant", mock.Anything, mock.MatchedBy(func(p database.UpdateProductVariantParams) bool {
+		return p.ID == "missing"
+	})).Return(database.ProductVariant{}, sql.ErrNoRows)
+	mockDB.On("UpdateProductVariant", mock.Anything, mock.Anything).Return(database.ProductVariant{}, &pq.Error{Code: uniqueViolation})
+
+	_, err := service.UpdateProductVariant(context.Background(), "p1", "", ProductVariantRequest{SKU: "S"})
+	requireAppErrorCode(t, err, "invalid_request")
+
+	_, err = service.UpdateProductVariant(context.Background(), "p1", "missing", ProductVariantRequest{SKU: "S"})
+	requireAppErrorCode(t, err, "variant_not_found")
+
+	_, err = service.UpdateProductVariant(context.Background(), "p1", "v1", ProductVariantRequest{SKU: "TAKEN"})
+	requireAppErrorCode(t, err, "sku_conflict")
+}
+
+// newVariantDeleteService returns a service whose transaction commits, with variant v1 of product p1 locked.
+func newVariantDeleteService() (*productServiceImpl, *mockDBQueries, *mockTx) {
+	mockDB := new(mockDBQueries)
+	mockConn := new(mockDBConn)
+	tx := new(mockTx)
+	mockConn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(tx, nil)
+	mockDB.On("WithTx", tx).Return(mockDB)
+	mockDB.On("GetProductVariantByIDForUpdate", mock.Anything, "v1").Return(database.ProductVariant{ID: "v1", ProductID: "p1"}, nil)
+	tx.On("Commit").Return(nil)
+	tx.On("Rollback").Return(nil)
+	return &productServiceImpl{db: mockDB, dbConn: mockConn}, mockDB, tx
+}
+
+// TestDeleteProductVariant verifies that deleting a variant deactivates it under a row lock.
+func TestDeleteProductVariant(t *testing.T) {
+	service, mockDB, tx := newVariantDeleteService()
+	mockDB.On("VariantHasOpenOrders", mock.Anything, sql.NullString{String: "v1", Valid: true}).Return(false, nil)
+	mockDB.On("DeactivateProductVariant", mock.Anything, mock.MatchedBy(func(p database.DeactivateProductVariantParams) bool {
+		return p.ID == "v1" && p.ProductID == "p1" && !p.UpdatedAt.IsZero()
+	})).Return(int64(1), nil)
+
+	require.NoError(t, service.DeleteProductVariant(context.Background(), "p1", "v1"))
+	mockDB.AssertExpectations(t)
+	tx.AssertCalled(t, "Commit")
+}
+
+// TestDeleteProductVariant_InUse verifies that a variant in a pending, paid or shipped order is kept active.
+func TestDeleteProductVariant_InUse(t *testing.T) {
+	service, mockDB, tx := newVariantDeleteService()
+	mockDB.On("VariantHasOpenOrders", mock.Anything, sql.NullString{String: "v1", Valid: true}).Return(true, nil)
+
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", "v1"), "variant_in_use")
+	mockDB.AssertNotCalled(t, "DeactivateProductVariant", mock.Anything, mock.Anything)
+	tx.AssertNotCalled(t, "Commit")
+}
+
+// TestDeleteProductVariant_Errors verifies the errors for missing IDs, unknown variants, and DB failures.
+func TestDeleteProductVariant_Errors(t *testing.T) {
+	service, mockDB, _ := newVariantDeleteService()
+	mockDB.On("GetProductVariantByIDForUpdate", mock.Anything, "missing").Return(database.ProductVariant{}, sql.ErrNoRows)
+	mockDB.On("GetProductVariantByIDForUpdate", mock.Anything, "other").Return(database.ProductVariant{ID: "other", ProductID: "p2"}, nil)
+	mockDB.On("GetProductVariantByIDForUpdate", mock.Anything, "broken").Return(database.ProductVariant{}, errors.New("db down"))
+	mockDB.On("VariantHasOpenOrders", mock.Anything, mock.Anything).Return(false, nil)
+	mockDB.On("DeactivateProductVariant", mock.Anything, mock.Anything).Return(int64(0), errors.New("db down"))
+
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", "missing"), "variant_not_found")
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", "other"), "variant_not_found")
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", "broken"), "delete_variant_error")
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", "v1"), "delete_variant_error")
+	requireAppErrorCode(t, service.DeleteProductVariant(context.Background(), "p1", ""), "invalid_request")
+	requireAppErrorCode(t, (&productServiceImpl{}).DeleteProductVariant(context.Background(), "p1", "v1"), "transaction_error")
+}
@@ -6,10 +6,14 @@ import (
 	"errors"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/STaninnat/ecom-backend/handlers"
+	uploadhandlers "github.com/STaninnat/ecom-backend/handlers/upload"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/models"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
@@ -20,7 +24,9 @@ import (
 type HandlersProductConfig struct {
 	DB             *database.Queries
 	DBConn         *sql.DB
-	Logger         handlers.HandlerLogger // for logging
+	Logger         handlers.HandlerLogger     // for logging
+	Storage        uploadhandlers.FileStorage // removes a deleted product's image files; nil keeps them
+	UploadPath     string
 	productService ProductService
 	productMutex   sync.RWMutex
 }
@@ -36,7 +42,7 @@ func (cfg *HandlersProductConfig) InitProductService() error {
 	}
 	cfg.productMutex.Lock()
 	defer cfg.productMutex.Unlock()
-	cfg.productService = NewProductService(cfg.DB, cfg.DBConn)
+	cfg.productService = NewProductService(cfg.DB, cfg.DBConn, cfg.Storage, cfg.UploadPath)
 	return nil
 }
 
@@ -53,9 +59,9 @@ func (cfg *HandlersProductConfig) GetProductService() ProductService {
 	defer cfg.productMutex.Unlock()
 	if cfg.productService == nil {
 		if cfg.DB == nil || cfg.DBConn == nil {
-			cfg.productService = NewProductService(nil, nil)
+			cfg.productService = NewProductService(nil, nil, nil, "")
 		} else {
-			cfg.productService = NewProductService(cfg.DB, cfg.DBConn)
+			cfg.productService = NewProductService(cfg.DB, cfg.DBConn, cfg.Storage, cfg.UploadPath)
 		}
 	}
 	return cfg.productService
@@ -69,12 +75,16 @@ func (cfg *HandlersProductConfig) handleProductError(w http.ResponseWriter, r *h
 	var appErr *handlers.AppError
 	if errors.As(err, &appErr) {
 		switch appErr.Code {
-		case "transaction_error", "update_failed", "commit_error", "create_product_error", "delete_product_error":
+		case "transaction_error", "update_failed", "commit_error", "create_product_error", "delete_product_error", "search_error",
+			"list_variants_error", "create_variant_error", "update_variant_error", "delete_variant_error", "list_images_error":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusInternalServerError, "Something went wrong, please try again later")
-		case "product_not_found":
+		case "product_not_found", "variant_not_found":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusNotFound, appErr.Message)
+		case "sku_conflict", "variant_in_use":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusConflict, appErr.Message)
 		case "invalid_request":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message)
@@ -93,14 +103,14 @@ func (cfg *HandlersProductConfig) handleProductError(w http.ResponseWriter, r *h
 // ProductRequest represents the data structure for creating or updating a product.
 // Includes all product fields with optional ID for updates and optional IsActive for status changes.
 type ProductRequest struct {
-	ID          string  `json:"id,omitempty"`
-	CategoryID  string  `json:"category_id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int32   `json:"stock"`
-	ImageURL    string  `json:"image_url"`
-	IsActive    *bool   `json:"is_active,omitempty"`
+	ID          string       `json:"id,omitempty"`
+	CategoryID  string       `json:"category_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+	Stock       int32        `json:"stock"`
+	ImageURL    string       `json:"image_url"`
+	IsActive    *bool        `json:"is_active,omitempty"`
 }
 
 // FilterProductsRequest represents the criteria for filtering products.
@@ -110,6 +120,65 @@ type FilterProductsRequest struct {
 	IsActive   utils.NullBool    `json:"is_active,omitempty"`
 	MinPrice   utils.NullFloat64 `json:"min_price,omitempty"`
 	MaxPrice   utils.NullFloat64 `json:"max_price,omitempty"`
+	MinRating  utils.NullFloat64 `json:"min_rating,omitempty"`
+	Sort       string            `json:"sort,omitempty"`
+}
+
+// SearchProductsRequest represents a keyword search over active products, optionally narrowed by the filter fields.
+// Sort is empty or "relevance" for most relevant first, or one of "newest", "rating_desc", "rating_asc", "reviews_desc".
+type SearchProductsRequest struct {
+	Query      string
+	CategoryID utils.NullString
+	MinPrice   utils.NullFloat64
+	MaxPrice   utils.NullFloat64
+	MinRating  utils.NullFloat64
+	Sort       string
+	// Limit of 0 returns the default number of results
+	Limit int
+}
+
+// ProductResponse represents a product in read responses, with its image gallery in display order.
+// The product's image_url is the URL of the gallery's primary image.
+type ProductResponse struct {
+	database.Product
+	Images []models.ProductImage `json:"images"`
+}
+
+// ProductSearchResult is a product matched by a search, with its relevance, highlighted HTML, and image gallery.
+type ProductSearchResult struct {
+	database.Product
+	Rank float32 `json:"rank"`
+	// NameHighlight and Snippet are HTML-escaped with matches wrapped in <mark>
+	NameHighlight string                `json:"name_highlight"`
+	Snippet       string                `json:"snippet"`
+	Images        []models.ProductImage `json:"images"`
+}
+
+// ProductVariantRequest represents the data structure for creating or updating a product variant.
+// Price overrides the product price when set; leave it out to sell the variant at the product price.
+type ProductVariantRequest struct {
+	SKU      string        `json:"sku"`
+	Size     string        `json:"size,omitempty"`
+	Color    string        `json:"color,omitempty"`
+	Price    *money.Amount `json:"price,omitempty"`
+	Stock    int32         `json:"stock"`
+	IsActive *bool         `json:"is_active,omitempty"`
+}
+
+// ProductVariantResponse represents a product variant in responses.
+// Price is the price the variant sells at: its override, or the product price when HasPriceOverride is false.
+type ProductVariantResponse struct {
+	ID               string    `json:"id"`
+	ProductID        string    `json:"product_id"`
+	SKU              string    `json:"sku"`
+	Size             string    `json:"size,omitempty"`
+	Color            string    `json:"color,omitempty"`
+	Price            string    `json:"price"`
+	HasPriceOverride bool      `json:"has_price_override"`
+	Stock            int32     `json:"stock"`
+	IsActive         bool      `json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 // productResponse represents the standard response structure for product operations.
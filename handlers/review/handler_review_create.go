@@ -35,13 +35,16 @@ func (r *ReviewCreateRequest) Validate() error {
 
 // HandlerCreateReview handles HTTP POST requests to create a new review.
 // @Summary      Create review
-// @Description  Creates a new review for a product
+// @Description  Creates a new review for a product. Each user can review a product once; reviews are marked as verified purchases when the user has a delivered order containing the product
 // @Tags         reviews
 // @Accept       json
 // @Produce      json
 // @Param        review  body  object{}  true  "Review payload"
 // @Success      201  {object}  handlers.APIResponse
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /v1/reviews/ [post]
 func (cfg *HandlersReviewConfig) HandlerCreateReview(w http.ResponseWriter, r *http.Request, user database.User) {
 	ip, userAgent := handlers.GetRequestMetadata(r)
@@ -70,7 +73,7 @@ func (cfg *HandlersReviewConfig) HandlerCreateReview(w http.ResponseWriter, r *h
 		ProductID: req.ProductID,
 		Rating:    req.Rating,
 		Comment:   req.Comment,
-		MediaURLs: req.MediaURLs,
+		MediaIDs:  req.MediaIDs,
 	}
 
 	if err := cfg.GetReviewService().CreateReview(ctx, review); err != nil {
@@ -35,7 +35,7 @@ func TestHandlerCreateReview_Success(t *testing.T) {
 		ProductID: "p1",
 		Rating:    5,
 		Comment:   "Great!",
-		MediaURLs: []string{"url1"},
+		MediaIDs:  []string{"media1"},
 	}
 	jsonBody, _ := json.Marshal(reqBody)
 	review := &models.Review{
@@ -43,7 +43,7 @@ func TestHandlerCreateReview_Success(t *testing.T) {
 		ProductID: reqBody.ProductID,
 		Rating:    reqBody.Rating,
 		Comment:   reqBody.Comment,
-		MediaURLs: reqBody.MediaURLs,
+		MediaIDs:  reqBody.MediaIDs,
 	}
 	mockService.On("CreateReview", mock.Anything, review).Return(nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "create_review", "Review created successfully", mock.Anything, mock.Anything).Return()
@@ -17,7 +17,7 @@ import (
 
 // HandlerDeleteReviewByID handles HTTP DELETE requests to delete a review by its ID.
 // @Summary      Delete review by ID
-// @Description  Deletes a review by its ID
+// @Description  Deletes a review by its ID (owner or admin)
 // @Tags         reviews
 // @Produce      json
 // @Param        id  path  string  true  "Review ID"
@@ -41,7 +41,7 @@ func (cfg *HandlersReviewConfig) HandlerDeleteReviewByID(w http.ResponseWriter,
 		return
 	}
 
-	if review.UserID != user.ID {
+	if review.UserID != user.ID && user.Role != "admin" {
 		cfg.Logger.LogHandlerError(ctx, "delete_review_by_id", "unauthorized", "You can only delete your own reviews", ip, userAgent, nil)
 		middlewares.RespondWithError(w, http.StatusForbidden, "You can only delete your own reviews")
 		return
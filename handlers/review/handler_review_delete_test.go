@@ -141,6 +141,28 @@ func TestHandlerDeleteReviewByID_Unauthorized(t *testing.T) {
 	mockLogger.AssertExpectations(t)
 }
 
+// TestHandlerDeleteReviewByID_Admin tests that admins can delete other users' reviews.
+func TestHandlerDeleteReviewByID_Admin(t *testing.T) {
+	mockService := new(MockReviewService)
+	mockLogger := new(MockLogger)
+	cfg := &HandlersReviewConfig{
+		Config:        &handlers.Config{},
+		Logger:        mockLogger,
+		ReviewService: mockService,
+	}
+	user := database.User{ID: "admin1", Role: "admin"}
+	review := &models.Review{ID: testReviewID, UserID: "other", ProductID: "p1"}
+	mockService.On("GetReviewByID", mock.Anything, testReviewID).Return(review, nil)
+	mockService.On("DeleteReviewByID", mock.Anything, testReviewID).Return(nil)
+	mockLogger.On("LogHandlerSuccess", mock.Anything, "delete_review_by_id", "Review deleted successfully", mock.Anything, mock.Anything).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerDeleteReviewByID(w, makeDeleteRequestWithID(testReviewID), user)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
 // TestHandlerDeleteReviewByID_ServiceError tests the handler's behavior when the review service encounters an error during deletion.
 // It ensures the handler returns HTTP 500 and logs the service error correctly when the deletion operation fails.
 func TestHandlerDeleteReviewByID_ServiceError(t *testing.T) {
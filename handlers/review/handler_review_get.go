@@ -224,6 +224,13 @@ func (cfg *HandlersReviewConfig) HandlerGetReviewByID(w http.ResponseWriter, r *
 		return
 	}
 
+	// Reviews that are not approved are not public; authors still see them in their own review list
+	if !isPublished(review) {
+		cfg.Logger.LogHandlerError(ctx, "get_review_by_id", "not_found", "Review is not published", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusNotFound, "Review not found")
+		return
+	}
+
 	cfg.Logger.LogHandlerSuccess(ctx, "get_review_by_id", "Got review successfully", ip, userAgent)
 	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
 		Message: "Review fetched successfully",
@@ -233,6 +233,30 @@ func TestHandlerGetReviewByID_Success(t *testing.T) {
 	mockLogger.AssertExpectations(t)
 }
 
+// TestHandlerGetReviewByID_NotPublished tests that reviews that are not approved are not returned.
+func TestHandlerGetReviewByID_NotPublished(t *testing.T) {
+	for _, status := range []string{models.ReviewStatusPending, models.ReviewStatusRejected, models.ReviewStatusHidden} {
+		t.Run(status, func(t *testing.T) {
+			mockService := new(MockReviewService)
+			mockLogger := new(MockLogger)
+			cfg := &HandlersReviewConfig{
+				Config:        &handlers.Config{},
+				Logger:        mockLogger,
+				ReviewService: mockService,
+			}
+			review := &models.Review{ID: testReviewID, ProductID: testProductID, Status: status}
+			mockService.On("GetReviewByID", mock.Anything, testReviewID).Return(review, nil)
+			mockLogger.On("LogHandlerError", mock.Anything, "get_review_by_id", "not_found", "Review is not published", mock.Anything, mock.Anything, nil).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerGetReviewByID(w, makeGetRequestWithReviewID(testReviewID))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
+
 // TestHandlerGetReviewByID_MissingID tests the handler's response when no review ID is provided in the request.
 // It checks that the handler returns HTTP 400 and logs the appropriate error for missing review ID.
 func TestHandlerGetReviewByID_MissingID(t *testing.T) {
@@ -0,0 +1,83 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_review_media.go: Handles uploading and deleting review photos.
+
+// HandlerUploadReviewMedia handles HTTP POST requests to upload a review photo.
+// @Summary      Upload review photo
+// @Description  Uploads a photo for use in the user's reviews. Pass the returned ID in media_ids when creating or updating a review
+// @Tags         reviews
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        image  formData  file  true  "Image file (JPEG, PNG, GIF or WebP)"
+// @Success      201  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      413  {object}  map[string]string
+// @Router       /v1/reviews/media [post]
+func (cfg *HandlersReviewConfig) HandlerUploadReviewMedia(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	media, err := cfg.GetReviewService().UploadReviewMedia(ctx, user.ID, r)
+	if err != nil {
+		cfg.handleReviewError(w, r, err, "upload_review_media", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "upload_review_media", "Photo uploaded", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusCreated, handlers.APIResponse{
+		Message: "Photo uploaded",
+		Code:    "success",
+		Data:    media,
+	})
+}
+
+// HandlerDeleteReviewMedia handles HTTP DELETE requests to delete an unused review photo.
+// @Summary      Delete review photo
+// @Description  Deletes one of the user's uploaded photos that no review uses
+// @Tags         reviews
+// @Produce      json
+// @Param        id  path  string  true  "Media ID"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/media/{id} [delete]
+func (cfg *HandlersReviewConfig) HandlerDeleteReviewMedia(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	mediaID := chi.URLParam(r, "id")
+	if mediaID == "" {
+		cfg.Logger.LogHandlerError(ctx, "delete_review_media", "invalid_request", "Media ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Media ID is required")
+		return
+	}
+
+	if err := cfg.GetReviewService().DeleteReviewMedia(ctx, user.ID, mediaID); err != nil {
+		cfg.handleReviewError(w, r, err, "delete_review_media", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "delete_review_media", "Photo deleted", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
+		Message: "Photo deleted",
+		Code:    "success",
+	})
+}
@@ -0,0 +1,95 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// handler_review_media_test.go: Tests for the review photo upload and delete handlers.
+
+// TestHandlerUploadReviewMedia tests uploads and how service errors map to status codes.
+func TestHandlerUploadReviewMedia(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "success", wantCode: http.StatusCreated},
+		{name: "bad_type", serviceErr: &handlers.AppError{Code: "invalid_request", Message: "Unsupported image type"}, wantCode: http.StatusBadRequest},
+		{name: "quota", serviceErr: &handlers.AppError{Code: "quota_exceeded", Message: "You can store at most 50 review photos"}, wantCode: http.StatusForbidden},
+		{name: "too_large", serviceErr: &handlers.AppError{Code: "file_too_large", Message: "Image must be at most 5242880 bytes"}, wantCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			var media *models.ReviewMedia
+			if tt.serviceErr == nil {
+				media = &models.ReviewMedia{ID: "m1", UserID: "u1", URL: "/static/a.png"}
+			}
+			r := httptest.NewRequest(http.MethodPost, "/reviews/media", nil)
+			mockService.On("UploadReviewMedia", mock.Anything, "u1", r).Return(media, tt.serviceErr)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "upload_review_media", "Photo uploaded", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "upload_review_media", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerUploadReviewMedia(w, r, database.User{ID: "u1"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.serviceErr == nil {
+				var resp struct {
+					Data models.ReviewMedia `json:"data"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, "m1", resp.Data.ID)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerDeleteReviewMedia tests deleting an upload and the not found case.
+func TestHandlerDeleteReviewMedia(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not_found", serviceErr: &handlers.AppError{Code: "not_found", Message: "Media not found"}, wantCode: http.StatusNotFound},
+		{name: "in_use", serviceErr: &handlers.AppError{Code: "invalid_request", Message: "Media is used by a review; remove it from the review first"}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			mockService.On("DeleteReviewMedia", mock.Anything, "u1", "m1").Return(tt.serviceErr)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "delete_review_media", "Photo deleted", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "delete_review_media", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+			r := httptest.NewRequest(http.MethodDelete, "/reviews/media/m1", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "m1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			cfg.HandlerDeleteReviewMedia(w, r, database.User{ID: "u1"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/models"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_review_moderation.go: Admin handlers for the review moderation queue and for approving, rejecting and hiding reviews.
+
+// HandlerAdminListReviews handles HTTP GET requests to list reviews in a moderation status.
+// @Summary      Admin list reviews by status
+// @Description  Lists reviews in a moderation status, oldest first (admin only). Defaults to the pending queue
+// @Tags         reviews
+// @Produce      json
+// @Param        status    query  string  false  "Moderation status (pending, approved, rejected, hidden)"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object}  PaginatedReviewsResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/reviews/admin [get]
+func (cfg *HandlersReviewConfig) HandlerAdminListReviews(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ReviewStatusPending
+	}
+
+	page, pageSize := parsePagination(r)
+	resultAny, err := cfg.GetReviewService().ListReviewsByStatus(ctx, status, page, pageSize)
+	if err != nil {
+		cfg.handleReviewError(w, r, err, "admin_list_reviews", ip, userAgent)
+		return
+	}
+	result, ok := resultAny.(PaginatedReviewsResponse)
+	if !ok {
+		cfg.Logger.LogHandlerError(ctx, "admin_list_reviews", "internal_error", "Unexpected response type", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_list_reviews", "Listed reviews successfully", ip, userAgent)
+	result.Code = "success"
+	result.Message = "Reviews fetched successfully"
+	middlewares.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// HandlerAdminApproveReview handles HTTP POST requests to approve a review.
+// @Summary      Admin approve review
+// @Description  Publishes a review (admin only)
+// @Tags         reviews
+// @Produce      json
+// @Param        id  path  string  true  "Review ID"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/admin/{id}/approve [post]
+func (cfg *HandlersReviewConfig) HandlerAdminApproveReview(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.moderateReview(w, r, user, models.ReviewStatusApproved, "admin_approve_review", "Review approved")
+}
+
+// HandlerAdminRejectReview handles HTTP POST requests to reject a review.
+// @Summary      Admin reject review
+// @Description  Rejects a review so it is not published (admin only)
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path  string                   true   "Review ID"
+// @Param        review  body  ReviewModerationRequest  false  "Reason"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/admin/{id}/reject [post]
+func (cfg *HandlersReviewConfig) HandlerAdminRejectReview(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.moderateReview(w, r, user, models.ReviewStatusRejected, "admin_reject_review", "Review rejected")
+}
+
+// HandlerAdminHideReview handles HTTP POST requests to take down a published review.
+// @Summary      Admin hide review
+// @Description  Hides a review from product pages and rating stats (admin only)
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path  string                   true   "Review ID"
+// @Param        review  body  ReviewModerationRequest  false  "Reason"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/admin/{id}/hide [post]
+func (cfg *HandlersReviewConfig) HandlerAdminHideReview(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.moderateReview(w, r, user, models.ReviewStatusHidden, "admin_hide_review", "Review hidden")
+}
+
+// moderateReview is the shared implementation of the approve, reject and hide handlers.
+// The request body is optional and only carries the reason.
+func (cfg *HandlersReviewConfig) moderateReview(w http.ResponseWriter, r *http.Request, user database.User, status, operation, successMsg string) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	reviewID := chi.URLParam(r, "id")
+	if reviewID == "" {
+		cfg.Logger.LogHandlerError(ctx, operation, "invalid_request", "Review ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Review ID is required")
+		return
+	}
+
+	var req ReviewModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		cfg.Logger.LogHandlerError(ctx, operation, "invalid_request", "Invalid request payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	err := cfg.GetReviewService().ModerateReview(ctx, ModerateReviewParams{
+		ReviewID: reviewID,
+		Status:   status,
+		AdminID:  user.ID,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		cfg.handleReviewError(w, r, err, operation, ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, operation, successMsg, ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
+		Message: successMsg,
+		Code:    "success",
+	})
+}
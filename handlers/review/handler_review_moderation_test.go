@@ -0,0 +1,188 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// handler_review_moderation_test.go: Tests for the admin review moderation handlers.
+
+// newModerationTestConfig returns a review handler config with mocked service and logger.
+func newModerationTestConfig() (*HandlersReviewConfig, *MockReviewService, *MockLogger) {
+	mockService := new(MockReviewService)
+	mockLogger := new(MockLogger)
+	return &HandlersReviewConfig{
+		Config:        &handlers.Config{},
+		Logger:        mockLogger,
+		ReviewService: mockService,
+	}, mockService, mockLogger
+}
+
+// makeModerationRequest creates a POST request for the given review ID with the given body.
+func makeModerationRequest(id, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/reviews/admin/"+id, strings.NewReader(body))
+	ctx := chi.NewRouteContext()
+	ctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, ctx))
+}
+
+// TestHandlerAdminListReviews tests listing the moderation queue, defaulting to pending reviews.
+func TestHandlerAdminListReviews(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status string
+	}{
+		{name: "default_pending", query: "", status: models.ReviewStatusPending},
+		{name: "hidden", query: "?status=hidden&page=2&pageSize=5", status: models.ReviewStatusHidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			page, pageSize := 1, 10
+			if tt.query != "" {
+				page, pageSize = 2, 5
+			}
+			mockService.On("ListReviewsByStatus", mock.Anything, tt.status, page, pageSize).Return(PaginatedReviewsResponse{
+				Data:       []*models.Review{{ID: testReviewID, Status: tt.status}},
+				TotalCount: 1,
+			}, nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "admin_list_reviews", "Listed reviews successfully", mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminListReviews(w, httptest.NewRequest(http.MethodGet, "/reviews/admin"+tt.query, nil), database.User{ID: "admin1"})
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp PaginatedReviewsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, "success", resp.Code)
+			assert.Equal(t, int64(1), resp.TotalCount)
+			mockService.AssertExpectations(t)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerAdminListReviews_InvalidStatus tests that an unknown status is rejected.
+func TestHandlerAdminListReviews_InvalidStatus(t *testing.T) {
+	cfg, mockService, mockLogger := newModerationTestConfig()
+	err := &handlers.AppError{Code: "invalid_request", Message: "Invalid review status"}
+	mockService.On("ListReviewsByStatus", mock.Anything, "bogus", 1, 10).Return(nil, err)
+	mockLogger.On("LogHandlerError", mock.Anything, "admin_list_reviews", "invalid_request", "Invalid review status", mock.Anything, mock.Anything, nil).Return()
+
+	w := httptest.NewRecorder()
+	cfg.HandlerAdminListReviews(w, httptest.NewRequest(http.MethodGet, "/reviews/admin?status=bogus", nil), database.User{ID: "admin1"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockLogger.AssertExpectations(t)
+}
+
+// TestHandlerAdminModerateReview tests the approve, reject and hide handlers.
+func TestHandlerAdminModerateReview(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   func(cfg *HandlersReviewConfig) func(http.ResponseWriter, *http.Request, database.User)
+		body      string
+		status    string
+		reason    string
+		operation string
+		message   string
+	}{
+		{
+			name: "approve",
+			handler: func(cfg *HandlersReviewConfig) func(http.ResponseWriter, *http.Request, database.User) {
+				return cfg.HandlerAdminApproveReview
+			},
+			status:    models.ReviewStatusApproved,
+			operation: "admin_approve_review",
+			message:   "Review approved",
+		},
+		{
+			name: "reject",
+			handler: func(cfg *HandlersReviewConfig) func(http.ResponseWriter, *http.Request, database.User) {
+				return cfg.HandlerAdminRejectReview
+			},
+			body:      `{"reason":"Off topic"}`,
+			status:    models.ReviewStatusRejected,
+			reason:    "Off topic",
+			operation: "admin_reject_review",
+			message:   "Review rejected",
+		},
+		{
+			name: "hide",
+			handler: func(cfg *HandlersReviewConfig) func(http.ResponseWriter, *http.Request, database.User) {
+				return cfg.HandlerAdminHideReview
+			},
+			body:      `{"reason":"Abusive"}`,
+			status:    models.ReviewStatusHidden,
+			reason:    "Abusive",
+			operation: "admin_hide_review",
+			message:   "Review hidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			mockService.On("ModerateReview", mock.Anything, ModerateReviewParams{
+				ReviewID: testReviewID,
+				Status:   tt.status,
+				AdminID:  "admin1",
+				Reason:   tt.reason,
+			}).Return(nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, tt.operation, tt.message, mock.Anything, mock.Anything).Return()
+
+			w := httptest.NewRecorder()
+			tt.handler(cfg)(w, makeModerationRequest(testReviewID, tt.body), database.User{ID: "admin1"})
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp handlers.APIResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tt.message, resp.Message)
+			mockService.AssertExpectations(t)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerAdminModerateReview_Errors tests bad input and service errors for the moderation handlers.
+func TestHandlerAdminModerateReview_Errors(t *testing.T) {
+	t.Run("invalid_json", func(t *testing.T) {
+		cfg, mockService, mockLogger := newModerationTestConfig()
+		mockLogger.On("LogHandlerError", mock.Anything, "admin_hide_review", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerAdminHideReview(w, makeModerationRequest(testReviewID, "{bad"), database.User{ID: "admin1"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ModerateReview", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		cfg, mockService, mockLogger := newModerationTestConfig()
+		err := &handlers.AppError{Code: "not_found", Message: "Review not found"}
+		mockService.On("ModerateReview", mock.Anything, mock.Anything).Return(err)
+		mockLogger.On("LogHandlerError", mock.Anything, "admin_approve_review", "not_found", "Review not found", mock.Anything, mock.Anything, nil).Return()
+
+		w := httptest.NewRecorder()
+		cfg.HandlerAdminApproveReview(w, makeModerationRequest(testReviewID, ""), database.User{ID: "admin1"})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockLogger.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,116 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_review_reply.go: Admin handlers for posting and removing the store's public reply to a review.
+
+// Validate checks that the reply has text.
+// Returns:
+//   - error: AppError with "invalid_request" code if the comment is blank, nil otherwise
+func (r *ReviewReplyRequest) Validate() error {
+	if strings.TrimSpace(r.Comment) == "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "Reply comment is required"}
+	}
+	return nil
+}
+
+// HandlerAdminReplyToReview handles HTTP PUT requests to set the store's reply to a review.
+// @Summary      Admin reply to review
+// @Description  Posts the store's public reply to a review, replacing any earlier reply (admin only)
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string              true  "Review ID"
+// @Param        reply  body  ReviewReplyRequest  true  "Reply"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/admin/{id}/reply [put]
+func (cfg *HandlersReviewConfig) HandlerAdminReplyToReview(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	reviewID := chi.URLParam(r, "id")
+	if reviewID == "" {
+		cfg.Logger.LogHandlerError(ctx, "admin_reply_review", "invalid_request", "Review ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Review ID is required")
+		return
+	}
+
+	var req ReviewReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		cfg.Logger.LogHandlerError(ctx, "admin_reply_review", "invalid_request", "Invalid request payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		cfg.handleReviewError(w, r, err, "admin_reply_review", ip, userAgent)
+		return
+	}
+
+	err := cfg.GetReviewService().ReplyToReview(ctx, ReviewReplyParams{
+		ReviewID: reviewID,
+		AdminID:  user.ID,
+		Comment:  req.Comment,
+	})
+	if err != nil {
+		cfg.handleReviewError(w, r, err, "admin_reply_review", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_reply_review", "Reply saved", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
+		Message: "Reply saved",
+		Code:    "success",
+	})
+}
+
+// HandlerAdminDeleteReviewReply handles HTTP DELETE requests to remove the store's reply to a review.
+// @Summary      Admin delete review reply
+// @Description  Removes the store's reply from a review (admin only)
+// @Tags         reviews
+// @Produce      json
+// @Param        id  path  string  true  "Review ID"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/admin/{id}/reply [delete]
+func (cfg *HandlersReviewConfig) HandlerAdminDeleteReviewReply(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	reviewID := chi.URLParam(r, "id")
+	if reviewID == "" {
+		cfg.Logger.LogHandlerError(ctx, "admin_delete_review_reply", "invalid_request", "Review ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Review ID is required")
+		return
+	}
+
+	if err := cfg.GetReviewService().DeleteReviewReply(ctx, reviewID); err != nil {
+		cfg.handleReviewError(w, r, err, "admin_delete_review_reply", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "admin_delete_review_reply", "Reply deleted", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
+		Message: "Reply deleted",
+		Code:    "success",
+	})
+}
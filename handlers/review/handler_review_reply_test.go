@@ -0,0 +1,78 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_review_reply_test.go: Tests for the admin merchant reply handlers.
+
+// TestHandlerAdminReplyToReview tests saving a reply, bad payloads and service errors.
+func TestHandlerAdminReplyToReview(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "success", body: `{"comment":"Thanks for the feedback"}`, wantCode: http.StatusOK},
+		{name: "blank_comment", body: `{"comment":"  "}`, wantCode: http.StatusBadRequest},
+		{name: "invalid_json", body: `{bad`, wantCode: http.StatusBadRequest},
+		{name: "not_found", body: `{"comment":"Thanks"}`, serviceErr: &handlers.AppError{Code: "not_found", Message: "Review not found"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			if tt.wantCode == http.StatusOK || tt.serviceErr != nil {
+				mockService.On("ReplyToReview", mock.Anything, mock.MatchedBy(func(params ReviewReplyParams) bool {
+					return params.ReviewID == testReviewID && params.AdminID == "admin1"
+				})).Return(tt.serviceErr)
+			}
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "admin_reply_review", "Reply saved", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "admin_reply_review", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminReplyToReview(w, makeModerationRequest(testReviewID, tt.body), database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerAdminDeleteReviewReply tests removing a reply and a missing review.
+func TestHandlerAdminDeleteReviewReply(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not_found", serviceErr: &handlers.AppError{Code: "not_found", Message: "Review not found"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			mockService.On("DeleteReviewReply", mock.Anything, testReviewID).Return(tt.serviceErr)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "admin_delete_review_reply", "Reply deleted", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "admin_delete_review_reply", "not_found", "Review not found", mock.Anything, mock.Anything, nil).Return().Maybe()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerAdminDeleteReviewReply(w, makeModerationRequest(testReviewID, ""), database.User{ID: "admin1"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockService.AssertExpectations(t)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
@@ -89,7 +89,8 @@ func (cfg *HandlersReviewConfig) HandlerUpdateReviewByID(w http.ResponseWriter,
 		ProductID: review.ProductID,
 		Rating:    req.Rating,
 		Comment:   req.Comment,
-		MediaURLs: req.MediaURLs,
+		MediaIDs:  req.MediaIDs,
+		Status:    review.Status,
 	}
 
 	if err := cfg.GetReviewService().UpdateReviewByID(ctx, reviewID, update); err != nil {
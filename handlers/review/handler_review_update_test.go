@@ -54,9 +54,9 @@ func TestHandlerUpdateReviewByID_Success(t *testing.T) {
 	reviewID := testReviewID
 	existing := &models.Review{UserID: user.ID, ProductID: "p1"}
 	mockService.On("GetReviewByID", mock.Anything, reviewID).Return(existing, nil)
-	updateReq := ReviewUpdateRequest{Rating: 4, Comment: "Updated!", MediaURLs: []string{"url1"}}
+	updateReq := ReviewUpdateRequest{Rating: 4, Comment: "Updated!", MediaIDs: []string{"media1"}}
 	jsonBody, _ := json.Marshal(updateReq)
-	update := &models.Review{UserID: user.ID, ProductID: existing.ProductID, Rating: updateReq.Rating, Comment: updateReq.Comment, MediaURLs: updateReq.MediaURLs}
+	update := &models.Review{UserID: user.ID, ProductID: existing.ProductID, Rating: updateReq.Rating, Comment: updateReq.Comment, MediaIDs: updateReq.MediaIDs}
 	mockService.On("UpdateReviewByID", mock.Anything, reviewID, update).Return(nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "update_review_by_id", "Review updated successfully", mock.Anything, mock.Anything).Return()
 
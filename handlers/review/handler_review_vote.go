@@ -0,0 +1,75 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_review_vote.go: Handles helpful/unhelpful votes on reviews.
+
+// Validate checks that the vote says whether the review was helpful.
+// Returns:
+//   - error: AppError with "invalid_request" code if helpful is missing, nil otherwise
+func (r *ReviewVoteRequest) Validate() error {
+	if r.Helpful == nil {
+		return &handlers.AppError{Code: "invalid_request", Message: "Helpful is required"}
+	}
+	return nil
+}
+
+// HandlerVoteReview handles HTTP POST requests to vote a review helpful or unhelpful.
+// @Summary      Vote on review
+// @Description  Votes a published review helpful or unhelpful. Each user has one vote per review; voting again replaces it
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string             true  "Review ID"
+// @Param        vote  body  ReviewVoteRequest  true  "Vote"
+// @Success      200  {object}  handlers.APIResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/reviews/{id}/vote [post]
+func (cfg *HandlersReviewConfig) HandlerVoteReview(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	reviewID := chi.URLParam(r, "id")
+	if reviewID == "" {
+		cfg.Logger.LogHandlerError(ctx, "vote_review", "invalid_request", "Review ID is required", ip, userAgent, nil)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Review ID is required")
+		return
+	}
+
+	var req ReviewVoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		cfg.Logger.LogHandlerError(ctx, "vote_review", "invalid_request", "Invalid request payload", ip, userAgent, err)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		cfg.handleReviewError(w, r, err, "vote_review", ip, userAgent)
+		return
+	}
+
+	if err := cfg.GetReviewService().VoteReview(ctx, reviewID, user.ID, *req.Helpful); err != nil {
+		cfg.handleReviewError(w, r, err, "vote_review", ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	cfg.Logger.LogHandlerSuccess(ctxWithUserID, "vote_review", "Vote recorded", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.APIResponse{
+		Message: "Vote recorded",
+		Code:    "success",
+	})
+}
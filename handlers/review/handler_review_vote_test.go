@@ -0,0 +1,51 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+)
+
+// handler_review_vote_test.go: Tests for the helpful/unhelpful vote handler.
+
+// TestHandlerVoteReview tests recording votes, bad payloads and service errors.
+func TestHandlerVoteReview(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		helpful    bool
+		serviceErr error
+		wantCode   int
+	}{
+		{name: "helpful", body: `{"helpful":true}`, helpful: true, wantCode: http.StatusOK},
+		{name: "unhelpful", body: `{"helpful":false}`, wantCode: http.StatusOK},
+		{name: "missing_helpful", body: `{}`, wantCode: http.StatusBadRequest},
+		{name: "invalid_json", body: `{bad`, wantCode: http.StatusBadRequest},
+		{name: "own_review", body: `{"helpful":true}`, helpful: true, serviceErr: &handlers.AppError{Code: "invalid_request", Message: "You cannot vote on your own review"}, wantCode: http.StatusBadRequest},
+		{name: "not_found", body: `{"helpful":true}`, helpful: true, serviceErr: &handlers.AppError{Code: "not_found", Message: "Review not found"}, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, mockService, mockLogger := newModerationTestConfig()
+			if tt.wantCode == http.StatusOK || tt.serviceErr != nil {
+				mockService.On("VoteReview", mock.Anything, testReviewID, "u1", tt.helpful).Return(tt.serviceErr)
+			}
+			mockLogger.On("LogHandlerSuccess", mock.Anything, "vote_review", "Vote recorded", mock.Anything, mock.Anything).Return().Maybe()
+			mockLogger.On("LogHandlerError", mock.Anything, "vote_review", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+			w := httptest.NewRecorder()
+			cfg.HandlerVoteReview(w, makeModerationRequest(testReviewID, tt.body), database.User{ID: "u1"})
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -3,10 +3,13 @@ package reviewhandlers
 
 import (
 	"context"
+	"mime/multipart"
+	"net/http"
 	"time"
 
 	"github.com/stretchr/testify/mock"
 
+	"github.com/STaninnat/ecom-backend/internal/database"
 	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
 	"github.com/STaninnat/ecom-backend/models"
 )
@@ -16,6 +19,61 @@ import (
 // Mock Service
 type mockReviewMongo struct{ mock.Mock }
 
+type mockReviewDB struct{ mock.Mock }
+
+func (m *mockReviewDB) GetProductByID(ctx context.Context, id string) (database.Product, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Product), args.Error(1)
+}
+
+func (m *mockReviewDB) HasDeliveredOrderWithProduct(ctx context.Context, arg database.HasDeliveredOrderWithProductParams) (bool, error) {
+	args := m.Called(ctx, arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockReviewDB) UpdateProductRatingSummary(ctx context.Context, arg database.UpdateProductRatingSummaryParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// RefreshProductRatingSummary computes the summary and records it through UpdateProductRatingSummary, so tests set expectations on the stored values.
+func (m *mockReviewDB) RefreshProductRatingSummary(ctx context.Context, productID string, compute func(context.Context) (intmongo.ProductRatingSummary, error)) error {
+	summary, err := compute(ctx)
+	if err != nil {
+		return err
+	}
+	return m.UpdateProductRatingSummary(ctx, ratingSummaryParams(productID, summary))
+}
+
+type mockProductCache struct{ mock.Mock }
+
+func (m *mockProductCache) DeletePattern(ctx context.Context, pattern string) error {
+	args := m.Called(ctx, pattern)
+	return args.Error(0)
+}
+
+func (m *mockReviewDB) ListProductIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// expectRatingRefresh sets up the rating summary refresh for a product: an empty summary is read from Mongo and written to the products table.
+func expectRatingRefresh(m *mockReviewMongo, db *mockReviewDB, productID string) {
+	m.On("GetProductRatingSummary", mock.Anything, productID).Return(intmongo.ProductRatingSummary{ProductID: productID}, nil)
+	db.On("UpdateProductRatingSummary", mock.Anything, ratingSummaryParams(productID, intmongo.ProductRatingSummary{})).Return(nil)
+}
+
+// newPurchasedReviewDB returns a mockReviewDB where product p1 exists and the user's delivered orders contain it when purchased is true.
+func newPurchasedReviewDB(purchased bool) *mockReviewDB {
+	db := new(mockReviewDB)
+	db.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	db.On("HasDeliveredOrderWithProduct", mock.Anything, database.HasDeliveredOrderWithProductParams{UserID: "u1", ProductID: "p1"}).Return(purchased, nil)
+	return db
+}
+
 func (m *mockReviewMongo) CreateReview(ctx context.Context, review *models.Review) error {
 	args := m.Called(ctx, review)
 	return args.Error(0)
@@ -49,8 +107,77 @@ func (m *mockReviewMongo) GetReviewsByUserIDPaginated(ctx context.Context, userI
 	args := m.Called(ctx, userID, opts)
 	return args.Get(0).(*intmongo.PaginatedResult[*models.Review]), args.Error(1)
 }
+func (m *mockReviewMongo) GetReviewsByStatusPaginated(ctx context.Context, status string, opts *intmongo.PaginationOptions) (*intmongo.PaginatedResult[*models.Review], error) {
+	args := m.Called(ctx, status, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*intmongo.PaginatedResult[*models.Review]), args.Error(1)
+}
+func (m *mockReviewMongo) UpdateReviewStatus(ctx context.Context, reviewID string, update intmongo.ReviewStatusUpdate) error {
+	args := m.Called(ctx, reviewID, update)
+	return args.Error(0)
+}
+func (m *mockReviewMongo) GetProductRatingSummary(ctx context.Context, productID string) (intmongo.ProductRatingSummary, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(intmongo.ProductRatingSummary), args.Error(1)
+}
+func (m *mockReviewMongo) VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error {
+	args := m.Called(ctx, reviewID, userID, helpful)
+	return args.Error(0)
+}
+func (m *mockReviewMongo) SetMerchantReply(ctx context.Context, reviewID string, reply *models.ReviewReply) error {
+	args := m.Called(ctx, reviewID, reply)
+	return args.Error(0)
+}
+func (m *mockReviewMongo) GetAllProductRatingSummaries(ctx context.Context) (map[string]intmongo.ProductRatingSummary, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]intmongo.ProductRatingSummary), args.Error(1)
+}
 
 // Mock Wrapper
+type mockReviewMedia struct{ mock.Mock }
+
+func (m *mockReviewMedia) CreateReviewMedia(ctx context.Context, media *models.ReviewMedia) error {
+	args := m.Called(ctx, media)
+	return args.Error(0)
+}
+func (m *mockReviewMedia) GetReviewMediaByIDs(ctx context.Context, ids []string) ([]*models.ReviewMedia, error) {
+	args := m.Called(ctx, ids)
+	media, _ := args.Get(0).([]*models.ReviewMedia)
+	return media, args.Error(1)
+}
+func (m *mockReviewMedia) CountReviewMediaByUser(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *mockReviewMedia) AttachReviewMedia(ctx context.Context, ids []string, userID, reviewID string) error {
+	args := m.Called(ctx, ids, userID, reviewID)
+	return args.Error(0)
+}
+func (m *mockReviewMedia) DetachReviewMedia(ctx context.Context, ids []string, reviewID string) error {
+	args := m.Called(ctx, ids, reviewID)
+	return args.Error(0)
+}
+func (m *mockReviewMedia) DeleteReviewMediaByIDs(ctx context.Context, ids []string) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
+type mockFileStorage struct{ mock.Mock }
+
+func (m *mockFileStorage) Save(file multipart.File, fileHeader *multipart.FileHeader, uploadPath string) (string, error) {
+	args := m.Called(file, fileHeader, uploadPath)
+	return args.String(0), args.Error(1)
+}
+func (m *mockFileStorage) Delete(imageURL, uploadPath string) error {
+	args := m.Called(imageURL, uploadPath)
+	return args.Error(0)
+}
+
 type mockLoggerWrapper struct{ mock.Mock }
 
 func (m *mockLoggerWrapper) LogHandlerError(ctx context.Context, op, code, msg, ip, ua string, err error) {
@@ -84,6 +211,21 @@ func (m *mockReviewService) GetReviewsByProductIDPaginated(_ context.Context, _
 func (m *mockReviewService) GetReviewsByUserIDPaginated(_ context.Context, _ string, _, _ int, _, _, _ *int, _, _ *time.Time, _ *bool, _ string) (any, error) {
 	return nil, nil
 }
+func (m *mockReviewService) ListReviewsByStatus(_ context.Context, _ string, _, _ int) (any, error) {
+	return nil, nil
+}
+func (m *mockReviewService) ModerateReview(_ context.Context, _ ModerateReviewParams) error {
+	return nil
+}
+func (m *mockReviewService) VoteReview(_ context.Context, _, _ string, _ bool) error { return nil }
+func (m *mockReviewService) ReplyToReview(_ context.Context, _ ReviewReplyParams) error {
+	return nil
+}
+func (m *mockReviewService) DeleteReviewReply(_ context.Context, _ string) error { return nil }
+func (m *mockReviewService) UploadReviewMedia(_ context.Context, _ string, _ *http.Request) (*models.ReviewMedia, error) {
+	return nil, nil
+}
+func (m *mockReviewService) DeleteReviewMedia(_ context.Context, _, _ string) error { return nil }
 
 // Mock Create Review
 type MockReviewService struct{ mock.Mock }
@@ -120,6 +262,42 @@ func (m *MockReviewService) GetReviewsByUserIDPaginated(ctx context.Context, use
 	args := m.Called(ctx, userID, page, pageSize, rating, minRating, maxRating, from, to, hasMedia, sort)
 	return args.Get(0), args.Error(1)
 }
+func (m *MockReviewService) ListReviewsByStatus(ctx context.Context, status string, page, pageSize int) (any, error) {
+	args := m.Called(ctx, status, page, pageSize)
+	return args.Get(0), args.Error(1)
+}
+func (m *MockReviewService) ModerateReview(ctx context.Context, params ModerateReviewParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockReviewService) VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error {
+	args := m.Called(ctx, reviewID, userID, helpful)
+	return args.Error(0)
+}
+
+func (m *MockReviewService) ReplyToReview(ctx context.Context, params ReviewReplyParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockReviewService) DeleteReviewReply(ctx context.Context, reviewID string) error {
+	args := m.Called(ctx, reviewID)
+	return args.Error(0)
+}
+
+func (m *MockReviewService) UploadReviewMedia(ctx context.Context, userID string, r *http.Request) (*models.ReviewMedia, error) {
+	args := m.Called(ctx, userID, r)
+	if media, ok := args.Get(0).(*models.ReviewMedia); ok {
+		return media, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReviewService) DeleteReviewMedia(ctx context.Context, userID, mediaID string) error {
+	args := m.Called(ctx, userID, mediaID)
+	return args.Error(0)
+}
 
 // ... other methods omitted for brevity ...
 
@@ -0,0 +1,267 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	uploadhandlers "github.com/STaninnat/ecom-backend/handlers/upload"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+	"github.com/STaninnat/ecom-backend/models"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// review_media.go: Review photo uploads stored through the upload package's FileStorage backends, with quotas and ownership checks.
+
+// ReviewMediaAPI defines the MongoDB operations on uploaded review media.
+type ReviewMediaAPI interface {
+	CreateReviewMedia(ctx context.Context, media *models.ReviewMedia) error
+	GetReviewMediaByIDs(ctx context.Context, ids []string) ([]*models.ReviewMedia, error)
+	CountReviewMediaByUser(ctx context.Context, userID string) (int64, error)
+	AttachReviewMedia(ctx context.Context, ids []string, userID, reviewID string) error
+	DetachReviewMedia(ctx context.Context, ids []string, reviewID string) error
+	DeleteReviewMediaByIDs(ctx context.Context, ids []string) error
+}
+
+// ReviewMediaConfig wires review photo uploads. With a nil Store, uploads are disabled and reviews cannot have media.
+type ReviewMediaConfig struct {
+	Store      ReviewMediaAPI
+	Storage    uploadhandlers.FileStorage
+	UploadPath string
+	// MaxFileSize is the largest accepted file in bytes.
+	MaxFileSize int64
+	// MaxFilesPerUser caps how many files a user can have stored at once; 0 means no limit.
+	MaxFilesPerUser int
+	// MaxPerReview caps how many files a single review can use; 0 means no limit.
+	MaxPerReview int
+}
+
+// reviewMediaTypes are the accepted image types, detected from the file content rather than the client's Content-Type.
+var reviewMediaTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/gif":  {},
+	"image/webp": {},
+}
+
+// UploadReviewMedia stores a photo from the request's "image" form field for later use in the user's reviews.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - userID: string identifier of the uploader
+//   - r: *http.Request containing the multipart form
+//
+// Returns:
+//   - *models.ReviewMedia: the stored media
+//   - error: nil on success, AppError with "invalid_request", "file_too_large", "quota_exceeded", "file_save_failed" or "create_failed" code on failure
+func (s *reviewServiceImpl) UploadReviewMedia(ctx context.Context, userID string, r *http.Request) (*models.ReviewMedia, error) {
+	if s.media.Store == nil {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Media uploads are not enabled"}
+	}
+
+	if s.media.MaxFilesPerUser > 0 {
+		count, err := s.media.Store.CountReviewMediaByUser(ctx, userID)
+		if err != nil {
+			return nil, &handlers.AppError{Code: "get_failed", Message: "Failed to check media quota", Err: err}
+		}
+		if count >= int64(s.media.MaxFilesPerUser) {
+			return nil, &handlers.AppError{Code: "quota_exceeded", Message: fmt.Sprintf("You can store at most %d review photos", s.media.MaxFilesPerUser)}
+		}
+	}
+
+	if s.media.MaxFileSize > 0 {
+		// Leave room for the rest of the multipart form
+		r.Body = http.MaxBytesReader(nil, r.Body, s.media.MaxFileSize+1<<20)
+	}
+	file, fileHeader, err := uploadhandlers.ParseAndGetImageFile(r)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, &handlers.AppError{Code: "file_too_large", Message: fmt.Sprintf("Image must be at most %d bytes", s.media.MaxFileSize), Err: err}
+		}
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Invalid image upload", Err: err}
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if s.media.MaxFileSize > 0 && fileHeader.Size > s.media.MaxFileSize {
+		return nil, &handlers.AppError{Code: "file_too_large", Message: fmt.Sprintf("Image must be at most %d bytes", s.media.MaxFileSize)}
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Invalid image upload", Err: err}
+	}
+	contentType := http.DetectContentType(head[:n])
+	if _, ok := reviewMediaTypes[contentType]; !ok {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Unsupported image type"}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, &handlers.AppError{Code: "file_save_failed", Message: "Failed to read image", Err: err}
+	}
+
+	saved, err := s.media.Storage.Save(file, fileHeader, s.media.UploadPath)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "file_save_failed", Message: "Failed to save image", Err: err}
+	}
+
+	media := &models.ReviewMedia{
+		ID:          utils.NewUUIDString(),
+		UserID:      userID,
+		URL:         storedFileURL(saved),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+	}
+	if err := s.media.Store.CreateReviewMedia(ctx, media); err != nil {
+		_ = s.media.Storage.Delete(media.URL, s.media.UploadPath)
+		return nil, &handlers.AppError{Code: "create_failed", Message: "Failed to save image", Err: err}
+	}
+
+	// Count again now that the upload is recorded: concurrent uploads can all pass the check above,
+	// and whichever sees the quota exceeded gives its file back
+	if s.media.MaxFilesPerUser > 0 {
+		count, err := s.media.Store.CountReviewMediaByUser(ctx, userID)
+		if err != nil || count > int64(s.media.MaxFilesPerUser) {
+			s.removeMedia(ctx, []string{media.ID})
+			if err != nil {
+				return nil, &handlers.AppError{Code: "get_failed", Message: "Failed to check media quota", Err: err}
+			}
+			return nil, &handlers.AppError{Code: "quota_exceeded", Message: fmt.Sprintf("You can store at most %d review photos", s.media.MaxFilesPerUser)}
+		}
+	}
+	return media, nil
+}
+
+// DeleteReviewMedia deletes one of the user's uploads that no review uses.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - userID: string identifier of the user
+//   - mediaID: string identifier of the media
+//
+// Returns:
+//   - error: nil on success, AppError with "invalid_request", "not_found" or "get_failed" code on failure
+func (s *reviewServiceImpl) DeleteReviewMedia(ctx context.Context, userID, mediaID string) error {
+	if s.media.Store == nil {
+		return &handlers.AppError{Code: "invalid_request", Message: "Media uploads are not enabled"}
+	}
+
+	found, err := s.media.Store.GetReviewMediaByIDs(ctx, []string{mediaID})
+	if err != nil {
+		return &handlers.AppError{Code: "get_failed", Message: "Failed to get media", Err: err}
+	}
+	if len(found) == 0 || found[0].UserID != userID {
+		return &handlers.AppError{Code: "not_found", Message: "Media not found"}
+	}
+	if found[0].ReviewID != "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "Media is used by a review; remove it from the review first"}
+	}
+
+	s.removeMedia(ctx, []string{mediaID})
+	return nil
+}
+
+// resolveMedia checks that every media ID was uploaded by the user and is unused or already used by this review,
+// and returns their URLs in the same order.
+func (s *reviewServiceImpl) resolveMedia(ctx context.Context, userID, reviewID string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if s.media.Store == nil {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Media uploads are not enabled"}
+	}
+	if s.media.MaxPerReview > 0 && len(ids) > s.media.MaxPerReview {
+		return nil, &handlers.AppError{Code: "invalid_request", Message: fmt.Sprintf("A review can have at most %d photos", s.media.MaxPerReview)}
+	}
+
+	found, err := s.media.Store.GetReviewMediaByIDs(ctx, ids)
+	if err != nil {
+		return nil, &handlers.AppError{Code: "get_failed", Message: "Failed to get media", Err: err}
+	}
+	byID := make(map[string]*models.ReviewMedia, len(found))
+	for _, media := range found {
+		byID[media.ID] = media
+	}
+
+	urls := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		media, ok := byID[id]
+		_, dup := seen[id]
+		if !ok || dup || media.UserID != userID || (media.ReviewID != "" && media.ReviewID != reviewID) {
+			return nil, &handlers.AppError{Code: "invalid_request", Message: "Unknown media: " + id}
+		}
+		seen[id] = struct{}{}
+		urls = append(urls, media.URL)
+	}
+	return urls, nil
+}
+
+// claimMedia attaches media to a review before the review is saved, so two reviews cannot both use an upload.
+// The store only attaches the user's media that is unused or already used by this review. When any of it cannot be
+// attached, the media this call attached on top of attached is released again and an error is returned.
+func (s *reviewServiceImpl) claimMedia(ctx context.Context, userID, reviewID string, ids, attached []string) error {
+	if s.media.Store == nil || len(ids) == 0 {
+		return nil
+	}
+	err := s.media.Store.AttachReviewMedia(ctx, ids, userID, reviewID)
+	if err == nil {
+		return nil
+	}
+	s.releaseMedia(ctx, droppedMedia(ids, attached), reviewID)
+	if errors.Is(err, intmongo.ErrReviewMediaUnavailable) {
+		return &handlers.AppError{Code: "invalid_request", Message: "Some of the photos are no longer available", Err: err}
+	}
+	return &handlers.AppError{Code: "update_failed", Message: "Failed to attach media", Err: err}
+}
+
+// releaseMedia marks media claimed for a review that was not saved as unused again.
+// Errors are ignored: a stale claim only keeps the owner from reusing the upload until it is deleted.
+func (s *reviewServiceImpl) releaseMedia(ctx context.Context, ids []string, reviewID string) {
+	if s.media.Store == nil || len(ids) == 0 {
+		return
+	}
+	_ = s.media.Store.DetachReviewMedia(ctx, ids, reviewID)
+}
+
+// removeMedia deletes the files and records of the given media.
+// Errors are ignored: the review change is already saved, and leftover files are not reachable from any review.
+func (s *reviewServiceImpl) removeMedia(ctx context.Context, ids []string) {
+	if s.media.Store == nil || len(ids) == 0 {
+		return
+	}
+	found, err := s.media.Store.GetReviewMediaByIDs(ctx, ids)
+	if err != nil {
+		return
+	}
+	for _, media := range found {
+		_ = s.media.Storage.Delete(media.URL, s.media.UploadPath)
+	}
+	_ = s.media.Store.DeleteReviewMediaByIDs(ctx, ids)
+}
+
+// droppedMedia returns the IDs in before that are not in after.
+func droppedMedia(before, after []string) []string {
+	var dropped []string
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			dropped = append(dropped, id)
+		}
+	}
+	return dropped
+}
+
+// storedFileURL turns what FileStorage.Save returned into a public URL.
+// S3 storage returns a URL; local storage returns a file path served under /static/.
+func storedFileURL(saved string) string {
+	if strings.HasPrefix(saved, "http://") || strings.HasPrefix(saved, "https://") {
+		return saved
+	}
+	return "/static/" + saved[strings.LastIndex(saved, "/")+1:]
+}
@@ -0,0 +1,329 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_media_test.go: Tests for review photo uploads, ownership checks and cleanup.
+
+// pngHeader is enough of a PNG file for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// newMediaUploadRequest builds a multipart request with the given file in the "image" field.
+func newMediaUploadRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("image", filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	r := httptest.NewRequest(http.MethodPost, "/reviews/media", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+// newMediaService returns a review service with mocked media store and file storage.
+func newMediaService(m *mockReviewMongo, db *mockReviewDB) (ReviewService, *mockReviewMedia, *mockFileStorage) {
+	store := new(mockReviewMedia)
+	storage := new(mockFileStorage)
+	svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{
+		Store:           store,
+		Storage:         storage,
+		UploadPath:      "./uploads",
+		MaxFileSize:     1 << 20,
+		MaxFilesPerUser: 3,
+		MaxPerReview:    2,
+	}, RatingSummaryConfig{})
+	return svc, store, storage
+}
+
+// appErrorCode returns the code of an AppError, or an empty string for other errors.
+func appErrorCode(err error) string {
+	var appErr *handlers.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+// TestUploadReviewMedia_Success tests that a PNG is saved through the file storage and recorded for the uploader.
+func TestUploadReviewMedia_Success(t *testing.T) {
+	svc, store, storage := newMediaService(new(mockReviewMongo), nil)
+	store.On("CountReviewMediaByUser", mock.Anything, "u1").Return(int64(0), nil)
+	storage.On("Save", mock.Anything, mock.Anything, "./uploads").Return("uploads/abc.png", nil)
+	store.On("CreateReviewMedia", mock.Anything, mock.MatchedBy(func(media *models.ReviewMedia) bool {
+		return media.UserID == "u1" && media.URL == "/static/abc.png" && media.ContentType == "image/png"
+	})).Return(nil)
+
+	media, err := svc.UploadReviewMedia(context.Background(), "u1", newMediaUploadRequest(t, "photo.png", pngHeader))
+	require.NoError(t, err)
+	assert.NotEmpty(t, media.ID)
+	assert.Equal(t, int64(len(pngHeader)), media.Size)
+	store.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+// TestUploadReviewMedia_Rejected tests the quota, content type and size checks, and that nothing is stored.
+func TestUploadReviewMedia_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int64
+		filename string
+		content  []byte
+		wantCode string
+	}{
+		{name: "quota", count: 3, filename: "photo.png", content: pngHeader, wantCode: "quota_exceeded"},
+		{name: "not_an_image", filename: "photo.png", content: []byte("hello, world"), wantCode: "invalid_request"},
+		{name: "bad_extension", filename: "photo.txt", content: pngHeader, wantCode: "invalid_request"},
+		{name: "too_large", filename: "photo.png", content: append(append([]byte{}, pngHeader...), make([]byte, 1<<20)...), wantCode: "file_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store, storage := newMediaService(new(mockReviewMongo), nil)
+			store.On("CountReviewMediaByUser", mock.Anything, "u1").Return(tt.count, nil)
+
+			_, err := svc.UploadReviewMedia(context.Background(), "u1", newMediaUploadRequest(t, tt.filename, tt.content))
+			assert.Equal(t, tt.wantCode, appErrorCode(err))
+			storage.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+			store.AssertNotCalled(t, "CreateReviewMedia", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestUploadReviewMedia_CreateFails tests that the stored file is deleted when the media record cannot be saved.
+func TestUploadReviewMedia_CreateFails(t *testing.T) {
+	svc, store, storage := newMediaService(new(mockReviewMongo), nil)
+	store.On("CountReviewMediaByUser", mock.Anything, "u1").Return(int64(0), nil)
+	storage.On("Save", mock.Anything, mock.Anything, "./uploads").Return("https://bucket.s3/abc.png", nil)
+	store.On("CreateReviewMedia", mock.Anything, mock.Anything).Return(errors.New("mongo fail"))
+	storage.On("Delete", "https://bucket.s3/abc.png", "./uploads").Return(nil)
+
+	_, err := svc.UploadReviewMedia(context.Background(), "u1", newMediaUploadRequest(t, "photo.png", pngHeader))
+	assert.Equal(t, "create_failed", appErrorCode(err))
+	storage.AssertExpectations(t)
+}
+
+// TestUploadReviewMedia_QuotaRecheck tests that an upload is removed again when concurrent uploads pushed the user
+// over the quota while it was being saved.
+func TestUploadReviewMedia_QuotaRecheck(t *testing.T) {
+	svc, store, storage := newMediaService(new(mockReviewMongo), nil)
+	store.On("CountReviewMediaByUser", mock.Anything, "u1").Return(int64(2), nil).Once()
+	store.On("CountReviewMediaByUser", mock.Anything, "u1").Return(int64(4), nil).Once()
+	storage.On("Save", mock.Anything, mock.Anything, "./uploads").Return("uploads/abc.png", nil)
+	var saved *models.ReviewMedia
+	store.On("CreateReviewMedia", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*models.ReviewMedia)
+	}).Return(nil)
+	store.On("GetReviewMediaByIDs", mock.Anything, mock.Anything).Return([]*models.ReviewMedia{{URL: "/static/abc.png"}}, nil)
+	storage.On("Delete", "/static/abc.png", "./uploads").Return(nil)
+	store.On("DeleteReviewMediaByIDs", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.UploadReviewMedia(context.Background(), "u1", newMediaUploadRequest(t, "photo.png", pngHeader))
+	assert.Equal(t, "quota_exceeded", appErrorCode(err))
+	require.NotNil(t, saved)
+	store.AssertCalled(t, "DeleteReviewMediaByIDs", mock.Anything, []string{saved.ID})
+	store.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+// TestUploadReviewMedia_Disabled tests that uploads are rejected without a media store.
+func TestUploadReviewMedia_Disabled(t *testing.T) {
+	svc := NewReviewService(new(mockReviewMongo), nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	_, err := svc.UploadReviewMedia(context.Background(), "u1", newMediaUploadRequest(t, "photo.png", pngHeader))
+	assert.Equal(t, "invalid_request", appErrorCode(err))
+}
+
+// TestDeleteReviewMedia tests that only the owner's unused media can be deleted.
+func TestDeleteReviewMedia(t *testing.T) {
+	tests := []struct {
+		name     string
+		media    []*models.ReviewMedia
+		wantCode string
+	}{
+		{name: "success", media: []*models.ReviewMedia{{ID: "m1", UserID: "u1", URL: "/static/a.png"}}},
+		{name: "missing", wantCode: "not_found"},
+		{name: "other_user", media: []*models.ReviewMedia{{ID: "m1", UserID: "u2"}}, wantCode: "not_found"},
+		{name: "in_use", media: []*models.ReviewMedia{{ID: "m1", UserID: "u1", ReviewID: "r1"}}, wantCode: "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store, storage := newMediaService(new(mockReviewMongo), nil)
+			store.On("GetReviewMediaByIDs", mock.Anything, []string{"m1"}).Return(tt.media, nil)
+			if tt.wantCode == "" {
+				storage.On("Delete", "/static/a.png", "./uploads").Return(nil)
+				store.On("DeleteReviewMediaByIDs", mock.Anything, []string{"m1"}).Return(nil)
+			}
+
+			err := svc.DeleteReviewMedia(context.Background(), "u1", "m1")
+			assert.Equal(t, tt.wantCode, appErrorCode(err))
+			if tt.wantCode == "" {
+				require.NoError(t, err)
+			}
+			store.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCreateReview_Media tests that reviews get the URLs of the user's unused uploads and that the uploads are attached.
+func TestCreateReview_Media(t *testing.T) {
+	m := new(mockReviewMongo)
+	db := newPurchasedReviewDB(true)
+	svc, store, _ := newMediaService(m, db)
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m2", "m1"}).Return([]*models.ReviewMedia{
+		{ID: "m1", UserID: "u1", URL: "/static/1.png"},
+		{ID: "m2", UserID: "u1", URL: "/static/2.png"},
+	}, nil)
+	store.On("AttachReviewMedia", mock.Anything, []string{"m2", "m1"}, "u1", "r1").Return(nil)
+	m.On("CreateReview", mock.Anything, mock.Anything).Return(nil)
+	expectRatingRefresh(m, db, "p1")
+
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1", Rating: 5, Comment: "Great product", MediaIDs: []string{"m2", "m1"}}
+	require.NoError(t, svc.CreateReview(context.Background(), review))
+	assert.Equal(t, []string{"/static/2.png", "/static/1.png"}, review.MediaURLs)
+	store.AssertExpectations(t)
+}
+
+// TestCreateReview_MediaRejected tests that media of other users, media used by other reviews, duplicates and
+// too many photos are rejected before the review is saved.
+func TestCreateReview_MediaRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		ids   []string
+		media []*models.ReviewMedia
+	}{
+		{name: "unknown", ids: []string{"m1"}},
+		{name: "other_user", ids: []string{"m1"}, media: []*models.ReviewMedia{{ID: "m1", UserID: "u2"}}},
+		{name: "used_elsewhere", ids: []string{"m1"}, media: []*models.ReviewMedia{{ID: "m1", UserID: "u1", ReviewID: "r9"}}},
+		{name: "duplicate", ids: []string{"m1", "m1"}, media: []*models.ReviewMedia{{ID: "m1", UserID: "u1"}}},
+		{name: "too_many", ids: []string{"m1", "m2", "m3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			svc, store, _ := newMediaService(m, newPurchasedReviewDB(true))
+			store.On("GetReviewMediaByIDs", mock.Anything, tt.ids).Return(tt.media, nil).Maybe()
+
+			review := &models.Review{UserID: "u1", ProductID: "p1", Rating: 5, Comment: "Great product", MediaIDs: tt.ids}
+			err := svc.CreateReview(context.Background(), review)
+			assert.Equal(t, "invalid_request", appErrorCode(err))
+			m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestCreateReview_MediaClaimFails tests that a review is not saved when another review claimed its media first,
+// and that the media this review claimed is released again.
+func TestCreateReview_MediaClaimFails(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc, store, _ := newMediaService(m, newPurchasedReviewDB(true))
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m1"}).Return([]*models.ReviewMedia{{ID: "m1", UserID: "u1"}}, nil)
+	store.On("AttachReviewMedia", mock.Anything, []string{"m1"}, "u1", "r1").Return(intmongo.ErrReviewMediaUnavailable)
+	store.On("DetachReviewMedia", mock.Anything, []string{"m1"}, "r1").Return(nil)
+
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1", Rating: 5, Comment: "Great product", MediaIDs: []string{"m1"}}
+	err := svc.CreateReview(context.Background(), review)
+	assert.Equal(t, "invalid_request", appErrorCode(err))
+	m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
+}
+
+// TestCreateReview_MediaReleasedOnCreateError tests that claimed media is released when the review cannot be saved.
+func TestCreateReview_MediaReleasedOnCreateError(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc, store, _ := newMediaService(m, newPurchasedReviewDB(true))
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m1"}).Return([]*models.ReviewMedia{{ID: "m1", UserID: "u1"}}, nil)
+	store.On("AttachReviewMedia", mock.Anything, []string{"m1"}, "u1", mock.Anything).Return(nil)
+	store.On("DetachReviewMedia", mock.Anything, []string{"m1"}, mock.Anything).Return(nil)
+	m.On("CreateReview", mock.Anything, mock.Anything).Return(intmongo.ErrDuplicateReview)
+
+	review := &models.Review{UserID: "u1", ProductID: "p1", Rating: 5, Comment: "Great product", MediaIDs: []string{"m1"}}
+	err := svc.CreateReview(context.Background(), review)
+	assert.Equal(t, "review_exists", appErrorCode(err))
+	assert.NotEmpty(t, review.ID)
+	store.AssertCalled(t, "DetachReviewMedia", mock.Anything, []string{"m1"}, review.ID)
+	store.AssertExpectations(t)
+}
+
+// TestCreateReview_MediaDisabled tests that media IDs are rejected without a media store.
+func TestCreateReview_MediaDisabled(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc := NewReviewService(m, newPurchasedReviewDB(true), ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	err := svc.CreateReview(context.Background(), &models.Review{UserID: "u1", ProductID: "p1", MediaIDs: []string{"m1"}})
+	assert.Equal(t, "invalid_request", appErrorCode(err))
+	m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+}
+
+// TestUpdateReviewByID_Media tests that media kept by the edit stay attached and dropped media are deleted from storage.
+func TestUpdateReviewByID_Media(t *testing.T) {
+	m := new(mockReviewMongo)
+	db := new(mockReviewDB)
+	svc, store, storage := newMediaService(m, db)
+	m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", UserID: "u1", ProductID: "p1", MediaIDs: []string{"m1", "m2"}}, nil)
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m1", "m3"}).Return([]*models.ReviewMedia{
+		{ID: "m1", UserID: "u1", ReviewID: "r1", URL: "/static/1.png"},
+		{ID: "m3", UserID: "u1", URL: "/static/3.png"},
+	}, nil)
+	m.On("UpdateReviewByID", mock.Anything, "r1", mock.Anything).Return(nil)
+	store.On("AttachReviewMedia", mock.Anything, []string{"m1", "m3"}, "u1", "r1").Return(nil)
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m2"}).Return([]*models.ReviewMedia{{ID: "m2", UserID: "u1", ReviewID: "r1", URL: "/static/2.png"}}, nil)
+	storage.On("Delete", "/static/2.png", "./uploads").Return(nil)
+	store.On("DeleteReviewMediaByIDs", mock.Anything, []string{"m2"}).Return(nil)
+	expectRatingRefresh(m, db, "p1")
+
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1", Rating: 4, Comment: "Still good", MediaIDs: []string{"m1", "m3"}}
+	require.NoError(t, svc.UpdateReviewByID(context.Background(), "r1", review))
+	assert.Equal(t, []string{"/static/1.png", "/static/3.png"}, review.MediaURLs)
+	store.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+// TestDeleteReviewByID_Media tests that deleting a review removes its media from storage.
+func TestDeleteReviewByID_Media(t *testing.T) {
+	m := new(mockReviewMongo)
+	db := new(mockReviewDB)
+	svc, store, storage := newMediaService(m, db)
+	m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", ProductID: "p1", MediaIDs: []string{"m1"}}, nil)
+	m.On("DeleteReviewByID", mock.Anything, "r1").Return(nil)
+	store.On("GetReviewMediaByIDs", mock.Anything, []string{"m1"}).Return([]*models.ReviewMedia{{ID: "m1", URL: "/static/1.png"}}, nil)
+	storage.On("Delete", "/static/1.png", "./uploads").Return(nil)
+	store.On("DeleteReviewMediaByIDs", mock.Anything, []string{"m1"}).Return(nil)
+	expectRatingRefresh(m, db, "p1")
+
+	require.NoError(t, svc.DeleteReviewByID(context.Background(), "r1"))
+	store.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+// TestDroppedMedia tests that only IDs missing from the new list are returned.
+func TestDroppedMedia(t *testing.T) {
+	assert.Equal(t, []string{"a", "c"}, droppedMedia([]string{"a", "b", "c"}, []string{"b", "d"}))
+	assert.Empty(t, droppedMedia(nil, []string{"a"}))
+}
+
+// TestStoredFileURL tests that S3 URLs are kept and local paths are served under /static/.
+func TestStoredFileURL(t *testing.T) {
+	assert.Equal(t, "https://bucket.s3.amazonaws.com/a.png", storedFileURL("https://bucket.s3.amazonaws.com/a.png"))
+	assert.Equal(t, "/static/a.png", storedFileURL("uploads/a.png"))
+	assert.Equal(t, "/static/a.png", storedFileURL("a.png"))
+}
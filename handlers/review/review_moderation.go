@@ -0,0 +1,118 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_moderation.go: Auto-moderation rules that decide whether a new or edited review is published, held for an admin, or rejected.
+
+// ModerationRule inspects a review before it is saved.
+// Check returns the status the review should get (pending or rejected) and why, or an empty status when the review passes.
+type ModerationRule interface {
+	Check(review *models.Review) (status, reason string)
+}
+
+// BannedWordsRule rejects reviews whose comment contains any of the banned words (case-insensitive, whole words).
+type BannedWordsRule struct {
+	Words []string
+}
+
+// Check implements ModerationRule.
+func (r BannedWordsRule) Check(review *models.Review) (string, string) {
+	banned := make(map[string]struct{}, len(r.Words))
+	for _, word := range r.Words {
+		banned[strings.ToLower(word)] = struct{}{}
+	}
+	for _, word := range commentWords(review.Comment) {
+		if _, ok := banned[word]; ok {
+			return models.ReviewStatusRejected, "Contains a banned word"
+		}
+	}
+	return "", ""
+}
+
+// linkPattern matches URLs and bare domain names.
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|info|biz|io|ru|cn|xyz|shop)\b`)
+
+// LinkRule holds reviews whose comment contains a link, a common sign of spam.
+type LinkRule struct{}
+
+// Check implements ModerationRule.
+func (r LinkRule) Check(review *models.Review) (string, string) {
+	if linkPattern.MatchString(review.Comment) {
+		return models.ReviewStatusPending, "Contains a link"
+	}
+	return "", ""
+}
+
+// RatingOnlySpamRule holds reviews whose comment has fewer than MinWords distinct words,
+// such as "good", "5/5" or "great great great", which add nothing to the rating.
+type RatingOnlySpamRule struct {
+	MinWords int
+}
+
+// Check implements ModerationRule.
+func (r RatingOnlySpamRule) Check(review *models.Review) (string, string) {
+	distinct := make(map[string]struct{})
+	for _, word := range commentWords(review.Comment) {
+		distinct[word] = struct{}{}
+	}
+	if len(distinct) < r.MinWords {
+		return models.ReviewStatusPending, "Comment is too short"
+	}
+	return "", ""
+}
+
+// NewModerationRules builds the default rule set: links are always held, banned words are rejected when any are
+// configured, and comments with fewer than minWords distinct words are held when minWords is positive.
+func NewModerationRules(bannedWords []string, minWords int) []ModerationRule {
+	rules := []ModerationRule{LinkRule{}}
+	if len(bannedWords) > 0 {
+		rules = append(rules, BannedWordsRule{Words: bannedWords})
+	}
+	if minWords > 0 {
+		rules = append(rules, RatingOnlySpamRule{MinWords: minWords})
+	}
+	return rules
+}
+
+// moderateReview runs the rules against a review and returns its status and the reason for it.
+// A rejection from any rule wins over a hold; a review no rule objects to is approved, unless every review needs approval.
+func moderateReview(rules []ModerationRule, requireApproval bool, review *models.Review) (string, string) {
+	status, reason := models.ReviewStatusApproved, ""
+	for _, rule := range rules {
+		ruleStatus, ruleReason := rule.Check(review)
+		switch ruleStatus {
+		case models.ReviewStatusRejected:
+			return ruleStatus, ruleReason
+		case models.ReviewStatusPending:
+			if status != models.ReviewStatusPending {
+				status, reason = ruleStatus, ruleReason
+			}
+		}
+	}
+	if status == models.ReviewStatusApproved && requireApproval {
+		return models.ReviewStatusPending, "Awaiting approval"
+	}
+	return status, reason
+}
+
+// commentWords splits a comment into lowercase words made of letters and digits.
+// Words without any letter, such as "5" or "10/10", are dropped.
+func commentWords(comment string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(comment), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if strings.IndexFunc(field, unicode.IsLetter) >= 0 {
+			words = append(words, field)
+		}
+	}
+	return words
+}
@@ -0,0 +1,77 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_moderation_test.go: Tests for the auto-moderation rules and how their verdicts are combined.
+
+// TestModerationRules tests each rule against comments it should and should not flag.
+func TestModerationRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       ModerationRule
+		comment    string
+		wantStatus string
+	}{
+		{name: "banned_word", rule: BannedWordsRule{Words: []string{"Scam"}}, comment: "Total scam, avoid", wantStatus: models.ReviewStatusRejected},
+		{name: "banned_word_inside_other_word", rule: BannedWordsRule{Words: []string{"scam"}}, comment: "Not a scampi fork but works"},
+		{name: "clean_comment", rule: BannedWordsRule{Words: []string{"scam"}}, comment: "Works as described"},
+		{name: "url", rule: LinkRule{}, comment: "Cheaper at https://example.test/deal", wantStatus: models.ReviewStatusPending},
+		{name: "www", rule: LinkRule{}, comment: "see www.example for more", wantStatus: models.ReviewStatusPending},
+		{name: "bare_domain", rule: LinkRule{}, comment: "buy from cheapstuff.shop instead", wantStatus: models.ReviewStatusPending},
+		{name: "no_link", rule: LinkRule{}, comment: "Fits well. Sturdy enough."},
+		{name: "rating_only", rule: RatingOnlySpamRule{MinWords: 2}, comment: "5/5", wantStatus: models.ReviewStatusPending},
+		{name: "repeated_word", rule: RatingOnlySpamRule{MinWords: 2}, comment: "great great great!!!", wantStatus: models.ReviewStatusPending},
+		{name: "enough_words", rule: RatingOnlySpamRule{MinWords: 2}, comment: "Great blender"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason := tt.rule.Check(&models.Review{Comment: tt.comment})
+			assert.Equal(t, tt.wantStatus, status)
+			if tt.wantStatus != "" {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+// TestModerateReview tests that rejections win over holds and that clean reviews are approved unless approval is required.
+func TestModerateReview(t *testing.T) {
+	rules := NewModerationRules([]string{"scam"}, 2)
+
+	tests := []struct {
+		name            string
+		comment         string
+		requireApproval bool
+		wantStatus      string
+	}{
+		{name: "clean", comment: "Solid kettle, boils fast", wantStatus: models.ReviewStatusApproved},
+		{name: "clean_require_approval", comment: "Solid kettle, boils fast", requireApproval: true, wantStatus: models.ReviewStatusPending},
+		{name: "held", comment: "ok", wantStatus: models.ReviewStatusPending},
+		{name: "held_and_rejected", comment: "scam www.example.com", wantStatus: models.ReviewStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _ := moderateReview(rules, tt.requireApproval, &models.Review{Comment: tt.comment})
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+// TestNewModerationRules tests which rules are enabled by the configuration.
+func TestNewModerationRules(t *testing.T) {
+	assert.Equal(t, []ModerationRule{LinkRule{}}, NewModerationRules(nil, 0))
+	assert.Equal(t, []ModerationRule{
+		LinkRule{},
+		BannedWordsRule{Words: []string{"scam"}},
+		RatingOnlySpamRule{MinWords: 3},
+	}, NewModerationRules([]string{"scam"}, 3))
+}
@@ -0,0 +1,133 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+)
+
+// review_rating_summary.go: Keeps the rating summary stored on each product in sync with its approved reviews.
+
+// RatingSummarySource computes rating summaries from approved reviews.
+type RatingSummarySource interface {
+	GetAllProductRatingSummaries(ctx context.Context) (map[string]intmongo.ProductRatingSummary, error)
+}
+
+// RatingSummaryStore writes rating summaries to the products table.
+type RatingSummaryStore interface {
+	ListProductIDs(ctx context.Context) ([]string, error)
+	UpdateProductRatingSummary(ctx context.Context, arg database.UpdateProductRatingSummaryParams) error
+}
+
+// productCachePattern matches the cached product responses, which include each product's rating summary.
+const productCachePattern = "products:*"
+
+// ProductCache holds cached product responses.
+type ProductCache interface {
+	DeletePattern(ctx context.Context, pattern string) error
+}
+
+// RatingSummaryConfig controls how the rating summary stored on each product follows its reviews.
+type RatingSummaryConfig struct {
+	// Cache has its product responses cleared whenever a summary is refreshed; nil when responses are not cached.
+	Cache ProductCache
+	// Logger records summaries that could not be refreshed; nil uses the standard logger.
+	Logger *logrus.Logger
+}
+
+// ReviewDBAdapter adds the locked rating summary refresh to the generated queries.
+type ReviewDBAdapter struct {
+	*database.Queries
+	DBConn *sql.DB
+}
+
+// RefreshProductRatingSummary locks the product's row, computes its rating summary with compute, and stores it
+// before the lock is released. Refreshes of a product therefore run one at a time and each one reads the reviews
+// after the previous one committed, so a slow refresh cannot store an older summary over a newer one.
+func (a *ReviewDBAdapter) RefreshProductRatingSummary(ctx context.Context, productID string, compute func(context.Context) (intmongo.ProductRatingSummary, error)) error {
+	tx, err := a.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	queries := a.WithTx(tx)
+	if _, err := queries.GetProductByIDForUpdate(ctx, productID); err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+	summary, err := compute(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to compute rating summary: %w", err)
+	}
+	if err := queries.UpdateProductRatingSummary(ctx, ratingSummaryParams(productID, summary)); err != nil {
+		return fmt.Errorf("failed to update rating summary: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rating summary: %w", err)
+	}
+	return nil
+}
+
+// refreshRatingSummary recomputes a product's rating summary from its approved reviews, stores it on the product
+// and clears the cached product responses. Recomputing instead of applying deltas keeps the summary correct however
+// a review moved between statuses. Errors are logged but not returned: the review change is already saved, and a
+// stale summary is corrected by the next change to the product's reviews or by the reconcile-ratings command.
+func (s *reviewServiceImpl) refreshRatingSummary(ctx context.Context, productID string) {
+	err := s.db.RefreshProductRatingSummary(ctx, productID, func(ctx context.Context) (intmongo.ProductRatingSummary, error) {
+		return s.reviewMongo.GetProductRatingSummary(ctx, productID)
+	})
+	if err != nil {
+		s.ratings.Logger.WithFields(logrus.Fields{"product_id": productID}).WithError(err).Error("Failed to refresh product rating summary")
+		return
+	}
+	if s.ratings.Cache == nil {
+		return
+	}
+	if err := s.ratings.Cache.DeletePattern(ctx, productCachePattern); err != nil {
+		s.ratings.Logger.WithFields(logrus.Fields{"product_id": productID}).WithError(err).Warn("Failed to clear cached products after a rating summary refresh")
+	}
+}
+
+// ReconcileRatingSummaries rebuilds the rating summary of every product from its approved reviews.
+// Products without approved reviews are reset to zero. Returns the number of products updated.
+func ReconcileRatingSummaries(ctx context.Context, source RatingSummarySource, store RatingSummaryStore) (int, error) {
+	summaries, err := source.GetAllProductRatingSummaries(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute rating summaries: %w", err)
+	}
+	productIDs, err := store.ListProductIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	updated := 0
+	for _, productID := range productIDs {
+		if err := store.UpdateProductRatingSummary(ctx, ratingSummaryParams(productID, summaries[productID])); err != nil {
+			return updated, fmt.Errorf("failed to update rating summary for product %s: %w", productID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ratingSummaryParams converts a rating summary to the products table columns.
+func ratingSummaryParams(productID string, summary intmongo.ProductRatingSummary) database.UpdateProductRatingSummaryParams {
+	return database.UpdateProductRatingSummaryParams{
+		ID:           productID,
+		RatingAvg:    fmt.Sprintf("%.2f", summary.Average),
+		RatingCount:  int32(summary.Count),
+		Rating1Count: int32(summary.Histogram[0]),
+		Rating2Count: int32(summary.Histogram[1]),
+		Rating3Count: int32(summary.Histogram[2]),
+		Rating4Count: int32(summary.Histogram[3]),
+		Rating5Count: int32(summary.Histogram[4]),
+	}
+}
@@ -0,0 +1,189 @@
+// Package reviewhandlers provides HTTP handlers for managing product reviews, including CRUD operations and listing with filters and pagination.
+package reviewhandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_rating_summary_test.go: Tests for refreshing and rebuilding product rating summaries.
+
+// TestReconcileRatingSummaries tests that every product gets its summary and products without reviews are reset to zero.
+func TestReconcileRatingSummaries(t *testing.T) {
+	source := new(mockReviewMongo)
+	store := new(mockReviewDB)
+	source.On("GetAllProductRatingSummaries", mock.Anything).Return(map[string]intmongo.ProductRatingSummary{
+		"p1": {ProductID: "p1", Average: 4.5, Count: 2, Histogram: [5]int64{0, 0, 0, 1, 1}},
+	}, nil)
+	store.On("ListProductIDs", mock.Anything).Return([]string{"p1", "p2"}, nil)
+	store.On("UpdateProductRatingSummary", mock.Anything, database.UpdateProductRatingSummaryParams{
+		ID: "p1", RatingAvg: "4.50", RatingCount: 2, Rating4Count: 1, Rating5Count: 1,
+	}).Return(nil)
+	store.On("UpdateProductRatingSummary", mock.Anything, database.UpdateProductRatingSummaryParams{
+		ID: "p2", RatingAvg: "0.00",
+	}).Return(nil)
+
+	updated, err := ReconcileRatingSummaries(context.Background(), source, store)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	store.AssertExpectations(t)
+}
+
+// TestReconcileRatingSummaries_Errors tests that failures to read summaries, list products or write a summary are returned.
+func TestReconcileRatingSummaries_Errors(t *testing.T) {
+	t.Run("aggregate_fails", func(t *testing.T) {
+		source := new(mockReviewMongo)
+		store := new(mockReviewDB)
+		source.On("GetAllProductRatingSummaries", mock.Anything).Return(nil, errors.New("mongo fail"))
+
+		updated, err := ReconcileRatingSummaries(context.Background(), source, store)
+		require.Error(t, err)
+		assert.Zero(t, updated)
+		store.AssertNotCalled(t, "ListProductIDs", mock.Anything)
+	})
+
+	t.Run("list_fails", func(t *testing.T) {
+		source := new(mockReviewMongo)
+		store := new(mockReviewDB)
+		source.On("GetAllProductRatingSummaries", mock.Anything).Return(map[string]intmongo.ProductRatingSummary{}, nil)
+		store.On("ListProductIDs", mock.Anything).Return(nil, errors.New("db fail"))
+
+		_, err := ReconcileRatingSummaries(context.Background(), source, store)
+		require.Error(t, err)
+	})
+
+	t.Run("update_fails", func(t *testing.T) {
+		source := new(mockReviewMongo)
+		store := new(mockReviewDB)
+		source.On("GetAllProductRatingSummaries", mock.Anything).Return(map[string]intmongo.ProductRatingSummary{}, nil)
+		store.On("ListProductIDs", mock.Anything).Return([]string{"p1", "p2"}, nil)
+		store.On("UpdateProductRatingSummary", mock.Anything, mock.Anything).Return(errors.New("db fail")).Once()
+
+		updated, err := ReconcileRatingSummaries(context.Background(), source, store)
+		require.ErrorContains(t, err, "p1")
+		assert.Zero(t, updated)
+	})
+}
+
+// productColumns lists the products table columns scanned by GetProductByIDForUpdate.
+var productColumns = []string{"id", "category_id", "name", "description", "price", "stock", "image_url", "is_active", "created_at", "updated_at", "rating_avg", "rating_count", "rating_1_count", "rating_2_count", "rating_3_count", "rating_4_count", "rating_5_count"}
+
+// newRatingSummaryAdapter returns a ReviewDBAdapter backed by sqlmock.
+func newRatingSummaryAdapter(t *testing.T) (*ReviewDBAdapter, sqlmock.Sqlmock) {
+	db, mockSQL, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return &ReviewDBAdapter{Queries: database.New(db), DBConn: db}, mockSQL
+}
+
+// TestReviewDBAdapter_RefreshProductRatingSummary tests that the summary is computed and stored while the product row is locked.
+func TestReviewDBAdapter_RefreshProductRatingSummary(t *testing.T) {
+	adapter, mockSQL := newRatingSummaryAdapter(t)
+	now := time.Now()
+	mockSQL.ExpectBegin()
+	mockSQL.ExpectQuery("SELECT .* FROM products\\s+WHERE id = \\$1\\s+FOR UPDATE").WithArgs("p1").
+		WillReturnRows(sqlmock.NewRows(productColumns).AddRow("p1", nil, "Product", nil, "10.00", 1, nil, true, now, now, "0.00", 0, 0, 0, 0, 0, 0))
+	mockSQL.ExpectExec("UPDATE products").WithArgs("p1", "4.50", int64(2), int64(0), int64(0), int64(0), int64(1), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+
+	err := adapter.RefreshProductRatingSummary(context.Background(), "p1", func(context.Context) (intmongo.ProductRatingSummary, error) {
+		return intmongo.ProductRatingSummary{ProductID: "p1", Average: 4.5, Count: 2, Histogram: [5]int64{0, 0, 0, 1, 1}}, nil
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mockSQL.ExpectationsWereMet())
+}
+
+// TestReviewDBAdapter_RefreshProductRatingSummary_Errors tests that a failed lock or summary read rolls back without writing.
+func TestReviewDBAdapter_RefreshProductRatingSummary_Errors(t *testing.T) {
+	t.Run("lock_fails", func(t *testing.T) {
+		adapter, mockSQL := newRatingSummaryAdapter(t)
+		mockSQL.ExpectBegin()
+		mockSQL.ExpectQuery("FOR UPDATE").WithArgs("p1").WillReturnError(errors.New("db fail"))
+		mockSQL.ExpectRollback()
+
+		err := adapter.RefreshProductRatingSummary(context.Background(), "p1", func(context.Context) (intmongo.ProductRatingSummary, error) {
+			t.Fatal("summary computed without the lock")
+			return intmongo.ProductRatingSummary{}, nil
+		})
+		require.ErrorContains(t, err, "failed to lock product")
+		assert.NoError(t, mockSQL.ExpectationsWereMet())
+	})
+
+	t.Run("compute_fails", func(t *testing.T) {
+		adapter, mockSQL := newRatingSummaryAdapter(t)
+		now := time.Now()
+		mockSQL.ExpectBegin()
+		mockSQL.ExpectQuery("FOR UPDATE").WithArgs("p1").
+			WillReturnRows(sqlmock.NewRows(productColumns).AddRow("p1", nil, "Product", nil, "10.00", 1, nil, true, now, now, "0.00", 0, 0, 0, 0, 0, 0))
+		mockSQL.ExpectRollback()
+
+		err := adapter.RefreshProductRatingSummary(context.Background(), "p1", func(context.Context) (intmongo.ProductRatingSummary, error) {
+			return intmongo.ProductRatingSummary{}, errors.New("mongo fail")
+		})
+		require.ErrorContains(t, err, "failed to compute rating summary")
+		assert.NoError(t, mockSQL.ExpectationsWereMet())
+	})
+}
+
+// TestRefreshRatingSummary_ClearsProductCache tests that cached products are cleared only after a summary is stored, and failures are logged.
+func TestRefreshRatingSummary_ClearsProductCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		mongoErr  error
+		cacheErr  error
+		wantClear bool
+		wantLog   string
+	}{
+		{name: "refreshed", wantClear: true},
+		{name: "refresh_fails", mongoErr: errors.New("mongo fail"), wantLog: "Failed to refresh product rating summary"},
+		{name: "clear_fails", cacheErr: errors.New("redis fail"), wantClear: true, wantLog: "Failed to clear cached products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := new(mockReviewDB)
+			cache := new(mockProductCache)
+			var logs bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logs)
+			svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{Cache: cache, Logger: logger})
+
+			review := &models.Review{ID: "r1", ProductID: "p1"}
+			m.On("UpdateReviewByID", mock.Anything, "r1", review).Return(nil)
+			m.On("GetProductRatingSummary", mock.Anything, "p1").Return(intmongo.ProductRatingSummary{ProductID: "p1"}, tt.mongoErr)
+			if tt.mongoErr == nil {
+				db.On("UpdateProductRatingSummary", mock.Anything, mock.Anything).Return(nil)
+			}
+			if tt.wantClear {
+				cache.On("DeletePattern", mock.Anything, "products:*").Return(tt.cacheErr)
+			}
+
+			require.NoError(t, svc.UpdateReviewByID(context.Background(), "r1", review))
+			cache.AssertExpectations(t)
+			if !tt.wantClear {
+				cache.AssertNotCalled(t, "DeletePattern", mock.Anything, mock.Anything)
+			}
+			if tt.wantLog != "" {
+				assert.Contains(t, logs.String(), tt.wantLog)
+				assert.Contains(t, logs.String(), "product_id=p1")
+			} else {
+				assert.Empty(t, logs.String())
+			}
+		})
+	}
+}
@@ -3,9 +3,16 @@ package reviewhandlers
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
 	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
 	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
 	"github.com/STaninnat/ecom-backend/models"
 )
@@ -27,6 +34,43 @@ type ReviewMongoAPI interface {
 	DeleteReviewByID(ctx context.Context, reviewID string) error
 	GetReviewsByProductIDPaginated(ctx context.Context, productID string, opts *intmongo.PaginationOptions) (*intmongo.PaginatedResult[*models.Review], error)
 	GetReviewsByUserIDPaginated(ctx context.Context, userID string, opts *intmongo.PaginationOptions) (*intmongo.PaginatedResult[*models.Review], error)
+	GetReviewsByStatusPaginated(ctx context.Context, status string, opts *intmongo.PaginationOptions) (*intmongo.PaginatedResult[*models.Review], error)
+	UpdateReviewStatus(ctx context.Context, reviewID string, update intmongo.ReviewStatusUpdate) error
+	GetProductRatingSummary(ctx context.Context, productID string) (intmongo.ProductRatingSummary, error)
+	VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error
+	SetMerchantReply(ctx context.Context, reviewID string, reply *models.ReviewReply) error
+}
+
+// ReviewDBAPI defines the PostgreSQL lookups used to check reviews against the catalog and order history.
+type ReviewDBAPI interface {
+	GetProductByID(ctx context.Context, id string) (database.Product, error)
+	HasDeliveredOrderWithProduct(ctx context.Context, arg database.HasDeliveredOrderWithProductParams) (bool, error)
+	RefreshProductRatingSummary(ctx context.Context, productID string, compute func(context.Context) (intmongo.ProductRatingSummary, error)) error
+}
+
+// ReviewPolicy controls which reviews are accepted.
+type ReviewPolicy struct {
+	// VerifiedPurchaseOnly rejects reviews from users without a delivered order containing the product.
+	VerifiedPurchaseOnly bool
+	// ModerationRules are run against new and edited reviews to decide whether they are published, held or rejected.
+	ModerationRules []ModerationRule
+	// RequireApproval holds every review for an admin, even when no rule objects to it.
+	RequireApproval bool
+}
+
+// ModerateReviewParams holds an admin's moderation decision.
+type ModerateReviewParams struct {
+	ReviewID string
+	Status   string
+	AdminID  string
+	Reason   string
+}
+
+// ReviewReplyParams holds the store's reply to a review.
+type ReviewReplyParams struct {
+	ReviewID string
+	AdminID  string
+	Comment  string
 }
 
 // reviewServiceImpl implements ReviewService for business logic.
@@ -34,31 +78,83 @@ type ReviewMongoAPI interface {
 // Provides business logic layer between handlers and data access layer.
 type reviewServiceImpl struct {
 	reviewMongo ReviewMongoAPI
+	db          ReviewDBAPI
+	policy      ReviewPolicy
+	media       ReviewMediaConfig
+	ratings     RatingSummaryConfig
 }
 
 // NewReviewService creates a new ReviewService instance.
 // Initializes the review service with the provided MongoDB API implementation.
 // Parameters:
 //   - reviewMongo: ReviewMongoAPI implementation for data access
+//   - db: ReviewDBAPI implementation for product and purchase lookups
+//   - policy: ReviewPolicy deciding which reviews are accepted
+//   - media: ReviewMediaConfig for review photo uploads
+//   - ratings: RatingSummaryConfig for keeping product rating summaries in sync
 //
 // Returns:
 //   - ReviewService: configured review service instance
-func NewReviewService(reviewMongo ReviewMongoAPI) ReviewService {
-	return &reviewServiceImpl{reviewMongo: reviewMongo}
+func NewReviewService(reviewMongo ReviewMongoAPI, db ReviewDBAPI, policy ReviewPolicy, media ReviewMediaConfig, ratings RatingSummaryConfig) ReviewService {
+	if ratings.Logger == nil {
+		ratings.Logger = logrus.StandardLogger()
+	}
+	return &reviewServiceImpl{reviewMongo: reviewMongo, db: db, policy: policy, media: media, ratings: ratings}
 }
 
 // CreateReview creates a new review.
-// Delegates to the MongoDB API and wraps any errors in standardized AppError format.
+// Checks that the product exists and marks the review as a verified purchase when the user has a delivered order
+// containing it. When the policy only allows verified reviews, other reviews are rejected.
+// Each user can review a product once. Media IDs must be unused uploads of the reviewer; they are claimed for the
+// review before it is saved, so two reviews cannot share an upload.
 // Parameters:
 //   - ctx: context.Context for the operation
 //   - review: *models.Review to be created
 //
 // Returns:
-//   - error: nil on success, AppError with "create_failed" code on failure
+//   - error: nil on success, AppError with "invalid_request", "product_not_found", "verified_purchase_required",
+//     "review_exists", "database_error" or "create_failed" code on failure
 func (s *reviewServiceImpl) CreateReview(ctx context.Context, review *models.Review) error {
+	if _, err := s.db.GetProductByID(ctx, review.ProductID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "product_not_found", Message: "Product not found", Err: err}
+		}
+		return &handlers.AppError{Code: "database_error", Message: "Failed to get product", Err: err}
+	}
+
+	verified, err := s.db.HasDeliveredOrderWithProduct(ctx, database.HasDeliveredOrderWithProductParams{
+		UserID:    review.UserID,
+		ProductID: review.ProductID,
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "database_error", Message: "Failed to check purchase history", Err: err}
+	}
+	if !verified && s.policy.VerifiedPurchaseOnly {
+		return &handlers.AppError{Code: "verified_purchase_required", Message: "Only customers who received this product can review it"}
+	}
+	review.VerifiedPurchase = verified
+	review.Status, review.ModerationReason = moderateReview(s.policy.ModerationRules, s.policy.RequireApproval, review)
+
+	if review.MediaURLs, err = s.resolveMedia(ctx, review.UserID, "", review.MediaIDs); err != nil {
+		return err
+	}
+
+	// The media is claimed under the review's ID before the review exists
+	if review.ID == "" {
+		review.ID = bson.NewObjectID().Hex()
+	}
+	if err := s.claimMedia(ctx, review.UserID, review.ID, review.MediaIDs, nil); err != nil {
+		return err
+	}
+
 	if err := s.reviewMongo.CreateReview(ctx, review); err != nil {
+		s.releaseMedia(ctx, review.MediaIDs, review.ID)
+		if errors.Is(err, intmongo.ErrDuplicateReview) {
+			return &handlers.AppError{Code: "review_exists", Message: "You have already reviewed this product", Err: err}
+		}
 		return &handlers.AppError{Code: "create_failed", Message: "Failed to create review", Err: err}
 	}
+	s.refreshRatingSummary(ctx, review.ProductID)
 	return nil
 }
 
@@ -117,6 +213,8 @@ func (s *reviewServiceImpl) GetReviewsByUserID(ctx context.Context, userID strin
 }
 
 // UpdateReviewByID updates a review by its ID.
+// Edited reviews are moderated again, except ones that were rejected or hidden, which keep their status.
+// Media IDs must be uploads of the reviewer; media the edit drops are deleted from storage.
 // Delegates to the MongoDB API and handles "not found" cases with appropriate error codes.
 // Parameters:
 //   - ctx: context.Context for the operation
@@ -124,18 +222,42 @@ func (s *reviewServiceImpl) GetReviewsByUserID(ctx context.Context, userID strin
 //   - updatedReview: *models.Review containing the updated data
 //
 // Returns:
-//   - error: nil on success, AppError with "not_found" or "update_failed" code on failure
+//   - error: nil on success, AppError with "invalid_request", "not_found" or "update_failed" code on failure
 func (s *reviewServiceImpl) UpdateReviewByID(ctx context.Context, reviewID string, updatedReview *models.Review) error {
+	if updatedReview.Status != models.ReviewStatusRejected && updatedReview.Status != models.ReviewStatusHidden {
+		updatedReview.Status, updatedReview.ModerationReason = moderateReview(s.policy.ModerationRules, s.policy.RequireApproval, updatedReview)
+	}
+
+	var previousMedia []string
+	if s.media.Store != nil {
+		existing, err := s.GetReviewByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+		previousMedia = existing.MediaIDs
+	}
+	urls, err := s.resolveMedia(ctx, updatedReview.UserID, reviewID, updatedReview.MediaIDs)
+	if err != nil {
+		return err
+	}
+	updatedReview.MediaURLs = urls
+	if err := s.claimMedia(ctx, updatedReview.UserID, reviewID, updatedReview.MediaIDs, previousMedia); err != nil {
+		return err
+	}
+
 	if err := s.reviewMongo.UpdateReviewByID(ctx, reviewID, updatedReview); err != nil {
+		s.releaseMedia(ctx, droppedMedia(updatedReview.MediaIDs, previousMedia), reviewID)
 		if err.Error() == reviewNotFoundMsg {
 			return &handlers.AppError{Code: "not_found", Message: "Review not found", Err: err}
 		}
 		return &handlers.AppError{Code: "update_failed", Message: "Failed to update review", Err: err}
 	}
+	s.removeMedia(ctx, droppedMedia(previousMedia, updatedReview.MediaIDs))
+	s.refreshRatingSummary(ctx, updatedReview.ProductID)
 	return nil
 }
 
-// DeleteReviewByID deletes a review by its ID.
+// DeleteReviewByID deletes a review by its ID, along with its media.
 // Delegates to the MongoDB API and handles "not found" cases with appropriate error codes.
 // Parameters:
 //   - ctx: context.Context for the operation
@@ -144,12 +266,18 @@ func (s *reviewServiceImpl) UpdateReviewByID(ctx context.Context, reviewID strin
 // Returns:
 //   - error: nil on success, AppError with "not_found" or "delete_failed" code on failure
 func (s *reviewServiceImpl) DeleteReviewByID(ctx context.Context, reviewID string) error {
+	review, err := s.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
 	if err := s.reviewMongo.DeleteReviewByID(ctx, reviewID); err != nil {
 		if err.Error() == reviewNotFoundMsg {
 			return &handlers.AppError{Code: "not_found", Message: "Review not found", Err: err}
 		}
 		return &handlers.AppError{Code: "delete_failed", Message: "Failed to delete review", Err: err}
 	}
+	s.removeMedia(ctx, review.MediaIDs)
+	s.refreshRatingSummary(ctx, review.ProductID)
 	return nil
 }
 
@@ -198,9 +326,13 @@ func (s *reviewServiceImpl) getReviewsByFieldPaginated(
 	rating, minRating, maxRating *int,
 	from, to *time.Time,
 	hasMedia *bool,
+	publishedOnly bool,
 	sort, errMsg string,
 ) (any, error) {
 	filter := buildReviewFilter(rootKey, id, rating, minRating, maxRating, from, to, hasMedia)
+	if publishedOnly {
+		filter["status"] = intmongo.PublishedReviewFilter()
+	}
 	findSort := parseSortOption(sort)
 	result, err := mongoFunc(ctx, id, &intmongo.PaginationOptions{
 		Page:     int64(page),
@@ -228,7 +360,7 @@ func (s *reviewServiceImpl) GetReviewsByProductIDPaginated(ctx context.Context,
 		"product_id",
 		productID,
 		s.reviewMongo.GetReviewsByProductIDPaginated,
-		page, pageSize, rating, minRating, maxRating, from, to, hasMedia, sort,
+		page, pageSize, rating, minRating, maxRating, from, to, hasMedia, true, sort,
 		"Failed to get reviews by product (paginated)",
 	)
 }
@@ -239,14 +371,167 @@ func (s *reviewServiceImpl) GetReviewsByUserIDPaginated(ctx context.Context, use
 		"user_id",
 		userID,
 		s.reviewMongo.GetReviewsByUserIDPaginated,
-		page, pageSize, rating, minRating, maxRating, from, to, hasMedia, sort,
+		page, pageSize, rating, minRating, maxRating, from, to, hasMedia, false, sort,
 		"Failed to get reviews by user (paginated)",
 	)
 }
 
+// ListReviewsByStatus returns reviews in a moderation status, oldest first, for the admin moderation queue.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - status: moderation status to list
+//   - page, pageSize: pagination
+//
+// Returns:
+//   - any: PaginatedReviewsResponse with the reviews
+//   - error: nil on success, AppError with "invalid_request" or "get_failed" code on failure
+func (s *reviewServiceImpl) ListReviewsByStatus(ctx context.Context, status string, page, pageSize int) (any, error) {
+	switch status {
+	case models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected, models.ReviewStatusHidden:
+	default:
+		return nil, &handlers.AppError{Code: "invalid_request", Message: "Invalid review status"}
+	}
+
+	result, err := s.reviewMongo.GetReviewsByStatusPaginated(ctx, status, &intmongo.PaginationOptions{
+		Page:     int64(page),
+		PageSize: int64(pageSize),
+		Sort:     map[string]any{"created_at": 1},
+	})
+	if err != nil {
+		return nil, &handlers.AppError{Code: "get_failed", Message: "Failed to get reviews by status", Err: err}
+	}
+	return PaginatedReviewsResponse{
+		Data:       result.Data,
+		TotalCount: result.TotalCount,
+		Page:       int(result.Page),
+		PageSize:   int(result.PageSize),
+		TotalPages: int(result.TotalPages),
+		HasNext:    result.HasNext,
+		HasPrev:    result.HasPrev,
+	}, nil
+}
+
+// ModerateReview records an admin's decision to approve, reject or hide a review.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - params: ModerateReviewParams with the review, new status, admin and reason
+//
+// Returns:
+//   - error: nil on success, AppError with "invalid_request", "not_found" or "update_failed" code on failure
+func (s *reviewServiceImpl) ModerateReview(ctx context.Context, params ModerateReviewParams) error {
+	switch params.Status {
+	case models.ReviewStatusApproved, models.ReviewStatusRejected, models.ReviewStatusHidden:
+	default:
+		return &handlers.AppError{Code: "invalid_request", Message: "Invalid review status"}
+	}
+
+	review, err := s.GetReviewByID(ctx, params.ReviewID)
+	if err != nil {
+		return err
+	}
+	err = s.reviewMongo.UpdateReviewStatus(ctx, params.ReviewID, intmongo.ReviewStatusUpdate{
+		Status:      params.Status,
+		Reason:      params.Reason,
+		ModeratedBy: params.AdminID,
+	})
+	if err != nil {
+		if err.Error() == reviewNotFoundMsg {
+			return &handlers.AppError{Code: "not_found", Message: "Review not found", Err: err}
+		}
+		return &handlers.AppError{Code: "update_failed", Message: "Failed to update review status", Err: err}
+	}
+	s.refreshRatingSummary(ctx, review.ProductID)
+	return nil
+}
+
+// VoteReview records a user's helpful or unhelpful vote on a published review.
+// Users cannot vote on their own reviews; voting again replaces the user's earlier vote.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - reviewID: string identifier of the review
+//   - userID: string identifier of the voting user
+//   - helpful: true for a helpful vote, false for an unhelpful one
+//
+// Returns:
+//   - error: nil on success, AppError with "invalid_request", "not_found" or "update_failed" code on failure
+func (s *reviewServiceImpl) VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error {
+	review, err := s.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if !isPublished(review) {
+		return &handlers.AppError{Code: "not_found", Message: "Review not found"}
+	}
+	if review.UserID == userID {
+		return &handlers.AppError{Code: "invalid_request", Message: "You cannot vote on your own review"}
+	}
+
+	if err := s.reviewMongo.VoteReview(ctx, reviewID, userID, helpful); err != nil {
+		if err.Error() == reviewNotFoundMsg {
+			return &handlers.AppError{Code: "not_found", Message: "Review not found", Err: err}
+		}
+		return &handlers.AppError{Code: "update_failed", Message: "Failed to record vote", Err: err}
+	}
+	return nil
+}
+
+// ReplyToReview sets the store's public reply to a review, replacing any earlier reply.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - params: ReviewReplyParams with the review, admin and reply text
+//
+// Returns:
+//   - error: nil on success, AppError with "invalid_request", "not_found" or "update_failed" code on failure
+func (s *reviewServiceImpl) ReplyToReview(ctx context.Context, params ReviewReplyParams) error {
+	comment := strings.TrimSpace(params.Comment)
+	if comment == "" {
+		return &handlers.AppError{Code: "invalid_request", Message: "Reply comment is required"}
+	}
+
+	review, err := s.GetReviewByID(ctx, params.ReviewID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	reply := &models.ReviewReply{Comment: comment, RepliedBy: params.AdminID, CreatedAt: now, UpdatedAt: now}
+	if review.MerchantReply != nil {
+		reply.CreatedAt = review.MerchantReply.CreatedAt
+	}
+	return s.setMerchantReply(ctx, params.ReviewID, reply)
+}
+
+// DeleteReviewReply removes the store's reply from a review.
+// Parameters:
+//   - ctx: context.Context for the operation
+//   - reviewID: string identifier of the review
+//
+// Returns:
+//   - error: nil on success, AppError with "not_found" or "update_failed" code on failure
+func (s *reviewServiceImpl) DeleteReviewReply(ctx context.Context, reviewID string) error {
+	return s.setMerchantReply(ctx, reviewID, nil)
+}
+
+// setMerchantReply stores or removes a merchant reply and wraps errors in AppError.
+func (s *reviewServiceImpl) setMerchantReply(ctx context.Context, reviewID string, reply *models.ReviewReply) error {
+	if err := s.reviewMongo.SetMerchantReply(ctx, reviewID, reply); err != nil {
+		if err.Error() == reviewNotFoundMsg {
+			return &handlers.AppError{Code: "not_found", Message: "Review not found", Err: err}
+		}
+		return &handlers.AppError{Code: "update_failed", Message: "Failed to update reply", Err: err}
+	}
+	return nil
+}
+
+// isPublished reports whether a review is visible to customers.
+// Reviews stored before moderation was added have no status and count as approved.
+func isPublished(review *models.Review) bool {
+	return review.Status == "" || review.Status == models.ReviewStatusApproved
+}
+
 // parseSortOption converts a sort string to a mongo sort option.
 // Maps human-readable sort options to MongoDB sort specifications.
-// Supported options: date_desc, date_asc, rating_desc, rating_asc, updated_desc, updated_asc, comment_length_desc, comment_length_asc.
+// Supported options: date_desc, date_asc, rating_desc, rating_asc, updated_desc, updated_asc, comment_length_desc, comment_length_asc, helpful_desc.
 // Parameters:
 //   - sort: string representing the sort option
 //
@@ -270,6 +555,8 @@ func parseSortOption(sort string) map[string]any {
 		return map[string]any{"$expr": map[string]any{"$strLenCP": "$comment"}, "$meta": -1}
 	case "comment_length_asc":
 		return map[string]any{"$expr": map[string]any{"$strLenCP": "$comment"}, "$meta": 1}
+	case "helpful_desc":
+		return map[string]any{"helpful_count": -1}
 	default:
 		return map[string]any{"created_at": -1}
 	}
@@ -3,6 +3,7 @@ package reviewhandlers
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
 	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
 	"github.com/STaninnat/ecom-backend/models"
 )
@@ -24,11 +26,112 @@ import (
 // when the database operation succeeds.
 func TestCreateReview_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
-	review := &models.Review{ID: "r1"}
+	db := newPurchasedReviewDB(false)
+	svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"}
 	m.On("CreateReview", mock.Anything, review).Return(nil)
+	expectRatingRefresh(m, db, "p1")
 	err := svc.CreateReview(context.Background(), review)
 	require.NoError(t, err)
+	assert.False(t, review.VerifiedPurchase)
+	m.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+// TestCreateReview_VerifiedPurchase tests that reviews from users with a delivered order containing the product are
+// marked as verified purchases, and that other reviews are rejected when the policy requires it.
+func TestCreateReview_VerifiedPurchase(t *testing.T) {
+	tests := []struct {
+		name         string
+		purchased    bool
+		verifiedOnly bool
+		wantCode     string
+	}{
+		{name: "purchased", purchased: true},
+		{name: "purchased_verified_only", purchased: true, verifiedOnly: true},
+		{name: "not_purchased_verified_only", verifiedOnly: true, wantCode: "verified_purchase_required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := newPurchasedReviewDB(tt.purchased)
+			svc := NewReviewService(m, db, ReviewPolicy{VerifiedPurchaseOnly: tt.verifiedOnly}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"}
+			if tt.wantCode == "" {
+				m.On("CreateReview", mock.Anything, review).Return(nil)
+				expectRatingRefresh(m, db, "p1")
+			}
+
+			err := svc.CreateReview(context.Background(), review)
+			if tt.wantCode != "" {
+				appErr := &handlers.AppError{}
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+				m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, review.VerifiedPurchase)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCreateReview_ProductLookup tests that reviews for missing products are rejected and lookup failures are wrapped.
+func TestCreateReview_ProductLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{name: "not_found", err: sql.ErrNoRows, wantCode: "product_not_found"},
+		{name: "db_error", err: errors.New("db fail"), wantCode: "database_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := new(mockReviewDB)
+			db.On("GetProductByID", mock.Anything, "p1").Return(database.Product{}, tt.err)
+			svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+
+			err := svc.CreateReview(context.Background(), &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"})
+			appErr := &handlers.AppError{}
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantCode, appErr.Code)
+			db.AssertNotCalled(t, "HasDeliveredOrderWithProduct", mock.Anything, mock.Anything)
+			m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestCreateReview_PurchaseCheckFailure tests that a failed purchase history lookup is wrapped as a database error.
+func TestCreateReview_PurchaseCheckFailure(t *testing.T) {
+	m := new(mockReviewMongo)
+	db := new(mockReviewDB)
+	db.On("GetProductByID", mock.Anything, "p1").Return(database.Product{ID: "p1"}, nil)
+	db.On("HasDeliveredOrderWithProduct", mock.Anything, mock.Anything).Return(false, errors.New("db fail"))
+	svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+
+	err := svc.CreateReview(context.Background(), &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"})
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "database_error", appErr.Code)
+	m.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+}
+
+// TestCreateReview_Duplicate tests that a second review of the same product by the same user is rejected.
+func TestCreateReview_Duplicate(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc := NewReviewService(m, newPurchasedReviewDB(true), ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"}
+	m.On("CreateReview", mock.Anything, review).Return(intmongo.ErrDuplicateReview)
+
+	err := svc.CreateReview(context.Background(), review)
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "review_exists", appErr.Code)
 	m.AssertExpectations(t)
 }
 
@@ -36,8 +139,8 @@ func TestCreateReview_Success(t *testing.T) {
 // It ensures the service correctly wraps the database error in an AppError with the appropriate code.
 func TestCreateReview_Failure(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
-	review := &models.Review{ID: "r1"}
+	svc := NewReviewService(m, newPurchasedReviewDB(false), ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1"}
 	m.On("CreateReview", mock.Anything, review).Return(errors.New("db fail"))
 	err := svc.CreateReview(context.Background(), review)
 	require.Error(t, err)
@@ -53,7 +156,7 @@ func TestCreateReview_Failure(t *testing.T) {
 // when the database operation succeeds.
 func TestGetReviewByID_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	review := &models.Review{ID: "r1"}
 	m.On("GetReviewByID", mock.Anything, "r1").Return(review, nil)
 	got, err := svc.GetReviewByID(context.Background(), "r1")
@@ -82,7 +185,7 @@ func TestGetReviewByID_ErrorScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := new(mockReviewMongo)
-			svc := NewReviewService(m)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 			m.On("GetReviewByID", mock.Anything, "r1").Return((*models.Review)(nil), tt.dbErr)
 			got, err := svc.GetReviewByID(context.Background(), "r1")
 			assert.Nil(t, got)
@@ -100,7 +203,7 @@ func TestGetReviewByID_ErrorScenarios(t *testing.T) {
 // when the database operation succeeds.
 func TestGetReviewsByProductID_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	reviews := []*models.Review{{ID: "r1"}}
 	m.On("GetReviewsByProductID", mock.Anything, "p1").Return(reviews, nil)
 	got, err := svc.GetReviewsByProductID(context.Background(), "p1")
@@ -135,7 +238,7 @@ func TestGetReviewsByID_FailureScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := new(mockReviewMongo)
-			svc := NewReviewService(m)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 			if tt.method == "GetReviewsByProductID" {
 				m.On("GetReviewsByProductID", mock.Anything, tt.id).Return(([]*models.Review)(nil), tt.dbErr)
 				got, err := svc.GetReviewsByProductID(context.Background(), tt.id)
@@ -164,7 +267,7 @@ func TestGetReviewsByID_FailureScenarios(t *testing.T) {
 // when the database operation succeeds.
 func TestGetReviewsByUserID_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	reviews := []*models.Review{{ID: "r1"}}
 	m.On("GetReviewsByUserID", mock.Anything, "u1").Return(reviews, nil)
 	got, err := svc.GetReviewsByUserID(context.Background(), "u1")
@@ -177,7 +280,7 @@ func TestGetReviewsByUserID_Success(t *testing.T) {
 // It ensures the service correctly wraps the database error in an AppError with the "get_failed" code.
 func TestGetReviewsByUserID_Failure(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	dbErr := errors.New("db fail")
 	m.On("GetReviewsByUserID", mock.Anything, "u1").Return(([]*models.Review)(nil), dbErr)
 	got, err := svc.GetReviewsByUserID(context.Background(), "u1")
@@ -194,12 +297,15 @@ func TestGetReviewsByUserID_Failure(t *testing.T) {
 // when the database operation succeeds.
 func TestUpdateReviewByID_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
-	review := &models.Review{ID: "r1"}
+	db := new(mockReviewDB)
+	svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	review := &models.Review{ID: "r1", ProductID: "p1"}
 	m.On("UpdateReviewByID", mock.Anything, "r1", review).Return(nil)
+	expectRatingRefresh(m, db, "p1")
 	err := svc.UpdateReviewByID(context.Background(), "r1", review)
 	require.NoError(t, err)
 	m.AssertExpectations(t)
+	db.AssertExpectations(t)
 }
 
 func TestUpdateReviewByID_ErrorScenarios(t *testing.T) {
@@ -222,7 +328,7 @@ func TestUpdateReviewByID_ErrorScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := new(mockReviewMongo)
-			svc := NewReviewService(m)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 			review := &models.Review{ID: "r1"}
 			m.On("UpdateReviewByID", mock.Anything, "r1", review).Return(tt.dbErr)
 			err := svc.UpdateReviewByID(context.Background(), "r1", review)
@@ -235,25 +341,179 @@ func TestUpdateReviewByID_ErrorScenarios(t *testing.T) {
 	}
 }
 
+// TestCreateReview_Moderation tests that new reviews get the status chosen by the moderation rules.
+func TestCreateReview_Moderation(t *testing.T) {
+	tests := []struct {
+		name       string
+		comment    string
+		wantStatus string
+	}{
+		{name: "approved", comment: "Sturdy and quiet", wantStatus: models.ReviewStatusApproved},
+		{name: "held", comment: "Cheaper at www.example.com", wantStatus: models.ReviewStatusPending},
+		{name: "rejected", comment: "Total scam", wantStatus: models.ReviewStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := newPurchasedReviewDB(true)
+			svc := NewReviewService(m, db, ReviewPolicy{ModerationRules: NewModerationRules([]string{"scam"}, 0)}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			review := &models.Review{ID: "r1", UserID: "u1", ProductID: "p1", Comment: tt.comment}
+			m.On("CreateReview", mock.Anything, review).Return(nil)
+			expectRatingRefresh(m, db, "p1")
+
+			err := svc.CreateReview(context.Background(), review)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, review.Status)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUpdateReviewByID_Moderation tests that edited reviews are moderated again unless an admin took them down.
+func TestUpdateReviewByID_Moderation(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		wantStatus string
+	}{
+		{name: "approved_edited_with_link", status: models.ReviewStatusApproved, wantStatus: models.ReviewStatusPending},
+		{name: "hidden_stays_hidden", status: models.ReviewStatusHidden, wantStatus: models.ReviewStatusHidden},
+		{name: "rejected_stays_rejected", status: models.ReviewStatusRejected, wantStatus: models.ReviewStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := new(mockReviewDB)
+			svc := NewReviewService(m, db, ReviewPolicy{ModerationRules: NewModerationRules(nil, 0)}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			review := &models.Review{ID: "r1", ProductID: "p1", Comment: "Now at www.example.com", Status: tt.status}
+			m.On("UpdateReviewByID", mock.Anything, "r1", review).Return(nil)
+			expectRatingRefresh(m, db, "p1")
+
+			err := svc.UpdateReviewByID(context.Background(), "r1", review)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, review.Status)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListReviewsByStatus tests the admin moderation queue listing.
+func TestListReviewsByStatus(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	m.On("GetReviewsByStatusPaginated", mock.Anything, models.ReviewStatusPending, mock.MatchedBy(func(opts *intmongo.PaginationOptions) bool {
+		return opts.Page == 2 && opts.PageSize == 5 && opts.Sort["created_at"] == 1
+	})).Return(&intmongo.PaginatedResult[*models.Review]{Data: []*models.Review{{ID: "r1"}}, TotalCount: 6, Page: 2, PageSize: 5, TotalPages: 2}, nil)
+
+	resp, err := svc.ListReviewsByStatus(context.Background(), models.ReviewStatusPending, 2, 5)
+	require.NoError(t, err)
+	result, ok := resp.(PaginatedReviewsResponse)
+	require.True(t, ok)
+	assert.Equal(t, int64(6), result.TotalCount)
+	m.AssertExpectations(t)
+
+	_, err = svc.ListReviewsByStatus(context.Background(), "bogus", 1, 10)
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "invalid_request", appErr.Code)
+
+	m.On("GetReviewsByStatusPaginated", mock.Anything, models.ReviewStatusHidden, mock.Anything).Return(nil, errors.New("db fail"))
+	_, err = svc.ListReviewsByStatus(context.Background(), models.ReviewStatusHidden, 1, 10)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "get_failed", appErr.Code)
+}
+
+// TestModerateReview_Service tests recording admin moderation decisions and how errors are wrapped.
+func TestModerateReview_Service(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		dbErr    error
+		wantCode string
+	}{
+		{name: "hide", status: models.ReviewStatusHidden},
+		{name: "pending_not_allowed", status: models.ReviewStatusPending, wantCode: "invalid_request"},
+		{name: "review_missing", status: models.ReviewStatusApproved, dbErr: errors.New("review not found"), wantCode: "not_found"},
+		{name: "not_found", status: models.ReviewStatusApproved, dbErr: errors.New("review not found"), wantCode: "not_found"},
+		{name: "db_error", status: models.ReviewStatusRejected, dbErr: errors.New("db fail"), wantCode: "update_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := new(mockReviewDB)
+			svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			switch {
+			case tt.name == "review_missing":
+				m.On("GetReviewByID", mock.Anything, "r1").Return((*models.Review)(nil), tt.dbErr)
+			case tt.wantCode != "invalid_request":
+				m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", ProductID: "p1"}, nil)
+				m.On("UpdateReviewStatus", mock.Anything, "r1", intmongo.ReviewStatusUpdate{Status: tt.status, Reason: "Abusive", ModeratedBy: "admin1"}).Return(tt.dbErr)
+				if tt.dbErr == nil {
+					expectRatingRefresh(m, db, "p1")
+				}
+			}
+
+			err := svc.ModerateReview(context.Background(), ModerateReviewParams{ReviewID: "r1", Status: tt.status, AdminID: "admin1", Reason: "Abusive"})
+			if tt.wantCode != "" {
+				appErr := &handlers.AppError{}
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			m.AssertExpectations(t)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetReviewsByProductIDPaginated_OnlyPublished tests that product listings only include approved reviews,
+// while a user's own listing includes reviews in every status.
+func TestGetReviewsByProductIDPaginated_OnlyPublished(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	result := &intmongo.PaginatedResult[*models.Review]{Page: 1, PageSize: 10}
+	m.On("GetReviewsByProductIDPaginated", mock.Anything, "p1", mock.MatchedBy(func(opts *intmongo.PaginationOptions) bool {
+		return assert.ObjectsAreEqual(intmongo.PublishedReviewFilter(), opts.Filter["status"])
+	})).Return(result, nil)
+	m.On("GetReviewsByUserIDPaginated", mock.Anything, "u1", mock.MatchedBy(func(opts *intmongo.PaginationOptions) bool {
+		_, ok := opts.Filter["status"]
+		return !ok
+	})).Return(result, nil)
+
+	_, err := svc.GetReviewsByProductIDPaginated(context.Background(), "p1", 1, 10, nil, nil, nil, nil, nil, nil, "")
+	require.NoError(t, err)
+	_, err = svc.GetReviewsByUserIDPaginated(context.Background(), "u1", 1, 10, nil, nil, nil, nil, nil, nil, "")
+	require.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
 // TestDeleteReviewByID_Success tests the successful deletion of a review by ID via the review service.
 // It verifies that the service correctly delegates to the MongoDB layer and returns no error
 // when the database operation succeeds.
 func TestDeleteReviewByID_Success(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	db := new(mockReviewDB)
+	svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", ProductID: "p1"}, nil)
 	m.On("DeleteReviewByID", mock.Anything, "r1").Return(nil)
+	expectRatingRefresh(m, db, "p1")
 	err := svc.DeleteReviewByID(context.Background(), "r1")
 	require.NoError(t, err)
 	m.AssertExpectations(t)
+	db.AssertExpectations(t)
 }
 
 // TestDeleteReviewByID_NotFound tests the review service behavior when the review to delete is not found.
 // It ensures the service correctly wraps the database error in an AppError with the "not_found" code.
 func TestDeleteReviewByID_NotFound(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	dbErr := errors.New("review not found")
-	m.On("DeleteReviewByID", mock.Anything, "r1").Return(dbErr)
+	m.On("GetReviewByID", mock.Anything, "r1").Return((*models.Review)(nil), dbErr)
 	err := svc.DeleteReviewByID(context.Background(), "r1")
 	appErr := &handlers.AppError{}
 	ok := errors.As(err, &appErr)
@@ -266,8 +526,9 @@ func TestDeleteReviewByID_NotFound(t *testing.T) {
 // It ensures the service correctly wraps the database error in an AppError with the "delete_failed" code.
 func TestDeleteReviewByID_Failure(t *testing.T) {
 	m := new(mockReviewMongo)
-	svc := NewReviewService(m)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 	dbErr := errors.New("db fail")
+	m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", ProductID: "p1"}, nil)
 	m.On("DeleteReviewByID", mock.Anything, "r1").Return(dbErr)
 	err := svc.DeleteReviewByID(context.Background(), "r1")
 	appErr := &handlers.AppError{}
@@ -277,6 +538,36 @@ func TestDeleteReviewByID_Failure(t *testing.T) {
 	m.AssertExpectations(t)
 }
 
+// TestRefreshRatingSummary_ErrorsIgnored tests that a failed rating summary refresh does not fail a saved review change.
+func TestRefreshRatingSummary_ErrorsIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		mongoErr error
+		dbErr    error
+	}{
+		{name: "summary_read_fails", mongoErr: errors.New("mongo fail")},
+		{name: "summary_write_fails", dbErr: errors.New("db fail")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			db := new(mockReviewDB)
+			svc := NewReviewService(m, db, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			review := &models.Review{ID: "r1", ProductID: "p1"}
+			m.On("UpdateReviewByID", mock.Anything, "r1", review).Return(nil)
+			m.On("GetProductRatingSummary", mock.Anything, "p1").Return(intmongo.ProductRatingSummary{ProductID: "p1"}, tt.mongoErr)
+			if tt.mongoErr == nil {
+				db.On("UpdateProductRatingSummary", mock.Anything, mock.Anything).Return(tt.dbErr)
+			}
+
+			require.NoError(t, svc.UpdateReviewByID(context.Background(), "r1", review))
+			m.AssertExpectations(t)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
 // Deduplicated test for paginated review retrieval with filters (product/user)
 func TestGetReviewsByPaginated_Scenarios(t *testing.T) {
 	tests := []struct {
@@ -321,7 +612,7 @@ func TestGetReviewsByPaginated_Scenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := new(mockReviewMongo)
-			svc := NewReviewService(m)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 			if tt.method == "product" {
 				m.On("GetReviewsByProductIDPaginated", mock.Anything, tt.id, mock.Anything).Return(tt.result, nil)
 				resp, err := svc.GetReviewsByProductIDPaginated(
@@ -388,7 +679,7 @@ func TestGetReviewsByPaginated_EdgeCases(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			m := new(mockReviewMongo)
-			svc := NewReviewService(m)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
 			result := &intmongo.PaginatedResult[*models.Review]{
 				Data:       []*models.Review{{ID: "r1"}},
 				TotalCount: 1,
@@ -467,4 +758,112 @@ func TestParseSortOption(t *testing.T) {
 	assert.Equal(t, map[string]any{"updated_at": 1}, parseSortOption("updated_asc"))
 	assert.Equal(t, map[string]any{"$expr": map[string]any{"$strLenCP": "$comment"}, "$meta": -1}, parseSortOption("comment_length_desc"))
 	assert.Equal(t, map[string]any{"$expr": map[string]any{"$strLenCP": "$comment"}, "$meta": 1}, parseSortOption("comment_length_asc"))
+	assert.Equal(t, map[string]any{"helpful_count": -1}, parseSortOption("helpful_desc"))
+}
+
+// TestVoteReview_Service tests voting on published reviews and the cases where a vote is refused.
+func TestVoteReview_Service(t *testing.T) {
+	tests := []struct {
+		name     string
+		review   *models.Review
+		getErr   error
+		voteErr  error
+		wantCode string
+	}{
+		{name: "success", review: &models.Review{ID: "r1", UserID: "u2", Status: models.ReviewStatusApproved}},
+		{name: "legacy_review", review: &models.Review{ID: "r1", UserID: "u2"}},
+		{name: "review_missing", getErr: errors.New("review not found"), wantCode: "not_found"},
+		{name: "not_published", review: &models.Review{ID: "r1", UserID: "u2", Status: models.ReviewStatusPending}, wantCode: "not_found"},
+		{name: "own_review", review: &models.Review{ID: "r1", UserID: "u1", Status: models.ReviewStatusApproved}, wantCode: "invalid_request"},
+		{name: "deleted_meanwhile", review: &models.Review{ID: "r1", UserID: "u2"}, voteErr: errors.New("review not found"), wantCode: "not_found"},
+		{name: "db_error", review: &models.Review{ID: "r1", UserID: "u2"}, voteErr: errors.New("db fail"), wantCode: "update_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mockReviewMongo)
+			svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+			m.On("GetReviewByID", mock.Anything, "r1").Return(tt.review, tt.getErr)
+			if tt.wantCode == "" || tt.voteErr != nil {
+				m.On("VoteReview", mock.Anything, "r1", "u1", true).Return(tt.voteErr)
+			}
+
+			err := svc.VoteReview(context.Background(), "r1", "u1", true)
+			if tt.wantCode != "" {
+				appErr := &handlers.AppError{}
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+// TestReplyToReview_Service tests posting, editing and failing to save a merchant reply.
+func TestReplyToReview_Service(t *testing.T) {
+	t.Run("new_reply", func(t *testing.T) {
+		m := new(mockReviewMongo)
+		svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+		m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1"}, nil)
+		m.On("SetMerchantReply", mock.Anything, "r1", mock.MatchedBy(func(reply *models.ReviewReply) bool {
+			return reply.Comment == "Thanks!" && reply.RepliedBy == "admin1" && reply.CreatedAt.Equal(reply.UpdatedAt)
+		})).Return(nil)
+
+		err := svc.ReplyToReview(context.Background(), ReviewReplyParams{ReviewID: "r1", AdminID: "admin1", Comment: "  Thanks!  "})
+		require.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("edit_keeps_created_at", func(t *testing.T) {
+		m := new(mockReviewMongo)
+		svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+		posted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1", MerchantReply: &models.ReviewReply{Comment: "Old", CreatedAt: posted}}, nil)
+		m.On("SetMerchantReply", mock.Anything, "r1", mock.MatchedBy(func(reply *models.ReviewReply) bool {
+			return reply.Comment == "New" && reply.CreatedAt.Equal(posted) && reply.UpdatedAt.After(posted)
+		})).Return(nil)
+
+		require.NoError(t, svc.ReplyToReview(context.Background(), ReviewReplyParams{ReviewID: "r1", AdminID: "admin1", Comment: "New"}))
+		m.AssertExpectations(t)
+	})
+
+	t.Run("blank_comment", func(t *testing.T) {
+		m := new(mockReviewMongo)
+		svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+
+		err := svc.ReplyToReview(context.Background(), ReviewReplyParams{ReviewID: "r1", Comment: "   "})
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "invalid_request", appErr.Code)
+		m.AssertNotCalled(t, "GetReviewByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		m := new(mockReviewMongo)
+		svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+		m.On("GetReviewByID", mock.Anything, "r1").Return(&models.Review{ID: "r1"}, nil)
+		m.On("SetMerchantReply", mock.Anything, "r1", mock.Anything).Return(errors.New("db fail"))
+
+		err := svc.ReplyToReview(context.Background(), ReviewReplyParams{ReviewID: "r1", Comment: "Thanks"})
+		appErr := &handlers.AppError{}
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, "update_failed", appErr.Code)
+	})
+}
+
+// TestDeleteReviewReply_Service tests removing a merchant reply.
+func TestDeleteReviewReply_Service(t *testing.T) {
+	m := new(mockReviewMongo)
+	svc := NewReviewService(m, nil, ReviewPolicy{}, ReviewMediaConfig{}, RatingSummaryConfig{})
+	m.On("SetMerchantReply", mock.Anything, "r1", (*models.ReviewReply)(nil)).Return(nil)
+	m.On("SetMerchantReply", mock.Anything, "r2", (*models.ReviewReply)(nil)).Return(errors.New("review not found"))
+
+	require.NoError(t, svc.DeleteReviewReply(context.Background(), "r1"))
+	err := svc.DeleteReviewReply(context.Background(), "r2")
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "not_found", appErr.Code)
+	m.AssertExpectations(t)
 }
@@ -29,6 +29,13 @@ type ReviewService interface {
 	DeleteReviewByID(ctx context.Context, reviewID string) error
 	GetReviewsByProductIDPaginated(ctx context.Context, productID string, page, pageSize int, rating, minRating, maxRating *int, from, to *time.Time, hasMedia *bool, sort string) (any, error)
 	GetReviewsByUserIDPaginated(ctx context.Context, userID string, page, pageSize int, rating, minRating, maxRating *int, from, to *time.Time, hasMedia *bool, sort string) (any, error)
+	ListReviewsByStatus(ctx context.Context, status string, page, pageSize int) (any, error)
+	ModerateReview(ctx context.Context, params ModerateReviewParams) error
+	UploadReviewMedia(ctx context.Context, userID string, r *http.Request) (*models.ReviewMedia, error)
+	DeleteReviewMedia(ctx context.Context, userID, mediaID string) error
+	VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error
+	ReplyToReview(ctx context.Context, params ReviewReplyParams) error
+	DeleteReviewReply(ctx context.Context, reviewID string) error
 }
 
 // HandlersReviewConfig contains configuration and dependencies for review handlers.
@@ -88,15 +95,21 @@ func (cfg *HandlersReviewConfig) handleReviewError(w http.ResponseWriter, r *htt
 	var appErr *handlers.AppError
 	if errors.As(err, &appErr) {
 		switch appErr.Code {
-		case "not_found":
+		case "not_found", "product_not_found":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusNotFound, appErr.Message)
-		case "unauthorized":
+		case "unauthorized", "verified_purchase_required", "quota_exceeded":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusForbidden, appErr.Message)
 		case "invalid_request":
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message)
+		case "review_exists":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusConflict, appErr.Message)
+		case "file_too_large":
+			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
+			middlewares.RespondWithError(w, http.StatusRequestEntityTooLarge, appErr.Message)
 		default:
 			cfg.Logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
@@ -114,7 +127,7 @@ type ReviewCreateRequest struct {
 	ProductID string   `json:"product_id"`
 	Rating    int      `json:"rating"`
 	Comment   string   `json:"comment"`
-	MediaURLs []string `json:"media_urls,omitempty"`
+	MediaIDs  []string `json:"media_ids,omitempty"`
 }
 
 // PaginatedReviewsResponse is the response for paginated review lists.
@@ -125,7 +138,7 @@ type ReviewCreateRequest struct {
 //   - min_rating, max_rating: rating range
 //   - from, to: created_at date range (RFC3339)
 //   - has_media: true/false (reviews with media)
-//   - sort: date_desc, date_asc, rating_desc, rating_asc, updated_desc, updated_asc, comment_length_desc, comment_length_asc
+//   - sort: date_desc, date_asc, rating_desc, rating_asc, updated_desc, updated_asc, comment_length_desc, comment_length_asc, helpful_desc
 type PaginatedReviewsResponse struct {
 	Data       any    `json:"data"`
 	TotalCount int64  `json:"totalCount"`
@@ -138,11 +151,26 @@ type PaginatedReviewsResponse struct {
 	Message    string `json:"message,omitempty"`
 }
 
+// ReviewModerationRequest is the DTO for rejecting or hiding a review.
+type ReviewModerationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReviewVoteRequest is the DTO for voting a review helpful or unhelpful.
+type ReviewVoteRequest struct {
+	Helpful *bool `json:"helpful"`
+}
+
+// ReviewReplyRequest is the DTO for the store's reply to a review.
+type ReviewReplyRequest struct {
+	Comment string `json:"comment"`
+}
+
 // ReviewUpdateRequest is the DTO for updating a review.
 // Contains fields that can be updated for an existing review.
 // Validation: Rating 1-5, Comment required
 type ReviewUpdateRequest struct {
-	Rating    int      `json:"rating"`
-	Comment   string   `json:"comment"`
-	MediaURLs []string `json:"media_urls,omitempty"`
+	Rating   int      `json:"rating"`
+	Comment  string   `json:"comment"`
+	MediaIDs []string `json:"media_ids,omitempty"`
 }
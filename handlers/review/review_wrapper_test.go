@@ -58,6 +58,9 @@ func TestHandleReviewError_AppErrorCodes(t *testing.T) {
 		{"not_found", "not_found", http.StatusNotFound},
 		{"unauthorized", "unauthorized", http.StatusForbidden},
 		{"invalid_request", "invalid_request", http.StatusBadRequest},
+		{"product_not_found", "product_not_found", http.StatusNotFound},
+		{"verified_purchase_required", "verified_purchase_required", http.StatusForbidden},
+		{"review_exists", "review_exists", http.StatusConflict},
 		{"default", "other", http.StatusInternalServerError},
 	}
 	for _, tc := range cases {
@@ -40,7 +40,7 @@ func handleUpdateProductImageByID(
 	w http.ResponseWriter,
 	r *http.Request,
 	user database.User,
-	serviceUpdate func(ctx context.Context, userID string, r *http.Request) (string, error),
+	serviceUpdate func(ctx context.Context, userID string, r *http.Request) (UploadedImage, error),
 	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
 	logger handlers.HandlerLogger,
 	operation, logMsg, respMsg string,
@@ -62,7 +62,7 @@ func handleUpdateProductImageByID(
 	}
 
 	// Wrap the serviceUpdate to inject productID
-	wrappedServiceUpdate := func(ctx context.Context, userID string, r *http.Request) (string, error) {
+	wrappedServiceUpdate := func(ctx context.Context, userID string, r *http.Request) (UploadedImage, error) {
 		return serviceUpdate(ctx, userID, r)
 	}
 
@@ -91,7 +91,7 @@ func handleUpdateProductImageByID(
 func (cfg *HandlersUploadConfig) HandlerUpdateProductImageByID(w http.ResponseWriter, r *http.Request, user database.User) {
 	handleUpdateProductImageByID(
 		w, r, user,
-		func(ctx context.Context, userID string, r *http.Request) (string, error) {
+		func(ctx context.Context, userID string, r *http.Request) (UploadedImage, error) {
 			productID := chiURLParam(r, "id")
 			return cfg.Service.UpdateProductImage(ctx, productID, userID, r)
 		},
@@ -108,7 +108,7 @@ func handleProductImageUpload(
 	w http.ResponseWriter,
 	r *http.Request,
 	user database.User,
-	serviceUpload func(ctx context.Context, userID string, r *http.Request) (string, error),
+	serviceUpload func(ctx context.Context, userID string, r *http.Request) (UploadedImage, error),
 	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
 	logger handlers.HandlerLogger,
 	operation, logMsg, respMsg string,
@@ -119,7 +119,7 @@ func handleProductImageUpload(
 	const maxUploadSize = 10 << 20 // 10 MB
 	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
 
-	imageURL, err := serviceUpload(ctx, user.ID, r)
+	uploaded, err := serviceUpload(ctx, user.ID, r)
 	if err != nil {
 		handleUploadError(w, r, err, operation, ip, userAgent)
 		return
@@ -129,7 +129,8 @@ func handleProductImageUpload(
 	logger.LogHandlerSuccess(ctxWithUserID, operation, logMsg, ip, userAgent)
 
 	middlewares.RespondWithJSON(w, http.StatusOK, imageUploadResponse{
-		Message:  respMsg,
-		ImageURL: imageURL,
+		Message:    respMsg,
+		ImageURL:   uploaded.ImageURL,
+		Renditions: uploaded.Renditions,
 	})
 }
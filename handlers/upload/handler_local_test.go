@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
 )
 
 // handler_local_test.go: Tests upload and update product image handlers for success, error, and missing ID cases, verifying correct HTTP responses and proper logging behavior.
@@ -44,11 +45,14 @@ func TestHandlerUploadProductImage_Scenarios(t *testing.T) {
 				cfg.(*HandlersUploadConfig).HandlerUploadProductImage(w, r, user)
 			},
 			mockSetup: func(service, logger *mock.Mock, req *http.Request, user database.User) {
-				service.On("UploadProductImage", req.Context(), user.ID, req).Return("/static/test.jpg", nil)
+				service.On("UploadProductImage", req.Context(), user.ID, req).Return(UploadedImage{
+					ImageURL:   "/static/test.jpg",
+					Renditions: models.ImageRenditions{Thumbnail: "/static/test_thumbnail.jpg"},
+				}, nil)
 				logger.On("LogHandlerSuccess", mock.Anything, "upload_product_image", "Image uploaded successfully and URL generated", mock.Anything, mock.Anything).Return()
 			},
 			expectedCode: http.StatusOK,
-			expectedBody: "/static/test.jpg",
+			expectedBody: `"thumbnail":"/static/test_thumbnail.jpg"`,
 		},
 		{
 			name: "Local_Error",
@@ -66,7 +70,7 @@ func TestHandlerUploadProductImage_Scenarios(t *testing.T) {
 			},
 			mockSetup: func(service, logger *mock.Mock, req *http.Request, user database.User) {
 				err := errors.New("upload failed")
-				service.On("UploadProductImage", req.Context(), user.ID, req).Return("", err)
+				service.On("UploadProductImage", req.Context(), user.ID, req).Return(UploadedImage{}, err)
 				logger.On("LogHandlerError", mock.Anything, "upload_product_image", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, err).Return()
 			},
 			expectedCode: http.StatusInternalServerError,
@@ -87,7 +91,7 @@ func TestHandlerUploadProductImage_Scenarios(t *testing.T) {
 				cfg.(*HandlersUploadS3Config).HandlerS3UploadProductImage(w, r, user)
 			},
 			mockSetup: func(service, logger *mock.Mock, req *http.Request, user database.User) {
-				service.On("UploadProductImage", req.Context(), user.ID, req).Return("https://s3/test.jpg", nil)
+				service.On("UploadProductImage", req.Context(), user.ID, req).Return(UploadedImage{ImageURL: "https://s3/test.jpg"}, nil)
 				logger.On("LogHandlerSuccess", mock.Anything, "s3_upload_product_image", "Image uploaded to S3 and URL generated", mock.Anything, mock.Anything).Return()
 			},
 			expectedCode: http.StatusOK,
@@ -109,7 +113,7 @@ func TestHandlerUploadProductImage_Scenarios(t *testing.T) {
 			},
 			mockSetup: func(service, logger *mock.Mock, req *http.Request, user database.User) {
 				err := errors.New("upload failed")
-				service.On("UploadProductImage", req.Context(), user.ID, req).Return("", err)
+				service.On("UploadProductImage", req.Context(), user.ID, req).Return(UploadedImage{}, err)
 				logger.On("LogHandlerError", mock.Anything, "s3_upload_product_image", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, err).Return()
 			},
 			expectedCode: http.StatusInternalServerError,
@@ -141,7 +145,7 @@ func TestHandlerUpdateProductImageByID_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	req = req.WithContext(context.WithValue(req.Context(), contextKey("chi.URLParams"), map[string]string{"id": testProductID}))
-	mockService.On("UpdateProductImage", req.Context(), testProductID, user.ID, req).Return("/static/updated.jpg", nil)
+	mockService.On("UpdateProductImage", req.Context(), testProductID, user.ID, req).Return(UploadedImage{ImageURL: "/static/updated.jpg"}, nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "update_product_image", "Product image updated", mock.Anything, mock.Anything).Return()
 
 	// Patch chi.URLParam for test
@@ -196,7 +200,7 @@ func TestHandlerUpdateProductImageByID_Error(t *testing.T) {
 
 	req = req.WithContext(context.WithValue(req.Context(), contextKey("chi.URLParams"), map[string]string{"id": testProductID}))
 	err := errors.New("update failed")
-	mockService.On("UpdateProductImage", req.Context(), testProductID, user.ID, req).Return("", err)
+	mockService.On("UpdateProductImage", req.Context(), testProductID, user.ID, req).Return(UploadedImage{}, err)
 	mockLogger.On("LogHandlerError", mock.Anything, "update_product_image", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, err).Return()
 
 	// Patch chi.URLParam for test
@@ -0,0 +1,196 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// handler_product_images.go: Handles the product image gallery (adding, reordering, choosing the primary image, and deleting)
+// for both the local and S3 backends through shared helpers.
+
+// HandlerAddProductImage handles HTTP POST requests to add an image to a product's gallery (local storage).
+// @Summary      Add product image
+// @Description  Uploads an image and appends it to the product's gallery (admin only). The first image, or one sent with is_primary=true, becomes the primary image shown as the product's image_url
+// @Tags         products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id          path      string  true   "Product ID"
+// @Param        image       formData  file    true   "Product image file"
+// @Param        alt_text    formData  string  false  "Alt text"
+// @Param        is_primary  formData  bool    false  "Make this the primary image"
+// @Success      201  {object}  models.ProductImage
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/products/{id}/images [post]
+func (cfg *HandlersUploadConfig) HandlerAddProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleAddProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "add_product_image")
+}
+
+// HandlerReorderProductImages handles HTTP PUT requests to reorder a product's gallery (local storage).
+// @Summary      Reorder product images
+// @Description  Sets the display order of a product's gallery (admin only). image_ids must list every image of the product exactly once
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string                       true  "Product ID"
+// @Param        order  body  ReorderProductImagesRequest  true  "Image IDs in display order"
+// @Success      200  {array}   models.ProductImage
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/products/{id}/images/order [put]
+func (cfg *HandlersUploadConfig) HandlerReorderProductImages(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleReorderProductImages(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "reorder_product_images")
+}
+
+// HandlerSetPrimaryProductImage handles HTTP PUT requests to choose a product's primary image (local storage).
+// @Summary      Set primary product image
+// @Description  Makes a gallery image the product's primary image and its image_url (admin only)
+// @Tags         products
+// @Produce      json
+// @Param        id        path  string  true  "Product ID"
+// @Param        image_id  path  string  true  "Image ID"
+// @Success      200  {array}   models.ProductImage
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/products/{id}/images/{image_id}/primary [put]
+func (cfg *HandlersUploadConfig) HandlerSetPrimaryProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleSetPrimaryProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "set_primary_product_image")
+}
+
+// HandlerDeleteProductImage handles HTTP DELETE requests to remove an image from a product's gallery (local storage).
+// @Summary      Delete product image
+// @Description  Deletes a gallery image and its file (admin only). Deleting the primary image promotes the next image in order
+// @Tags         products
+// @Produce      json
+// @Param        id        path  string  true  "Product ID"
+// @Param        image_id  path  string  true  "Image ID"
+// @Success      200  {object}  handlers.HandlerResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/products/{id}/images/{image_id} [delete]
+func (cfg *HandlersUploadConfig) HandlerDeleteProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleDeleteProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "delete_product_image")
+}
+
+// handleAddProductImage is a shared helper that adds an uploaded image to a product's gallery for both local and S3 uploads.
+func handleAddProductImage(
+	w http.ResponseWriter,
+	r *http.Request,
+	user database.User,
+	service UploadService,
+	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
+	logger handlers.HandlerLogger,
+	operation string,
+) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	const maxUploadSize = 10 << 20 // 10 MB
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	image, err := service.AddProductImage(ctx, chiURLParam(r, "id"), r)
+	if err != nil {
+		handleUploadError(w, r, err, operation, ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	logger.LogHandlerSuccess(ctxWithUserID, operation, "Product image added", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusCreated, image)
+}
+
+// handleReorderProductImages is a shared helper that reorders a product's gallery for both local and S3 uploads.
+func handleReorderProductImages(
+	w http.ResponseWriter,
+	r *http.Request,
+	user database.User,
+	service UploadService,
+	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
+	logger handlers.HandlerLogger,
+	operation string,
+) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	var params ReorderProductImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.LogHandlerError(
+			ctx,
+			operation,
+			"invalid_request",
+			"Invalid request payload",
+			ip, userAgent, err,
+		)
+		middlewares.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	images, err := service.ReorderProductImages(ctx, chiURLParam(r, "id"), params.ImageIDs)
+	if err != nil {
+		handleUploadError(w, r, err, operation, ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	logger.LogHandlerSuccess(ctxWithUserID, operation, "Product images reordered", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, images)
+}
+
+// handleSetPrimaryProductImage is a shared helper that chooses a product's primary image for both local and S3 uploads.
+func handleSetPrimaryProductImage(
+	w http.ResponseWriter,
+	r *http.Request,
+	user database.User,
+	service UploadService,
+	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
+	logger handlers.HandlerLogger,
+	operation string,
+) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	images, err := service.SetPrimaryProductImage(ctx, chiURLParam(r, "id"), chiURLParam(r, "image_id"))
+	if err != nil {
+		handleUploadError(w, r, err, operation, ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	logger.LogHandlerSuccess(ctxWithUserID, operation, "Primary product image set", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, images)
+}
+
+// handleDeleteProductImage is a shared helper that removes an image from a product's gallery for both local and S3 uploads.
+func handleDeleteProductImage(
+	w http.ResponseWriter,
+	r *http.Request,
+	user database.User,
+	service UploadService,
+	handleUploadError func(http.ResponseWriter, *http.Request, error, string, string, string),
+	logger handlers.HandlerLogger,
+	operation string,
+) {
+	ip, userAgent := handlers.GetRequestMetadata(r)
+	ctx := r.Context()
+
+	err := service.DeleteProductImage(ctx, chiURLParam(r, "id"), chiURLParam(r, "image_id"))
+	if err != nil {
+		handleUploadError(w, r, err, operation, ip, userAgent)
+		return
+	}
+
+	ctxWithUserID := context.WithValue(ctx, utils.ContextKeyUserID, user.ID)
+	logger.LogHandlerSuccess(ctxWithUserID, operation, "Product image deleted", ip, userAgent)
+
+	middlewares.RespondWithJSON(w, http.StatusOK, handlers.HandlerResponse{
+		Message: "Product image deleted successfully",
+	})
+}
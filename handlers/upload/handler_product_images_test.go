@@ -0,0 +1,220 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// handler_product_images_test.go: Tests the product image gallery handlers (local and S3) for success and error responses and logging.
+
+// galleryHandlerConfigs returns a local and an S3 handler config sharing the given service and logger, keyed by operation prefix.
+func galleryHandlerConfigs(service UploadService, logger handlers.HandlerLogger) map[string]struct {
+	add, reorder, setPrimary, remove func(http.ResponseWriter, *http.Request, database.User)
+} {
+	local := &HandlersUploadConfig{Logger: logger, Service: service}
+	s3 := &HandlersUploadS3Config{Logger: logger, Service: service}
+	return map[string]struct {
+		add, reorder, setPrimary, remove func(http.ResponseWriter, *http.Request, database.User)
+	}{
+		"":    {local.HandlerAddProductImage, local.HandlerReorderProductImages, local.HandlerSetPrimaryProductImage, local.HandlerDeleteProductImage},
+		"s3_": {s3.HandlerS3AddProductImage, s3.HandlerS3ReorderProductImages, s3.HandlerS3SetPrimaryProductImage, s3.HandlerS3DeleteProductImage},
+	}
+}
+
+// patchGalleryURLParams makes chiURLParam return the product and image IDs for the duration of the test.
+func patchGalleryURLParams(t *testing.T, productID, imageID string) {
+	oldURLParam := chiURLParam
+	chiURLParam = func(_ *http.Request, key string) string {
+		if key == "image_id" {
+			return imageID
+		}
+		return productID
+	}
+	t.Cleanup(func() { chiURLParam = oldURLParam })
+}
+
+// TestHandlerAddProductImage tests that adding an image responds 201 with the image, and maps service errors.
+func TestHandlerAddProductImage(t *testing.T) {
+	patchGalleryURLParams(t, testProductID, "")
+	user := database.User{ID: "admin1"}
+
+	for prefix := range galleryHandlerConfigs(nil, nil) {
+		t.Run("success_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].add
+			req := httptest.NewRequest("POST", "/v1/products/prod123/images", nil)
+			w := httptest.NewRecorder()
+
+			image := models.ProductImage{ID: "img1", ImageURL: "/static/a.png", IsPrimary: true}
+			mockService.On("AddProductImage", mock.Anything, testProductID, mock.Anything).Return(image, nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, prefix+"add_product_image", "Product image added", mock.Anything, mock.Anything).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Contains(t, w.Body.String(), `"image_url":"/static/a.png"`)
+			assert.Contains(t, w.Body.String(), `"is_primary":true`)
+			mockService.AssertExpectations(t)
+			mockLogger.AssertExpectations(t)
+		})
+
+		t.Run("not_found_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].add
+			req := httptest.NewRequest("POST", "/v1/products/prod123/images", nil)
+			w := httptest.NewRecorder()
+
+			appErr := &handlers.AppError{Code: "not_found", Message: "Product not found"}
+			mockService.On("AddProductImage", mock.Anything, testProductID, mock.Anything).Return(models.ProductImage{}, appErr)
+			mockLogger.On("LogHandlerError", mock.Anything, prefix+"add_product_image", "not_found", "Product not found", mock.Anything, mock.Anything, nil).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			mockLogger.AssertExpectations(t)
+		})
+	}
+}
+
+// TestHandlerReorderProductImages tests that reordering passes the image IDs through, and rejects malformed bodies.
+func TestHandlerReorderProductImages(t *testing.T) {
+	patchGalleryURLParams(t, testProductID, "")
+	user := database.User{ID: "admin1"}
+
+	for prefix := range galleryHandlerConfigs(nil, nil) {
+		t.Run("success_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].reorder
+			req := httptest.NewRequest("PUT", "/v1/products/prod123/images/order", strings.NewReader(`{"image_ids":["b","a"]}`))
+			w := httptest.NewRecorder()
+
+			images := []models.ProductImage{{ID: "b", Position: 0}, {ID: "a", Position: 1, IsPrimary: true}}
+			mockService.On("ReorderProductImages", mock.Anything, testProductID, []string{"b", "a"}).Return(images, nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, prefix+"reorder_product_images", "Product images reordered", mock.Anything, mock.Anything).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"id":"b"`)
+			mockService.AssertExpectations(t)
+		})
+
+		t.Run("invalid_payload_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].reorder
+			req := httptest.NewRequest("PUT", "/v1/products/prod123/images/order", strings.NewReader(`{"image_ids":`))
+			w := httptest.NewRecorder()
+
+			mockLogger.On("LogHandlerError", mock.Anything, prefix+"reorder_product_images", "invalid_request", "Invalid request payload", mock.Anything, mock.Anything, mock.Anything).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "ReorderProductImages", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		t.Run("invalid_ids_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].reorder
+			req := httptest.NewRequest("PUT", "/v1/products/prod123/images/order", strings.NewReader(`{"image_ids":["a"]}`))
+			w := httptest.NewRecorder()
+
+			appErr := &handlers.AppError{Code: "invalid_request", Message: "image_ids must list every image of the product exactly once"}
+			mockService.On("ReorderProductImages", mock.Anything, testProductID, []string{"a"}).Return(nil, appErr)
+			mockLogger.On("LogHandlerError", mock.Anything, prefix+"reorder_product_images", "invalid_request", appErr.Message, mock.Anything, mock.Anything, nil).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "exactly once")
+		})
+	}
+}
+
+// TestHandlerSetPrimaryProductImage tests that choosing the primary image returns the gallery, and maps unknown images to 404.
+func TestHandlerSetPrimaryProductImage(t *testing.T) {
+	patchGalleryURLParams(t, testProductID, "img2")
+	user := database.User{ID: "admin1"}
+
+	for prefix := range galleryHandlerConfigs(nil, nil) {
+		t.Run("success_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].setPrimary
+			req := httptest.NewRequest("PUT", "/v1/products/prod123/images/img2/primary", nil)
+			w := httptest.NewRecorder()
+
+			mockService.On("SetPrimaryProductImage", mock.Anything, testProductID, "img2").Return([]models.ProductImage{{ID: "img2", IsPrimary: true}}, nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, prefix+"set_primary_product_image", "Primary product image set", mock.Anything, mock.Anything).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockService.AssertExpectations(t)
+		})
+
+		t.Run("image_not_found_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].setPrimary
+			req := httptest.NewRequest("PUT", "/v1/products/prod123/images/img2/primary", nil)
+			w := httptest.NewRecorder()
+
+			appErr := &handlers.AppError{Code: "image_not_found", Message: "Product image not found"}
+			mockService.On("SetPrimaryProductImage", mock.Anything, testProductID, "img2").Return(nil, appErr)
+			mockLogger.On("LogHandlerError", mock.Anything, prefix+"set_primary_product_image", "image_not_found", "Product image not found", mock.Anything, mock.Anything, nil).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		})
+	}
+}
+
+// TestHandlerDeleteProductImage tests that deleting an image responds with a message, and maps service failures to 500.
+func TestHandlerDeleteProductImage(t *testing.T) {
+	patchGalleryURLParams(t, testProductID, "img2")
+	user := database.User{ID: "admin1"}
+
+	for prefix := range galleryHandlerConfigs(nil, nil) {
+		t.Run("success_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].remove
+			req := httptest.NewRequest("DELETE", "/v1/products/prod123/images/img2", nil)
+			w := httptest.NewRecorder()
+
+			mockService.On("DeleteProductImage", mock.Anything, testProductID, "img2").Return(nil)
+			mockLogger.On("LogHandlerSuccess", mock.Anything, prefix+"delete_product_image", "Product image deleted", mock.Anything, mock.Anything).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), "Product image deleted successfully")
+			mockService.AssertExpectations(t)
+		})
+
+		t.Run("error_"+prefix, func(t *testing.T) {
+			mockService := new(mockUploadService)
+			mockLogger := new(mockLogger)
+			handler := galleryHandlerConfigs(mockService, mockLogger)[prefix].remove
+			req := httptest.NewRequest("DELETE", "/v1/products/prod123/images/img2", nil)
+			w := httptest.NewRecorder()
+
+			err := errors.New("boom")
+			mockService.On("DeleteProductImage", mock.Anything, testProductID, "img2").Return(err)
+			mockLogger.On("LogHandlerError", mock.Anything, prefix+"delete_product_image", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, err).Return()
+
+			handler(w, req, user)
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+		})
+	}
+}
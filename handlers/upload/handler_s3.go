@@ -27,7 +27,7 @@ func (cfg *HandlersUploadS3Config) HandlerS3UploadProductImage(w http.ResponseWr
 func (cfg *HandlersUploadS3Config) HandlerS3UpdateProductImageByID(w http.ResponseWriter, r *http.Request, user database.User) {
 	handleUpdateProductImageByID(
 		w, r, user,
-		func(ctx context.Context, userID string, r *http.Request) (string, error) {
+		func(ctx context.Context, userID string, r *http.Request) (UploadedImage, error) {
 			productID := chiURLParam(r, "id")
 			return cfg.Service.UpdateProductImage(ctx, productID, userID, r)
 		},
@@ -38,3 +38,23 @@ func (cfg *HandlersUploadS3Config) HandlerS3UpdateProductImageByID(w http.Respon
 		"Product image updated successfully (S3)",
 	)
 }
+
+// HandlerS3AddProductImage handles HTTP POST requests to add an image to a product's gallery in S3 storage.
+func (cfg *HandlersUploadS3Config) HandlerS3AddProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleAddProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "s3_add_product_image")
+}
+
+// HandlerS3ReorderProductImages handles HTTP PUT requests to reorder a product's gallery in S3 storage.
+func (cfg *HandlersUploadS3Config) HandlerS3ReorderProductImages(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleReorderProductImages(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "s3_reorder_product_images")
+}
+
+// HandlerS3SetPrimaryProductImage handles HTTP PUT requests to choose a product's primary image in S3 storage.
+func (cfg *HandlersUploadS3Config) HandlerS3SetPrimaryProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleSetPrimaryProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "s3_set_primary_product_image")
+}
+
+// HandlerS3DeleteProductImage handles HTTP DELETE requests to remove an image from a product's gallery in S3 storage.
+func (cfg *HandlersUploadS3Config) HandlerS3DeleteProductImage(w http.ResponseWriter, r *http.Request, user database.User) {
+	handleDeleteProductImage(w, r, user, cfg.Service, cfg.handleUploadError, cfg.Logger, "s3_delete_product_image")
+}
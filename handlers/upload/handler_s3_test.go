@@ -32,7 +32,7 @@ func TestHandlerS3UpdateProductImageByID_Success(t *testing.T) {
 	req := httptest.NewRequest("POST", "/update/123", nil)
 	w := httptest.NewRecorder()
 
-	mockService.On("UpdateProductImage", req.Context(), "prod123", user.ID, req).Return("https://s3/updated.jpg", nil)
+	mockService.On("UpdateProductImage", req.Context(), "prod123", user.ID, req).Return(UploadedImage{ImageURL: "https://s3/updated.jpg"}, nil)
 	mockLogger.On("LogHandlerSuccess", mock.Anything, "s3_update_product_image", "Product image updated in S3", mock.Anything, mock.Anything).Return()
 
 	cfg.HandlerS3UpdateProductImageByID(w, req, user)
@@ -86,7 +86,7 @@ func TestHandlerS3UpdateProductImageByID_Error(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	err := errors.New("update failed")
-	mockService.On("UpdateProductImage", req.Context(), "prod123", user.ID, req).Return("", err)
+	mockService.On("UpdateProductImage", req.Context(), "prod123", user.ID, req).Return(UploadedImage{}, err)
 	mockLogger.On("LogHandlerError", mock.Anything, "s3_update_product_image", "unknown_error", "Unknown error occurred", mock.Anything, mock.Anything, err).Return()
 
 	cfg.HandlerS3UpdateProductImageByID(w, req, user)
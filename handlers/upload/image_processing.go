@@ -0,0 +1,429 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// image_processing.go: Verifies uploaded images by their content, strips their metadata, and renders the resized
+// renditions (thumbnail, medium, large) served alongside the original. Pure Go, so it needs no cgo or system libraries.
+
+const (
+	// maxImagePixels caps width*height, checked from the image header before the pixels are decoded.
+	// For a GIF it also caps the pixels of all frames together.
+	maxImagePixels = 40_000_000
+	// maxGIFFrames caps the number of frames in an animated GIF.
+	maxGIFFrames = 300
+
+	// Longest edge of each rendition in pixels. Smaller images are never upscaled.
+	thumbnailMaxEdge = 160
+	mediumMaxEdge    = 640
+	largeMaxEdge     = 1280
+
+	originalJPEGQuality  = 90
+	renditionJPEGQuality = 85
+
+	// exifOrientationTag is the EXIF tag holding how a JPEG must be rotated or flipped for display.
+	exifOrientationTag = 0x0112
+
+	// VP8X flag bits announcing EXIF and XMP chunks in a WebP file.
+	webpExifFlag = 0x08
+	webpXMPFlag  = 0x04
+)
+
+var (
+	// errInvalidImage reports content that is not a decodable JPEG, PNG, GIF, or WebP image.
+	errInvalidImage = errors.New("invalid image")
+	// errImageTooLarge reports an image whose dimensions exceed maxImagePixels, or a GIF with too many frames.
+	errImageTooLarge = errors.New("image too large")
+)
+
+// sniffedImageFormats maps the content types accepted by sniffing to the decoder name and file extension of each format.
+var sniffedImageFormats = map[string]struct{ format, ext string }{
+	"image/jpeg": {format: "jpeg", ext: ".jpg"},
+	"image/png":  {format: "png", ext: ".png"},
+	"image/gif":  {format: "gif", ext: ".gif"},
+	"image/webp": {format: "webp", ext: ".webp"},
+}
+
+// encodedImage is an image ready to be stored, with the extension and content type matching its bytes.
+type encodedImage struct {
+	data        []byte
+	ext         string
+	contentType string
+}
+
+// processedImage holds a metadata-free copy of an upload and its resized renditions.
+type processedImage struct {
+	original  encodedImage
+	thumbnail encodedImage
+	medium    encodedImage
+	large     encodedImage
+}
+
+// processImage checks an upload by its actual bytes and prepares everything that gets stored for it.
+// The content is sniffed and must decode as the sniffed format, regardless of the declared MIME type or extension.
+// The original is re-encoded (or, for WebP, rewritten without its metadata chunks) so EXIF data such as GPS
+// coordinates never reaches storage; a JPEG's EXIF orientation is applied to its pixels first.
+// Returns errInvalidImage or errImageTooLarge (wrapped) when the upload is rejected.
+func processImage(data []byte) (processedImage, error) {
+	contentType := http.DetectContentType(data)
+	format, ok := sniffedImageFormats[contentType]
+	if !ok {
+		return processedImage{}, fmt.Errorf("%w: unsupported content type %s", errInvalidImage, contentType)
+	}
+
+	config, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return processedImage{}, fmt.Errorf("%w: %v", errInvalidImage, err)
+	}
+	if name != format.format {
+		return processedImage{}, fmt.Errorf("%w: content sniffed as %s but decodes as %s", errInvalidImage, contentType, name)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return processedImage{}, fmt.Errorf("%w: empty image", errInvalidImage)
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return processedImage{}, fmt.Errorf("%w: %dx%d pixels", errImageTooLarge, config.Width, config.Height)
+	}
+
+	original, img, err := stripImageMetadata(data, format.format)
+	if err != nil {
+		return processedImage{}, err
+	}
+	original.ext = format.ext
+	original.contentType = contentType
+
+	result := processedImage{original: original}
+	for _, rendition := range []struct {
+		maxEdge int
+		dst     *encodedImage
+	}{
+		{thumbnailMaxEdge, &result.thumbnail},
+		{mediumMaxEdge, &result.medium},
+		{largeMaxEdge, &result.large},
+	} {
+		*rendition.dst, err = encodeRendition(resizeToFit(img, rendition.maxEdge))
+		if err != nil {
+			return processedImage{}, err
+		}
+	}
+	return result, nil
+}
+
+// stripImageMetadata returns the image without its metadata, along with the decoded image used for the renditions.
+// The returned encodedImage only carries data; the caller sets its extension and content type.
+func stripImageMetadata(data []byte, format string) (encodedImage, image.Image, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return encodedImage{}, nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: originalJPEGQuality}); err != nil {
+			return encodedImage{}, nil, err
+		}
+		return encodedImage{data: buf.Bytes()}, img, nil
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return encodedImage{}, nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+		}
+		if err := png.Encode(&buf, img); err != nil {
+			return encodedImage{}, nil, err
+		}
+		return encodedImage{data: buf.Bytes()}, img, nil
+	case "gif":
+		// Re-encoding keeps every frame of an animation but drops comment and application extensions.
+		// Every frame is held in memory at once, so the frames are counted before any of them is decoded.
+		frames, pixels, err := gifFrameStats(data)
+		if err != nil {
+			return encodedImage{}, nil, err
+		}
+		if frames > maxGIFFrames || pixels > maxImagePixels {
+			return encodedImage{}, nil, fmt.Errorf("%w: %d frames, %d pixels", errImageTooLarge, frames, pixels)
+		}
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return encodedImage{}, nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+		}
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return encodedImage{}, nil, err
+		}
+		return encodedImage{data: buf.Bytes()}, firstGIFFrame(anim), nil
+	case "webp":
+		// There is no pure Go WebP encoder, so the file is kept as is minus its metadata chunks
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return encodedImage{}, nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+		}
+		stripped, err := stripWebPMetadata(data)
+		if err != nil {
+			return encodedImage{}, nil, err
+		}
+		return encodedImage{data: stripped}, img, nil
+	default:
+		return encodedImage{}, nil, fmt.Errorf("%w: unsupported format %s", errInvalidImage, format)
+	}
+}
+
+// gifFrameStats walks the blocks of a GIF without decoding any pixels and returns its number of frames and the
+// pixels of all its frames together. A file that ends early is left for the decoder to reject.
+func gifFrameStats(data []byte) (frames int, pixels int64, err error) {
+	const headerLen = 13 // signature, version, and logical screen descriptor
+	if len(data) < headerLen {
+		return 0, 0, fmt.Errorf("%w: truncated GIF header", errInvalidImage)
+	}
+	pos := headerLen
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1) // global color table
+	}
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: label, then data sub-blocks
+			pos = skipGIFSubBlocks(data, pos+2)
+		case 0x2C: // image descriptor: position, size, flags, then the LZW code size and data sub-blocks
+			if pos+10 > len(data) {
+				return frames, pixels, nil
+			}
+			width := binary.LittleEndian.Uint16(data[pos+5:])
+			height := binary.LittleEndian.Uint16(data[pos+7:])
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1) // local color table
+			}
+			frames++
+			pixels += int64(width) * int64(height)
+			pos = skipGIFSubBlocks(data, pos+1)
+		case 0x3B: // trailer
+			return frames, pixels, nil
+		default:
+			return 0, 0, fmt.Errorf("%w: unknown GIF block 0x%02x", errInvalidImage, data[pos])
+		}
+	}
+	return frames, pixels, nil
+}
+
+// skipGIFSubBlocks returns the position after the data sub-blocks starting at pos, or len(data) if they run past it.
+func skipGIFSubBlocks(data []byte, pos int) int {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos
+		}
+		pos += size
+	}
+	return len(data)
+}
+
+// firstGIFFrame draws the first frame of a GIF onto a canvas of the GIF's full size.
+func firstGIFFrame(anim *gif.GIF) image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	frame := anim.Image[0]
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas
+}
+
+// resizeToFit scales img down so its longest edge is at most maxEdge, keeping its aspect ratio.
+// Images that already fit are returned unchanged.
+func resizeToFit(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxEdge && height <= maxEdge {
+		return img
+	}
+	if width >= height {
+		width, height = maxEdge, max(1, height*maxEdge/width)
+	} else {
+		width, height = max(1, width*maxEdge/height), maxEdge
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	return dst
+}
+
+// encodeRendition encodes opaque images as JPEG and images with transparency as PNG.
+func encodeRendition(img image.Image) (encodedImage, error) {
+	var buf bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: renditionJPEGQuality}); err != nil {
+			return encodedImage{}, err
+		}
+		return encodedImage{data: buf.Bytes(), ext: ".jpg", contentType: "image/jpeg"}, nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return encodedImage{}, err
+	}
+	return encodedImage{data: buf.Bytes(), ext: ".png", contentType: "image/png"}, nil
+}
+
+// applyOrientation rotates and flips img as described by an EXIF orientation value (1-8).
+// Orientation 1 and unknown values return img unchanged.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := range dstHeight {
+		for x := range dstWidth {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = width-1-x, y
+			case 3: // rotated 180°
+				sx, sy = width-1-x, height-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, height-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs a 90° clockwise rotation
+				sx, sy = y, height-1-x
+			case 7: // transversed
+				sx, sy = width-1-y, height-1-x
+			case 8: // needs a 90° counter-clockwise rotation
+				sx, sy = width-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 (upright) if it has none.
+// Only the segments before the image data are scanned.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // markers without a length
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9: // start of scan or end of image: no metadata follows
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF-formatted EXIF block.
+// Returns 1 if the tag is missing or the block is malformed.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := range count {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		return 1
+	}
+	return 1
+}
+
+// stripWebPMetadata removes the EXIF and XMP chunks of a WebP file and clears their flags in the VP8X header.
+// Image data chunks are copied byte for byte.
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: missing WebP header", errInvalidImage)
+	}
+	if riffEnd := 8 + int(binary.LittleEndian.Uint32(data[4:8])); riffEnd < len(data) {
+		data = data[:riffEnd]
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk header", errInvalidImage)
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		if i+8+size > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP %q chunk", errInvalidImage, fourCC)
+		}
+		// Chunks are padded to an even size
+		end := min(i+8+size+size&1, len(data))
+		chunk := data[i:end]
+		i = end
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			if size > 0 {
+				chunk = bytes.Clone(chunk)
+				chunk[8] &^= webpExifFlag | webpXMPFlag
+			}
+		}
+		out = append(out, chunk...)
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
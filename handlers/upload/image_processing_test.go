@@ -0,0 +1,284 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// image_processing_test.go: Tests for upload image processing: content sniffing and decoding, the pixel limit, metadata stripping
+// for each format, EXIF orientation, and rendition sizes and encodings.
+
+// oversizedGIF is a GIF header announcing 8000x8000 pixels, over maxImagePixels, without any pixel data.
+var oversizedGIF = []byte("GIF89a\x40\x1f\x40\x1f\x00\x00\x00")
+
+// gifWithFrames builds a GIF whose screen and frames are width x height, with frame headers and empty pixel data, so
+// it is small however many pixels it announces.
+func gifWithFrames(width, height uint16, frames int) []byte {
+	data := []byte("GIF89a")
+	data = binary.LittleEndian.AppendUint16(data, width)
+	data = binary.LittleEndian.AppendUint16(data, height)
+	data = append(data, 0x00, 0x00, 0x00) // no global color table
+	for range frames {
+		data = append(data, 0x2C, 0, 0, 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, width)
+		data = binary.LittleEndian.AppendUint16(data, height)
+		data = append(data, 0x00, 0x02, 0x00) // no local color table, LZW code size 2, no data
+	}
+	return append(data, 0x3B)
+}
+
+// tinyWebP is a 1x1 transparent lossless WebP.
+var tinyWebP = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+// decodeEncoded decodes an encodedImage and checks that its content type matches its bytes.
+func decodeEncoded(t *testing.T, encoded encodedImage) image.Image {
+	t.Helper()
+	img, format, err := image.Decode(bytes.NewReader(encoded.data))
+	require.NoError(t, err)
+	assert.Equal(t, "image/"+format, encoded.contentType)
+	return img
+}
+
+// withJPEGExifOrientation inserts an EXIF segment carrying the given orientation right after a JPEG's SOI marker.
+func withJPEGExifOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = append(tiff, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01) // SHORT, count 1
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00) // value padding, no next IFD
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// webpChunk encodes a RIFF chunk, padded to an even size.
+func webpChunk(fourCC string, payload []byte) []byte {
+	chunk := []byte(fourCC)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// TestProcessImage_PNGRenditions tests that a large PNG keeps its size and format while each rendition is scaled to fit its edge.
+func TestProcessImage_PNGRenditions(t *testing.T) {
+	processed, err := processImage(newTestPNG(t, 2000, 1000))
+	require.NoError(t, err)
+
+	assert.Equal(t, ".png", processed.original.ext)
+	assert.Equal(t, image.Pt(2000, 1000), decodeEncoded(t, processed.original).Bounds().Size())
+	for _, tt := range []struct {
+		name      string
+		rendition encodedImage
+		size      image.Point
+	}{
+		{"thumbnail", processed.thumbnail, image.Pt(160, 80)},
+		{"medium", processed.medium, image.Pt(640, 320)},
+		{"large", processed.large, image.Pt(1280, 640)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, ".jpg", tt.rendition.ext)
+			assert.Equal(t, tt.size, decodeEncoded(t, tt.rendition).Bounds().Size())
+		})
+	}
+}
+
+// TestProcessImage_NoUpscale tests that renditions of a small image keep its size, here for a portrait image.
+func TestProcessImage_NoUpscale(t *testing.T) {
+	processed, err := processImage(newTestPNG(t, 120, 300))
+	require.NoError(t, err)
+
+	assert.Equal(t, image.Pt(64, 160), decodeEncoded(t, processed.thumbnail).Bounds().Size())
+	assert.Equal(t, image.Pt(120, 300), decodeEncoded(t, processed.medium).Bounds().Size())
+	assert.Equal(t, image.Pt(120, 300), decodeEncoded(t, processed.large).Bounds().Size())
+}
+
+// TestProcessImage_Rejects tests that content which is not a supported, decodable image of acceptable size is rejected.
+func TestProcessImage_Rejects(t *testing.T) {
+	pngSignature := newTestPNG(t, 4, 4)[:16]
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("fake image data"), errInvalidImage},
+		{"pdf", []byte("%PDF-1.7\n"), errInvalidImage},
+		{"bmp", []byte("BM\x00\x00\x00\x00\x00\x00\x00\x00"), errInvalidImage},
+		{"truncated_png", pngSignature, errInvalidImage},
+		{"oversized_gif", oversizedGIF, errImageTooLarge},
+		{"too_many_gif_frames", gifWithFrames(1, 1, maxGIFFrames+1), errImageTooLarge},
+		{"too_many_gif_pixels", gifWithFrames(4000, 4000, 3), errImageTooLarge},
+		{"malformed_gif_block", append(gifWithFrames(1, 1, 1)[:13], 0x99), errInvalidImage},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := processImage(tt.data)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+// TestProcessImage_JPEGOrientation tests that a JPEG's EXIF orientation is applied to its pixels and the EXIF data dropped.
+func TestProcessImage_JPEGOrientation(t *testing.T) {
+	// Left half red, right half blue; orientation 6 means the image must be turned 90° clockwise
+	src := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := range 32 {
+		for x := range 64 {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 32 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, src, &jpeg.Options{Quality: 95}))
+	data := withJPEGExifOrientation(buf.Bytes(), 6)
+	require.Equal(t, 6, jpegOrientation(data))
+
+	processed, err := processImage(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, ".jpg", processed.original.ext)
+	assert.NotContains(t, string(processed.original.data), "Exif")
+	assert.Equal(t, 1, jpegOrientation(processed.original.data))
+	img := decodeEncoded(t, processed.original)
+	require.Equal(t, image.Pt(32, 64), img.Bounds().Size())
+	topRed, _, topBlue, _ := img.At(16, 8).RGBA()
+	bottomRed, _, bottomBlue, _ := img.At(16, 56).RGBA()
+	assert.Greater(t, topRed, topBlue, "top half should be red")
+	assert.Greater(t, bottomBlue, bottomRed, "bottom half should be blue")
+}
+
+// TestProcessImage_GIF tests that an animated GIF keeps its frames and its renditions show the first frame.
+func TestProcessImage_GIF(t *testing.T) {
+	frame := func(c uint8) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 20, 10), palette.WebSafe)
+		for i := range img.Pix {
+			img.Pix[i] = c
+		}
+		return img
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame(1), frame(2)}, Delay: []int{10, 10}}))
+
+	processed, err := processImage(buf.Bytes())
+	require.NoError(t, err)
+
+	assert.Equal(t, ".gif", processed.original.ext)
+	assert.Equal(t, "image/gif", processed.original.contentType)
+	anim, err := gif.DecodeAll(bytes.NewReader(processed.original.data))
+	require.NoError(t, err)
+	assert.Len(t, anim.Image, 2)
+	assert.Equal(t, image.Pt(20, 10), decodeEncoded(t, processed.thumbnail).Bounds().Size())
+}
+
+// TestProcessImage_WebPStripsMetadata tests that EXIF and XMP chunks are removed from a WebP and its VP8X flags cleared.
+func TestProcessImage_WebPStripsMetadata(t *testing.T) {
+	vp8x := []byte{webpExifFlag | webpXMPFlag, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	body := []byte("WEBP")
+	body = append(body, webpChunk("VP8X", vp8x)...)
+	body = append(body, webpChunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	body = append(body, tinyWebP[12:]...)
+	body = append(body, webpChunk("EXIF", []byte("MM\x00\x2a\x00\x00\x00\x08GPS"))...)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	data = append(data, body...)
+
+	processed, err := processImage(data)
+	require.NoError(t, err)
+
+	original := processed.original.data
+	assert.Equal(t, ".webp", processed.original.ext)
+	assert.NotContains(t, string(original), "EXIF")
+	assert.NotContains(t, string(original), "XMP ")
+	assert.Equal(t, byte(0), original[20]&(webpExifFlag|webpXMPFlag))
+	assert.Equal(t, uint32(len(original)-8), binary.LittleEndian.Uint32(original[4:8]))
+	_, err = webp.Decode(bytes.NewReader(original))
+	require.NoError(t, err)
+
+	// The image is transparent, so its renditions keep the alpha channel as PNG
+	assert.Equal(t, ".png", processed.thumbnail.ext)
+	decodeEncoded(t, processed.thumbnail)
+}
+
+// TestStripWebPMetadata_Truncated tests that malformed WebP chunk data is rejected.
+func TestStripWebPMetadata_Truncated(t *testing.T) {
+	_, err := stripWebPMetadata([]byte("RIFF\x20\x00\x00\x00WEBPVP8L\xff\x00\x00\x00"))
+	require.ErrorIs(t, err, errInvalidImage)
+	_, err = stripWebPMetadata([]byte("not a webp file"))
+	require.ErrorIs(t, err, errInvalidImage)
+}
+
+// TestJPEGOrientation_Missing tests that images without usable EXIF data are treated as upright.
+func TestJPEGOrientation_Missing(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+
+	assert.Equal(t, 1, jpegOrientation(buf.Bytes()))
+	assert.Equal(t, 1, jpegOrientation(withJPEGExifOrientation(buf.Bytes(), 9)))
+	assert.Equal(t, 1, jpegOrientation([]byte("\xFF\xD8\xFF\xE1\xFF")))
+	assert.Equal(t, 1, jpegOrientation([]byte("not a jpeg")))
+	assert.Equal(t, 1, exifOrientation([]byte("XX\x00\x2a\x00\x00\x00\x08")))
+}
+
+// TestApplyOrientation tests the size of the result and which source pixel lands in its top-left corner for every orientation.
+func TestApplyOrientation(t *testing.T) {
+	// Each pixel records its own coordinates: R = x, G = y
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := range 2 {
+		for x := range 3 {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+
+	for orientation, want := range map[int]struct {
+		size    image.Point
+		topLeft image.Point
+	}{
+		1: {image.Pt(3, 2), image.Pt(0, 0)},
+		2: {image.Pt(3, 2), image.Pt(2, 0)},
+		3: {image.Pt(3, 2), image.Pt(2, 1)},
+		4: {image.Pt(3, 2), image.Pt(0, 1)},
+		5: {image.Pt(2, 3), image.Pt(0, 0)},
+		6: {image.Pt(2, 3), image.Pt(0, 1)},
+		7: {image.Pt(2, 3), image.Pt(2, 1)},
+		8: {image.Pt(2, 3), image.Pt(2, 0)},
+	} {
+		dst := applyOrientation(src, orientation)
+		assert.Equal(t, want.size, dst.Bounds().Size(), "orientation %d", orientation)
+		c := color.RGBAModel.Convert(dst.At(0, 0)).(color.RGBA)
+		assert.Equal(t, want.topLeft, image.Pt(int(c.R), int(c.G)), "orientation %d", orientation)
+	}
+}
+
+// TestEncodeRendition tests that opaque images become JPEG and images with transparency PNG.
+func TestEncodeRendition(t *testing.T) {
+	opaque, err := encodeRendition(image.NewGray(image.Rect(0, 0, 4, 4)))
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", opaque.contentType)
+
+	transparent, err := encodeRendition(image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", transparent.contentType)
+	_, err = png.Decode(bytes.NewReader(transparent.data))
+	require.NoError(t, err)
+}
@@ -0,0 +1,290 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// product_image_service.go: Implements the product image gallery: adding processed images and their renditions through the configured FileStorage, reordering,
+// choosing the primary image, and deleting images, keeping products.image_url in sync with the primary image.
+
+// maxAltTextLen caps the length of an image's alt text.
+const maxAltTextLen = 255
+
+// AddProductImage uploads an image and appends it to the product's gallery.
+// The multipart form carries the "image" file, an optional "alt_text", and an optional "is_primary" flag.
+// The first image of a gallery always becomes its primary image.
+func (s *uploadServiceImpl) AddProductImage(ctx context.Context, productID string, r *http.Request) (models.ProductImage, error) {
+	return s.addProductImage(ctx, productID, r, false)
+}
+
+// addProductImage saves the uploaded image and adds it to the gallery, as primary when makePrimary is set or requested by the form.
+// The saved files are deleted again if the gallery cannot be updated.
+func (s *uploadServiceImpl) addProductImage(ctx context.Context, productID string, r *http.Request, makePrimary bool) (models.ProductImage, error) {
+	if productID == "" {
+		return models.ProductImage{}, &handlers.AppError{Code: "missing_product_id", Message: "Product ID not found"}
+	}
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return models.ProductImage{}, &handlers.AppError{Code: "not_found", Message: "Product not found", Err: err}
+	}
+
+	uploaded, err := s.saveImage(r)
+	if err != nil {
+		return models.ProductImage{}, err
+	}
+
+	altText := strings.TrimSpace(r.FormValue("alt_text"))
+	if utf8.RuneCountInString(altText) > maxAltTextLen {
+		s.deleteImageFiles(uploaded)
+		return models.ProductImage{}, &handlers.AppError{Code: "invalid_form", Message: fmt.Sprintf("Alt text must be at most %d characters", maxAltTextLen)}
+	}
+	if primary := r.FormValue("is_primary"); primary != "" {
+		requested, err := strconv.ParseBool(primary)
+		if err != nil {
+			s.deleteImageFiles(uploaded)
+			return models.ProductImage{}, &handlers.AppError{Code: "invalid_form", Message: "is_primary must be true or false", Err: err}
+		}
+		makePrimary = makePrimary || requested
+	}
+
+	var image database.ProductImage
+	err = s.inGalleryTx(ctx, productID, func(q ProductDB, now time.Time) error {
+		position, err := q.NextProductImagePosition(ctx, productID)
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to read product images", Err: err}
+		}
+		isPrimary := makePrimary || position == 0
+		if isPrimary {
+			err := q.ClearPrimaryProductImage(ctx, database.ClearPrimaryProductImageParams{ProductID: productID, UpdatedAt: now})
+			if err != nil {
+				return &handlers.AppError{Code: "db_error", Message: "Failed to update product images", Err: err}
+			}
+		}
+
+		image, err = q.CreateProductImage(ctx, database.CreateProductImageParams{
+			ID:           utils.NewUUIDString(),
+			ProductID:    productID,
+			ImageUrl:     uploaded.ImageURL,
+			AltText:      utils.ToNullString(altText),
+			Position:     position,
+			IsPrimary:    isPrimary,
+			ThumbnailUrl: utils.ToNullString(uploaded.Renditions.Thumbnail),
+			MediumUrl:    utils.ToNullString(uploaded.Renditions.Medium),
+			LargeUrl:     utils.ToNullString(uploaded.Renditions.Large),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to add product image", Err: err}
+		}
+		if isPrimary {
+			return syncPrimaryImageURL(ctx, q, productID, uploaded.ImageURL, now)
+		}
+		return nil
+	})
+	if err != nil {
+		s.deleteImageFiles(uploaded)
+		return models.ProductImage{}, err
+	}
+	return models.MapProductImageToResponse(image), nil
+}
+
+// ReorderProductImages sets the gallery order to imageIDs, which must list every image of the product exactly once.
+// Returns the gallery in its new order.
+func (s *uploadServiceImpl) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]models.ProductImage, error) {
+	if productID == "" {
+		return nil, &handlers.AppError{Code: "missing_product_id", Message: "Product ID not found"}
+	}
+
+	var gallery []database.ProductImage
+	err := s.inGalleryTx(ctx, productID, func(q ProductDB, now time.Time) error {
+		images, err := q.ListProductImagesByProductID(ctx, productID)
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to read product images", Err: err}
+		}
+
+		byID := make(map[string]database.ProductImage, len(images))
+		for _, image := range images {
+			byID[image.ID] = image
+		}
+		if len(imageIDs) != len(images) {
+			return &handlers.AppError{Code: "invalid_request", Message: "image_ids must list every image of the product exactly once"}
+		}
+		gallery = make([]database.ProductImage, 0, len(imageIDs))
+		for position, imageID := range imageIDs {
+			image, ok := byID[imageID]
+			if !ok {
+				return &handlers.AppError{Code: "invalid_request", Message: "image_ids must list every image of the product exactly once"}
+			}
+			delete(byID, imageID)
+
+			image.Position = int32(position)
+			err := q.UpdateProductImagePosition(ctx, database.UpdateProductImagePositionParams{
+				ID:        image.ID,
+				ProductID: productID,
+				Position:  image.Position,
+				UpdatedAt: now,
+			})
+			if err != nil {
+				return &handlers.AppError{Code: "db_error", Message: "Failed to reorder product images", Err: err}
+			}
+			gallery = append(gallery, image)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProductImages(gallery), nil
+}
+
+// SetPrimaryProductImage makes a gallery image the product's primary image and returns the updated gallery.
+func (s *uploadServiceImpl) SetPrimaryProductImage(ctx context.Context, productID, imageID string) ([]models.ProductImage, error) {
+	if productID == "" {
+		return nil, &handlers.AppError{Code: "missing_product_id", Message: "Product ID not found"}
+	}
+
+	var gallery []database.ProductImage
+	err := s.inGalleryTx(ctx, productID, func(q ProductDB, now time.Time) error {
+		err := q.ClearPrimaryProductImage(ctx, database.ClearPrimaryProductImageParams{ProductID: productID, UpdatedAt: now})
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to update product images", Err: err}
+		}
+		image, err := q.SetPrimaryProductImage(ctx, database.SetPrimaryProductImageParams{ID: imageID, ProductID: productID, UpdatedAt: now})
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "image_not_found", Message: "Product image not found", Err: err}
+		}
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to update product images", Err: err}
+		}
+		if err := syncPrimaryImageURL(ctx, q, productID, image.ImageUrl, now); err != nil {
+			return err
+		}
+
+		gallery, err = q.ListProductImagesByProductID(ctx, productID)
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to read product images", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProductImages(gallery), nil
+}
+
+// DeleteProductImage removes an image from the gallery and deletes its files once the change is committed.
+// Deleting the primary image promotes the first remaining image, or clears the product's image URL if none is left.
+func (s *uploadServiceImpl) DeleteProductImage(ctx context.Context, productID, imageID string) error {
+	if productID == "" {
+		return &handlers.AppError{Code: "missing_product_id", Message: "Product ID not found"}
+	}
+
+	var deleted database.ProductImage
+	err := s.inGalleryTx(ctx, productID, func(q ProductDB, now time.Time) error {
+		var err error
+		deleted, err = q.DeleteProductImage(ctx, database.DeleteProductImageParams{ID: imageID, ProductID: productID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "image_not_found", Message: "Product image not found", Err: err}
+		}
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to delete product image", Err: err}
+		}
+		if !deleted.IsPrimary {
+			return nil
+		}
+
+		remaining, err := q.ListProductImagesByProductID(ctx, productID)
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to read product images", Err: err}
+		}
+		if len(remaining) == 0 {
+			return syncPrimaryImageURL(ctx, q, productID, "", now)
+		}
+		next, err := q.SetPrimaryProductImage(ctx, database.SetPrimaryProductImageParams{ID: remaining[0].ID, ProductID: productID, UpdatedAt: now})
+		if err != nil {
+			return &handlers.AppError{Code: "db_error", Message: "Failed to update product images", Err: err}
+		}
+		return syncPrimaryImageURL(ctx, q, productID, next.ImageUrl, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The row is gone, so a file left behind by a failed delete is only orphaned, never shown
+	s.deleteImageFiles(UploadedImage{
+		ImageURL: deleted.ImageUrl,
+		Renditions: models.ImageRenditions{
+			Thumbnail: deleted.ThumbnailUrl.String,
+			Medium:    deleted.MediumUrl.String,
+			Large:     deleted.LargeUrl.String,
+		},
+	})
+	return nil
+}
+
+// inGalleryTx runs fn in a transaction that holds the product's row lock, so concurrent changes to one
+// gallery are applied one at a time. Returns "not_found" if the product does not exist.
+func (s *uploadServiceImpl) inGalleryTx(ctx context.Context, productID string, fn func(q ProductDB, now time.Time) error) error {
+	if s.dbConn == nil {
+		return &handlers.AppError{Code: "transaction_error", Message: "DB connection is nil", Err: errors.New("dbConn is nil")}
+	}
+	tx, err := s.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return &handlers.AppError{Code: "transaction_error", Message: "Error starting transaction", Err: err}
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := s.db.WithTx(tx)
+	if _, err := q.GetProductByIDForUpdate(ctx, productID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &handlers.AppError{Code: "not_found", Message: "Product not found", Err: err}
+		}
+		return &handlers.AppError{Code: "db_error", Message: "Failed to lock product", Err: err}
+	}
+
+	if err := fn(q, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
+	}
+	return nil
+}
+
+// syncPrimaryImageURL mirrors the primary image's URL into products.image_url; an empty URL clears it.
+func syncPrimaryImageURL(ctx context.Context, q ProductDB, productID, imageURL string, now time.Time) error {
+	err := q.UpdateProductImageURL(ctx, UpdateProductImageURLParams{
+		ID:        productID,
+		ImageURL:  imageURL,
+		UpdatedAt: now.Unix(),
+	})
+	if err != nil {
+		return &handlers.AppError{Code: "db_error", Message: "Failed to update product image", Err: err}
+	}
+	return nil
+}
+
+// toProductImages converts gallery rows to response models, keeping their order.
+func toProductImages(images []database.ProductImage) []models.ProductImage {
+	result := make([]models.ProductImage, 0, len(images))
+	for _, image := range images {
+		result = append(result, models.MapProductImageToResponse(image))
+	}
+	return result
+}
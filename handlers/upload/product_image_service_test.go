@@ -0,0 +1,561 @@
+// Package uploadhandlers manages product image uploads with local and S3 storage, including validation, error handling, and logging.
+package uploadhandlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/handlers"
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// product_image_service_test.go: Tests for the product image gallery: adding, reordering, choosing the primary image, and deleting,
+// including primary image URL syncing, file cleanup, and transaction failures.
+
+// newGalleryImageRequest builds a multipart request with a PNG "image" file and the given extra form fields.
+func newGalleryImageRequest(t *testing.T, fields map[string]string) *http.Request {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	header := make(map[string][]string)
+	header["Content-Disposition"] = []string{`form-data; name="image"; filename="photo.png"`}
+	header["Content-Type"] = []string{"image/png"}
+	fw, err := w.CreatePart(header)
+	require.NoError(t, err)
+	_, err = fw.Write(newTestPNG(t, 32, 24))
+	require.NoError(t, err)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest("POST", "/v1/products/prod123/images", &b)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// expectGalleryLock sets up the product lookups every gallery change makes before touching images.
+func expectGalleryLock(mockDB *mockProductDB, productID string) {
+	mockDB.On("GetProductByIDForUpdate", mock.Anything, productID).Return(Product{ID: productID}, nil)
+}
+
+// requireAppErrorCode asserts that err is an AppError with the given code.
+func requireAppErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	appErr := &handlers.AppError{}
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, code, appErr.Code)
+}
+
+// TestAddProductImage_AppendsToGallery tests that an image added to a non-empty gallery is appended without becoming primary,
+// with the URLs of its renditions stored and returned.
+func TestAddProductImage_AppendsToGallery(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	req := newGalleryImageRequest(t, map[string]string{"alt_text": "  Side view "})
+	mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+	uploaded := expectImageSaves(mockStorage, "photo")
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("NextProductImagePosition", mock.Anything, "prod123").Return(int32(1), nil)
+	mockDB.On("CreateProductImage", mock.Anything, mock.MatchedBy(func(p database.CreateProductImageParams) bool {
+		return p.ID != "" && p.ProductID == "prod123" && p.ImageUrl == "/static/photo.png" && p.Position == 1 && !p.IsPrimary &&
+			p.AltText == sql.NullString{String: "Side view", Valid: true} &&
+			p.ThumbnailUrl.String == uploaded.Renditions.Thumbnail && p.MediumUrl.String == uploaded.Renditions.Medium &&
+			p.LargeUrl.String == uploaded.Renditions.Large
+	})).Return(database.ProductImage{
+		ID:           "img2",
+		ProductID:    "prod123",
+		ImageUrl:     "/static/photo.png",
+		AltText:      sql.NullString{String: "Side view", Valid: true},
+		Position:     1,
+		ThumbnailUrl: sql.NullString{String: uploaded.Renditions.Thumbnail, Valid: true},
+		MediumUrl:    sql.NullString{String: uploaded.Renditions.Medium, Valid: true},
+		LargeUrl:     sql.NullString{String: uploaded.Renditions.Large, Valid: true},
+	}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	image, err := service.AddProductImage(context.Background(), "prod123", req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ProductImage{
+		ID:         "img2",
+		ImageURL:   "/static/photo.png",
+		AltText:    "Side view",
+		Position:   1,
+		Renditions: &uploaded.Renditions,
+	}, image)
+	mockDB.AssertNotCalled(t, "ClearPrimaryProductImage", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "UpdateProductImageURL", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestAddProductImage_FirstImageIsPrimary tests that the first image of a gallery becomes primary and the product's image URL.
+func TestAddProductImage_FirstImageIsPrimary(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	req := newGalleryImageRequest(t, nil)
+	mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+	expectImageSaves(mockStorage, "photo")
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("NextProductImagePosition", mock.Anything, "prod123").Return(int32(0), nil)
+	mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.MatchedBy(func(p database.ClearPrimaryProductImageParams) bool {
+		return p.ProductID == "prod123"
+	})).Return(nil)
+	mockDB.On("CreateProductImage", mock.Anything, mock.MatchedBy(func(p database.CreateProductImageParams) bool {
+		return p.IsPrimary && p.Position == 0 && !p.AltText.Valid
+	})).Return(database.ProductImage{ID: "img1", ImageUrl: "/static/photo.png", IsPrimary: true}, nil)
+	mockDB.On("UpdateProductImageURL", mock.Anything, mock.MatchedBy(func(p UpdateProductImageURLParams) bool {
+		return p.ID == "prod123" && p.ImageURL == "/static/photo.png"
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	image, err := service.AddProductImage(context.Background(), "prod123", req)
+	require.NoError(t, err)
+	assert.True(t, image.IsPrimary)
+	mockDB.AssertExpectations(t)
+}
+
+// TestAddProductImage_RequestedPrimary tests that is_primary=true makes a later image the primary one.
+func TestAddProductImage_RequestedPrimary(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	req := newGalleryImageRequest(t, map[string]string{"is_primary": "true"})
+	mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+	expectImageSaves(mockStorage, "photo")
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("NextProductImagePosition", mock.Anything, "prod123").Return(int32(3), nil)
+	mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("CreateProductImage", mock.Anything, mock.MatchedBy(func(p database.CreateProductImageParams) bool {
+		return p.IsPrimary && p.Position == 3
+	})).Return(database.ProductImage{ID: "img4", ImageUrl: "/static/photo.png", Position: 3, IsPrimary: true}, nil)
+	mockDB.On("UpdateProductImageURL", mock.Anything, mock.Anything).Return(nil)
+	mockTx.On("Commit").Return(nil)
+
+	image, err := service.AddProductImage(context.Background(), "prod123", req)
+	require.NoError(t, err)
+	assert.True(t, image.IsPrimary)
+	mockDB.AssertExpectations(t)
+}
+
+// TestAddProductImage_InvalidFields tests that a too-long alt text or a malformed is_primary is rejected and the saved file removed.
+func TestAddProductImage_InvalidFields(t *testing.T) {
+	for name, fields := range map[string]map[string]string{
+		"long_alt_text":      {"alt_text": strings.Repeat("a", maxAltTextLen+1)},
+		"malformed_primary":  {"is_primary": "maybe"},
+		"long_alt_multibyte": {"alt_text": strings.Repeat("é", maxAltTextLen+1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockDB := new(mockProductDB)
+			mockStorage := new(mockFileStorage)
+			service := NewUploadService(mockDB, nil, "/tmp/uploads", mockStorage)
+
+			mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+			expectImageDeletes(mockStorage, expectImageSaves(mockStorage, "photo"))
+
+			_, err := service.AddProductImage(context.Background(), "prod123", newGalleryImageRequest(t, fields))
+			requireAppErrorCode(t, err, "invalid_form")
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+// TestAddProductImage_Errors tests missing IDs, unknown products, and transaction failures; failures after saving remove the file.
+func TestAddProductImage_Errors(t *testing.T) {
+	t.Run("missing_product_id", func(t *testing.T) {
+		service := NewUploadService(new(mockProductDB), nil, "/tmp/uploads", new(mockFileStorage))
+		_, err := service.AddProductImage(context.Background(), "", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "missing_product_id")
+	})
+
+	t.Run("product_not_found", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		service := NewUploadService(mockDB, nil, "/tmp/uploads", new(mockFileStorage))
+		mockDB.On("GetProductByID", mock.Anything, "prod404").Return(Product{}, sql.ErrNoRows)
+		_, err := service.AddProductImage(context.Background(), "prod404", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "not_found")
+	})
+
+	t.Run("nil_conn", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		service := NewUploadService(mockDB, nil, "/tmp/uploads", mockStorage)
+		mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+		expectImageDeletes(mockStorage, expectImageSaves(mockStorage, "photo"))
+		_, err := service.AddProductImage(context.Background(), "prod123", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "transaction_error")
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("begin_tx_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		mockConn := new(mockProductDBConn)
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+		mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+		expectImageDeletes(mockStorage, expectImageSaves(mockStorage, "photo"))
+		mockConn.On("BeginTx", mock.Anything, mock.Anything).Return(nil, errors.New("no connection"))
+		_, err := service.AddProductImage(context.Background(), "prod123", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "transaction_error")
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("create_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+		mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+		expectImageDeletes(mockStorage, expectImageSaves(mockStorage, "photo"))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("NextProductImagePosition", mock.Anything, "prod123").Return(int32(1), nil)
+		mockDB.On("CreateProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{}, errors.New("insert failed"))
+		_, err := service.AddProductImage(context.Background(), "prod123", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "db_error")
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("commit_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		mockConn, mockTx := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+		mockDB.On("GetProductByID", mock.Anything, "prod123").Return(Product{ID: "prod123"}, nil)
+		expectImageDeletes(mockStorage, expectImageSaves(mockStorage, "photo"))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("NextProductImagePosition", mock.Anything, "prod123").Return(int32(1), nil)
+		mockDB.On("CreateProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "img2"}, nil)
+		mockTx.On("Commit").Return(errors.New("commit failed"))
+		_, err := service.AddProductImage(context.Background(), "prod123", newGalleryImageRequest(t, nil))
+		requireAppErrorCode(t, err, "commit_error")
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+// TestReorderProductImages_Success tests that positions follow the given order and the gallery is returned in that order.
+func TestReorderProductImages_Success(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{
+		{ID: "a", ProductID: "prod123", ImageUrl: "/static/a.png", Position: 0, IsPrimary: true},
+		{ID: "b", ProductID: "prod123", ImageUrl: "/static/b.png", Position: 1},
+		{ID: "c", ProductID: "prod123", ImageUrl: "/static/c.png", Position: 2},
+	}, nil)
+	for id, position := range map[string]int32{"c": 0, "a": 1, "b": 2} {
+		mockDB.On("UpdateProductImagePosition", mock.Anything, mock.MatchedBy(func(p database.UpdateProductImagePositionParams) bool {
+			return p.ID == id && p.ProductID == "prod123" && p.Position == position
+		})).Return(nil).Once()
+	}
+	mockTx.On("Commit").Return(nil)
+
+	images, err := service.ReorderProductImages(context.Background(), "prod123", []string{"c", "a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ProductImage{
+		{ID: "c", ImageURL: "/static/c.png", Position: 0},
+		{ID: "a", ImageURL: "/static/a.png", Position: 1, IsPrimary: true},
+		{ID: "b", ImageURL: "/static/b.png", Position: 2},
+	}, images)
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestReorderProductImages_InvalidIDs tests that image_ids missing, repeating, or adding images are rejected without updates.
+func TestReorderProductImages_InvalidIDs(t *testing.T) {
+	for name, ids := range map[string][]string{
+		"empty":     {},
+		"missing":   {"a"},
+		"duplicate": {"a", "a"},
+		"unknown":   {"a", "x"},
+		"extra":     {"a", "b", "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockDB := new(mockProductDB)
+			mockConn, mockTx := newMockGalleryTx()
+			service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+			expectGalleryLock(mockDB, "prod123")
+			mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{{ID: "a"}, {ID: "b"}}, nil)
+			mockDB.On("UpdateProductImagePosition", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			_, err := service.ReorderProductImages(context.Background(), "prod123", ids)
+			requireAppErrorCode(t, err, "invalid_request")
+			mockTx.AssertNotCalled(t, "Commit")
+		})
+	}
+}
+
+// TestReorderProductImages_Errors tests a missing product ID, an unknown product, and failing queries.
+func TestReorderProductImages_Errors(t *testing.T) {
+	t.Run("missing_product_id", func(t *testing.T) {
+		service := NewUploadService(new(mockProductDB), nil, "/tmp/uploads", new(mockFileStorage))
+		_, err := service.ReorderProductImages(context.Background(), "", []string{"a"})
+		requireAppErrorCode(t, err, "missing_product_id")
+	})
+
+	t.Run("product_not_found", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		mockDB.On("GetProductByIDForUpdate", mock.Anything, "prod404").Return(Product{}, sql.ErrNoRows)
+		_, err := service.ReorderProductImages(context.Background(), "prod404", []string{"a"})
+		requireAppErrorCode(t, err, "not_found")
+	})
+
+	t.Run("lock_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		mockDB.On("GetProductByIDForUpdate", mock.Anything, "prod123").Return(Product{}, errors.New("db down"))
+		_, err := service.ReorderProductImages(context.Background(), "prod123", []string{"a"})
+		requireAppErrorCode(t, err, "db_error")
+	})
+
+	t.Run("list_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return(nil, errors.New("db down"))
+		_, err := service.ReorderProductImages(context.Background(), "prod123", []string{"a"})
+		requireAppErrorCode(t, err, "db_error")
+	})
+
+	t.Run("update_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{{ID: "a"}}, nil)
+		mockDB.On("UpdateProductImagePosition", mock.Anything, mock.Anything).Return(errors.New("update failed"))
+		_, err := service.ReorderProductImages(context.Background(), "prod123", []string{"a"})
+		requireAppErrorCode(t, err, "db_error")
+	})
+}
+
+// TestSetPrimaryProductImage_Success tests that the chosen image becomes primary and its URL is mirrored into the product.
+func TestSetPrimaryProductImage_Success(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.Anything).Return(nil)
+	mockDB.On("SetPrimaryProductImage", mock.Anything, mock.MatchedBy(func(p database.SetPrimaryProductImageParams) bool {
+		return p.ID == "b" && p.ProductID == "prod123"
+	})).Return(database.ProductImage{ID: "b", ImageUrl: "/static/b.png", Position: 1, IsPrimary: true}, nil)
+	mockDB.On("UpdateProductImageURL", mock.Anything, mock.MatchedBy(func(p UpdateProductImageURLParams) bool {
+		return p.ID == "prod123" && p.ImageURL == "/static/b.png"
+	})).Return(nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{
+		{ID: "a", ImageUrl: "/static/a.png", Position: 0},
+		{ID: "b", ImageUrl: "/static/b.png", Position: 1, IsPrimary: true},
+	}, nil)
+	mockTx.On("Commit").Return(nil)
+
+	images, err := service.SetPrimaryProductImage(context.Background(), "prod123", "b")
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.False(t, images[0].IsPrimary)
+	assert.True(t, images[1].IsPrimary)
+	mockDB.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestSetPrimaryProductImage_Errors tests an image from another product and failing queries.
+func TestSetPrimaryProductImage_Errors(t *testing.T) {
+	t.Run("missing_product_id", func(t *testing.T) {
+		service := NewUploadService(new(mockProductDB), nil, "/tmp/uploads", new(mockFileStorage))
+		_, err := service.SetPrimaryProductImage(context.Background(), "", "a")
+		requireAppErrorCode(t, err, "missing_product_id")
+	})
+
+	t.Run("image_not_found", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, mockTx := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.Anything).Return(nil)
+		mockDB.On("SetPrimaryProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{}, sql.ErrNoRows)
+		_, err := service.SetPrimaryProductImage(context.Background(), "prod123", "other")
+		requireAppErrorCode(t, err, "image_not_found")
+		mockTx.AssertNotCalled(t, "Commit")
+	})
+
+	t.Run("clear_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.Anything).Return(errors.New("db down"))
+		_, err := service.SetPrimaryProductImage(context.Background(), "prod123", "a")
+		requireAppErrorCode(t, err, "db_error")
+	})
+
+	t.Run("sync_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("ClearPrimaryProductImage", mock.Anything, mock.Anything).Return(nil)
+		mockDB.On("SetPrimaryProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "a", ImageUrl: "/static/a.png"}, nil)
+		mockDB.On("UpdateProductImageURL", mock.Anything, mock.Anything).Return(errors.New("db down"))
+		_, err := service.SetPrimaryProductImage(context.Background(), "prod123", "a")
+		requireAppErrorCode(t, err, "db_error")
+	})
+}
+
+// TestDeleteProductImage_NotPrimary tests that deleting a non-primary image leaves the primary alone and removes its files after commit.
+func TestDeleteProductImage_NotPrimary(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("DeleteProductImage", mock.Anything, database.DeleteProductImageParams{ID: "b", ProductID: "prod123"}).
+		Return(database.ProductImage{
+			ID:           "b",
+			ImageUrl:     "/static/b.png",
+			ThumbnailUrl: sql.NullString{String: "/static/b_thumbnail.jpg", Valid: true},
+			MediumUrl:    sql.NullString{String: "/static/b_medium.jpg", Valid: true},
+			LargeUrl:     sql.NullString{String: "/static/b_large.jpg", Valid: true},
+		}, nil)
+	mockTx.On("Commit").Return(nil)
+	expectImageDeletes(mockStorage, UploadedImage{
+		ImageURL:   "/static/b.png",
+		Renditions: models.ImageRenditions{Thumbnail: "/static/b_thumbnail.jpg", Medium: "/static/b_medium.jpg", Large: "/static/b_large.jpg"},
+	})
+
+	err := service.DeleteProductImage(context.Background(), "prod123", "b")
+	require.NoError(t, err)
+	mockDB.AssertNotCalled(t, "SetPrimaryProductImage", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "UpdateProductImageURL", mock.Anything, mock.Anything)
+	mockStorage.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+// TestDeleteProductImage_PromotesNext tests that deleting the primary image promotes the first remaining image.
+func TestDeleteProductImage_PromotesNext(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "a", ImageUrl: "/static/a.png", IsPrimary: true}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{
+		{ID: "b", ImageUrl: "/static/b.png", Position: 1},
+		{ID: "c", ImageUrl: "/static/c.png", Position: 2},
+	}, nil)
+	mockDB.On("SetPrimaryProductImage", mock.Anything, mock.MatchedBy(func(p database.SetPrimaryProductImageParams) bool {
+		return p.ID == "b" && p.ProductID == "prod123"
+	})).Return(database.ProductImage{ID: "b", ImageUrl: "/static/b.png", Position: 1, IsPrimary: true}, nil)
+	mockDB.On("UpdateProductImageURL", mock.Anything, mock.MatchedBy(func(p UpdateProductImageURLParams) bool {
+		return p.ImageURL == "/static/b.png"
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+	mockStorage.On("Delete", "/static/a.png", "/tmp/uploads").Return(nil)
+
+	err := service.DeleteProductImage(context.Background(), "prod123", "a")
+	require.NoError(t, err)
+	mockDB.AssertExpectations(t)
+	mockStorage.AssertExpectations(t)
+}
+
+// TestDeleteProductImage_LastImage tests that deleting the only image clears the product's image URL.
+func TestDeleteProductImage_LastImage(t *testing.T) {
+	mockDB := new(mockProductDB)
+	mockStorage := new(mockFileStorage)
+	mockConn, mockTx := newMockGalleryTx()
+	service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+
+	expectGalleryLock(mockDB, "prod123")
+	mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "a", ImageUrl: "/static/a.png", IsPrimary: true}, nil)
+	mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{}, nil)
+	mockDB.On("UpdateProductImageURL", mock.Anything, mock.MatchedBy(func(p UpdateProductImageURLParams) bool {
+		return p.ID == "prod123" && p.ImageURL == ""
+	})).Return(nil)
+	mockTx.On("Commit").Return(nil)
+	mockStorage.On("Delete", "/static/a.png", "/tmp/uploads").Return(errors.New("already gone"))
+
+	err := service.DeleteProductImage(context.Background(), "prod123", "a")
+	require.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+// TestDeleteProductImage_Errors tests unknown images and failed commits; the file is kept unless the delete is committed.
+func TestDeleteProductImage_Errors(t *testing.T) {
+	t.Run("missing_product_id", func(t *testing.T) {
+		service := NewUploadService(new(mockProductDB), nil, "/tmp/uploads", new(mockFileStorage))
+		err := service.DeleteProductImage(context.Background(), "", "a")
+		requireAppErrorCode(t, err, "missing_product_id")
+	})
+
+	t.Run("image_not_found", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{}, sql.ErrNoRows)
+		err := service.DeleteProductImage(context.Background(), "prod123", "x")
+		requireAppErrorCode(t, err, "image_not_found")
+		mockStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("delete_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{}, errors.New("db down"))
+		err := service.DeleteProductImage(context.Background(), "prod123", "a")
+		requireAppErrorCode(t, err, "db_error")
+	})
+
+	t.Run("promote_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockConn, _ := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", new(mockFileStorage))
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "a", IsPrimary: true}, nil)
+		mockDB.On("ListProductImagesByProductID", mock.Anything, "prod123").Return([]database.ProductImage{{ID: "b"}}, nil)
+		mockDB.On("SetPrimaryProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{}, errors.New("db down"))
+		err := service.DeleteProductImage(context.Background(), "prod123", "a")
+		requireAppErrorCode(t, err, "db_error")
+	})
+
+	t.Run("commit_error", func(t *testing.T) {
+		mockDB := new(mockProductDB)
+		mockStorage := new(mockFileStorage)
+		mockConn, mockTx := newMockGalleryTx()
+		service := NewUploadService(mockDB, mockConn, "/tmp/uploads", mockStorage)
+		expectGalleryLock(mockDB, "prod123")
+		mockDB.On("DeleteProductImage", mock.Anything, mock.Anything).Return(database.ProductImage{ID: "b", ImageUrl: "/static/b.png"}, nil)
+		mockTx.On("Commit").Return(errors.New("commit failed"))
+		err := service.DeleteProductImage(context.Background(), "prod123", "b")
+		requireAppErrorCode(t, err, "commit_error")
+		mockStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
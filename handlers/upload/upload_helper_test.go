@@ -4,17 +4,25 @@ package uploadhandlers
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
 )
 
 // upload_helper_test.go: Mocks for logger, upload service, storage (local and S3), product DB, and helpers for multipart file requests,
@@ -34,13 +42,35 @@ func (m *mockLogger) LogHandlerSuccess(ctx context.Context, operation, message,
 
 type mockUploadService struct{ mock.Mock }
 
-func (m *mockUploadService) UploadProductImage(ctx context.Context, userID string, r *http.Request) (string, error) {
+func (m *mockUploadService) UploadProductImage(ctx context.Context, userID string, r *http.Request) (UploadedImage, error) {
 	args := m.Called(ctx, userID, r)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(UploadedImage), args.Error(1)
 }
-func (m *mockUploadService) UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (string, error) {
+func (m *mockUploadService) UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (UploadedImage, error) {
 	args := m.Called(ctx, productID, userID, r)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(UploadedImage), args.Error(1)
+}
+func (m *mockUploadService) AddProductImage(ctx context.Context, productID string, r *http.Request) (models.ProductImage, error) {
+	args := m.Called(ctx, productID, r)
+	return args.Get(0).(models.ProductImage), args.Error(1)
+}
+func (m *mockUploadService) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]models.ProductImage, error) {
+	args := m.Called(ctx, productID, imageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductImage), args.Error(1)
+}
+func (m *mockUploadService) SetPrimaryProductImage(ctx context.Context, productID, imageID string) ([]models.ProductImage, error) {
+	args := m.Called(ctx, productID, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductImage), args.Error(1)
+}
+func (m *mockUploadService) DeleteProductImage(ctx context.Context, productID, imageID string) error {
+	args := m.Called(ctx, productID, imageID)
+	return args.Error(0)
 }
 
 // --- Mock Handler S3 ---
@@ -55,13 +85,35 @@ func (m *mockS3Logger) LogHandlerSuccess(ctx context.Context, operation, message
 
 type mockS3UploadService struct{ mock.Mock }
 
-func (m *mockS3UploadService) UploadProductImage(ctx context.Context, userID string, r *http.Request) (string, error) {
+func (m *mockS3UploadService) UploadProductImage(ctx context.Context, userID string, r *http.Request) (UploadedImage, error) {
 	args := m.Called(ctx, userID, r)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(UploadedImage), args.Error(1)
 }
-func (m *mockS3UploadService) UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (string, error) {
+func (m *mockS3UploadService) UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (UploadedImage, error) {
 	args := m.Called(ctx, productID, userID, r)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(UploadedImage), args.Error(1)
+}
+func (m *mockS3UploadService) AddProductImage(ctx context.Context, productID string, r *http.Request) (models.ProductImage, error) {
+	args := m.Called(ctx, productID, r)
+	return args.Get(0).(models.ProductImage), args.Error(1)
+}
+func (m *mockS3UploadService) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]models.ProductImage, error) {
+	args := m.Called(ctx, productID, imageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductImage), args.Error(1)
+}
+func (m *mockS3UploadService) SetPrimaryProductImage(ctx context.Context, productID, imageID string) ([]models.ProductImage, error) {
+	args := m.Called(ctx, productID, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductImage), args.Error(1)
+}
+func (m *mockS3UploadService) DeleteProductImage(ctx context.Context, productID, imageID string) error {
+	args := m.Called(ctx, productID, imageID)
+	return args.Error(0)
 }
 
 // --- Mock Storage S3 ---
@@ -150,6 +202,77 @@ func (m *mockProductDB) UpdateProductImageURL(ctx context.Context, params Update
 	return args.Error(0)
 }
 
+// WithTx returns the mock itself, so queries made in a transaction hit the same expectations.
+func (m *mockProductDB) WithTx(_ ProductDBTx) ProductDB {
+	return m
+}
+func (m *mockProductDB) GetProductByIDForUpdate(ctx context.Context, id string) (Product, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Product), args.Error(1)
+}
+func (m *mockProductDB) ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.ProductImage), args.Error(1)
+}
+func (m *mockProductDB) NextProductImagePosition(ctx context.Context, productID string) (int32, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(int32), args.Error(1)
+}
+func (m *mockProductDB) CreateProductImage(ctx context.Context, params database.CreateProductImageParams) (database.ProductImage, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(database.ProductImage), args.Error(1)
+}
+func (m *mockProductDB) UpdateProductImagePosition(ctx context.Context, params database.UpdateProductImagePositionParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+func (m *mockProductDB) ClearPrimaryProductImage(ctx context.Context, params database.ClearPrimaryProductImageParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+func (m *mockProductDB) SetPrimaryProductImage(ctx context.Context, params database.SetPrimaryProductImageParams) (database.ProductImage, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(database.ProductImage), args.Error(1)
+}
+func (m *mockProductDB) DeleteProductImage(ctx context.Context, params database.DeleteProductImageParams) (database.ProductImage, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(database.ProductImage), args.Error(1)
+}
+
+type mockProductDBConn struct{ mock.Mock }
+
+func (m *mockProductDBConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (ProductDBTx, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(ProductDBTx), args.Error(1)
+}
+
+type mockProductDBTx struct{ mock.Mock }
+
+func (m *mockProductDBTx) Commit() error {
+	args := m.Called()
+	return args.Error(0)
+}
+func (m *mockProductDBTx) Rollback() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// newMockGalleryTx returns a connection whose BeginTx starts the returned transaction.
+// Rollback is always allowed since the service defers it; set Commit expectations per test.
+func newMockGalleryTx() (*mockProductDBConn, *mockProductDBTx) {
+	conn := new(mockProductDBConn)
+	tx := new(mockProductDBTx)
+	conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil)
+	tx.On("Rollback").Return(sql.ErrTxDone).Maybe()
+	return conn, tx
+}
+
 type mockFileStorage struct{ mock.Mock }
 
 func (m *mockFileStorage) Save(file multipart.File, fileHeader *multipart.FileHeader, uploadPath string) (string, error) {
@@ -161,6 +284,43 @@ func (m *mockFileStorage) Delete(imageURL, uploadPath string) error {
 	return args.Error(0)
 }
 
+// expectImageSaves sets up the four saves of a processed upload (original, thumbnail, medium, large), in that order,
+// and returns the URLs the service reports for them.
+func expectImageSaves(mockStorage *mockFileStorage, name string) UploadedImage {
+	uploaded := UploadedImage{
+		ImageURL: "/static/" + name + ".png",
+		Renditions: models.ImageRenditions{
+			Thumbnail: "/static/" + name + "_thumbnail.jpg",
+			Medium:    "/static/" + name + "_medium.jpg",
+			Large:     "/static/" + name + "_large.jpg",
+		},
+	}
+	for _, url := range []string{uploaded.ImageURL, uploaded.Renditions.Thumbnail, uploaded.Renditions.Medium, uploaded.Renditions.Large} {
+		mockStorage.On("Save", mock.Anything, mock.Anything, "/tmp/uploads").Return("/tmp/uploads/"+strings.TrimPrefix(url, "/static/"), nil).Once()
+	}
+	return uploaded
+}
+
+// expectImageDeletes sets up the deletion of an image and its renditions.
+func expectImageDeletes(mockStorage *mockFileStorage, uploaded UploadedImage) {
+	for _, url := range []string{uploaded.ImageURL, uploaded.Renditions.Thumbnail, uploaded.Renditions.Medium, uploaded.Renditions.Large} {
+		mockStorage.On("Delete", url, "/tmp/uploads").Return(nil)
+	}
+}
+
+// newTestPNG encodes an opaque width x height PNG; uploads are checked by their content, so tests need real images.
+func newTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
 // --- Helper to create a multipart request with an image file ---
 // fieldName is always 'image' in tests, so we use _
 func newMultipartImageRequest(t *testing.T, _ string, fileName string, fileContent []byte) (*http.Request, *multipart.FileHeader) {
@@ -2,32 +2,65 @@
 package uploadhandlers
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/models"
 	"github.com/STaninnat/ecom-backend/utils"
 )
 
 // upload_service.go: Defines upload service interface, database adapter, and implementation for handling product image uploads and updates,
-// including file validation, storage, and DB updates with error handling.
+// including file validation, image processing, storage, and DB updates with error handling.
 
 // UploadService defines the business logic interface for uploads (local or S3).
-// Provides methods to upload and update product images with validation and error handling.
+// Provides methods to upload product images and to manage each product's ordered image gallery.
 type UploadService interface {
-	UploadProductImage(ctx context.Context, userID string, r *http.Request) (string, error)
-	UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (string, error)
+	UploadProductImage(ctx context.Context, userID string, r *http.Request) (UploadedImage, error)
+	UpdateProductImage(ctx context.Context, productID string, userID string, r *http.Request) (UploadedImage, error)
+	AddProductImage(ctx context.Context, productID string, r *http.Request) (models.ProductImage, error)
+	ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]models.ProductImage, error)
+	SetPrimaryProductImage(ctx context.Context, productID, imageID string) ([]models.ProductImage, error)
+	DeleteProductImage(ctx context.Context, productID, imageID string) error
 }
 
 // ProductDB defines the database operations needed for product image uploads.
-// Provides data access layer methods for product retrieval and image URL updates.
+// Provides data access layer methods for product retrieval, image URL updates, and the product image gallery.
 // Implemented by ProductDBAdapter.
 type ProductDB interface {
+	WithTx(tx ProductDBTx) ProductDB
 	GetProductByID(ctx context.Context, id string) (Product, error)
+	GetProductByIDForUpdate(ctx context.Context, id string) (Product, error)
 	UpdateProductImageURL(ctx context.Context, params UpdateProductImageURLParams) error
+	ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error)
+	NextProductImagePosition(ctx context.Context, productID string) (int32, error)
+	CreateProductImage(ctx context.Context, params database.CreateProductImageParams) (database.ProductImage, error)
+	UpdateProductImagePosition(ctx context.Context, params database.UpdateProductImagePositionParams) error
+	ClearPrimaryProductImage(ctx context.Context, params database.ClearPrimaryProductImageParams) error
+	SetPrimaryProductImage(ctx context.Context, params database.SetPrimaryProductImageParams) (database.ProductImage, error)
+	DeleteProductImage(ctx context.Context, params database.DeleteProductImageParams) (database.ProductImage, error)
+}
+
+// ProductDBConn defines the interface for beginning database transactions for product image gallery changes.
+type ProductDBConn interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (ProductDBTx, error)
+}
+
+// ProductDBTx defines the interface for a database transaction used in product image gallery changes.
+type ProductDBTx interface {
+	Commit() error
+	Rollback() error
 }
 
 // ProductDBAdapter implements ProductDB using *database.Queries.
@@ -62,6 +95,21 @@ func (a *ProductDBAdapter) GetProductByID(ctx context.Context, id string) (Produ
 	if err != nil {
 		return Product{}, err
 	}
+	return toUploadProduct(dbProduct), nil
+}
+
+// GetProductByIDForUpdate retrieves a product by its ID and locks its row until the transaction ends.
+// Gallery changes lock the product first so concurrent changes to the same gallery are serialized.
+func (a *ProductDBAdapter) GetProductByIDForUpdate(ctx context.Context, id string) (Product, error) {
+	dbProduct, err := a.Queries.GetProductByIDForUpdate(ctx, id)
+	if err != nil {
+		return Product{}, err
+	}
+	return toUploadProduct(dbProduct), nil
+}
+
+// toUploadProduct maps a database product to the local Product type.
+func toUploadProduct(dbProduct database.Product) Product {
 	return Product{
 		ID: dbProduct.ID,
 		ImageURL: struct {
@@ -71,7 +119,7 @@ func (a *ProductDBAdapter) GetProductByID(ctx context.Context, id string) (Produ
 			String: dbProduct.ImageUrl.String,
 			Valid:  dbProduct.ImageUrl.Valid,
 		},
-	}, nil
+	}
 }
 
 // UpdateProductImageURL updates the image URL for a product in the database.
@@ -90,6 +138,57 @@ func (a *ProductDBAdapter) UpdateProductImageURL(ctx context.Context, params Upd
 	})
 }
 
+// WithTx returns a ProductDB that runs its queries in the given transaction.
+func (a *ProductDBAdapter) WithTx(tx ProductDBTx) ProductDB {
+	return &ProductDBAdapter{Queries: a.Queries.WithTx(tx.(*sql.Tx))}
+}
+
+// ListProductImagesByProductID retrieves a product's gallery in display order.
+func (a *ProductDBAdapter) ListProductImagesByProductID(ctx context.Context, productID string) ([]database.ProductImage, error) {
+	return a.Queries.ListProductImagesByProductID(ctx, productID)
+}
+
+// NextProductImagePosition returns the position after the last image of a product's gallery.
+func (a *ProductDBAdapter) NextProductImagePosition(ctx context.Context, productID string) (int32, error) {
+	return a.Queries.NextProductImagePosition(ctx, productID)
+}
+
+// CreateProductImage adds an image to a product's gallery and returns the stored row.
+func (a *ProductDBAdapter) CreateProductImage(ctx context.Context, params database.CreateProductImageParams) (database.ProductImage, error) {
+	return a.Queries.CreateProductImage(ctx, params)
+}
+
+// UpdateProductImagePosition moves a gallery image to a new position.
+func (a *ProductDBAdapter) UpdateProductImagePosition(ctx context.Context, params database.UpdateProductImagePositionParams) error {
+	return a.Queries.UpdateProductImagePosition(ctx, params)
+}
+
+// ClearPrimaryProductImage unmarks the primary image of a product's gallery.
+func (a *ProductDBAdapter) ClearPrimaryProductImage(ctx context.Context, params database.ClearPrimaryProductImageParams) error {
+	return a.Queries.ClearPrimaryProductImage(ctx, params)
+}
+
+// SetPrimaryProductImage marks a gallery image as primary and returns the updated row.
+func (a *ProductDBAdapter) SetPrimaryProductImage(ctx context.Context, params database.SetPrimaryProductImageParams) (database.ProductImage, error) {
+	return a.Queries.SetPrimaryProductImage(ctx, params)
+}
+
+// DeleteProductImage removes a gallery image and returns the deleted row.
+func (a *ProductDBAdapter) DeleteProductImage(ctx context.Context, params database.DeleteProductImageParams) (database.ProductImage, error) {
+	return a.Queries.DeleteProductImage(ctx, params)
+}
+
+// ProductDBConnAdapter adapts a sql.DB to the ProductDBConn interface.
+type ProductDBConnAdapter struct {
+	*sql.DB
+}
+
+// BeginTx begins a new database transaction.
+func (a *ProductDBConnAdapter) BeginTx(ctx context.Context, opts *sql.TxOptions) (ProductDBTx, error) {
+	tx, err := a.DB.BeginTx(ctx, opts)
+	return tx, err
+}
+
 // Product represents a product with an optional image URL.
 // Local type for upload service operations, mapped from database models.
 type Product struct {
@@ -113,6 +212,7 @@ type UpdateProductImageURLParams struct {
 // Manages file validation, storage operations, and database updates.
 type uploadServiceImpl struct {
 	db        ProductDB
+	dbConn    ProductDBConn
 	uploadDir string
 	storage   FileStorage
 }
@@ -121,17 +221,24 @@ type uploadServiceImpl struct {
 // Factory function for creating upload service instances with configurable storage backends.
 // Parameters:
 //   - db: ProductDB for database operations
+//   - dbConn: ProductDBConn for the transactions of gallery changes
 //   - uploadDir: string path for uploads
 //   - storage: FileStorage implementation (local or S3)
 //
 // Returns:
 //   - UploadService: configured upload service instance
-func NewUploadService(db ProductDB, uploadDir string, storage FileStorage) UploadService {
-	return &uploadServiceImpl{db: db, uploadDir: uploadDir, storage: storage}
+func NewUploadService(db ProductDB, dbConn ProductDBConn, uploadDir string, storage FileStorage) UploadService {
+	return &uploadServiceImpl{db: db, dbConn: dbConn, uploadDir: uploadDir, storage: storage}
+}
+
+// UploadedImage is a stored upload: the metadata-free original and its resized renditions.
+type UploadedImage struct {
+	ImageURL   string
+	Renditions models.ImageRenditions
 }
 
 // UploadProductImage handles uploading a new product image.
-// Validates the form, checks MIME type, saves the file, and returns the image URL.
+// Validates the form, verifies the image by its content, saves it with its renditions, and returns their URLs.
 // Supports JPEG, PNG, GIF, and WebP image formats with proper error handling.
 // Parameters:
 //   - ctx: context.Context for request-scoped values
@@ -139,41 +246,96 @@ func NewUploadService(db ProductDB, uploadDir string, storage FileStorage) Uploa
 //   - r: *http.Request containing the multipart form
 //
 // Returns:
-//   - string: the generated image URL on success
+//   - UploadedImage: the generated image and rendition URLs on success
 //   - error: AppError with appropriate code and message on failure
-func (s *uploadServiceImpl) UploadProductImage(_ context.Context, _ string, r *http.Request) (string, error) {
+func (s *uploadServiceImpl) UploadProductImage(_ context.Context, _ string, r *http.Request) (UploadedImage, error) {
+	return s.saveImage(r)
+}
+
+// saveImage validates the form's image file, processes it (see processImage), and saves the original and its renditions.
+// Files saved before a failure are deleted again.
+func (s *uploadServiceImpl) saveImage(r *http.Request) (UploadedImage, error) {
 	file, fileHeader, err := ParseAndGetImageFile(r)
 	if err != nil {
-		return "", &handlers.AppError{Code: "invalid_form", Message: err.Error(), Err: err}
+		return UploadedImage{}, &handlers.AppError{Code: "invalid_form", Message: err.Error(), Err: err}
 	}
 	defer func() {
 		// Log error but don't return it since we're in defer
 		_ = file.Close()
 	}()
 
-	// MIME type validation
-	allowedMIMEs := map[string]struct{}{
-		"image/jpeg": {},
-		"image/png":  {},
-		"image/gif":  {},
-		"image/webp": {},
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return UploadedImage{}, &handlers.AppError{Code: "invalid_form", Message: "Failed to read image", Err: err}
 	}
-	mimeType := fileHeader.Header.Get("Content-Type")
-	if _, ok := allowedMIMEs[mimeType]; !ok {
-		return "", &handlers.AppError{Code: "invalid_image", Message: "Unsupported image MIME type", Err: nil}
+	processed, err := processImage(data)
+	switch {
+	case errors.Is(err, errImageTooLarge):
+		return UploadedImage{}, &handlers.AppError{Code: "image_too_large", Message: fmt.Sprintf("Image must be at most %d pixels", maxImagePixels), Err: err}
+	case errors.Is(err, errInvalidImage):
+		return UploadedImage{}, &handlers.AppError{Code: "invalid_image", Message: "File is not a valid JPEG, PNG, GIF, or WebP image", Err: err}
+	case err != nil:
+		return UploadedImage{}, &handlers.AppError{Code: "image_processing_failed", Message: "Failed to process image", Err: err}
 	}
 
-	filename, err := s.storage.Save(file, fileHeader, s.uploadDir)
+	name := strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
+	var uploaded UploadedImage
+	for _, stored := range []struct {
+		image encodedImage
+		url   *string
+	}{
+		{processed.original, &uploaded.ImageURL},
+		{processed.thumbnail, &uploaded.Renditions.Thumbnail},
+		{processed.medium, &uploaded.Renditions.Medium},
+		{processed.large, &uploaded.Renditions.Large},
+	} {
+		url, err := s.storeImage(stored.image, name)
+		if err != nil {
+			s.deleteImageFiles(uploaded)
+			return UploadedImage{}, &handlers.AppError{Code: "file_save_failed", Message: err.Error(), Err: err}
+		}
+		*stored.url = url
+	}
+	return uploaded, nil
+}
+
+// storeImage saves an encoded image through the FileStorage and returns its URL.
+func (s *uploadServiceImpl) storeImage(img encodedImage, name string) (string, error) {
+	fileHeader := &multipart.FileHeader{
+		Filename: name + img.ext,
+		Header:   textproto.MIMEHeader{"Content-Type": {img.contentType}},
+		Size:     int64(len(img.data)),
+	}
+	filename, err := s.storage.Save(memoryFile{bytes.NewReader(img.data)}, fileHeader, s.uploadDir)
 	if err != nil {
-		return "", &handlers.AppError{Code: "file_save_failed", Message: err.Error(), Err: err}
+		return "", err
 	}
-	imageURL := "/static/" + filename[strings.LastIndex(filename, "/")+1:]
-	return imageURL, nil
+	return "/static/" + filename[strings.LastIndex(filename, "/")+1:], nil
+}
+
+// deleteImageFiles deletes an image and its renditions, skipping URLs that were never set.
+// Failures are ignored: a leftover file is orphaned but never shown.
+func (s *uploadServiceImpl) deleteImageFiles(image UploadedImage) {
+	for _, url := range []string{image.ImageURL, image.Renditions.Thumbnail, image.Renditions.Medium, image.Renditions.Large} {
+		if url != "" {
+			_ = s.storage.Delete(url, s.uploadDir)
+		}
+	}
+}
+
+// memoryFile adapts an in-memory image to multipart.File so processed images are saved like uploaded files.
+type memoryFile struct {
+	*bytes.Reader
+}
+
+// Close implements multipart.File; there is nothing to release.
+func (memoryFile) Close() error {
+	return nil
 }
 
 // UpdateProductImage handles updating a product's image.
-// Retrieves the product, validates the form, deletes the old image, saves the new file,
-// updates the DB, and returns the new image URL. Supports JPEG, PNG, GIF, and WebP formats.
+// Adds the uploaded image to the product's gallery as its primary image and returns the new image and rendition URLs.
+// The previous primary image stays in the gallery; remove it with DeleteProductImage. Supports JPEG, PNG, GIF, and WebP formats.
 // Parameters:
 //   - ctx: context.Context for request-scoped values
 //   - productID: string product identifier
@@ -181,55 +343,18 @@ func (s *uploadServiceImpl) UploadProductImage(_ context.Context, _ string, r *h
 //   - r: *http.Request containing the multipart form
 //
 // Returns:
-//   - string: the new image URL on success
+//   - UploadedImage: the new image and rendition URLs on success
 //   - error: AppError with appropriate code and message on failure
-func (s *uploadServiceImpl) UpdateProductImage(ctx context.Context, productID string, _ string, r *http.Request) (string, error) {
-	product, err := s.db.GetProductByID(ctx, productID)
+func (s *uploadServiceImpl) UpdateProductImage(ctx context.Context, productID string, _ string, r *http.Request) (UploadedImage, error) {
+	image, err := s.addProductImage(ctx, productID, r, true)
 	if err != nil {
-		return "", &handlers.AppError{Code: "not_found", Message: "Product not found", Err: err}
-	}
-
-	file, fileHeader, err := ParseAndGetImageFile(r)
-	if err != nil {
-		return "", &handlers.AppError{Code: "invalid_form", Message: err.Error(), Err: err}
-	}
-	defer func() {
-		// Log error but don't return it since we're in defer
-		_ = file.Close()
-	}()
-
-	// MIME type validation
-	allowedMIMEs := map[string]struct{}{
-		"image/jpeg": {},
-		"image/png":  {},
-		"image/gif":  {},
-		"image/webp": {},
-	}
-	mimeType := fileHeader.Header.Get("Content-Type")
-	if _, ok := allowedMIMEs[mimeType]; !ok {
-		return "", &handlers.AppError{Code: "invalid_image", Message: "Unsupported image MIME type", Err: nil}
-	}
-
-	// Delete old image if exists
-	if product.ImageURL.Valid && product.ImageURL.String != "" {
-		_ = s.storage.Delete(product.ImageURL.String, s.uploadDir)
-	}
-
-	filename, err := s.storage.Save(file, fileHeader, s.uploadDir)
-	if err != nil {
-		return "", &handlers.AppError{Code: "file_save_failed", Message: err.Error(), Err: err}
-	}
-	imageURL := "/static/" + filename[strings.LastIndex(filename, "/")+1:]
-
-	params := UpdateProductImageURLParams{
-		ID:        productID,
-		ImageURL:  imageURL,
-		UpdatedAt: time.Now().Unix(),
+		return UploadedImage{}, err
 	}
-	if err := s.db.UpdateProductImageURL(ctx, params); err != nil {
-		return "", &handlers.AppError{Code: "db_error", Message: "Failed to update product image", Err: err}
+	uploaded := UploadedImage{ImageURL: image.ImageURL}
+	if image.Renditions != nil {
+		uploaded.Renditions = *image.Renditions
 	}
-	return imageURL, nil
+	return uploaded, nil
 }
 
 // --- FileStorage interface will be in storage_local.go ---
@@ -9,6 +9,7 @@ import (
 
 	"github.com/STaninnat/ecom-backend/handlers"
 	"github.com/STaninnat/ecom-backend/middlewares"
+	"github.com/STaninnat/ecom-backend/models"
 )
 
 // upload_wrapper.go: Provides configuration, error handling, and response structures for local and S3 upload handlers.
@@ -32,10 +33,17 @@ type HandlersUploadS3Config struct {
 }
 
 // imageUploadResponse is the response payload for image upload endpoints.
-// Contains success message and the generated image URL for client consumption.
+// Contains success message, the generated image URL, and the URLs of its resized renditions for client consumption.
 type imageUploadResponse struct {
-	Message  string `json:"message"`
-	ImageURL string `json:"image_url"`
+	Message    string                 `json:"message"`
+	ImageURL   string                 `json:"image_url"`
+	Renditions models.ImageRenditions `json:"renditions"`
+}
+
+// ReorderProductImagesRequest is the request payload for reordering a product's image gallery.
+// Lists every image ID of the product in the desired display order.
+type ReorderProductImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
 }
 
 // chiURLParam is a patchable reference to chi.URLParam for testing.
@@ -59,13 +67,13 @@ func handleUploadErrorShared(
 		case "missing_product_id":
 			logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, nil)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message)
-		case "not_found":
+		case "not_found", "image_not_found":
 			logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusNotFound, appErr.Message)
-		case "invalid_form", "invalid_image":
+		case "invalid_form", "invalid_image", "image_too_large", "invalid_request":
 			logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusBadRequest, appErr.Message)
-		case "db_error", "file_save_failed", "transaction_error", "commit_error":
+		case "db_error", "file_save_failed", "image_processing_failed", "transaction_error", "commit_error":
 			logger.LogHandlerError(ctx, operation, appErr.Code, appErr.Message, ip, userAgent, appErr.Err)
 			middlewares.RespondWithError(w, http.StatusInternalServerError, "Something went wrong, please try again later")
 		default:
@@ -48,12 +48,16 @@ func TestHandlersUploadConfigAndS3Config_handleUploadError_AllBranches(t *testin
 	}{
 		{"missing_product_id", &handlers.AppError{Code: "missing_product_id", Message: "Missing product"}, http.StatusBadRequest, "Missing product", "missing_product_id", nil},
 		{"not_found", &handlers.AppError{Code: "not_found", Message: "Not found", Err: errors.New("db")}, http.StatusNotFound, "Not found", "not_found", errors.New("db")},
+		{"image_not_found", &handlers.AppError{Code: "image_not_found", Message: "Image not found", Err: errors.New("db")}, http.StatusNotFound, "Image not found", "image_not_found", errors.New("db")},
+		{"invalid_request", &handlers.AppError{Code: "invalid_request", Message: "Invalid request", Err: nil}, http.StatusBadRequest, "Invalid request", "invalid_request", nil},
+		{"image_too_large", &handlers.AppError{Code: "image_too_large", Message: "Image too large", Err: nil}, http.StatusBadRequest, "Image too large", "image_too_large", nil},
 		{"invalid_form", &handlers.AppError{Code: "invalid_form", Message: "Invalid form", Err: errors.New("form")}, http.StatusBadRequest, "Invalid form", "invalid_form", errors.New("form")},
 		{"invalid_image", &handlers.AppError{Code: "invalid_image", Message: "Invalid image", Err: errors.New("img")}, http.StatusBadRequest, "Invalid image", "invalid_image", errors.New("img")},
 		{"db_error", &handlers.AppError{Code: "db_error", Message: "DB fail", Err: errors.New("db")}, http.StatusInternalServerError, "Something went wrong, please try again later", "db_error", errors.New("db")},
 		{"file_save_failed", &handlers.AppError{Code: "file_save_failed", Message: "Save fail", Err: errors.New("fs")}, http.StatusInternalServerError, "Something went wrong, please try again later", "file_save_failed", errors.New("fs")},
 		{"transaction_error", &handlers.AppError{Code: "transaction_error", Message: "Tx fail", Err: errors.New("tx")}, http.StatusInternalServerError, "Something went wrong, please try again later", "transaction_error", errors.New("tx")},
 		{"commit_error", &handlers.AppError{Code: "commit_error", Message: "Commit fail", Err: errors.New("commit")}, http.StatusInternalServerError, "Something went wrong, please try again later", "commit_error", errors.New("commit")},
+		{"image_processing_failed", &handlers.AppError{Code: "image_processing_failed", Message: "Processing fail", Err: errors.New("encode")}, http.StatusInternalServerError, "Something went wrong, please try again later", "image_processing_failed", errors.New("encode")},
 		{"default_app_error", &handlers.AppError{Code: "other", Message: "Other fail", Err: errors.New("other")}, http.StatusInternalServerError, "Internal server error", "internal_error", errors.New("other")},
 		{"generic_error", errors.New("something bad"), http.StatusInternalServerError, "Internal server error", "unknown_error", errors.New("something bad")},
 	}
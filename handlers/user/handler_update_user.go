@@ -24,7 +24,7 @@ type UpdateUserRequest struct {
 
 // HandlerUpdateUser handles HTTP PUT requests to update user information.
 // @Summary      Update current user
-// @Description  Updates the current user's information
+// @Description  Updates the current user's information; a changed email must be verified again through a link mailed to it
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -43,11 +43,17 @@ type UserResponse struct {
 	Address string `json:"address,omitempty"`
 }
 
+// EmailVerifier mails a verification link to a user's new email address.
+type EmailVerifier interface {
+	SendEmailChangeVerification(ctx context.Context, userID, email string)
+}
+
 // userServiceImpl implements UserService.
 // Provides business logic for user operations with database transaction management.
 type userServiceImpl struct {
-	db     *database.Queries
-	dbConn *sql.DB
+	db       *database.Queries
+	dbConn   *sql.DB
+	verifier EmailVerifier
 }
 
 // NewUserService creates a new UserService instance.
@@ -55,13 +61,15 @@ type userServiceImpl struct {
 // Parameters:
 //   - db: *database.Queries for database operations
 //   - dbConn: *sql.DB for transaction management
+//   - verifier: EmailVerifier for changed email addresses; nil sends no verification links
 //
 // Returns:
 //   - UserService: configured user service instance
-func NewUserService(db *database.Queries, dbConn *sql.DB) UserService {
+func NewUserService(db *database.Queries, dbConn *sql.DB, verifier EmailVerifier) UserService {
 	return &userServiceImpl{
-		db:     db,
-		dbConn: dbConn,
+		db:       db,
+		dbConn:   dbConn,
+		verifier: verifier,
 	}
 }
 
@@ -86,6 +94,7 @@ func (s *userServiceImpl) GetUser(_ context.Context, user database.User) (*UserR
 
 // UpdateUser updates the user's information in the database.
 // Uses database transactions to ensure data consistency and proper error handling.
+// Changing the email marks it unverified and mails a verification link to the new address.
 // Parameters:
 //   - ctx: context.Context for the operation
 //   - user: database.User to update
@@ -124,6 +133,9 @@ func (s *userServiceImpl) UpdateUser(ctx context.Context, user database.User, pa
 		return &handlers.AppError{Code: "commit_error", Message: "Error committing transaction", Err: err}
 	}
 
+	if params.Email != user.Email && s.verifier != nil {
+		s.verifier.SendEmailChangeVerification(ctx, user.ID, params.Email)
+	}
 	return nil
 }
 
@@ -132,8 +132,8 @@ func TestUserService_GetUserByID_Success(t *testing.T) {
 
 	// Mock the database query
 	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("u1").WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at"}).
-			AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Email, expectedUser.Phone.String, expectedUser.Address.String, nil, "", nil, "", time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Email, expectedUser.Phone.String, expectedUser.Address.String, nil, "", nil, "", time.Now(), time.Now(), nil),
 	)
 
 	user, err := service.GetUserByID(context.Background(), "u1")
@@ -207,6 +207,58 @@ func TestUserService_UpdateUser_SuccessScenarios(t *testing.T) {
 	}
 }
 
+// fakeEmailVerifier records the addresses it is asked to verify.
+type fakeEmailVerifier struct {
+	sent []string
+}
+
+func (f *fakeEmailVerifier) SendEmailChangeVerification(_ context.Context, userID, email string) {
+	f.sent = append(f.sent, userID+":"+email)
+}
+
+// TestUserService_UpdateUser_EmailChange tests that only a changed email is sent a verification link,
+// and that the update clears the verification of a changed email.
+func TestUserService_UpdateUser_EmailChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		wantSent []string
+	}{
+		{name: "Changed", email: "new@example.com", wantSent: []string{"u1:new@example.com"}},
+		{name: "Unchanged", email: "alice@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			verifier := &fakeEmailVerifier{}
+			service := NewUserService(database.New(db), db, verifier)
+			user := database.User{ID: "u1", Email: "alice@example.com"}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`email_verified_at = CASE WHEN email = \$3 THEN email_verified_at END`).WithArgs(
+				user.ID, "Alice", tt.email,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, service.UpdateUser(context.Background(), user, UpdateUserParams{Name: "Alice", Email: tt.email}))
+			assert.Equal(t, tt.wantSent, verifier.sent)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	// Nothing is sent when the update fails
+	db, mock, _ := sqlmock.New()
+	verifier := &fakeEmailVerifier{}
+	service := NewUserService(database.New(db), db, verifier)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WillReturnError(errors.New("update error"))
+	mock.ExpectRollback()
+	require.Error(t, service.UpdateUser(context.Background(), database.User{ID: "u1"}, UpdateUserParams{Email: "new@example.com"}))
+	assert.Empty(t, verifier.sent)
+}
+
 // TestUserService_UpdateUser_PartialParams tests that UpdateUser successfully handles
 // partial parameters (some fields empty)
 func TestUserService_UpdateUser_PartialParams(t *testing.T) {
@@ -257,8 +309,8 @@ func TestUserService_PromoteUserToAdmin_Success(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs(targetUserID).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at"}).
-			AddRow(targetUser.ID, "Target", "target@example.com", "", "", "", "local", "", targetUser.Role, time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(targetUser.ID, "Target", "target@example.com", "", "", "", "local", "", targetUser.Role, time.Now(), time.Now(), nil),
 	)
 	mock.ExpectExec("UPDATE users").WithArgs(targetUserID, "admin").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -289,8 +341,8 @@ func TestUserService_PromoteUserToAdmin_AlreadyAdmin(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs(targetUserID).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at"}).
-			AddRow(targetUser.ID, "Target", "target@example.com", "", "", "", "local", "", targetUser.Role, time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(targetUser.ID, "Target", "target@example.com", "", "", "", "local", "", targetUser.Role, time.Now(), time.Now(), nil),
 	)
 	mock.ExpectRollback()
 
@@ -338,8 +390,8 @@ func TestUserService_PromoteUserToAdmin_UpdateError(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs(targetUserID).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at"}).
-			AddRow(targetUserID, "Target", "target@example.com", "", "", "", "local", "", "user", time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(targetUserID, "Target", "target@example.com", "", "", "", "local", "", "user", time.Now(), time.Now(), nil),
 	)
 	mock.ExpectExec("UPDATE users").WithArgs(targetUserID, "admin").WillReturnError(errors.New("update error"))
 	mock.ExpectRollback()
@@ -360,8 +412,8 @@ func TestUserService_PromoteUserToAdmin_CommitError(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs(targetUserID).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at"}).
-			AddRow(targetUserID, "Target", "target@example.com", "", "", "", "local", "", "user", time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id", "name", "email", "phone", "address", "password", "provider", "provider_id", "role", "created_at", "updated_at", "email_verified_at"}).
+			AddRow(targetUserID, "Target", "target@example.com", "", "", "", "local", "", "user", time.Now(), time.Now(), nil),
 	)
 	mock.ExpectExec("UPDATE users").WithArgs(targetUserID, "admin").WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(errors.New("commit error"))
@@ -390,7 +442,7 @@ func TestUserService_GetUserByID_UserNotFound(t *testing.T) {
 func TestNewUserService_ReturnsNonNil(t *testing.T) {
 	db := &database.Queries{}
 	dbConn := new(sql.DB)
-	service := NewUserService(db, dbConn)
+	service := NewUserService(db, dbConn, nil)
 	assert.NotNil(t, service)
 }
 
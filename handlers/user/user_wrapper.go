@@ -20,6 +20,7 @@ import (
 type HandlersUserConfig struct {
 	Config      *handlers.Config       // for DB, etc.
 	Logger      handlers.HandlerLogger // for logging
+	Verifier    EmailVerifier          // mails verification links for changed emails; nil sends none
 	userService UserService
 	userMutex   sync.RWMutex
 }
@@ -38,7 +39,7 @@ func (cfg *HandlersUserConfig) InitUserService() error {
 	}
 	cfg.userMutex.Lock()
 	defer cfg.userMutex.Unlock()
-	cfg.userService = NewUserService(cfg.Config.DB, cfg.Config.DBConn)
+	cfg.userService = NewUserService(cfg.Config.DB, cfg.Config.DBConn, cfg.Verifier)
 	return nil
 }
 
@@ -58,9 +59,9 @@ func (cfg *HandlersUserConfig) GetUserService() UserService {
 	defer cfg.userMutex.Unlock()
 	if cfg.userService == nil {
 		if cfg.Config == nil || cfg.Config.DB == nil {
-			cfg.userService = NewUserService(nil, nil)
+			cfg.userService = NewUserService(nil, nil, nil)
 		} else {
-			cfg.userService = NewUserService(cfg.Config.DB, cfg.Config.DBConn)
+			cfg.userService = NewUserService(cfg.Config.DB, cfg.Config.DBConn, cfg.Verifier)
 		}
 	}
 	return cfg.userService
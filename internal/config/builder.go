@@ -4,10 +4,20 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
+	"github.com/STaninnat/ecom-backend/internal/mailer"
+	intmongo "github.com/STaninnat/ecom-backend/internal/mongo"
 )
 
 // builder.go: Configuration builder pattern and construction logic.
 
+// createMongoIndexes builds the MongoDB indexes once connected; replaced in tests.
+var createMongoIndexes = intmongo.CreateIndexes
+
 // BuilderImpl implements the ConfigBuilder interface for constructing APIConfig instances with various providers and settings.
 type BuilderImpl struct {
 	provider Provider
@@ -90,6 +100,113 @@ func (b *BuilderImpl) getOptionalConfig() (uploadBackend, uploadPath string) {
 	return
 }
 
+// Helper to load the inventory hold settings for unpaid pending orders
+func (b *BuilderImpl) getOrderHoldConfig() (holdWindow, reaperInterval time.Duration, err error) {
+	holdWindow, err = time.ParseDuration(b.provider.GetStringOrDefault("ORDER_HOLD_WINDOW", "30m"))
+	if err != nil || holdWindow <= 0 {
+		return 0, 0, fmt.Errorf("invalid ORDER_HOLD_WINDOW: must be a positive duration")
+	}
+	reaperInterval, err = time.ParseDuration(b.provider.GetStringOrDefault("ORDER_REAPER_INTERVAL", "1m"))
+	if err != nil || reaperInterval <= 0 {
+		return 0, 0, fmt.Errorf("invalid ORDER_REAPER_INTERVAL: must be a positive duration")
+	}
+	return holdWindow, reaperInterval, nil
+}
+
+// Helper to load the review moderation settings.
+// REVIEW_BANNED_WORDS is a comma-separated list; REVIEW_MIN_WORDS of 0 turns off the short comment check.
+func (b *BuilderImpl) getReviewModerationConfig() (requireApproval bool, bannedWords []string, minWords int) {
+	requireApproval = b.provider.GetBoolOrDefault("REVIEWS_REQUIRE_APPROVAL", false)
+	for word := range strings.SplitSeq(b.provider.GetString("REVIEW_BANNED_WORDS"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			bannedWords = append(bannedWords, word)
+		}
+	}
+	minWords = b.provider.GetIntOrDefault("REVIEW_MIN_WORDS", 2)
+	return
+}
+
+// Helper to load the failed sign-in limits. SIGNIN_MAX_ATTEMPTS failures lock an account out for SIGNIN_LOCKOUT;
+// SIGNIN_IP_MAX_ATTEMPTS does the same for an IP across accounts. Earlier failures are delayed by up to SIGNIN_MAX_DELAY.
+func (b *BuilderImpl) getSignInLimitConfig() (maxAttempts, ipMaxAttempts int, lockout, maxDelay time.Duration, err error) {
+	maxAttempts = b.provider.GetIntOrDefault("SIGNIN_MAX_ATTEMPTS", 5)
+	ipMaxAttempts = b.provider.GetIntOrDefault("SIGNIN_IP_MAX_ATTEMPTS", 20)
+	lockout, err = time.ParseDuration(b.provider.GetStringOrDefault("SIGNIN_LOCKOUT", "15m"))
+	if err != nil || lockout <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("invalid SIGNIN_LOCKOUT: must be a positive duration")
+	}
+	maxDelay, err = time.ParseDuration(b.provider.GetStringOrDefault("SIGNIN_MAX_DELAY", "30s"))
+	if err != nil || maxDelay <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("invalid SIGNIN_MAX_DELAY: must be a positive duration")
+	}
+	return maxAttempts, ipMaxAttempts, lockout, maxDelay, nil
+}
+
+// Helper to load the proxies trusted to report the client IP for sign-in limits. SIGNIN_TRUSTED_PROXIES is a
+// comma-separated list of IPs or CIDRs; when empty, the connecting address is always used.
+func (b *BuilderImpl) getTrustedProxies() ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for entry := range strings.SplitSeq(b.provider.GetString("SIGNIN_TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid SIGNIN_TRUSTED_PROXIES entry %q", entry)
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SIGNIN_TRUSTED_PROXIES entry %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Helper to load the review photo limits. REVIEW_MEDIA_MAX_SIZE_MB is converted to bytes.
+func (b *BuilderImpl) getReviewMediaConfig() (maxFileSize int64, maxPerUser, maxPerReview int) {
+	maxFileSize = int64(b.provider.GetIntOrDefault("REVIEW_MEDIA_MAX_SIZE_MB", 5)) << 20
+	maxPerUser = b.provider.GetIntOrDefault("REVIEW_MEDIA_MAX_PER_USER", 50)
+	maxPerReview = b.provider.GetIntOrDefault("REVIEW_MEDIA_MAX_PER_REVIEW", 5)
+	return
+}
+
+// Helper to load the mail settings. MAIL_BACKEND is "file" (the default, writing to MAIL_DIR or the log) or "smtp".
+func (b *BuilderImpl) getMailConfig() (mailer.Config, error) {
+	cfg := mailer.Config{
+		Backend:      b.provider.GetStringOrDefault("MAIL_BACKEND", mailer.BackendFile),
+		From:         b.provider.GetStringOrDefault("MAIL_FROM", "no-reply@localhost"),
+		SMTPHost:     b.provider.GetString("SMTP_HOST"),
+		SMTPPort:     b.provider.GetIntOrDefault("SMTP_PORT", 587),
+		SMTPUsername: b.provider.GetString("SMTP_USERNAME"),
+		SMTPPassword: b.provider.GetString("SMTP_PASSWORD"),
+		Dir:          b.provider.GetString("MAIL_DIR"),
+	}
+	if _, err := mailer.New(cfg); err != nil {
+		return mailer.Config{}, fmt.Errorf("invalid mail config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Helper to load the access token signing keys. JWT_KEYS_DIR holds one PEM file per key, named <kid>.pem;
+// JWT_SIGNING_KEY_ID picks the one new tokens are signed with. Without JWT_KEYS_DIR tokens are signed with JWT_SECRET.
+func (b *BuilderImpl) getJWTKeys() (*jwtkeys.KeySet, error) {
+	dir := b.provider.GetString("JWT_KEYS_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+	keys, err := jwtkeys.Load(dir, b.provider.GetString("JWT_SIGNING_KEY_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT keys: %w", err)
+	}
+	return keys, nil
+}
+
 func (b *BuilderImpl) connectRedis(ctx context.Context, config *APIConfig) error {
 	redisAddr := b.provider.GetString("REDIS_ADDR")
 	redisUsername := b.provider.GetString("REDIS_USERNAME")
@@ -116,6 +233,11 @@ func (b *BuilderImpl) connectMongo(ctx context.Context, config *APIConfig, mongo
 	if err != nil {
 		return fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
+	// The unique review index is what enforces one review per user and product
+	if err := createMongoIndexes(mongoDB); err != nil {
+		_ = mongoProvider.Close(ctx)
+		return fmt.Errorf("failed to create MongoDB indexes: %w", err)
+	}
 	config.MongoClient = mongoClient
 	config.MongoDB = mongoDB
 	return nil
@@ -150,21 +272,55 @@ func (b *BuilderImpl) Build(ctx context.Context) (*APIConfig, error) {
 		return nil, err
 	}
 	uploadBackend, uploadPath := b.getOptionalConfig()
+	holdWindow, reaperInterval, err := b.getOrderHoldConfig()
+	if err != nil {
+		return nil, err
+	}
+	signInMaxAttempts, signInIPMaxAttempts, signInLockout, signInMaxDelay, err := b.getSignInLimitConfig()
+	if err != nil {
+		return nil, err
+	}
+	trustedProxies, err := b.getTrustedProxies()
+	if err != nil {
+		return nil, err
+	}
+	mailConfig, err := b.getMailConfig()
+	if err != nil {
+		return nil, err
+	}
+	jwtKeys, err := b.getJWTKeys()
+	if err != nil {
+		return nil, err
+	}
 
 	config := &APIConfig{
-		Port:                required["PORT"],
-		JWTSecret:           required["JWT_SECRET"],
-		RefreshSecret:       required["REFRESH_SECRET"],
-		Issuer:              required["ISSUER"],
-		Audience:            required["AUDIENCE"],
-		CredsPath:           required["GOOGLE_CREDENTIALS_PATH"],
-		S3Bucket:            required["S3_BUCKET"],
-		S3Region:            required["S3_REGION"],
-		StripeSecretKey:     required["STRIPE_SECRET_KEY"],
-		StripeWebhookSecret: required["STRIPE_WEBHOOK_SECRET"],
-		UploadBackend:       uploadBackend,
-		UploadPath:          uploadPath,
+		Port:                 required["PORT"],
+		JWTSecret:            required["JWT_SECRET"],
+		JWTKeys:              jwtKeys,
+		RefreshSecret:        required["REFRESH_SECRET"],
+		Issuer:               required["ISSUER"],
+		Audience:             required["AUDIENCE"],
+		CredsPath:            required["GOOGLE_CREDENTIALS_PATH"],
+		S3Bucket:             required["S3_BUCKET"],
+		S3Region:             required["S3_REGION"],
+		StripeSecretKey:      required["STRIPE_SECRET_KEY"],
+		StripeWebhookSecret:  required["STRIPE_WEBHOOK_SECRET"],
+		UploadBackend:        uploadBackend,
+		UploadPath:           uploadPath,
+		OrderHoldWindow:      holdWindow,
+		OrderReaperInterval:  reaperInterval,
+		ReviewsVerifiedOnly:  b.provider.GetBoolOrDefault("REVIEWS_VERIFIED_ONLY", false),
+		SignInMaxAttempts:    signInMaxAttempts,
+		SignInIPMaxAttempts:  signInIPMaxAttempts,
+		SignInLockout:        signInLockout,
+		SignInMaxDelay:       signInMaxDelay,
+		SignInTrustedProxies: trustedProxies,
+		Mail:                 mailConfig,
+		AppBaseURL:           b.provider.GetStringOrDefault("APP_BASE_URL", "http://localhost:3000"),
 	}
+	config.EmailVerificationRequired = b.provider.GetBoolOrDefault("EMAIL_VERIFICATION_REQUIRED", false)
+	config.ReviewsRequireApproval, config.ReviewBannedWords, config.ReviewMinWords = b.getReviewModerationConfig()
+	config.ReviewMediaMaxFileSize, config.ReviewMediaMaxPerUser, config.ReviewMediaMaxPerReview = b.getReviewMediaConfig()
 
 	if b.redis != nil {
 		if err := b.connectRedis(ctx, config); err != nil {
@@ -6,7 +6,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/redis/go-redis/v9"
@@ -15,6 +18,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
 )
 
 // builder_test.go: Tests for configuration builder logic and provider integration.
@@ -212,6 +216,7 @@ func TestBuilder_WithAllProviders(t *testing.T) {
 		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
 		// Don't set REDIS_ADDR to avoid real connection attempts
 	}}
+	stubMongoIndexes(t, nil)
 
 	builder := NewConfigBuilder().
 		WithProvider(provider).
@@ -247,8 +252,143 @@ func TestBuilder_DefaultValues(t *testing.T) {
 
 	// This will fail on service connections, but we can check the default values
 	if err == nil {
-		assert.Equal(t, "local", cfg.UploadBackend)  // default value
-		assert.Equal(t, "./uploads", cfg.UploadPath) // default value
+		assert.Equal(t, "local", cfg.UploadBackend)               // default value
+		assert.Equal(t, "./uploads", cfg.UploadPath)              // default value
+		assert.Equal(t, 30*time.Minute, cfg.OrderHoldWindow)      // default value
+		assert.Equal(t, time.Minute, cfg.OrderReaperInterval)     // default value
+		assert.False(t, cfg.ReviewsVerifiedOnly)                  // default value
+		assert.False(t, cfg.ReviewsRequireApproval)               // default value
+		assert.Empty(t, cfg.ReviewBannedWords)                    // default value
+		assert.Equal(t, 2, cfg.ReviewMinWords)                    // default value
+		assert.Equal(t, int64(5<<20), cfg.ReviewMediaMaxFileSize) // default value
+		assert.Equal(t, 50, cfg.ReviewMediaMaxPerUser)            // default value
+		assert.Equal(t, 5, cfg.ReviewMediaMaxPerReview)           // default value
+	}
+}
+
+// TestBuilder_ReviewModerationConfig tests that the banned word list is split and trimmed.
+func TestBuilder_ReviewModerationConfig(t *testing.T) {
+	provider := &mockProvider{values: map[string]string{
+		"PORT": "8080", "JWT_SECRET": "jwt", "REFRESH_SECRET": "refresh", "ISSUER": "issuer", "AUDIENCE": "aud",
+		"GOOGLE_CREDENTIALS_PATH": "creds.json", "S3_BUCKET": "bucket", "S3_REGION": "region", "STRIPE_SECRET_KEY": "sk",
+		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
+		"REVIEWS_REQUIRE_APPROVAL": "true", "REVIEW_BANNED_WORDS": " scam, ,fake ",
+	}}
+
+	cfg, err := NewConfigBuilder().WithProvider(provider).Build(context.Background())
+	require.NoError(t, err)
+	assert.True(t, cfg.ReviewsRequireApproval)
+	assert.Equal(t, []string{"scam", "fake"}, cfg.ReviewBannedWords)
+}
+
+// TestBuilder_OrderHoldConfig tests the config builder with custom and invalid order hold settings.
+// It verifies that durations are parsed and that invalid values are rejected.
+func TestBuilder_OrderHoldConfig(t *testing.T) {
+	base := map[string]string{
+		"PORT": "8080", "JWT_SECRET": "jwt", "REFRESH_SECRET": "refresh", "ISSUER": "issuer", "AUDIENCE": "aud",
+		"GOOGLE_CREDENTIALS_PATH": "creds.json", "S3_BUCKET": "bucket", "S3_REGION": "region", "STRIPE_SECRET_KEY": "sk",
+		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
+	}
+	withValues := func(extra map[string]string) *mockProvider {
+		values := make(map[string]string, len(base)+len(extra))
+		for k, v := range base {
+			values[k] = v
+		}
+		for k, v := range extra {
+			values[k] = v
+		}
+		return &mockProvider{values: values}
+	}
+
+	cfg, err := NewConfigBuilder().WithProvider(withValues(map[string]string{
+		"ORDER_HOLD_WINDOW": "15m", "ORDER_REAPER_INTERVAL": "30s",
+	})).Build(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, cfg.OrderHoldWindow)
+	assert.Equal(t, 30*time.Second, cfg.OrderReaperInterval)
+
+	_, err = NewConfigBuilder().WithProvider(withValues(map[string]string{
+		"ORDER_HOLD_WINDOW": "soon",
+	})).Build(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ORDER_HOLD_WINDOW")
+
+	_, err = NewConfigBuilder().WithProvider(withValues(map[string]string{
+		"ORDER_REAPER_INTERVAL": "-1m",
+	})).Build(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ORDER_REAPER_INTERVAL")
+}
+
+// TestBuilder_SignInLimitConfig tests the failed sign-in limit defaults, trusted proxy parsing, and that invalid
+// durations and proxies are rejected.
+func TestBuilder_SignInLimitConfig(t *testing.T) {
+	base := map[string]string{
+		"PORT": "8080", "JWT_SECRET": "jwt", "REFRESH_SECRET": "refresh", "ISSUER": "issuer", "AUDIENCE": "aud",
+		"GOOGLE_CREDENTIALS_PATH": "creds.json", "S3_BUCKET": "bucket", "S3_REGION": "region", "STRIPE_SECRET_KEY": "sk",
+		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
+	}
+
+	cfg, err := NewConfigBuilder().WithProvider(&mockProvider{values: base}).Build(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.SignInMaxAttempts)
+	assert.Equal(t, 20, cfg.SignInIPMaxAttempts)
+	assert.Equal(t, 15*time.Minute, cfg.SignInLockout)
+	assert.Equal(t, 30*time.Second, cfg.SignInMaxDelay)
+	assert.Empty(t, cfg.SignInTrustedProxies)
+
+	values := map[string]string{"SIGNIN_TRUSTED_PROXIES": "10.0.0.0/8, 127.0.0.1"}
+	for k, v := range base {
+		values[k] = v
+	}
+	cfg, err = NewConfigBuilder().WithProvider(&mockProvider{values: values}).Build(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cfg.SignInTrustedProxies, 2)
+	assert.Equal(t, "10.0.0.0/8", cfg.SignInTrustedProxies[0].String())
+	assert.Equal(t, "127.0.0.1/32", cfg.SignInTrustedProxies[1].String())
+
+	for key, value := range map[string]string{"SIGNIN_LOCKOUT": "0s", "SIGNIN_MAX_DELAY": "0s", "SIGNIN_TRUSTED_PROXIES": "10.0.0.0/33"} {
+		values := map[string]string{key: value}
+		for k, v := range base {
+			values[k] = v
+		}
+		_, err := NewConfigBuilder().WithProvider(&mockProvider{values: values}).Build(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), key)
+	}
+}
+
+// TestBuilder_MailConfig tests the mail defaults and that an unusable mail backend fails the build.
+func TestBuilder_MailConfig(t *testing.T) {
+	base := map[string]string{
+		"PORT": "8080", "JWT_SECRET": "jwt", "REFRESH_SECRET": "refresh", "ISSUER": "issuer", "AUDIENCE": "aud",
+		"GOOGLE_CREDENTIALS_PATH": "creds.json", "S3_BUCKET": "bucket", "S3_REGION": "region", "STRIPE_SECRET_KEY": "sk",
+		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
+	}
+
+	cfg, err := NewConfigBuilder().WithProvider(&mockProvider{values: base}).Build(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "file", cfg.Mail.Backend)
+	assert.Equal(t, 587, cfg.Mail.SMTPPort)
+	assert.Equal(t, "http://localhost:3000", cfg.AppBaseURL)
+	assert.False(t, cfg.EmailVerificationRequired)
+
+	for name, extra := range map[string]map[string]string{
+		"unknown_backend": {"MAIL_BACKEND": "pigeon"},
+		"smtp_no_host":    {"MAIL_BACKEND": "smtp"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			values := map[string]string{}
+			for k, v := range base {
+				values[k] = v
+			}
+			for k, v := range extra {
+				values[k] = v
+			}
+			_, err := NewConfigBuilder().WithProvider(&mockProvider{values: values}).Build(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid mail config")
+		})
 	}
 }
 
@@ -505,10 +645,23 @@ func (s *successMongoProvider) Connect(ctx context.Context) (*mongo.Client, *mon
 // Close is a no-op close method for successMongoProvider.
 func (s *successMongoProvider) Close(ctx context.Context) error { return nil }
 
-// TestBuilder_connectMongo_Success verifies that the builder correctly connects to MongoDB.
+// stubMongoIndexes replaces index creation for the duration of a test and records the database it was called with.
+func stubMongoIndexes(t *testing.T, err error) **mongo.Database {
+	var indexed *mongo.Database
+	original := createMongoIndexes
+	createMongoIndexes = func(db *mongo.Database) error {
+		indexed = db
+		return err
+	}
+	t.Cleanup(func() { createMongoIndexes = original })
+	return &indexed
+}
+
+// TestBuilder_connectMongo_Success verifies that the builder correctly connects to MongoDB and creates its indexes.
 func TestBuilder_connectMongo_Success(t *testing.T) {
 	client := &mongo.Client{}
 	db := &mongo.Database{}
+	indexed := stubMongoIndexes(t, nil)
 	b := &BuilderImpl{
 		provider: &mockProvider{values: map[string]string{}},
 		mongo:    &successMongoProvider{client: client, db: db},
@@ -518,6 +671,21 @@ func TestBuilder_connectMongo_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, client, config.MongoClient)
 	assert.Equal(t, db, config.MongoDB)
+	assert.Same(t, db, *indexed)
+}
+
+// TestBuilder_connectMongo_IndexError verifies that startup stops when the MongoDB indexes cannot be created.
+func TestBuilder_connectMongo_IndexError(t *testing.T) {
+	stubMongoIndexes(t, errors.New("duplicate key"))
+	b := &BuilderImpl{
+		provider: &mockProvider{values: map[string]string{}},
+		mongo:    &successMongoProvider{client: &mongo.Client{}, db: &mongo.Database{}},
+	}
+	config := &APIConfig{}
+	err := b.connectMongo(context.Background(), config, "mongodb://localhost:27017")
+	require.ErrorContains(t, err, "failed to create MongoDB indexes")
+	assert.Nil(t, config.MongoClient)
+	assert.Nil(t, config.MongoDB)
 }
 
 // errorOAuthProvider is a mock OAuth provider that simulates a failure to load config.
@@ -542,3 +710,36 @@ func TestBuilder_Build_ValidatorError(t *testing.T) {
 	assert.Nil(t, cfg)
 	assert.Contains(t, err.Error(), "failed to get PORT")
 }
+
+// TestBuilder_JWTKeys tests that keys load from JWT_KEYS_DIR and that a missing signing key fails the build.
+func TestBuilder_JWTKeys(t *testing.T) {
+	base := map[string]string{
+		"PORT": "8080", "JWT_SECRET": "jwt", "REFRESH_SECRET": "refresh", "ISSUER": "issuer", "AUDIENCE": "aud",
+		"GOOGLE_CREDENTIALS_PATH": "creds.json", "S3_BUCKET": "bucket", "S3_REGION": "region", "STRIPE_SECRET_KEY": "sk",
+		"STRIPE_WEBHOOK_SECRET": "wh", "MONGO_URI": "mongodb://localhost:27017",
+	}
+
+	cfg, err := NewConfigBuilder().WithProvider(&mockProvider{values: base}).Build(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, cfg.JWTKeys)
+
+	dir := t.TempDir()
+	priv, err := jwtkeys.Generate(jwtkeys.AlgEdDSA)
+	require.NoError(t, err)
+	data, err := jwtkeys.EncodePrivateKeyPEM(priv)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2026-10.pem"), data, 0o600))
+
+	values := map[string]string{"JWT_KEYS_DIR": dir, "JWT_SIGNING_KEY_ID": "2026-10"}
+	for k, v := range base {
+		values[k] = v
+	}
+	cfg, err = NewConfigBuilder().WithProvider(&mockProvider{values: values}).Build(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, cfg.JWTKeys)
+	assert.Equal(t, "2026-10", cfg.JWTKeys.SigningKey().ID)
+
+	values["JWT_SIGNING_KEY_ID"] = "2026-11"
+	_, err = NewConfigBuilder().WithProvider(&mockProvider{values: values}).Build(context.Background())
+	require.ErrorContains(t, err, "invalid JWT keys")
+}
@@ -6,19 +6,23 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/jwtkeys"
+	"github.com/STaninnat/ecom-backend/internal/mailer"
 )
 
 // config.go: Main API configuration struct, loading, and environment integration.
 
-// APIConfig holds all configuration for the API, including server, JWT, database, Redis, MongoDB, S3, Stripe, upload, and OAuth settings.
+// APIConfig holds all configuration for the API, including server, JWT, database, Redis, MongoDB, S3, Stripe, upload, OAuth, and order hold settings.
 type APIConfig struct {
 	// Server configuration
 	Port string
@@ -28,6 +32,8 @@ type APIConfig struct {
 	RefreshSecret string
 	Issuer        string
 	Audience      string
+	// JWTKeys signs access tokens with RS256 or EdDSA; nil falls back to HS256 with JWTSecret
+	JWTKeys *jwtkeys.KeySet
 
 	// Database configuration
 	DBConn *sql.DB
@@ -55,6 +61,33 @@ type APIConfig struct {
 
 	// OAuth configuration
 	CredsPath string
+
+	// Order hold configuration
+	OrderHoldWindow     time.Duration
+	OrderReaperInterval time.Duration
+
+	// Review configuration
+	ReviewsVerifiedOnly    bool
+	ReviewsRequireApproval bool
+	ReviewBannedWords      []string
+	ReviewMinWords         int
+
+	// Failed sign-in limits
+	SignInMaxAttempts    int
+	SignInIPMaxAttempts  int
+	SignInLockout        time.Duration
+	SignInMaxDelay       time.Duration
+	SignInTrustedProxies []*net.IPNet
+
+	// Review media configuration
+	ReviewMediaMaxFileSize  int64
+	ReviewMediaMaxPerUser   int
+	ReviewMediaMaxPerReview int
+
+	// Account email configuration
+	Mail                      mailer.Config
+	AppBaseURL                string
+	EmailVerificationRequired bool
 }
 
 // LoadConfig loads configuration from environment variables and initializes services.
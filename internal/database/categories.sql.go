@@ -46,11 +46,41 @@ func (q *Queries) DeleteCategory(ctx context.Context, id string) error {
 }
 
 const getAllCategories = `-- name: GetAllCategories :many
-SELECT id, name, description, created_at, updated_at FROM categories ORDER BY name
+SELECT id, name, description, created_at, updated_at FROM categories
+WHERE
+    $1::text IS NULL OR CASE $2::text
+        WHEN 'name_desc' THEN (name, id) < ($3::text, $1::text)
+        WHEN 'newest' THEN (created_at, id) < ($4::timestamp, $1::text)
+        WHEN 'oldest' THEN (created_at, id) > ($4::timestamp, $1::text)
+        ELSE (name, id) > ($3::text, $1::text)
+    END
+ORDER BY
+    CASE WHEN $2::text = 'name_desc' THEN name END DESC,
+    CASE WHEN $2::text = 'newest' THEN created_at END DESC,
+    CASE WHEN $2::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN $2::text IN ('name_desc', 'newest') THEN id END DESC,
+    name,
+    id
+LIMIT $5
 `
 
-func (q *Queries) GetAllCategories(ctx context.Context) ([]Category, error) {
-	rows, err := q.db.QueryContext(ctx, getAllCategories)
+type GetAllCategoriesParams struct {
+	CursorID        sql.NullString
+	Sort            string
+	CursorValue     sql.NullString
+	CursorCreatedAt sql.NullTime
+	Limit           int32
+}
+
+// Keyset pagination: pass the last row of the previous page as the cursor_* args. Sorted by name unless sort says otherwise.
+func (q *Queries) GetAllCategories(ctx context.Context, arg GetAllCategoriesParams) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getAllCategories,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorValue,
+		arg.CursorCreatedAt,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -29,6 +30,7 @@ type Order struct {
 	ContactPhone      sql.NullString
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
+	RefundStatus      string
 }
 
 type OrderItem struct {
@@ -39,6 +41,17 @@ type OrderItem struct {
 	Price     string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	VariantID sql.NullString
+}
+
+type OrderStatusHistory struct {
+	ID          string
+	OrderID     string
+	FromStatus  string
+	ToStatus    string
+	ActorUserID sql.NullString
+	Reason      sql.NullString
+	CreatedAt   time.Time
 }
 
 type Payment struct {
@@ -55,28 +68,125 @@ type Payment struct {
 }
 
 type Product struct {
-	ID          string
-	CategoryID  sql.NullString
-	Name        string
-	Description sql.NullString
-	Price       string
-	Stock       int32
-	ImageUrl    sql.NullString
-	IsActive    bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID           string
+	CategoryID   sql.NullString
+	Name         string
+	Description  sql.NullString
+	Price        string
+	Stock        int32
+	ImageUrl     sql.NullString
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	RatingAvg    string
+	RatingCount  int32
+	Rating1Count int32
+	Rating2Count int32
+	Rating3Count int32
+	Rating4Count int32
+	Rating5Count int32
 }
 
-type User struct {
+type ProductImage struct {
+	ID           string
+	ProductID    string
+	ImageUrl     string
+	AltText      sql.NullString
+	Position     int32
+	IsPrimary    bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ThumbnailUrl sql.NullString
+	MediumUrl    sql.NullString
+	LargeUrl     sql.NullString
+}
+
+type ProductVariant struct {
+	ID        string
+	ProductID string
+	Sku       string
+	Size      sql.NullString
+	Color     sql.NullString
+	Price     sql.NullString
+	Stock     int32
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Refund struct {
+	ID               string
+	PaymentID        string
+	OrderID          string
+	Amount           string
+	Currency         string
+	Status           string
+	ProviderRefundID sql.NullString
+	Reason           sql.NullString
+	CreatedBy        sql.NullString
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type RefundRequest struct {
 	ID         string
-	Name       string
-	Email      string
-	Password   sql.NullString
-	Provider   string
-	ProviderID sql.NullString
-	Phone      sql.NullString
-	Address    sql.NullString
-	Role       string
+	OrderID    string
+	PaymentID  string
+	UserID     string
+	Amount     string
+	Currency   string
+	Items      json.RawMessage
+	Reason     string
+	Status     string
+	RefundID   sql.NullString
+	ReviewedBy sql.NullString
+	ReviewNote sql.NullString
+	ReviewedAt sql.NullTime
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+type RefundItem struct {
+	RefundID    string
+	OrderItemID string
+	Quantity    int32
+	Amount      string
+}
+
+type SecurityEvent struct {
+	ID          string
+	EventType   string
+	Subject     string
+	IpAddress   sql.NullString
+	ActorUserID sql.NullString
+	Details     sql.NullString
+	CreatedAt   time.Time
+}
+
+type User struct {
+	ID              string
+	Name            string
+	Email           string
+	Password        sql.NullString
+	Provider        string
+	ProviderID      sql.NullString
+	Phone           sql.NullString
+	Address         sql.NullString
+	Role            string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	EmailVerifiedAt sql.NullTime
+}
+
+type WebhookEvent struct {
+	ID                string
+	Type              string
+	Payload           json.RawMessage
+	ProviderPaymentID sql.NullString
+	Status            string
+	LastError         sql.NullString
+	Attempts          int32
+	EventCreatedAt    time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -7,16 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const createOrderItem = `-- name: CreateOrderItem :exec
 INSERT INTO order_items (
-    id, order_id, product_id, quantity, price,
+    id, order_id, product_id, variant_id, quantity, price,
     created_at, updated_at
 )
 VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 `
 
@@ -24,6 +25,7 @@ type CreateOrderItemParams struct {
 	ID        string
 	OrderID   string
 	ProductID string
+	VariantID sql.NullString
 	Quantity  int32
 	Price     string
 	CreatedAt time.Time
@@ -35,6 +37,7 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		arg.ID,
 		arg.OrderID,
 		arg.ProductID,
+		arg.VariantID,
 		arg.Quantity,
 		arg.Price,
 		arg.CreatedAt,
@@ -44,7 +47,7 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 }
 
 const getOrderItemsByOrderID = `-- name: GetOrderItemsByOrderID :many
-SELECT id, order_id, product_id, quantity, price, created_at, updated_at FROM order_items 
+SELECT id, order_id, product_id, quantity, price, created_at, updated_at, variant_id FROM order_items 
 WHERE order_id = $1
 `
 
@@ -65,6 +68,7 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID string) ([
 			&i.Price,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.VariantID,
 		); err != nil {
 			return nil, err
 		}
@@ -78,3 +82,25 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID string) ([
 	}
 	return items, nil
 }
+
+const hasDeliveredOrderWithProduct = `-- name: HasDeliveredOrderWithProduct :one
+SELECT EXISTS (
+    SELECT 1 FROM order_items
+    JOIN orders ON orders.id = order_items.order_id
+    WHERE orders.user_id = $1
+      AND order_items.product_id = $2
+      AND orders.status = 'delivered'
+)
+`
+
+type HasDeliveredOrderWithProductParams struct {
+	UserID    string
+	ProductID string
+}
+
+func (q *Queries) HasDeliveredOrderWithProduct(ctx context.Context, arg HasDeliveredOrderWithProductParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasDeliveredOrderWithProduct, arg.UserID, arg.ProductID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -20,7 +20,7 @@ INSERT INTO orders (
 VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 )
-RETURNING id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at
+RETURNING id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status
 `
 
 type CreateOrderParams struct {
@@ -64,6 +64,7 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.ContactPhone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RefundStatus,
 	)
 	return i, err
 }
@@ -79,7 +80,7 @@ func (q *Queries) DeleteOrderByID(ctx context.Context, id string) error {
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at FROM orders 
+SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status FROM orders 
 WHERE id = $1
 `
 
@@ -98,12 +99,39 @@ func (q *Queries) GetOrderByID(ctx context.Context, id string) (Order, error) {
 		&i.ContactPhone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RefundStatus,
+	)
+	return i, err
+}
+
+const getOrderByIDForUpdate = `-- name: GetOrderByIDForUpdate :one
+SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderByIDForUpdate(ctx context.Context, id string) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderByIDForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TotalAmount,
+		&i.Status,
+		&i.PaymentMethod,
+		&i.ExternalPaymentID,
+		&i.TrackingNumber,
+		&i.ShippingAddress,
+		&i.ContactPhone,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RefundStatus,
 	)
 	return i, err
 }
 
 const getOrderByUserID = `-- name: GetOrderByUserID :many
-SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at FROM orders 
+SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status FROM orders 
 WHERE user_id = $1
 `
 
@@ -128,6 +156,7 @@ func (q *Queries) GetOrderByUserID(ctx context.Context, userID string) ([]Order,
 			&i.ContactPhone,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RefundStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -143,12 +172,35 @@ func (q *Queries) GetOrderByUserID(ctx context.Context, userID string) ([]Order,
 }
 
 const listAllOrders = `-- name: ListAllOrders :many
-SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at FROM orders 
-ORDER BY created_at DESC
+SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status FROM orders
+WHERE
+    $1::text IS NULL OR CASE $2::text
+        WHEN 'oldest' THEN (created_at, id) > ($3::timestamp, $1::text)
+        ELSE (created_at, id) < ($3::timestamp, $1::text)
+    END
+ORDER BY
+    CASE WHEN $2::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN $2::text = 'oldest' THEN id END ASC,
+    created_at DESC,
+    id DESC
+LIMIT $4
 `
 
-func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
-	rows, err := q.db.QueryContext(ctx, listAllOrders)
+type ListAllOrdersParams struct {
+	CursorID        sql.NullString
+	Sort            string
+	CursorCreatedAt sql.NullTime
+	Limit           int32
+}
+
+// Keyset pagination: pass the last row of the previous page as the cursor_* args. Newest first unless sort is 'oldest'.
+func (q *Queries) ListAllOrders(ctx context.Context, arg ListAllOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listAllOrders,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorCreatedAt,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -168,6 +220,7 @@ func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
 			&i.ContactPhone,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RefundStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -182,6 +235,76 @@ func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
 	return items, nil
 }
 
+const listExpiredPendingOrders = `-- name: ListExpiredPendingOrders :many
+SELECT id, user_id, total_amount, status, payment_method, external_payment_id, tracking_number, shipping_address, contact_phone, created_at, updated_at, refund_status FROM orders
+WHERE status = 'pending' AND created_at < $1
+  AND NOT EXISTS (
+      SELECT 1 FROM payments
+      WHERE payments.order_id = orders.id AND payments.provider = 'manual' AND payments.status = 'pending'
+  )
+ORDER BY created_at
+LIMIT $2
+`
+
+type ListExpiredPendingOrdersParams struct {
+	CreatedAt time.Time
+	Limit     int32
+}
+
+// Orders awaiting a manual payment (cash on delivery, bank transfer) are settled by an admin and never expire.
+func (q *Queries) ListExpiredPendingOrders(ctx context.Context, arg ListExpiredPendingOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredPendingOrders, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.TotalAmount,
+			&i.Status,
+			&i.PaymentMethod,
+			&i.ExternalPaymentID,
+			&i.TrackingNumber,
+			&i.ShippingAddress,
+			&i.ContactPhone,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RefundStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrderRefundStatus = `-- name: UpdateOrderRefundStatus :exec
+UPDATE orders
+SET refund_status = $2, updated_at = $3
+WHERE id = $1
+`
+
+type UpdateOrderRefundStatusParams struct {
+	ID           string
+	RefundStatus string
+	UpdatedAt    time.Time
+}
+
+func (q *Queries) UpdateOrderRefundStatus(ctx context.Context, arg UpdateOrderRefundStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateOrderRefundStatus, arg.ID, arg.RefundStatus, arg.UpdatedAt)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status = $2, updated_at = $3
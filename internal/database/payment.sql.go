@@ -59,11 +59,37 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (C
 const getAllPayments = `-- name: GetAllPayments :many
 SELECT id, order_id, user_id, amount, currency, status, provider, provider_payment_id, created_at, updated_at
 FROM payments
-ORDER BY updated_at DESC
+WHERE
+    (status = $1 OR $1 IS NULL) AND
+    ($2::text IS NULL OR CASE $3::text
+        WHEN 'oldest' THEN (created_at, id) > ($4::timestamp, $2::text)
+        ELSE (created_at, id) < ($4::timestamp, $2::text)
+    END)
+ORDER BY
+    CASE WHEN $3::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN $3::text = 'oldest' THEN id END ASC,
+    created_at DESC,
+    id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetAllPayments(ctx context.Context) ([]Payment, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPayments)
+type GetAllPaymentsParams struct {
+	Status          sql.NullString
+	CursorID        sql.NullString
+	Sort            string
+	CursorCreatedAt sql.NullTime
+	Limit           int32
+}
+
+// Keyset pagination: pass the last row of the previous page as the cursor_* args. Newest first unless sort is 'oldest'.
+func (q *Queries) GetAllPayments(ctx context.Context, arg GetAllPaymentsParams) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPayments,
+		arg.Status,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorCreatedAt,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -121,15 +147,38 @@ func (q *Queries) GetPaymentByOrderID(ctx context.Context, orderID string) (Paym
 	return i, err
 }
 
-const getPaymentsByStatus = `-- name: GetPaymentsByStatus :many
-SELECT id, order_id, user_id, amount, currency, status, provider, provider_payment_id, created_at, updated_at
-FROM payments
-WHERE status = $1
+const getPaymentByProviderPaymentID = `-- name: GetPaymentByProviderPaymentID :one
+SELECT id, order_id, user_id, amount, currency, status, provider, provider_payment_id, created_at, updated_at FROM payments
+WHERE provider_payment_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPaymentByProviderPaymentID(ctx context.Context, providerPaymentID sql.NullString) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentByProviderPaymentID, providerPaymentID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.UserID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.Provider,
+		&i.ProviderPaymentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPaymentsByUserID = `-- name: GetPaymentsByUserID :many
+SELECT id, order_id, user_id, amount, currency, status, provider, provider_payment_id, created_at, updated_at FROM payments
+WHERE user_id = $1
 ORDER BY updated_at DESC
 `
 
-func (q *Queries) GetPaymentsByStatus(ctx context.Context, status string) ([]Payment, error) {
-	rows, err := q.db.QueryContext(ctx, getPaymentsByStatus, status)
+func (q *Queries) GetPaymentsByUserID(ctx context.Context, userID string) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, getPaymentsByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -162,14 +211,20 @@ func (q *Queries) GetPaymentsByStatus(ctx context.Context, status string) ([]Pay
 	return items, nil
 }
 
-const getPaymentsByUserID = `-- name: GetPaymentsByUserID :many
+const listCancelPendingPayments = `-- name: ListCancelPendingPayments :many
 SELECT id, order_id, user_id, amount, currency, status, provider, provider_payment_id, created_at, updated_at FROM payments
-WHERE user_id = $1
-ORDER BY updated_at DESC
+WHERE status = 'cancel_pending' AND updated_at < $1
+ORDER BY updated_at
+LIMIT $2
 `
 
-func (q *Queries) GetPaymentsByUserID(ctx context.Context, userID string) ([]Payment, error) {
-	rows, err := q.db.QueryContext(ctx, getPaymentsByUserID, userID)
+type ListCancelPendingPaymentsParams struct {
+	UpdatedAt time.Time
+	Limit     int32
+}
+
+func (q *Queries) ListCancelPendingPayments(ctx context.Context, arg ListCancelPendingPaymentsParams) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, listCancelPendingPayments, arg.UpdatedAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -202,6 +257,43 @@ func (q *Queries) GetPaymentsByUserID(ctx context.Context, userID string) ([]Pay
 	return items, nil
 }
 
+const markPaymentCancelled = `-- name: MarkPaymentCancelled :execrows
+UPDATE payments
+SET status = 'cancelled', updated_at = $2
+WHERE id = $1 AND status = 'cancel_pending'
+`
+
+type MarkPaymentCancelledParams struct {
+	ID        string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) MarkPaymentCancelled(ctx context.Context, arg MarkPaymentCancelledParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markPaymentCancelled, arg.ID, arg.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markPaymentsCancelPendingByOrderID = `-- name: MarkPaymentsCancelPendingByOrderID :exec
+UPDATE payments
+SET status = 'cancel_pending', updated_at = $2
+WHERE order_id = $1 AND status IN ('pending', 'failed')
+`
+
+type MarkPaymentsCancelPendingByOrderIDParams struct {
+	OrderID   string
+	UpdatedAt time.Time
+}
+
+// Failed payments are included: their intent stays open for another attempt until it is cancelled.
+// The payments become cancelled once their intents are voided with the provider, after the order release commits.
+func (q *Queries) MarkPaymentsCancelPendingByOrderID(ctx context.Context, arg MarkPaymentsCancelPendingByOrderIDParams) error {
+	_, err := q.db.ExecContext(ctx, markPaymentsCancelPendingByOrderID, arg.OrderID, arg.UpdatedAt)
+	return err
+}
+
 const updatePaymentStatus = `-- name: UpdatePaymentStatus :exec
 UPDATE payments
 SET status = $2, updated_at = $3
@@ -45,6 +45,26 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) er
 	return err
 }
 
+const decrementProductStock = `-- name: DecrementProductStock :execrows
+UPDATE products
+SET stock = stock - $1::int, updated_at = $2
+WHERE id = $3 AND stock >= $1::int
+`
+
+type DecrementProductStockParams struct {
+	Quantity  int32
+	UpdatedAt time.Time
+	ID        string
+}
+
+func (q *Queries) DecrementProductStock(ctx context.Context, arg DecrementProductStockParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, decrementProductStock, arg.Quantity, arg.UpdatedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteProductByID = `-- name: DeleteProductByID :exec
 DELETE FROM products 
 WHERE id = $1
@@ -56,29 +76,70 @@ func (q *Queries) DeleteProductByID(ctx context.Context, id string) error {
 }
 
 const filterProducts = `-- name: FilterProducts :many
-SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at
+SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at, rating_avg, rating_count, rating_1_count, rating_2_count, rating_3_count, rating_4_count, rating_5_count
 FROM products
 WHERE
     (category_id = $1 OR $1 IS NULL) AND
     (is_active = $2 OR $2 IS NULL) AND
     (price >= $3 OR $3 IS NULL) AND
-    (price <= $4 OR $4 IS NULL)
-ORDER BY created_at DESC
+    (price <= $4 OR $4 IS NULL) AND
+    (rating_avg >= $5 OR $5 IS NULL) AND
+    ($6::text IS NULL OR CASE $7::text
+        WHEN 'oldest' THEN (created_at, id) > ($8::timestamp, $6::text)
+        WHEN 'price_asc' THEN (price, id) > ($9::numeric, $6::text)
+        WHEN 'price_desc' THEN (price, id) < ($9::numeric, $6::text)
+        WHEN 'name_asc' THEN (name, id) > ($9::text, $6::text)
+        WHEN 'name_desc' THEN (name, id) < ($9::text, $6::text)
+        WHEN 'rating_desc' THEN (rating_avg, rating_count, id) < ($9::numeric, $10::int, $6::text)
+        WHEN 'rating_asc' THEN (rating_avg, id) > ($9::numeric, $6::text)
+        WHEN 'reviews_desc' THEN (rating_count, id) < ($10::int, $6::text)
+        ELSE (created_at, id) < ($8::timestamp, $6::text)
+    END)
+ORDER BY
+    CASE WHEN $7::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN $7::text = 'price_asc' THEN price END ASC,
+    CASE WHEN $7::text = 'price_desc' THEN price END DESC,
+    CASE WHEN $7::text = 'name_asc' THEN name END ASC,
+    CASE WHEN $7::text = 'name_desc' THEN name END DESC,
+    CASE WHEN $7::text = 'rating_desc' THEN rating_avg END DESC,
+    CASE WHEN $7::text = 'rating_asc' THEN rating_avg END ASC,
+    CASE WHEN $7::text IN ('rating_desc', 'reviews_desc') THEN rating_count END DESC,
+    CASE WHEN $7::text IN ('oldest', 'price_asc', 'name_asc', 'rating_asc') THEN id END ASC,
+    CASE WHEN $7::text IN ('price_desc', 'name_desc', 'rating_desc', 'reviews_desc') THEN id END DESC,
+    created_at DESC,
+    id DESC
+LIMIT $11
 `
 
 type FilterProductsParams struct {
-	CategoryID sql.NullString
-	IsActive   sql.NullBool
-	MinPrice   sql.NullString
-	MaxPrice   sql.NullString
+	CategoryID      sql.NullString
+	IsActive        sql.NullBool
+	MinPrice        sql.NullString
+	MaxPrice        sql.NullString
+	MinRating       sql.NullString
+	CursorID        sql.NullString
+	Sort            string
+	CursorCreatedAt sql.NullTime
+	CursorValue     sql.NullString
+	CursorCount     sql.NullInt32
+	Limit           int32
 }
 
+// Keyset pagination: pass the last row of the previous page as the cursor_* args (only those its sort orders by).
+// Every sort ends with id so the order is total and the cursor comparison matches the ORDER BY.
 func (q *Queries) FilterProducts(ctx context.Context, arg FilterProductsParams) ([]Product, error) {
 	rows, err := q.db.QueryContext(ctx, filterProducts,
 		arg.CategoryID,
 		arg.IsActive,
 		arg.MinPrice,
 		arg.MaxPrice,
+		arg.MinRating,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorCreatedAt,
+		arg.CursorValue,
+		arg.CursorCount,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -98,6 +159,13 @@ func (q *Queries) FilterProducts(ctx context.Context, arg FilterProductsParams)
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RatingAvg,
+			&i.RatingCount,
+			&i.Rating1Count,
+			&i.Rating2Count,
+			&i.Rating3Count,
+			&i.Rating4Count,
+			&i.Rating5Count,
 		); err != nil {
 			return nil, err
 		}
@@ -113,7 +181,7 @@ func (q *Queries) FilterProducts(ctx context.Context, arg FilterProductsParams)
 }
 
 const getActiveProductByID = `-- name: GetActiveProductByID :one
-SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at
+SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at, rating_avg, rating_count, rating_1_count, rating_2_count, rating_3_count, rating_4_count, rating_5_count
 FROM products
 WHERE id = $1 AND is_active = TRUE
 `
@@ -132,41 +200,113 @@ func (q *Queries) GetActiveProductByID(ctx context.Context, id string) (Product,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RatingAvg,
+		&i.RatingCount,
+		&i.Rating1Count,
+		&i.Rating2Count,
+		&i.Rating3Count,
+		&i.Rating4Count,
+		&i.Rating5Count,
 	)
 	return i, err
 }
 
-const getAllActiveProducts = `-- name: GetAllActiveProducts :many
-SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at
-FROM products
-WHERE is_active = TRUE
-ORDER BY updated_at DESC
+const getProductByID = `-- name: GetProductByID :one
+SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at, rating_avg, rating_count, rating_1_count, rating_2_count, rating_3_count, rating_4_count, rating_5_count FROM products 
+WHERE id = $1
+`
+
+func (q *Queries) GetProductByID(ctx context.Context, id string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByID, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.ImageUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RatingAvg,
+		&i.RatingCount,
+		&i.Rating1Count,
+		&i.Rating2Count,
+		&i.Rating3Count,
+		&i.Rating4Count,
+		&i.Rating5Count,
+	)
+	return i, err
+}
+
+const getProductByIDForUpdate = `-- name: GetProductByIDForUpdate :one
+SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at, rating_avg, rating_count, rating_1_count, rating_2_count, rating_3_count, rating_4_count, rating_5_count FROM products
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetProductByIDForUpdate(ctx context.Context, id string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByIDForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.ImageUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RatingAvg,
+		&i.RatingCount,
+		&i.Rating1Count,
+		&i.Rating2Count,
+		&i.Rating3Count,
+		&i.Rating4Count,
+		&i.Rating5Count,
+	)
+	return i, err
+}
+
+const incrementProductStock = `-- name: IncrementProductStock :exec
+UPDATE products
+SET stock = stock + $1::int, updated_at = $2
+WHERE id = $3
+`
+
+type IncrementProductStockParams struct {
+	Quantity  int32
+	UpdatedAt time.Time
+	ID        string
+}
+
+func (q *Queries) IncrementProductStock(ctx context.Context, arg IncrementProductStockParams) error {
+	_, err := q.db.ExecContext(ctx, incrementProductStock, arg.Quantity, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const listProductIDs = `-- name: ListProductIDs :many
+SELECT id FROM products
+ORDER BY id
 `
 
-func (q *Queries) GetAllActiveProducts(ctx context.Context) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, getAllActiveProducts)
+func (q *Queries) ListProductIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listProductIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Product
+	var items []string
 	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
-			&i.ID,
-			&i.CategoryID,
-			&i.Name,
-			&i.Description,
-			&i.Price,
-			&i.Stock,
-			&i.ImageUrl,
-			&i.IsActive,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
+		var id string
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -177,20 +317,82 @@ func (q *Queries) GetAllActiveProducts(ctx context.Context) ([]Product, error) {
 	return items, nil
 }
 
-const getAllProducts = `-- name: GetAllProducts :many
-SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at FROM products 
-ORDER BY updated_at DESC
+const searchProducts = `-- name: SearchProducts :many
+SELECT
+    p.id, p.category_id, p.name, p.description, p.price, p.stock, p.image_url, p.is_active, p.created_at, p.updated_at,
+    p.rating_avg, p.rating_count, p.rating_1_count, p.rating_2_count, p.rating_3_count, p.rating_4_count, p.rating_5_count,
+    ts_rank(product_search_document(p.name, p.description), q.query)::real AS rank,
+    ts_headline('english', p.name, q.query, 'HighlightAll=true, StartSel=' || chr(57344) || ', StopSel=' || chr(57345))::text AS name_highlight,
+    ts_headline('english', coalesce(p.description, ''), q.query, 'MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" ... ", StartSel=' || chr(57344) || ', StopSel=' || chr(57345))::text AS snippet
+FROM products p, websearch_to_tsquery('english', $1::text) AS q(query)
+WHERE
+    product_search_document(p.name, p.description) @@ q.query AND
+    p.is_active = TRUE AND
+    (p.category_id = $2 OR $2 IS NULL) AND
+    (p.price >= $3 OR $3 IS NULL) AND
+    (p.price <= $4 OR $4 IS NULL) AND
+    (p.rating_avg >= $5 OR $5 IS NULL)
+ORDER BY
+    CASE WHEN $6::text = 'rating_desc' THEN p.rating_avg END DESC,
+    CASE WHEN $6::text = 'rating_asc' THEN p.rating_avg END ASC,
+    CASE WHEN $6::text IN ('rating_desc', 'reviews_desc') THEN p.rating_count END DESC,
+    CASE WHEN $6::text = 'newest' THEN p.created_at END DESC,
+    rank DESC,
+    p.created_at DESC
+LIMIT $7::int
 `
 
-func (q *Queries) GetAllProducts(ctx context.Context) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, getAllProducts)
+type SearchProductsParams struct {
+	Query      string
+	CategoryID sql.NullString
+	MinPrice   sql.NullString
+	MaxPrice   sql.NullString
+	MinRating  sql.NullString
+	Sort       string
+	Limit      int32
+}
+
+type SearchProductsRow struct {
+	ID            string
+	CategoryID    sql.NullString
+	Name          string
+	Description   sql.NullString
+	Price         string
+	Stock         int32
+	ImageUrl      sql.NullString
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	RatingAvg     string
+	RatingCount   int32
+	Rating1Count  int32
+	Rating2Count  int32
+	Rating3Count  int32
+	Rating4Count  int32
+	Rating5Count  int32
+	Rank          float32
+	NameHighlight string
+	Snippet       string
+}
+
+// Highlights are wrapped in U+E000/U+E001 so the caller can escape the text before adding markup.
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchProducts,
+		arg.Query,
+		arg.CategoryID,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.MinRating,
+		arg.Sort,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Product
+	var items []SearchProductsRow
 	for rows.Next() {
-		var i Product
+		var i SearchProductsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CategoryID,
@@ -202,6 +404,16 @@ func (q *Queries) GetAllProducts(ctx context.Context) ([]Product, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RatingAvg,
+			&i.RatingCount,
+			&i.Rating1Count,
+			&i.Rating2Count,
+			&i.Rating3Count,
+			&i.Rating4Count,
+			&i.Rating5Count,
+			&i.Rank,
+			&i.NameHighlight,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -216,32 +428,11 @@ func (q *Queries) GetAllProducts(ctx context.Context) ([]Product, error) {
 	return items, nil
 }
 
-const getProductByID = `-- name: GetProductByID :one
-SELECT id, category_id, name, description, price, stock, image_url, is_active, created_at, updated_at FROM products 
-WHERE id = $1
-`
-
-func (q *Queries) GetProductByID(ctx context.Context, id string) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProductByID, id)
-	var i Product
-	err := row.Scan(
-		&i.ID,
-		&i.CategoryID,
-		&i.Name,
-		&i.Description,
-		&i.Price,
-		&i.Stock,
-		&i.ImageUrl,
-		&i.IsActive,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
 const updateProduct = `-- name: UpdateProduct :exec
 UPDATE products
-SET category_id = $2, name = $3, description = $4, price = $5, stock = $6, image_url = $7, is_active = $8, updated_at = $9
+SET category_id = $2, name = $3, description = $4, price = $5, stock = $6,
+    image_url = CASE WHEN EXISTS (SELECT 1 FROM product_images WHERE product_images.product_id = $1) THEN image_url ELSE $7 END,
+    is_active = $8, updated_at = $9
 WHERE id = $1
 `
 
@@ -257,6 +448,7 @@ type UpdateProductParams struct {
 	UpdatedAt   time.Time
 }
 
+// image_url is only written for products without a gallery; otherwise it mirrors the primary image.
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) error {
 	_, err := q.db.ExecContext(ctx, updateProduct,
 		arg.ID,
@@ -289,6 +481,38 @@ func (q *Queries) UpdateProductImageURL(ctx context.Context, arg UpdateProductIm
 	return err
 }
 
+const updateProductRatingSummary = `-- name: UpdateProductRatingSummary :exec
+UPDATE products
+SET rating_avg = $2, rating_count = $3,
+    rating_1_count = $4, rating_2_count = $5, rating_3_count = $6, rating_4_count = $7, rating_5_count = $8
+WHERE id = $1
+`
+
+type UpdateProductRatingSummaryParams struct {
+	ID           string
+	RatingAvg    string
+	RatingCount  int32
+	Rating1Count int32
+	Rating2Count int32
+	Rating3Count int32
+	Rating4Count int32
+	Rating5Count int32
+}
+
+func (q *Queries) UpdateProductRatingSummary(ctx context.Context, arg UpdateProductRatingSummaryParams) error {
+	_, err := q.db.ExecContext(ctx, updateProductRatingSummary,
+		arg.ID,
+		arg.RatingAvg,
+		arg.RatingCount,
+		arg.Rating1Count,
+		arg.Rating2Count,
+		arg.Rating3Count,
+		arg.Rating4Count,
+		arg.Rating5Count,
+	)
+	return err
+}
+
 const updateProductStock = `-- name: UpdateProductStock :exec
 UPDATE products
 SET stock = $2
@@ -87,7 +87,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at FROM users
+SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at, email_verified_at FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -107,12 +107,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at FROM users
+SELECT id, name, email, password, provider, provider_id, phone, address, role, created_at, updated_at, email_verified_at FROM users
 WHERE id = $1
 LIMIT 1
 `
@@ -132,13 +133,35 @@ func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+WHERE id = $1 AND email = $3
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID              string
+	EmailVerifiedAt sql.NullTime
+	Email           string
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markUserEmailVerified, arg.ID, arg.EmailVerifiedAt, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUserInfo = `-- name: UpdateUserInfo :exec
 UPDATE users
-SET  name = $2, email = $3, phone = $4, address = $5, updated_at = $6
+SET  name = $2, email = $3, phone = $4, address = $5, updated_at = $6,
+     email_verified_at = CASE WHEN email = $3 THEN email_verified_at END
 WHERE id = $1
 `
 
@@ -163,6 +186,23 @@ func (q *Queries) UpdateUserInfo(ctx context.Context, arg UpdateUserInfoParams)
 	return err
 }
 
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, updated_at = $3
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID        string
+	Password  sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password, arg.UpdatedAt)
+	return err
+}
+
 const updateUserRole = `-- name: UpdateUserRole :exec
 UPDATE users 
 SET role = $2 WHERE id = $1
@@ -180,7 +220,7 @@ func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams)
 
 const updateUserSigninStatusByEmail = `-- name: UpdateUserSigninStatusByEmail :exec
 UPDATE users
-SET provider = $2, provider_id = $3, updated_at = $4
+SET provider = $2, provider_id = $3, updated_at = $4, email_verified_at = COALESCE(email_verified_at, $4)
 WHERE email = $1
 `
 
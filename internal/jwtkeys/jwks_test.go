@@ -0,0 +1,49 @@
+// Package jwtkeys loads and generates the asymmetric keys that sign access tokens and publishes their public halves as a JWKS.
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwks_test.go: Tests for publishing the key set as a JWKS.
+
+// TestKeySet_JWKS tests that RSA and Ed25519 keys are published without their private parts.
+func TestKeySet_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	set, err := NewKeySet("ed",
+		&Key{ID: "ed", Algorithm: AlgEdDSA, Private: edPriv, Public: edPub},
+		&Key{ID: "rsa-old", Algorithm: AlgRS256, Public: &rsaKey.PublicKey},
+	)
+	require.NoError(t, err)
+
+	jwks := set.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	ed := jwks.Keys[0]
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: AlgEdDSA, Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPub)}, ed)
+
+	rsaJWK := jwks.Keys[1]
+	assert.Equal(t, "RSA", rsaJWK.KeyType)
+	assert.Equal(t, "rsa-old", rsaJWK.KeyID)
+	assert.Equal(t, AlgRS256, rsaJWK.Algorithm)
+	assert.Equal(t, "AQAB", rsaJWK.E)
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	require.NoError(t, err)
+	assert.Equal(t, rsaKey.N.Bytes(), n)
+
+	body, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `"d"`)
+}
@@ -0,0 +1,119 @@
+// Package jwtkeys loads and generates the asymmetric keys that sign access tokens and publishes their public halves as a JWKS.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// keyset.go: Holds the signing key and the verification keys, picked by key ID.
+
+// Supported signing algorithms, named as in the JWT "alg" header.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is one key of the set. Private is nil for keys kept only to verify tokens signed before a rotation.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens may still be verified with.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet returns a key set signing with the key signingID. Every key verifies tokens;
+// the signing key must be among them and have a private key.
+func NewKeySet(signingID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		if _, exists := set.keys[k.ID]; exists {
+			return nil, fmt.Errorf("duplicate key ID %q", k.ID)
+		}
+		set.keys[k.ID] = k
+	}
+
+	signing, ok := set.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", signingID)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingID)
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// Load reads every *.pem file in dir as one key whose ID is the file name without the extension,
+// and signs with the key signingID. A file may hold a private key or, for retired keys, only a public key.
+func Load(dir, signingID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("listing keys: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.pem keys found in %s", dir)
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading key: %w", err)
+		}
+		key, err := ParsePEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(signingID, keys...)
+}
+
+// SigningKey returns the key new tokens are signed with.
+func (s *KeySet) SigningKey() *Key {
+	return s.signing
+}
+
+// Key returns the verification key with the given ID.
+func (s *KeySet) Key(id string) (*Key, bool) {
+	k, ok := s.keys[id]
+	return k, ok
+}
+
+// Keys returns every verification key, ordered by ID.
+func (s *KeySet) Keys() []*Key {
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// algorithmFor returns the signing algorithm used with a public key.
+func algorithmFor(pub crypto.PublicKey) (string, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return "", fmt.Errorf("RSA keys must be at least 2048 bits, got %d", pub.N.BitLen())
+		}
+		return AlgRS256, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %T", pub)
+	}
+}
@@ -0,0 +1,91 @@
+// Package jwtkeys loads and generates the asymmetric keys that sign access tokens and publishes their public halves as a JWKS.
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyset_test.go: Tests for building and loading key sets.
+
+// writeKey writes the PEM of a new Ed25519 key to dir/<id>.pem, only its public half if public is set.
+func writeKey(t *testing.T, dir, id string, public bool) {
+	t.Helper()
+	priv, err := Generate(AlgEdDSA)
+	require.NoError(t, err)
+	var data []byte
+	if public {
+		data, err = EncodePublicKeyPEM(priv.Public())
+	} else {
+		data, err = EncodePrivateKeyPEM(priv)
+	}
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600))
+}
+
+// TestLoad tests that every key in the directory verifies and the chosen one signs.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2026-01", true)
+	writeKey(t, dir, "2026-02", false)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a key"), 0o600))
+
+	set, err := Load(dir, "2026-02")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02", set.SigningKey().ID)
+	assert.Equal(t, AlgEdDSA, set.SigningKey().Algorithm)
+
+	retired, ok := set.Key("2026-01")
+	require.True(t, ok)
+	assert.Nil(t, retired.Private)
+	assert.Len(t, set.Keys(), 2)
+	assert.Equal(t, "2026-01", set.Keys()[0].ID)
+
+	_, ok = set.Key("missing")
+	assert.False(t, ok)
+}
+
+// TestLoad_Errors tests that missing keys, public-only signing keys and bad files are rejected.
+func TestLoad_Errors(t *testing.T) {
+	_, err := Load(t.TempDir(), "k1")
+	require.ErrorContains(t, err, "no *.pem keys")
+
+	dir := t.TempDir()
+	writeKey(t, dir, "k1", true)
+	_, err = Load(dir, "k1")
+	require.ErrorContains(t, err, "no private key")
+	_, err = Load(dir, "k2")
+	require.ErrorContains(t, err, "not found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("garbage"), 0o600))
+	_, err = Load(dir, "k1")
+	require.ErrorContains(t, err, "broken.pem")
+}
+
+// TestNewKeySet_DuplicateID tests that two keys cannot share an ID.
+func TestNewKeySet_DuplicateID(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k := &Key{ID: "k1", Algorithm: AlgEdDSA, Private: priv, Public: priv.Public()}
+
+	_, err = NewKeySet("k1", k, k)
+	require.ErrorContains(t, err, "duplicate")
+}
+
+// TestAlgorithmFor tests that small RSA keys and unknown key types are rejected.
+func TestAlgorithmFor(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = algorithmFor(&small.PublicKey)
+	require.ErrorContains(t, err, "at least 2048")
+
+	_, err = algorithmFor("not a key")
+	require.ErrorContains(t, err, "unsupported key type")
+}
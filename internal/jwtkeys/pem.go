@@ -0,0 +1,94 @@
+// Package jwtkeys loads and generates the asymmetric keys that sign access tokens and publishes their public halves as a JWKS.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// pem.go: Generates keys and converts them to and from PEM.
+
+// rsaKeyBits is the size of generated RSA keys.
+const rsaKeyBits = 3072
+
+// Generate returns a new private key for the algorithm.
+func Generate(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use %s or %s", alg, AlgRS256, AlgEdDSA)
+	}
+}
+
+// EncodePrivateKeyPEM encodes a private key as a PKCS #8 "PRIVATE KEY" PEM block.
+func EncodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("encoding private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncodePublicKeyPEM encodes a public key as a PKIX "PUBLIC KEY" PEM block.
+func EncodePublicKeyPEM(key crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("encoding public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePEM parses a PKCS #8 private key, a PKCS #1 RSA private key, or a PKIX public key into a Key with the given ID.
+func ParsePEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: id}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", parsed)
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", err)
+		}
+		key.Private = parsed
+		key.Public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key: %w", err)
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	alg, err := algorithmFor(key.Public)
+	if err != nil {
+		return nil, err
+	}
+	key.Algorithm = alg
+	return key, nil
+}
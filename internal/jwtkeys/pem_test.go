@@ -0,0 +1,70 @@
+// Package jwtkeys loads and generates the asymmetric keys that sign access tokens and publishes their public halves as a JWKS.
+package jwtkeys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pem_test.go: Tests for generating keys and round-tripping them through PEM.
+
+// TestGenerate_RoundTrip tests that generated keys parse back with the right algorithm.
+func TestGenerate_RoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			priv, err := Generate(alg)
+			require.NoError(t, err)
+
+			data, err := EncodePrivateKeyPEM(priv)
+			require.NoError(t, err)
+			key, err := ParsePEM("k1", data)
+			require.NoError(t, err)
+			assert.Equal(t, "k1", key.ID)
+			assert.Equal(t, alg, key.Algorithm)
+			assert.NotNil(t, key.Private)
+
+			data, err = EncodePublicKeyPEM(priv.Public())
+			require.NoError(t, err)
+			key, err = ParsePEM("k1", data)
+			require.NoError(t, err)
+			assert.Equal(t, alg, key.Algorithm)
+			assert.Nil(t, key.Private)
+			assert.Equal(t, priv.Public(), key.Public)
+		})
+	}
+
+	_, err := Generate("HS256")
+	require.ErrorContains(t, err, "unsupported algorithm")
+}
+
+// TestParsePEM_PKCS1 tests that RSA keys in the older PKCS #1 format are accepted.
+func TestParsePEM_PKCS1(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+
+	key, err := ParsePEM("legacy", data)
+	require.NoError(t, err)
+	assert.Equal(t, AlgRS256, key.Algorithm)
+	assert.NotNil(t, key.Private)
+}
+
+// TestParsePEM_Errors tests that unknown or corrupt PEM input is rejected.
+func TestParsePEM_Errors(t *testing.T) {
+	_, err := ParsePEM("k", []byte("not pem"))
+	require.ErrorContains(t, err, "no PEM block")
+
+	_, err = ParsePEM("k", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}))
+	require.ErrorContains(t, err, "unsupported PEM block")
+
+	for _, typ := range []string{"PRIVATE KEY", "RSA PRIVATE KEY", "PUBLIC KEY"} {
+		_, err = ParsePEM("k", pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: []byte{1, 2, 3}}))
+		require.Error(t, err, typ)
+	}
+}
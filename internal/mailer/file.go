@@ -0,0 +1,44 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// file.go: Writes email to .eml files or the log instead of sending it, for local development and tests.
+
+// FileMailer writes each message to its own .eml file in dir, or to the log when dir is empty.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a FileMailer.
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+// Send writes the message.
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	content := formatMessage(m.from, msg)
+	if m.dir == "" {
+		log.Printf("Email not sent (file mailer):\n%s", content)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), utils.NewUUIDString())
+	if err := os.WriteFile(filepath.Join(m.dir, name), content, 0o600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
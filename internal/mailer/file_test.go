@@ -0,0 +1,35 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// file_test.go: Tests for the file and log mailer.
+
+// TestFileMailer_Send tests that each message is written to its own .eml file.
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewFileMailer(dir, "shop@example.com")
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "a@example.com", Subject: "One", Body: "first"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "b@example.com", Subject: "Two", Body: "second"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "From: shop@example.com")
+}
+
+// TestFileMailer_SendToLog tests that messages go to the log when no directory is set.
+func TestFileMailer_SendToLog(t *testing.T) {
+	require.NoError(t, NewFileMailer("", "shop@example.com").Send(context.Background(), Message{To: "a@example.com"}))
+}
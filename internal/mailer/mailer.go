@@ -0,0 +1,58 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// mailer.go: Defines the Mailer interface and picks an implementation from configuration.
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Supported backends.
+const (
+	BackendSMTP = "smtp"
+	BackendFile = "file"
+)
+
+// Config selects and configures a Mailer.
+type Config struct {
+	// Backend is BackendSMTP or BackendFile.
+	Backend string
+	// From is the sender address.
+	From string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Dir is where the file backend writes messages; empty writes them to the log instead.
+	Dir string
+}
+
+// New returns the Mailer for the configured backend.
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Backend {
+	case BackendSMTP:
+		if cfg.SMTPHost == "" || cfg.From == "" {
+			return nil, fmt.Errorf("smtp mailer needs a host and a from address")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case BackendFile, "":
+		return NewFileMailer(cfg.Dir, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend: %s", cfg.Backend)
+	}
+}
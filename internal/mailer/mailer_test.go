@@ -0,0 +1,28 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mailer_test.go: Tests for choosing a mailer from configuration.
+
+// TestNew tests that each backend returns its implementation and that bad configuration is rejected.
+func TestNew(t *testing.T) {
+	m, err := New(Config{Backend: BackendSMTP, SMTPHost: "smtp.example.com", SMTPPort: 587, From: "shop@example.com"})
+	require.NoError(t, err)
+	assert.IsType(t, &SMTPMailer{}, m)
+
+	m, err = New(Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &FileMailer{}, m)
+
+	_, err = New(Config{Backend: BackendSMTP})
+	require.Error(t, err)
+
+	_, err = New(Config{Backend: "carrier-pigeon"})
+	require.ErrorContains(t, err, "carrier-pigeon")
+}
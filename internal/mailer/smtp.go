@@ -0,0 +1,59 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// smtp.go: Sends email through an SMTP server using PLAIN auth.
+
+// SMTPMailer sends email through an SMTP server.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// send is smtp.SendMail, replaceable in tests
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer creates an SMTPMailer. Authentication is skipped when username is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+		send: smtp.SendMail,
+	}
+}
+
+// Send delivers the message. The context is not used because net/smtp does not support cancellation.
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	if err := m.send(m.addr, m.auth, m.from, []string{msg.To}, formatMessage(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// formatMessage renders the message with the headers mail clients need.
+// Header values have line breaks removed so that user input cannot add headers.
+func formatMessage(from string, msg Message) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", clean.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", clean.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", clean.Replace(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
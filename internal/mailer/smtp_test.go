@@ -0,0 +1,51 @@
+// Package mailer sends transactional email through SMTP or, for local development, to files or the log.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtp_test.go: Tests for the SMTP mailer and message formatting.
+
+// TestSMTPMailer_Send tests that the message is sent to the configured server with headers and CRLF line endings.
+func TestSMTPMailer_Send(t *testing.T) {
+	m := NewSMTPMailer("smtp.example.com", 587, "user", "pass", "shop@example.com")
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.NotNil(t, a)
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	err := m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "shop@example.com", gotFrom)
+	assert.Equal(t, []string{"user@example.com"}, gotTo)
+	assert.Contains(t, string(gotMsg), "Subject: Hello\r\n")
+	assert.Contains(t, string(gotMsg), "\r\n\r\nLine one\r\nLine two")
+}
+
+// TestSMTPMailer_SendError tests that delivery errors are returned.
+func TestSMTPMailer_SendError(t *testing.T) {
+	m := NewSMTPMailer("smtp.example.com", 25, "", "", "shop@example.com")
+	assert.Nil(t, m.auth)
+	m.send = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("connection refused") }
+
+	err := m.Send(context.Background(), Message{To: "user@example.com"})
+	require.ErrorContains(t, err, "connection refused")
+}
+
+// TestFormatMessage_HeaderInjection tests that line breaks in header values cannot add headers.
+func TestFormatMessage_HeaderInjection(t *testing.T) {
+	msg := string(formatMessage("shop@example.com", Message{To: "user@example.com\r\nBcc: evil@example.com", Subject: "Hi"}))
+	assert.NotContains(t, msg, "\r\nBcc:")
+}
@@ -0,0 +1,63 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// currency.go: Defines the supported ISO 4217 currencies and their minor units.
+
+// Currency is an upper-case ISO 4217 currency code.
+type Currency string
+
+// Supported currencies.
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+	CAD Currency = "CAD"
+	AUD Currency = "AUD"
+	CHF Currency = "CHF"
+	CNY Currency = "CNY"
+	SEK Currency = "SEK"
+	NZD Currency = "NZD"
+)
+
+// DefaultCurrency is the currency catalog prices, cart items and order totals are kept in.
+const DefaultCurrency = USD
+
+// defaultExponent is used to format amounts whose currency is unknown.
+const defaultExponent = 2
+
+// exponents maps each supported currency to its number of decimal places.
+var exponents = map[Currency]int{
+	USD: 2, EUR: 2, GBP: 2, JPY: 0, CAD: 2, AUD: 2, CHF: 2, CNY: 2, SEK: 2, NZD: 2,
+}
+
+// ParseCurrency parses a currency code case-insensitively and checks that it is supported.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !currency.IsSupported() {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return currency, nil
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit, and false if the currency is not supported.
+func (c Currency) Exponent() (int, bool) {
+	exponent, ok := exponents[c]
+	return exponent, ok
+}
+
+// IsSupported reports whether the currency is supported.
+func (c Currency) IsSupported() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+// String returns the currency code.
+func (c Currency) String() string {
+	return string(c)
+}
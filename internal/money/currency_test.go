@@ -0,0 +1,40 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// currency_test.go: Tests for currency parsing and minor unit exponents.
+
+// TestParseCurrency tests that codes are normalized and unsupported codes are rejected.
+func TestParseCurrency(t *testing.T) {
+	currency, err := ParseCurrency(" usd ")
+	require.NoError(t, err)
+	assert.Equal(t, USD, currency)
+
+	for _, code := range []string{"", "US", "EURO", "XXX"} {
+		_, err := ParseCurrency(code)
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency, code)
+	}
+}
+
+// TestCurrency_Exponent tests the number of decimal places for supported and unknown currencies.
+func TestCurrency_Exponent(t *testing.T) {
+	exponent, ok := USD.Exponent()
+	assert.True(t, ok)
+	assert.Equal(t, 2, exponent)
+
+	exponent, ok = JPY.Exponent()
+	assert.True(t, ok)
+	assert.Equal(t, 0, exponent)
+
+	_, ok = Currency("usd").Exponent()
+	assert.False(t, ok)
+	assert.False(t, Currency("").IsSupported())
+	assert.True(t, DefaultCurrency.IsSupported())
+	assert.Equal(t, "GBP", GBP.String())
+}
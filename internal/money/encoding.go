@@ -0,0 +1,80 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// encoding.go: JSON and BSON encoding of Amount.
+
+// MarshalJSON encodes the amount as a JSON number with the currency's decimal places, e.g. 10.50.
+// The currency is not included; responses that need it carry a separate currency field.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or string such as 10.5 or "10.50" without going through float64.
+// The amount keeps the receiver's currency if set, otherwise DefaultCurrency. null leaves the amount unchanged.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	} else if bytes.ContainsAny(data, "eE") {
+		// Exponent notation is valid JSON but never produced for prices
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, text)
+	}
+
+	currency := a.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	amount, err := Parse(text, currency)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// UnmarshalBSONValue decodes an amount stored as {minor, currency}.
+// Documents written before amounts were introduced store prices as doubles; those are read in DefaultCurrency.
+func (a *Amount) UnmarshalBSONValue(typ byte, data []byte) error {
+	switch bson.Type(typ) {
+	case bson.TypeEmbeddedDocument:
+		type plain Amount
+		var decoded plain
+		if err := bson.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		*a = Amount(decoded)
+		return nil
+	case bson.TypeDouble:
+		value, ok := bson.RawValue{Type: bson.TypeDouble, Value: data}.DoubleOK()
+		if !ok {
+			return fmt.Errorf("%w: malformed double", ErrInvalidAmount)
+		}
+		amount, err := Parse(strconv.FormatFloat(value, 'f', -1, 64), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*a = amount
+		return nil
+	case bson.TypeNull:
+		*a = Amount{}
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot decode BSON type %s", ErrInvalidAmount, bson.Type(typ))
+	}
+}
@@ -0,0 +1,100 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// encoding_test.go: Tests for JSON and BSON encoding of amounts, including legacy double prices.
+
+type pricedDoc struct {
+	Price Amount `json:"price" bson:"price"`
+}
+
+// TestAmount_MarshalJSON tests that amounts are written as JSON numbers with fixed decimal places.
+func TestAmount_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(pricedDoc{Price: MustParse("10.50", USD)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":10.50}`, string(data))
+	assert.Equal(t, `{"price":10.50}`, string(data))
+}
+
+// TestAmount_UnmarshalJSON tests decoding numbers and strings without float rounding.
+func TestAmount_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Amount
+	}{
+		{`{"price":10.5}`, New(1050, USD)},
+		{`{"price":"19.99"}`, New(1999, USD)},
+		{`{"price":0.29}`, New(29, USD)},
+		{`{"price":1.15}`, New(115, USD)},
+		{`{"price":null}`, Amount{}},
+		{`{}`, Amount{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var doc pricedDoc
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &doc))
+			assert.Equal(t, tt.want, doc.Price)
+		})
+	}
+}
+
+// TestAmount_UnmarshalJSON_KeepsCurrency tests that a preset currency is used to parse the value.
+func TestAmount_UnmarshalJSON_KeepsCurrency(t *testing.T) {
+	amount := Amount{Currency: JPY}
+	require.NoError(t, json.Unmarshal([]byte(`1050`), &amount))
+	assert.Equal(t, New(1050, JPY), amount)
+}
+
+// TestAmount_UnmarshalJSON_Errors tests that imprecise or malformed values are rejected.
+func TestAmount_UnmarshalJSON_Errors(t *testing.T) {
+	for _, input := range []string{`{"price":10.999}`, `{"price":1e2}`, `{"price":"abc"}`, `{"price":true}`} {
+		var doc pricedDoc
+		assert.Error(t, json.Unmarshal([]byte(input), &doc), input)
+	}
+}
+
+// TestAmount_BSONRoundTrip tests that amounts are stored as minor units with their currency.
+func TestAmount_BSONRoundTrip(t *testing.T) {
+	data, err := bson.Marshal(pricedDoc{Price: MustParse("19.99", EUR)})
+	require.NoError(t, err)
+
+	raw := bson.Raw(data)
+	assert.Equal(t, int64(1999), raw.Lookup("price", "minor").Int64())
+	assert.Equal(t, "EUR", raw.Lookup("price", "currency").StringValue())
+
+	var decoded pricedDoc
+	require.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, New(1999, EUR), decoded.Price)
+}
+
+// TestAmount_UnmarshalBSON_LegacyDouble tests that prices stored as doubles by older versions are still readable.
+func TestAmount_UnmarshalBSON_LegacyDouble(t *testing.T) {
+	data, err := bson.Marshal(bson.M{"price": 10.99})
+	require.NoError(t, err)
+
+	var decoded pricedDoc
+	require.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, New(1099, DefaultCurrency), decoded.Price)
+}
+
+// TestAmount_UnmarshalBSON_Errors tests that unexpected BSON types are rejected.
+func TestAmount_UnmarshalBSON_Errors(t *testing.T) {
+	data, err := bson.Marshal(bson.M{"price": "10.99"})
+	require.NoError(t, err)
+
+	var decoded pricedDoc
+	assert.ErrorIs(t, bson.Unmarshal(data, &decoded), ErrInvalidAmount)
+
+	data, err = bson.Marshal(bson.M{"price": 10.999})
+	require.NoError(t, err)
+	assert.ErrorIs(t, bson.Unmarshal(data, &decoded), ErrTooPrecise)
+}
@@ -0,0 +1,197 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// money.go: Defines Amount and the parsing, formatting and arithmetic used for prices, totals and payments.
+
+// Errors returned by parsing and arithmetic.
+var (
+	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrTooPrecise          = errors.New("amount has more decimal places than the currency allows")
+	ErrOverflow            = errors.New("amount overflows")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+)
+
+// Amount is a monetary amount in the minor unit of its currency (cents for USD, yen for JPY).
+// The zero value is zero in no currency; arithmetic treats it as zero in the other operand's currency.
+type Amount struct {
+	Minor    int64    `bson:"minor"`
+	Currency Currency `bson:"currency"`
+}
+
+// New returns an amount of minor units in the given currency.
+func New(minor int64, currency Currency) Amount {
+	return Amount{Minor: minor, Currency: currency}
+}
+
+// Parse parses a decimal string such as "10.50" or "-3" into an amount in the given currency.
+// The conversion is exact: digits beyond the currency's minor unit must be zero, otherwise ErrTooPrecise is returned.
+func Parse(s string, currency Currency) (Amount, error) {
+	exponent, ok := currency.Exponent()
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, string(currency))
+	}
+
+	value := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "-"):
+		negative = true
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	// Extra decimal places are only accepted when they are zero, e.g. "10.500" for USD
+	if len(frac) > exponent {
+		if strings.Trim(frac[exponent:], "0") != "" {
+			return Amount{}, fmt.Errorf("%w: %q", ErrTooPrecise, s)
+		}
+		frac = frac[:exponent]
+	}
+	frac += strings.Repeat("0", exponent-len(frac))
+
+	digits := strings.TrimLeft(whole+frac, "0")
+	if digits == "" {
+		return Amount{Currency: currency}, nil
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Amount{Minor: minor, Currency: currency}, nil
+}
+
+// MustParse is like Parse but panics if the amount cannot be parsed. It is intended for constants and tests.
+func MustParse(s string, currency Currency) Amount {
+	amount, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// isDigits reports whether s consists only of ASCII digits. The empty string counts as digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a plain decimal with the currency's number of decimal places, e.g. "10.50".
+// This is the format stored in DECIMAL columns.
+func (a Amount) String() string {
+	exponent, ok := a.Currency.Exponent()
+	if !ok {
+		exponent = defaultExponent
+	}
+
+	sign := ""
+	minor := a.Minor
+	var abs uint64
+	if minor < 0 {
+		sign = "-"
+		abs = uint64(-(minor + 1)) + 1 // avoids overflow for math.MinInt64
+	} else {
+		abs = uint64(minor)
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// IsZero reports whether the amount is zero.
+func (a Amount) IsZero() bool {
+	return a.Minor == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (a Amount) IsNegative() bool {
+	return a.Minor < 0
+}
+
+// IsPositive reports whether the amount is above zero.
+func (a Amount) IsPositive() bool {
+	return a.Minor > 0
+}
+
+// Equal reports whether two amounts have the same value and currency. Zero amounts are equal regardless of currency.
+func (a Amount) Equal(b Amount) bool {
+	if a.Minor == 0 && b.Minor == 0 {
+		return true
+	}
+	return a.Minor == b.Minor && a.Currency == b.Currency
+}
+
+// Add returns a + b. Both amounts must be in the same currency unless one of them is the zero value.
+func (a Amount) Add(b Amount) (Amount, error) {
+	currency, err := commonCurrency(a, b)
+	if err != nil {
+		return Amount{}, err
+	}
+	if (b.Minor > 0 && a.Minor > math.MaxInt64-b.Minor) || (b.Minor < 0 && a.Minor < math.MinInt64-b.Minor) {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{Minor: a.Minor + b.Minor, Currency: currency}, nil
+}
+
+// Sub returns a - b. Both amounts must be in the same currency unless one of them is the zero value.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b.Minor == math.MinInt64 {
+		return Amount{}, ErrOverflow
+	}
+	return a.Add(Amount{Minor: -b.Minor, Currency: b.Currency})
+}
+
+// Mul returns the amount multiplied by a whole quantity.
+func (a Amount) Mul(quantity int64) (Amount, error) {
+	if a.Minor == 0 || quantity == 0 {
+		return Amount{Currency: a.Currency}, nil
+	}
+	product := a.Minor * quantity
+	if product/quantity != a.Minor || (a.Minor == -1 && quantity == math.MinInt64) || (quantity == -1 && a.Minor == math.MinInt64) {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{Minor: product, Currency: a.Currency}, nil
+}
+
+// commonCurrency returns the currency two amounts share. A zero amount without a currency adopts the other currency.
+func commonCurrency(a, b Amount) (Currency, error) {
+	switch {
+	case a.Currency == b.Currency:
+		return a.Currency, nil
+	case a.Currency == "" && a.Minor == 0:
+		return b.Currency, nil
+	case b.Currency == "" && b.Minor == 0:
+		return a.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+}
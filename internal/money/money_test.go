@@ -0,0 +1,188 @@
+// Package money provides an exact monetary amount stored in integer minor units with its currency.
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// money_test.go: Tests for parsing, formatting and exact arithmetic of amounts.
+
+// TestParse tests parsing decimal strings into minor units for currencies with different exponents.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		minor    int64
+	}{
+		{"10.50", USD, 1050},
+		{"10.5", USD, 1050},
+		{"10", USD, 1000},
+		{"0.01", USD, 1},
+		{".99", USD, 99},
+		{"7.", USD, 700},
+		{"10.500", USD, 1050},
+		{"-3.25", USD, -325},
+		{"+3.25", USD, 325},
+		{" 19.99 ", USD, 1999},
+		{"0", USD, 0},
+		{"0007.10", USD, 710},
+		{"1050", JPY, 1050},
+		{"1050.00", JPY, 1050},
+		{"92233720368547758.07", USD, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			amount, err := Parse(tt.input, tt.currency)
+			require.NoError(t, err)
+			assert.Equal(t, New(tt.minor, tt.currency), amount)
+		})
+	}
+}
+
+// TestParse_Errors tests that malformed, over-precise and out-of-range amounts are rejected.
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		want     error
+	}{
+		{"", USD, ErrInvalidAmount},
+		{".", USD, ErrInvalidAmount},
+		{"-", USD, ErrInvalidAmount},
+		{"abc", USD, ErrInvalidAmount},
+		{"1.2.3", USD, ErrInvalidAmount},
+		{"1e3", USD, ErrInvalidAmount},
+		{"1,000.00", USD, ErrInvalidAmount},
+		{"--1", USD, ErrInvalidAmount},
+		{"1.005", USD, ErrTooPrecise},
+		{"10.50", JPY, ErrTooPrecise},
+		{"92233720368547758.08", USD, ErrOverflow},
+		{"10.00", Currency("XXX"), ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input, tt.currency)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+// TestMustParse tests that MustParse returns the parsed amount and panics on invalid input.
+func TestMustParse(t *testing.T) {
+	assert.Equal(t, New(1999, USD), MustParse("19.99", USD))
+	assert.Panics(t, func() { MustParse("19.999", USD) })
+}
+
+// TestAmount_String tests formatting with the currency's decimal places.
+func TestAmount_String(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{New(1050, USD), "10.50"},
+		{New(5, USD), "0.05"},
+		{New(0, USD), "0.00"},
+		{New(-5, USD), "-0.05"},
+		{New(-1999, EUR), "-19.99"},
+		{New(1050, JPY), "1050"},
+		{New(1050, ""), "10.50"},
+		{New(math.MaxInt64, USD), "92233720368547758.07"},
+		{New(math.MinInt64, USD), "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.amount.String())
+		})
+	}
+}
+
+// TestAmount_ParseStringRoundTrip tests that every cent value survives formatting and parsing unchanged.
+func TestAmount_ParseStringRoundTrip(t *testing.T) {
+	for minor := int64(-1000); minor <= 100000; minor++ {
+		amount := New(minor, USD)
+		parsed, err := Parse(amount.String(), USD)
+		require.NoError(t, err)
+		require.Equal(t, amount, parsed)
+	}
+}
+
+// TestAmount_ExactArithmetic tests sums and products that drift when computed in float64.
+func TestAmount_ExactArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 in float64
+	sum, err := MustParse("0.10", USD).Add(MustParse("0.20", USD))
+	require.NoError(t, err)
+	assert.Equal(t, "0.30", sum.String())
+
+	// Adding one cent ten thousand times drifts in float64 but not in minor units
+	total := New(0, USD)
+	for range 10000 {
+		total, err = total.Add(MustParse("0.01", USD))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "100.00", total.String())
+
+	// 19.99 * 3 is 59.970000000000006 in float64, and 1.15 * 100 truncates to 114
+	line, err := MustParse("19.99", USD).Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, "59.97", line.String())
+	assert.Equal(t, int64(115), MustParse("1.15", USD).Minor)
+
+	diff, err := MustParse("10.00", USD).Sub(MustParse("9.99", USD))
+	require.NoError(t, err)
+	assert.Equal(t, New(1, USD), diff)
+}
+
+// TestAmount_CurrencyMismatch tests that amounts in different currencies cannot be combined.
+func TestAmount_CurrencyMismatch(t *testing.T) {
+	_, err := New(100, USD).Add(New(100, EUR))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(100, USD).Sub(New(100, JPY))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	// The zero value adopts the other currency
+	sum, err := Amount{}.Add(New(100, EUR))
+	require.NoError(t, err)
+	assert.Equal(t, New(100, EUR), sum)
+}
+
+// TestAmount_Overflow tests that arithmetic beyond int64 minor units is reported instead of wrapping.
+func TestAmount_Overflow(t *testing.T) {
+	_, err := New(math.MaxInt64, USD).Add(New(1, USD))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, USD).Add(New(-1, USD))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(0, USD).Sub(New(math.MinInt64, USD))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MaxInt64/2+1, USD).Mul(2)
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(-1, USD).Mul(math.MinInt64)
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	zero, err := New(math.MaxInt64, USD).Mul(0)
+	require.NoError(t, err)
+	assert.True(t, zero.IsZero())
+}
+
+// TestAmount_Comparisons tests the sign helpers and equality.
+func TestAmount_Comparisons(t *testing.T) {
+	assert.True(t, New(1, USD).IsPositive())
+	assert.True(t, New(-1, USD).IsNegative())
+	assert.True(t, Amount{}.IsZero())
+
+	assert.True(t, New(100, USD).Equal(MustParse("1.00", USD)))
+	assert.False(t, New(100, USD).Equal(New(100, EUR)))
+	assert.False(t, New(100, USD).Equal(New(101, USD)))
+	assert.True(t, Amount{}.Equal(New(0, USD)))
+}
@@ -11,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/models"
 )
 
@@ -54,6 +55,15 @@ func (c *CartMongo) GetCartByUserID(ctx context.Context, userID string) (*models
 	return &cart, nil
 }
 
+// cartItemMatch matches the cart line of a product variant. An empty variantID matches the line without
+// a variant, including lines stored before variants existed.
+func cartItemMatch(productID, variantID string) bson.M {
+	if variantID == "" {
+		return bson.M{"product_id": productID, "variant_id": bson.M{"$in": bson.A{nil, ""}}}
+	}
+	return bson.M{"product_id": productID, "variant_id": variantID}
+}
+
 // generateCartID generates a unique string ID for a cart (simple version using userID and timestamp)
 func generateCartID(userID string) string {
 	return fmt.Sprintf("%s-%d", userID, time.Now().UnixNano())
@@ -142,12 +152,12 @@ func (c *CartMongo) AddItemsToCart(ctx context.Context, userID string, items []m
 	return nil
 }
 
-// RemoveItemFromCart removes an item from a user's cart.
-func (c *CartMongo) RemoveItemFromCart(ctx context.Context, userID string, productID string) error {
+// RemoveItemFromCart removes the line of a product variant from a user's cart; an empty variantID removes the line without a variant.
+func (c *CartMongo) RemoveItemFromCart(ctx context.Context, userID, productID, variantID string) error {
 	filter := bson.M{"user_id": userID}
 	update := bson.M{
 		"$pull": bson.M{
-			"items": bson.M{"product_id": productID},
+			"items": cartItemMatch(productID, variantID),
 		},
 		"$set": bson.M{
 			"updated_at": time.Now().UTC(),
@@ -162,7 +172,7 @@ func (c *CartMongo) RemoveItemFromCart(ctx context.Context, userID string, produ
 	return nil
 }
 
-// RemoveItemsFromCart removes multiple items from a user's cart.
+// RemoveItemsFromCart removes every line of the given products from a user's cart, whatever their variant.
 func (c *CartMongo) RemoveItemsFromCart(ctx context.Context, userID string, productIDs []string) error {
 	if len(productIDs) == 0 {
 		return fmt.Errorf("product IDs slice cannot be empty")
@@ -226,18 +236,19 @@ func (c *CartMongo) ClearCarts(ctx context.Context, userIDs []string) error {
 	return nil
 }
 
-// UpdateItemQuantity updates the quantity of an item in a user's cart.
-func (c *CartMongo) UpdateItemQuantity(ctx context.Context, userID, productID string, quantity int) error {
+// UpdateItemQuantity updates the quantity of a product variant's line in a user's cart; an empty variantID
+// updates the line without a variant.
+func (c *CartMongo) UpdateItemQuantity(ctx context.Context, userID, productID, variantID string, quantity int) error {
 	filter := bson.M{
-		"user_id":          userID,
-		"items.product_id": productID,
+		"user_id": userID,
+		"items":   bson.M{"$elemMatch": cartItemMatch(productID, variantID)},
 	}
 
 	var update bson.M
 	if quantity <= 0 {
 		update = bson.M{
 			"$pull": bson.M{
-				"items": bson.M{"product_id": productID},
+				"items": cartItemMatch(productID, variantID),
 			},
 		}
 	} else {
@@ -260,7 +271,7 @@ func (c *CartMongo) UpdateItemQuantity(ctx context.Context, userID, productID st
 	return nil
 }
 
-// UpdateItemQuantities updates quantities of multiple items in a user's cart.
+// UpdateItemQuantities updates quantities of multiple items in a user's cart, keyed by product ID; only lines without a variant are updated.
 func (c *CartMongo) UpdateItemQuantities(ctx context.Context, userID string, updates map[string]int) error {
 	if len(updates) == 0 {
 		return fmt.Errorf("updates map cannot be empty")
@@ -268,7 +279,7 @@ func (c *CartMongo) UpdateItemQuantities(ctx context.Context, userID string, upd
 
 	// Process each update individually for simplicity and reliability
 	for productID, quantity := range updates {
-		err := c.UpdateItemQuantity(ctx, userID, productID, quantity)
+		err := c.UpdateItemQuantity(ctx, userID, productID, "", quantity)
 		if err != nil {
 			return fmt.Errorf("failed to update item %s: %w", productID, err)
 		}
@@ -277,6 +288,30 @@ func (c *CartMongo) UpdateItemQuantities(ctx context.Context, userID string, upd
 	return nil
 }
 
+// UpdateItemPrice updates the price snapshot of a product variant's line in a user's cart.
+func (c *CartMongo) UpdateItemPrice(ctx context.Context, userID, productID, variantID string, price money.Amount) error {
+	filter := bson.M{
+		"user_id": userID,
+		"items":   bson.M{"$elemMatch": cartItemMatch(productID, variantID)},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"items.$.price": price,
+			"updated_at":    time.Now().UTC(),
+		},
+	}
+
+	result, err := c.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update item price: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("item not found in cart")
+	}
+
+	return nil
+}
+
 // UpsertCart creates or updates a user's cart.
 func (c *CartMongo) UpsertCart(ctx context.Context, userID string, cart models.Cart) error {
 	timeNow := time.Now().UTC()
@@ -13,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/models"
 )
 
@@ -399,7 +400,7 @@ func TestAddItemToCart(t *testing.T) {
 	item := models.CartItem{
 		ProductID: "product123",
 		Quantity:  2,
-		Price:     10.99,
+		Price:     money.MustParse("10.99", money.USD),
 		Name:      "Test Product",
 	}
 
@@ -454,8 +455,8 @@ func TestAddItemsToCart(t *testing.T) {
 	ctx := context.Background()
 
 	items := []models.CartItem{
-		{ProductID: "product1", Quantity: 1, Price: 10.99, Name: "Product 1"},
-		{ProductID: "product2", Quantity: 2, Price: 20.99, Name: "Product 2"},
+		{ProductID: "product1", Quantity: 1, Price: money.MustParse("10.99", money.USD), Name: "Product 1"},
+		{ProductID: "product2", Quantity: 2, Price: money.MustParse("20.99", money.USD), Name: "Product 2"},
 	}
 
 	tests := []struct {
@@ -547,7 +548,7 @@ func TestRemoveItemFromCart(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMock()
 
-			err := cartMongo.RemoveItemFromCart(ctx, tt.userID, tt.productID)
+			err := cartMongo.RemoveItemFromCart(ctx, tt.userID, tt.productID, "")
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -778,7 +779,7 @@ func TestUpdateItemQuantity(t *testing.T) {
 			productID: "product123",
 			quantity:  5,
 			setupMock: func() {
-				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items.product_id": "product123"}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
+				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items": bson.M{"$elemMatch": cartItemMatch("product123", "")}}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
 			},
 			expectError: false,
 		},
@@ -788,7 +789,7 @@ func TestUpdateItemQuantity(t *testing.T) {
 			productID: "product123",
 			quantity:  0,
 			setupMock: func() {
-				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items.product_id": "product123"}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
+				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items": bson.M{"$elemMatch": cartItemMatch("product123", "")}}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
 			},
 			expectError: false,
 		},
@@ -798,7 +799,7 @@ func TestUpdateItemQuantity(t *testing.T) {
 			productID: "product123",
 			quantity:  -1,
 			setupMock: func() {
-				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items.product_id": "product123"}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
+				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items": bson.M{"$elemMatch": cartItemMatch("product123", "")}}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
 			},
 			expectError: false,
 		},
@@ -808,7 +809,7 @@ func TestUpdateItemQuantity(t *testing.T) {
 			productID: "product123",
 			quantity:  5,
 			setupMock: func() {
-				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items.product_id": "product123"}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil).Once()
+				mockCollection.On("UpdateOne", ctx, bson.M{"user_id": "user123", "items": bson.M{"$elemMatch": cartItemMatch("product123", "")}}, mock.AnythingOfType("bson.M"), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil).Once()
 			},
 			expectError: true,
 		},
@@ -818,7 +819,7 @@ func TestUpdateItemQuantity(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMock()
 
-			err := cartMongo.UpdateItemQuantity(ctx, tt.userID, tt.productID, tt.quantity)
+			err := cartMongo.UpdateItemQuantity(ctx, tt.userID, tt.productID, "", tt.quantity)
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -840,15 +841,15 @@ func TestUpdateItemQuantity_ZeroQuantity(t *testing.T) {
 
 	mockResult := &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product123",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product123", "")},
 	}, bson.M{
 		"$pull": bson.M{
-			"items": bson.M{"product_id": "product123"},
+			"items": cartItemMatch("product123", ""),
 		},
 	}, mock.Anything).Return(mockResult, nil)
 
-	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", 0)
+	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", "", 0)
 
 	assert.NoError(t, err)
 }
@@ -862,15 +863,15 @@ func TestUpdateItemQuantity_NegativeQuantity(t *testing.T) {
 
 	mockResult := &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product123",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product123", "")},
 	}, bson.M{
 		"$pull": bson.M{
-			"items": bson.M{"product_id": "product123"},
+			"items": cartItemMatch("product123", ""),
 		},
 	}, mock.Anything).Return(mockResult, nil)
 
-	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", -1)
+	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", "", -1)
 
 	assert.NoError(t, err)
 }
@@ -884,11 +885,11 @@ func TestUpdateItemQuantity_ItemNotFound(t *testing.T) {
 
 	mockResult := &mongo.UpdateResult{MatchedCount: 0, ModifiedCount: 0}
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product123",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product123", "")},
 	}, mock.Anything, mock.Anything).Return(mockResult, nil)
 
-	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", 5)
+	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", "", 5)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "item not found in cart")
@@ -902,11 +903,11 @@ func TestUpdateItemQuantity_DatabaseError(t *testing.T) {
 	ctx := context.Background()
 
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product123",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product123", "")},
 	}, mock.Anything, mock.Anything).Return(nil, assert.AnError)
 
-	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", 5)
+	err := cartMongo.UpdateItemQuantity(ctx, "user123", "product123", "", 5)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update item quantity")
@@ -966,6 +967,45 @@ func TestUpdateItemQuantities(t *testing.T) {
 	}
 }
 
+// TestUpdateItemPrice tests the UpdateItemPrice function.
+// It verifies that the price snapshot is set on the matching item and that missing items and errors are reported.
+func TestUpdateItemPrice(t *testing.T) {
+	ctx := context.Background()
+	filter := bson.M{"user_id": "user123", "items": bson.M{"$elemMatch": cartItemMatch("product123", "")}}
+
+	tests := []struct {
+		name        string
+		result      *mongo.UpdateResult
+		err         error
+		expectError string
+	}{
+		{name: "updates price", result: &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}},
+		{name: "item not found", result: &mongo.UpdateResult{MatchedCount: 0}, expectError: "item not found in cart"},
+		{name: "update error", result: nil, err: assert.AnError, expectError: "failed to update item price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCollection := &MockCartCollectionInterface{}
+			cartMongo := &CartMongo{Collection: mockCollection}
+			mockCollection.On("UpdateOne", ctx, filter, mock.MatchedBy(func(update bson.M) bool {
+				set, ok := update["$set"].(bson.M)
+				return ok && set["items.$.price"] == money.New(1250, money.USD)
+			}), mock.Anything).Return(tt.result, tt.err)
+
+			err := cartMongo.UpdateItemPrice(ctx, "user123", "product123", "", money.New(1250, money.USD))
+
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockCollection.AssertExpectations(t)
+		})
+	}
+}
+
 // TestUpdateItemQuantities_IndividualError tests UpdateItemQuantities when individual updates fail.
 // It verifies proper error handling when some updates succeed and others fail.
 func TestUpdateItemQuantities_IndividualError(t *testing.T) {
@@ -978,13 +1018,13 @@ func TestUpdateItemQuantities_IndividualError(t *testing.T) {
 	mockResult2 := &mongo.UpdateResult{MatchedCount: 0, ModifiedCount: 0}
 
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product1",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product1", "")},
 	}, mock.Anything, mock.Anything).Return(mockResult1, nil)
 
 	mockCollection.On("UpdateOne", ctx, bson.M{
-		"user_id":          "user123",
-		"items.product_id": "product2",
+		"user_id": "user123",
+		"items":   bson.M{"$elemMatch": cartItemMatch("product2", "")},
 	}, mock.Anything, mock.Anything).Return(mockResult2, nil)
 
 	updates := map[string]int{
@@ -1017,7 +1057,7 @@ func TestCart_AddItemAndUpsertCart(t *testing.T) {
 	cartMongo := &CartMongo{Collection: mockCollection}
 	ctx := context.Background()
 
-	item := models.CartItem{ProductID: "product1", Quantity: 1, Price: 10.99, Name: "Product 1"}
+	item := models.CartItem{ProductID: "product1", Quantity: 1, Price: money.MustParse("10.99", money.USD), Name: "Product 1"}
 	cart := models.Cart{UserID: "user123", Items: []models.CartItem{item}}
 
 	tests := []struct {
@@ -17,6 +17,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
+	"github.com/STaninnat/ecom-backend/internal/money"
 	"github.com/STaninnat/ecom-backend/models"
 )
 
@@ -319,12 +320,28 @@ func TestCreateIndexes_Integration(t *testing.T) {
 	tc := setupTestContainer(t)
 	defer cleanupTestContainer(t, tc)
 
+	ctx := context.Background()
+
+	// Duplicate reviews keep the unique index from building until they are removed
+	older := time.Now().UTC().Add(-time.Hour)
+	_, err := tc.Database.Collection("reviews").InsertMany(ctx, []any{
+		bson.M{"_id": "dup-approved", "user_id": "user1", "product_id": "product1", "status": "approved", "updated_at": older},
+		bson.M{"_id": "dup-pending", "user_id": "user1", "product_id": "product1", "status": "pending", "updated_at": time.Now().UTC()},
+	})
+	require.NoError(t, err)
+	err = CreateIndexes(tc.Database)
+	require.ErrorContains(t, err, "dedupe-reviews")
+
+	deleted, err := DedupeReviews(ctx, &MongoCollectionAdapter{Inner: tc.Database.Collection("reviews")}, &MongoCollectionAdapter{Inner: tc.Database.Collection("review_media")})
+	require.NoError(t, err)
+	assert.Equal(t, []DuplicateReview{{ID: "dup-pending", UserID: "user1", ProductID: "product1"}}, deleted)
+	require.NoError(t, tc.Database.Collection("reviews").FindOne(ctx, bson.M{"_id": "dup-approved"}).Err())
+
 	// Test index creation
-	err := CreateIndexes(tc.Database)
+	err = CreateIndexes(tc.Database)
 	require.NoError(t, err)
 
 	// Verify indexes were created
-	ctx := context.Background()
 
 	// Check cart indexes
 	cartIndexes, err := tc.Database.Collection("carts").Indexes().List(ctx)
@@ -357,7 +374,7 @@ func TestCartMongo_Integration(t *testing.T) {
 	item := models.CartItem{
 		ProductID: "product123",
 		Quantity:  2,
-		Price:     29.99,
+		Price:     money.MustParse("29.99", money.USD),
 	}
 	err = cartMongo.AddItemToCart(ctx, "user123", item)
 	require.NoError(t, err)
@@ -370,7 +387,7 @@ func TestCartMongo_Integration(t *testing.T) {
 	assert.Equal(t, "product123", cart.Items[0].ProductID)
 
 	// Test UpdateItemQuantity
-	err = cartMongo.UpdateItemQuantity(ctx, "user123", "product123", 5)
+	err = cartMongo.UpdateItemQuantity(ctx, "user123", "product123", "", 5)
 	require.NoError(t, err)
 
 	// Verify quantity was updated
@@ -379,7 +396,7 @@ func TestCartMongo_Integration(t *testing.T) {
 	assert.Equal(t, 5, cart.Items[0].Quantity)
 
 	// Test RemoveItemFromCart
-	err = cartMongo.RemoveItemFromCart(ctx, "user123", "product123")
+	err = cartMongo.RemoveItemFromCart(ctx, "user123", "product123", "")
 	require.NoError(t, err)
 
 	// Verify item was removed
@@ -9,6 +9,8 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/STaninnat/ecom-backend/models"
 )
 
 // mongo_helper.go: MongoDB connection management, abstractions, and index helpers.
@@ -281,7 +283,9 @@ var (
 // Index Management
 // =====================
 
-// CreateIndexes creates necessary indexes for optimal performance
+// CreateIndexes creates necessary indexes for optimal performance.
+// The unique index on a review's user and product cannot be built while duplicate reviews exist;
+// remove them with the dedupe-reviews command first.
 func CreateIndexes(db *mongo.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -319,5 +323,129 @@ func CreateIndexes(db *mongo.Database) error {
 		return fmt.Errorf("review index error: %w", err)
 	}
 
+	// Admin moderation queue
+	_, err = reviewCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "created_at", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("review index error: %w", err)
+	}
+
+	// Most helpful reviews of a product
+	_, err = reviewCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "product_id", Value: 1},
+			{Key: "helpful_count", Value: -1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("review index error: %w", err)
+	}
+
+	// One review per user and product
+	_, err = reviewCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "product_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("review index error: duplicate reviews exist, run the dedupe-reviews command: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("review index error: %w", err)
+	}
+
+	// Review media quota and ownership lookups
+	_, err = db.Collection("review_media").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("review media index error: %w", err)
+	}
+
 	return nil
 }
+
+// duplicateReviews is one user's reviews of one product, the one to keep first.
+type duplicateReviews struct {
+	Key struct {
+		UserID    string `bson:"user_id"`
+		ProductID string `bson:"product_id"`
+	} `bson:"_id"`
+	IDs []string `bson:"ids"`
+}
+
+// DuplicateReview identifies a review deleted by DedupeReviews.
+type DuplicateReview struct {
+	ID        string
+	UserID    string
+	ProductID string
+}
+
+// DedupeReviews deletes all but one review of each user and product, and marks the media of the deleted reviews
+// unused again so their owners can reuse or delete it. A published review is kept over a pending one, and a pending
+// one over a rejected or hidden one; among reviews with the same standing the most recently updated is kept.
+// Returns the deleted reviews, which are also returned with the error when only releasing their media failed.
+func DedupeReviews(ctx context.Context, reviews, media CollectionInterface) ([]DuplicateReview, error) {
+	pipeline := []bson.M{
+		{"$addFields": bson.M{"keep_rank": bson.M{"$switch": bson.M{
+			"branches": bson.A{
+				bson.M{"case": bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$status", models.ReviewStatusApproved}}, models.ReviewStatusApproved}}, "then": 2},
+				bson.M{"case": bson.M{"$eq": bson.A{"$status", models.ReviewStatusPending}}, "then": 1},
+			},
+			"default": 0,
+		}}}},
+		{"$sort": bson.D{{Key: "keep_rank", Value: -1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"user_id": "$user_id", "product_id": "$product_id"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+
+	cursor, err := reviews.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate reviews: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			fmt.Printf("cursor.Close failed: %v\n", err)
+		}
+	}()
+
+	var groups []duplicateReviews
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode duplicate reviews: %w", err)
+	}
+
+	var stale []DuplicateReview
+	var staleIDs []string
+	for _, group := range groups {
+		if len(group.IDs) < 2 {
+			continue
+		}
+		for _, id := range group.IDs[1:] {
+			stale = append(stale, DuplicateReview{ID: id, UserID: group.Key.UserID, ProductID: group.Key.ProductID})
+			staleIDs = append(staleIDs, id)
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	if _, err := reviews.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": staleIDs}}); err != nil {
+		return nil, fmt.Errorf("failed to delete duplicate reviews: %w", err)
+	}
+	if _, err := media.UpdateMany(ctx, bson.M{"review_id": bson.M{"$in": staleIDs}}, bson.M{"$unset": bson.M{"review_id": ""}}); err != nil {
+		return stale, fmt.Errorf("failed to release media of duplicate reviews: %w", err)
+	}
+	return stale, nil
+}
@@ -407,6 +407,75 @@ func TestCreateIndexes_Error(t *testing.T) {
 	t.Skip("Requires MongoDB instance for full testing")
 }
 
+// TestDedupeReviews tests that one review of each user and product is kept, and that the media of the deleted
+// reviews is released.
+func TestDedupeReviews(t *testing.T) {
+	ctx := context.Background()
+	reviews := &MockReviewCollectionInterface{}
+	media := &MockReviewCollectionInterface{}
+	cursor := &MockCursor{}
+	reviews.On("Aggregate", ctx, mock.AnythingOfType("[]bson.M"), mock.Anything).Return(cursor, nil)
+	cursor.On("All", ctx, mock.AnythingOfType("*[]intmongo.duplicateReviews")).Run(func(args mock.Arguments) {
+		groups := make([]duplicateReviews, 2)
+		groups[0].Key.UserID, groups[0].Key.ProductID, groups[0].IDs = "u1", "p1", []string{"r3", "r1"}
+		groups[1].Key.UserID, groups[1].Key.ProductID, groups[1].IDs = "u2", "p1", []string{"r5", "r4", "r2"}
+		*args.Get(1).(*[]duplicateReviews) = groups
+	}).Return(nil)
+	cursor.On("Close", ctx).Return(nil)
+	stale := []string{"r1", "r4", "r2"}
+	reviews.On("DeleteMany", ctx, bson.M{"_id": bson.M{"$in": stale}}, mock.Anything).Return(&mongo.DeleteResult{DeletedCount: 3}, nil)
+	media.On("UpdateMany", ctx, bson.M{"review_id": bson.M{"$in": stale}}, bson.M{"$unset": bson.M{"review_id": ""}}, mock.Anything).Return(&mongo.UpdateResult{}, nil)
+
+	deleted, err := DedupeReviews(ctx, reviews, media)
+	require.NoError(t, err)
+	assert.Equal(t, []DuplicateReview{
+		{ID: "r1", UserID: "u1", ProductID: "p1"},
+		{ID: "r4", UserID: "u2", ProductID: "p1"},
+		{ID: "r2", UserID: "u2", ProductID: "p1"},
+	}, deleted)
+	reviews.AssertExpectations(t)
+	media.AssertExpectations(t)
+}
+
+// TestDedupeReviews_NoDuplicates tests that nothing is deleted when every user reviewed each product once.
+func TestDedupeReviews_NoDuplicates(t *testing.T) {
+	ctx := context.Background()
+	reviews := &MockReviewCollectionInterface{}
+	media := &MockReviewCollectionInterface{}
+	cursor := &MockCursor{}
+	reviews.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(cursor, nil)
+	cursor.On("All", ctx, mock.Anything).Return(nil)
+	cursor.On("Close", ctx).Return(nil)
+
+	deleted, err := DedupeReviews(ctx, reviews, media)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	reviews.AssertNotCalled(t, "DeleteMany", mock.Anything, mock.Anything, mock.Anything)
+	media.AssertNotCalled(t, "UpdateMany", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestDedupeReviews_Errors tests that failures to find or delete duplicates are returned.
+func TestDedupeReviews_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	reviews := &MockReviewCollectionInterface{}
+	reviews.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	_, err := DedupeReviews(ctx, reviews, &MockReviewCollectionInterface{})
+	require.ErrorContains(t, err, "failed to find duplicate reviews")
+
+	reviews = &MockReviewCollectionInterface{}
+	cursor := &MockCursor{}
+	reviews.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(cursor, nil)
+	cursor.On("All", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*[]duplicateReviews) = []duplicateReviews{{IDs: []string{"r2", "r1"}}}
+	}).Return(nil)
+	cursor.On("Close", ctx).Return(nil)
+	reviews.On("DeleteMany", ctx, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	deleted, err := DedupeReviews(ctx, reviews, &MockReviewCollectionInterface{})
+	require.ErrorContains(t, err, "failed to delete duplicate reviews")
+	assert.Empty(t, deleted)
+}
+
 // TestCollectionInterfaceMethods tests all MockCollectionInterface methods.
 // It verifies that all collection operations work correctly with mocked responses.
 func TestCollectionInterfaceMethods(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"math"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,6 +18,22 @@ import (
 
 // review.go: MongoDB repository and operations for product reviews.
 
+// ErrDuplicateReview is returned when a user has already reviewed the product.
+var ErrDuplicateReview = errors.New("review already exists")
+
+// PublishedReviewFilter matches reviews that are visible to customers.
+// Reviews stored before moderation was added have no status and count as approved.
+func PublishedReviewFilter() bson.M {
+	return bson.M{"$in": bson.A{models.ReviewStatusApproved, nil}}
+}
+
+// ReviewStatusUpdate holds an admin's moderation decision for a review.
+type ReviewStatusUpdate struct {
+	Status      string
+	Reason      string
+	ModeratedBy string
+}
+
 // ReviewMongo handles review operations in MongoDB.
 type ReviewMongo struct {
 	Collection CollectionInterface
@@ -47,6 +64,9 @@ func (r *ReviewMongo) CreateReview(ctx context.Context, review *models.Review) e
 
 	_, err := r.Collection.InsertOne(ctx, review)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateReview
+		}
 		return fmt.Errorf("failed to create review: %w", err)
 	}
 
@@ -175,6 +195,10 @@ func (r *ReviewMongo) GetReviewsByUserIDPaginated(ctx context.Context, userID st
 	return r.getReviewsByFieldPaginated(ctx, "user_id", "user ID", userID, pagination)
 }
 
+func (r *ReviewMongo) GetReviewsByStatusPaginated(ctx context.Context, status string, pagination *PaginationOptions) (*PaginatedResult[*models.Review], error) {
+	return r.getReviewsByFieldPaginated(ctx, "status", "status", status, pagination)
+}
+
 // GetReviewByID retrieves a specific review by its ID.
 func (r *ReviewMongo) GetReviewByID(ctx context.Context, reviewID string) (*models.Review, error) {
 	if reviewID == "" {
@@ -211,10 +235,13 @@ func (r *ReviewMongo) UpdateReviewByID(ctx context.Context, reviewID string, upd
 	filter := bson.M{"_id": reviewID}
 	update := bson.M{
 		"$set": bson.M{
-			"rating":     updatedReview.Rating,
-			"comment":    updatedReview.Comment,
-			"media_urls": updatedReview.MediaURLs,
-			"updated_at": time.Now().UTC(),
+			"rating":            updatedReview.Rating,
+			"comment":           updatedReview.Comment,
+			"media_ids":         updatedReview.MediaIDs,
+			"media_urls":        updatedReview.MediaURLs,
+			"status":            updatedReview.Status,
+			"moderation_reason": updatedReview.ModerationReason,
+			"updated_at":        time.Now().UTC(),
 		},
 	}
 
@@ -229,6 +256,116 @@ func (r *ReviewMongo) UpdateReviewByID(ctx context.Context, reviewID string, upd
 	return nil
 }
 
+// UpdateReviewStatus records an admin's moderation decision for a review.
+func (r *ReviewMongo) UpdateReviewStatus(ctx context.Context, reviewID string, update ReviewStatusUpdate) error {
+	if reviewID == "" {
+		return fmt.Errorf("review ID cannot be empty")
+	}
+	if update.Status == "" {
+		return fmt.Errorf("status cannot be empty")
+	}
+
+	timeNow := time.Now().UTC()
+	filter := bson.M{"_id": reviewID}
+	set := bson.M{
+		"$set": bson.M{
+			"status":            update.Status,
+			"moderation_reason": update.Reason,
+			"moderated_by":      update.ModeratedBy,
+			"moderated_at":      timeNow,
+			"updated_at":        timeNow,
+		},
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, set)
+	if err != nil {
+		return fmt.Errorf("failed to update review status: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("review not found")
+	}
+
+	return nil
+}
+
+// VoteReview records a user's helpful or unhelpful vote on a review.
+// A user has at most one vote per review: voting the same way again changes nothing, and voting the other way moves the vote.
+// Each step updates the voter list and its count in a single document update, so concurrent votes cannot be counted twice.
+func (r *ReviewMongo) VoteReview(ctx context.Context, reviewID, userID string, helpful bool) error {
+	if reviewID == "" {
+		return fmt.Errorf("review ID cannot be empty")
+	}
+	if userID == "" {
+		return fmt.Errorf("user ID cannot be empty")
+	}
+
+	voters, count := "unhelpful_voters", "unhelpful_count"
+	otherVoters, otherCount := "helpful_voters", "helpful_count"
+	if helpful {
+		voters, count, otherVoters, otherCount = otherVoters, otherCount, voters, count
+	}
+
+	// First vote by this user
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": reviewID, voters: bson.M{"$ne": userID}, otherVoters: bson.M{"$ne": userID}},
+		bson.M{"$addToSet": bson.M{voters: userID}, "$inc": bson.M{count: 1}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to vote on review: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// The user voted the other way before
+	result, err = r.Collection.UpdateOne(ctx,
+		bson.M{"_id": reviewID, otherVoters: userID},
+		bson.M{
+			"$pull":     bson.M{otherVoters: userID},
+			"$addToSet": bson.M{voters: userID},
+			"$inc":      bson.M{count: 1, otherCount: -1},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to vote on review: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Either the user already voted this way or the review does not exist
+	found, err := r.Collection.CountDocuments(ctx, bson.M{"_id": reviewID})
+	if err != nil {
+		return fmt.Errorf("failed to vote on review: %w", err)
+	}
+	if found == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+// SetMerchantReply stores the store's reply to a review, replacing any earlier reply. A nil reply removes it.
+func (r *ReviewMongo) SetMerchantReply(ctx context.Context, reviewID string, reply *models.ReviewReply) error {
+	if reviewID == "" {
+		return fmt.Errorf("review ID cannot be empty")
+	}
+
+	update := bson.M{"$unset": bson.M{"merchant_reply": ""}}
+	if reply != nil {
+		update = bson.M{"$set": bson.M{"merchant_reply": reply}}
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, bson.M{"_id": reviewID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update merchant reply: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("review not found")
+	}
+
+	return nil
+}
+
 // UpdateReviewsByProductID updates all reviews for a specific product.
 func (r *ReviewMongo) UpdateReviewsByProductID(ctx context.Context, productID string, update bson.M) error {
 	if productID == "" {
@@ -283,14 +420,14 @@ func (r *ReviewMongo) DeleteReviewsByUserID(ctx context.Context, userID string)
 	return nil
 }
 
-// GetProductRatingStats gets rating statistics for a product.
+// GetProductRatingStats gets rating statistics for a product's approved reviews.
 func (r *ReviewMongo) GetProductRatingStats(ctx context.Context, productID string) (map[string]any, error) {
 	if productID == "" {
 		return nil, fmt.Errorf("product ID cannot be empty")
 	}
 
 	pipeline := []bson.M{
-		{"$match": bson.M{"product_id": productID}},
+		{"$match": bson.M{"product_id": productID, "status": PublishedReviewFilter()}},
 		{"$group": bson.M{
 			"_id":           nil,
 			"averageRating": bson.M{"$avg": "$rating"},
@@ -330,3 +467,84 @@ func (r *ReviewMongo) GetProductRatingStats(ctx context.Context, productID strin
 
 	return results[0], nil
 }
+
+// ProductRatingSummary holds the average, count and 1-5 star histogram of a product's approved reviews.
+type ProductRatingSummary struct {
+	ProductID string
+	Average   float64
+	Count     int64
+	Histogram [5]int64 // Histogram[0] counts 1-star reviews
+}
+
+// GetProductRatingSummary computes the rating summary of a product's approved reviews.
+func (r *ReviewMongo) GetProductRatingSummary(ctx context.Context, productID string) (ProductRatingSummary, error) {
+	if productID == "" {
+		return ProductRatingSummary{}, fmt.Errorf("product ID cannot be empty")
+	}
+
+	summaries, err := r.aggregateRatingSummaries(ctx, bson.M{"product_id": productID, "status": PublishedReviewFilter()})
+	if err != nil {
+		return ProductRatingSummary{}, err
+	}
+	if summary, ok := summaries[productID]; ok {
+		return summary, nil
+	}
+	return ProductRatingSummary{ProductID: productID}, nil
+}
+
+// GetAllProductRatingSummaries computes the rating summaries of every product with approved reviews, keyed by product ID.
+func (r *ReviewMongo) GetAllProductRatingSummaries(ctx context.Context) (map[string]ProductRatingSummary, error) {
+	return r.aggregateRatingSummaries(ctx, bson.M{"status": PublishedReviewFilter()})
+}
+
+// aggregateRatingSummaries counts matching reviews per product and rating, and builds a summary for each product.
+func (r *ReviewMongo) aggregateRatingSummaries(ctx context.Context, match bson.M) (map[string]ProductRatingSummary, error) {
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":   bson.M{"product_id": "$product_id", "rating": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate rating summaries: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			fmt.Printf("cursor.Close failed: %v\n", err)
+		}
+	}()
+
+	var groups []struct {
+		ID struct {
+			ProductID string `bson:"product_id"`
+			Rating    int    `bson:"rating"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode rating summaries: %w", err)
+	}
+
+	totals := make(map[string]int64)
+	summaries := make(map[string]ProductRatingSummary)
+	for _, group := range groups {
+		if group.ID.Rating < 1 || group.ID.Rating > 5 {
+			continue
+		}
+		summary := summaries[group.ID.ProductID]
+		summary.ProductID = group.ID.ProductID
+		summary.Histogram[group.ID.Rating-1] += group.Count
+		summary.Count += group.Count
+		summaries[group.ID.ProductID] = summary
+		totals[group.ID.ProductID] += int64(group.ID.Rating) * group.Count
+	}
+	for productID, summary := range summaries {
+		summary.Average = math.Round(float64(totals[productID])/float64(summary.Count)*100) / 100
+		summaries[productID] = summary
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,150 @@
+// Package mongo provides MongoDB repositories and helpers for the ecom-backend project.
+package intmongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_media.go: MongoDB repository for photos uploaded for reviews, tracking who uploaded them and which review uses them.
+
+// ErrReviewMediaUnavailable is returned when media cannot be attached to a review because it does not exist,
+// belongs to another user or is already used by another review.
+var ErrReviewMediaUnavailable = errors.New("review media not available")
+
+// ReviewMediaMongo handles review media operations in MongoDB.
+type ReviewMediaMongo struct {
+	Collection CollectionInterface
+}
+
+// NewReviewMediaMongo creates a new ReviewMediaMongo instance for the given MongoDB database.
+func NewReviewMediaMongo(db *mongo.Database) *ReviewMediaMongo {
+	return &ReviewMediaMongo{
+		Collection: &MongoCollectionAdapter{
+			Inner: db.Collection("review_media"),
+		},
+	}
+}
+
+// CreateReviewMedia records an uploaded file.
+func (r *ReviewMediaMongo) CreateReviewMedia(ctx context.Context, media *models.ReviewMedia) error {
+	if media == nil {
+		return fmt.Errorf("media cannot be nil")
+	}
+	if media.ID == "" {
+		media.ID = bson.NewObjectID().Hex()
+	}
+	if media.CreatedAt.IsZero() {
+		media.CreatedAt = time.Now().UTC()
+	}
+
+	if _, err := r.Collection.InsertOne(ctx, media); err != nil {
+		return fmt.Errorf("failed to create review media: %w", err)
+	}
+	return nil
+}
+
+// GetReviewMediaByIDs returns the media with the given IDs. IDs that do not exist are left out.
+func (r *ReviewMediaMongo) GetReviewMediaByIDs(ctx context.Context, ids []string) ([]*models.ReviewMedia, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find review media: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			fmt.Printf("cursor.Close failed: %v\n", err)
+		}
+	}()
+
+	var media []*models.ReviewMedia
+	if err := cursor.All(ctx, &media); err != nil {
+		return nil, fmt.Errorf("failed to decode review media: %w", err)
+	}
+	return media, nil
+}
+
+// CountReviewMediaByUser returns how many files a user has uploaded and not deleted.
+func (r *ReviewMediaMongo) CountReviewMediaByUser(ctx context.Context, userID string) (int64, error) {
+	if userID == "" {
+		return 0, fmt.Errorf("user ID cannot be empty")
+	}
+
+	count, err := r.Collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count review media: %w", err)
+	}
+	return count, nil
+}
+
+// AttachReviewMedia marks the media as used by a review. Only media uploaded by userID that is unused or already used
+// by this review is updated, so concurrent reviews cannot claim the same upload. The IDs must be distinct.
+// Returns ErrReviewMediaUnavailable when any of them did not match; the ones that did are attached anyway and can be
+// released with DetachReviewMedia.
+func (r *ReviewMediaMongo) AttachReviewMedia(ctx context.Context, ids []string, userID, reviewID string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if userID == "" {
+		return fmt.Errorf("user ID cannot be empty")
+	}
+	if reviewID == "" {
+		return fmt.Errorf("review ID cannot be empty")
+	}
+
+	filter := bson.M{
+		"_id":     bson.M{"$in": ids},
+		"user_id": userID,
+		"$or": bson.A{
+			bson.M{"review_id": bson.M{"$exists": false}},
+			bson.M{"review_id": ""},
+			bson.M{"review_id": reviewID},
+		},
+	}
+	result, err := r.Collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"review_id": reviewID}})
+	if err != nil {
+		return fmt.Errorf("failed to attach review media: %w", err)
+	}
+	if result.MatchedCount != int64(len(ids)) {
+		return ErrReviewMediaUnavailable
+	}
+	return nil
+}
+
+// DetachReviewMedia marks the media as unused again, leaving media attached to other reviews alone.
+func (r *ReviewMediaMongo) DetachReviewMedia(ctx context.Context, ids []string, reviewID string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if reviewID == "" {
+		return fmt.Errorf("review ID cannot be empty")
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}, "review_id": reviewID}
+	if _, err := r.Collection.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"review_id": ""}}); err != nil {
+		return fmt.Errorf("failed to detach review media: %w", err)
+	}
+	return nil
+}
+
+// DeleteReviewMediaByIDs removes the records of the given media. The stored files are removed by the caller.
+func (r *ReviewMediaMongo) DeleteReviewMediaByIDs(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := r.Collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("failed to delete review media: %w", err)
+	}
+	return nil
+}
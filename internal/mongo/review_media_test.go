@@ -0,0 +1,153 @@
+// Package mongo provides MongoDB repositories and helpers for the ecom-backend project.
+package intmongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/STaninnat/ecom-backend/models"
+)
+
+// review_media_test.go: Tests for the review media repository.
+
+// TestCreateReviewMedia tests that new media get an ID and upload time and that insert failures are wrapped.
+func TestCreateReviewMedia(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		repo := &ReviewMediaMongo{Collection: mockCollection}
+		media := &models.ReviewMedia{UserID: "user1", URL: "/static/a.jpg"}
+		mockCollection.On("InsertOne", ctx, media).Return(&mongo.InsertOneResult{}, nil)
+
+		require.NoError(t, repo.CreateReviewMedia(ctx, media))
+		assert.NotEmpty(t, media.ID)
+		assert.False(t, media.CreatedAt.IsZero())
+	})
+
+	t.Run("insert_fails", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		repo := &ReviewMediaMongo{Collection: mockCollection}
+		mockCollection.On("InsertOne", ctx, mock.Anything).Return(nil, assert.AnError)
+
+		err := repo.CreateReviewMedia(ctx, &models.ReviewMedia{UserID: "user1"})
+		require.ErrorContains(t, err, "failed to create review media")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		repo := &ReviewMediaMongo{Collection: &MockReviewCollectionInterface{}}
+		require.EqualError(t, repo.CreateReviewMedia(ctx, nil), "media cannot be nil")
+	})
+}
+
+// TestGetReviewMediaByIDs tests looking up media by ID.
+func TestGetReviewMediaByIDs(t *testing.T) {
+	ctx := context.Background()
+	mockCollection := &MockReviewCollectionInterface{}
+	repo := &ReviewMediaMongo{Collection: mockCollection}
+
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		decodeDocsInto(t, args.Get(1), bson.M{"_id": "m1", "user_id": "user1", "url": "/static/a.jpg"})
+	}).Return(nil)
+	mockCollection.On("Find", ctx, bson.M{"_id": bson.M{"$in": []string{"m1", "m2"}}}, mock.Anything).Return(mockCursor, nil)
+
+	media, err := repo.GetReviewMediaByIDs(ctx, []string{"m1", "m2"})
+	require.NoError(t, err)
+	require.Len(t, media, 1)
+	assert.Equal(t, "user1", media[0].UserID)
+
+	media, err = repo.GetReviewMediaByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, media)
+	mockCollection.AssertNumberOfCalls(t, "Find", 1)
+}
+
+// TestCountReviewMediaByUser tests counting a user's uploads.
+func TestCountReviewMediaByUser(t *testing.T) {
+	ctx := context.Background()
+	mockCollection := &MockReviewCollectionInterface{}
+	repo := &ReviewMediaMongo{Collection: mockCollection}
+	mockCollection.On("CountDocuments", ctx, bson.M{"user_id": "user1"}, mock.Anything).Return(int64(3), nil)
+	mockCollection.On("CountDocuments", ctx, bson.M{"user_id": "user2"}, mock.Anything).Return(int64(0), assert.AnError)
+
+	count, err := repo.CountReviewMediaByUser(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	_, err = repo.CountReviewMediaByUser(ctx, "user2")
+	require.ErrorContains(t, err, "failed to count review media")
+
+	_, err = repo.CountReviewMediaByUser(ctx, "")
+	require.EqualError(t, err, "user ID cannot be empty")
+}
+
+// TestAttachAndDeleteReviewMedia tests attaching media to a review and deleting media records.
+func TestAttachAndDeleteReviewMedia(t *testing.T) {
+	ctx := context.Background()
+	ids := []string{"m1", "m2"}
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	attachFilter := bson.M{
+		"_id":     bson.M{"$in": ids},
+		"user_id": "user1",
+		"$or": bson.A{
+			bson.M{"review_id": bson.M{"$exists": false}},
+			bson.M{"review_id": ""},
+			bson.M{"review_id": "review1"},
+		},
+	}
+
+	mockCollection := &MockReviewCollectionInterface{}
+	repo := &ReviewMediaMongo{Collection: mockCollection}
+	mockCollection.On("UpdateMany", ctx, attachFilter, bson.M{"$set": bson.M{"review_id": "review1"}}, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 2}, nil)
+	mockCollection.On("DeleteMany", ctx, filter, mock.Anything).Return(&mongo.DeleteResult{DeletedCount: 2}, nil)
+
+	require.NoError(t, repo.AttachReviewMedia(ctx, ids, "user1", "review1"))
+	require.NoError(t, repo.DeleteReviewMediaByIDs(ctx, ids))
+	require.NoError(t, repo.AttachReviewMedia(ctx, nil, "user1", "review1"))
+	require.NoError(t, repo.DeleteReviewMediaByIDs(ctx, nil))
+	require.EqualError(t, repo.AttachReviewMedia(ctx, ids, "", "review1"), "user ID cannot be empty")
+	require.EqualError(t, repo.AttachReviewMedia(ctx, ids, "user1", ""), "review ID cannot be empty")
+	mockCollection.AssertExpectations(t)
+
+	// Media owned by someone else or used by another review is not matched
+	partial := &MockReviewCollectionInterface{}
+	repo = &ReviewMediaMongo{Collection: partial}
+	partial.On("UpdateMany", ctx, attachFilter, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+	require.ErrorIs(t, repo.AttachReviewMedia(ctx, ids, "user1", "review1"), ErrReviewMediaUnavailable)
+
+	failing := &MockReviewCollectionInterface{}
+	repo = &ReviewMediaMongo{Collection: failing}
+	failing.On("UpdateMany", ctx, attachFilter, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	failing.On("DeleteMany", ctx, filter, mock.Anything).Return(nil, assert.AnError)
+	require.ErrorContains(t, repo.AttachReviewMedia(ctx, ids, "user1", "review1"), "failed to attach review media")
+	require.ErrorContains(t, repo.DeleteReviewMediaByIDs(ctx, ids), "failed to delete review media")
+}
+
+// TestDetachReviewMedia tests that only media attached to the given review is marked unused again.
+func TestDetachReviewMedia(t *testing.T) {
+	ctx := context.Background()
+	ids := []string{"m1", "m2"}
+	filter := bson.M{"_id": bson.M{"$in": ids}, "review_id": "review1"}
+
+	mockCollection := &MockReviewCollectionInterface{}
+	repo := &ReviewMediaMongo{Collection: mockCollection}
+	mockCollection.On("UpdateMany", ctx, filter, bson.M{"$unset": bson.M{"review_id": ""}}, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 2}, nil)
+
+	require.NoError(t, repo.DetachReviewMedia(ctx, ids, "review1"))
+	require.NoError(t, repo.DetachReviewMedia(ctx, nil, "review1"))
+	require.EqualError(t, repo.DetachReviewMedia(ctx, ids, ""), "review ID cannot be empty")
+	mockCollection.AssertExpectations(t)
+
+	failing := &MockReviewCollectionInterface{}
+	repo = &ReviewMediaMongo{Collection: failing}
+	failing.On("UpdateMany", ctx, filter, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	require.ErrorContains(t, repo.DetachReviewMedia(ctx, ids, "review1"), "failed to detach review media")
+}
@@ -4,6 +4,7 @@ package intmongo
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -266,6 +267,20 @@ func TestCreateReview(t *testing.T) {
 	}
 }
 
+// TestCreateReview_Duplicate tests that a duplicate key error from the unique user/product index maps to ErrDuplicateReview.
+func TestCreateReview_Duplicate(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	dupErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+	mockCollection.On("InsertOne", ctx, mock.AnythingOfType("*models.Review")).Return(nil, dupErr)
+
+	err := reviewMongo.CreateReview(ctx, &models.Review{UserID: "user123", ProductID: "product123", Rating: 5})
+	require.ErrorIs(t, err, ErrDuplicateReview)
+	mockCollection.AssertExpectations(t)
+}
+
 // TestCreateReviews tests the CreateReviews function with multiple reviews.
 // It verifies successful batch review creation and database error handling.
 func TestCreateReviews(t *testing.T) {
@@ -1394,6 +1409,26 @@ func TestGetProductRatingStats(t *testing.T) {
 	}
 }
 
+// TestGetProductRatingStats_OnlyPublished tests that rating stats only count approved reviews.
+func TestGetProductRatingStats_OnlyPublished(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.Anything).Return(nil)
+	mockCollection.On("Aggregate", ctx, mock.MatchedBy(func(pipeline []bson.M) bool {
+		match, ok := pipeline[0]["$match"].(bson.M)
+		return ok && match["product_id"] == "product123" && assert.ObjectsAreEqual(PublishedReviewFilter(), match["status"])
+	}), mock.Anything).Return(mockCursor, nil)
+
+	_, err := reviewMongo.GetProductRatingStats(ctx, "product123")
+
+	require.NoError(t, err)
+	mockCollection.AssertExpectations(t)
+}
+
 // TestGetProductRatingStats_EmptyProductID tests GetProductRatingStats with empty product ID.
 // It verifies that empty product ID returns an error.
 func TestGetProductRatingStats_EmptyProductID(t *testing.T) {
@@ -1666,3 +1701,284 @@ func TestGetReviewsByID_DecodeError(t *testing.T) {
 		})
 	}
 }
+
+// TestGetReviewsByStatusPaginated tests listing reviews in a moderation status.
+func TestGetReviewsByStatusPaginated(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	mockCollection.On("CountDocuments", ctx, bson.M{"status": models.ReviewStatusPending}, mock.Anything).Return(int64(3), nil)
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.AnythingOfType("*[]*models.Review")).Return(nil)
+	mockCollection.On("Find", ctx, bson.M{"status": models.ReviewStatusPending}, mock.Anything).Return(mockCursor, nil)
+
+	result, err := reviewMongo.GetReviewsByStatusPaginated(ctx, models.ReviewStatusPending, NewPaginationOptions(1, 10))
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.TotalCount)
+	mockCollection.AssertExpectations(t)
+}
+
+// TestUpdateReviewStatus tests recording a moderation decision.
+func TestUpdateReviewStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		reviewID    string
+		update      ReviewStatusUpdate
+		result      *mongo.UpdateResult
+		dbErr       error
+		expectedErr string
+	}{
+		{
+			name:     "success",
+			reviewID: "review123",
+			update:   ReviewStatusUpdate{Status: models.ReviewStatusHidden, Reason: "Abusive", ModeratedBy: "admin1"},
+			result:   &mongo.UpdateResult{MatchedCount: 1},
+		},
+		{name: "empty_id", update: ReviewStatusUpdate{Status: models.ReviewStatusApproved}, expectedErr: "review ID cannot be empty"},
+		{name: "empty_status", reviewID: "review123", expectedErr: "status cannot be empty"},
+		{
+			name:        "not_found",
+			reviewID:    "review123",
+			update:      ReviewStatusUpdate{Status: models.ReviewStatusApproved},
+			result:      &mongo.UpdateResult{MatchedCount: 0},
+			expectedErr: "review not found",
+		},
+		{
+			name:        "db_error",
+			reviewID:    "review123",
+			update:      ReviewStatusUpdate{Status: models.ReviewStatusApproved},
+			dbErr:       assert.AnError,
+			expectedErr: "failed to update review status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCollection := &MockReviewCollectionInterface{}
+			reviewMongo := &ReviewMongo{Collection: mockCollection}
+			ctx := context.Background()
+			if tt.result != nil || tt.dbErr != nil {
+				mockCollection.On("UpdateOne", ctx, bson.M{"_id": tt.reviewID}, mock.MatchedBy(func(update bson.M) bool {
+					set := update["$set"].(bson.M)
+					return set["status"] == tt.update.Status && set["moderation_reason"] == tt.update.Reason && set["moderated_by"] == tt.update.ModeratedBy
+				}), mock.Anything).Return(tt.result, tt.dbErr)
+			}
+
+			err := reviewMongo.UpdateReviewStatus(ctx, tt.reviewID, tt.update)
+
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			mockCollection.AssertExpectations(t)
+		})
+	}
+}
+
+// decodeDocsInto fills the slice pointed to by results with the given documents, as cursor.All would.
+func decodeDocsInto(t *testing.T, results any, docs ...bson.M) {
+	t.Helper()
+	slice := reflect.ValueOf(results).Elem()
+	for _, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		require.NoError(t, err)
+		elem := reflect.New(slice.Type().Elem())
+		require.NoError(t, bson.Unmarshal(raw, elem.Interface()))
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+}
+
+// TestGetProductRatingSummary tests that per-rating counts are turned into an average and histogram.
+func TestGetProductRatingSummary(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		decodeDocsInto(t, args.Get(1),
+			bson.M{"_id": bson.M{"product_id": "product123", "rating": 5}, "count": 2},
+			bson.M{"_id": bson.M{"product_id": "product123", "rating": 4}, "count": 1},
+			bson.M{"_id": bson.M{"product_id": "product123", "rating": 1}, "count": 1},
+		)
+	}).Return(nil)
+	mockCollection.On("Aggregate", ctx, mock.MatchedBy(func(pipeline []bson.M) bool {
+		match := pipeline[0]["$match"].(bson.M)
+		return match["product_id"] == "product123" && assert.ObjectsAreEqual(PublishedReviewFilter(), match["status"])
+	}), mock.Anything).Return(mockCursor, nil)
+
+	summary, err := reviewMongo.GetProductRatingSummary(ctx, "product123")
+
+	require.NoError(t, err)
+	assert.Equal(t, ProductRatingSummary{
+		ProductID: "product123",
+		Average:   3.75,
+		Count:     4,
+		Histogram: [5]int64{1, 0, 0, 1, 2},
+	}, summary)
+}
+
+// TestGetProductRatingSummary_NoReviews tests that a product without approved reviews gets an empty summary.
+func TestGetProductRatingSummary_NoReviews(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.Anything).Return(nil)
+	mockCollection.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(mockCursor, nil)
+
+	summary, err := reviewMongo.GetProductRatingSummary(ctx, "product123")
+
+	require.NoError(t, err)
+	assert.Equal(t, ProductRatingSummary{ProductID: "product123"}, summary)
+
+	_, err = reviewMongo.GetProductRatingSummary(ctx, "")
+	require.Error(t, err)
+}
+
+// TestGetAllProductRatingSummaries tests building summaries for several products in one aggregation.
+func TestGetAllProductRatingSummaries(t *testing.T) {
+	mockCollection := &MockReviewCollectionInterface{}
+	reviewMongo := &ReviewMongo{Collection: mockCollection}
+	ctx := context.Background()
+
+	mockCursor := &MockCursor{}
+	mockCursor.On("Close", ctx).Return(nil)
+	mockCursor.On("All", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		decodeDocsInto(t, args.Get(1),
+			bson.M{"_id": bson.M{"product_id": "p1", "rating": 3}, "count": 2},
+			bson.M{"_id": bson.M{"product_id": "p2", "rating": 5}, "count": 1},
+			bson.M{"_id": bson.M{"product_id": "p2", "rating": 9}, "count": 1},
+		)
+	}).Return(nil)
+	mockCollection.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(mockCursor, nil)
+
+	summaries, err := reviewMongo.GetAllProductRatingSummaries(ctx)
+
+	require.NoError(t, err)
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, ProductRatingSummary{ProductID: "p1", Average: 3, Count: 2, Histogram: [5]int64{0, 0, 2, 0, 0}}, summaries["p1"])
+	assert.Equal(t, ProductRatingSummary{ProductID: "p2", Average: 5, Count: 1, Histogram: [5]int64{0, 0, 0, 0, 1}}, summaries["p2"])
+
+	mockCollection.ExpectedCalls = nil
+	mockCollection.On("Aggregate", ctx, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	_, err = reviewMongo.GetAllProductRatingSummaries(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to aggregate rating summaries")
+}
+
+// TestVoteReview tests first votes, switched votes, repeated votes and missing reviews.
+func TestVoteReview(t *testing.T) {
+	ctx := context.Background()
+	firstVote := bson.M{"_id": "review123", "helpful_voters": bson.M{"$ne": "user1"}, "unhelpful_voters": bson.M{"$ne": "user1"}}
+	switchVote := bson.M{"_id": "review123", "unhelpful_voters": "user1"}
+
+	t.Run("first_vote", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		reviewMongo := &ReviewMongo{Collection: mockCollection}
+		mockCollection.On("UpdateOne", ctx, firstVote, bson.M{
+			"$addToSet": bson.M{"helpful_voters": "user1"},
+			"$inc":      bson.M{"helpful_count": 1},
+		}, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+		require.NoError(t, reviewMongo.VoteReview(ctx, "review123", "user1", true))
+		mockCollection.AssertExpectations(t)
+	})
+
+	t.Run("switch_vote", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		reviewMongo := &ReviewMongo{Collection: mockCollection}
+		mockCollection.On("UpdateOne", ctx, firstVote, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, nil)
+		mockCollection.On("UpdateOne", ctx, switchVote, bson.M{
+			"$pull":     bson.M{"unhelpful_voters": "user1"},
+			"$addToSet": bson.M{"helpful_voters": "user1"},
+			"$inc":      bson.M{"helpful_count": 1, "unhelpful_count": -1},
+		}, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+		require.NoError(t, reviewMongo.VoteReview(ctx, "review123", "user1", true))
+		mockCollection.AssertExpectations(t)
+	})
+
+	t.Run("repeated_vote", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		reviewMongo := &ReviewMongo{Collection: mockCollection}
+		mockCollection.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, nil)
+		mockCollection.On("CountDocuments", ctx, bson.M{"_id": "review123"}, mock.Anything).Return(int64(1), nil)
+
+		require.NoError(t, reviewMongo.VoteReview(ctx, "review123", "user1", true))
+		mockCollection.AssertNumberOfCalls(t, "UpdateOne", 2)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		reviewMongo := &ReviewMongo{Collection: mockCollection}
+		mockCollection.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, nil)
+		mockCollection.On("CountDocuments", ctx, bson.M{"_id": "review123"}, mock.Anything).Return(int64(0), nil)
+
+		err := reviewMongo.VoteReview(ctx, "review123", "user1", false)
+		require.EqualError(t, err, "review not found")
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		mockCollection := &MockReviewCollectionInterface{}
+		reviewMongo := &ReviewMongo{Collection: mockCollection}
+		mockCollection.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		err := reviewMongo.VoteReview(ctx, "review123", "user1", false)
+		require.ErrorContains(t, err, "failed to vote on review")
+	})
+
+	t.Run("empty_ids", func(t *testing.T) {
+		reviewMongo := &ReviewMongo{Collection: &MockReviewCollectionInterface{}}
+		require.EqualError(t, reviewMongo.VoteReview(ctx, "", "user1", true), "review ID cannot be empty")
+		require.EqualError(t, reviewMongo.VoteReview(ctx, "review123", "", true), "user ID cannot be empty")
+	})
+}
+
+// TestSetMerchantReply tests setting, removing and failing to store a merchant reply.
+func TestSetMerchantReply(t *testing.T) {
+	ctx := context.Background()
+	reply := &models.ReviewReply{Comment: "Thanks for the feedback", RepliedBy: "admin1"}
+
+	tests := []struct {
+		name        string
+		reviewID    string
+		reply       *models.ReviewReply
+		update      bson.M
+		result      *mongo.UpdateResult
+		dbErr       error
+		expectedErr string
+	}{
+		{name: "set", reviewID: "review123", reply: reply, update: bson.M{"$set": bson.M{"merchant_reply": reply}}, result: &mongo.UpdateResult{MatchedCount: 1}},
+		{name: "remove", reviewID: "review123", update: bson.M{"$unset": bson.M{"merchant_reply": ""}}, result: &mongo.UpdateResult{MatchedCount: 1}},
+		{name: "not_found", reviewID: "review123", reply: reply, update: bson.M{"$set": bson.M{"merchant_reply": reply}}, result: &mongo.UpdateResult{}, expectedErr: "review not found"},
+		{name: "db_error", reviewID: "review123", reply: reply, update: bson.M{"$set": bson.M{"merchant_reply": reply}}, dbErr: assert.AnError, expectedErr: "failed to update merchant reply"},
+		{name: "empty_id", reply: reply, expectedErr: "review ID cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCollection := &MockReviewCollectionInterface{}
+			reviewMongo := &ReviewMongo{Collection: mockCollection}
+			if tt.update != nil {
+				mockCollection.On("UpdateOne", ctx, bson.M{"_id": tt.reviewID}, tt.update, mock.Anything).Return(tt.result, tt.dbErr)
+			}
+
+			err := reviewMongo.SetMerchantReply(ctx, tt.reviewID, tt.reply)
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			mockCollection.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// cursor.go: Defines Cursor, its opaque token encoding and the query arguments it turns into.
+
+// ErrInvalidCursor is returned when a cursor token is malformed or was issued for a different sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last row of a page: the sort it was read under, that row's sort values and its ID.
+// Only the values the sort orders by are set; the ID breaks ties so the order is stable.
+type Cursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"t,omitzero"`
+	Value     string    `json:"v,omitempty"`
+	Count     int32     `json:"c,omitempty"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a token returned by Encode. An empty token means the first page and returns nil.
+// The token must have been issued for sort, so a client cannot continue a listing under a different order.
+func Decode(token, sort string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// IDArg returns the cursor's ID as a query argument; it is null for a nil cursor, meaning the first page.
+func (c *Cursor) IDArg() sql.NullString {
+	if c == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: c.ID, Valid: true}
+}
+
+// CreatedAtArg returns the cursor's creation time as a query argument, null for a nil cursor or when it is unset.
+func (c *Cursor) CreatedAtArg() sql.NullTime {
+	if c == nil || c.CreatedAt.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: c.CreatedAt, Valid: true}
+}
+
+// ValueArg returns the cursor's sort value as a query argument, null for a nil cursor.
+func (c *Cursor) ValueArg() sql.NullString {
+	if c == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: c.Value, Valid: true}
+}
+
+// CountArg returns the cursor's count as a query argument, null for a nil cursor.
+func (c *Cursor) CountArg() sql.NullInt32 {
+	if c == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: c.Count, Valid: true}
+}
@@ -0,0 +1,67 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cursor_test.go: Tests for cursor token encoding and decoding.
+
+// TestCursor_RoundTrip tests that a decoded token matches the encoded cursor.
+func TestCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 9, 30, 0, 123456000, time.UTC)
+	c := Cursor{Sort: "price_asc", CreatedAt: createdAt, Value: "19.99", Count: 4, ID: "prod_1"}
+
+	decoded, err := Decode(c.Encode(), "price_asc")
+	require.NoError(t, err)
+	require.NotNil(t, decoded)
+	assert.True(t, createdAt.Equal(decoded.CreatedAt))
+	decoded.CreatedAt = createdAt
+	assert.Equal(t, c, *decoded)
+}
+
+// TestDecode_Empty tests that an empty token means the first page.
+func TestDecode_Empty(t *testing.T) {
+	c, err := Decode("", "newest")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+// TestDecode_Invalid tests that malformed tokens and tokens issued for another sort are rejected.
+func TestDecode_Invalid(t *testing.T) {
+	tokens := map[string]string{
+		"not base64":   "%%%",
+		"not json":     base64.RawURLEncoding.EncodeToString([]byte("nope")),
+		"missing id":   Cursor{Sort: "newest"}.Encode(),
+		"another sort": Cursor{Sort: "oldest", ID: "prod_1"}.Encode(),
+	}
+	for name, token := range tokens {
+		c, err := Decode(token, "newest")
+		assert.ErrorIs(t, err, ErrInvalidCursor, name)
+		assert.Nil(t, c, name)
+	}
+}
+
+// TestCursor_Args tests the query arguments of a cursor and of the first page.
+func TestCursor_Args(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	c := &Cursor{Sort: "rating_desc", CreatedAt: createdAt, Value: "4.50", Count: 12, ID: "prod_1"}
+
+	assert.Equal(t, sql.NullString{String: "prod_1", Valid: true}, c.IDArg())
+	assert.Equal(t, sql.NullTime{Time: createdAt, Valid: true}, c.CreatedAtArg())
+	assert.Equal(t, sql.NullString{String: "4.50", Valid: true}, c.ValueArg())
+	assert.Equal(t, sql.NullInt32{Int32: 12, Valid: true}, c.CountArg())
+	assert.False(t, (&Cursor{ID: "prod_1"}).CreatedAtArg().Valid)
+
+	var first *Cursor
+	assert.False(t, first.IDArg().Valid)
+	assert.False(t, first.CreatedAtArg().Valid)
+	assert.False(t, first.ValueArg().Valid)
+	assert.False(t, first.CountArg().Valid)
+}
@@ -0,0 +1,33 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+// page.go: Defines Page, the response shape of cursor-paginated listings.
+
+// Page is one page of a cursor-paginated listing, the SQL counterpart of the Mongo PaginatedResult.
+// NextCursor is set when HasNext is true and is passed back as the cursor query parameter to get the next page.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+}
+
+// NewPage builds a page from rows queried with FetchLimit(limit). The extra row, if present, is dropped and
+// signals that there is a next page, whose cursor is built from the last row kept.
+func NewPage[T any](rows []T, limit int, cursor func(T) Cursor) Page[T] {
+	page := Page[T]{Data: rows, Limit: limit}
+	if len(rows) > limit {
+		page.Data = rows[:limit]
+		page.HasNext = true
+		page.NextCursor = cursor(page.Data[limit-1]).Encode()
+	}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+	return page
+}
+
+// FetchLimit returns how many rows to query for a page of limit rows: one more, to tell whether a next page exists.
+func FetchLimit(limit int) int32 {
+	return int32(limit + 1)
+}
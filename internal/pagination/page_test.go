@@ -0,0 +1,51 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// page_test.go: Tests for building pages from rows queried with one extra row.
+
+func idCursor(id string) Cursor {
+	return Cursor{Sort: "newest", ID: id}
+}
+
+// TestNewPage_HasNext tests that the extra row is dropped and the cursor points at the last row kept.
+func TestNewPage_HasNext(t *testing.T) {
+	page := NewPage([]string{"a", "b", "c"}, 2, idCursor)
+
+	assert.Equal(t, []string{"a", "b"}, page.Data)
+	assert.Equal(t, 2, page.Limit)
+	assert.True(t, page.HasNext)
+	c, err := Decode(page.NextCursor, "newest")
+	require.NoError(t, err)
+	assert.Equal(t, "b", c.ID)
+}
+
+// TestNewPage_LastPage tests that a short page has no next cursor.
+func TestNewPage_LastPage(t *testing.T) {
+	page := NewPage([]string{"a", "b"}, 2, idCursor)
+
+	assert.Equal(t, []string{"a", "b"}, page.Data)
+	assert.False(t, page.HasNext)
+	assert.Empty(t, page.NextCursor)
+}
+
+// TestNewPage_Empty tests that an empty page marshals its data as an empty array.
+func TestNewPage_Empty(t *testing.T) {
+	page := NewPage[string](nil, 20, idCursor)
+
+	data, err := json.Marshal(page)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[],"limit":20,"has_next":false}`, string(data))
+}
+
+// TestFetchLimit tests that one extra row is queried.
+func TestFetchLimit(t *testing.T) {
+	assert.Equal(t, int32(21), FetchLimit(20))
+}
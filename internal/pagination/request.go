@@ -0,0 +1,44 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// request.go: Parses the cursor, limit and sort query parameters of a listing request.
+
+// Page size limits.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Request holds the page a client asked for. Cursor is empty for the first page.
+type Request struct {
+	Cursor string
+	Limit  int
+	Sort   string
+}
+
+// ParseRequest reads the cursor, limit and sort query parameters. The cursor is decoded later, against the sort.
+func ParseRequest(r *http.Request) Request {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	return Request{
+		Cursor: q.Get("cursor"),
+		Limit:  NormalizeLimit(limit),
+		Sort:   q.Get("sort"),
+	}
+}
+
+// NormalizeLimit returns DefaultLimit for a missing or non-positive limit and caps it at MaxLimit.
+func NormalizeLimit(limit int) int {
+	if limit < 1 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
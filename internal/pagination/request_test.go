@@ -0,0 +1,34 @@
+// Package pagination provides keyset (cursor) pagination for SQL listings: opaque cursors, limit caps and a page shape.
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// request_test.go: Tests for parsing the pagination query parameters.
+
+// TestParseRequest tests that the cursor, limit and sort query parameters are read.
+func TestParseRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/products/?cursor=abc&limit=5&sort=price_asc", nil)
+
+	assert.Equal(t, Request{Cursor: "abc", Limit: 5, Sort: "price_asc"}, ParseRequest(req))
+}
+
+// TestParseRequest_Defaults tests that a missing or invalid limit falls back to the default.
+func TestParseRequest_Defaults(t *testing.T) {
+	for _, target := range []string{"/v1/products/", "/v1/products/?limit=abc", "/v1/products/?limit=-3"} {
+		req := httptest.NewRequest("GET", target, nil)
+		assert.Equal(t, Request{Limit: DefaultLimit}, ParseRequest(req), target)
+	}
+}
+
+// TestNormalizeLimit tests the default and the cap.
+func TestNormalizeLimit(t *testing.T) {
+	assert.Equal(t, DefaultLimit, NormalizeLimit(0))
+	assert.Equal(t, 1, NormalizeLimit(1))
+	assert.Equal(t, MaxLimit, NormalizeLimit(MaxLimit))
+	assert.Equal(t, MaxLimit, NormalizeLimit(MaxLimit+1))
+}
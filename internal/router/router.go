@@ -51,6 +51,8 @@ func (apicfg *Config) SetupRouter(logger *logrus.Logger) *chi.Mux {
 	v1Router := apicfg.createV1Router(handlerConfigs, cacheConfigs)
 
 	router.Mount("/v1", v1Router)
+	// Published outside /v1 at the well-known path verifiers look for
+	router.Get("/.well-known/jwks.json", handlerConfigs.auth.HandlerJWKS)
 	return router
 }
 
@@ -127,19 +129,25 @@ func (apicfg *Config) createHandlerConfigs() *handlerConfigs {
 	// --- Handler Configurations ---
 	// Auth handler config: provides dependencies for auth-related handlers
 	authHandlersConfig := &authhandlers.HandlersAuthConfig{Config: apicfg.Config}
-	// User handler config: provides dependencies for user-related handlers
-	userHandlersConfig := &userhandlers.HandlersUserConfig{Config: apicfg.Config}
-	// Product handler config: includes DB, connection, and logger for product endpoints
+	// User handler config: provides dependencies for user-related handlers; changed emails are verified through the auth service
+	userHandlersConfig := &userhandlers.HandlersUserConfig{Config: apicfg.Config, Verifier: authHandlersConfig}
+	// Product handler config: includes DB, connection, logger, and image storage for product endpoints
 	productHandlersConfig := &producthandlers.HandlersProductConfig{
-		DB:     apicfg.DB,
-		DBConn: apicfg.DBConn,
-		Logger: apicfg.Config,
+		DB:         apicfg.DB,
+		DBConn:     apicfg.DBConn,
+		Logger:     apicfg.Config,
+		Storage:    apicfg.newFileStorage(),
+		UploadPath: apicfg.UploadPath,
 	}
 	// Category handler config: for category endpoints
 	categoryHandlersConfig := &categoryhandlers.HandlersCategoryConfig{Config: apicfg.Config}
 
 	// --- Order and Payment Handler Configs ---
-	orderHandlersConfig := &orderhandlers.HandlersOrderConfig{Config: apicfg.Config}
+	// Order handler config: cancelling an order settles its payment with the same providers as the payment handlers
+	orderHandlersConfig := &orderhandlers.HandlersOrderConfig{
+		Config:   apicfg.Config,
+		Payments: paymenthandlers.NewPaymentReleaser(apicfg.DB, apicfg.DBConn, apicfg.StripeSecretKey),
+	}
 	paymentHandlersConfig := &paymenthandlers.HandlersPaymentConfig{Config: apicfg.Config}
 
 	// Initialize MongoDB-dependent configs as nil
@@ -162,6 +170,7 @@ func (apicfg *Config) setupUploadHandlers(configs *handlerConfigs) {
 	// --- Upload Handler Setup ---
 	// Set up the upload handler and service, supporting both S3 and local backends
 	productDB := uploadhandlers.NewProductDBAdapter(apicfg.DB)
+	productDBConn := &uploadhandlers.ProductDBConnAdapter{DB: apicfg.DBConn}
 	var fileStorage uploadhandlers.FileStorage
 	if apicfg.UploadBackend == "s3" {
 		// Use S3 for file storage
@@ -170,7 +179,7 @@ func (apicfg *Config) setupUploadHandlers(configs *handlerConfigs) {
 			BucketName: apicfg.S3Bucket, // S3 bucket name
 		}
 		// Upload service combines DB, path, and storage backend
-		uploadService := uploadhandlers.NewUploadService(productDB, apicfg.UploadPath, fileStorage)
+		uploadService := uploadhandlers.NewUploadService(productDB, productDBConn, apicfg.UploadPath, fileStorage)
 		// Upload handler config: provides dependencies for S3 upload endpoints
 		configs.upload = &uploadhandlers.HandlersUploadS3Config{
 			Config:     apicfg.Config,
@@ -182,7 +191,7 @@ func (apicfg *Config) setupUploadHandlers(configs *handlerConfigs) {
 		// Use local filesystem for file storage
 		fileStorage = &uploadhandlers.LocalFileStorage{}
 		// Upload service combines DB, path, and storage backend
-		uploadService := uploadhandlers.NewUploadService(productDB, apicfg.UploadPath, fileStorage)
+		uploadService := uploadhandlers.NewUploadService(productDB, productDBConn, apicfg.UploadPath, fileStorage)
 		// Upload handler config: provides dependencies for local upload endpoints
 		configs.upload = &uploadhandlers.HandlersUploadConfig{
 			Config:     apicfg.Config,
@@ -193,6 +202,14 @@ func (apicfg *Config) setupUploadHandlers(configs *handlerConfigs) {
 	}
 }
 
+// newFileStorage returns the file storage backend selected by UploadBackend.
+func (apicfg *Config) newFileStorage() uploadhandlers.FileStorage {
+	if apicfg.UploadBackend == "s3" {
+		return &uploadhandlers.S3FileStorage{S3Client: apicfg.S3Client, BucketName: apicfg.S3Bucket}
+	}
+	return &uploadhandlers.LocalFileStorage{}
+}
+
 func (apicfg *Config) setupMongoHandlers(configs *handlerConfigs, logger *logrus.Logger) {
 	// --- Review and Cart Service Setup ---
 	if apicfg.MongoDB != nil {
@@ -203,7 +220,24 @@ func (apicfg *Config) setupMongoHandlers(configs *handlerConfigs, logger *logrus
 		configs.review = &reviewhandlers.HandlersReviewConfig{
 			Config: apicfg.Config,
 		}
-		reviewService := reviewhandlers.NewReviewService(reviewMongoRepo)
+		// Rating summaries are refreshed under the product row lock; cached product responses are cleared afterwards
+		ratings := reviewhandlers.RatingSummaryConfig{Logger: logger}
+		if apicfg.CacheService != nil {
+			ratings.Cache = apicfg.CacheService
+		}
+		reviewDB := &reviewhandlers.ReviewDBAdapter{Queries: apicfg.DB, DBConn: apicfg.DBConn}
+		reviewService := reviewhandlers.NewReviewService(reviewMongoRepo, reviewDB, reviewhandlers.ReviewPolicy{
+			VerifiedPurchaseOnly: apicfg.ReviewsVerifiedOnly,
+			ModerationRules:      reviewhandlers.NewModerationRules(apicfg.ReviewBannedWords, apicfg.ReviewMinWords),
+			RequireApproval:      apicfg.ReviewsRequireApproval,
+		}, reviewhandlers.ReviewMediaConfig{
+			Store:           intmongo.NewReviewMediaMongo(apicfg.MongoDB),
+			Storage:         apicfg.newFileStorage(),
+			UploadPath:      apicfg.UploadPath,
+			MaxFileSize:     apicfg.ReviewMediaMaxFileSize,
+			MaxFilesPerUser: apicfg.ReviewMediaMaxPerUser,
+			MaxPerReview:    apicfg.ReviewMediaMaxPerReview,
+		}, ratings)
 		err := configs.review.InitReviewService(reviewService)
 		if err != nil {
 			logger.Fatal("Failed to initialize review service:", err)
@@ -265,7 +299,7 @@ func (apicfg *Config) createV1Router(configs *handlerConfigs, cacheConfigs map[s
 	apicfg.setupCartRoutes(v1Router, configs.cart)
 	apicfg.setupPaymentRoutes(v1Router, configs.payment)
 	apicfg.setupReviewRoutes(v1Router, configs.review)
-	apicfg.setupAdminRoutes(v1Router, configs.user)
+	apicfg.setupAdminRoutes(v1Router, configs.user, configs.auth)
 
 	return v1Router
 }
@@ -273,12 +307,18 @@ func (apicfg *Config) createV1Router(configs *handlerConfigs, cacheConfigs map[s
 func (apicfg *Config) setupAuthRoutes(v1Router *chi.Mux, authConfig *authhandlers.HandlersAuthConfig) {
 	// --- Auth Subrouter ---
 	authRouter := chi.NewRouter()
-	authRouter.Post("/signup", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignUp)).(http.HandlerFunc))        // User registration
-	authRouter.Post("/signin", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignIn)).(http.HandlerFunc))        // User login
-	authRouter.Post("/signout", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignOut)).(http.HandlerFunc))      // User logout
-	authRouter.Post("/refresh", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerRefreshToken)).(http.HandlerFunc)) // Refresh JWT tokens
-	authRouter.Get("/google/signin", Adapt(authConfig.HandlerGoogleSignIn))                                           // Google OAuth2 start
-	authRouter.Get("/google/callback", Adapt(authConfig.HandlerGoogleCallback))                                       // Google OAuth2 callback
+	authRouter.Post("/signup", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignUp)).(http.HandlerFunc))                                 // User registration
+	authRouter.Post("/signin", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignIn)).(http.HandlerFunc))                                 // User login
+	authRouter.Post("/signout", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerSignOut)).(http.HandlerFunc))                               // User logout
+	authRouter.Post("/refresh", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerRefreshToken)).(http.HandlerFunc))                          // Refresh JWT tokens
+	authRouter.Get("/google/signin", Adapt(authConfig.HandlerGoogleSignIn))                                                                    // Google OAuth2 start
+	authRouter.Get("/google/callback", Adapt(authConfig.HandlerGoogleCallback))                                                                // Google OAuth2 callback
+	authRouter.Post("/verify-email", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerVerifyEmail)).(http.HandlerFunc))                      // Verify email address
+	authRouter.Post("/forgot-password", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerForgotPassword)).(http.HandlerFunc))                // Request password reset email
+	authRouter.Post("/reset-password", middlewares.NoCacheHeaders(Adapt(authConfig.HandlerResetPassword)).(http.HandlerFunc))                  // Reset password with emailed token
+	authRouter.Get("/sessions", middlewares.NoCacheHeaders(WithUser(authConfig.HandlerListSessions)).(http.HandlerFunc))                       // List signed-in devices
+	authRouter.Delete("/sessions/{id}", middlewares.NoCacheHeaders(WithUser(authConfig.HandlerRevokeSession)).(http.HandlerFunc))              // Sign out one device
+	authRouter.Post("/sessions/revoke-others", middlewares.NoCacheHeaders(WithUser(authConfig.HandlerRevokeOtherSessions)).(http.HandlerFunc)) // Sign out all other devices
 	v1Router.Mount("/auth", authRouter)
 }
 
@@ -293,21 +333,34 @@ func (apicfg *Config) setupUserRoutes(v1Router *chi.Mux, userConfig *userhandler
 func (apicfg *Config) setupProductRoutes(v1Router *chi.Mux, productConfig *producthandlers.HandlersProductConfig, uploadConfig any, cacheConfig middlewares.CacheConfig) {
 	// --- Product Subrouter ---
 	productsRouter := chi.NewRouter()
-	productsRouter.Get("/", middlewares.CacheMiddleware(cacheConfig)(WithOptionalUser(productConfig.HandlerGetAllProducts)).(http.HandlerFunc))                      // List all products (cached)
-	productsRouter.Get("/filter", middlewares.CacheMiddleware(cacheConfig)(WithOptionalUser(productConfig.HandlerFilterProducts)).(http.HandlerFunc))                // Filter products (cached)
-	productsRouter.Get("/{id}", WithUser(productConfig.HandlerGetProductByID))                                                                                       // Get product details (requires auth)
-	productsRouter.Post("/", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerCreateProduct)).(http.HandlerFunc))       // Admin: create product, invalidates cache
-	productsRouter.Put("/", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerUpdateProduct)).(http.HandlerFunc))        // Admin: update product, invalidates cache
-	productsRouter.Delete("/{id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerDeleteProduct)).(http.HandlerFunc)) // Admin: delete product, invalidates cache
-	// Use correct upload handler based on backend
+	productsRouter.Get("/", middlewares.CacheMiddleware(cacheConfig)(WithOptionalUser(productConfig.HandlerGetAllProducts)).(http.HandlerFunc))                                                   // List all products (cached)
+	productsRouter.Get("/filter", middlewares.CacheMiddleware(cacheConfig)(WithOptionalUser(productConfig.HandlerFilterProducts)).(http.HandlerFunc))                                             // Filter products (cached)
+	productsRouter.Get("/search", middlewares.CacheMiddleware(cacheConfig)(WithOptionalUser(productConfig.HandlerSearchProducts)).(http.HandlerFunc))                                             // Search products by keyword (cached per query string)
+	productsRouter.Get("/{id}", WithUser(productConfig.HandlerGetProductByID))                                                                                                                    // Get product details (requires auth)
+	productsRouter.Post("/", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerCreateProduct)).(http.HandlerFunc))                                    // Admin: create product, invalidates cache
+	productsRouter.Put("/", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerUpdateProduct)).(http.HandlerFunc))                                     // Admin: update product, invalidates cache
+	productsRouter.Delete("/{id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerDeleteProduct)).(http.HandlerFunc))                              // Admin: delete product, invalidates cache
+	productsRouter.Get("/{id}/variants", WithOptionalUser(productConfig.HandlerListProductVariants))                                                                                              // List product variants (admin sees inactive ones)
+	productsRouter.Post("/{id}/variants", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerCreateProductVariant)).(http.HandlerFunc))                // Admin: add a variant, invalidates cache
+	productsRouter.Put("/{id}/variants/{variant_id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerUpdateProductVariant)).(http.HandlerFunc))    // Admin: update a variant, invalidates cache
+	productsRouter.Delete("/{id}/variants/{variant_id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(productConfig.HandlerDeleteProductVariant)).(http.HandlerFunc)) // Admin: deactivate a variant, invalidates cache
+	// Use correct upload handler based on backend; image changes invalidate the cache since listings embed the gallery
 	if apicfg.UploadBackend == "s3" {
 		s3UploadConfig := uploadConfig.(*uploadhandlers.HandlersUploadS3Config)
 		productsRouter.Post("/upload-image", WithAdmin(s3UploadConfig.HandlerS3UploadProductImage))
-		productsRouter.Post("/{id}/image", WithAdmin(s3UploadConfig.HandlerS3UpdateProductImageByID))
+		productsRouter.Post("/{id}/image", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(s3UploadConfig.HandlerS3UpdateProductImageByID)).(http.HandlerFunc))
+		productsRouter.Post("/{id}/images", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(s3UploadConfig.HandlerS3AddProductImage)).(http.HandlerFunc))
+		productsRouter.Put("/{id}/images/order", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(s3UploadConfig.HandlerS3ReorderProductImages)).(http.HandlerFunc))
+		productsRouter.Put("/{id}/images/{image_id}/primary", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(s3UploadConfig.HandlerS3SetPrimaryProductImage)).(http.HandlerFunc))
+		productsRouter.Delete("/{id}/images/{image_id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(s3UploadConfig.HandlerS3DeleteProductImage)).(http.HandlerFunc))
 	} else {
 		localUploadConfig := uploadConfig.(*uploadhandlers.HandlersUploadConfig)
 		productsRouter.Post("/upload-image", WithAdmin(localUploadConfig.HandlerUploadProductImage))
-		productsRouter.Post("/{id}/image", WithAdmin(localUploadConfig.HandlerUpdateProductImageByID))
+		productsRouter.Post("/{id}/image", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(localUploadConfig.HandlerUpdateProductImageByID)).(http.HandlerFunc))
+		productsRouter.Post("/{id}/images", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(localUploadConfig.HandlerAddProductImage)).(http.HandlerFunc))
+		productsRouter.Put("/{id}/images/order", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(localUploadConfig.HandlerReorderProductImages)).(http.HandlerFunc))
+		productsRouter.Put("/{id}/images/{image_id}/primary", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(localUploadConfig.HandlerSetPrimaryProductImage)).(http.HandlerFunc))
+		productsRouter.Delete("/{id}/images/{image_id}", middlewares.InvalidateCache(apicfg.CacheService, "products:*")(WithAdmin(localUploadConfig.HandlerDeleteProductImage)).(http.HandlerFunc))
 	}
 	v1Router.Mount("/products", productsRouter)
 }
@@ -325,12 +378,13 @@ func (apicfg *Config) setupCategoryRoutes(v1Router *chi.Mux, categoryConfig *cat
 func (apicfg *Config) setupOrderRoutes(v1Router *chi.Mux, orderConfig *orderhandlers.HandlersOrderConfig) {
 	// --- Order Subrouter ---
 	ordersRouter := chi.NewRouter()
-	ordersRouter.Post("/", WithUser(orderConfig.HandlerCreateOrder))                           // Create new order
-	ordersRouter.Get("/user", WithUser(orderConfig.HandlerGetUserOrders))                      // Get orders for current user
-	ordersRouter.Get("/items/{order_id}", WithUser(orderConfig.HandlerGetOrderItemsByOrderID)) // Get items for a specific order
-	ordersRouter.Put("/{order_id}/status", WithAdmin(orderConfig.HandlerUpdateOrderStatus))    // Admin: update order status
-	ordersRouter.Delete("/{order_id}", WithAdmin(orderConfig.HandlerDeleteOrder))              // Admin: delete order
-	ordersRouter.Get("/", WithAdmin(orderConfig.HandlerGetAllOrders))                          // Admin: list all orders
+	ordersRouter.Post("/", WithUser(orderConfig.HandlerCreateOrder))                            // Create new order
+	ordersRouter.Get("/user", WithUser(orderConfig.HandlerGetUserOrders))                       // Get orders for current user
+	ordersRouter.Get("/items/{order_id}", WithUser(orderConfig.HandlerGetOrderItemsByOrderID))  // Get items for a specific order
+	ordersRouter.Get("/{order_id}/history", WithUser(orderConfig.HandlerGetOrderStatusHistory)) // Get status history (owner or admin)
+	ordersRouter.Put("/{order_id}/status", WithAdmin(orderConfig.HandlerUpdateOrderStatus))     // Admin: update order status
+	ordersRouter.Delete("/{order_id}", WithAdmin(orderConfig.HandlerDeleteOrder))               // Admin: delete order
+	ordersRouter.Get("/", WithAdmin(orderConfig.HandlerGetAllOrders))                           // Admin: list all orders
 	v1Router.Mount("/orders", ordersRouter)
 }
 
@@ -363,13 +417,20 @@ func (apicfg *Config) setupCartRoutes(v1Router *chi.Mux, cartConfig *carthandler
 func (apicfg *Config) setupPaymentRoutes(v1Router *chi.Mux, paymentConfig *paymenthandlers.HandlersPaymentConfig) {
 	// --- Payment Subrouter ---
 	paymentsRouter := chi.NewRouter()
-	paymentsRouter.Post("/webhook", Adapt(paymentConfig.HandlerStripeWebhook))              // Stripe webhook endpoint
-	paymentsRouter.Post("/intent", WithUser(paymentConfig.HandlerCreatePayment))            // Create payment intent
-	paymentsRouter.Post("/confirm", WithUser(paymentConfig.HandlerConfirmPayment))          // Confirm payment
-	paymentsRouter.Get("/{order_id}", WithUser(paymentConfig.HandlerGetPayment))            // Get payment for order
-	paymentsRouter.Get("/history", WithUser(paymentConfig.HandlerGetPaymentHistory))        // Get payment history for user
-	paymentsRouter.Post("/{order_id}/refund", WithUser(paymentConfig.HandlerRefundPayment)) // Refund payment for order
-	paymentsRouter.Get("/admin/{status}", WithAdmin(paymentConfig.HandlerAdminGetPayments)) // Admin: get payments by status
+	paymentsRouter.Post("/webhook", Adapt(paymentConfig.HandlerStripeWebhook))                                                    // Stripe webhook endpoint
+	paymentsRouter.Post("/intent", WithUser(paymentConfig.HandlerCreatePayment))                                                  // Create payment intent
+	paymentsRouter.Post("/confirm", WithUser(paymentConfig.HandlerConfirmPayment))                                                // Confirm payment
+	paymentsRouter.Get("/{order_id}", WithUser(paymentConfig.HandlerGetPayment))                                                  // Get payment for order
+	paymentsRouter.Get("/history", WithUser(paymentConfig.HandlerGetPaymentHistory))                                              // Get payment history for user
+	paymentsRouter.Post("/{order_id}/refund", WithUser(paymentConfig.HandlerRequestRefund))                                       // Request a refund for order
+	paymentsRouter.Get("/admin/{status}", WithAdmin(paymentConfig.HandlerAdminGetPayments))                                       // Admin: get payments by status
+	paymentsRouter.Post("/admin/{order_id}/mark-received", WithAdmin(paymentConfig.HandlerAdminMarkPaymentReceived))              // Admin: mark manual payment received
+	paymentsRouter.Get("/admin/webhook-events", WithAdmin(paymentConfig.HandlerAdminListWebhookEvents))                           // Admin: list webhook events
+	paymentsRouter.Post("/admin/webhook-events/{event_id}/retry", WithAdmin(paymentConfig.HandlerAdminRetryWebhookEvent))         // Admin: re-run failed webhook event
+	paymentsRouter.Get("/admin/refund-requests", WithAdmin(paymentConfig.HandlerAdminListRefundRequests))                         // Admin: list refund requests
+	paymentsRouter.Post("/admin/refund-requests/{request_id}/approve", WithAdmin(paymentConfig.HandlerAdminApproveRefundRequest)) // Admin: approve refund request
+	paymentsRouter.Post("/admin/refund-requests/{request_id}/reject", WithAdmin(paymentConfig.HandlerAdminRejectRefundRequest))   // Admin: reject refund request
+	paymentsRouter.Post("/admin/refunds/{refund_id}/retry", WithAdmin(paymentConfig.HandlerAdminRetryRefund))                     // Admin: resend refund the provider did not accept
 	v1Router.Mount("/payments", paymentsRouter)
 }
 
@@ -377,19 +438,29 @@ func (apicfg *Config) setupReviewRoutes(v1Router *chi.Mux, reviewConfig *reviewh
 	// --- Review Subrouter ---
 	if reviewConfig != nil {
 		reviewsRouter := chi.NewRouter()
-		reviewsRouter.Get("/product/{product_id}", Adapt(reviewConfig.HandlerGetReviewsByProductID)) // Get reviews for a product
-		reviewsRouter.Get("/{id}", Adapt(reviewConfig.HandlerGetReviewByID))                         // Get review by ID
-		reviewsRouter.Post("/", WithUser(reviewConfig.HandlerCreateReview))                          // Create review (auth required)
-		reviewsRouter.Get("/user", WithUser(reviewConfig.HandlerGetReviewsByUserID))                 // Get reviews by user
-		reviewsRouter.Put("/{id}", WithUser(reviewConfig.HandlerUpdateReviewByID))                   // Update review (auth required)
-		reviewsRouter.Delete("/{id}", WithUser(reviewConfig.HandlerDeleteReviewByID))                // Delete review (auth required)
+		reviewsRouter.Get("/product/{product_id}", Adapt(reviewConfig.HandlerGetReviewsByProductID))     // Get reviews for a product
+		reviewsRouter.Post("/media", WithUser(reviewConfig.HandlerUploadReviewMedia))                    // Upload review photo
+		reviewsRouter.Delete("/media/{id}", WithUser(reviewConfig.HandlerDeleteReviewMedia))             // Delete unused review photo
+		reviewsRouter.Get("/{id}", Adapt(reviewConfig.HandlerGetReviewByID))                             // Get review by ID
+		reviewsRouter.Post("/", WithUser(reviewConfig.HandlerCreateReview))                              // Create review (auth required)
+		reviewsRouter.Get("/user", WithUser(reviewConfig.HandlerGetReviewsByUserID))                     // Get reviews by user
+		reviewsRouter.Put("/{id}", WithUser(reviewConfig.HandlerUpdateReviewByID))                       // Update review (auth required)
+		reviewsRouter.Delete("/{id}", WithUser(reviewConfig.HandlerDeleteReviewByID))                    // Delete review (owner or admin)
+		reviewsRouter.Get("/admin", WithAdmin(reviewConfig.HandlerAdminListReviews))                     // Admin: moderation queue
+		reviewsRouter.Post("/admin/{id}/approve", WithAdmin(reviewConfig.HandlerAdminApproveReview))     // Admin: publish review
+		reviewsRouter.Post("/admin/{id}/reject", WithAdmin(reviewConfig.HandlerAdminRejectReview))       // Admin: reject review
+		reviewsRouter.Post("/admin/{id}/hide", WithAdmin(reviewConfig.HandlerAdminHideReview))           // Admin: take down review
+		reviewsRouter.Put("/admin/{id}/reply", WithAdmin(reviewConfig.HandlerAdminReplyToReview))        // Admin: reply to review
+		reviewsRouter.Delete("/admin/{id}/reply", WithAdmin(reviewConfig.HandlerAdminDeleteReviewReply)) // Admin: remove reply
+		reviewsRouter.Post("/{id}/vote", WithUser(reviewConfig.HandlerVoteReview))                       // Vote review helpful/unhelpful
 		v1Router.Mount("/reviews", reviewsRouter)
 	}
 }
 
-func (apicfg *Config) setupAdminRoutes(v1Router *chi.Mux, userConfig *userhandlers.HandlersUserConfig) {
+func (apicfg *Config) setupAdminRoutes(v1Router *chi.Mux, userConfig *userhandlers.HandlersUserConfig, authConfig *authhandlers.HandlersAuthConfig) {
 	// --- Admin Subrouter ---
 	adminRouter := chi.NewRouter()
 	adminRouter.Post("/user/promote", WithAdmin(userConfig.AuthHandlerPromoteUserToAdmin)) // Promote user to admin
+	adminRouter.Post("/user/unlock", WithAdmin(authConfig.HandlerAdminUnlockAccount))      // Clear failed sign-in lockout
 	v1Router.Mount("/admin", adminRouter)
 }
@@ -153,6 +153,19 @@ func TestSetupRouter_ReadinessEndpoint(t *testing.T) {
 	assert.NotEqual(t, http.StatusNotFound, w.Code, "Readiness endpoint should be registered")
 }
 
+func TestSetupRouter_JWKSEndpoint(t *testing.T) {
+	routerCfg := setupTestRouterConfig(t)
+	logger := logrus.New()
+	router := routerCfg.SetupRouter(logger)
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	req.RemoteAddr = testRemoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.NotEqual(t, http.StatusNotFound, w.Code, "JWKS endpoint should be registered")
+}
+
 func TestSetupRouter_HealthzEndpoint(t *testing.T) {
 	routerCfg := setupTestRouterConfig(t)
 	logger := logrus.New()
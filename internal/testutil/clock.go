@@ -0,0 +1,93 @@
+// Package internal_testutil provides shared test utilities and mock implementations to support unit testing of internal handlers and services.
+package internal_testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/STaninnat/ecom-backend/utils"
+)
+
+// clock.go: Provides a manually advanced clock for testing time-driven background jobs.
+
+// FakeClock implements utils.Clock with a time that only moves when Advance is called.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// fakeTicker delivers ticks when its FakeClock is advanced past the next tick time.
+type fakeTicker struct {
+	clock   *FakeClock
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+// NewFakeClock creates a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker that fires each time the clock is advanced past its period.
+func (c *FakeClock) NewTicker(d time.Duration) utils.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward and fires every ticker that became due.
+// Like time.Ticker, a tick is dropped if the previous one has not been received yet.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.stopped || t.next.After(c.now) {
+			continue
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+// ActiveTickers returns the number of tickers that have not been stopped.
+func (c *FakeClock) ActiveTickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, t := range c.tickers {
+		if !t.stopped {
+			count++
+		}
+	}
+	return count
+}
+
+// C returns the channel on which ticks are delivered.
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop turns off the ticker.
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/STaninnat/ecom-backend/handlers"
+	orderhandlers "github.com/STaninnat/ecom-backend/handlers/order"
+	paymenthandlers "github.com/STaninnat/ecom-backend/handlers/payment"
 	"github.com/STaninnat/ecom-backend/internal/router"
 	"github.com/STaninnat/ecom-backend/utils"
 
@@ -62,5 +64,13 @@ func main() {
 		}
 	}()
 
-	utils.GracefulShutdown(srv, Config.APIConfig, 10*time.Second)
+	// Release stock held by pending orders that were never paid
+	paymentReleaser := paymenthandlers.NewPaymentReleaser(Config.DB, Config.DBConn, Config.StripeSecretKey)
+	orderReaper := orderhandlers.NewOrderReaper(Config.DB, Config.DBConn, paymentReleaser, utils.RealClock{}, logger, orderhandlers.OrderReaperConfig{
+		HoldWindow: Config.OrderHoldWindow,
+		Interval:   Config.OrderReaperInterval,
+	})
+	orderReaper.Start()
+
+	utils.GracefulShutdown(srv, Config.APIConfig, 10*time.Second, orderReaper)
 }
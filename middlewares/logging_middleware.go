@@ -126,6 +126,49 @@ func GetIPAddress(r *http.Request) string {
 	return ""
 }
 
+// GetTrustedClientIP returns the client IP address of a request for security decisions such as sign-in limits.
+// Unlike GetIPAddress it uses RemoteAddr and only honours X-Forwarded-For and X-Real-IP when the request comes from one
+// of the trusted proxies. X-Forwarded-For is read from the right, skipping trusted proxies, because clients can
+// prepend any entries they like.
+func GetTrustedClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	remote := net.ParseIP(host)
+	if remote == nil {
+		return ""
+	}
+	if !ipInNets(remote, trustedProxies) {
+		return remote.String()
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !ipInNets(ip, trustedProxies) {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(r.Header.Get("X-Real-IP")); ip != nil {
+		return ip.String()
+	}
+	return remote.String()
+}
+
+// ipInNets reports whether ip belongs to any of the networks.
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsValidIP validates whether a string represents a valid IP address (IPv4 or IPv6).
 func IsValidIP(ip string) bool {
 	parsed := net.ParseIP(ip)
@@ -2,6 +2,7 @@
 package middlewares
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -68,6 +69,36 @@ func TestGetIPAddress(t *testing.T) {
 	}
 }
 
+// TestGetTrustedClientIP tests that forwarding headers are only honoured from trusted proxies and that spoofed
+// X-Forwarded-For entries prepended by the client are skipped
+func TestGetTrustedClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+	tests := []struct {
+		headers map[string]string
+		remote  string
+		want    string
+		name    string
+	}{
+		{map[string]string{"X-Forwarded-For": "5.6.7.8", "X-Real-IP": "1.2.3.4"}, "8.8.8.8:1234", "8.8.8.8", "untrusted remote ignores headers"},
+		{map[string]string{"X-Forwarded-For": "5.6.7.8, 9.9.9.9"}, "10.0.0.1:1234", "9.9.9.9", "rightmost untrusted hop"},
+		{map[string]string{"X-Forwarded-For": "9.9.9.9, 10.0.0.2"}, "10.0.0.1:1234", "9.9.9.9", "skips trusted hops"},
+		{map[string]string{"X-Real-IP": "1.2.3.4"}, "10.0.0.1:1234", "1.2.3.4", "real ip from trusted proxy"},
+		{map[string]string{}, "10.0.0.1:1234", "10.0.0.1", "trusted proxy without headers"},
+		{map[string]string{}, "badaddr", "", "invalid remote addr"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		r.RemoteAddr = tt.remote
+		if got := GetTrustedClientIP(r, trusted); got != tt.want {
+			t.Errorf("%s: GetTrustedClientIP() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
 // TestIsValidIP tests IP address validation functionality
 // It verifies that both valid IPv4/IPv6 addresses and invalid formats are handled correctly
 func TestIsValidIP(t *testing.T) {
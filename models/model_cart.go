@@ -1,17 +1,29 @@
 // Package models defines data structures and database models for the ecom-backend project.
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
 
 // model_cart.go: Defines Cart and CartItem models for shopping cart functionality.
 
 // CartItem represents a single item in a user's shopping cart.
 // It contains product information and quantity for checkout purposes.
+// A line is identified by its product and variant, so the same product can be in the cart once per variant.
 type CartItem struct {
-	ProductID string  `bson:"product_id" json:"product_id"` // ID of the product in the cart
-	Quantity  int     `bson:"quantity" json:"quantity"`     // Number of units of this product
-	Price     float64 `bson:"price" json:"price"`           // Current price per unit
-	Name      string  `bson:"name" json:"name"`             // Product name for display
+	ProductID string       `bson:"product_id" json:"product_id"`                     // ID of the product in the cart
+	VariantID string       `bson:"variant_id,omitempty" json:"variant_id,omitempty"` // ID of the chosen variant, empty for products without variants
+	SKU       string       `bson:"sku,omitempty" json:"sku,omitempty"`               // SKU of the chosen variant for display
+	Quantity  int          `bson:"quantity" json:"quantity"`                         // Number of units of this product
+	Price     money.Amount `bson:"price" json:"price"`                               // Price per unit when the item was added
+	Name      string       `bson:"name" json:"name"`                                 // Product name for display
+}
+
+// Matches reports whether the item is the cart line of the given product variant.
+func (i CartItem) Matches(productID, variantID string) bool {
+	return i.ProductID == productID && i.VariantID == variantID
 }
 
 // Cart represents a user's shopping cart containing multiple items.
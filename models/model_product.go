@@ -1,21 +1,66 @@
 // Package models defines data structures and database models for the ecom-backend project.
 package models
 
-import "time"
+import (
+	"time"
 
-// model_product.go: Defines the Product model for catalog items.
+	"github.com/STaninnat/ecom-backend/internal/database"
+	"github.com/STaninnat/ecom-backend/internal/money"
+)
+
+// model_product.go: Defines the Product model for catalog items, its gallery images, and mapping from database entities.
 
 // Product represents an item available for purchase in the e-commerce system.
 // It contains product details, pricing, inventory, and availability status.
 type Product struct {
-	ID          string    `json:"id"`          // Unique identifier for the product
-	CategoryID  string    `json:"category_id"` // ID of the category this product belongs to
-	Name        string    `json:"name"`        // Product name/title
-	Description string    `json:"description"` // Detailed product description
-	Price       float64   `json:"price"`       // Current selling price
-	Stock       int32     `json:"stock"`       // Available quantity in inventory
-	ImageURL    string    `json:"image_url"`   // URL to the product's main image
-	IsActive    bool      `json:"is_active"`   // Whether the product is available for purchase
-	CreatedAt   time.Time `json:"created_at"`  // When the product was added to the catalog
-	UpdatedAt   time.Time `json:"updated_at"`  // When the product information was last updated
+	ID          string       `json:"id"`          // Unique identifier for the product
+	CategoryID  string       `json:"category_id"` // ID of the category this product belongs to
+	Name        string       `json:"name"`        // Product name/title
+	Description string       `json:"description"` // Detailed product description
+	Price       money.Amount `json:"price"`       // Current selling price
+	Stock       int32        `json:"stock"`       // Available quantity in inventory
+	ImageURL    string       `json:"image_url"`   // URL to the product's main image
+	IsActive    bool         `json:"is_active"`   // Whether the product is available for purchase
+	CreatedAt   time.Time    `json:"created_at"`  // When the product was added to the catalog
+	UpdatedAt   time.Time    `json:"updated_at"`  // When the product information was last updated
+}
+
+// ProductImage is one image of a product's gallery. Galleries are shown in Position order,
+// and the primary image is the one mirrored into the product's ImageURL.
+type ProductImage struct {
+	ID        string `json:"id"`                 // Unique identifier for the image
+	ImageURL  string `json:"image_url"`          // URL of the stored image file
+	AltText   string `json:"alt_text,omitempty"` // Text alternative for accessibility
+	Position  int32  `json:"position"`           // Display order within the gallery, lowest first
+	IsPrimary bool   `json:"is_primary"`         // Whether this is the product's main image
+	// Resized copies of the image; nil for images uploaded before renditions were generated
+	Renditions *ImageRenditions `json:"renditions,omitempty"`
+}
+
+// ImageRenditions holds the URLs of the resized copies generated for an uploaded image.
+// Each copy fits within its size's bounding box and is never larger than the original.
+type ImageRenditions struct {
+	Thumbnail string `json:"thumbnail"` // Fits within 160x160 pixels
+	Medium    string `json:"medium"`    // Fits within 640x640 pixels
+	Large     string `json:"large"`     // Fits within 1280x1280 pixels
+}
+
+// MapProductImageToResponse converts a database ProductImage entity to a ProductImage model for API responses.
+// Renditions are only set when the image has them; a null alt text becomes an empty string.
+func MapProductImageToResponse(image database.ProductImage) ProductImage {
+	response := ProductImage{
+		ID:        image.ID,
+		ImageURL:  image.ImageUrl,
+		AltText:   image.AltText.String,
+		Position:  image.Position,
+		IsPrimary: image.IsPrimary,
+	}
+	if image.ThumbnailUrl.Valid {
+		response.Renditions = &ImageRenditions{
+			Thumbnail: image.ThumbnailUrl.String,
+			Medium:    image.MediumUrl.String,
+			Large:     image.LargeUrl.String,
+		}
+	}
+	return response
 }
@@ -7,15 +7,54 @@ import (
 
 // model_review.go: Defines the Review model for product reviews and ratings.
 
+// Review moderation statuses. Only approved reviews are shown on product pages and counted in rating stats.
+const (
+	ReviewStatusPending  = "pending"  // Waiting for an admin to approve or reject it
+	ReviewStatusApproved = "approved" // Public
+	ReviewStatusRejected = "rejected" // Turned down by auto-moderation or an admin
+	ReviewStatusHidden   = "hidden"   // Taken down by an admin after being approved
+)
+
 // Review represents a product review submitted by a user.
 // It contains rating, comment, and optional media attachments.
 type Review struct {
-	ID        string    `bson:"_id,omitempty" json:"id"`                          // Unique identifier for the review
-	UserID    string    `bson:"user_id" json:"user_id"`                           // ID of the user who wrote the review
-	ProductID string    `bson:"product_id" json:"product_id"`                     // ID of the product being reviewed
-	Rating    int       `bson:"rating" json:"rating"`                             // Numeric rating (typically 1-5 stars)
-	Comment   string    `bson:"comment,omitempty" json:"comment,omitempty"`       // Optional text review
-	MediaURLs []string  `bson:"media_urls,omitempty" json:"media_urls,omitempty"` // Optional image/video URLs
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`                     // When the review was created
-	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`                     // When the review was last updated
+	ID               string       `bson:"_id,omitempty" json:"id"`                                        // Unique identifier for the review
+	UserID           string       `bson:"user_id" json:"user_id"`                                         // ID of the user who wrote the review
+	ProductID        string       `bson:"product_id" json:"product_id"`                                   // ID of the product being reviewed
+	Rating           int          `bson:"rating" json:"rating"`                                           // Numeric rating (typically 1-5 stars)
+	Comment          string       `bson:"comment,omitempty" json:"comment,omitempty"`                     // Optional text review
+	MediaIDs         []string     `bson:"media_ids,omitempty" json:"media_ids,omitempty"`                 // IDs of the uploaded media attached to the review
+	MediaURLs        []string     `bson:"media_urls,omitempty" json:"media_urls,omitempty"`               // URLs of the attached media, in MediaIDs order
+	VerifiedPurchase bool         `bson:"verified_purchase" json:"verified_purchase"`                     // Whether the user has a delivered order containing the product
+	Status           string       `bson:"status" json:"status"`                                           // Moderation status (pending, approved, rejected, hidden)
+	ModerationReason string       `bson:"moderation_reason,omitempty" json:"moderation_reason,omitempty"` // Why the review was held, rejected or hidden
+	ModeratedBy      string       `bson:"moderated_by,omitempty" json:"moderated_by,omitempty"`           // ID of the admin who last moderated the review
+	ModeratedAt      *time.Time   `bson:"moderated_at,omitempty" json:"moderated_at,omitempty"`           // When an admin last moderated the review
+	HelpfulCount     int64        `bson:"helpful_count" json:"helpful_count"`                             // Number of users who found the review helpful
+	UnhelpfulCount   int64        `bson:"unhelpful_count" json:"unhelpful_count"`                         // Number of users who found the review unhelpful
+	HelpfulVoters    []string     `bson:"helpful_voters,omitempty" json:"-"`                              // IDs of users who voted the review helpful
+	UnhelpfulVoters  []string     `bson:"unhelpful_voters,omitempty" json:"-"`                            // IDs of users who voted the review unhelpful
+	MerchantReply    *ReviewReply `bson:"merchant_reply,omitempty" json:"merchant_reply,omitempty"`       // The store's public reply, if any
+	CreatedAt        time.Time    `bson:"created_at" json:"created_at"`                                   // When the review was created
+	UpdatedAt        time.Time    `bson:"updated_at" json:"updated_at"`                                   // When the review was last updated
+}
+
+// ReviewReply is the store's public reply to a review. A review has at most one.
+type ReviewReply struct {
+	Comment   string    `bson:"comment" json:"comment"`       // Reply text
+	RepliedBy string    `bson:"replied_by" json:"replied_by"` // ID of the admin who wrote the reply
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // When the reply was first posted
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"` // When the reply was last edited
+}
+
+// ReviewMedia is a photo a user uploaded for their reviews.
+// It belongs to the uploader and is attached to at most one review.
+type ReviewMedia struct {
+	ID          string    `bson:"_id" json:"id"`                                  // Unique identifier for the media
+	UserID      string    `bson:"user_id" json:"user_id"`                         // ID of the user who uploaded it
+	ReviewID    string    `bson:"review_id,omitempty" json:"review_id,omitempty"` // ID of the review it is attached to, empty until used
+	URL         string    `bson:"url" json:"url"`                                 // Public URL of the stored file
+	ContentType string    `bson:"content_type" json:"content_type"`               // Detected MIME type
+	Size        int64     `bson:"size" json:"size"`                               // File size in bytes
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`                   // When it was uploaded
 }
@@ -3,7 +3,23 @@ INSERT INTO categories (id, name, description, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5);
 
 -- name: GetAllCategories :many
-SELECT * FROM categories ORDER BY name;
+-- Keyset pagination: pass the last row of the previous page as the cursor_* args. Sorted by name unless sort says otherwise.
+SELECT * FROM categories
+WHERE
+    sqlc.narg('cursor_id')::text IS NULL OR CASE sqlc.arg('sort')::text
+        WHEN 'name_desc' THEN (name, id) < (sqlc.narg('cursor_value')::text, sqlc.narg('cursor_id')::text)
+        WHEN 'newest' THEN (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+        WHEN 'oldest' THEN (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+        ELSE (name, id) > (sqlc.narg('cursor_value')::text, sqlc.narg('cursor_id')::text)
+    END
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'name_desc' THEN name END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'newest' THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text IN ('name_desc', 'newest') THEN id END DESC,
+    name,
+    id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateCategories :exec
 UPDATE categories
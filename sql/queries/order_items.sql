@@ -1,12 +1,21 @@
 -- name: CreateOrderItem :exec
 INSERT INTO order_items (
-    id, order_id, product_id, quantity, price,
+    id, order_id, product_id, variant_id, quantity, price,
     created_at, updated_at
 )
 VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 );
 
 -- name: GetOrderItemsByOrderID :many
 SELECT * FROM order_items 
-WHERE order_id = $1;
\ No newline at end of file
+WHERE order_id = $1;
+
+-- name: HasDeliveredOrderWithProduct :one
+SELECT EXISTS (
+    SELECT 1 FROM order_items
+    JOIN orders ON orders.id = order_items.order_id
+    WHERE orders.user_id = $1
+      AND order_items.product_id = $2
+      AND orders.status = 'delivered'
+);
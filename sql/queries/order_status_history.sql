@@ -0,0 +1,19 @@
+-- name: CreateOrderStatusHistory :exec
+INSERT INTO order_status_history (
+    id, order_id, from_status, to_status,
+    actor_user_id, reason, created_at
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: GetOrderStatusHistoryByOrderID :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetLatestOrderStatusChangeTime :one
+SELECT created_at FROM order_status_history
+WHERE order_id = $1 AND to_status = $2
+ORDER BY created_at DESC
+LIMIT 1;
@@ -23,9 +23,41 @@ SET status = $2, updated_at = $3
 WHERE id = $1;
 
 -- name: ListAllOrders :many
-SELECT * FROM orders 
-ORDER BY created_at DESC;
+-- Keyset pagination: pass the last row of the previous page as the cursor_* args. Newest first unless sort is 'oldest'.
+SELECT * FROM orders
+WHERE
+    sqlc.narg('cursor_id')::text IS NULL OR CASE sqlc.arg('sort')::text
+        WHEN 'oldest' THEN (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+        ELSE (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+    END
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN id END ASC,
+    created_at DESC,
+    id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: DeleteOrderByID :exec
 DELETE FROM orders 
 WHERE id = $1;
+
+-- name: GetOrderByIDForUpdate :one
+SELECT * FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListExpiredPendingOrders :many
+-- Orders awaiting a manual payment (cash on delivery, bank transfer) are settled by an admin and never expire.
+SELECT * FROM orders
+WHERE status = 'pending' AND created_at < $1
+  AND NOT EXISTS (
+      SELECT 1 FROM payments
+      WHERE payments.order_id = orders.id AND payments.provider = 'manual' AND payments.status = 'pending'
+  )
+ORDER BY created_at
+LIMIT $2;
+
+-- name: UpdateOrderRefundStatus :exec
+UPDATE orders
+SET refund_status = $2, updated_at = $3
+WHERE id = $1;
@@ -12,21 +12,32 @@ WHERE order_id = $1
 ORDER BY updated_at DESC
 LIMIT 1;
 
+-- name: GetPaymentByProviderPaymentID :one
+SELECT * FROM payments
+WHERE provider_payment_id = $1
+LIMIT 1;
+
 -- name: GetPaymentsByUserID :many
 SELECT * FROM payments
 WHERE user_id = $1
 ORDER BY updated_at DESC;
 
 -- name: GetAllPayments :many
+-- Keyset pagination: pass the last row of the previous page as the cursor_* args. Newest first unless sort is 'oldest'.
 SELECT *
 FROM payments
-ORDER BY updated_at DESC;
-
--- name: GetPaymentsByStatus :many
-SELECT *
-FROM payments
-WHERE status = $1
-ORDER BY updated_at DESC;
+WHERE
+    (status = sqlc.narg('status') OR sqlc.narg('status') IS NULL) AND
+    (sqlc.narg('cursor_id')::text IS NULL OR CASE sqlc.arg('sort')::text
+        WHEN 'oldest' THEN (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+        ELSE (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+    END)
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN id END ASC,
+    created_at DESC,
+    id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: UpdatePaymentStatus :exec
 UPDATE payments
@@ -41,4 +52,22 @@ WHERE provider_payment_id = $1;
 -- name: UpdatePaymentStatusByID :exec
 UPDATE payments
 SET status = $2
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: MarkPaymentsCancelPendingByOrderID :exec
+-- Failed payments are included: their intent stays open for another attempt until it is cancelled.
+-- The payments become cancelled once their intents are voided with the provider, after the order release commits.
+UPDATE payments
+SET status = 'cancel_pending', updated_at = $2
+WHERE order_id = $1 AND status IN ('pending', 'failed');
+
+-- name: MarkPaymentCancelled :execrows
+UPDATE payments
+SET status = 'cancelled', updated_at = $2
+WHERE id = $1 AND status = 'cancel_pending';
+
+-- name: ListCancelPendingPayments :many
+SELECT * FROM payments
+WHERE status = 'cancel_pending' AND updated_at < $1
+ORDER BY updated_at
+LIMIT $2;
@@ -0,0 +1,42 @@
+-- name: CreateProductImage :one
+INSERT INTO product_images (
+    id, product_id, image_url, alt_text, position, is_primary, thumbnail_url, medium_url, large_url, created_at, updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: ListProductImagesByProductID :many
+SELECT * FROM product_images
+WHERE product_id = $1
+ORDER BY position, created_at, id;
+
+-- name: ListProductImagesByProductIDs :many
+SELECT * FROM product_images
+WHERE product_id = ANY(sqlc.arg(product_ids)::text[])
+ORDER BY product_id, position, created_at, id;
+
+-- name: NextProductImagePosition :one
+SELECT (COALESCE(MAX(position), -1) + 1)::int
+FROM product_images
+WHERE product_id = $1;
+
+-- name: UpdateProductImagePosition :exec
+UPDATE product_images
+SET position = $3, updated_at = $4
+WHERE id = $1 AND product_id = $2;
+
+-- name: ClearPrimaryProductImage :exec
+UPDATE product_images
+SET is_primary = FALSE, updated_at = $2
+WHERE product_id = $1 AND is_primary;
+
+-- name: SetPrimaryProductImage :one
+UPDATE product_images
+SET is_primary = TRUE, updated_at = $3
+WHERE id = $1 AND product_id = $2
+RETURNING *;
+
+-- name: DeleteProductImage :one
+DELETE FROM product_images
+WHERE id = $1 AND product_id = $2
+RETURNING *;
@@ -0,0 +1,53 @@
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (id, product_id, sku, size, color, price, stock, is_active, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetProductVariantByID :one
+SELECT * FROM product_variants
+WHERE id = $1;
+
+-- name: GetProductVariantByIDForUpdate :one
+SELECT * FROM product_variants
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListProductVariantsByProductID :many
+SELECT * FROM product_variants
+WHERE product_id = $1
+ORDER BY created_at, id;
+
+-- name: ProductHasActiveVariants :one
+SELECT EXISTS (
+    SELECT 1 FROM product_variants
+    WHERE product_id = $1 AND is_active = TRUE
+);
+
+-- name: UpdateProductVariant :one
+UPDATE product_variants
+SET sku = $3, size = $4, color = $5, price = $6, stock = $7, is_active = $8, updated_at = $9
+WHERE id = $1 AND product_id = $2
+RETURNING *;
+
+-- name: DeactivateProductVariant :execrows
+UPDATE product_variants
+SET is_active = FALSE, updated_at = $3
+WHERE id = $1 AND product_id = $2;
+
+-- name: VariantHasOpenOrders :one
+-- Reports whether an order that is still pending, paid or shipped contains the variant.
+SELECT EXISTS (
+    SELECT 1 FROM order_items
+    JOIN orders ON orders.id = order_items.order_id
+    WHERE order_items.variant_id = $1 AND orders.status IN ('pending', 'paid', 'shipped')
+);
+
+-- name: DecrementProductVariantStock :execrows
+UPDATE product_variants
+SET stock = stock - sqlc.arg(quantity)::int, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND stock >= sqlc.arg(quantity)::int;
+
+-- name: IncrementProductVariantStock :exec
+UPDATE product_variants
+SET stock = stock + sqlc.arg(quantity)::int, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id);
@@ -7,24 +7,22 @@ VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 SELECT * FROM products 
 WHERE id = $1;
 
+-- name: GetProductByIDForUpdate :one
+SELECT * FROM products
+WHERE id = $1
+FOR UPDATE;
+
 -- name: GetActiveProductByID :one
 SELECT *
 FROM products
 WHERE id = $1 AND is_active = TRUE;
 
--- name: GetAllProducts :many
-SELECT * FROM products 
-ORDER BY updated_at DESC;
-
--- name: GetAllActiveProducts :many
-SELECT *
-FROM products
-WHERE is_active = TRUE
-ORDER BY updated_at DESC;
-
 -- name: UpdateProduct :exec
+-- image_url is only written for products without a gallery; otherwise it mirrors the primary image.
 UPDATE products
-SET category_id = $2, name = $3, description = $4, price = $5, stock = $6, image_url = $7, is_active = $8, updated_at = $9
+SET category_id = $2, name = $3, description = $4, price = $5, stock = $6,
+    image_url = CASE WHEN EXISTS (SELECT 1 FROM product_images WHERE product_images.product_id = $1) THEN image_url ELSE $7 END,
+    is_active = $8, updated_at = $9
 WHERE id = $1;
 
 -- name: UpdateProductImageURL :exec
@@ -37,17 +35,88 @@ UPDATE products
 SET stock = $2
 WHERE id = $1;
 
+-- name: DecrementProductStock :execrows
+UPDATE products
+SET stock = stock - sqlc.arg(quantity)::int, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND stock >= sqlc.arg(quantity)::int;
+
 -- name: DeleteProductByID :exec
 DELETE FROM products 
 WHERE id = $1;
 
 -- name: FilterProducts :many
+-- Keyset pagination: pass the last row of the previous page as the cursor_* args (only those its sort orders by).
+-- Every sort ends with id so the order is total and the cursor comparison matches the ORDER BY.
 SELECT *
 FROM products
 WHERE
     (category_id = sqlc.narg('category_id') OR sqlc.narg('category_id') IS NULL) AND
     (is_active = sqlc.narg('is_active') OR sqlc.narg('is_active') IS NULL) AND
     (price >= sqlc.narg('min_price') OR sqlc.narg('min_price') IS NULL) AND
-    (price <= sqlc.narg('max_price') OR sqlc.narg('max_price') IS NULL)
-ORDER BY created_at DESC;
+    (price <= sqlc.narg('max_price') OR sqlc.narg('max_price') IS NULL) AND
+    (rating_avg >= sqlc.narg('min_rating') OR sqlc.narg('min_rating') IS NULL) AND
+    (sqlc.narg('cursor_id')::text IS NULL OR CASE sqlc.arg('sort')::text
+        WHEN 'oldest' THEN (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+        WHEN 'price_asc' THEN (price, id) > (sqlc.narg('cursor_value')::numeric, sqlc.narg('cursor_id')::text)
+        WHEN 'price_desc' THEN (price, id) < (sqlc.narg('cursor_value')::numeric, sqlc.narg('cursor_id')::text)
+        WHEN 'name_asc' THEN (name, id) > (sqlc.narg('cursor_value')::text, sqlc.narg('cursor_id')::text)
+        WHEN 'name_desc' THEN (name, id) < (sqlc.narg('cursor_value')::text, sqlc.narg('cursor_id')::text)
+        WHEN 'rating_desc' THEN (rating_avg, rating_count, id) < (sqlc.narg('cursor_value')::numeric, sqlc.narg('cursor_count')::int, sqlc.narg('cursor_id')::text)
+        WHEN 'rating_asc' THEN (rating_avg, id) > (sqlc.narg('cursor_value')::numeric, sqlc.narg('cursor_id')::text)
+        WHEN 'reviews_desc' THEN (rating_count, id) < (sqlc.narg('cursor_count')::int, sqlc.narg('cursor_id')::text)
+        ELSE (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::text)
+    END)
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'price_asc' THEN price END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'price_desc' THEN price END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'name_asc' THEN name END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'name_desc' THEN name END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'rating_desc' THEN rating_avg END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'rating_asc' THEN rating_avg END ASC,
+    CASE WHEN sqlc.arg('sort')::text IN ('rating_desc', 'reviews_desc') THEN rating_count END DESC,
+    CASE WHEN sqlc.arg('sort')::text IN ('oldest', 'price_asc', 'name_asc', 'rating_asc') THEN id END ASC,
+    CASE WHEN sqlc.arg('sort')::text IN ('price_desc', 'name_desc', 'rating_desc', 'reviews_desc') THEN id END DESC,
+    created_at DESC,
+    id DESC
+LIMIT sqlc.arg('limit');
 
+-- name: IncrementProductStock :exec
+UPDATE products
+SET stock = stock + sqlc.arg(quantity)::int, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateProductRatingSummary :exec
+UPDATE products
+SET rating_avg = $2, rating_count = $3,
+    rating_1_count = $4, rating_2_count = $5, rating_3_count = $6, rating_4_count = $7, rating_5_count = $8
+WHERE id = $1;
+
+-- name: ListProductIDs :many
+SELECT id FROM products
+ORDER BY id;
+
+-- name: SearchProducts :many
+-- Highlights are wrapped in U+E000/U+E001 so the caller can escape the text before adding markup.
+SELECT
+    p.id, p.category_id, p.name, p.description, p.price, p.stock, p.image_url, p.is_active, p.created_at, p.updated_at,
+    p.rating_avg, p.rating_count, p.rating_1_count, p.rating_2_count, p.rating_3_count, p.rating_4_count, p.rating_5_count,
+    ts_rank(product_search_document(p.name, p.description), q.query)::real AS rank,
+    ts_headline('english', p.name, q.query, 'HighlightAll=true, StartSel=' || chr(57344) || ', StopSel=' || chr(57345))::text AS name_highlight,
+    ts_headline('english', coalesce(p.description, ''), q.query, 'MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" ... ", StartSel=' || chr(57344) || ', StopSel=' || chr(57345))::text AS snippet
+FROM products p, websearch_to_tsquery('english', sqlc.arg('query')::text) AS q(query)
+WHERE
+    product_search_document(p.name, p.description) @@ q.query AND
+    p.is_active = TRUE AND
+    (p.category_id = sqlc.narg('category_id') OR sqlc.narg('category_id') IS NULL) AND
+    (p.price >= sqlc.narg('min_price') OR sqlc.narg('min_price') IS NULL) AND
+    (p.price <= sqlc.narg('max_price') OR sqlc.narg('max_price') IS NULL) AND
+    (p.rating_avg >= sqlc.narg('min_rating') OR sqlc.narg('min_rating') IS NULL)
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'rating_desc' THEN p.rating_avg END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'rating_asc' THEN p.rating_avg END ASC,
+    CASE WHEN sqlc.arg('sort')::text IN ('rating_desc', 'reviews_desc') THEN p.rating_count END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'newest' THEN p.created_at END DESC,
+    rank DESC,
+    p.created_at DESC
+LIMIT sqlc.arg('limit')::int;
@@ -0,0 +1,38 @@
+-- name: CreateRefundRequest :exec
+INSERT INTO refund_requests (
+    id, order_id, payment_id, user_id, amount, currency,
+    items, reason, status, created_at, updated_at
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9, $10
+);
+
+-- name: GetPendingRefundRequestByOrderID :one
+SELECT * FROM refund_requests
+WHERE order_id = $1 AND status = 'pending'
+LIMIT 1;
+
+-- name: GetRefundRequestByIDForUpdate :one
+SELECT * FROM refund_requests
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListRefundRequestsByStatus :many
+SELECT * FROM refund_requests
+WHERE status = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateRefundRequestRefundID :exec
+UPDATE refund_requests
+SET refund_id = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: UpdateRefundRequestReview :exec
+UPDATE refund_requests
+SET status = $2,
+    refund_id = $3,
+    reviewed_by = $4,
+    review_note = $5,
+    reviewed_at = $6,
+    updated_at = $6
+WHERE id = $1;
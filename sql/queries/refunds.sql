@@ -0,0 +1,43 @@
+-- name: CreateRefund :exec
+INSERT INTO refunds (
+    id, payment_id, order_id, amount, currency, status,
+    provider_refund_id, reason, created_by, created_at, updated_at
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+);
+
+-- name: CreateRefundItem :exec
+INSERT INTO refund_items (
+    refund_id, order_item_id, quantity, amount
+)
+VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: GetRefundByID :one
+SELECT * FROM refunds
+WHERE id = $1;
+
+-- name: GetRefundByIDForUpdate :one
+SELECT * FROM refunds
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetRefundedAmountByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::TEXT AS refunded_amount
+FROM refunds
+WHERE payment_id = $1 AND status IN ('pending', 'succeeded');
+
+-- name: GetRefundedQuantitiesByOrderID :many
+SELECT ri.order_item_id, SUM(ri.quantity)::INT AS quantity
+FROM refund_items ri
+JOIN refunds r ON r.id = ri.refund_id
+WHERE r.order_id = $1 AND r.status IN ('pending', 'succeeded')
+GROUP BY ri.order_item_id;
+
+-- name: UpdateRefundResult :exec
+-- A refund without a provider_refund_id has not been confirmed by the provider yet and can be sent again.
+UPDATE refunds
+SET status = $2, provider_refund_id = $3, updated_at = $4
+WHERE id = $1;
@@ -0,0 +1,13 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (
+    id, event_type, subject, ip_address,
+    actor_user_id, details, created_at
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListSecurityEventsBySubject :many
+SELECT * FROM security_events
+WHERE subject = $1
+ORDER BY created_at DESC;
@@ -37,10 +37,21 @@ WHERE id = $1;
 
 -- name: UpdateUserSigninStatusByEmail :exec
 UPDATE users
-SET provider = $2, provider_id = $3, updated_at = $4
+SET provider = $2, provider_id = $3, updated_at = $4, email_verified_at = COALESCE(email_verified_at, $4)
 WHERE email = $1;
 
 -- name: UpdateUserInfo :exec
 UPDATE users
-SET  name = $2, email = $3, phone = $4, address = $5, updated_at = $6
-WHERE id = $1;
\ No newline at end of file
+SET  name = $2, email = $3, phone = $4, address = $5, updated_at = $6,
+     email_verified_at = CASE WHEN email = $3 THEN email_verified_at END
+WHERE id = $1;
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+WHERE id = $1 AND email = $3;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, updated_at = $3
+WHERE id = $1;
@@ -0,0 +1,34 @@
+-- name: CreateWebhookEvent :execrows
+INSERT INTO webhook_events (
+    id, type, payload, provider_payment_id,
+    event_created_at, created_at, updated_at
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetWebhookEventByID :one
+SELECT * FROM webhook_events
+WHERE id = $1;
+
+-- name: GetWebhookEventByIDForUpdate :one
+SELECT * FROM webhook_events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetLatestProcessedWebhookEventTime :one
+SELECT event_created_at FROM webhook_events
+WHERE provider_payment_id = $1 AND status = 'processed'
+ORDER BY event_created_at DESC
+LIMIT 1;
+
+-- name: ListWebhookEventsByStatus :many
+SELECT * FROM webhook_events
+WHERE status = $1
+ORDER BY updated_at DESC;
+
+-- name: UpdateWebhookEventResult :exec
+UPDATE webhook_events
+SET status = $2, last_error = $3, attempts = attempts + 1, updated_at = $4
+WHERE id = $1;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_orders_status_created_at ON orders(status, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_status_created_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE
+    order_status_history (
+        id TEXT PRIMARY KEY,
+        order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+        from_status TEXT NOT NULL,
+        to_status TEXT NOT NULL,
+        actor_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+        reason TEXT,
+        created_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_order_status_history_order_id;
+DROP TABLE IF EXISTS order_status_history;
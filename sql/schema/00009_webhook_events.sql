@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE
+    webhook_events (
+        id TEXT PRIMARY KEY,
+        type TEXT NOT NULL,
+        payload JSONB NOT NULL,
+        provider_payment_id TEXT,
+        status TEXT NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'processed', 'skipped', 'failed')),
+        last_error TEXT,
+        attempts INTEGER NOT NULL DEFAULT 0,
+        event_created_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_webhook_events_status ON webhook_events(status, updated_at);
+
+CREATE INDEX idx_webhook_events_provider_payment_id ON webhook_events(provider_payment_id, event_created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_events_provider_payment_id;
+DROP INDEX IF EXISTS idx_webhook_events_status;
+DROP TABLE IF EXISTS webhook_events;
@@ -0,0 +1,56 @@
+-- +goose Up
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'succeeded', 'failed', 'cancelled', 'partially_refunded', 'refunded'));
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (
+    status IN ('pending', 'paid', 'shipped', 'delivered',
+        'cancelled', 'partially_refunded', 'refunded'));
+
+CREATE TABLE
+    refunds (
+        id TEXT PRIMARY KEY,
+        payment_id TEXT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+        order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+        amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+        currency TEXT NOT NULL,
+        status TEXT NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+        provider_refund_id TEXT,
+        reason TEXT,
+        created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
+CREATE UNIQUE INDEX idx_refunds_provider_refund_id ON refunds(provider_refund_id);
+
+CREATE TABLE
+    refund_items (
+        refund_id TEXT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+        order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+        quantity INT NOT NULL CHECK (quantity > 0),
+        amount DECIMAL(10, 2) NOT NULL,
+        PRIMARY KEY (refund_id, order_item_id)
+    );
+
+CREATE INDEX idx_refund_items_order_item_id ON refund_items(order_item_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refund_items_order_item_id;
+DROP TABLE IF EXISTS refund_items;
+DROP INDEX IF EXISTS idx_refunds_provider_refund_id;
+DROP INDEX IF EXISTS idx_refunds_order_id;
+DROP INDEX IF EXISTS idx_refunds_payment_id;
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (
+    status IN ('pending', 'paid', 'shipped', 'delivered',
+        'cancelled', 'refunded'));
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'succeeded', 'failed', 'cancelled', 'refunded'));
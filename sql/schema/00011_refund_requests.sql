@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE
+    refund_requests (
+        id TEXT PRIMARY KEY,
+        order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+        payment_id TEXT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+        currency TEXT NOT NULL,
+        items JSONB NOT NULL DEFAULT '[]',
+        reason TEXT NOT NULL,
+        status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+        refund_id TEXT REFERENCES refunds(id) ON DELETE SET NULL,
+        reviewed_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+        review_note TEXT,
+        reviewed_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_refund_requests_status_created_at ON refund_requests(status, created_at);
+CREATE UNIQUE INDEX idx_refund_requests_pending_order_id ON refund_requests(order_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refund_requests_pending_order_id;
+DROP INDEX IF EXISTS idx_refund_requests_status_created_at;
+DROP TABLE IF EXISTS refund_requests;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE payments ADD CONSTRAINT payments_provider_check CHECK (
+    provider IN ('stripe', 'manual'));
+
+-- +goose Down
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_provider_check;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE products
+    ADD COLUMN rating_avg NUMERIC(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_1_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_2_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_3_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_4_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN rating_5_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_products_rating_avg ON products(rating_avg);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_products_rating_avg;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS rating_avg,
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating_1_count,
+    DROP COLUMN IF EXISTS rating_2_count,
+    DROP COLUMN IF EXISTS rating_3_count,
+    DROP COLUMN IF EXISTS rating_4_count,
+    DROP COLUMN IF EXISTS rating_5_count;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE
+    security_events (
+        id TEXT PRIMARY KEY,
+        event_type TEXT NOT NULL,
+        subject TEXT NOT NULL,
+        ip_address TEXT,
+        actor_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+        details TEXT,
+        created_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_security_events_subject ON security_events(subject, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_security_events_subject;
+DROP TABLE IF EXISTS security_events;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Name weighs more than description. Indexed as an expression rather than a stored column so
+-- SELECT * on products, and the Product model built from it, stay unchanged.
+-- +goose StatementBegin
+CREATE FUNCTION product_search_document(name TEXT, description TEXT) RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT setweight(to_tsvector('english'::regconfig, coalesce(name, '')), 'A') ||
+           setweight(to_tsvector('english'::regconfig, coalesce(description, '')), 'B')
+$$;
+-- +goose StatementEnd
+
+CREATE INDEX idx_products_search ON products USING GIN (product_search_document(name, description));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_products_search;
+DROP FUNCTION IF EXISTS product_search_document(TEXT, TEXT);
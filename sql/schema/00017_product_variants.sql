@@ -0,0 +1,27 @@
+-- +goose Up
+-- A variant is one sellable option of a product (e.g. size M in red). When a product has active variants,
+-- stock is held per variant and a NULL price falls back to the product price.
+CREATE TABLE
+    product_variants (
+        id TEXT PRIMARY KEY,
+        product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+        sku TEXT NOT NULL UNIQUE,
+        size TEXT,
+        color TEXT,
+        price DECIMAL(10,2),
+        stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+        is_active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_product_variants_product_id ON product_variants(product_id);
+
+ALTER TABLE order_items
+    ADD COLUMN variant_id TEXT REFERENCES product_variants(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS variant_id;
+DROP INDEX IF EXISTS idx_product_variants_product_id;
+DROP TABLE IF EXISTS product_variants;
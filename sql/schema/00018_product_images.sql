@@ -0,0 +1,28 @@
+-- +goose Up
+-- A product's image gallery, shown in position order. At most one image per product is primary, and
+-- products.image_url mirrors the primary image's URL so single-image readers keep working.
+CREATE TABLE
+    product_images (
+        id TEXT PRIMARY KEY,
+        product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+        image_url TEXT NOT NULL,
+        alt_text TEXT,
+        position INT NOT NULL,
+        is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX idx_product_images_product_position ON product_images(product_id, position);
+CREATE UNIQUE INDEX idx_product_images_one_primary ON product_images(product_id) WHERE is_primary;
+
+-- Existing single images become the primary image of each product's gallery
+INSERT INTO product_images (id, product_id, image_url, position, is_primary, created_at, updated_at)
+SELECT md5(id || ':primary-image'), id, image_url, 0, TRUE, updated_at, updated_at
+FROM products
+WHERE image_url IS NOT NULL AND image_url <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_product_images_one_primary;
+DROP INDEX IF EXISTS idx_product_images_product_position;
+DROP TABLE IF EXISTS product_images;
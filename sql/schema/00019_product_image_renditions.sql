@@ -0,0 +1,12 @@
+-- +goose Up
+-- Resized copies generated when an image is uploaded. Images uploaded before renditions existed have none.
+ALTER TABLE product_images
+    ADD COLUMN thumbnail_url TEXT,
+    ADD COLUMN medium_url TEXT,
+    ADD COLUMN large_url TEXT;
+
+-- +goose Down
+ALTER TABLE product_images
+    DROP COLUMN IF EXISTS large_url,
+    DROP COLUMN IF EXISTS medium_url,
+    DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Variants are deactivated rather than deleted, so order items always keep their variant. The reference no longer
+-- nulls out on delete; NO ACTION (unlike RESTRICT) still lets a product delete cascade to its variants and order items.
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_variant_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants(id);
+
+-- +goose Down
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_variant_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE SET NULL;
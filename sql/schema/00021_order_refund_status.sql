@@ -0,0 +1,39 @@
+-- +goose Up
+-- Refunds are tracked apart from fulfilment, so a refunded order keeps its shipped or delivered status.
+ALTER TABLE orders ADD COLUMN refund_status TEXT NOT NULL DEFAULT 'none'
+    CHECK (refund_status IN ('none', 'partially_refunded', 'refunded'));
+
+-- Partially refunded orders go back to the last fulfilment status they reached
+UPDATE orders
+SET refund_status = 'partially_refunded',
+    status = COALESCE((
+        SELECT h.to_status FROM order_status_history h
+        WHERE h.order_id = orders.id AND h.to_status IN ('paid', 'shipped', 'delivered')
+        ORDER BY h.created_at DESC
+        LIMIT 1
+    ), 'paid')
+WHERE status = 'partially_refunded';
+
+UPDATE orders
+SET refund_status = 'refunded'
+WHERE EXISTS (
+    SELECT 1 FROM payments
+    WHERE payments.order_id = orders.id AND payments.status = 'refunded'
+);
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (
+    status IN ('pending', 'paid', 'shipped', 'delivered',
+        'cancelled', 'refunded'));
+
+-- +goose Down
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (
+    status IN ('pending', 'paid', 'shipped', 'delivered',
+        'cancelled', 'partially_refunded', 'refunded'));
+
+UPDATE orders
+SET status = refund_status
+WHERE refund_status <> 'none' AND status <> 'cancelled';
+
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_status;
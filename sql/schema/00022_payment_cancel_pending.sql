@@ -0,0 +1,13 @@
+-- +goose Up
+-- A released order's payments wait in cancel_pending until their intents are voided with the provider,
+-- which happens after the release commits.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'succeeded', 'failed', 'cancel_pending', 'cancelled', 'partially_refunded', 'refunded'));
+
+-- +goose Down
+UPDATE payments SET status = 'cancelled' WHERE status = 'cancel_pending';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'succeeded', 'failed', 'cancelled', 'partially_refunded', 'refunded'));
@@ -0,0 +1,46 @@
+// Package utils provides utility functions and helpers used throughout the ecom-backend project.
+package utils
+
+import "time"
+
+// clock.go: Provides a clock abstraction so time-driven background jobs can be controlled in tests.
+
+// Clock abstracts the current time and ticker creation.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker abstracts a time.Ticker so fake clocks can deliver ticks on demand.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock implements Clock using the standard library time package.
+type RealClock struct{}
+
+// Now returns the current local time.
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a Ticker backed by time.NewTicker.
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+// realTicker adapts *time.Ticker to the Ticker interface.
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// C returns the channel on which ticks are delivered.
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// Stop turns off the ticker.
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,32 @@
+// Package utils provides utility functions and helpers used throughout the ecom-backend project.
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// clock_test.go: Tests for the real clock implementation.
+
+// TestRealClock_Now tests that RealClock returns the current time.
+func TestRealClock_Now(t *testing.T) {
+	before := time.Now()
+	now := RealClock{}.Now()
+	after := time.Now()
+
+	if now.Before(before) || now.After(after) {
+		t.Errorf("expected now between %v and %v, got %v", before, after, now)
+	}
+}
+
+// TestRealClock_NewTicker tests that tickers from RealClock deliver ticks and can be stopped.
+func TestRealClock_NewTicker(t *testing.T) {
+	ticker := RealClock{}.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatal("expected a tick within one second")
+	}
+}
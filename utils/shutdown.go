@@ -10,7 +10,7 @@ import (
 	"time"
 )
 
-// shutdown.go: Implements graceful server shutdown and MongoDB disconnect logic on OS signals.
+// shutdown.go: Implements graceful server shutdown, background worker stop, and MongoDB disconnect logic on OS signals.
 
 // ServerWithShutdown is an interface for servers that support graceful shutdown via a Shutdown method.
 type ServerWithShutdown interface {
@@ -22,9 +22,14 @@ type APIConfigWithDisconnect interface {
 	DisconnectMongoDB(ctx context.Context) error
 }
 
+// BackgroundWorker is an interface for background jobs that can be stopped via a Stop method.
+type BackgroundWorker interface {
+	Stop(ctx context.Context) error
+}
+
 // GracefulShutdown handles OS signals to gracefully shut down the server and disconnect from MongoDB with a timeout.
-// It listens for interrupt or termination signals, shuts down the server, and disconnects MongoDB, logging the results.
-func GracefulShutdown(srv ServerWithShutdown, cfg APIConfigWithDisconnect, timeout time.Duration) {
+// It listens for interrupt or termination signals, shuts down the server, stops the background workers, and disconnects MongoDB, logging the results.
+func GracefulShutdown(srv ServerWithShutdown, cfg APIConfigWithDisconnect, timeout time.Duration, workers ...BackgroundWorker) {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
@@ -39,6 +44,14 @@ func GracefulShutdown(srv ServerWithShutdown, cfg APIConfigWithDisconnect, timeo
 		log.Println("Server shutdown gracefully.")
 	}
 
+	for _, worker := range workers {
+		if err := worker.Stop(ctxTimeout); err != nil {
+			log.Printf("Error stopping background worker: %v", err)
+		} else {
+			log.Println("Background worker stopped.")
+		}
+	}
+
 	if err := cfg.DisconnectMongoDB(context.Background()); err != nil {
 		log.Printf("Error disconnecting MongoDB: %v", err)
 	} else {
@@ -37,6 +37,18 @@ func (m *mockConfig) DisconnectMongoDB(_ context.Context) error {
 	return m.disconnectErr
 }
 
+// mockWorker is a mock implementation of a background worker for shutdown tests.
+type mockWorker struct {
+	stopCalled bool
+	stopErr    error
+}
+
+// Stop simulates stopping the worker and records if it was called.
+func (m *mockWorker) Stop(_ context.Context) error {
+	m.stopCalled = true
+	return m.stopErr
+}
+
 // TestGracefulShutdown_Success tests GracefulShutdown for a successful shutdown sequence.
 func TestGracefulShutdown_Success(t *testing.T) {
 	// Redirect log output
@@ -101,6 +113,39 @@ func TestGracefulShutdown_Errors(t *testing.T) {
 	}
 }
 
+// TestGracefulShutdown_StopsWorkers tests that GracefulShutdown stops every background worker and logs failures.
+func TestGracefulShutdown_StopsWorkers(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	srv := &mockServer{}
+	cfg := &mockConfig{}
+	okWorker := &mockWorker{}
+	failingWorker := &mockWorker{stopErr: context.DeadlineExceeded}
+
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p, _ := os.FindProcess(os.Getpid())
+		if err := p.Signal(syscall.SIGTERM); err != nil {
+			t.Errorf("p.Signal failed: %v", err)
+		}
+		close(done)
+	}()
+
+	GracefulShutdown(srv, cfg, 100*time.Millisecond, okWorker, failingWorker)
+	<-done
+
+	if !okWorker.stopCalled || !failingWorker.stopCalled {
+		t.Error("expected Stop to be called on every worker")
+	}
+	out := buf.String()
+	if !containsAll(out, "Background worker stopped.", "Error stopping background worker", "MongoDB disconnected.") {
+		t.Errorf("unexpected log output: %q", out)
+	}
+}
+
 // containsAll checks if all substrings are present in the given string.
 func containsAll(s string, subs ...string) bool {
 	for _, sub := range subs {